@@ -0,0 +1,148 @@
+// Package client calls the tools of a GoCreate server from Go. Every tool has
+// a method taking the argument struct its handler is declared with, so a call
+// is checked by the compiler rather than by the server, and tools answering
+// with JSON have it decoded into the type the server encoded.
+//
+//	c, err := client.Start(ctx, "gocreate", nil, nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//	entries, err := c.ListDirectory(ctx, filesystem.ListDirectoryArgs{Path: "."})
+//
+// Tools answering with text return it as is. Call reaches any tool by name,
+// including tools proxied from upstream servers.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gocreate/tools/config"
+	"gocreate/tools/proxy"
+)
+
+// Client is a session with one GoCreate server. Its methods may be called
+// from several goroutines.
+type Client struct {
+	session *proxy.Session
+}
+
+// Start launches the GoCreate server at command with args and talks to it
+// over stdio. The server's standard error is copied to stderr, or discarded
+// when stderr is nil. The server runs until Close.
+func Start(ctx context.Context, command string, args []string, stderr io.Writer) (*Client, error) {
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	session, err := proxy.Dial(ctx, config.UpstreamServer{Command: command, Args: args}, nopCloser{stderr})
+	if err != nil {
+		return nil, err
+	}
+	return &Client{session: session}, nil
+}
+
+// Connect talks to a GoCreate server served over streamable HTTP at url,
+// sending headers, such as Authorization, with every request.
+func Connect(ctx context.Context, url string, headers map[string]string) (*Client, error) {
+	session, err := proxy.Dial(ctx, config.UpstreamServer{URL: url, Headers: headers}, nopCloser{io.Discard})
+	if err != nil {
+		return nil, err
+	}
+	return &Client{session: session}, nil
+}
+
+// Close ends the session, stopping a server launched by Start.
+func (c *Client) Close() error {
+	return c.session.Close()
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// Result is a tool's answer as sent by the server.
+type Result struct {
+	Text    string // the text content items, one per line
+	IsError bool   // the server reports the call as failed
+}
+
+// ToolError is returned when the server reports a call as failed, such as for
+// arguments that do not validate or a file that cannot be read.
+type ToolError struct {
+	Tool    string
+	Message string
+}
+
+func (e *ToolError) Error() string { return e.Tool + ": " + e.Message }
+
+// MessageError is returned by the methods of tools answering with JSON when
+// the tool answered with a message instead, such as a path outside the
+// allowed directories or a call denied by a policy.
+type MessageError struct {
+	Tool string
+	Text string
+}
+
+func (e *MessageError) Error() string { return e.Tool + ": " + e.Text }
+
+// Call calls the tool name with args, which are sent as their JSON encoding.
+// A failed call is returned as a Result with IsError set, not as an error;
+// the error reports calls that did not reach the tool.
+func (c *Client) Call(ctx context.Context, name string, args interface{}) (*Result, error) {
+	raw, err := c.session.CallTool(ctx, name, args)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("invalid %s result: %w", name, err)
+	}
+	var texts []string
+	for _, item := range result.Content {
+		if item.Type == "text" {
+			texts = append(texts, item.Text)
+		}
+	}
+	return &Result{Text: strings.Join(texts, "\n"), IsError: result.IsError}, nil
+}
+
+// text calls a tool answering with text and returns it, or a ToolError.
+func (c *Client) text(ctx context.Context, name string, args interface{}) (string, error) {
+	result, err := c.Call(ctx, name, args)
+	if err != nil {
+		return "", err
+	}
+	if result.IsError {
+		return "", &ToolError{Tool: name, Message: result.Text}
+	}
+	return result.Text, nil
+}
+
+// decode calls a tool answering with JSON and decodes the value into v. It
+// returns the text following the value, such as the diffs replace_in_files
+// prints after its summary.
+func (c *Client) decode(ctx context.Context, name string, args interface{}, v interface{}) (string, error) {
+	text, err := c.text(ctx, name, args)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(strings.NewReader(text))
+	if err := dec.Decode(v); err != nil {
+		return "", &MessageError{Tool: name, Text: text}
+	}
+	return strings.TrimSpace(text[dec.InputOffset():]), nil
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gocreate/tools/filesystem"
+	"gocreate/tools/search"
+)
+
+// startServer builds the gocreate binary into a temporary directory, where
+// its configuration file is created too, and starts a session with it.
+func startServer(t *testing.T) *Client {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds the server")
+	}
+	bin := filepath.Join(t.TempDir(), "gocreate")
+	build := exec.Command("go", "build", "-o", bin, "gocreate")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("Building the server failed: %v\n%s", err, out)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	c, err := Start(ctx, bin, nil, nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient(t *testing.T) {
+	c := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+
+	if _, err := c.WriteFile(ctx, filesystem.WriteFileArgs{Path: file, Content: "hello world\n"}); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	content, err := c.ReadFile(ctx, filesystem.ReadFileArgs{FilePath: file})
+	if err != nil || !strings.Contains(content, "hello world") {
+		t.Errorf("ReadFile = %q, %v", content, err)
+	}
+
+	entries, err := c.ListDirectory(ctx, filesystem.ListDirectoryArgs{Path: dir})
+	if err != nil || len(entries) != 1 || entries[0] != "[FILE] notes.txt" {
+		t.Errorf("ListDirectory = %q, %v", entries, err)
+	}
+	info, err := c.GetFileInfo(ctx, filesystem.GetFileInfoArgs{Path: file})
+	if err != nil || info.Name != "notes.txt" || info.Size != 12 || info.IsDir {
+		t.Errorf("GetFileInfo = %+v, %v", info, err)
+	}
+
+	// The summary is decoded and the diffs following it are kept
+	dryRun := true
+	rewrite, err := c.ReplaceInFiles(ctx, search.ReplaceInFilesArgs{Path: dir, Pattern: "world", Replacement: "there", DryRun: &dryRun})
+	if err != nil {
+		t.Fatalf("ReplaceInFiles failed: %v", err)
+	}
+	if !rewrite.Summary.DryRun || rewrite.Summary.TotalReplacements != 1 || !strings.Contains(rewrite.Diffs, "+hello there") {
+		t.Errorf("ReplaceInFiles = %+v", rewrite)
+	}
+
+	results, err := c.SearchCodeResults(ctx, search.SearchCodeArgs{Path: dir, Pattern: "world"})
+	if err != nil || len(results.Matches) != 1 || results.Matches[0].Column != 7 {
+		t.Errorf("SearchCodeResults = %+v, %v", results, err)
+	}
+
+	report, err := c.UsageReport(ctx)
+	if err != nil || report.Calls != 7 {
+		t.Errorf("UsageReport = %+v, %v; want 7 calls", report, err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	c := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	dir := t.TempDir()
+
+	_, err := c.ReadFile(ctx, filesystem.ReadFileArgs{FilePath: filepath.Join(dir, "missing.txt")})
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Tool != "read_file" || !strings.Contains(toolErr.Message, "no such file") {
+		t.Errorf("ReadFile of a missing file returned %v", err)
+	}
+
+	// merge_directory answers with a message instead of its report
+	_, err = c.MergeDirectory(ctx, filesystem.MergeDirectoryArgs{Source: dir, Destination: dir})
+	var msgErr *MessageError
+	if !errors.As(err, &msgErr) || msgErr.Tool != "merge_directory" {
+		t.Errorf("MergeDirectory into itself returned %v", err)
+	}
+
+	result, err := c.Call(ctx, "read_file", map[string]interface{}{})
+	if err != nil || !result.IsError || !strings.Contains(result.Text, "file_path") {
+		t.Errorf("Call without a required argument = %+v, %v", result, err)
+	}
+}
+
+func TestStartFails(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := Start(ctx, filepath.Join(t.TempDir(), "missing"), nil, nil); err == nil {
+		t.Error("Start of a missing binary succeeded")
+	}
+	if _, err := Start(ctx, os.DevNull, nil, nil); err == nil {
+		t.Error("Start of a file that is not a server succeeded")
+	}
+}
@@ -0,0 +1,474 @@
+package client
+
+import (
+	"context"
+
+	"gocreate/tools/config"
+	"gocreate/tools/edit"
+	"gocreate/tools/filesystem"
+	"gocreate/tools/gitignore"
+	"gocreate/tools/gomod"
+	"gocreate/tools/journal"
+	"gocreate/tools/notebook"
+	"gocreate/tools/process"
+	"gocreate/tools/release"
+	"gocreate/tools/search"
+	"gocreate/tools/sqlite"
+	"gocreate/tools/structured"
+	"gocreate/tools/terminal"
+	"gocreate/tools/usage"
+)
+
+// FileInfo is the result of get_file_info.
+type FileInfo struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	IsDir   bool   `json:"is_dir"`
+	Mode    string `json:"mode"`
+	ModTime string `json:"mod_time"`
+}
+
+// TerminalRequest is the result of execute_in_terminal: the command the
+// client is asked to run in its own terminal.
+type TerminalRequest struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+	Cwd     string `json:"cwd"`
+	Message string `json:"message"`
+}
+
+// Rewrite is the result of replace_in_files and rename_symbol.
+type Rewrite struct {
+	Summary search.ReplaceSummary
+	Diffs   string // the diffs following the summary, if any
+}
+
+// Configuration tools
+
+// GetConfig calls get_config.
+func (c *Client) GetConfig(ctx context.Context) (*config.ServerConfig, error) {
+	var cfg config.ServerConfig
+	if _, err := c.decode(ctx, "get_config", config.GetConfigArgs{}, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// SetConfigValue calls set_config_value.
+func (c *Client) SetConfigValue(ctx context.Context, args config.SetConfigValueArgs) (string, error) {
+	return c.text(ctx, "set_config_value", args)
+}
+
+// ValidateConfig calls validate_config.
+func (c *Client) ValidateConfig(ctx context.Context, args config.ValidateConfigArgs) (*config.ConfigValidationReport, error) {
+	var report config.ConfigValidationReport
+	if _, err := c.decode(ctx, "validate_config", args, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Filesystem tools
+
+// ReadFile calls read_file.
+func (c *Client) ReadFile(ctx context.Context, args filesystem.ReadFileArgs) (string, error) {
+	return c.text(ctx, "read_file", args)
+}
+
+// ReadMultipleFiles calls read_multiple_files and returns the content of each
+// file, or the error reading it, by path.
+func (c *Client) ReadMultipleFiles(ctx context.Context, args filesystem.ReadMultipleFilesArgs) (map[string]string, error) {
+	var contents map[string]string
+	if _, err := c.decode(ctx, "read_multiple_files", args, &contents); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// WriteFile calls write_file.
+func (c *Client) WriteFile(ctx context.Context, args filesystem.WriteFileArgs) (string, error) {
+	return c.text(ctx, "write_file", args)
+}
+
+// CreateDirectory calls create_directory.
+func (c *Client) CreateDirectory(ctx context.Context, args filesystem.CreateDirectoryArgs) (string, error) {
+	return c.text(ctx, "create_directory", args)
+}
+
+// ListDirectory calls list_directory and returns its entries, each prefixed
+// with [FILE] or [DIR].
+func (c *Client) ListDirectory(ctx context.Context, args filesystem.ListDirectoryArgs) ([]string, error) {
+	var entries []string
+	if _, err := c.decode(ctx, "list_directory", args, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// MoveFile calls move_file.
+func (c *Client) MoveFile(ctx context.Context, args filesystem.MoveFileArgs) (string, error) {
+	return c.text(ctx, "move_file", args)
+}
+
+// MergeDirectory calls merge_directory.
+func (c *Client) MergeDirectory(ctx context.Context, args filesystem.MergeDirectoryArgs) (*filesystem.MergeReport, error) {
+	var report filesystem.MergeReport
+	if _, err := c.decode(ctx, "merge_directory", args, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// CreateSymlink calls create_symlink.
+func (c *Client) CreateSymlink(ctx context.Context, args filesystem.CreateSymlinkArgs) (string, error) {
+	return c.text(ctx, "create_symlink", args)
+}
+
+// CreateHardlink calls create_hardlink.
+func (c *Client) CreateHardlink(ctx context.Context, args filesystem.CreateHardlinkArgs) (string, error) {
+	return c.text(ctx, "create_hardlink", args)
+}
+
+// SearchFiles calls search_files and returns the paths of the matching files.
+func (c *Client) SearchFiles(ctx context.Context, args filesystem.SearchFilesArgs) ([]string, error) {
+	var paths []string
+	if _, err := c.decode(ctx, "search_files", args, &paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// GetFileInfo calls get_file_info.
+func (c *Client) GetFileInfo(ctx context.Context, args filesystem.GetFileInfoArgs) (*FileInfo, error) {
+	var info FileInfo
+	if _, err := c.decode(ctx, "get_file_info", args, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Code search tools
+
+// SearchCode calls search_code.
+func (c *Client) SearchCode(ctx context.Context, args search.SearchCodeArgs) (string, error) {
+	return c.text(ctx, "search_code", args)
+}
+
+// SearchCodeResults calls search_code with format json and returns the
+// matches and statistics it reports.
+func (c *Client) SearchCodeResults(ctx context.Context, args search.SearchCodeArgs) (*search.SearchResults, error) {
+	format := "json"
+	args.Format = &format
+	var results search.SearchResults
+	if _, err := c.decode(ctx, "search_code", args, &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// SearchSymbols calls search_symbols.
+func (c *Client) SearchSymbols(ctx context.Context, args search.SearchSymbolsArgs) (string, error) {
+	return c.text(ctx, "search_symbols", args)
+}
+
+// ListTodos calls list_todos.
+func (c *Client) ListTodos(ctx context.Context, args search.ListTodosArgs) (string, error) {
+	return c.text(ctx, "list_todos", args)
+}
+
+// SearchDiagnostics calls search_diagnostics and returns the statistics of
+// the search with its diagnostics.
+func (c *Client) SearchDiagnostics(ctx context.Context, args search.SearchCodeArgs) (*search.SearchStats, error) {
+	var stats search.SearchStats
+	if _, err := c.decode(ctx, "search_diagnostics", args, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ReplaceInFiles calls replace_in_files.
+func (c *Client) ReplaceInFiles(ctx context.Context, args search.ReplaceInFilesArgs) (*Rewrite, error) {
+	return c.rewrite(ctx, "replace_in_files", args)
+}
+
+// PreviewReplace calls preview_replace.
+func (c *Client) PreviewReplace(ctx context.Context, args search.PreviewReplaceArgs) (string, error) {
+	return c.text(ctx, "preview_replace", args)
+}
+
+// RenameSymbol calls rename_symbol.
+func (c *Client) RenameSymbol(ctx context.Context, args search.RenameSymbolArgs) (*Rewrite, error) {
+	return c.rewrite(ctx, "rename_symbol", args)
+}
+
+func (c *Client) rewrite(ctx context.Context, name string, args interface{}) (*Rewrite, error) {
+	var r Rewrite
+	diffs, err := c.decode(ctx, name, args, &r.Summary)
+	if err != nil {
+		return nil, err
+	}
+	r.Diffs = diffs
+	return &r, nil
+}
+
+// Text editing tools
+
+// EditBlock calls edit_block.
+func (c *Client) EditBlock(ctx context.Context, args edit.EditBlockArgs) (string, error) {
+	return c.text(ctx, "edit_block", args)
+}
+
+// PreciseEdit calls precise_edit.
+func (c *Client) PreciseEdit(ctx context.Context, args edit.PreciseEditArgs) (string, error) {
+	return c.text(ctx, "precise_edit", args)
+}
+
+// InsertAtLine calls insert_at_line.
+func (c *Client) InsertAtLine(ctx context.Context, args edit.InsertAtLineArgs) (string, error) {
+	return c.text(ctx, "insert_at_line", args)
+}
+
+// DeleteLines calls delete_lines.
+func (c *Client) DeleteLines(ctx context.Context, args edit.DeleteLinesArgs) (string, error) {
+	return c.text(ctx, "delete_lines", args)
+}
+
+// EditColumns calls edit_columns.
+func (c *Client) EditColumns(ctx context.Context, args edit.EditColumnsArgs) (string, error) {
+	return c.text(ctx, "edit_columns", args)
+}
+
+// TransformLines calls transform_lines.
+func (c *Client) TransformLines(ctx context.Context, args edit.TransformLinesArgs) (string, error) {
+	return c.text(ctx, "transform_lines", args)
+}
+
+// AdjustIndentation calls adjust_indentation.
+func (c *Client) AdjustIndentation(ctx context.Context, args edit.AdjustIndentationArgs) (string, error) {
+	return c.text(ctx, "adjust_indentation", args)
+}
+
+// InsertTemplate calls insert_template.
+func (c *Client) InsertTemplate(ctx context.Context, args edit.InsertTemplateArgs) (string, error) {
+	return c.text(ctx, "insert_template", args)
+}
+
+// MergeFiles calls merge_files.
+func (c *Client) MergeFiles(ctx context.Context, args edit.MergeFilesArgs) (string, error) {
+	return c.text(ctx, "merge_files", args)
+}
+
+// ApplyEdits calls apply_edits.
+func (c *Client) ApplyEdits(ctx context.Context, args edit.ApplyEditsArgs) (string, error) {
+	return c.text(ctx, "apply_edits", args)
+}
+
+// ApplyPatchInteractive calls apply_patch_interactive.
+func (c *Client) ApplyPatchInteractive(ctx context.Context, args edit.ApplyPatchInteractiveArgs) (string, error) {
+	return c.text(ctx, "apply_patch_interactive", args)
+}
+
+// ApplyHunks calls apply_hunks.
+func (c *Client) ApplyHunks(ctx context.Context, args edit.ApplyHunksArgs) (string, error) {
+	return c.text(ctx, "apply_hunks", args)
+}
+
+// ConvertLineEndings calls convert_line_endings.
+func (c *Client) ConvertLineEndings(ctx context.Context, args edit.ConvertLineEndingsArgs) (string, error) {
+	return c.text(ctx, "convert_line_endings", args)
+}
+
+// Edit history tools
+
+// ListEdits calls list_edits.
+func (c *Client) ListEdits(ctx context.Context, args journal.ListEditsArgs) ([]journal.EditInfo, error) {
+	var edits []journal.EditInfo
+	if _, err := c.decode(ctx, "list_edits", args, &edits); err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// UndoEdit calls undo_edit.
+func (c *Client) UndoEdit(ctx context.Context, args journal.UndoEditArgs) (string, error) {
+	return c.text(ctx, "undo_edit", args)
+}
+
+// Structured data tools
+
+// SemanticDiff calls semantic_diff.
+func (c *Client) SemanticDiff(ctx context.Context, args structured.SemanticDiffArgs) (string, error) {
+	return c.text(ctx, "semantic_diff", args)
+}
+
+// JSONEdit calls json_edit.
+func (c *Client) JSONEdit(ctx context.Context, args structured.StructuredEditArgs) (string, error) {
+	return c.text(ctx, "json_edit", args)
+}
+
+// YAMLEdit calls yaml_edit.
+func (c *Client) YAMLEdit(ctx context.Context, args structured.StructuredEditArgs) (string, error) {
+	return c.text(ctx, "yaml_edit", args)
+}
+
+// TOMLEdit calls toml_edit.
+func (c *Client) TOMLEdit(ctx context.Context, args structured.StructuredEditArgs) (string, error) {
+	return c.text(ctx, "toml_edit", args)
+}
+
+// NotebookRead calls notebook_read.
+func (c *Client) NotebookRead(ctx context.Context, args notebook.NotebookReadArgs) (string, error) {
+	return c.text(ctx, "notebook_read", args)
+}
+
+// NotebookEditCell calls notebook_edit_cell.
+func (c *Client) NotebookEditCell(ctx context.Context, args notebook.NotebookEditCellArgs) (string, error) {
+	return c.text(ctx, "notebook_edit_cell", args)
+}
+
+// InspectSQLite calls inspect_sqlite.
+func (c *Client) InspectSQLite(ctx context.Context, args sqlite.InspectSQLiteArgs) (string, error) {
+	return c.text(ctx, "inspect_sqlite", args)
+}
+
+// Terminal tools
+
+// ExecuteCommand calls execute_command.
+func (c *Client) ExecuteCommand(ctx context.Context, args terminal.ExecuteCommandArgs) (string, error) {
+	return c.text(ctx, "execute_command", args)
+}
+
+// ValidateCommand calls validate_command.
+func (c *Client) ValidateCommand(ctx context.Context, args terminal.ValidateCommandArgs) (*terminal.CommandReport, error) {
+	var report terminal.CommandReport
+	if _, err := c.decode(ctx, "validate_command", args, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ReadOutput calls read_output.
+func (c *Client) ReadOutput(ctx context.Context, args terminal.ReadOutputArgs) (*terminal.SessionOutput, error) {
+	var output terminal.SessionOutput
+	if _, err := c.decode(ctx, "read_output", args, &output); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// WaitForCompletion calls wait_for_completion.
+func (c *Client) WaitForCompletion(ctx context.Context, args terminal.WaitForCompletionArgs) (*terminal.SessionOutput, error) {
+	var output terminal.SessionOutput
+	if _, err := c.decode(ctx, "wait_for_completion", args, &output); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// GetSessionResult calls get_session_result.
+func (c *Client) GetSessionResult(ctx context.Context, args terminal.GetSessionResultArgs) (*terminal.SessionResult, error) {
+	var result terminal.SessionResult
+	if _, err := c.decode(ctx, "get_session_result", args, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SendInput calls send_input.
+func (c *Client) SendInput(ctx context.Context, args terminal.SendInputArgs) (string, error) {
+	return c.text(ctx, "send_input", args)
+}
+
+// ResizeTerminal calls resize_terminal.
+func (c *Client) ResizeTerminal(ctx context.Context, args terminal.ResizeTerminalArgs) (string, error) {
+	return c.text(ctx, "resize_terminal", args)
+}
+
+// ForceTerminate calls force_terminate.
+func (c *Client) ForceTerminate(ctx context.Context, args terminal.ForceTerminateArgs) (string, error) {
+	return c.text(ctx, "force_terminate", args)
+}
+
+// ListSessions calls list_sessions.
+func (c *Client) ListSessions(ctx context.Context, args terminal.ListSessionsArgs) ([]terminal.ActiveSessionInfo, error) {
+	var sessions []terminal.ActiveSessionInfo
+	if _, err := c.decode(ctx, "list_sessions", args, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RunMacro calls run_macro.
+func (c *Client) RunMacro(ctx context.Context, args terminal.RunMacroArgs) (string, error) {
+	return c.text(ctx, "run_macro", args)
+}
+
+// ExecuteInTerminal calls execute_in_terminal.
+func (c *Client) ExecuteInTerminal(ctx context.Context, args terminal.ExecuteInTerminalArgs) (*TerminalRequest, error) {
+	var req TerminalRequest
+	if _, err := c.decode(ctx, "execute_in_terminal", args, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Process management tools
+
+// ListProcesses calls list_processes.
+func (c *Client) ListProcesses(ctx context.Context, args process.ListProcessesArgs) ([]process.ProcessInfo, error) {
+	var processes []process.ProcessInfo
+	if _, err := c.decode(ctx, "list_processes", args, &processes); err != nil {
+		return nil, err
+	}
+	return processes, nil
+}
+
+// KillProcess calls kill_process.
+func (c *Client) KillProcess(ctx context.Context, args process.KillProcessArgs) (string, error) {
+	return c.text(ctx, "kill_process", args)
+}
+
+// Release tools
+
+// BumpVersion calls bump_version.
+func (c *Client) BumpVersion(ctx context.Context, args release.BumpVersionArgs) (string, error) {
+	return c.text(ctx, "bump_version", args)
+}
+
+// BuildRelease calls build_release.
+func (c *Client) BuildRelease(ctx context.Context, args release.BuildReleaseArgs) (*release.ReleaseManifest, error) {
+	var manifest release.ReleaseManifest
+	if _, err := c.decode(ctx, "build_release", args, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// Go module tools
+
+// GoModWhy calls go_mod_why.
+func (c *Client) GoModWhy(ctx context.Context, args gomod.GoModWhyArgs) (*gomod.ModWhyReport, error) {
+	var report gomod.ModWhyReport
+	if _, err := c.decode(ctx, "go_mod_why", args, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Repository tools
+
+// SuggestIgnores calls suggest_ignores.
+func (c *Client) SuggestIgnores(ctx context.Context, args gitignore.SuggestIgnoresArgs) (string, error) {
+	return c.text(ctx, "suggest_ignores", args)
+}
+
+// Session tools
+
+// UsageReport calls usage_report.
+func (c *Client) UsageReport(ctx context.Context) (*usage.Report, error) {
+	var report usage.Report
+	if _, err := c.decode(ctx, usage.ReportTool, usage.UsageReportArgs{}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
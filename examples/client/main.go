@@ -0,0 +1,109 @@
+// Command client is an example of driving a GoCreate server from Go with the
+// gocreate/client package. It starts the server binary, runs one tool and
+// prints the result:
+//
+//	client -server ./gocreate ls .
+//	client -server ./gocreate grep ./tools "func Handle" '*.go'
+//	client -server ./gocreate call read_file '{"file_path": "go.mod"}'
+//	client -server ./gocreate usage
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"gocreate/client"
+	"gocreate/tools/filesystem"
+	"gocreate/tools/search"
+)
+
+func main() {
+	serverPath := flag.String("server", "gocreate", "the GoCreate server binary to start")
+	timeout := flag.Duration("timeout", time.Minute, "how long the command may take")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: client [-server path] [-timeout d] command args...")
+		fmt.Fprintln(os.Stderr, "commands:")
+		fmt.Fprintln(os.Stderr, "  ls dir                      list a directory")
+		fmt.Fprintln(os.Stderr, "  stat path                   show a file's size, mode and modification time")
+		fmt.Fprintln(os.Stderr, "  grep dir pattern [glob...]  search code, optionally in files matching the globs")
+		fmt.Fprintln(os.Stderr, "  call tool [json]            call any tool with JSON arguments")
+		fmt.Fprintln(os.Stderr, "  usage                       print the session's usage report")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	c, err := client.Start(ctx, *serverPath, nil, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not start the server:", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	if err := run(ctx, c, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		c.Close()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, c *client.Client, command string, args []string) error {
+	switch {
+	case command == "ls" && len(args) == 1:
+		entries, err := c.ListDirectory(ctx, filesystem.ListDirectoryArgs{Path: args[0]})
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			fmt.Println(entry)
+		}
+	case command == "stat" && len(args) == 1:
+		info, err := c.GetFileInfo(ctx, filesystem.GetFileInfoArgs{Path: args[0]})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %d %s %s\n", info.Mode, info.Size, info.ModTime, info.Name)
+	case command == "grep" && len(args) >= 2:
+		matches, err := c.SearchCode(ctx, search.SearchCodeArgs{Path: args[0], Pattern: args[1], FilePatterns: args[2:]})
+		if err != nil {
+			return err
+		}
+		fmt.Println(matches)
+	case command == "call" && (len(args) == 1 || len(args) == 2):
+		toolArgs := map[string]interface{}{}
+		if len(args) == 2 {
+			if err := json.Unmarshal([]byte(args[1]), &toolArgs); err != nil {
+				return fmt.Errorf("invalid JSON arguments: %w", err)
+			}
+		}
+		result, err := c.Call(ctx, args[0], toolArgs)
+		if err != nil {
+			return err
+		}
+		if result.IsError {
+			return fmt.Errorf("%s failed: %s", args[0], result.Text)
+		}
+		fmt.Println(result.Text)
+	case command == "usage" && len(args) == 0:
+		report, err := c.UsageReport(ctx)
+		if err != nil {
+			return err
+		}
+		for _, u := range report.Tools {
+			fmt.Printf("%-24s %4d calls %8d bytes in %8d bytes out\n", u.Tool, u.Calls, u.BytesIn, u.BytesOut)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
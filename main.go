@@ -1,100 +1,321 @@
 package main
 
 import (
-	"log"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"gocreate/tools/config"
 	"gocreate/tools/edit"
 	"gocreate/tools/filesystem"
+	"gocreate/tools/gitignore"
+	"gocreate/tools/gomod"
+	"gocreate/tools/health"
+	"gocreate/tools/journal"
+	"gocreate/tools/notebook"
+	"gocreate/tools/policy"
 	"gocreate/tools/process"
+	"gocreate/tools/proxy"
+	"gocreate/tools/release"
 	"gocreate/tools/search"
+	"gocreate/tools/sqlite"
+	"gocreate/tools/stdio"
+	"gocreate/tools/structured"
 	"gocreate/tools/terminal"
+	"gocreate/tools/usage"
+	"gocreate/tools/webhook"
 
 	"github.com/localrivet/gomcp/server"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
-	// Create a logger
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	// A server that fails shuts down as one told to stop would, then exits
+	// with an error once the deferred cleanup has run
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// Create a logger. Stdout carries the JSON-RPC stream, so logs go to stderr,
+	// or nowhere when stderr was redirected into stdout
+	var logOutput io.Writer = os.Stderr
+	if stdio.SharesStdout(os.Stderr) {
+		logOutput = io.Discard
+	}
+	logger := slog.New(slog.NewTextHandler(logOutput, &slog.HandlerOptions{
 		Level: slog.LevelError,
 	}))
 
+	// Health, readiness and build-info endpoints for orchestrators
+	checker := health.New(version, logger)
+	if cfg, err := config.GetCurrentConfig(&server.Context{Logger: logger}); err == nil && cfg.HealthAddress != nil && *cfg.HealthAddress != "" {
+		if _, err := health.Serve(*cfg.HealthAddress, checker); err != nil {
+			logger.Error("Could not start health endpoints", "address", *cfg.HealthAddress, "error", err)
+		}
+	}
+
+	// The client closing stdin, as it does when it disconnects, stops the
+	// server. The transport must read the watched stdin, so it comes after
+	stdinEnded, err := stdio.WatchStdin()
+	if err != nil {
+		logger.Error("Could not watch stdin", "error", err)
+	}
+
 	// Create a new server
 	s := server.NewServer("GoCreate",
 		server.WithLogger(logger),
 	).AsStdio()
 
+	// The transport holds the real stdout; anything else writing there is
+	// intercepted and logged
+	if restore, err := stdio.Guard(logger); err != nil {
+		logger.Error("Could not guard stdout", "error", err)
+	} else {
+		defer restore()
+	}
+
 	// Register tools using the API
 	// Configuration tools
-	s.Tool("get_config", "Get the complete server configuration as JSON.",
+	tool(s, "get_config", "Get the complete server configuration as JSON.",
 		config.HandleGetConfig)
 
-	s.Tool("set_config_value", "Set a specific configuration value by key.",
+	tool(s, "set_config_value", "Set a specific configuration value by key.",
 		config.HandleSetConfigValue)
 
+	tool(s, "validate_config", "Check the configuration file for unknown keys, contradictions and missing paths.",
+		config.HandleValidateConfig)
+
 	// Filesystem tools
-	s.Tool("read_file", "Read the contents of a file. Supports optional start_line and end_line parameters for paging.",
+	tool(s, "read_file", "Read the contents of a file. Supports optional start_line and end_line parameters for paging.",
 		filesystem.HandleReadFile)
 
-	s.Tool("read_multiple_files", "Read the contents of multiple files simultaneously.",
+	tool(s, "read_multiple_files", "Read the contents of multiple files simultaneously.",
 		filesystem.HandleReadMultipleFiles)
 
-	s.Tool("write_file", "Completely replace file contents.",
+	tool(s, "write_file", "Completely replace file contents.",
 		filesystem.HandleWriteFile)
 
-	s.Tool("create_directory", "Create a new directory or ensure a directory exists.",
+	tool(s, "create_directory", "Create a new directory or ensure a directory exists.",
 		filesystem.HandleCreateDirectory)
 
-	s.Tool("list_directory", "Get a detailed listing of all files and directories in a specified path.",
+	tool(s, "list_directory", "Get a detailed listing of all files and directories in a specified path.",
 		filesystem.HandleListDirectory)
 
-	s.Tool("move_file", "Move or rename files and directories.",
+	tool(s, "move_file", "Move or rename files and directories.",
 		filesystem.HandleMoveFile)
 
-	s.Tool("search_files", "Finds files by name using a case-insensitive substring matching.",
+	tool(s, "merge_directory", "Merge a directory into an existing one, resolving files present in both with a skip, overwrite or rename policy.",
+		filesystem.HandleMergeDirectory)
+
+	tool(s, "create_symlink", "Create a symbolic link inside the allowed directories (requires allowLinkCreation).",
+		filesystem.HandleCreateSymlink)
+
+	tool(s, "create_hardlink", "Create a hard link to a regular file inside the allowed directories (requires allowLinkCreation).",
+		filesystem.HandleCreateHardlink)
+
+	tool(s, "search_files", "Finds files or directories by name using a case-insensitive substring matching, optionally filtered by modification time, size, extension and type.",
 		filesystem.HandleSearchFiles)
 
-	s.Tool("get_file_info", "Retrieve detailed metadata about a file or directory.",
+	tool(s, "get_file_info", "Retrieve detailed metadata about a file or directory.",
 		filesystem.HandleGetFileInfo)
 
-	s.Tool("search_code", "Search for text/code patterns within file contents using pure Go implementation.",
+	tool(s, "search_code", "Search for text/code patterns within file contents, using ripgrep when it is installed and the built-in Go engine otherwise.",
 		search.HandleSearchCode)
 
-	s.Tool("edit_block", "Apply surgical text replacements to files.",
+	tool(s, "search_symbols", "Find Go function, method, type, const and var definitions by name, parsed with go/parser, with their file, line and signature.",
+		search.HandleSearchSymbols)
+
+	tool(s, "list_todos", "List TODO, FIXME, HACK and XXX comments, or other markers, grouped by file, with their author and date from git blame when available.",
+		search.HandleListTodos)
+
+	tool(s, "search_diagnostics", "Run a search_code search on the built-in engine and report where it spent its time: files and directories skipped and why, the slowest directories and what each worker did.",
+		search.HandleSearchDiagnostics)
+
+	tool(s, "replace_in_files", "Search and replace a literal or regex pattern across files, with optional dry-run diff output.",
+		search.HandleReplaceInFiles)
+
+	tool(s, "preview_replace", "Preview a search and replace across files: each matched line with what it would become, without writing anything.",
+		search.HandlePreviewReplace)
+
+	tool(s, "rename_symbol", "Rename an identifier across files: Go files are tokenized so substrings, strings and comments are left alone.",
+		search.HandleRenameSymbol)
+
+	tool(s, "edit_block", "Apply surgical text replacements to files.",
 		edit.HandleEditBlock)
 
-	s.Tool("precise_edit", "Precisely edit file content based on start and end line numbers.",
+	tool(s, "precise_edit", "Precisely edit file content based on start and end line numbers.",
 		edit.HandlePreciseEdit)
 
+	tool(s, "insert_at_line", "Insert content before a 1-indexed line; use the line count + 1 to append.",
+		edit.HandleInsertAtLine)
+
+	tool(s, "delete_lines", "Delete an inclusive 1-indexed range of lines from a file.",
+		edit.HandleDeleteLines)
+
+	tool(s, "edit_columns", "Insert, delete or replace a rectangular block of columns across a range of lines, like visual-block editing: prefix many lines, align tables or edit fixed-width data.",
+		edit.HandleEditColumns)
+
+	tool(s, "transform_lines", "Sort, deduplicate or reverse the lines of a file or a 1-indexed line range, without sending the lines through the model.",
+		edit.HandleTransformLines)
+
+	tool(s, "adjust_indentation", "Convert leading indentation between tabs and spaces, or shift a line range left or right by whole levels while keeping relative indentation.",
+		edit.HandleAdjustIndentation)
+
+	tool(s, "insert_template", "Expand a named template from the templates directory with variables and insert it at a line or write it as a new file.",
+		edit.HandleInsertTemplate)
+
+	tool(s, "merge_files", "Three-way merge of base, ours and theirs versions of a file; overlapping changes get git-style conflict markers.",
+		edit.HandleMergeFiles)
+
+	tool(s, "apply_edits", "Apply text replacements across several files atomically: all edits succeed or none are written.",
+		edit.HandleApplyEdits)
+
+	tool(s, "apply_patch_interactive", "Split a unified diff into numbered hunks for review without changing any file; apply the chosen ones with apply_hunks.",
+		edit.HandleApplyPatchInteractive)
+
+	tool(s, "apply_hunks", "Apply the selected hunks of a patch split by apply_patch_interactive, to all affected files or none.",
+		edit.HandleApplyHunks)
+
+	tool(s, "convert_line_endings", "Convert every line ending in a file to LF or CRLF.",
+		edit.HandleConvertLineEndings)
+
+	// Edit history tools
+	tool(s, "list_edits", "List recorded file edits that can be reverted with undo_edit.",
+		journal.HandleListEdits)
+
+	tool(s, "undo_edit", "Revert the last N recorded edits to a file.",
+		journal.HandleUndoEdit)
+
+	// Structured data tools
+	tool(s, "semantic_diff", "Compare two JSON or YAML files structurally, reporting added, removed, changed and moved values by path.",
+		structured.HandleSemanticDiff)
+
+	tool(s, "json_edit", "Set, delete or append a value in a JSON file by JSON Pointer or dot path, keeping the file's formatting.",
+		structured.HandleJSONEdit)
+
+	tool(s, "yaml_edit", "Set, delete or append a value in a YAML file by JSON Pointer or dot path, keeping comments where possible.",
+		structured.HandleYAMLEdit)
+
+	tool(s, "toml_edit", "Set, delete or append a value in a TOML file by JSON Pointer or dot path, rewriting only the affected line or table.",
+		structured.HandleTOMLEdit)
+
+	// Notebook tools
+	tool(s, "notebook_read", "Read a Jupyter notebook as numbered cells with their source; outputs are summarized unless include_outputs is set.",
+		notebook.HandleNotebookRead)
+
+	tool(s, "notebook_edit_cell", "Replace, insert or delete a single cell of a Jupyter notebook by index or cell id.",
+		notebook.HandleNotebookEditCell)
+
+	// Database tools
+	tool(s, "inspect_sqlite", "List the tables, schemas and row counts of an SQLite database, preview a table's rows, or run a read-only query. Given a directory, lists the databases under it.",
+		sqlite.HandleInspectSQLite)
+
 	// Terminal tools
-	s.Tool("execute_command", "Execute a terminal command with timeout.",
+	tool(s, "execute_command", "Execute a terminal command with timeout.",
 		terminal.HandleExecuteCommand)
 
-	s.Tool("read_output", "Read new output from a running terminal session.",
+	tool(s, "validate_command", "Check a command string as execute_command would without running it: report the commands it would invoke, how the shell splits their arguments, and which are blocked and why.",
+		terminal.HandleValidateCommand)
+
+	tool(s, "read_output", "Read new output from a terminal session, with its status (running or exited), exit code and duration.",
 		terminal.HandleReadOutput)
 
-	s.Tool("force_terminate", "Force terminate a running terminal session.",
+	tool(s, "wait_for_completion", "Wait until a terminal session's command exits, up to a timeout, and return its exit code and remaining output.",
+		terminal.HandleWaitForCompletion)
+
+	tool(s, "get_session_result", "Get a terminal session's command, exit code, timing and full output, also after it has finished.",
+		terminal.HandleGetSessionResult)
+
+	tool(s, "send_input", "Write text to the stdin of a running terminal session, such as an answer to a prompt or a line for a REPL.",
+		terminal.HandleSendInput)
+
+	tool(s, "resize_terminal", "Resize the pseudoterminal of a session started with pty.",
+		terminal.HandleResizeTerminal)
+
+	tool(s, "force_terminate", "Force terminate a running terminal session.",
 		terminal.HandleForceTerminate)
 
-	s.Tool("list_sessions", "List all active terminal sessions.",
+	tool(s, "list_sessions", "List all active terminal sessions.",
 		terminal.HandleListSessions)
 
-	s.Tool("execute_in_terminal", "Execute a command in the terminal (client-side execution).",
+	tool(s, "run_macro", "Run a named command template from the configuration with values for its parameters, as execute_command runs a command.",
+		terminal.HandleRunMacro)
+
+	tool(s, "execute_in_terminal", "Execute a command in the terminal (client-side execution).",
 		terminal.HandleExecuteInTerminal)
 
 	// Process tools
-	s.Tool("list_processes", "List all running processes.",
+	tool(s, "list_processes", "List all running processes.",
 		process.HandleListProcesses)
 
-	s.Tool("kill_process", "Terminate a running process by PID.",
+	tool(s, "kill_process", "Terminate a running process by PID.",
 		process.HandleKillProcess)
 
+	// Release tools
+	tool(s, "bump_version", "Bump the major, minor or patch version consistently across manifest files.",
+		release.HandleBumpVersion)
+
+	tool(s, "build_release", "Cross-compile the Go project for a GOOS/GOARCH matrix and write checksummed artifacts.",
+		release.HandleBuildRelease)
+
+	// Go module tools
+	tool(s, "go_mod_why", "Explain why each go.mod requirement is in the build: the import chain from go mod why, the modules requiring it from go mod graph, and requirements that look removable.",
+		gomod.HandleGoModWhy)
+
+	// Repository tools
+	tool(s, "suggest_ignores", "Scan a repository for build artifacts, caches and large files that .gitignore does not cover and suggest patterns with the space each would save.",
+		gitignore.HandleSuggestIgnores)
+
+	// Session tools
+	tool(s, usage.ReportTool, "Report the tool calls, argument and result bytes and time this session has used, per tool, with the configured usage limits.",
+		usage.HandleUsageReport)
+
+	// Upstream MCP servers, re-exposed as namespace.tool
+	if cfg, err := config.GetCurrentConfig(&server.Context{Logger: logger}); err == nil && len(cfg.UpstreamServers) > 0 {
+		upstreams := proxy.Start(s, logger, cfg.UpstreamServers)
+		defer upstreams.Close()
+	}
+
+	// Check every tool call, proxied ones included, against the configured
+	// policies, then count it against the usage limits
+	policy.Enforce(s, logger)
+	usage.Track(s, logger)
+
+	// Outbound webhooks for edits; command events are sent by the terminal tools
+	webhook.WatchEdits(logger)
+
 	// Start the server
+	checker.SetReady(true)
 	logger.Info("Starting GoCreate MCP server...")
-	if err := s.Run(); err != nil {
-		log.Fatalf("Server exited with error: %v", err)
+	runErr := make(chan error, 1)
+	go func() { runErr <- s.Run() }()
+
+	// Run until the client disconnects or the server is told to stop, then
+	// end the terminal sessions rather than leak them
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	select {
+	case err := <-runErr:
+		if err != nil {
+			logger.Error("Server exited with error, shutting down", "error", err)
+			exitCode = 1
+		}
+	case sig := <-signals:
+		logger.Info("Received signal, shutting down", "signal", sig)
+	case <-stdinEnded:
+		logger.Info("Client closed stdin, shutting down")
+	}
+	checker.SetReady(false)
+	terminal.Shutdown(&server.Context{Logger: logger})
+	if err := s.Shutdown(); err != nil {
+		logger.Error("Error shutting down server", "error", err)
 	}
 	logger.Info("Server shutdown complete.")
 }
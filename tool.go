@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// toolRegistry is implemented by the gomcp server.
+type toolRegistry interface {
+	GetTools() map[string]*server.Tool
+}
+
+// tool registers handler as the tool name. Every tool is registered through
+// it, as it works around two bugs of gomcp v1.5.2 that the typed client ran
+// into end to end:
+//
+//   - The wrapper gomcp puts around a handler calls IsNil on its result, which
+//     panics when the handler returns a string, as all of ours do. The handler
+//     is registered behind one returning interface{} instead.
+//   - The schema gomcp generates requires every field that is not a pointer,
+//     so a call leaving out an optional slice or string failed validation.
+//     Only the fields tagged required:"true" are left required.
+func tool[A any](s server.Server, name, description string, handler func(*server.Context, A) (string, error)) {
+	s.Tool(name, description, func(ctx *server.Context, args A) (interface{}, error) {
+		return handler(ctx, args)
+	})
+	registry, ok := s.(toolRegistry)
+	if !ok {
+		return
+	}
+	if t := registry.GetTools()[name]; t != nil {
+		if schema, ok := t.Schema.(map[string]interface{}); ok {
+			schema["required"] = requiredFields(reflect.TypeOf((*A)(nil)).Elem())
+		}
+	}
+}
+
+// requiredFields returns the JSON names of the fields of the struct t tagged
+// required:"true".
+func requiredFields(t reflect.Type) []string {
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("required") == "true" {
+			required = append(required, strings.Split(f.Tag.Get("json"), ",")[0])
+		}
+	}
+	return required
+}
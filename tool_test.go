@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+type echoArgs struct {
+	Text   string   `json:"text" required:"true"`
+	Suffix string   `json:"suffix,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Count  *int     `json:"count,omitempty"`
+}
+
+func TestRequiredFields(t *testing.T) {
+	if got := requiredFields(reflect.TypeOf(echoArgs{})); !reflect.DeepEqual(got, []string{"text"}) {
+		t.Errorf("requiredFields = %q, want [text]", got)
+	}
+	if got := requiredFields(reflect.TypeOf(struct{}{})); got == nil || len(got) != 0 {
+		t.Errorf("requiredFields of no fields = %#v, want an empty list", got)
+	}
+}
+
+func TestTool(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := server.NewServer("test", server.WithLogger(logger))
+	tool(s, "echo", "Echo text", func(ctx *server.Context, args echoArgs) (string, error) {
+		return args.Text + args.Suffix, nil
+	})
+
+	registered := s.(toolRegistry).GetTools()["echo"]
+	if registered == nil {
+		t.Fatal("echo was not registered")
+	}
+	if required := registered.Schema.(map[string]interface{})["required"]; !reflect.DeepEqual(required, []string{"text"}) {
+		t.Errorf("schema requires %v, want [text]", required)
+	}
+
+	// A string result comes back, and the optional fields can be left out
+	handler := registered.Handler.(func(*server.Context, interface{}) (interface{}, error))
+	ctx := &server.Context{Logger: logger}
+	result, err := handler(ctx, map[string]interface{}{"text": "hi"})
+	if err != nil || result != "hi" {
+		t.Errorf("echo = %v, %v; want hi", result, err)
+	}
+	if _, err := handler(ctx, map[string]interface{}{"suffix": "!"}); err == nil {
+		t.Error("echo without its required text was accepted")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath" // Keep for potential DefaultShell logic later
+	"strings"
 	"sync"
 
 	"github.com/localrivet/gomcp/server"
@@ -14,64 +15,191 @@ import (
 const configDir = "config"
 const configFileName = "config.json"
 
+// Default directory of insert_template templates, next to the config directory
+const templatesDir = "templates"
+
+// Default directory of terminal session output, next to the config directory
+const sessionsDir = "sessions"
+
 // Configuration struct to match config.json
 type ServerConfig struct {
-	BlockedCommands    []string `json:"blockedCommands"`
-	DefaultShell       *string  `json:"defaultShell,omitempty"`       // Pointer to distinguish between empty string and not set
-	AllowedDirectories []string `json:"allowedDirectories,omitempty"` // Use omitempty; nil slice means not set, empty slice means allow all
-	TelemetryEnabled   *bool    `json:"telemetryEnabled,omitempty"`   // Pointer for explicit true/false/not set
+	BlockedCommands      []string                  `json:"blockedCommands"`
+	AllowedCommands      []string                  `json:"allowedCommands,omitempty"`      // When set, the only commands execute_command may run, besides being checked against BlockedCommands
+	CommandRules         []CommandRule             `json:"commandRules,omitempty"`         // Deny execute_command commands by their arguments, the paths they name or what they are piped into
+	Macros               map[string]string         `json:"macros,omitempty"`               // Name to command template run by run_macro, e.g. {"test": "go test ./{{pkg}}/... -run {{name}}"}
+	DefaultShell         *string                   `json:"defaultShell,omitempty"`         // Pointer to distinguish between empty string and not set
+	AllowedDirectories   []string                  `json:"allowedDirectories,omitempty"`   // Use omitempty; nil slice means not set, empty slice means allow all
+	TelemetryEnabled     *bool                     `json:"telemetryEnabled,omitempty"`     // Pointer for explicit true/false/not set
+	VersionVariable      *string                   `json:"versionVariable,omitempty"`      // Makefile variable holding the release version (default VERSION)
+	ReleaseTargets       []string                  `json:"releaseTargets,omitempty"`       // GOOS/GOARCH pairs built by build_release
+	Locale               *string                   `json:"locale,omitempty"`               // Language for human-readable tool messages (e.g. "es", "de-DE")
+	PlainOutput          *bool                     `json:"plainOutput,omitempty"`          // Render diffs and reports without symbols or color escapes
+	FormatOnEdit         *bool                     `json:"formatOnEdit,omitempty"`         // Run the configured formatter after edit_block, precise_edit and write_file
+	Formatters           map[string]string         `json:"formatters,omitempty"`           // File extension (".go") to formatter command ("gofmt -w {file}")
+	AllowLinkCreation    *bool                     `json:"allowLinkCreation,omitempty"`    // Enable create_symlink and create_hardlink (default false)
+	StampGenerated       *bool                     `json:"stampGenerated,omitempty"`       // Stamp a generated-code header comment on files written by write_file
+	GeneratedMarker      *string                   `json:"generatedMarker,omitempty"`      // Header text for stamped files (default "Code generated by gocreate. DO NOT EDIT.")
+	UpstreamServers      map[string]UpstreamServer `json:"upstreamServers,omitempty"`      // Namespace to MCP server whose tools are re-exposed as namespace.tool
+	TemplatesDirectory   *string                   `json:"templatesDirectory,omitempty"`   // Directory of insert_template templates (default: templates next to the config directory)
+	Webhooks             []Webhook                 `json:"webhooks,omitempty"`             // Outbound HTTP notifications of commands and edits
+	HealthAddress        *string                   `json:"healthAddress,omitempty"`        // Listen address of /healthz, /readyz and /buildinfo (e.g. "127.0.0.1:8081"); off when unset
+	SearchEngine         *string                   `json:"searchEngine,omitempty"`         // search_code engine: "auto" (rg when found, the default), "builtin" or "ripgrep"
+	RipgrepPath          *string                   `json:"ripgrepPath,omitempty"`          // rg executable used by search_code (default: rg on the PATH)
+	SearchWorkers        *int                      `json:"searchWorkers,omitempty"`        // Files searched at once by search_code and rg threads (default: the number of CPUs)
+	SearchBufferKB       *int                      `json:"searchBufferKB,omitempty"`       // Size in KB of the blocks files are read in by search_code (default and minimum 64)
+	SessionHistory       *int                      `json:"sessionHistory,omitempty"`       // Finished terminal sessions kept with their output for read_output and get_session_result (default 100)
+	SessionsDirectory    *string                   `json:"sessionsDirectory,omitempty"`    // Directory terminal session output and records are written to (default: sessions next to the config directory)
+	SessionBufferKB      *int                      `json:"sessionBufferKB,omitempty"`      // KB of each output stream a terminal session keeps in memory, when it has no files, before dropping the oldest (default 1024)
+	SessionFileMB        *int                      `json:"sessionFileMB,omitempty"`        // MB of each output stream a terminal session keeps in its files before dropping the oldest (default 100)
+	DetachSessionsOnExit *bool                     `json:"detachSessionsOnExit,omitempty"` // Leave running terminal sessions running when the server exits instead of terminating them (default false)
+	StallTimeoutSec      *int                      `json:"stallTimeoutSec,omitempty"`      // Seconds a running terminal session may print nothing before it is reported stalled (default 300; 0 never)
+	TerminateStalled     *bool                     `json:"terminateStalled,omitempty"`     // Terminate stalled terminal sessions (default false)
+	MaxSessions          *int                      `json:"maxSessions,omitempty"`          // Most terminal sessions running at once (default 0, no limit)
+	QueueSessions        *bool                     `json:"queueSessions,omitempty"`        // Past maxSessions, make execute_command wait for a session to finish instead of failing (default false)
+	QueueTimeoutSec      *int                      `json:"queueTimeoutSec,omitempty"`      // Seconds a queued execute_command waits before it fails (default 600)
+	Container            *Container                `json:"container,omitempty"`            // Run execute_command commands in a Docker or Podman container instead of on the host
+	UsageLimits          *UsageLimits              `json:"usageLimits,omitempty"`          // Per-session ceilings on tool calls and bytes; none when unset
+	Policies             []Policy                  `json:"policies,omitempty"`             // CEL rules checked before every tool call; the first that matches allows or denies it
+}
+
+// UpstreamServer is an MCP server whose tools GoCreate proxies. Exactly one of
+// Command (a server launched over stdio) or URL (a streamable HTTP endpoint)
+// is set.
+type UpstreamServer struct {
+	Command   string            `json:"command,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`   // Extra HTTP headers, e.g. Authorization
+	Tools     []string          `json:"tools,omitempty"`     // Upstream tools to expose; empty exposes all
+	TimeoutMs *int              `json:"timeoutMs,omitempty"` // Per-call timeout (default 60000)
+}
+
+// Webhook is an endpoint that is POSTed a JSON event whenever one of Events
+// happens.
+type Webhook struct {
+	URL       string            `json:"url"`
+	Events    []string          `json:"events,omitempty"`    // Events to send (see WebhookEvents); empty sends all
+	Secret    string            `json:"secret,omitempty"`    // Key for the HMAC-SHA256 body signature in X-Gocreate-Signature
+	Headers   map[string]string `json:"headers,omitempty"`   // Extra HTTP headers, e.g. Authorization
+	TimeoutMs *int              `json:"timeoutMs,omitempty"` // Delivery timeout (default 10000)
+}
+
+// UsageLimits caps what one server session may consume. Zero or unset values
+// are not enforced.
+type UsageLimits struct {
+	MaxToolCalls int            `json:"maxToolCalls,omitempty"` // Tool calls of any kind
+	MaxBytes     int64          `json:"maxBytes,omitempty"`     // Argument and result bytes of all tool calls
+	Tools        map[string]int `json:"tools,omitempty"`        // Tool name to its own call ceiling, e.g. {"execute_command": 100}
 }
 
+// Policy is a rule checked before every tool call. When is a CEL expression
+// over the call (see the policy package); the first policy for which it is
+// true decides the call by its Effect.
+type Policy struct {
+	Name    string `json:"name"`
+	When    string `json:"when"`
+	Effect  string `json:"effect,omitempty"`  // "deny" (the default) or "allow"
+	Message string `json:"message,omitempty"` // Reason given to the client when the call is denied
+}
+
+// Container is the container execute_command runs each command in, with a
+// host directory, the workspace, mounted into it.
+type Container struct {
+	Image     string   `json:"image"`
+	Runtime   string   `json:"runtime,omitempty"`   // "docker", "podman" or the path of either (default: docker when found, otherwise podman)
+	Workspace string   `json:"workspace,omitempty"` // Host directory mounted into the container, within which commands run (default: the server's working directory)
+	MountPath string   `json:"mountPath,omitempty"` // Where the workspace is mounted in the container (default /workspace)
+	ReadOnly  bool     `json:"readOnly,omitempty"`  // Mount the workspace read-only
+	Network   string   `json:"network,omitempty"`   // Network the container joins: "none" (the default), "bridge", "host" or a network name
+	Shell     string   `json:"shell,omitempty"`     // Shell in the image that runs the command (default /bin/sh)
+	Args      []string `json:"args,omitempty"`      // More options of the run command, e.g. ["--memory=1g", "--cpus=2"]
+}
+
+// CommandRule denies the commands execute_command runs by how they are run, as
+// the shell parses them. It applies to each call of Command in a command
+// string, and denies the string when all of the conditions it sets hold for
+// one; a rule that sets none denies Command outright.
+type CommandRule struct {
+	Name        string   `json:"name"`
+	Command     string   `json:"command"`               // Command name, matched lowercased and without its directory
+	Args        []string `json:"args,omitempty"`        // Each pattern (path.Match syntax) matches an argument, e.g. ["push", "--force*"]
+	PathsWithin []string `json:"pathsWithin,omitempty"` // An operand names a path outside all of these; {cwd} and {tmp} stand for the command's and the temporary directory
+	PipedTo     []string `json:"pipedTo,omitempty"`     // The output is piped into one of these commands, e.g. ["sh", "bash"]
+	Message     string   `json:"message,omitempty"`     // Reason given to the client when a command is denied
+}
+
+// CompilePolicy checks a policy's When expression. It is set by the policy
+// package, and validate_config reports the expressions it rejects.
+var CompilePolicy func(expr string) error
+
+// WebhookEvents lists the events a webhook can subscribe to.
+var WebhookEvents = []string{"command_finished", "command_blocked", "edit_applied"}
+
 var currentConfig *ServerConfig
-var loadConfigOnce sync.Once
+var configLoaded bool
 var loadConfigErr error
+var configMu sync.Mutex // Protects currentConfig, configLoaded and loadConfigErr
 
 // For testing purposes
 var testConfigDir string
 
-// loadConfig loads the configuration from file or creates default. Used internally.
+// loadConfig returns the cached configuration, reading it on first use and
+// after invalidateConfig. Used internally.
 func loadConfig(ctx *server.Context) (*ServerConfig, error) {
-	loadConfigOnce.Do(func() {
-		configPath, err := getConfigPath()
-		if err != nil {
-			loadConfigErr = fmt.Errorf("failed to get config path: %w", err)
-			return
-		}
+	configMu.Lock()
+	defer configMu.Unlock()
+	if !configLoaded {
+		currentConfig, loadConfigErr = readConfig(ctx)
+		configLoaded = true
+	}
+	return currentConfig, loadConfigErr
+}
 
-		content, err := os.ReadFile(configPath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				ctx.Logger.Info("Config file not found at %s, creating default for internal use", "configPath", configPath)
-				cfg := ServerConfig{
-					BlockedCommands: defaultBlockedCommands, // Use var from get_config.go
-				}
-				// Attempt to write default file, but proceed even if write fails
-				configJson, marshalErr := json.MarshalIndent(cfg, "", "  ")
-				if marshalErr == nil {
-					_ = os.MkdirAll(filepath.Dir(configPath), 0755) // Ignore error
-					_ = os.WriteFile(configPath, configJson, 0644)  // Ignore error
-				} else {
-					ctx.Logger.Info("Error marshalling default config for write", "error", marshalErr)
-				}
-				currentConfig = &cfg // Use in-memory default
-			} else {
-				loadConfigErr = fmt.Errorf("error reading config file %s: %w", configPath, err)
-				return
-			}
+// readConfig loads the configuration from file or creates default.
+func readConfig(ctx *server.Context) (*ServerConfig, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error reading config file %s: %w", configPath, err)
+		}
+		ctx.Logger.Info("Config file not found at %s, creating default for internal use", "configPath", configPath)
+		cfg := ServerConfig{
+			BlockedCommands: defaultBlockedCommands, // Use var from get_config.go
+		}
+		// Attempt to write default file, but proceed even if write fails
+		configJson, marshalErr := json.MarshalIndent(cfg, "", "  ")
+		if marshalErr == nil {
+			_ = os.MkdirAll(filepath.Dir(configPath), 0755) // Ignore error
+			_ = os.WriteFile(configPath, configJson, 0644)  // Ignore error
 		} else {
-			var cfg ServerConfig
-			if err := json.Unmarshal(content, &cfg); err != nil {
-				loadConfigErr = fmt.Errorf("error unmarshalling config file %s: %w", configPath, err)
-				return
-			}
-			// Ensure BlockedCommands is not nil if file exists but key is missing
-			if cfg.BlockedCommands == nil {
-				cfg.BlockedCommands = []string{} // Initialize to empty slice
-			}
-			currentConfig = &cfg
+			ctx.Logger.Info("Error marshalling default config for write", "error", marshalErr)
 		}
-	})
-	return currentConfig, loadConfigErr
+		return &cfg, nil // Use in-memory default
+	}
+
+	var cfg ServerConfig
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("error unmarshalling config file %s: %w", configPath, err)
+	}
+	// Ensure BlockedCommands is not nil if file exists but key is missing
+	if cfg.BlockedCommands == nil {
+		cfg.BlockedCommands = []string{} // Initialize to empty slice
+	}
+	return &cfg, nil
+}
+
+// invalidateConfig discards the cached configuration so the next lookup
+// re-reads the file. It is called after set_config_value writes a change.
+func invalidateConfig() {
+	configMu.Lock()
+	defer configMu.Unlock()
+	configLoaded = false
 }
 
 // GetCurrentConfig provides access to the loaded configuration.
@@ -79,6 +207,75 @@ func GetCurrentConfig(ctx *server.Context) (*ServerConfig, error) {
 	return loadConfig(ctx)
 }
 
+// TemplatesDir returns the directory insert_template reads templates from:
+// templatesDirectory when it is set, otherwise templates beside the config
+// directory.
+func TemplatesDir(cfg *ServerConfig) (string, error) {
+	if cfg != nil && cfg.TemplatesDirectory != nil && *cfg.TemplatesDirectory != "" {
+		return *cfg.TemplatesDirectory, nil
+	}
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(filepath.Dir(configPath)), templatesDir), nil
+}
+
+// SessionsDir returns the directory terminal sessions spill their output to:
+// sessionsDirectory when it is set, otherwise sessions beside the config
+// directory.
+func SessionsDir(cfg *ServerConfig) (string, error) {
+	if cfg != nil && cfg.SessionsDirectory != nil && *cfg.SessionsDirectory != "" {
+		return *cfg.SessionsDirectory, nil
+	}
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(filepath.Dir(configPath)), sessionsDir), nil
+}
+
+// PathAllowed reports whether path lies within one of the configured allowed
+// directories. Symbolic links in existing parents are resolved first so a link
+// cannot be used to step outside a root. An unset or empty list allows every path.
+func PathAllowed(cfg *ServerConfig, path string) bool {
+	if cfg == nil || len(cfg.AllowedDirectories) == 0 {
+		return true
+	}
+	resolved := ResolvePath(path)
+	for _, dir := range cfg.AllowedDirectories {
+		rel, err := filepath.Rel(ResolvePath(dir), resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvePath returns the absolute form of path with symbolic links in its
+// longest existing prefix resolved. Components that do not exist yet are kept.
+func ResolvePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	var missing []string
+	for dir := abs; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs
+		}
+		missing = append(missing, filepath.Base(dir))
+		dir = parent
+	}
+}
+
 // getConfigPath returns the absolute path to the configuration file.
 func getConfigPath() (string, error) {
 	if testConfigDir != "" {
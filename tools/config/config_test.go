@@ -0,0 +1,69 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestSetConfigValueReloads(t *testing.T) {
+	defer func(old string) { testConfigDir = old; invalidateConfig() }(testConfigDir)
+	testConfigDir = t.TempDir()
+	invalidateConfig()
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	cfg, err := GetCurrentConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetCurrentConfig failed: %v", err)
+	}
+	if cfg.Locale != nil {
+		t.Fatalf("Expected no locale by default, got %q", *cfg.Locale)
+	}
+
+	if _, err := HandleSetConfigValue(ctx, SetConfigValueArgs{Key: "locale", Value: "es"}); err != nil {
+		t.Fatalf("HandleSetConfigValue failed: %v", err)
+	}
+
+	cfg, err = GetCurrentConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetCurrentConfig failed: %v", err)
+	}
+	if cfg.Locale == nil || *cfg.Locale != "es" {
+		t.Errorf("Expected locale es after set_config_value, got %v", cfg.Locale)
+	}
+}
+
+func TestPathAllowed(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	if err := os.Symlink(other, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	cfg := &ServerConfig{AllowedDirectories: []string{root}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{root, true},
+		{filepath.Join(root, "new", "file.txt"), true},
+		{filepath.Join(root, "..", filepath.Base(other)), false},
+		{filepath.Join(root, "escape", "file.txt"), false},
+		{other, false},
+	}
+	for _, tt := range tests {
+		if got := PathAllowed(cfg, tt.path); got != tt.want {
+			t.Errorf("PathAllowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if !PathAllowed(&ServerConfig{}, other) {
+		t.Error("Expected an unset allowed list to allow every path")
+	}
+}
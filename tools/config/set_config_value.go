@@ -52,6 +52,9 @@ func HandleSetConfigValue(ctx *server.Context, args SetConfigValueArgs) (string,
 		return "Error writing configuration file", err
 	}
 
+	// Settings such as locale and plainOutput take effect on the next tool call
+	invalidateConfig()
+
 	ctx.Logger.Info("Configuration value set successfully", "key", args.Key)
 	return "Configuration value set successfully.", nil
 }
@@ -0,0 +1,589 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// macroParam matches a {{name}} parameter of a macro, as run_macro does.
+var macroParam = regexp.MustCompile(`\{\{\s*[A-Za-z_][A-Za-z0-9_]*\s*\}\}`)
+
+// ValidateConfigArgs defines the arguments for the validate_config tool.
+type ValidateConfigArgs struct{}
+
+// ConfigIssue describes a single problem found in the configuration file.
+type ConfigIssue struct {
+	Key     string `json:"key"`
+	Problem string `json:"problem"`
+	Fix     string `json:"fix"`
+}
+
+// ConfigValidationReport is the result returned by validate_config.
+type ConfigValidationReport struct {
+	ConfigPath string        `json:"configPath"`
+	Valid      bool          `json:"valid"`
+	Issues     []ConfigIssue `json:"issues"`
+}
+
+// knownConfigKeys returns the JSON keys understood by ServerConfig.
+func knownConfigKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(ServerConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// validateServerConfig checks a parsed configuration and its raw keys for contradictions.
+func validateServerConfig(cfg ServerConfig, raw map[string]json.RawMessage) []ConfigIssue {
+	issues := []ConfigIssue{}
+
+	// Keys left behind by set_config_value that the server never reads
+	known := knownConfigKeys()
+	var unknown []string
+	for key := range raw {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		issues = append(issues, ConfigIssue{
+			Key:     key,
+			Problem: "unknown configuration key; it is ignored by the server",
+			Fix:     fmt.Sprintf("remove %q or correct its spelling", key),
+		})
+	}
+
+	issues = append(issues, commandListIssues("blockedCommands", "blocked", cfg.BlockedCommands)...)
+	issues = append(issues, commandListIssues("allowedCommands", "allowed", cfg.AllowedCommands)...)
+	blocked := make(map[string]bool, len(cfg.BlockedCommands))
+	for _, cmd := range cfg.BlockedCommands {
+		blocked[strings.TrimSpace(cmd)] = true
+	}
+	for _, cmd := range cfg.AllowedCommands {
+		if blocked[strings.TrimSpace(cmd)] {
+			issues = append(issues, ConfigIssue{
+				Key:     "allowedCommands",
+				Problem: fmt.Sprintf("%q is blocked as well, so it still cannot run", cmd),
+				Fix:     "remove it from one of allowedCommands and blockedCommands",
+			})
+		}
+	}
+
+	for i, rule := range cfg.CommandRules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		if strings.TrimSpace(rule.Command) == "" {
+			issues = append(issues, ConfigIssue{
+				Key:     "commandRules",
+				Problem: fmt.Sprintf("%s has no command", name),
+				Fix:     "set command to the name of the command the rule applies to, such as \"rm\"",
+			})
+		}
+		for _, pattern := range rule.Args {
+			if _, err := path.Match(pattern, ""); err != nil {
+				issues = append(issues, ConfigIssue{
+					Key:     "commandRules",
+					Problem: fmt.Sprintf("%s has malformed argument pattern %q", name, pattern),
+					Fix:     "use path.Match syntax, e.g. \"--force*\"",
+				})
+			}
+		}
+		for _, list := range [][]string{rule.PathsWithin, rule.PipedTo} {
+			for _, entry := range list {
+				if strings.TrimSpace(entry) == "" {
+					issues = append(issues, ConfigIssue{
+						Key:     "commandRules",
+						Problem: fmt.Sprintf("%s has an empty entry in pathsWithin or pipedTo", name),
+						Fix:     "remove the empty entry",
+					})
+				}
+			}
+		}
+	}
+
+	macros := make([]string, 0, len(cfg.Macros))
+	for name := range cfg.Macros {
+		macros = append(macros, name)
+	}
+	sort.Strings(macros)
+	for _, name := range macros {
+		template := cfg.Macros[name]
+		switch {
+		case strings.TrimSpace(template) == "":
+			issues = append(issues, ConfigIssue{
+				Key:     "macros",
+				Problem: fmt.Sprintf("macro %q has no command", name),
+				Fix:     "set a command template such as \"go test ./{{pkg}}/...\"",
+			})
+		case strings.Contains(macroParam.ReplaceAllString(template, ""), "{{"):
+			// Such as {{.pkg}}, which is passed to the shell as it is
+			issues = append(issues, ConfigIssue{
+				Key:     "macros",
+				Problem: fmt.Sprintf("macro %q has a placeholder that is not a {{name}} parameter", name),
+				Fix:     "write parameters as {{name}}, with a name of letters, digits and underscores",
+			})
+		}
+	}
+
+	for _, dir := range cfg.AllowedDirectories {
+		info, err := os.Stat(dir)
+		if err != nil {
+			issues = append(issues, ConfigIssue{
+				Key:     "allowedDirectories",
+				Problem: fmt.Sprintf("allowed directory %q does not exist", dir),
+				Fix:     "create the directory or remove it from the list",
+			})
+		} else if !info.IsDir() {
+			issues = append(issues, ConfigIssue{
+				Key:     "allowedDirectories",
+				Problem: fmt.Sprintf("allowed directory %q is not a directory", dir),
+				Fix:     "point the entry at a directory",
+			})
+		}
+	}
+
+	if cfg.DefaultShell != nil {
+		if *cfg.DefaultShell == "" {
+			issues = append(issues, ConfigIssue{
+				Key:     "defaultShell",
+				Problem: "default shell is set to an empty string",
+				Fix:     "remove the key to use automatic shell detection",
+			})
+		} else if _, err := exec.LookPath(*cfg.DefaultShell); err != nil {
+			issues = append(issues, ConfigIssue{
+				Key:     "defaultShell",
+				Problem: fmt.Sprintf("default shell %q was not found", *cfg.DefaultShell),
+				Fix:     "use an absolute path to an installed shell",
+			})
+		}
+	}
+
+	if cfg.VersionVariable != nil && strings.TrimSpace(*cfg.VersionVariable) == "" {
+		issues = append(issues, ConfigIssue{
+			Key:     "versionVariable",
+			Problem: "version variable is set to an empty string",
+			Fix:     "remove the key to use the default VERSION variable",
+		})
+	}
+
+	for _, target := range cfg.ReleaseTargets {
+		goos, goarch, ok := strings.Cut(target, "/")
+		if !ok || goos == "" || goarch == "" {
+			issues = append(issues, ConfigIssue{
+				Key:     "releaseTargets",
+				Problem: fmt.Sprintf("target %q is not in GOOS/GOARCH form", target),
+				Fix:     "use entries such as \"linux/amd64\"",
+			})
+		}
+	}
+
+	exts := make([]string, 0, len(cfg.Formatters))
+	for ext := range cfg.Formatters {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		fields := strings.Fields(cfg.Formatters[ext])
+		switch {
+		case !strings.HasPrefix(ext, "."):
+			issues = append(issues, ConfigIssue{
+				Key:     "formatters",
+				Problem: fmt.Sprintf("extension %q does not start with a dot and will never match", ext),
+				Fix:     fmt.Sprintf("use %q", "."+ext),
+			})
+		case len(fields) == 0:
+			issues = append(issues, ConfigIssue{
+				Key:     "formatters",
+				Problem: fmt.Sprintf("formatter for %q is empty", ext),
+				Fix:     "remove the entry or set a command such as \"gofmt -w {file}\"",
+			})
+		default:
+			if _, err := exec.LookPath(fields[0]); err != nil {
+				issues = append(issues, ConfigIssue{
+					Key:     "formatters",
+					Problem: fmt.Sprintf("formatter %q for %q was not found", fields[0], ext),
+					Fix:     "install the formatter or use an absolute path",
+				})
+			}
+		}
+	}
+
+	if cfg.TemplatesDirectory != nil && *cfg.TemplatesDirectory != "" {
+		if info, err := os.Stat(*cfg.TemplatesDirectory); err != nil || !info.IsDir() {
+			issues = append(issues, ConfigIssue{
+				Key:     "templatesDirectory",
+				Problem: fmt.Sprintf("templates directory %q does not exist", *cfg.TemplatesDirectory),
+				Fix:     "create the directory or remove the key to use the default",
+			})
+		}
+	}
+	if cfg.SessionsDirectory != nil && *cfg.SessionsDirectory != "" {
+		// A missing directory is created, but a file cannot be used
+		if info, err := os.Stat(*cfg.SessionsDirectory); err == nil && !info.IsDir() {
+			issues = append(issues, ConfigIssue{
+				Key:     "sessionsDirectory",
+				Problem: fmt.Sprintf("%q is not a directory, so session output is kept in memory", *cfg.SessionsDirectory),
+				Fix:     "point it at a directory or remove the key to use the default",
+			})
+		}
+	}
+
+	if c := cfg.Container; c != nil {
+		if strings.TrimSpace(c.Image) == "" {
+			issues = append(issues, ConfigIssue{
+				Key:     "container",
+				Problem: "no image is set, so no command can run",
+				Fix:     "set image, e.g. \"golang:1.24\", or remove the key to run commands on the host",
+			})
+		}
+		if c.Runtime != "" {
+			if _, err := exec.LookPath(c.Runtime); err != nil {
+				issues = append(issues, ConfigIssue{
+					Key:     "container",
+					Problem: fmt.Sprintf("runtime %q was not found", c.Runtime),
+					Fix:     "install docker or podman, or use an absolute path",
+				})
+			}
+		}
+		if c.Workspace != "" {
+			if info, err := os.Stat(c.Workspace); err != nil || !info.IsDir() {
+				issues = append(issues, ConfigIssue{
+					Key:     "container",
+					Problem: fmt.Sprintf("workspace %q is not a directory", c.Workspace),
+					Fix:     "point workspace at the directory to mount, or remove it to mount the server's working directory",
+				})
+			}
+		}
+		if c.MountPath != "" && !path.IsAbs(c.MountPath) {
+			issues = append(issues, ConfigIssue{
+				Key:     "container",
+				Problem: fmt.Sprintf("mount path %q is not absolute", c.MountPath),
+				Fix:     "use an absolute path such as \"/workspace\"",
+			})
+		}
+	}
+
+	namespaces := make([]string, 0, len(cfg.UpstreamServers))
+	for ns := range cfg.UpstreamServers {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		up := cfg.UpstreamServers[ns]
+		switch {
+		case ns == "" || strings.ContainsAny(ns, ". \t"):
+			issues = append(issues, ConfigIssue{
+				Key:     "upstreamServers",
+				Problem: fmt.Sprintf("namespace %q must be a non-empty name without dots or spaces", ns),
+				Fix:     "use a short name such as \"db\"",
+			})
+		case (up.Command == "") == (up.URL == ""):
+			issues = append(issues, ConfigIssue{
+				Key:     "upstreamServers",
+				Problem: fmt.Sprintf("upstream %q must set exactly one of command or url", ns),
+				Fix:     "set command to launch a stdio server, or url for an HTTP server",
+			})
+		case up.Command != "":
+			if _, err := exec.LookPath(up.Command); err != nil {
+				issues = append(issues, ConfigIssue{
+					Key:     "upstreamServers",
+					Problem: fmt.Sprintf("command %q for upstream %q was not found", up.Command, ns),
+					Fix:     "install the server or use an absolute path",
+				})
+			}
+		}
+	}
+
+	if cfg.HealthAddress != nil && *cfg.HealthAddress != "" {
+		if _, _, err := net.SplitHostPort(*cfg.HealthAddress); err != nil {
+			issues = append(issues, ConfigIssue{
+				Key:     "healthAddress",
+				Problem: fmt.Sprintf("%q is not a host:port address", *cfg.HealthAddress),
+				Fix:     "use a value such as \"127.0.0.1:8081\" or \":8081\"",
+			})
+		}
+	}
+
+	if cfg.SearchEngine != nil {
+		switch *cfg.SearchEngine {
+		case "auto", "builtin", "ripgrep":
+		default:
+			issues = append(issues, ConfigIssue{
+				Key:     "searchEngine",
+				Problem: fmt.Sprintf("unknown search engine %q", *cfg.SearchEngine),
+				Fix:     "use \"auto\", \"builtin\" or \"ripgrep\"",
+			})
+		}
+	}
+	if cfg.RipgrepPath != nil && *cfg.RipgrepPath != "" {
+		if _, err := exec.LookPath(*cfg.RipgrepPath); err != nil {
+			issues = append(issues, ConfigIssue{
+				Key:     "ripgrepPath",
+				Problem: fmt.Sprintf("ripgrep executable %q was not found; search_code uses its built-in engine", *cfg.RipgrepPath),
+				Fix:     "install ripgrep or point ripgrepPath at the rg binary",
+			})
+		}
+	}
+
+	if cfg.SearchWorkers != nil && *cfg.SearchWorkers < 1 {
+		issues = append(issues, ConfigIssue{
+			Key:     "searchWorkers",
+			Problem: fmt.Sprintf("%d workers cannot search anything", *cfg.SearchWorkers),
+			Fix:     "use 1 or more, or remove it to use one per CPU",
+		})
+	}
+	if cfg.SearchBufferKB != nil && *cfg.SearchBufferKB < 64 {
+		issues = append(issues, ConfigIssue{
+			Key:     "searchBufferKB",
+			Problem: fmt.Sprintf("%d KB is below the 64 KB minimum, which is used instead", *cfg.SearchBufferKB),
+			Fix:     "use 64 or more",
+		})
+	}
+	if cfg.SessionHistory != nil && *cfg.SessionHistory < 0 {
+		issues = append(issues, ConfigIssue{
+			Key:     "sessionHistory",
+			Problem: fmt.Sprintf("%d finished sessions cannot be kept, so none are", *cfg.SessionHistory),
+			Fix:     "use 0 or more, or remove it to keep the last 100",
+		})
+	}
+	if cfg.SessionBufferKB != nil && *cfg.SessionBufferKB < 1 {
+		issues = append(issues, ConfigIssue{
+			Key:     "sessionBufferKB",
+			Problem: fmt.Sprintf("%d KB cannot hold any output, so 1 byte is kept", *cfg.SessionBufferKB),
+			Fix:     "use 1 or more, or remove it to keep 1024 KB",
+		})
+	}
+	if cfg.SessionFileMB != nil && *cfg.SessionFileMB < 1 {
+		issues = append(issues, ConfigIssue{
+			Key:     "sessionFileMB",
+			Problem: fmt.Sprintf("%d MB cannot hold any output, so 1 byte is kept", *cfg.SessionFileMB),
+			Fix:     "use 1 or more, or remove it to keep 100 MB",
+		})
+	}
+	if cfg.StallTimeoutSec != nil && *cfg.StallTimeoutSec < 0 {
+		issues = append(issues, ConfigIssue{
+			Key:     "stallTimeoutSec",
+			Problem: fmt.Sprintf("stall timeout %d is negative", *cfg.StallTimeoutSec),
+			Fix:     "use a number of seconds, or 0 to never report sessions stalled",
+		})
+	}
+	if cfg.TerminateStalled != nil && *cfg.TerminateStalled && cfg.StallTimeoutSec != nil && *cfg.StallTimeoutSec <= 0 {
+		issues = append(issues, ConfigIssue{
+			Key:     "terminateStalled",
+			Problem: "sessions are never reported stalled, so none is terminated",
+			Fix:     "set stallTimeoutSec to a number of seconds",
+		})
+	}
+
+	if cfg.MaxSessions != nil && *cfg.MaxSessions < 0 {
+		issues = append(issues, ConfigIssue{
+			Key:     "maxSessions",
+			Problem: fmt.Sprintf("session limit %d is negative", *cfg.MaxSessions),
+			Fix:     "use the most sessions to run at once, or 0 for no limit",
+		})
+	}
+	if cfg.QueueSessions != nil && *cfg.QueueSessions && (cfg.MaxSessions == nil || *cfg.MaxSessions <= 0) {
+		issues = append(issues, ConfigIssue{
+			Key:     "queueSessions",
+			Problem: "there is no session limit, so no command is ever queued",
+			Fix:     "set maxSessions to the most sessions to run at once",
+		})
+	}
+	if cfg.QueueTimeoutSec != nil && *cfg.QueueTimeoutSec < 1 {
+		issues = append(issues, ConfigIssue{
+			Key:     "queueTimeoutSec",
+			Problem: fmt.Sprintf("queued commands give up after %d seconds, before any session can finish", *cfg.QueueTimeoutSec),
+			Fix:     "use 1 or more, or remove it to wait up to 600 seconds",
+		})
+	}
+
+	if limits := cfg.UsageLimits; limits != nil {
+		if limits.MaxToolCalls < 0 || limits.MaxBytes < 0 {
+			issues = append(issues, ConfigIssue{
+				Key:     "usageLimits",
+				Problem: "maxToolCalls and maxBytes cannot be negative",
+				Fix:     "use a positive ceiling, or 0 for none",
+			})
+		}
+		tools := make([]string, 0, len(limits.Tools))
+		for tool := range limits.Tools {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+		for _, tool := range tools {
+			if limits.Tools[tool] < 0 {
+				issues = append(issues, ConfigIssue{
+					Key:     "usageLimits",
+					Problem: fmt.Sprintf("the ceiling for %q is negative", tool),
+					Fix:     "use a positive ceiling, or 0 for none",
+				})
+			}
+		}
+	}
+
+	for i, policy := range cfg.Policies {
+		name := policy.Name
+		if name == "" {
+			name = fmt.Sprintf("policy %d", i+1)
+		}
+		if policy.Effect != "" && policy.Effect != "allow" && policy.Effect != "deny" {
+			issues = append(issues, ConfigIssue{
+				Key:     "policies",
+				Problem: fmt.Sprintf("%s has unknown effect %q", name, policy.Effect),
+				Fix:     "use \"allow\" or \"deny\"",
+			})
+		}
+		if strings.TrimSpace(policy.When) == "" {
+			issues = append(issues, ConfigIssue{
+				Key:     "policies",
+				Problem: fmt.Sprintf("%s has no when expression", name),
+				Fix:     "set when to a CEL expression such as \"tool == 'execute_command'\"",
+			})
+		} else if CompilePolicy != nil {
+			if err := CompilePolicy(policy.When); err != nil {
+				issues = append(issues, ConfigIssue{
+					Key:     "policies",
+					Problem: fmt.Sprintf("%s does not compile: %v", name, err),
+					Fix:     "correct the CEL expression; it must evaluate to a bool",
+				})
+			}
+		}
+	}
+
+	knownEvents := make(map[string]bool, len(WebhookEvents))
+	for _, event := range WebhookEvents {
+		knownEvents[event] = true
+	}
+	for i, hook := range cfg.Webhooks {
+		if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			issues = append(issues, ConfigIssue{
+				Key:     "webhooks",
+				Problem: fmt.Sprintf("webhook %d has an invalid url %q", i+1, hook.URL),
+				Fix:     "use an absolute http or https URL",
+			})
+		}
+		for _, event := range hook.Events {
+			if !knownEvents[event] {
+				issues = append(issues, ConfigIssue{
+					Key:     "webhooks",
+					Problem: fmt.Sprintf("webhook %d subscribes to unknown event %q", i+1, event),
+					Fix:     "use one of " + strings.Join(WebhookEvents, ", "),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// HandleValidateConfig implements the validate_config tool.
+func HandleValidateConfig(ctx *server.Context, args ValidateConfigArgs) (string, error) {
+	ctx.Logger.Info("Handling validate_config tool call")
+
+	configPath, err := getConfigPath()
+	if err != nil {
+		ctx.Logger.Info("Error getting config path", "error", err)
+		return "Error getting configuration file path", err
+	}
+
+	report := ConfigValidationReport{ConfigPath: configPath, Issues: []ConfigIssue{}}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.Logger.Info("Error reading config file", "configPath", configPath, "error", err)
+			return "Error reading configuration file", err
+		}
+		report.Issues = append(report.Issues, ConfigIssue{
+			Key:     "",
+			Problem: "configuration file does not exist; built-in defaults are in use",
+			Fix:     "call get_config to write the default configuration file",
+		})
+	} else {
+		var raw map[string]json.RawMessage
+		var cfg ServerConfig
+		if err := json.Unmarshal(content, &raw); err != nil {
+			report.Issues = append(report.Issues, ConfigIssue{
+				Key:     "",
+				Problem: "configuration file is not a valid JSON object: " + err.Error(),
+				Fix:     "correct the JSON syntax",
+			})
+		} else if err := json.Unmarshal(content, &cfg); err != nil {
+			report.Issues = append(report.Issues, ConfigIssue{
+				Key:     "",
+				Problem: "configuration value has the wrong type: " + err.Error(),
+				Fix:     "set the value using the documented type",
+			})
+		} else {
+			report.Issues = validateServerConfig(cfg, raw)
+		}
+	}
+	report.Valid = len(report.Issues) == 0
+
+	reportJson, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling validation report", "error", err)
+		return "Error generating validation report", err
+	}
+	return string(reportJson), nil
+}
+
+// commandListIssues checks a list of command names, the key's, which are
+// matched lowercased and whole: kind says what the list does to them.
+func commandListIssues(key, kind string, list []string) []ConfigIssue {
+	var issues []ConfigIssue
+	seen := make(map[string]bool)
+	for _, cmd := range list {
+		trimmed := strings.TrimSpace(cmd)
+		switch {
+		case trimmed == "":
+			issues = append(issues, ConfigIssue{
+				Key:     key,
+				Problem: fmt.Sprintf("empty entry in %s command list", kind),
+				Fix:     "remove the empty entry",
+			})
+			continue
+		case trimmed != strings.ToLower(trimmed):
+			// Command names are lowercased before lookup, so mixed-case entries never match
+			issues = append(issues, ConfigIssue{
+				Key:     key,
+				Problem: fmt.Sprintf("entry %q contains uppercase characters and will never match", cmd),
+				Fix:     fmt.Sprintf("replace it with %q", strings.ToLower(trimmed)),
+			})
+		case trimmed != cmd || strings.ContainsAny(trimmed, " \t"):
+			issues = append(issues, ConfigIssue{
+				Key:     key,
+				Problem: fmt.Sprintf("entry %q contains whitespace; only bare command names are matched", cmd),
+				Fix:     "list the bare command name only",
+			})
+		}
+		if seen[trimmed] {
+			issues = append(issues, ConfigIssue{
+				Key:     key,
+				Problem: fmt.Sprintf("duplicate entry %q", cmd),
+				Fix:     "remove the duplicate",
+			})
+		}
+		seen[trimmed] = true
+	}
+	return issues
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateServerConfig(t *testing.T) {
+	existing := t.TempDir()
+	missing := filepath.Join(existing, "does-not-exist")
+	file := filepath.Join(existing, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		json string
+		// want lists "key: problem substring" pairs that must all be reported
+		want []string
+	}{
+		{
+			name: "clean",
+			json: `{"blockedCommands": ["rm", "sudo"], "allowedDirectories": [` + quote(existing) + `], "releaseTargets": ["linux/amd64"]}`,
+		},
+		{
+			name: "unknown key",
+			json: `{"blockedCommands": [], "blockedCommand": ["rm"]}`,
+			want: []string{"blockedCommand: unknown configuration key"},
+		},
+		{
+			name: "mixed-case blocked command",
+			json: `{"blockedCommands": ["Sudo"]}`,
+			want: []string{"blockedCommands: uppercase"},
+		},
+		{
+			name: "duplicate blocked command",
+			json: `{"blockedCommands": ["rm", "rm"]}`,
+			want: []string{"blockedCommands: duplicate"},
+		},
+		{
+			name: "whitespace in blocked command",
+			json: `{"blockedCommands": [" rm", "rm -rf", ""]}`,
+			want: []string{"blockedCommands: \" rm\" contains whitespace", "blockedCommands: \"rm -rf\" contains whitespace", "blockedCommands: empty entry"},
+		},
+		{
+			name: "allowed command issues",
+			json: `{"blockedCommands": ["rm"], "allowedCommands": ["go", "Go", "rm"]}`,
+			want: []string{"allowedCommands: uppercase", "allowedCommands: \"rm\" is blocked as well"},
+		},
+		{
+			name: "command rule issues",
+			json: `{"blockedCommands": [], "commandRules": [{"name": "force", "command": "git", "args": ["--force["]}, {"pipedTo": [""]}]}`,
+			want: []string{"commandRules: \"--force[\"", "commandRules: rule 2 has no command", "commandRules: rule 2 has an empty entry"},
+		},
+		{
+			name: "missing allowed directory",
+			json: `{"blockedCommands": [], "allowedDirectories": [` + quote(missing) + `]}`,
+			want: []string{"allowedDirectories: does not exist"},
+		},
+		{
+			name: "malformed release target",
+			json: `{"blockedCommands": [], "releaseTargets": ["linux", "/amd64", "darwin/arm64"]}`,
+			want: []string{"releaseTargets: \"linux\"", "releaseTargets: \"/amd64\""},
+		},
+		{
+			name: "missing templates directory",
+			json: `{"blockedCommands": [], "templatesDirectory": ` + quote(missing) + `}`,
+			want: []string{"templatesDirectory: does not exist"},
+		},
+		{
+			name: "sessions directory is a file",
+			json: `{"blockedCommands": [], "sessionsDirectory": ` + quote(file) + `}`,
+			want: []string{"sessionsDirectory: is not a directory"},
+		},
+		{
+			name: "malformed macros",
+			json: `{"blockedCommands": [], "macros": {"test": "go test ./{{pkg}}/... -run {{ name }}", "lint": " ", "vet": "go vet ./{{.pkg}}"}}`,
+			want: []string{"macros: \"lint\" has no command", "macros: \"vet\" has a placeholder"},
+		},
+		{
+			name: "malformed container",
+			json: `{"blockedCommands": [], "container": {"runtime": "gocreate-no-such-docker", "workspace": ` + quote(missing) + `, "mountPath": "workspace"}}`,
+			want: []string{"container: no image", "container: runtime \"gocreate-no-such-docker\" was not found", "container: is not a directory", "container: not absolute"},
+		},
+		{
+			name: "health address without port",
+			json: `{"blockedCommands": [], "healthAddress": "localhost"}`,
+			want: []string{"healthAddress: not a host:port address"},
+		},
+		{
+			name: "unknown search engine and missing ripgrep",
+			json: `{"blockedCommands": [], "searchEngine": "grep", "ripgrepPath": "gocreate-no-such-rg"}`,
+			want: []string{"searchEngine: unknown search engine \"grep\"", "ripgrepPath: was not found"},
+		},
+		{
+			name: "search tuning out of range",
+			json: `{"blockedCommands": [], "searchWorkers": 0, "searchBufferKB": 16}`,
+			want: []string{"searchWorkers: cannot search anything", "searchBufferKB: below the 64 KB minimum"},
+		},
+		{
+			name: "session history and buffers out of range",
+			json: `{"blockedCommands": [], "sessionHistory": -1, "sessionBufferKB": 0, "sessionFileMB": -5}`,
+			want: []string{"sessionHistory: cannot be kept", "sessionBufferKB: cannot hold any output", "sessionFileMB: cannot hold any output"},
+		},
+		{
+			name: "stall timeout out of range",
+			json: `{"blockedCommands": [], "stallTimeoutSec": -1, "terminateStalled": true}`,
+			want: []string{"stallTimeoutSec: is negative", "terminateStalled: never reported stalled"},
+		},
+		{
+			name: "session limit out of range",
+			json: `{"blockedCommands": [], "maxSessions": -2, "queueSessions": true, "queueTimeoutSec": 0}`,
+			want: []string{"maxSessions: is negative", "queueSessions: no session limit", "queueTimeoutSec: give up after 0 seconds"},
+		},
+		{
+			name: "negative usage limits",
+			json: `{"blockedCommands": [], "usageLimits": {"maxBytes": -1, "tools": {"read_file": 10, "execute_command": -5}}}`,
+			want: []string{"usageLimits: cannot be negative", "usageLimits: the ceiling for \"execute_command\" is negative"},
+		},
+		{
+			name: "malformed policies",
+			json: `{"blockedCommands": [], "policies": [{"name": "no-etc", "when": "", "effect": "block"}]}`,
+			want: []string{"policies: no-etc has unknown effect \"block\"", "policies: no-etc has no when expression"},
+		},
+		{
+			name: "malformed webhooks",
+			json: `{"blockedCommands": [], "webhooks": [{"url": "hooks.example.com"}, {"url": "https://hooks.example.com/x", "events": ["edit_applied", "edit_made"]}]}`,
+			want: []string{"webhooks: webhook 1 has an invalid url", "webhooks: webhook 2 subscribes to unknown event \"edit_made\""},
+		},
+		{
+			name: "malformed upstream servers",
+			json: `{"blockedCommands": [], "upstreamServers": {"db.main": {"url": "http://localhost:9000/mcp"}, "both": {"command": "go", "url": "http://x"}, "none": {}, "web": {"url": "http://localhost:9000/mcp"}}}`,
+			want: []string{"upstreamServers: namespace \"db.main\"", "upstreamServers: upstream \"both\"", "upstreamServers: upstream \"none\""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw map[string]json.RawMessage
+			var cfg ServerConfig
+			if err := json.Unmarshal([]byte(tt.json), &raw); err != nil {
+				t.Fatalf("Invalid test JSON: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.json), &cfg); err != nil {
+				t.Fatalf("Invalid test config: %v", err)
+			}
+
+			issues := validateServerConfig(cfg, raw)
+			if len(issues) != len(tt.want) {
+				t.Errorf("Got %d issues, want %d: %+v", len(issues), len(tt.want), issues)
+			}
+			for _, w := range tt.want {
+				key, problem, _ := strings.Cut(w, ": ")
+				found := false
+				for _, issue := range issues {
+					if issue.Key == key && strings.Contains(issue.Problem, problem) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Missing issue %q in %+v", w, issues)
+				}
+			}
+		})
+	}
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
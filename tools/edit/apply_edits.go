@@ -0,0 +1,104 @@
+package edit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// FileEdit is a single text replacement applied by apply_edits.
+type FileEdit struct {
+	FilePath             string `json:"file_path" description:"The path to the file to edit." required:"true"`
+	OldString            string `json:"old_string" description:"The exact block of text to find and replace." required:"true"`
+	NewString            string `json:"new_string" description:"The new block of text to insert." required:"true"`
+	ExpectedReplacements *int   `json:"expected_replacements,omitempty" description:"Optional. The number of occurrences to replace. Defaults to 1."`
+}
+
+// ApplyEditsArgs defines the arguments for the apply_edits tool.
+type ApplyEditsArgs struct {
+	Edits []FileEdit `json:"edits" description:"The edits to apply. Edits to the same file are applied in order. Either every edit is applied or none are." required:"true"`
+}
+
+// stageEdits applies edits in memory and returns the staged files in first-seen order.
+func stageEdits(edits []FileEdit) ([]*StagedFile, error) {
+	var order []*StagedFile
+	staged := make(map[string]*StagedFile)
+
+	for i, e := range edits {
+		// Resolve symlinks and relative spellings so every edit to the same file
+		// is staged together and the rename replaces the target, not the link
+		key, err := filepath.EvalSymlinks(e.FilePath)
+		if err == nil {
+			key, err = filepath.Abs(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %w", i+1, err)
+		}
+		sf, ok := staged[key]
+		if !ok {
+			info, err := os.Stat(key)
+			if err != nil {
+				return nil, fmt.Errorf("edit %d: %w", i+1, err)
+			}
+			if info.Size() > maxEditFileSize {
+				return nil, fmt.Errorf("edit %d: %s exceeds the %d MB limit for this editing tool", i+1, e.FilePath, maxEditFileSize/(1024*1024))
+			}
+			content, err := os.ReadFile(key)
+			if err != nil {
+				return nil, fmt.Errorf("edit %d: %w", i+1, err)
+			}
+			sf = &StagedFile{Path: key, Original: content, Content: string(content), Mode: info.Mode()}
+			staged[key] = sf
+			order = append(order, sf)
+		}
+
+		expected := 1
+		if e.ExpectedReplacements != nil {
+			expected = *e.ExpectedReplacements
+			if expected <= 0 {
+				return nil, fmt.Errorf("edit %d: expected_replacements must be positive", i+1)
+			}
+		}
+		if e.OldString == "" {
+			return nil, fmt.Errorf("edit %d: old_string must not be empty", i+1)
+		}
+		occurrences := strings.Count(sf.Content, e.OldString)
+		if occurrences < expected {
+			return nil, fmt.Errorf("edit %d: expected %d replacement(s) in %s, but found %d occurrence(s) of old_string", i+1, expected, e.FilePath, occurrences)
+		}
+		sf.Content = strings.Replace(sf.Content, e.OldString, e.NewString, expected)
+	}
+	return order, nil
+}
+
+// HandleApplyEdits implements the apply_edits tool. All edits are staged in memory
+// and written to temporary files next to their targets; the temporary files are only
+// renamed into place once every edit has been validated. If any rename fails, the
+// files already swapped are restored from their original contents.
+func HandleApplyEdits(ctx *server.Context, args ApplyEditsArgs) (string, error) {
+	ctx.Logger.Info("Handling apply_edits tool call", "edits", len(args.Edits))
+
+	if len(args.Edits) == 0 {
+		return i18n.T(ctx, i18n.NoEditsProvided), nil
+	}
+
+	// --- Stage ---
+	files, err := stageEdits(args.Edits)
+	if err != nil {
+		ctx.Logger.Info("Validation failed for apply_edits", "error", err)
+		return i18n.T(ctx, i18n.EditsNotApplied, err.Error()), nil
+	}
+
+	// --- Commit ---
+	if err := CommitStaged("apply_edits", files); err != nil {
+		return commitFailure(ctx, "apply_edits", err)
+	}
+
+	ctx.Logger.Info("Edits applied atomically", "edits", len(args.Edits), "files", len(files))
+	return i18n.T(ctx, i18n.EditsApplied, len(args.Edits), len(files)), nil
+}
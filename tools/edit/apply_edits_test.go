@@ -0,0 +1,119 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newApplyEditsDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+	}
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("Temporary file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestHandleApplyEditsFailureLeavesFilesUntouched(t *testing.T) {
+	dir := newApplyEditsDir(t, map[string]string{"a.txt": "alpha\n", "b.txt": "beta\n"})
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	result, err := HandleApplyEdits(ctx, ApplyEditsArgs{Edits: []FileEdit{
+		{FilePath: filepath.Join(dir, "a.txt"), OldString: "alpha", NewString: "ALPHA"},
+		{FilePath: filepath.Join(dir, "b.txt"), OldString: "missing", NewString: "x"},
+	}})
+	if err != nil {
+		t.Fatalf("HandleApplyEdits failed: %v", err)
+	}
+	if !strings.Contains(result, "No files were changed") || !strings.Contains(result, "edit 2") {
+		t.Errorf("Expected the second edit to be reported, got: %s", result)
+	}
+	assertContent(t, filepath.Join(dir, "a.txt"), "alpha\n")
+	assertContent(t, filepath.Join(dir, "b.txt"), "beta\n")
+	assertNoTempFiles(t, dir)
+}
+
+func TestHandleApplyEditsSameFileInOrder(t *testing.T) {
+	dir := newApplyEditsDir(t, map[string]string{"a.txt": "one two\n"})
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	t.Chdir(dir)
+
+	// The second edit only matches after the first has been applied, and the
+	// third uses a different spelling of the same path
+	result, err := HandleApplyEdits(ctx, ApplyEditsArgs{Edits: []FileEdit{
+		{FilePath: filepath.Join(dir, "a.txt"), OldString: "one", NewString: "three"},
+		{FilePath: "a.txt", OldString: "three two", NewString: "four"},
+		{FilePath: "./a.txt", OldString: "four", NewString: "five"},
+	}})
+	if err != nil {
+		t.Fatalf("HandleApplyEdits failed: %v (%s)", err, result)
+	}
+	if !strings.Contains(result, "across 1 file") {
+		t.Errorf("Expected all edits to be staged on one file, got: %s", result)
+	}
+	assertContent(t, filepath.Join(dir, "a.txt"), "five\n")
+	assertNoTempFiles(t, dir)
+}
+
+func TestHandleApplyEditsThroughSymlink(t *testing.T) {
+	dir := newApplyEditsDir(t, map[string]string{"target.txt": "old\n"})
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink("target.txt", link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	if _, err := HandleApplyEdits(ctx, ApplyEditsArgs{Edits: []FileEdit{
+		{FilePath: link, OldString: "old", NewString: "new"},
+	}}); err != nil {
+		t.Fatalf("HandleApplyEdits failed: %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatalf("Lstat failed: %v", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("The symlink was replaced by a regular file")
+	}
+	assertContent(t, filepath.Join(dir, "target.txt"), "new\n")
+	assertNoTempFiles(t, dir)
+}
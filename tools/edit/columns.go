@@ -0,0 +1,127 @@
+package edit
+
+import (
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// EditColumnsArgs defines the arguments for the edit_columns tool.
+type EditColumnsArgs struct {
+	FilePath    string  `json:"file_path" description:"The path to the file to edit." required:"true"`
+	Operation   string  `json:"operation" description:"insert (text before start_column), delete (columns start_column to end_column) or replace (those columns with text)." required:"true"`
+	StartLine   int     `json:"start_line" description:"The 1-indexed first line of the block (inclusive)." required:"true"`
+	EndLine     int     `json:"end_line" description:"The 1-indexed last line of the block (inclusive)." required:"true"`
+	StartColumn int     `json:"start_column" description:"The 1-indexed first column of the block, counted in characters; a tab is one column." required:"true"`
+	EndColumn   *int    `json:"end_column,omitempty" description:"Optional. The 1-indexed last column of the block (inclusive). Required for delete and replace."`
+	Text        *string `json:"text,omitempty" description:"Optional. The text for insert and replace: a single line applied to every line of the block, or one line per line of the block."`
+	Plain       *bool   `json:"plain,omitempty" description:"Optional. If true, render the returned diff as plain text without symbols. Defaults to the plainOutput config value."`
+}
+
+// editColumns applies a rectangular edit to lines. Columns are 1-indexed rune
+// positions and endColumn is inclusive; for insert it is ignored. texts holds
+// the text for each line. Lines shorter than startColumn are padded with
+// spaces for insert and replace and left alone by delete.
+func editColumns(lines []string, op string, startColumn, endColumn int, texts []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		runes := []rune(line)
+		if len(runes) < startColumn-1 {
+			if op == "delete" {
+				out[i] = line
+				continue
+			}
+			runes = append(runes, []rune(strings.Repeat(" ", startColumn-1-len(runes)))...)
+		}
+		head := string(runes[:startColumn-1])
+		tail := string(runes[startColumn-1:])
+		if op != "insert" {
+			tail = string(runes[min(endColumn, len(runes)):])
+		}
+		switch op {
+		case "insert", "replace":
+			out[i] = head + texts[i] + tail
+		case "delete":
+			out[i] = head + tail
+		}
+	}
+	return out
+}
+
+// HandleEditColumns implements the edit_columns tool.
+func HandleEditColumns(ctx *server.Context, args EditColumnsArgs) (string, error) {
+	ctx.Logger.Info("Handling edit_columns tool call")
+
+	op := strings.ToLower(strings.TrimSpace(args.Operation))
+	switch op {
+	case "insert", "delete", "replace":
+	default:
+		return i18n.T(ctx, i18n.ColumnsInvalidOperation, args.Operation), nil
+	}
+	endColumn := args.StartColumn
+	if args.EndColumn != nil {
+		endColumn = *args.EndColumn
+	} else if op != "insert" {
+		return i18n.T(ctx, i18n.ColumnsEndRequired, op), nil
+	}
+	if args.StartColumn < 1 || (op != "insert" && endColumn < args.StartColumn) {
+		return i18n.T(ctx, i18n.ColumnsRange, args.StartColumn, endColumn), nil
+	}
+	var textLines []string
+	if op != "delete" {
+		if args.Text == nil {
+			return i18n.T(ctx, i18n.ColumnsTextRequired, op), nil
+		}
+		textLines = strings.Split(strings.ReplaceAll(*args.Text, "\r\n", "\n"), "\n")
+	}
+
+	original, mode, msg, err := readEditableFile(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+
+	lines, lineEnding, trailingNewline := splitLines(original)
+	numLines := len(lines)
+	start, end := args.StartLine, args.EndLine
+	if start < 1 || end < start || end > numLines {
+		msg := i18n.T(ctx, i18n.DeleteLineRange, start, end, numLines)
+		ctx.Logger.Info(msg)
+		return msg, nil
+	}
+
+	texts := make([]string, end-start+1)
+	if op != "delete" {
+		switch len(textLines) {
+		case 1:
+			for i := range texts {
+				texts[i] = textLines[0]
+			}
+		case len(texts):
+			copy(texts, textLines)
+		default:
+			return i18n.T(ctx, i18n.ColumnsTextLines, len(textLines), len(texts)), nil
+		}
+	}
+
+	edited := editColumns(lines[start-1:end], op, args.StartColumn, endColumn, texts)
+	newLines := append(append(append([]string(nil), lines[:start-1]...), edited...), lines[end:]...)
+	updated := strings.Join(newLines, lineEnding)
+	if trailingNewline && len(newLines) > 0 {
+		updated += lineEnding
+	}
+	if updated == original {
+		return i18n.T(ctx, i18n.ColumnsUnchanged, start, end), nil
+	}
+
+	if err := writeEditedFile(ctx, args.FilePath, "edit_columns", updated, mode); err != nil {
+		ctx.Logger.Info("Error writing file after edit_columns", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+
+	ctx.Logger.Info("Columns edited", "filePath", args.FilePath, "operation", op, "start", start, "end", end)
+	result := i18n.T(ctx, i18n.ColumnsApplied, op, args.StartColumn, endColumn, start, end)
+	return result + appliedDiff(args.FilePath, original, []byte(updated), render.Plain(ctx, args.Plain)), nil
+}
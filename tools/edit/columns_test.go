@@ -0,0 +1,118 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestEditColumns(t *testing.T) {
+	lines := []string{"alpha  1", "be     22", "", "äöü    3"}
+	tests := []struct {
+		name       string
+		op         string
+		start, end int
+		texts      []string
+		want       []string
+	}{
+		{"prefix", "insert", 1, 1, []string{"// ", "// ", "// ", "// "}, []string{"// alpha  1", "// be     22", "// ", "// äöü    3"}},
+		{"insert pads short lines", "insert", 6, 6, []string{"|", "|", "|", "|"}, []string{"alpha|  1", "be   |  22", "     |", "äöü  |  3"}},
+		{"delete", "delete", 6, 7, nil, []string{"alpha1", "be   22", "", "äöü  3"}},
+		{"delete past the end of short lines", "delete", 2, 20, nil, []string{"a", "b", "", "ä"}},
+		{"replace per line", "replace", 1, 3, []string{"ALP", "BE ", "NEW", "AOU"}, []string{"ALPha  1", "BE     22", "NEW", "AOU    3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			texts := tt.texts
+			if texts == nil {
+				texts = make([]string, len(lines))
+			}
+			if got := editColumns(lines, tt.op, tt.start, tt.end, texts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("editColumns() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleEditColumns(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	intPtr := func(i int) *int { return &i }
+	strPtr := func(s string) *string { return &s }
+	const table = "id name\r\n1  ann\r\n2  bob\r\n"
+
+	tests := []struct {
+		name    string
+		args    EditColumnsArgs
+		want    string
+		wantMsg string
+	}{
+		{
+			name:    "insert a column",
+			args:    EditColumnsArgs{Operation: "insert", StartLine: 1, EndLine: 3, StartColumn: 4, Text: strPtr("| ")},
+			want:    "id | name\r\n1  | ann\r\n2  | bob\r\n",
+			wantMsg: "Applied insert to columns 4-4 of lines 1-3.",
+		},
+		{
+			name: "replace with one line per line",
+			args: EditColumnsArgs{Operation: "replace", StartLine: 2, EndLine: 3, StartColumn: 1, EndColumn: intPtr(1), Text: strPtr("7\n8")},
+			want: "id name\r\n7  ann\r\n8  bob\r\n",
+		},
+		{
+			name:    "text lines do not fit",
+			args:    EditColumnsArgs{Operation: "replace", StartLine: 1, EndLine: 3, StartColumn: 1, EndColumn: intPtr(1), Text: strPtr("a\nb")},
+			want:    table,
+			wantMsg: "text has 2 lines, but the block has 3",
+		},
+		{
+			name:    "delete needs end_column",
+			args:    EditColumnsArgs{Operation: "delete", StartLine: 1, EndLine: 3, StartColumn: 1},
+			want:    table,
+			wantMsg: "end_column is required for delete.",
+		},
+		{
+			name:    "unknown operation",
+			args:    EditColumnsArgs{Operation: "yank", StartLine: 1, EndLine: 3, StartColumn: 1},
+			want:    table,
+			wantMsg: `Unknown operation "yank"`,
+		},
+		{
+			name:    "lines out of range",
+			args:    EditColumnsArgs{Operation: "insert", StartLine: 2, EndLine: 4, StartColumn: 1, Text: strPtr("x")},
+			want:    table,
+			wantMsg: "invalid range 2-4",
+		},
+		{
+			name:    "no change",
+			args:    EditColumnsArgs{Operation: "replace", StartLine: 1, EndLine: 1, StartColumn: 1, EndColumn: intPtr(2), Text: strPtr("id")},
+			want:    table,
+			wantMsg: "already match",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "table.txt")
+			if err := os.WriteFile(filePath, []byte(table), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tt.args.FilePath = filePath
+			msg, err := HandleEditColumns(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("HandleEditColumns failed: %v", err)
+			}
+			if tt.wantMsg != "" && !strings.Contains(msg, tt.wantMsg) {
+				t.Errorf("HandleEditColumns returned %q, want it to mention %q", msg, tt.wantMsg)
+			}
+			if got, _ := os.ReadFile(filePath); string(got) != tt.want {
+				t.Errorf("File = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package edit
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// formatCharDiff renders a character-level diff. The default rendering uses
+// colored output; plain mode marks changes with bracketed words instead.
+func formatCharDiff(dmp *diffmatchpatch.DiffMatchPatch, diffs []diffmatchpatch.Diff, plain bool) string {
+	if !plain {
+		return dmp.DiffPrettyText(diffs)
+	}
+	var sb strings.Builder
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			sb.WriteString("[removed: " + d.Text + "]")
+		case diffmatchpatch.DiffInsert:
+			sb.WriteString("[added: " + d.Text + "]")
+		default:
+			sb.WriteString(d.Text)
+		}
+	}
+	return sb.String()
+}
@@ -3,8 +3,14 @@ package edit
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"gocreate/tools/format"
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+	"gocreate/tools/render"
+
 	"github.com/localrivet/gomcp/server"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
@@ -13,10 +19,19 @@ const maxEditFileSize = 100 * 1024 * 1024 // 100 MB limit
 
 // Go structs for tool arguments
 type EditBlockArgs struct {
-	FilePath             string `json:"file_path" description:"The path to the file to edit." required:"true"`
-	OldString            string `json:"old_string" description:"The exact block of text to find and replace." required:"true"`
-	NewString            string `json:"new_string" description:"The new block of text to insert." required:"true"`
-	ExpectedReplacements *int   `json:"expected_replacements,omitempty" description:"Optional. If provided, the exact number of replacements expected. Defaults to 1."`
+	FilePath             string   `json:"file_path" description:"The path to the file to edit." required:"true"`
+	OldString            string   `json:"old_string" description:"The exact block of text to find and replace." required:"true"`
+	NewString            string   `json:"new_string" description:"The new block of text to insert." required:"true"`
+	ExpectedReplacements *int     `json:"expected_replacements,omitempty" description:"Optional. If provided, the exact number of replacements expected. Defaults to 1. If old_string occurs more often, nothing is replaced and every occurrence is listed with its line number."`
+	IgnoreWhitespace     *bool    `json:"ignore_whitespace,omitempty" description:"Optional. If true and old_string is not found exactly, match lines ignoring leading indentation and trailing whitespace, then re-indent new_string to the file's indentation. Cannot be combined with expected_replacements greater than 1."`
+	FuzzyApply           *bool    `json:"fuzzy_apply,omitempty" description:"Optional. If true and old_string is not found exactly, apply the replacement at the most similar block of lines when it meets fuzzy_threshold. Files over 4 MB are not fuzzy-matched. Cannot be combined with expected_replacements greater than 1."`
+	FuzzyThreshold       *float64 `json:"fuzzy_threshold,omitempty" description:"Optional. Minimum similarity (0-1) required by fuzzy_apply. Defaults to 0.8."`
+	LineEnding           *string  `json:"line_ending,omitempty" description:"Optional. Line breaks in old_string and new_string are matched and written using the file's dominant ending (auto, the default); lf or crlf converts the whole file."`
+	Plain                *bool    `json:"plain,omitempty" description:"Optional. If true, render diffs as plain text without color or symbols. Defaults to the plainOutput config value."`
+	Format               *bool    `json:"format,omitempty" description:"Optional. If true, run the formatter configured for the file's extension after the edit. Defaults to the formatOnEdit config value."`
+	StartLine            *int     `json:"start_line,omitempty" description:"Optional. The 1-indexed first line (inclusive) of the window old_string is searched in. Defaults to 1."`
+	EndLine              *int     `json:"end_line,omitempty" description:"Optional. The 1-indexed last line (inclusive) of the window old_string is searched in, including its line ending. Defaults to the last line."`
+	CreateIfMissing      *bool    `json:"create_if_missing,omitempty" description:"Optional. If true and the file does not exist, treat it as empty and create it and its parent directories; use an empty old_string to write new_string as its content. Defaults to false."`
 }
 
 // HandleEditBlock implements the edit_block tool using the new API
@@ -25,100 +40,202 @@ func HandleEditBlock(ctx *server.Context, args EditBlockArgs) (string, error) {
 
 	// --- File Size Check ---
 	fileInfo, err := os.Stat(args.FilePath)
-	if err != nil {
+	creating := os.IsNotExist(err) && args.CreateIfMissing != nil && *args.CreateIfMissing
+	if err != nil && !creating {
 		// Handle file not found or other stat errors
 		if os.IsNotExist(err) {
 			ctx.Logger.Info("File not found", "filePath", args.FilePath)
-			return "Error: File not found.", err
+			return i18n.T(ctx, i18n.FileNotFound), err
 		}
 		ctx.Logger.Info("Error getting file info", "filePath", args.FilePath, "error", err)
-		return "Error accessing file information.", err
+		return i18n.T(ctx, i18n.FileAccessError), err
 	}
 
-	if fileInfo.Size() > maxEditFileSize {
-		errorMsg := fmt.Sprintf("Error: File size (%d bytes) exceeds the %d MB limit for this editing tool due to memory constraints. Please use a different tool or method for editing very large files. If this is a source code file, consider splitting it into smaller modules/files if appropriate for the language.", fileInfo.Size(), maxEditFileSize/(1024*1024))
+	if !creating && fileInfo.Size() > maxEditFileSize {
+		errorMsg := i18n.T(ctx, i18n.FileTooLarge, fileInfo.Size(), maxEditFileSize/(1024*1024))
 		ctx.Logger.Info(errorMsg)
 		return errorMsg, nil
 	}
 	// --- End File Size Check ---
 
-	// Read the file (now known to be within size limit)
-	content, err := os.ReadFile(args.FilePath)
-	if err != nil {
-		// This error should be less likely now after Stat, but handle anyway
-		ctx.Logger.Info("Error reading file", "filePath", args.FilePath, "error", err)
-		return "Error reading file for editing", err
+	// Read the file (now known to be within size limit); a file to be created starts empty
+	var content []byte
+	if !creating {
+		content, err = os.ReadFile(args.FilePath)
+		if err != nil {
+			// This error should be less likely now after Stat, but handle anyway
+			ctx.Logger.Info("Error reading file", "filePath", args.FilePath, "error", err)
+			return i18n.T(ctx, i18n.FileReadError), err
+		}
+	}
+
+	lineEnding, ok := parseLineEnding(args.LineEnding)
+	if !ok {
+		return i18n.T(ctx, i18n.LineEndingInvalid, *args.LineEnding), nil
 	}
 
 	originalContent := string(content)
 	var modifiedContent string
 
+	// Only search the requested lines, so one of several similar blocks can be targeted
+	scopeStart, scopeEnd, lineOffset := 0, len(originalContent), 0
+	if args.StartLine != nil || args.EndLine != nil {
+		lines, _, _ := splitLines(originalContent)
+		start, end := 1, len(lines)
+		if args.StartLine != nil {
+			start = *args.StartLine
+		}
+		if args.EndLine != nil {
+			end = *args.EndLine
+		}
+		if start < 1 || end < start || end > len(lines) {
+			msg := i18n.T(ctx, i18n.DeleteLineRange, start, end, len(lines))
+			ctx.Logger.Info(msg)
+			return msg, nil
+		}
+		scopeStart, scopeEnd = lineSpan(originalContent, start, end)
+		lineOffset = start - 1
+	}
+	scope := originalContent[scopeStart:scopeEnd]
+
+	// Write new_string with the file's line endings, and match old_string with them
+	// when the caller's line breaks differ from the file's
+	fileEnding := detectLineEnding(originalContent)
+	args.NewString = toLineEnding(args.NewString, fileEnding)
+	if !strings.Contains(scope, args.OldString) {
+		if converted := toLineEnding(args.OldString, fileEnding); strings.Contains(scope, converted) {
+			args.OldString = converted
+		}
+	}
+	resultMsg := i18n.T(ctx, i18n.FileEdited)
+	if creating {
+		resultMsg = i18n.T(ctx, i18n.FileCreatedByEdit, args.FilePath)
+	}
+	plain := render.Plain(ctx, args.Plain)
+
+	fuzzyApply := args.FuzzyApply != nil && *args.FuzzyApply
+	fuzzyThreshold := defaultFuzzyThreshold
+	if args.FuzzyThreshold != nil {
+		fuzzyThreshold = *args.FuzzyThreshold
+		if fuzzyThreshold <= 0 || fuzzyThreshold > 1 {
+			return i18n.T(ctx, i18n.FuzzyThresholdRange), nil
+		}
+	}
+
 	// --- Perform Context-Aware Replacement ---
 	replacementsMade := 0
 	expected := 1 // Default expectation
 
-	if args.ExpectedReplacements != nil {
+	ignoreWhitespace := args.IgnoreWhitespace != nil && *args.IgnoreWhitespace
+	if args.ExpectedReplacements != nil && *args.ExpectedReplacements > 1 && (fuzzyApply || ignoreWhitespace) {
+		return i18n.T(ctx, i18n.MatchModeConflict), nil
+	}
+
+	if args.ExpectedReplacements != nil && *args.ExpectedReplacements != 1 {
 		expected = *args.ExpectedReplacements
 		if expected <= 0 {
-			return "expected_replacements must be positive", nil
+			return i18n.T(ctx, i18n.ExpectedPositive), nil
 		}
 		// --- Handle Multiple Replacements (Using strings.Replace for now) ---
-		actualOccurrences := strings.Count(originalContent, args.OldString)
+		actualOccurrences := strings.Count(scope, args.OldString)
 		if actualOccurrences < expected {
-			msg := fmt.Sprintf("Expected %d replacements, but only found %d occurrences of the old string.", expected, actualOccurrences)
+			msg := i18n.T(ctx, i18n.ReplacementsShort, expected, actualOccurrences)
 			ctx.Logger.Info(msg, "filePath", args.FilePath)
 			return msg, nil
 		}
-		modifiedContent = strings.Replace(originalContent, args.OldString, args.NewString, expected)
-		if modifiedContent == originalContent && expected > 0 && actualOccurrences > 0 {
-			msg := fmt.Sprintf("Replacement failed unexpectedly for %d expected replacements despite %d occurrences.", expected, actualOccurrences)
+		if actualOccurrences > expected {
+			return ambiguousEdit(ctx, args.FilePath, scope, args.OldString, expected, lineOffset), nil
+		}
+		modifiedContent = strings.Replace(scope, args.OldString, args.NewString, expected)
+		if modifiedContent == scope && expected > 0 && actualOccurrences > 0 {
+			msg := i18n.T(ctx, i18n.ReplacementFailed, expected, actualOccurrences)
 			ctx.Logger.Info(msg, "filePath", args.FilePath)
 			return msg, nil
 		}
 		replacementsMade = expected
 	} else {
 		// --- Handle Single Replacement (Default) ---
-		index := strings.Index(originalContent, args.OldString)
+		index := strings.Index(scope, args.OldString)
+		if index != -1 && args.OldString != "" && strings.Count(scope, args.OldString) > 1 {
+			return ambiguousEdit(ctx, args.FilePath, scope, args.OldString, expected, lineOffset), nil
+		}
+
+		if index == -1 && ignoreWhitespace {
+			// Old string not found exactly, retry ignoring indentation and trailing whitespace
+			wsMatches := findWhitespaceMatches(scope, args.OldString)
+			if len(wsMatches) > 1 {
+				lines := make([]string, len(wsMatches))
+				for i, m := range wsMatches {
+					lines[i] = fmt.Sprintf("%d", m.StartLine+lineOffset)
+				}
+				msg := i18n.T(ctx, i18n.WhitespaceAmbiguous, len(wsMatches), strings.Join(lines, ", "))
+				ctx.Logger.Info(msg, "filePath", args.FilePath)
+				return msg, nil
+			}
+			if len(wsMatches) == 1 {
+				m := wsMatches[0]
+				reindented := reindentBlock(args.NewString, args.OldString, m.FileLines)
+				modifiedContent = scope[:m.Start] + reindented + scope[m.End:]
+				replacementsMade = 1
+				ctx.Logger.Info("Whitespace-insensitive match applied for edit_block", "filePath", args.FilePath, "line", m.StartLine+lineOffset)
+			}
+		}
+
+		if index == -1 && replacementsMade == 0 && fuzzyApply && len(scope) > maxFuzzyFileSize {
+			ctx.Logger.Info("File too large for fuzzy matching in edit_block", "filePath", args.FilePath, "size", len(scope))
+		} else if index == -1 && replacementsMade == 0 && fuzzyApply {
+			// Old string not found exactly, try to apply at the most similar block
+			if match, ok := findFuzzyMatch(scope, args.OldString, fuzzyThreshold); ok && match.Similarity >= fuzzyThreshold {
+				matchedBlock := scope[match.Start:match.End]
+				modifiedContent = scope[:match.Start] + args.NewString + scope[match.End:]
+				replacementsMade = 1
+				resultMsg = i18n.T(ctx, i18n.FuzzyApplied,
+					match.StartLine+lineOffset, match.EndLine+lineOffset, match.Similarity, render.Block(plain, render.LineDiff(matchedBlock, args.NewString, plain)))
+				ctx.Logger.Info("Fuzzy match applied for edit_block", "filePath", args.FilePath, "similarity", match.Similarity)
+			} else if ok {
+				ctx.Logger.Info("Fuzzy match below threshold for edit_block", "filePath", args.FilePath, "similarity", match.Similarity, "threshold", fuzzyThreshold)
+			}
+		}
 
-		if index == -1 {
+		if index == -1 && replacementsMade == 0 {
 			// Old string not found, generate near-miss diff if possible
 			ctx.Logger.Info("Old string block not found in file", "filePath", args.FilePath)
 
 			// --- Generate Diff for Near Miss ---
 			dmp := diffmatchpatch.New()
-			bestMatchIndex := dmp.MatchMain(originalContent, args.OldString, 0)
+			bestMatchIndex := dmp.MatchMain(scope, args.OldString, 0)
 
 			var errorMsg string
 			if bestMatchIndex != -1 {
 				// Found a potential near miss location
 				endIndex := bestMatchIndex + len(args.OldString)
-				if endIndex > len(originalContent) {
-					endIndex = len(originalContent)
+				if endIndex > len(scope) {
+					endIndex = len(scope)
 				}
-				closestMatchBlock := originalContent[bestMatchIndex:endIndex]
+				closestMatchBlock := scope[bestMatchIndex:endIndex]
 
 				// Generate diff between expected OldString and the actual block found
 				diffs := dmp.DiffMain(args.OldString, closestMatchBlock, false)
-				diffText := dmp.DiffPrettyText(diffs)
+				diffText := formatCharDiff(dmp, diffs, plain)
 				diffText = strings.ReplaceAll(diffText, "\\n", "\n")
 				diffText = strings.ReplaceAll(diffText, "%", "%%")
-				errorMsg = fmt.Sprintf("Failed to apply edit. Found a potential match near character %d with differences:\n---\n%s\n---", bestMatchIndex, diffText)
+				errorMsg = fmt.Sprintf("Failed to apply edit. Found a potential match near character %d with differences:\n%s", scopeStart+bestMatchIndex, render.Block(plain, diffText))
 				ctx.Logger.Info("Near miss found for edit_block", "filePath", args.FilePath)
 
 			} else {
 				// Couldn't find a reasonable match, just show the expected block
 				ctx.Logger.Info("Near miss check failed to find any likely match for edit_block", "filePath", args.FilePath)
 				diffsNotFound := dmp.DiffMain(args.OldString, "", false)
-				diffText := dmp.DiffPrettyText(diffsNotFound)
+				diffText := formatCharDiff(dmp, diffsNotFound, plain)
 				diffText = strings.ReplaceAll(diffText, "\\n", "\n")
 				diffText = strings.ReplaceAll(diffText, "%", "%%")
-				errorMsg = fmt.Sprintf("Failed to apply edit. Old string block not found/matched exactly. Expected block looked like:\n---\n%s\n---", diffText)
+				errorMsg = fmt.Sprintf("Failed to apply edit. Old string block not found/matched exactly. Expected block looked like:\n%s", render.Block(plain, diffText))
 			}
 			return errorMsg, nil
 
-		} else {
+		} else if index != -1 {
 			// Old string found, perform the replacement
-			modifiedContent = originalContent[:index] + args.NewString + originalContent[index+len(args.OldString):]
+			modifiedContent = scope[:index] + args.NewString + scope[index+len(args.OldString):]
 			replacementsMade = 1
 		}
 	}
@@ -126,14 +243,115 @@ func HandleEditBlock(ctx *server.Context, args EditBlockArgs) (string, error) {
 	// This check is slightly redundant now but kept as a safeguard
 	if replacementsMade == 0 && expected > 0 {
 		ctx.Logger.Info("Replacement logic failed unexpectedly", "filePath", args.FilePath)
-		return "Internal error during replacement.", nil
+		return i18n.T(ctx, i18n.InternalEditError), nil
 	}
 
+	modifiedContent = originalContent[:scopeStart] + modifiedContent + originalContent[scopeEnd:]
+	if lineEnding != "" {
+		modifiedContent = toLineEnding(modifiedContent, lineEnding)
+	}
+
+	if creating {
+		if err := createParentDirs(args.FilePath); err != nil {
+			ctx.Logger.Info("Error creating parent directories for edit_block", "filePath", args.FilePath, "error", err)
+			return i18n.T(ctx, i18n.FileWriteError), err
+		}
+	}
+
+	// Record the before image so the edit can be undone
+	pending := journal.Capture(ctx.Logger, args.FilePath, "edit_block")
+
 	// Write the modified content back to the file
 	if err := os.WriteFile(args.FilePath, []byte(modifiedContent), 0644); err != nil {
 		ctx.Logger.Info("Error writing file after edit_block", "filePath", args.FilePath, "error", err)
-		return "Error writing file after editing", err
+		return i18n.T(ctx, i18n.FileWriteError), err
 	}
+	formatted, note := format.AfterEdit(ctx, args.FilePath, []byte(modifiedContent), args.Format)
+	pending.Commit(formatted)
+
+	return resultMsg + appliedDiff(args.FilePath, originalContent, formatted, plain) + note, nil
+}
 
-	return "File edited successfully.", nil
+// createParentDirs creates the missing parent directories of path, for edits
+// with create_if_missing.
+func createParentDirs(path string) error {
+	return os.MkdirAll(filepath.Dir(path), 0755)
+}
+
+// maxListedOccurrences bounds the occurrences listed when edit_block is ambiguous.
+const maxListedOccurrences = 50
+
+// maxOccurrenceContext is the number of characters of a line shown for each occurrence.
+const maxOccurrenceContext = 80
+
+// ambiguousEdit returns the message refusing an edit whose old_string occurs
+// in content more often than expected. Every occurrence is listed with its line
+// number, offset by lineOffset, and the line it starts on, so the next call can
+// target one of them.
+func ambiguousEdit(ctx *server.Context, filePath, content, oldString string, expected, lineOffset int) string {
+	var list strings.Builder
+	count, line, lineStart, pos := 0, 1, 0, 0
+	for {
+		i := strings.Index(content[pos:], oldString)
+		if i == -1 {
+			break
+		}
+		at := pos + i
+		count++
+		if count <= maxListedOccurrences {
+			line += strings.Count(content[lineStart:at], "\n")
+			if nl := strings.LastIndexByte(content[:at], '\n'); nl != -1 {
+				lineStart = nl + 1
+			}
+			text, _, _ := strings.Cut(content[lineStart:], "\n")
+			text = strings.TrimSpace(text)
+			if runes := []rune(text); len(runes) > maxOccurrenceContext {
+				text = string(runes[:maxOccurrenceContext]) + "…"
+			}
+			if count > 1 {
+				list.WriteString("\n")
+			}
+			fmt.Fprintf(&list, "  line %d: %s", line+lineOffset, text)
+		}
+		pos = at + len(oldString)
+	}
+	if count > maxListedOccurrences {
+		list.WriteString(i18n.T(ctx, i18n.EditAmbiguousMore, count-maxListedOccurrences))
+	}
+	msg := i18n.T(ctx, i18n.EditAmbiguous, count, expected, list.String())
+	ctx.Logger.Info("Ambiguous old_string in edit_block", "filePath", filePath, "occurrences", count, "expected", expected)
+	return msg
+}
+
+// lineSpan returns the byte offsets of lines startLine..endLine (1-indexed,
+// inclusive) of content, including the line ending of endLine.
+func lineSpan(content string, startLine, endLine int) (int, int) {
+	from, line := 0, 1
+	for i := 0; i < len(content); i++ {
+		if content[i] != '\n' {
+			continue
+		}
+		line++
+		if line == startLine {
+			from = i + 1
+		}
+		if line > endLine {
+			return from, i + 1
+		}
+	}
+	return from, len(content)
+}
+
+// resultDiffContext is the number of unchanged lines shown around each change
+// in the diff returned by edit_block and precise_edit.
+const resultDiffContext = 2
+
+// appliedDiff renders what an edit actually wrote, so the client can check the
+// change without reading the file again. It is empty if nothing changed.
+func appliedDiff(path, before string, written []byte, plain bool) string {
+	diff := render.UnifiedDiff(path, before, string(written), resultDiffContext, plain)
+	if diff == "" {
+		return ""
+	}
+	return "\n" + diff
 }
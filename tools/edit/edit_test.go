@@ -0,0 +1,209 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestLineSpan(t *testing.T) {
+	content := "one\ntwo\r\nthree\nfour"
+	tests := []struct {
+		start, end int
+		want       string
+	}{
+		{1, 1, "one\n"},
+		{2, 3, "two\r\nthree\n"},
+		{3, 4, "three\nfour"},
+		{1, 4, content},
+	}
+	for _, tt := range tests {
+		from, to := lineSpan(content, tt.start, tt.end)
+		if got := content[from:to]; got != tt.want {
+			t.Errorf("lineSpan(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestEditBlockLineRange(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	original := "func a() {\n\treturn nil\n}\n\nfunc b() {\n\treturn nil\n}\n"
+	intPtr := func(n int) *int { return &n }
+	two := 2
+	ignore := true
+	tests := []struct {
+		name    string
+		args    EditBlockArgs
+		want    string
+		wantMsg string
+	}{
+		{
+			name: "second of two identical blocks",
+			args: EditBlockArgs{OldString: "\treturn nil", NewString: "\treturn errB", StartLine: intPtr(5)},
+			want: "func a() {\n\treturn nil\n}\n\nfunc b() {\n\treturn errB\n}\n",
+		},
+		{
+			name: "first block with end_line only",
+			args: EditBlockArgs{OldString: "\treturn nil\n}\n", NewString: "\treturn errA\n}\n", EndLine: intPtr(3)},
+			want: "func a() {\n\treturn errA\n}\n\nfunc b() {\n\treturn nil\n}\n",
+		},
+		{
+			name:    "expected replacements counted within the window",
+			args:    EditBlockArgs{OldString: "return nil", NewString: "return err", StartLine: intPtr(4), ExpectedReplacements: &two},
+			want:    original,
+			wantMsg: "Expected 2",
+		},
+		{
+			name:    "whitespace-insensitive match reports file line numbers",
+			args:    EditBlockArgs{OldString: "    return nil  ", NewString: "return err", StartLine: intPtr(2), IgnoreWhitespace: &ignore},
+			want:    original,
+			wantMsg: "2, 6",
+		},
+		{
+			name:    "not found outside the window",
+			args:    EditBlockArgs{OldString: "func b()", NewString: "func c()", StartLine: intPtr(1), EndLine: intPtr(3)},
+			want:    original,
+			wantMsg: "Failed to apply edit",
+		},
+		{
+			name:    "range past the end",
+			args:    EditBlockArgs{OldString: "return nil", NewString: "return err", StartLine: intPtr(5), EndLine: intPtr(9)},
+			want:    original,
+			wantMsg: "invalid range 5-9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "f.go")
+			if err := os.WriteFile(filePath, []byte(original), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tt.args.FilePath = filePath
+			msg, err := HandleEditBlock(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("HandleEditBlock failed: %v", err)
+			}
+			if tt.wantMsg != "" && !strings.Contains(msg, tt.wantMsg) {
+				t.Errorf("HandleEditBlock returned %q, want it to mention %q", msg, tt.wantMsg)
+			}
+			got, _ := os.ReadFile(filePath)
+			if string(got) != tt.want {
+				t.Errorf("File = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditBlockAmbiguous(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	original := "package x\n\nfunc a() error {\n\treturn nil\n}\n\nfunc b() error {\n\treturn nil\n}\n\nfunc c() error {\n\treturn nil\n}\n"
+	two, six := 2, 6
+	tests := []struct {
+		name string
+		args EditBlockArgs
+		want []string
+	}{
+		{
+			name: "default expects one",
+			args: EditBlockArgs{OldString: "return nil", NewString: "return err"},
+			want: []string{"occurs 3 times, but 1", "line 4: return nil", "line 8: return nil", "line 12: return nil"},
+		},
+		{
+			name: "more than expected",
+			args: EditBlockArgs{OldString: "return nil\n}", NewString: "return err\n}", ExpectedReplacements: &two},
+			want: []string{"occurs 3 times, but 2", "line 4:", "line 8:", "line 12:"},
+		},
+		{
+			name: "within a line range",
+			args: EditBlockArgs{OldString: "error {", NewString: "(err error) {", StartLine: &six},
+			want: []string{"occurs 2 times", "line 7: func b() error {", "line 11: func c() error {"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "x.go")
+			if err := os.WriteFile(filePath, []byte(original), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tt.args.FilePath = filePath
+			msg, err := HandleEditBlock(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("HandleEditBlock failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(msg, want) {
+					t.Errorf("HandleEditBlock returned %q, want it to mention %q", msg, want)
+				}
+			}
+			if got, _ := os.ReadFile(filePath); string(got) != original {
+				t.Errorf("Ambiguous edit changed the file to %q", got)
+			}
+		})
+	}
+}
+
+func TestAmbiguousEditListLimit(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	content := strings.Repeat("x = 1\n", maxListedOccurrences+5)
+	msg := ambiguousEdit(ctx, "f", content, "x = 1", 1, 0)
+	if strings.Count(msg, "  line ") != maxListedOccurrences || !strings.Contains(msg, "and 5 more") {
+		t.Errorf("ambiguousEdit listed %d occurrences: %q", strings.Count(msg, "  line "), msg)
+	}
+	if !strings.Contains(msg, "line 50: x = 1") || strings.Contains(msg, "line 51:") {
+		t.Errorf("ambiguousEdit = %q, want occurrences listed up to line 50", msg)
+	}
+}
+
+func TestEditToolsCreateIfMissing(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	create := true
+	dir := t.TempDir()
+
+	blockPath := filepath.Join(dir, "pkg", "users", "users.go")
+	msg, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: blockPath, OldString: "", NewString: "package users\n", CreateIfMissing: &create})
+	if err != nil || !strings.Contains(msg, "was created") {
+		t.Fatalf("edit_block with create_if_missing = %q, %v", msg, err)
+	}
+	if got, _ := os.ReadFile(blockPath); string(got) != "package users\n" {
+		t.Errorf("edit_block created %q", got)
+	}
+
+	precisePath := filepath.Join(dir, "pkg", "orders", "orders.go")
+	msg, err = HandlePreciseEdit(ctx, PreciseEditArgs{FilePath: precisePath, StartLine: 1, EndLine: 0, NewContent: "package orders\n", CreateIfMissing: &create})
+	if err != nil || !strings.Contains(msg, "was created") {
+		t.Fatalf("precise_edit with create_if_missing = %q, %v", msg, err)
+	}
+	if got, _ := os.ReadFile(precisePath); string(got) != "package orders\n" {
+		t.Errorf("precise_edit created %q", got)
+	}
+
+	// Edits that cannot apply to an empty file create nothing
+	missing := filepath.Join(dir, "none", "x.go")
+	if _, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: missing, OldString: "package x", NewString: "package y", CreateIfMissing: &create}); err != nil {
+		t.Fatalf("HandleEditBlock failed: %v", err)
+	}
+	if _, err := HandlePreciseEdit(ctx, PreciseEditArgs{FilePath: missing, StartLine: 2, EndLine: 2, NewContent: "x", CreateIfMissing: &create}); err != nil {
+		t.Fatalf("HandlePreciseEdit failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(missing)); !os.IsNotExist(err) {
+		t.Errorf("Failed edits created %s", filepath.Dir(missing))
+	}
+
+	// Without the option a missing file is still an error
+	if _, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: filepath.Join(dir, "y.go"), OldString: "", NewString: "x"}); !os.IsNotExist(err) {
+		t.Errorf("edit_block without create_if_missing returned %v, want a not-exist error", err)
+	}
+}
@@ -0,0 +1,92 @@
+package edit
+
+import (
+	"strings"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// ConvertLineEndingsArgs defines the arguments for the convert_line_endings tool.
+type ConvertLineEndingsArgs struct {
+	FilePath   string `json:"file_path" description:"The path to the file to convert." required:"true"`
+	LineEnding string `json:"line_ending" description:"The line ending to convert to: lf or crlf." required:"true"`
+}
+
+// detectLineEnding returns the dominant line ending of content. Ties, and
+// content without any line break, resolve to LF.
+func detectLineEnding(content string) string {
+	crlf := strings.Count(content, "\r\n")
+	lf := strings.Count(content, "\n") - crlf
+	if crlf > lf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// toLineEnding rewrites every line break in s to ending.
+func toLineEnding(s, ending string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if ending == "\r\n" {
+		s = strings.ReplaceAll(s, "\n", "\r\n")
+	}
+	return s
+}
+
+// parseLineEnding maps a line_ending argument to the ending it selects. An
+// empty result means auto: keep the file's dominant ending.
+func parseLineEnding(value *string) (string, bool) {
+	if value == nil {
+		return "", true
+	}
+	switch strings.ToLower(*value) {
+	case "", "auto":
+		return "", true
+	case "lf":
+		return "\n", true
+	case "crlf":
+		return "\r\n", true
+	}
+	return "", false
+}
+
+// lineEndingName returns the display name of ending.
+func lineEndingName(ending string) string {
+	if ending == "\r\n" {
+		return "CRLF"
+	}
+	return "LF"
+}
+
+// HandleConvertLineEndings implements the convert_line_endings tool.
+func HandleConvertLineEndings(ctx *server.Context, args ConvertLineEndingsArgs) (string, error) {
+	ctx.Logger.Info("Handling convert_line_endings tool call")
+
+	ending, ok := parseLineEnding(&args.LineEnding)
+	if !ok || ending == "" {
+		return i18n.T(ctx, i18n.LineEndingInvalid, args.LineEnding), nil
+	}
+
+	original, mode, msg, err := readEditableFile(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+
+	crlf := strings.Count(original, "\r\n")
+	changed := crlf
+	if ending == "\r\n" {
+		changed = strings.Count(original, "\n") - crlf
+	}
+	if changed == 0 {
+		return i18n.T(ctx, i18n.LineEndingsUnchanged, lineEndingName(ending)), nil
+	}
+
+	if err := writeEditedFile(ctx, args.FilePath, "convert_line_endings", toLineEnding(original, ending), mode); err != nil {
+		ctx.Logger.Info("Error writing file after convert_line_endings", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+
+	ctx.Logger.Info("Line endings converted", "filePath", args.FilePath, "lineEnding", lineEndingName(ending), "changed", changed)
+	return i18n.T(ctx, i18n.LineEndingsConverted, changed, lineEndingName(ending)), nil
+}
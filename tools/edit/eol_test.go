@@ -0,0 +1,107 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestDetectLineEnding(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"", "\n"},
+		{"no newline", "\n"},
+		{"a\nb\n", "\n"},
+		{"a\r\nb\r\n", "\r\n"},
+		{"a\r\nb\r\nc\n", "\r\n"},
+		{"a\r\nb\nc\n", "\n"},
+		{"a\r\nb\n", "\n"}, // ties resolve to LF
+	}
+	for _, tt := range tests {
+		if got := detectLineEnding(tt.content); got != tt.want {
+			t.Errorf("detectLineEnding(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestSplitLinesMixedEndings(t *testing.T) {
+	tests := []struct {
+		content  string
+		lines    []string
+		ending   string
+		trailing bool
+	}{
+		{"a\r\nb\nc\r\n", []string{"a", "b", "c"}, "\r\n", true},
+		{"a\nb\r\nc", []string{"a", "b", "c"}, "\n", false},
+		{"a\nb\r", []string{"a", "b\r"}, "\n", false},
+	}
+	for _, tt := range tests {
+		lines, ending, trailing := splitLines(tt.content)
+		if !reflect.DeepEqual(lines, tt.lines) || ending != tt.ending || trailing != tt.trailing {
+			t.Errorf("splitLines(%q) = %q, %q, %v; want %q, %q, %v", tt.content, lines, ending, trailing, tt.lines, tt.ending, tt.trailing)
+		}
+	}
+}
+
+func TestEditBlockLineEndings(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	lf := "lf"
+	tests := []struct {
+		name       string
+		original   string
+		oldString  string
+		newString  string
+		lineEnding *string
+		want       string
+	}{
+		{"lf arguments in crlf file", "a\r\nb\r\nc\r\n", "a\nb\n", "x\ny\nz\n", nil, "x\r\ny\r\nz\r\nc\r\n"},
+		{"crlf arguments in lf file", "a\nb\nc\n", "b\r\n", "x\r\n", nil, "a\nx\nc\n"},
+		{"override converts the whole file", "a\r\nb\r\n", "b", "x", &lf, "a\nx\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := newLinesTestFile(t, tt.original)
+			if _, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: filePath, OldString: tt.oldString, NewString: tt.newString, LineEnding: tt.lineEnding}); err != nil {
+				t.Fatalf("HandleEditBlock failed: %v", err)
+			}
+			got, _ := os.ReadFile(filePath)
+			if string(got) != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleConvertLineEndings(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	tests := []struct {
+		original string
+		ending   string
+		want     string
+	}{
+		{"a\nb\r\nc\n", "crlf", "a\r\nb\r\nc\r\n"},
+		{"a\r\nb\nc", "lf", "a\nb\nc"},
+		{"a\nb\n", "lf", "a\nb\n"},
+		{"a\nb\n", "cr", "a\nb\n"}, // rejected
+	}
+	for _, tt := range tests {
+		filePath := newLinesTestFile(t, tt.original)
+		if _, err := HandleConvertLineEndings(ctx, ConvertLineEndingsArgs{FilePath: filePath, LineEnding: tt.ending}); err != nil {
+			t.Fatalf("HandleConvertLineEndings(%q) failed: %v", tt.ending, err)
+		}
+		got, _ := os.ReadFile(filePath)
+		if string(got) != tt.want {
+			t.Errorf("convert %q to %s = %q, want %q", tt.original, tt.ending, got, tt.want)
+		}
+	}
+}
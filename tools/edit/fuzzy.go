@@ -0,0 +1,127 @@
+package edit
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const defaultFuzzyThreshold = 0.8 // Minimum similarity for fuzzy apply
+
+// maxFuzzyFileSize bounds the files searched by fuzzy_apply. Every candidate
+// window that survives the prefilter costs a full diff, so the search is only
+// attempted on files of ordinary source size.
+const maxFuzzyFileSize = 4 * 1024 * 1024
+
+// fuzzyMatch describes the best approximate location of a block within a file.
+type fuzzyMatch struct {
+	Start      int     // Byte offset of the matched block
+	End        int     // Byte offset just past the matched block
+	StartLine  int     // 1-indexed first line of the match
+	EndLine    int     // 1-indexed last line of the match
+	Similarity float64 // 1.0 means identical
+}
+
+// similarity returns a score between 0 and 1 based on the Levenshtein distance of a and b.
+func similarity(dmp *diffmatchpatch.DiffMatchPatch, a, b string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	distance := dmp.DiffLevenshtein(dmp.DiffMain(a, b, false))
+	return 1 - float64(distance)/float64(longest)
+}
+
+// byteHistogram counts the occurrences of each byte value.
+type byteHistogram [256]int
+
+func (h *byteHistogram) add(s string, delta int) {
+	for i := 0; i < len(s); i++ {
+		h[s[i]] += delta
+	}
+}
+
+// distanceBound returns a lower bound on the edit distance between the strings
+// counted by a and b: every insertion, deletion or substitution changes the
+// surplus of at most one byte value on each side.
+func distanceBound(a, b *byteHistogram) int {
+	surplusA, surplusB := 0, 0
+	for i := range a {
+		if d := a[i] - b[i]; d > 0 {
+			surplusA += d
+		} else {
+			surplusB -= d
+		}
+	}
+	return max(surplusA, surplusB)
+}
+
+// findFuzzyMatch slides a window of whole lines over content and returns the
+// window most similar to block. Windows one line shorter and longer than the
+// block are also considered so that a dropped or added line does not prevent a match.
+// Windows whose byte histogram shows they cannot reach minSimilarity, or cannot
+// beat the best window so far, are skipped without diffing. The returned match
+// may therefore be below minSimilarity only when no window reaches it.
+func findFuzzyMatch(content, block string, minSimilarity float64) (fuzzyMatch, bool) {
+	if block == "" || content == "" {
+		return fuzzyMatch{}, false
+	}
+
+	lineStarts := lineOffsets(content)
+	numLines := len(lineStarts) - 1
+
+	trailingNewline := strings.HasSuffix(block, "\n")
+	blockLines := strings.Count(strings.TrimSuffix(block, "\n"), "\n") + 1
+
+	dmp := diffmatchpatch.New()
+	best := fuzzyMatch{Similarity: -1}
+
+	var blockHist byteHistogram
+	blockHist.add(block, 1)
+
+	for window := max(1, blockLines-1); window <= blockLines+1; window++ {
+		if window > numLines {
+			break
+		}
+		var windowHist byteHistogram
+		windowHist.add(content[lineStarts[0]:lineStarts[window-1]], 1)
+
+		for first := 0; first+window <= numLines; first++ {
+			// Slide the histogram: drop the line that left, add the line that entered
+			if first > 0 {
+				windowHist.add(content[lineStarts[first-1]:lineStarts[first]], -1)
+			}
+			windowHist.add(content[lineStarts[first+window-1]:lineStarts[first+window]], 1)
+
+			start := lineStarts[first]
+			end := lineStarts[first+window]
+			// Only keep the trailing newline if the block itself ends with one
+			trimmed := !trailingNewline && end > start && content[end-1] == '\n'
+			if trimmed {
+				end--
+				windowHist['\n']--
+			}
+
+			longest := max(len(block), end-start)
+			bound := 1 - float64(distanceBound(&blockHist, &windowHist))/float64(longest)
+			if trimmed {
+				windowHist['\n']++
+			}
+			if bound < minSimilarity || bound <= best.Similarity {
+				continue
+			}
+
+			score := similarity(dmp, block, content[start:end])
+			if score > best.Similarity {
+				best = fuzzyMatch{
+					Start:      start,
+					End:        end,
+					StartLine:  first + 1,
+					EndLine:    first + window,
+					Similarity: score,
+				}
+			}
+		}
+	}
+	return best, best.Similarity >= 0
+}
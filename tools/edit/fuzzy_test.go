@@ -0,0 +1,143 @@
+package edit
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestFindFuzzyMatch(t *testing.T) {
+	content := "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}\n\nfunc c() {\n\tx++\n\treturn x.Value\n}\n"
+
+	tests := []struct {
+		name      string
+		block     string
+		threshold float64
+		wantOK    bool
+		wantStart int // 1-indexed first line
+		wantEnd   int // 1-indexed last line
+	}{
+		{"exact block", "func b() {\n\treturn 2\n}", 0.8, true, 5, 7},
+		{"typo in block", "func b() {\n\tretrun 2\n}", 0.8, true, 5, 7},
+		{"missing line", "func c() {\n\treturn x.Value\n}", 0.6, true, 9, 12},
+		{"extra line", "func b() {\n\t// note\n\treturn 2\n}", 0.7, true, 5, 7},
+		{"nothing similar", "completely unrelated text here", 0.8, false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, ok := findFuzzyMatch(content, tt.block, tt.threshold)
+			ok = ok && match.Similarity >= tt.threshold
+			if ok != tt.wantOK {
+				t.Fatalf("findFuzzyMatch ok = %v (similarity %.2f), want %v", ok, match.Similarity, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if match.StartLine != tt.wantStart || match.EndLine != tt.wantEnd {
+				t.Errorf("Matched lines %d-%d, want %d-%d", match.StartLine, match.EndLine, tt.wantStart, tt.wantEnd)
+			}
+			if strings.HasSuffix(tt.block, "\n") != strings.HasSuffix(content[match.Start:match.End], "\n") {
+				t.Errorf("Trailing newline of match %q does not follow the block", content[match.Start:match.End])
+			}
+		})
+	}
+}
+
+func TestFindFuzzyMatchPrefilterKeepsBest(t *testing.T) {
+	// The prefilter must never discard the window a full scan would choose
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sb, "line %d: value = compute(%d)\n", i, i*7)
+	}
+	content := sb.String()
+	block := "line 120: value = compute(840)\nline 121: valeu = compute(847)\n"
+
+	match, ok := findFuzzyMatch(content, block, 0)
+	if !ok {
+		t.Fatal("Expected a match")
+	}
+	if match.StartLine != 121 || match.EndLine != 122 {
+		t.Errorf("Matched lines %d-%d, want 121-122", match.StartLine, match.EndLine)
+	}
+}
+
+func TestFindFuzzyMatchLargeFile(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, "\tresult%d := process(input%d, options) // step %d\n", i, i, i)
+	}
+	content := sb.String()
+	block := "\tresult15000 := process(input15000, optoins) // step 15000\n\tresult15001 := process(input15001, options) // step 15001\n"
+
+	start := time.Now()
+	match, ok := findFuzzyMatch(content, block, defaultFuzzyThreshold)
+	elapsed := time.Since(start)
+	if !ok || match.StartLine != 15001 {
+		t.Errorf("Expected a match at line 15001, got %+v (ok=%v)", match, ok)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("findFuzzyMatch took %v on a %d byte file", elapsed, len(content))
+	}
+}
+
+func TestDistanceBound(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"kitten", "sitting"},
+		{"abc", "abc"},
+		{"", "abc"},
+		{"flaw", "lawn"},
+	}
+	dmp := diffmatchpatch.New()
+	for _, tt := range tests {
+		var ha, hb byteHistogram
+		ha.add(tt.a, 1)
+		hb.add(tt.b, 1)
+		bound := distanceBound(&ha, &hb)
+		actual := dmp.DiffLevenshtein(dmp.DiffMain(tt.a, tt.b, false))
+		if bound > actual {
+			t.Errorf("distanceBound(%q, %q) = %d exceeds the edit distance %d", tt.a, tt.b, bound, actual)
+		}
+	}
+}
+
+func TestEditBlockExpectedReplacementsWithFuzzy(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(filePath, []byte("line one here\nline two here\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	fuzzy := true
+
+	two := 2
+	result, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: filePath, OldString: "line tow here", NewString: "line TWO here", FuzzyApply: &fuzzy, ExpectedReplacements: &two})
+	if err != nil {
+		t.Fatalf("HandleEditBlock failed: %v", err)
+	}
+	if !strings.Contains(result, "cannot be combined") {
+		t.Errorf("Expected the combination to be rejected, got: %s", result)
+	}
+
+	// expected_replacements=1 is the default and keeps fuzzy matching enabled
+	one := 1
+	threshold := 0.7
+	if _, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: filePath, OldString: "line tow here", NewString: "line TWO here", FuzzyApply: &fuzzy, FuzzyThreshold: &threshold, ExpectedReplacements: &one}); err != nil {
+		t.Fatalf("HandleEditBlock failed: %v", err)
+	}
+	content, _ := os.ReadFile(filePath)
+	if string(content) != "line one here\nline TWO here\n" {
+		t.Errorf("Fuzzy edit with expected_replacements=1 gave %q", content)
+	}
+}
@@ -0,0 +1,174 @@
+package edit
+
+import (
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// AdjustIndentationArgs defines the arguments for the adjust_indentation tool.
+type AdjustIndentationArgs struct {
+	FilePath  string  `json:"file_path" description:"The path to the file to edit." required:"true"`
+	StartLine *int    `json:"start_line,omitempty" description:"Optional. The 1-indexed first line of the range (inclusive). Defaults to 1."`
+	EndLine   *int    `json:"end_line,omitempty" description:"Optional. The 1-indexed last line of the range (inclusive). Defaults to the last line."`
+	Convert   *string `json:"convert,omitempty" description:"Optional. Convert leading indentation to spaces or tabs."`
+	Shift     *int    `json:"shift,omitempty" description:"Optional. Indentation levels to shift the range by: positive moves right, negative moves left. Relative indentation is kept."`
+	TabWidth  *int    `json:"tab_width,omitempty" description:"Optional. Columns per tab, and per level in space-indented files whose level cannot be inferred. Defaults to 4."`
+	Plain     *bool   `json:"plain,omitempty" description:"Optional. If true, render the returned diff as plain text without symbols. Defaults to the plainOutput config value."`
+}
+
+// indentUnit returns one indentation level as used by lines: a tab when any
+// indented line starts with one, otherwise the greatest common divisor of the
+// space indents, or tabWidth spaces when there are none.
+func indentUnit(lines []string, tabWidth int) string {
+	unit := 0
+	for _, line := range lines {
+		indent := leadingIndent(line)
+		if indent == "" || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if indent[0] == '\t' {
+			return "\t"
+		}
+		n := len(indent)
+		for n != 0 {
+			unit, n = n, unit%n
+		}
+	}
+	if unit < 2 {
+		// A single space step is almost always alignment, not a level
+		unit = tabWidth
+	}
+	return strings.Repeat(" ", unit)
+}
+
+// convertIndent rewrites the leading whitespace of line using only spaces or,
+// for useTabs, as many tabs as fit followed by spaces.
+func convertIndent(line string, useTabs bool, tabWidth int) string {
+	indent := leadingIndent(line)
+	width := indentWidth(indent, tabWidth)
+	converted := strings.Repeat(" ", width)
+	if useTabs {
+		converted = strings.Repeat("\t", width/tabWidth) + strings.Repeat(" ", width%tabWidth)
+	}
+	return converted + line[len(indent):]
+}
+
+// shiftIndent adds levels of unit to line, or removes them when levels is
+// negative. It reports false when line has too little indentation to remove.
+func shiftIndent(line, unit string, levels, tabWidth int) (string, bool) {
+	if levels >= 0 {
+		return strings.Repeat(unit, levels) + line, true
+	}
+	indent := leadingIndent(line)
+	remove := strings.Repeat(unit, -levels)
+	if strings.HasPrefix(indent, remove) {
+		return line[len(remove):], true
+	}
+	// Mixed indentation: remove the equivalent width instead
+	width := indentWidth(indent, tabWidth) - indentWidth(remove, tabWidth)
+	if width < 0 {
+		return line, false
+	}
+	if unit == "\t" {
+		return strings.Repeat("\t", width/tabWidth) + strings.Repeat(" ", width%tabWidth) + line[len(indent):], true
+	}
+	return strings.Repeat(" ", width) + line[len(indent):], true
+}
+
+// HandleAdjustIndentation implements the adjust_indentation tool.
+func HandleAdjustIndentation(ctx *server.Context, args AdjustIndentationArgs) (string, error) {
+	ctx.Logger.Info("Handling adjust_indentation tool call")
+
+	convert := ""
+	if args.Convert != nil {
+		convert = strings.ToLower(strings.TrimSpace(*args.Convert))
+		if convert != "" && convert != "spaces" && convert != "tabs" {
+			return i18n.T(ctx, i18n.IndentInvalidConvert, *args.Convert), nil
+		}
+	}
+	shift := 0
+	if args.Shift != nil {
+		shift = *args.Shift
+	}
+	if convert == "" && shift == 0 {
+		return i18n.T(ctx, i18n.IndentNothingToDo), nil
+	}
+	tabWidth := defaultIndentWidth
+	if args.TabWidth != nil && *args.TabWidth > 0 {
+		tabWidth = *args.TabWidth
+	}
+
+	original, mode, msg, err := readEditableFile(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+
+	lines, lineEnding, trailingNewline := splitLines(original)
+	numLines := len(lines)
+	start, end := 1, numLines
+	if args.StartLine != nil {
+		start = *args.StartLine
+	}
+	if args.EndLine != nil {
+		end = *args.EndLine
+	}
+	if start < 1 || end < start || end > numLines {
+		msg := i18n.T(ctx, i18n.DeleteLineRange, start, end, numLines)
+		ctx.Logger.Info(msg)
+		return msg, nil
+	}
+
+	// Shift by the file's level so the range lines up with its surroundings
+	unit := indentUnit(lines, tabWidth)
+	switch convert {
+	case "spaces":
+		if unit == "\t" {
+			unit = strings.Repeat(" ", tabWidth)
+		}
+	case "tabs":
+		unit = "\t"
+	}
+
+	changed := 0
+	for i := start - 1; i < end; i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			continue // blank lines stay as they are
+		}
+		if convert != "" {
+			line = convertIndent(line, convert == "tabs", tabWidth)
+		}
+		if shift != 0 {
+			shifted, ok := shiftIndent(line, unit, shift, tabWidth)
+			if !ok {
+				return i18n.T(ctx, i18n.IndentShiftTooFar, i+1, -shift), nil
+			}
+			line = shifted
+		}
+		if line != lines[i] {
+			lines[i] = line
+			changed++
+		}
+	}
+
+	updated := strings.Join(lines, lineEnding)
+	if trailingNewline {
+		updated += lineEnding
+	}
+	if updated == original {
+		return i18n.T(ctx, i18n.TransformUnchanged, start, end), nil
+	}
+
+	if err := writeEditedFile(ctx, args.FilePath, "adjust_indentation", updated, mode); err != nil {
+		ctx.Logger.Info("Error writing file after adjust_indentation", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+
+	ctx.Logger.Info("Indentation adjusted", "filePath", args.FilePath, "start", start, "end", end, "changed", changed)
+	result := i18n.T(ctx, i18n.IndentAdjusted, start, end, changed)
+	return result + appliedDiff(args.FilePath, original, []byte(updated), render.Plain(ctx, args.Plain)), nil
+}
@@ -0,0 +1,103 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestIndentUnit(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"tabs", []string{"func f() {", "\treturn", "}"}, "\t"},
+		{"two spaces", []string{"a:", "  b:", "    c: 1"}, "  "},
+		{"four spaces", []string{"def f():", "    if x:", "        pass"}, "    "},
+		{"no indentation", []string{"a", "b"}, "    "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indentUnit(tt.lines, 4); got != tt.want {
+				t.Errorf("indentUnit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleAdjustIndentation(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	intPtr := func(i int) *int { return &i }
+	strPtr := func(s string) *string { return &s }
+	const python = "def f():\n    if x:\n        pass\n\n    return 1\n"
+
+	tests := []struct {
+		name     string
+		original string
+		args     AdjustIndentationArgs
+		want     string
+		wantMsg  string
+	}{
+		{
+			name: "shift right keeps relative indentation", original: python,
+			args:    AdjustIndentationArgs{StartLine: intPtr(2), EndLine: intPtr(3), Shift: intPtr(1)},
+			want:    "def f():\n        if x:\n            pass\n\n    return 1\n",
+			wantMsg: "Adjusted the indentation of lines 2-3: 2 line(s) changed.",
+		},
+		{
+			name: "shift left", original: python,
+			args: AdjustIndentationArgs{StartLine: intPtr(2), EndLine: intPtr(5), Shift: intPtr(-1)},
+			want: "def f():\nif x:\n    pass\n\nreturn 1\n",
+		},
+		{
+			name: "shift left too far is refused", original: python,
+			args:    AdjustIndentationArgs{Shift: intPtr(-1)},
+			want:    python,
+			wantMsg: "Line 1 is indented by less than 1 level(s)",
+		},
+		{
+			name: "spaces to tabs", original: python,
+			args: AdjustIndentationArgs{Convert: strPtr("tabs")},
+			want: "def f():\n\tif x:\n\t\tpass\n\n\treturn 1\n",
+		},
+		{
+			name: "tabs to two spaces", original: "func f() {\n\tif x {\n\t\treturn\n\t}\n}\n",
+			args: AdjustIndentationArgs{Convert: strPtr("spaces"), TabWidth: intPtr(2)},
+			want: "func f() {\n  if x {\n    return\n  }\n}\n",
+		},
+		{
+			name: "convert and shift use the new unit", original: "a\n\tb\n",
+			args: AdjustIndentationArgs{StartLine: intPtr(2), EndLine: intPtr(2), Convert: strPtr("spaces"), Shift: intPtr(1)},
+			want: "a\n        b\n",
+		},
+		{
+			name: "nothing to do", original: python,
+			args: AdjustIndentationArgs{}, want: python, wantMsg: "Specify convert, shift or both.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := newLinesTestFile(t, tt.original)
+			tt.args.FilePath = filePath
+			result, err := HandleAdjustIndentation(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("HandleAdjustIndentation failed: %v", err)
+			}
+			if !strings.Contains(result, tt.wantMsg) {
+				t.Errorf("result = %q, want it to contain %q", result, tt.wantMsg)
+			}
+			got, _ := os.ReadFile(filePath)
+			if string(got) != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package edit
+
+import (
+	"os"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// InsertAtLineArgs defines the arguments for the insert_at_line tool.
+type InsertAtLineArgs struct {
+	FilePath   string  `json:"file_path" description:"The path to the file to edit." required:"true"`
+	Line       int     `json:"line" description:"The 1-indexed line number the content is inserted before. Use the number of lines + 1 to append at the end of the file." required:"true"`
+	Content    string  `json:"content" description:"The content (potentially multi-line) to insert." required:"true"`
+	LineEnding *string `json:"line_ending,omitempty" description:"Optional. Line ending for the written file: auto (default) keeps the file's dominant ending, lf or crlf converts the whole file."`
+}
+
+// DeleteLinesArgs defines the arguments for the delete_lines tool.
+type DeleteLinesArgs struct {
+	FilePath  string `json:"file_path" description:"The path to the file to edit." required:"true"`
+	StartLine int    `json:"start_line" description:"The 1-indexed first line to delete (inclusive)." required:"true"`
+	EndLine   int    `json:"end_line" description:"The 1-indexed last line to delete (inclusive)." required:"true"`
+}
+
+// readEditableFile reads a file that is within the editing size limit.
+// The returned message is non-empty when the file cannot be edited.
+func readEditableFile(ctx *server.Context, filePath string) (string, os.FileMode, string, error) {
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			ctx.Logger.Info("File not found", "filePath", filePath)
+			return "", 0, i18n.T(ctx, i18n.FileNotFound), nil
+		}
+		ctx.Logger.Info("Error getting file info", "filePath", filePath, "error", err)
+		return "", 0, i18n.T(ctx, i18n.FileAccessError), err
+	}
+	if fileInfo.Size() > maxEditFileSize {
+		errorMsg := i18n.T(ctx, i18n.FileTooLarge, fileInfo.Size(), maxEditFileSize/(1024*1024))
+		ctx.Logger.Info(errorMsg)
+		return "", 0, errorMsg, nil
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		ctx.Logger.Info("Error reading file", "filePath", filePath, "error", err)
+		return "", 0, i18n.T(ctx, i18n.FileReadError), err
+	}
+	return string(content), fileInfo.Mode(), "", nil
+}
+
+// writeEditedFile writes content back to filePath, recording the before image in the edit journal.
+func writeEditedFile(ctx *server.Context, filePath, tool, content string, mode os.FileMode) error {
+	pending := journal.Capture(ctx.Logger, filePath, tool)
+	if err := os.WriteFile(filePath, []byte(content), mode); err != nil {
+		return err
+	}
+	pending.Commit([]byte(content))
+	return nil
+}
+
+// HandleInsertAtLine implements the insert_at_line tool.
+func HandleInsertAtLine(ctx *server.Context, args InsertAtLineArgs) (string, error) {
+	ctx.Logger.Info("Handling insert_at_line tool call")
+
+	lineEnding, ok := parseLineEnding(args.LineEnding)
+	if !ok {
+		return i18n.T(ctx, i18n.LineEndingInvalid, *args.LineEnding), nil
+	}
+
+	original, mode, msg, err := readEditableFile(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+
+	lines, _, _ := splitLines(original)
+	if args.Line < 1 || args.Line > len(lines)+1 {
+		msg := i18n.T(ctx, i18n.InsertLineRange, args.Line, len(lines)+1)
+		ctx.Logger.Info(msg)
+		return msg, nil
+	}
+
+	// Inserting empty content adds a single blank line
+	content := args.Content
+	if content == "" {
+		content = "\n"
+	}
+	updated, err := applyLineEdit(original, args.Line, args.Line-1, content)
+	if err != nil {
+		ctx.Logger.Info(err.Error())
+		return err.Error(), nil
+	}
+	if lineEnding != "" {
+		updated = toLineEnding(updated, lineEnding)
+	}
+
+	if err := writeEditedFile(ctx, args.FilePath, "insert_at_line", updated, mode); err != nil {
+		ctx.Logger.Info("Error writing file after insert_at_line", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+
+	ctx.Logger.Info("Lines inserted", "filePath", args.FilePath, "line", args.Line)
+	return i18n.T(ctx, i18n.FileEdited), nil
+}
+
+// HandleDeleteLines implements the delete_lines tool.
+func HandleDeleteLines(ctx *server.Context, args DeleteLinesArgs) (string, error) {
+	ctx.Logger.Info("Handling delete_lines tool call")
+
+	original, mode, msg, err := readEditableFile(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+
+	lines, _, _ := splitLines(original)
+	numLines := len(lines)
+	if args.StartLine < 1 || args.EndLine < args.StartLine || args.EndLine > numLines {
+		msg := i18n.T(ctx, i18n.DeleteLineRange, args.StartLine, args.EndLine, numLines)
+		ctx.Logger.Info(msg)
+		return msg, nil
+	}
+
+	updated, err := applyLineEdit(original, args.StartLine, args.EndLine, "")
+	if err != nil {
+		ctx.Logger.Info(err.Error())
+		return err.Error(), nil
+	}
+
+	if err := writeEditedFile(ctx, args.FilePath, "delete_lines", updated, mode); err != nil {
+		ctx.Logger.Info("Error writing file after delete_lines", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+
+	ctx.Logger.Info("Lines deleted", "filePath", args.FilePath, "start", args.StartLine, "end", args.EndLine)
+	return i18n.T(ctx, i18n.FileEdited), nil
+}
@@ -0,0 +1,115 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newLinesTestFile(t *testing.T, content string) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "lines.txt")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	return filePath
+}
+
+func TestHandleInsertAtLine(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	tests := []struct {
+		name     string
+		original string
+		line     int
+		content  string
+		want     string // Expected file content, or the original if the range is rejected
+		rejected bool
+	}{
+		{"insert at top", "a\nb\n", 1, "x", "x\na\nb\n", false},
+		{"insert in middle", "a\nb\n", 2, "x\ny\n", "a\nx\ny\nb\n", false},
+		{"append", "a\nb\n", 3, "x", "a\nb\nx\n", false},
+		{"append without trailing newline", "a\nb", 3, "x", "a\nb\nx", false},
+		{"crlf file", "a\r\nb\r\n", 2, "x\n", "a\r\nx\r\nb\r\n", false},
+		{"empty content inserts blank line", "a\n", 1, "", "\na\n", false},
+		{"into empty file", "", 1, "x", "x\n", false},
+		{"line zero", "a\n", 0, "x", "a\n", true},
+		{"past append position", "a\n", 3, "x", "a\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := newLinesTestFile(t, tt.original)
+			result, err := HandleInsertAtLine(ctx, InsertAtLineArgs{FilePath: filePath, Line: tt.line, Content: tt.content})
+			if err != nil {
+				t.Fatalf("HandleInsertAtLine failed: %v", err)
+			}
+			if rejected := strings.Contains(result, "must be between"); rejected != tt.rejected {
+				t.Errorf("rejected = %v, want %v (result %q)", rejected, tt.rejected, result)
+			}
+			got, _ := os.ReadFile(filePath)
+			if string(got) != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteLines(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	tests := []struct {
+		name       string
+		original   string
+		start, end int
+		want       string
+		rejected   bool
+	}{
+		{"delete first line", "a\nb\nc\n", 1, 1, "b\nc\n", false},
+		{"delete range", "a\nb\nc\n", 2, 3, "a\n", false},
+		{"delete everything", "a\nb\n", 1, 2, "", false},
+		{"keep missing trailing newline", "a\nb\nc", 1, 1, "b\nc", false},
+		{"crlf file", "a\r\nb\r\nc\r\n", 2, 2, "a\r\nc\r\n", false},
+		{"start zero", "a\nb\n", 0, 1, "a\nb\n", true},
+		{"end before start", "a\nb\n", 2, 1, "a\nb\n", true},
+		{"end past last line", "a\nb\n", 1, 3, "a\nb\n", true},
+		{"empty file", "", 1, 1, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := newLinesTestFile(t, tt.original)
+			result, err := HandleDeleteLines(ctx, DeleteLinesArgs{FilePath: filePath, StartLine: tt.start, EndLine: tt.end})
+			if err != nil {
+				t.Fatalf("HandleDeleteLines failed: %v", err)
+			}
+			if rejected := strings.Contains(result, "invalid range"); rejected != tt.rejected {
+				t.Errorf("rejected = %v, want %v (result %q)", rejected, tt.rejected, result)
+			}
+			got, _ := os.ReadFile(filePath)
+			if string(got) != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineToolsMissingFile(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	if result, _ := HandleInsertAtLine(ctx, InsertAtLineArgs{FilePath: missing, Line: 1, Content: "x"}); !strings.Contains(result, "not found") {
+		t.Errorf("insert_at_line on a missing file returned %q", result)
+	}
+	if result, _ := HandleDeleteLines(ctx, DeleteLinesArgs{FilePath: missing, StartLine: 1, EndLine: 1}); !strings.Contains(result, "not found") {
+		t.Errorf("delete_lines on a missing file returned %q", result)
+	}
+}
@@ -0,0 +1,236 @@
+package edit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// MergeFilesArgs defines the arguments for the merge_files tool.
+type MergeFilesArgs struct {
+	Base        string  `json:"base" description:"The common ancestor version of the file." required:"true"`
+	Ours        string  `json:"ours" description:"Our edited version of the file." required:"true"`
+	Theirs      string  `json:"theirs" description:"Their edited version of the file." required:"true"`
+	OutputPath  *string `json:"output_path,omitempty" description:"Optional. Where to write the merged result, which may be one of the inputs. If omitted, the merged content is returned instead."`
+	Style       *string `json:"style,omitempty" description:"Optional. Conflict style: merge (default) shows both sides, diff3 also shows the base version between them."`
+	OursLabel   *string `json:"ours_label,omitempty" description:"Optional. Label after <<<<<<< in conflict markers. Defaults to the ours file name."`
+	TheirsLabel *string `json:"theirs_label,omitempty" description:"Optional. Label after >>>>>>> in conflict markers. Defaults to the theirs file name."`
+}
+
+// lineChange replaces base lines [start, end) with lines. An insertion has
+// start == end.
+type lineChange struct {
+	start, end int
+	lines      []string
+}
+
+// splitKeepEnds splits content into lines that keep their line endings.
+func splitKeepEnds(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineChanges lists the changes that turn base into edited, by line.
+func lineChanges(base, edited string) []lineChange {
+	dmp := diffmatchpatch.New()
+	a, b, lineArray := dmp.DiffLinesToChars(base, edited)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lineArray)
+	editedLines := splitKeepEnds(edited)
+
+	var changes []lineChange
+	baseIdx, editedIdx := 0, 0
+	var current *lineChange
+	for _, d := range diffs {
+		n := len(splitKeepEnds(d.Text))
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			if current != nil {
+				changes = append(changes, *current)
+				current = nil
+			}
+			baseIdx += n
+			editedIdx += n
+		case diffmatchpatch.DiffDelete:
+			if current == nil {
+				current = &lineChange{start: baseIdx, end: baseIdx}
+			}
+			baseIdx += n
+			current.end = baseIdx
+		case diffmatchpatch.DiffInsert:
+			if current == nil {
+				current = &lineChange{start: baseIdx, end: baseIdx}
+			}
+			current.lines = append(current.lines, editedLines[editedIdx:editedIdx+n]...)
+			editedIdx += n
+		}
+	}
+	if current != nil {
+		changes = append(changes, *current)
+	}
+	return changes
+}
+
+// applyChanges rewrites base lines [start, end) with changes, which must all
+// lie within that range.
+func applyChanges(baseLines []string, start, end int, changes []lineChange) []string {
+	var out []string
+	pos := start
+	for _, c := range changes {
+		out = append(out, baseLines[pos:c.start]...)
+		out = append(out, c.lines...)
+		pos = c.end
+	}
+	return append(out, baseLines[pos:end]...)
+}
+
+// mergeResult is the outcome of a three-way merge.
+type mergeResult struct {
+	content   string
+	merged    int // regions changed on one side, or identically on both
+	conflicts int
+}
+
+// threeWayMerge merges the changes from base to ours and from base to theirs.
+// Changes that overlap or touch form one region; a region changed differently
+// on both sides becomes a conflict.
+func threeWayMerge(base, ours, theirs, oursLabel, theirsLabel string, diff3 bool) mergeResult {
+	baseLines := splitKeepEnds(base)
+	oursChanges := lineChanges(base, ours)
+	theirsChanges := lineChanges(base, theirs)
+
+	var b strings.Builder
+	var result mergeResult
+	pos, i, j := 0, 0, 0
+	for i < len(oursChanges) || j < len(theirsChanges) {
+		// Start a region at the earliest change, then absorb every change
+		// from either side that overlaps or touches it
+		var start int
+		if j >= len(theirsChanges) || (i < len(oursChanges) && oursChanges[i].start <= theirsChanges[j].start) {
+			start = oursChanges[i].start
+		} else {
+			start = theirsChanges[j].start
+		}
+		end := start
+		var oursRegion, theirsRegion []lineChange
+		for {
+			if i < len(oursChanges) && oursChanges[i].start <= end {
+				end = max(end, oursChanges[i].end)
+				oursRegion = append(oursRegion, oursChanges[i])
+				i++
+			} else if j < len(theirsChanges) && theirsChanges[j].start <= end {
+				end = max(end, theirsChanges[j].end)
+				theirsRegion = append(theirsRegion, theirsChanges[j])
+				j++
+			} else {
+				break
+			}
+		}
+
+		b.WriteString(strings.Join(baseLines[pos:start], ""))
+		pos = end
+		oursText := strings.Join(applyChanges(baseLines, start, end, oursRegion), "")
+		theirsText := strings.Join(applyChanges(baseLines, start, end, theirsRegion), "")
+		switch {
+		case theirsRegion == nil:
+			b.WriteString(oursText)
+		case oursRegion == nil:
+			b.WriteString(theirsText)
+		case oursText == theirsText:
+			b.WriteString(oursText)
+		default:
+			result.conflicts++
+			b.WriteString("<<<<<<< " + oursLabel + "\n")
+			b.WriteString(terminated(oursText))
+			if diff3 {
+				b.WriteString("||||||| base\n")
+				b.WriteString(terminated(strings.Join(baseLines[start:end], "")))
+			}
+			b.WriteString("=======\n")
+			b.WriteString(terminated(theirsText))
+			b.WriteString(">>>>>>> " + theirsLabel + "\n")
+			continue
+		}
+		result.merged++
+	}
+	b.WriteString(strings.Join(baseLines[pos:], ""))
+	result.content = b.String()
+	return result
+}
+
+// terminated ends non-empty text with a newline so a conflict marker after it
+// starts on its own line.
+func terminated(text string) string {
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		return text + "\n"
+	}
+	return text
+}
+
+// HandleMergeFiles implements the merge_files tool.
+func HandleMergeFiles(ctx *server.Context, args MergeFilesArgs) (string, error) {
+	ctx.Logger.Info("Handling merge_files tool call")
+
+	diff3 := false
+	if args.Style != nil {
+		switch strings.ToLower(*args.Style) {
+		case "", "merge":
+		case "diff3":
+			diff3 = true
+		default:
+			return i18n.T(ctx, i18n.MergeFilesInvalidStyle, *args.Style), nil
+		}
+	}
+
+	var contents [3]string
+	for k, path := range []string{args.Base, args.Ours, args.Theirs} {
+		content, _, msg, err := readEditableFile(ctx, path)
+		if msg != "" {
+			return msg, err
+		}
+		contents[k] = content
+	}
+
+	oursLabel, theirsLabel := filepath.Base(args.Ours), filepath.Base(args.Theirs)
+	if args.OursLabel != nil && *args.OursLabel != "" {
+		oursLabel = *args.OursLabel
+	}
+	if args.TheirsLabel != nil && *args.TheirsLabel != "" {
+		theirsLabel = *args.TheirsLabel
+	}
+	// Conflict markers follow the line ending the file already uses
+	lineEnding := detectLineEnding(contents[1])
+	result := threeWayMerge(toLineEnding(contents[0], "\n"), toLineEnding(contents[1], "\n"), toLineEnding(contents[2], "\n"), oursLabel, theirsLabel, diff3)
+	merged := toLineEnding(result.content, lineEnding)
+
+	summary := i18n.T(ctx, i18n.MergeFilesSummary, result.merged, result.conflicts)
+	if args.OutputPath == nil || *args.OutputPath == "" {
+		return summary + "\n\n" + merged, nil
+	}
+
+	outputPath := *args.OutputPath
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(args.Ours); err == nil {
+		mode = info.Mode()
+	}
+	pending := journal.Capture(ctx.Logger, outputPath, "merge_files")
+	if err := os.WriteFile(outputPath, []byte(merged), mode); err != nil {
+		ctx.Logger.Info("Error writing merge result", "filePath", outputPath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+	pending.Commit([]byte(merged))
+
+	ctx.Logger.Info("Files merged", "output", outputPath, "merged", result.merged, "conflicts", result.conflicts)
+	return summary + "\n" + i18n.T(ctx, i18n.MergeFilesWritten, outputPath), nil
+}
@@ -0,0 +1,108 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	const base = "a\nb\nc\nd\ne\n"
+
+	tests := []struct {
+		name          string
+		ours, theirs  string
+		diff3         bool
+		want          string
+		wantConflicts int
+	}{
+		{
+			name: "separate changes merge cleanly",
+			ours: "A\nb\nc\nd\ne\n", theirs: "a\nb\nc\nd\nE\n",
+			want: "A\nb\nc\nd\nE\n",
+		},
+		{
+			name: "identical change on both sides",
+			ours: "a\nB\nc\nd\ne\n", theirs: "a\nB\nc\nd\ne\n",
+			want: "a\nB\nc\nd\ne\n",
+		},
+		{
+			name: "insertions and deletions",
+			ours: "a\nb\nx\nc\nd\ne\n", theirs: "a\nb\nc\ne\n",
+			want: "a\nb\nx\nc\ne\n",
+		},
+		{
+			name: "conflicting change",
+			ours: "a\nb\nours\nd\ne\n", theirs: "a\nb\ntheirs\nd\ne\n",
+			want:          "a\nb\n<<<<<<< mine\nours\n=======\ntheirs\n>>>>>>> yours\nd\ne\n",
+			wantConflicts: 1,
+		},
+		{
+			name: "diff3 shows the base",
+			ours: "a\nb\nours\nd\ne\n", theirs: "a\nb\nd\ne\n", diff3: true,
+			want:          "a\nb\n<<<<<<< mine\nours\n||||||| base\nc\n=======\n>>>>>>> yours\nd\ne\n",
+			wantConflicts: 1,
+		},
+		{
+			name: "no trailing newline",
+			ours: "a\nb\nc\nd\nours", theirs: "a\nb\nc\nd\ntheirs",
+			want:          "a\nb\nc\nd\n<<<<<<< mine\nours\n=======\ntheirs\n>>>>>>> yours\n",
+			wantConflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := threeWayMerge(base, tt.ours, tt.theirs, "mine", "yours", tt.diff3)
+			if got.content != tt.want {
+				t.Errorf("content = %q, want %q", got.content, tt.want)
+			}
+			if got.conflicts != tt.wantConflicts {
+				t.Errorf("conflicts = %d, want %d", got.conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestHandleMergeFiles(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base := write("base.txt", "one\r\ntwo\r\nthree\r\n")
+	ours := write("ours.txt", "ONE\r\ntwo\r\nthree\r\n")
+	theirs := write("theirs.txt", "one\r\ntwo\r\nTHREE\r\n")
+
+	result, err := HandleMergeFiles(ctx, MergeFilesArgs{Base: base, Ours: ours, Theirs: theirs})
+	if err != nil {
+		t.Fatalf("HandleMergeFiles failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "\n\nONE\r\ntwo\r\nTHREE\r\n") || !strings.Contains(result, "Merged 2 change(s) cleanly; 0 conflict(s)") {
+		t.Errorf("result = %q", result)
+	}
+
+	theirs = write("theirs.txt", "uno\r\ntwo\r\nthree\r\n")
+	result, err = HandleMergeFiles(ctx, MergeFilesArgs{Base: base, Ours: ours, Theirs: theirs, OutputPath: &ours})
+	if err != nil {
+		t.Fatalf("HandleMergeFiles failed: %v", err)
+	}
+	if !strings.Contains(result, "1 conflict(s)") {
+		t.Errorf("result = %q", result)
+	}
+	got, _ := os.ReadFile(ours)
+	if want := "<<<<<<< ours.txt\r\nONE\r\n=======\r\nuno\r\n>>>>>>> theirs.txt\r\ntwo\r\nthree\r\n"; string(got) != want {
+		t.Errorf("merged file = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,356 @@
+package edit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// ApplyPatchInteractiveArgs defines the arguments for the apply_patch_interactive tool.
+type ApplyPatchInteractiveArgs struct {
+	Patch   string  `json:"patch" description:"A unified diff, as written by diff -u or git diff, changing one or more existing files." required:"true"`
+	BaseDir *string `json:"base_dir,omitempty" description:"Optional. The directory the patch's file names are relative to. Defaults to the server's working directory."`
+}
+
+// ApplyHunksArgs defines the arguments for the apply_hunks tool.
+type ApplyHunksArgs struct {
+	PatchID string `json:"patch_id" description:"The patch ID returned by apply_patch_interactive." required:"true"`
+	Hunks   []int  `json:"hunks" description:"The numbers of the hunks to apply, as listed by apply_patch_interactive. The others are left out." required:"true"`
+}
+
+// patchHunk is one @@ section of a unified diff.
+type patchHunk struct {
+	Number   int      // 1-indexed position in the patch
+	Path     string   // the file the hunk changes
+	Header   string   // the @@ line
+	OldStart int      // 1-indexed first line of the hunk in the original file
+	Old      []string // the lines the hunk expects: context and removed lines
+	New      []string // the lines it leaves: context and added lines
+	Text     string   // the hunk as it appeared in the patch
+}
+
+// pendingPatch is a parsed patch waiting for apply_hunks.
+type pendingPatch struct {
+	Hunks []patchHunk
+}
+
+// maxPendingPatches is how many parsed patches are kept; the oldest is
+// dropped when another is parsed.
+const maxPendingPatches = 20
+
+var patches = struct {
+	mu    sync.Mutex
+	next  int
+	byID  map[string]*pendingPatch
+	order []string
+}{byID: make(map[string]*pendingPatch)}
+
+// storePatch keeps p for apply_hunks and returns its ID.
+func storePatch(p *pendingPatch) string {
+	patches.mu.Lock()
+	defer patches.mu.Unlock()
+	patches.next++
+	id := "patch-" + strconv.Itoa(patches.next)
+	patches.byID[id] = p
+	patches.order = append(patches.order, id)
+	if len(patches.order) > maxPendingPatches {
+		delete(patches.byID, patches.order[0])
+		patches.order = patches.order[1:]
+	}
+	return id
+}
+
+// lookupPatch returns the patch stored under id, or nil.
+func lookupPatch(id string) *pendingPatch {
+	patches.mu.Lock()
+	defer patches.mu.Unlock()
+	return patches.byID[strings.TrimSpace(id)]
+}
+
+// forgetPatch drops the patch stored under id once its hunks are applied.
+func forgetPatch(id string) {
+	patches.mu.Lock()
+	defer patches.mu.Unlock()
+	id = strings.TrimSpace(id)
+	delete(patches.byID, id)
+	for i, stored := range patches.order {
+		if stored == id {
+			patches.order = append(patches.order[:i], patches.order[i+1:]...)
+			break
+		}
+	}
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parsePatch splits a unified diff into hunks. File names are joined to
+// baseDir after dropping git's a/ and b/ prefixes. Patches that create or
+// delete files are refused, as hunks can only be applied to existing files.
+func parsePatch(patch, baseDir string) ([]patchHunk, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	var hunks []patchHunk
+	var oldName, path string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			oldName = patchFileName(line[4:])
+		case strings.HasPrefix(line, "+++ "):
+			newName := patchFileName(line[4:])
+			if oldName == "/dev/null" || newName == "/dev/null" {
+				return nil, fmt.Errorf("line %d: creating or deleting files is not supported; use write_file or move_file", i+1)
+			}
+			if strings.HasPrefix(oldName, "a/") && strings.HasPrefix(newName, "b/") {
+				newName = newName[2:]
+			}
+			path = filepath.Join(baseDir, filepath.FromSlash(newName))
+		case strings.HasPrefix(line, "@@"):
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: malformed hunk header %q", i+1, line)
+			}
+			if path == "" {
+				return nil, fmt.Errorf("line %d: hunk before the ---/+++ file header", i+1)
+			}
+			h := patchHunk{Number: len(hunks) + 1, Path: path, Header: line}
+			h.OldStart, _ = strconv.Atoi(m[1])
+			oldCount, newCount := hunkCount(m[2]), hunkCount(m[4])
+			body := []string{line}
+			for oldSeen, newSeen := 0, 0; oldSeen < oldCount || newSeen < newCount; {
+				i++
+				if i >= len(lines) {
+					return nil, fmt.Errorf("hunk %q ends early", line)
+				}
+				l := lines[i]
+				body = append(body, l)
+				switch {
+				case strings.HasPrefix(l, "\\"):
+					// "\ No newline at end of file"
+				case strings.HasPrefix(l, "-"):
+					h.Old = append(h.Old, l[1:])
+					oldSeen++
+				case strings.HasPrefix(l, "+"):
+					h.New = append(h.New, l[1:])
+					newSeen++
+				case strings.HasPrefix(l, " ") || l == "":
+					text := strings.TrimPrefix(l, " ")
+					h.Old = append(h.Old, text)
+					h.New = append(h.New, text)
+					oldSeen++
+					newSeen++
+				default:
+					return nil, fmt.Errorf("line %d: unexpected %q in hunk %q", i+1, l, line)
+				}
+			}
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\\") {
+				i++
+				body = append(body, lines[i])
+			}
+			h.Text = strings.Join(body, "\n")
+			hunks = append(hunks, h)
+		}
+	}
+	return hunks, nil
+}
+
+// patchFileName returns the file name of a ---/+++ line without the
+// timestamp diff -u appends after a tab.
+func patchFileName(name string) string {
+	name, _, _ = strings.Cut(name, "\t")
+	return strings.TrimSpace(name)
+}
+
+// hunkCount parses the optional line count of a hunk header, which is 1 when omitted.
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// locateHunk returns the 0-indexed line of lines where h's old lines start,
+// trying want first and then the nearest other position. It returns -1 when
+// they are nowhere.
+func locateHunk(lines []string, h patchHunk, want int) int {
+	matchesAt := func(at int) bool {
+		if at < 0 || at+len(h.Old) > len(lines) {
+			return false
+		}
+		for i, l := range h.Old {
+			if lines[at+i] != l {
+				return false
+			}
+		}
+		return true
+	}
+	for d := 0; d <= len(lines); d++ {
+		if matchesAt(want - d) {
+			return want - d
+		}
+		if d > 0 && matchesAt(want+d) {
+			return want + d
+		}
+	}
+	return -1
+}
+
+// applyHunks applies hunks, all to the same file and in file order, to
+// lines. Each hunk is looked for where the patch says it is, shifted by the
+// lines the hunks before it added or removed. It returns the first hunk that
+// does not apply, if any.
+func applyHunks(lines []string, hunks []patchHunk) ([]string, *patchHunk) {
+	out := append([]string(nil), lines...)
+	delta := 0
+	for i, h := range hunks {
+		at := locateHunk(out, h, hunkLine(h)+delta)
+		if at < 0 {
+			return nil, &hunks[i]
+		}
+		out = append(out[:at], append(append([]string(nil), h.New...), out[at+len(h.Old):]...)...)
+		delta += len(h.New) - len(h.Old)
+	}
+	return out, nil
+}
+
+// hunkLine returns the 0-indexed line the patch places h at. A hunk that
+// only adds lines names the line they follow.
+func hunkLine(h patchHunk) int {
+	if len(h.Old) == 0 {
+		return h.OldStart
+	}
+	return h.OldStart - 1
+}
+
+// hunkStatus describes whether h applies to the current contents of its file.
+func hunkStatus(ctx *server.Context, h patchHunk) string {
+	content, err := os.ReadFile(h.Path)
+	if err != nil {
+		return i18n.T(ctx, i18n.PatchHunkFails, err.Error())
+	}
+	lines, _, _ := splitLines(string(content))
+	want := hunkLine(h)
+	switch at := locateHunk(lines, h, want); {
+	case at < 0:
+		return i18n.T(ctx, i18n.PatchHunkFails, i18n.T(ctx, i18n.PatchHunkMissing))
+	case at != want:
+		return i18n.T(ctx, i18n.PatchHunkOffset, at+1)
+	default:
+		return i18n.T(ctx, i18n.PatchHunkApplies)
+	}
+}
+
+// HandleApplyPatchInteractive implements the apply_patch_interactive tool. It
+// changes nothing: the patch's hunks are numbered and kept for apply_hunks.
+func HandleApplyPatchInteractive(ctx *server.Context, args ApplyPatchInteractiveArgs) (string, error) {
+	ctx.Logger.Info("Handling apply_patch_interactive tool call")
+
+	baseDir := ""
+	if args.BaseDir != nil {
+		baseDir = *args.BaseDir
+	}
+	hunks, err := parsePatch(args.Patch, baseDir)
+	if err != nil {
+		return i18n.T(ctx, i18n.PatchParseError, err.Error()), nil
+	}
+	if len(hunks) == 0 {
+		return i18n.T(ctx, i18n.PatchEmpty), nil
+	}
+
+	files := make(map[string]bool)
+	for _, h := range hunks {
+		files[h.Path] = true
+	}
+	id := storePatch(&pendingPatch{Hunks: hunks})
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(ctx, i18n.PatchHunks, id, len(hunks), len(files)))
+	for _, h := range hunks {
+		sb.WriteString(fmt.Sprintf("\n\n[%d] %s (%s)\n%s", h.Number, h.Path, hunkStatus(ctx, h), h.Text))
+	}
+	ctx.Logger.Info("Patch split into hunks", "patchId", id, "hunks", len(hunks), "files", len(files))
+	return sb.String(), nil
+}
+
+// HandleApplyHunks implements the apply_hunks tool. The selected hunks are
+// applied to every file they touch or to none of them. Once applied the patch
+// is forgotten; after a refusal another selection can be tried.
+func HandleApplyHunks(ctx *server.Context, args ApplyHunksArgs) (string, error) {
+	ctx.Logger.Info("Handling apply_hunks tool call", "patchId", args.PatchID, "hunks", args.Hunks)
+
+	p := lookupPatch(args.PatchID)
+	if p == nil {
+		return i18n.T(ctx, i18n.PatchNotFound, args.PatchID), nil
+	}
+	if len(args.Hunks) == 0 {
+		return i18n.T(ctx, i18n.PatchNoHunks), nil
+	}
+
+	// Group the selected hunks by file, in the order they appear in the patch
+	selected := make(map[int]bool)
+	for _, n := range args.Hunks {
+		if n < 1 || n > len(p.Hunks) {
+			return i18n.T(ctx, i18n.PatchUnknownHunk, args.PatchID, n, len(p.Hunks)), nil
+		}
+		selected[n-1] = true
+	}
+	numbers := make([]int, 0, len(selected))
+	for n := range selected {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	var order []string
+	byFile := make(map[string][]patchHunk)
+	for _, n := range numbers {
+		h := p.Hunks[n]
+		if _, ok := byFile[h.Path]; !ok {
+			order = append(order, h.Path)
+		}
+		byFile[h.Path] = append(byFile[h.Path], h)
+	}
+
+	// Stage every file, then write them together
+	var files []*StagedFile
+	for _, path := range order {
+		key, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			key, err = filepath.Abs(key)
+		}
+		if err != nil {
+			return i18n.T(ctx, i18n.EditsNotApplied, err.Error()), nil
+		}
+		original, mode, msg, err := readEditableFile(ctx, key)
+		if msg != "" {
+			return msg, err
+		}
+		hunks := byFile[path]
+		sort.SliceStable(hunks, func(i, j int) bool { return hunks[i].OldStart < hunks[j].OldStart })
+		lines, lineEnding, trailingNewline := splitLines(original)
+		updated, failed := applyHunks(lines, hunks)
+		if failed != nil {
+			return i18n.T(ctx, i18n.PatchConflict, failed.Number, path), nil
+		}
+		content := strings.Join(updated, lineEnding)
+		if trailingNewline && len(updated) > 0 {
+			content += lineEnding
+		}
+		files = append(files, &StagedFile{Path: key, Original: []byte(original), Content: content, Mode: mode})
+	}
+
+	if err := CommitStaged("apply_hunks", files); err != nil {
+		return commitFailure(ctx, "apply_hunks", err)
+	}
+	forgetPatch(args.PatchID)
+
+	ctx.Logger.Info("Hunks applied", "patchId", args.PatchID, "hunks", len(numbers), "files", len(files))
+	return i18n.T(ctx, i18n.PatchApplied, len(numbers), len(p.Hunks), args.PatchID, len(files)), nil
+}
@@ -0,0 +1,143 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+const twoHunkPatch = `diff --git a/notes.txt b/notes.txt
+--- a/notes.txt
++++ b/notes.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+@@ -8,2 +8,3 @@
+ eight
+ nine
++nine and a half
+`
+
+func TestParsePatch(t *testing.T) {
+	hunks, err := parsePatch(twoHunkPatch, "base")
+	if err != nil {
+		t.Fatalf("parsePatch failed: %v", err)
+	}
+	want := []patchHunk{
+		{Number: 1, Path: filepath.Join("base", "notes.txt"), Header: "@@ -1,3 +1,3 @@", OldStart: 1,
+			Old: []string{"one", "two", "three"}, New: []string{"one", "TWO", "three"},
+			Text: "@@ -1,3 +1,3 @@\n one\n-two\n+TWO\n three"},
+		{Number: 2, Path: filepath.Join("base", "notes.txt"), Header: "@@ -8,2 +8,3 @@", OldStart: 8,
+			Old: []string{"eight", "nine"}, New: []string{"eight", "nine", "nine and a half"},
+			Text: "@@ -8,2 +8,3 @@\n eight\n nine\n+nine and a half"},
+	}
+	if !reflect.DeepEqual(hunks, want) {
+		t.Errorf("parsePatch = %+v\nwant %+v", hunks, want)
+	}
+
+	errorCases := map[string]string{
+		"new file":       "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+x\n",
+		"bad header":     "--- a/x\n+++ b/x\n@@ -1 +1 @\n",
+		"no file header": "@@ -1 +1 @@\n-a\n+b\n",
+		"truncated":      "--- a/x\n+++ b/x\n@@ -1,3 +1,3 @@\n a\n",
+	}
+	for name, patch := range errorCases {
+		if _, err := parsePatch(patch, ""); err == nil {
+			t.Errorf("parsePatch accepted a patch with %s", name)
+		}
+	}
+
+	// diff -u names carry a timestamp; without a/ and b/ they are kept as is
+	hunks, err = parsePatch("--- x.txt\t2026-01-01\n+++ x.txt\t2026-01-02\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file\n", "")
+	if err != nil || len(hunks) != 1 || hunks[0].Path != "x.txt" || !reflect.DeepEqual(hunks[0].New, []string{"b"}) {
+		t.Errorf("parsePatch of a diff -u patch = %+v, %v", hunks, err)
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	hunks, _ := parsePatch(twoHunkPatch, "")
+	lines := []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+
+	// The file gained a line at the top; both hunks are found one line down
+	got, failed := applyHunks(lines, hunks)
+	want := []string{"zero", "one", "TWO", "three", "four", "five", "six", "seven", "eight", "nine", "nine and a half", "ten"}
+	if failed != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("applyHunks = %q, %v", got, failed)
+	}
+
+	got, failed = applyHunks(lines, hunks[1:])
+	if failed != nil || got[2] != "two" || got[10] != "nine and a half" {
+		t.Errorf("applyHunks of the second hunk only = %q, %v", got, failed)
+	}
+
+	if _, failed := applyHunks([]string{"one", "2", "three"}, hunks[:1]); failed == nil || failed.Number != 1 {
+		t.Errorf("applyHunks of a conflicting hunk reported %v", failed)
+	}
+}
+
+func TestHandleApplyHunks(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	original := "one\r\ntwo\r\nthree\r\nfour\r\nfive\r\nsix\r\nseven\r\neight\r\nnine\r\n"
+	if err := os.WriteFile(file, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	listing, err := HandleApplyPatchInteractive(ctx, ApplyPatchInteractiveArgs{Patch: twoHunkPatch, BaseDir: &dir})
+	if err != nil {
+		t.Fatalf("HandleApplyPatchInteractive failed: %v", err)
+	}
+	id := regexp.MustCompile(`patch-\d+`).FindString(listing)
+	if id == "" || !strings.Contains(listing, "[1] "+file+" (applies)") || !strings.Contains(listing, "+nine and a half") {
+		t.Fatalf("Unexpected listing:\n%s", listing)
+	}
+	if got, _ := os.ReadFile(file); string(got) != original {
+		t.Fatal("apply_patch_interactive changed the file")
+	}
+
+	msg, _ := HandleApplyHunks(ctx, ApplyHunksArgs{PatchID: id, Hunks: []int{3}})
+	if !strings.Contains(msg, "no hunk 3") {
+		t.Errorf("Unknown hunk returned %q", msg)
+	}
+
+	// The patch survives the bad selection and applies only the chosen hunk
+	msg, err = HandleApplyHunks(ctx, ApplyHunksArgs{PatchID: id, Hunks: []int{2}})
+	if err != nil || !strings.Contains(msg, "Applied 1 of 2 hunks") {
+		t.Fatalf("HandleApplyHunks = %q, %v", msg, err)
+	}
+	want := strings.Replace(original, "nine\r\n", "nine\r\nnine and a half\r\n", 1)
+	if got, _ := os.ReadFile(file); string(got) != want {
+		t.Errorf("File = %q, want %q", got, want)
+	}
+
+	msg, _ = HandleApplyHunks(ctx, ApplyHunksArgs{PatchID: id, Hunks: []int{1}})
+	if !strings.Contains(msg, "No pending patch") {
+		t.Errorf("An applied patch was kept: %q", msg)
+	}
+
+	// A hunk whose lines changed since the listing refuses the whole selection
+	listing, _ = HandleApplyPatchInteractive(ctx, ApplyPatchInteractiveArgs{Patch: twoHunkPatch, BaseDir: &dir})
+	id = regexp.MustCompile(`patch-\d+`).FindString(listing)
+	if err := os.WriteFile(file, []byte("one\ntwo!\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	msg, _ = HandleApplyHunks(ctx, ApplyHunksArgs{PatchID: id, Hunks: []int{1}})
+	if !strings.Contains(msg, "Hunk 1 no longer applies") {
+		t.Errorf("Conflicting hunk returned %q", msg)
+	}
+	if got, _ := os.ReadFile(file); string(got) != "one\ntwo!\nthree\n" {
+		t.Errorf("A conflicting selection changed the file to %q", got)
+	}
+}
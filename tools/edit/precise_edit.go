@@ -1,10 +1,16 @@
 package edit
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"gocreate/tools/format"
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+	"gocreate/tools/render"
+
 	"github.com/localrivet/gomcp/server"
 )
 
@@ -12,10 +18,14 @@ import (
 
 // Go structs for tool arguments - Updated for line-based editing
 type PreciseEditArgs struct {
-	FilePath   string `json:"file_path" description:"The path to the file to edit." required:"true"`
-	StartLine  int    `json:"start_line" description:"The 1-indexed line number where the edit begins (inclusive)." required:"true"`
-	EndLine    int    `json:"end_line" description:"The 1-indexed line number where the block to be replaced ends (inclusive). For insertion before start_line, use end_line = start_line - 1." required:"true"`
-	NewContent string `json:"new_content" description:"The new content (potentially multi-line) to insert or replace the specified lines with." required:"true"`
+	FilePath        string  `json:"file_path" description:"The path to the file to edit." required:"true"`
+	StartLine       int     `json:"start_line" description:"The 1-indexed line number where the edit begins (inclusive)." required:"true"`
+	EndLine         int     `json:"end_line" description:"The 1-indexed line number where the block to be replaced ends (inclusive). For insertion before start_line, use end_line = start_line - 1." required:"true"`
+	NewContent      string  `json:"new_content" description:"The new content (potentially multi-line) to insert or replace the specified lines with. A trailing newline ends the last line and does not add a blank line; an empty string deletes the lines." required:"true"`
+	LineEnding      *string `json:"line_ending,omitempty" description:"Optional. Line ending for the written file: auto (default) keeps the file's dominant ending, lf or crlf converts the whole file."`
+	Format          *bool   `json:"format,omitempty" description:"Optional. If true, run the formatter configured for the file's extension after the edit. Defaults to the formatOnEdit config value."`
+	Plain           *bool   `json:"plain,omitempty" description:"Optional. If true, render the returned diff as plain text without symbols. Defaults to the plainOutput config value."`
+	CreateIfMissing *bool   `json:"create_if_missing,omitempty" description:"Optional. If true and the file does not exist, treat it as empty and create it and its parent directories; use start_line 1 and end_line 0 to write new_content as its content. Defaults to false."`
 }
 
 // HandlePreciseEdit performs line-based editing on a file using the new API
@@ -24,16 +34,20 @@ func HandlePreciseEdit(ctx *server.Context, args PreciseEditArgs) (string, error
 
 	// --- Input Validation ---
 	if args.StartLine <= 0 {
-		msg := "start_line must be positive and 1-indexed"
+		msg := i18n.T(ctx, i18n.StartLinePositive)
 		ctx.Logger.Info(msg)
 		return msg, nil
 	}
 	// Allow end_line to be start_line - 1 for insertion
 	if args.EndLine < args.StartLine-1 {
-		msg := "end_line cannot be less than start_line - 1"
+		msg := i18n.T(ctx, i18n.EndLineBeforeStart)
 		ctx.Logger.Info(msg)
 		return msg, nil
 	}
+	lineEnding, ok := parseLineEnding(args.LineEnding)
+	if !ok {
+		return i18n.T(ctx, i18n.LineEndingInvalid, *args.LineEnding), nil
+	}
 
 	// --- File Size Check ---
 	fileInfo, err := os.Stat(args.FilePath)
@@ -41,20 +55,19 @@ func HandlePreciseEdit(ctx *server.Context, args PreciseEditArgs) (string, error
 
 	if err != nil && fileExists { // Handle stat errors only if file exists
 		ctx.Logger.Info("Error getting file info", "filePath", args.FilePath, "error", err)
-		return "Error accessing file information.", err
+		return i18n.T(ctx, i18n.FileAccessError), err
 	}
 
 	// Allow file not found only if inserting at the beginning of a new file
-	if !fileExists && !(args.StartLine == 1 && args.EndLine == 0) {
+	creating := !fileExists && args.CreateIfMissing != nil && *args.CreateIfMissing
+	if !fileExists && !creating && !(args.StartLine == 1 && args.EndLine == 0) {
 		ctx.Logger.Info("File does not exist and cannot perform edit", "filePath", args.FilePath)
-		return "Error: File not found.", nil
+		return i18n.T(ctx, i18n.FileNotFound), nil
 	}
 
-	// Check size only if the file exists
+	// Files over the limit are streamed instead of loaded
 	if fileExists && fileInfo.Size() > maxEditFileSize {
-		errorMsg := fmt.Sprintf("Error: File size (%d bytes) exceeds the %d MB limit for this editing tool due to memory constraints. Please use a different tool or method for editing very large files. If this is a source code file, consider splitting it into smaller modules/files if appropriate for the language.", fileInfo.Size(), maxEditFileSize/(1024*1024))
-		ctx.Logger.Info(errorMsg)
-		return errorMsg, nil
+		return streamPreciseEdit(ctx, args, lineEnding)
 	}
 	// --- End File Size Check ---
 
@@ -65,7 +78,7 @@ func HandlePreciseEdit(ctx *server.Context, args PreciseEditArgs) (string, error
 		if err != nil {
 			// This error should be less likely now after Stat, but handle anyway
 			ctx.Logger.Info("Error reading file for precise_edit", "filePath", args.FilePath, "error", err)
-			return "Error reading file for patching", err
+			return i18n.T(ctx, i18n.FileReadError), err
 		}
 	} else {
 		// File doesn't exist, but we are inserting at start
@@ -73,90 +86,18 @@ func HandlePreciseEdit(ctx *server.Context, args PreciseEditArgs) (string, error
 		contentBytes = []byte{} // Start with empty content
 	}
 
-	originalContent := string(contentBytes)
-	// Detect line endings, default to \n
-	lineEnding := "\n"
-	if strings.Contains(originalContent, "\r\n") {
-		lineEnding = "\r\n"
-	}
-	lines := strings.Split(originalContent, lineEnding)
-
-	// Handle potential trailing newline splitting issue
-	// If the file ends with a newline, Split leaves an empty string at the end.
-	if len(originalContent) > 0 && strings.HasSuffix(originalContent, lineEnding) && len(lines) > 0 {
-		// Keep the empty string unless the file was *only* the line ending(s)
-		if len(lines) == 1 && lines[0] == "" {
-			lines = []string{""} // File was empty or just newline(s)
-		}
-		// Otherwise, the empty string from split is handled correctly by len(lines) below
-	} else if originalContent == "" {
-		lines = []string{} // If the original file was completely empty
-	}
-
-	numLines := len(lines)
-	// Correct numLines if the split resulted in [""] for an empty file
-	if numLines == 1 && lines[0] == "" {
-		numLines = 0
-	}
-
-	// --- Line Number Validation ---
-	// Allow insertion *after* the last line
-	if args.StartLine > numLines+1 {
-		msg := fmt.Sprintf("start_line (%d) exceeds the number of lines (%d) + 1", args.StartLine, numLines)
+	// --- Construct New Content ---
+	finalContent, editErr := applyLineEdit(string(contentBytes), args.StartLine, args.EndLine, args.NewContent)
+	if editErr != nil {
+		msg := editErr.Error()
 		ctx.Logger.Info(msg)
 		return msg, nil
 	}
-	// EndLine must be within bounds or StartLine-1 for insertion
-	if args.EndLine > numLines || args.EndLine < args.StartLine-1 {
-		if !(args.EndLine == 0 && args.StartLine == 1 && numLines == 0) { // Allow insert into empty file
-			msg := fmt.Sprintf("end_line (%d) is out of bounds [0..%d] or invalid relative to start_line (%d)", args.EndLine, numLines, args.StartLine)
-			ctx.Logger.Info(msg)
-			return msg, nil
-		}
-	}
-
-	// --- Construct New Content ---
-	var newLines []string
-
-	// 1. Add lines before the start line (adjust index to 0-based)
-	startIdx := args.StartLine - 1
-	if startIdx > 0 && startIdx <= numLines { // Ensure startIdx is valid
-		newLines = append(newLines, lines[0:startIdx]...)
-	}
-
-	// 2. Add the new content (split if multi-line)
-	if args.NewContent != "" {
-		// Use the detected line ending for splitting NewContent as well
-		newContentLines := strings.Split(args.NewContent, lineEnding)
-		// Handle potential trailing newline in NewContent causing extra empty string
-		if len(args.NewContent) > 0 && strings.HasSuffix(args.NewContent, lineEnding) && len(newContentLines) > 0 {
-			// If NewContent ends with newline, Split adds an empty string. We usually want that empty string
-			// to represent the line break *after* the last line of actual content.
-			newLines = append(newLines, newContentLines...)
-		} else {
-			newLines = append(newLines, newContentLines...)
-		}
-	}
-
-	// 3. Add lines after the end line (adjust index to 0-based)
-	// endIdx is the line number *after* the last line to be replaced/skipped
-	endIdx := args.EndLine + 1
-	if endIdx <= numLines { // Check if endIdx is within the bounds of original lines
-		newLines = append(newLines, lines[endIdx-1:]...) // Add from the line *after* EndLine
+	if lineEnding != "" {
+		finalContent = toLineEnding(finalContent, lineEnding)
 	}
 
 	// --- Write File ---
-	// Join lines with the original line ending
-	finalContent := strings.Join(newLines, lineEnding)
-	// Ensure trailing newline if the original had one and the edit didn't remove the last line
-	// Or if the original was empty and new content was added.
-	if (len(originalContent) > 0 && strings.HasSuffix(originalContent, lineEnding) && endIdx <= numLines) ||
-		(len(originalContent) == 0 && len(finalContent) > 0) {
-		if !strings.HasSuffix(finalContent, lineEnding) {
-			finalContent += lineEnding
-		}
-	}
-
 	// Get original file info for permissions
 	fileMode := os.FileMode(0644) // Default permission
 	if fileExists {
@@ -168,12 +109,134 @@ func HandlePreciseEdit(ctx *server.Context, args PreciseEditArgs) (string, error
 		}
 	}
 
+	if creating {
+		if err := createParentDirs(args.FilePath); err != nil {
+			ctx.Logger.Info("Error creating parent directories for precise_edit", "filePath", args.FilePath, "error", err)
+			return i18n.T(ctx, i18n.FileWriteError), err
+		}
+	}
+
+	// Record the before image so the edit can be undone
+	pending := journal.Capture(ctx.Logger, args.FilePath, "precise_edit")
+
 	// Write the patched content back to the original file path (truncates existing)
 	if err := os.WriteFile(args.FilePath, []byte(finalContent), fileMode); err != nil {
 		ctx.Logger.Info("Error writing patched file", "filePath", args.FilePath, "error", err)
-		return "Error writing patched file", err
+		return i18n.T(ctx, i18n.FileWriteError), err
 	}
+	formatted, note := format.AfterEdit(ctx, args.FilePath, []byte(finalContent), args.Format)
+	pending.Commit(formatted)
 
 	ctx.Logger.Info("File edited successfully using precise_edit (in-memory)", "filePath", args.FilePath)
-	return "File edited successfully.", nil
+	result := i18n.T(ctx, i18n.FileEdited)
+	if creating {
+		result = i18n.T(ctx, i18n.FileCreatedByEdit, args.FilePath)
+	}
+	return result + appliedDiff(args.FilePath, string(contentBytes), formatted, render.Plain(ctx, args.Plain)) + note, nil
+}
+
+// streamPreciseEdit applies a precise_edit to a file above maxEditFileSize with
+// streamLineEdit. No diff is returned, the formatter is not run and the edit
+// cannot be undone.
+func streamPreciseEdit(ctx *server.Context, args PreciseEditArgs, lineEnding string) (string, error) {
+	ctx.Logger.Info("Streaming precise_edit for large file", "filePath", args.FilePath)
+	removed, err := streamLineEdit(args.FilePath, args.StartLine, args.EndLine, args.NewContent, lineEnding)
+	if err != nil {
+		var rangeErr *lineRangeError
+		if errors.As(err, &rangeErr) {
+			msg := err.Error()
+			ctx.Logger.Info(msg)
+			return msg, nil
+		}
+		ctx.Logger.Info("Error streaming precise_edit", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+	ctx.Logger.Info("File edited successfully using precise_edit (streamed)", "filePath", args.FilePath)
+	return i18n.T(ctx, i18n.StreamEditApplied, maxEditFileSize/(1024*1024), removed, len(contentLines(args.NewContent))), nil
+}
+
+// applyLineEdit replaces lines startLine..endLine (1-indexed, inclusive) of content with
+// newContent. An endLine of startLine-1 inserts before startLine without removing anything,
+// and an empty newContent deletes the range. A single trailing line ending on newContent is
+// treated as the terminator of its last line rather than an extra blank line. The file's line
+// ending (LF or CRLF) is applied to the inserted lines, and whether the file ends with a line
+// ending is preserved; content added to an empty file is terminated with a line ending.
+func applyLineEdit(content string, startLine, endLine int, newContent string) (string, error) {
+	lines, lineEnding, trailingNewline := splitLines(content)
+	numLines := len(lines)
+	if err := checkLineRange(startLine, endLine, numLines); err != nil {
+		return "", err
+	}
+	insertLines := contentLines(newContent)
+
+	newLines := make([]string, 0, numLines-(endLine-startLine+1)+len(insertLines))
+	newLines = append(newLines, lines[:startLine-1]...)
+	newLines = append(newLines, insertLines...)
+	newLines = append(newLines, lines[endLine:]...)
+
+	if len(newLines) == 0 {
+		return "", nil
+	}
+	result := strings.Join(newLines, lineEnding)
+	if trailingNewline {
+		result += lineEnding
+	}
+	return result, nil
+}
+
+// lineRangeError reports a precise_edit range that does not fit the file.
+type lineRangeError struct {
+	msg string
+}
+
+func (e *lineRangeError) Error() string { return e.msg }
+
+// checkLineRange validates a precise_edit range against a file of numLines lines.
+func checkLineRange(startLine, endLine, numLines int) error {
+	// Allow insertion *after* the last line
+	if startLine > numLines+1 {
+		return &lineRangeError{fmt.Sprintf("start_line (%d) exceeds the number of lines (%d) + 1", startLine, numLines)}
+	}
+	// EndLine must be within bounds or StartLine-1 for insertion
+	if endLine > numLines || endLine < startLine-1 {
+		return &lineRangeError{fmt.Sprintf("end_line (%d) is out of bounds [0..%d] or invalid relative to start_line (%d)", endLine, numLines, startLine)}
+	}
+	return nil
+}
+
+// contentLines splits new_content into the lines it inserts. A single trailing
+// line ending terminates the last line, and an empty string inserts nothing.
+func contentLines(newContent string) []string {
+	if newContent == "" {
+		return nil
+	}
+	normalized := strings.ReplaceAll(newContent, "\r\n", "\n")
+	normalized = strings.TrimSuffix(normalized, "\n")
+	return strings.Split(normalized, "\n")
+}
+
+// splitLines splits content into logical lines. Lines end at LF; a CR before it
+// belongs to the line ending, and lineEnding is the dominant one (see
+// detectLineEnding), so files with mixed endings are rejoined consistently. A
+// final line ending terminates the last line rather than starting a new one;
+// trailingNewline reports whether it was present, and is true for empty content
+// so that lines added to an empty file are terminated.
+func splitLines(content string) (lines []string, lineEnding string, trailingNewline bool) {
+	lineEnding = detectLineEnding(content)
+	if content == "" {
+		return nil, lineEnding, true
+	}
+	body := content
+	trailingNewline = strings.HasSuffix(body, "\n")
+	if trailingNewline {
+		body = body[:len(body)-1]
+	}
+	lines = strings.Split(body, "\n")
+	for i, line := range lines {
+		// A CR on an unterminated last line is content, not a line ending
+		if i < len(lines)-1 || trailingNewline {
+			lines[i] = strings.TrimSuffix(line, "\r")
+		}
+	}
+	return lines, lineEnding, trailingNewline
 }
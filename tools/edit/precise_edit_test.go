@@ -0,0 +1,254 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// lineEditCase is a randomly generated file and line-range edit.
+type lineEditCase struct {
+	Lines       []string // Original file lines, without line endings
+	CRLF        bool     // File uses CRLF line endings
+	Trailing    bool     // File ends with a line ending
+	Start       int      // 1-indexed start line of the edit
+	End         int      // 1-indexed inclusive end line (Start-1 for insertion)
+	NewLines    []string // Replacement lines (nil deletes the range)
+	NewCRLF     bool     // Replacement content uses CRLF line endings
+	NewTrailing bool     // Replacement content ends with a line ending
+}
+
+// randomLines returns up to max lines drawn from a small alphabet that includes
+// whitespace and empty lines, the usual sources of off-by-one mistakes.
+func randomLines(r *rand.Rand, max int) []string {
+	alphabet := []string{"", "a", "b", " ", "\t", "x y", "}"}
+	n := r.Intn(max + 1)
+	lines := make([]string, n)
+	for i := range lines {
+		var sb strings.Builder
+		for k := r.Intn(3); k >= 0; k-- {
+			sb.WriteString(alphabet[r.Intn(len(alphabet))])
+		}
+		lines[i] = sb.String()
+	}
+	return lines
+}
+
+// Generate implements quick.Generator so that only valid line ranges are produced.
+func (lineEditCase) Generate(r *rand.Rand, size int) reflect.Value {
+	c := lineEditCase{
+		Lines:       randomLines(r, 8),
+		CRLF:        r.Intn(2) == 0,
+		Trailing:    r.Intn(2) == 0,
+		NewLines:    randomLines(r, 4),
+		NewCRLF:     r.Intn(2) == 0,
+		NewTrailing: r.Intn(2) == 0,
+	}
+	// A final empty line can only be expressed with a trailing line ending
+	if n := len(c.Lines); n > 0 && c.Lines[n-1] == "" {
+		c.Trailing = true
+	}
+	if n := len(c.NewLines); n > 0 && c.NewLines[n-1] == "" {
+		c.NewTrailing = true
+	}
+	c.Start = 1 + r.Intn(len(c.Lines)+1)
+	c.End = c.Start - 1 + r.Intn(len(c.Lines)-c.Start+2)
+	return reflect.ValueOf(c)
+}
+
+// joinLines renders lines with the given line ending and optional final terminator.
+func joinLines(lines []string, ending string, trailing bool) string {
+	var sb strings.Builder
+	for i, line := range lines {
+		sb.WriteString(line)
+		if i < len(lines)-1 || trailing {
+			sb.WriteString(ending)
+		}
+	}
+	return sb.String()
+}
+
+func (c lineEditCase) content() string {
+	ending := "\n"
+	if c.CRLF {
+		ending = "\r\n"
+	}
+	return joinLines(c.Lines, ending, c.Trailing && len(c.Lines) > 0)
+}
+
+func (c lineEditCase) newContent() string {
+	ending := "\n"
+	if c.NewCRLF {
+		ending = "\r\n"
+	}
+	return joinLines(c.NewLines, ending, c.NewTrailing && len(c.NewLines) > 0)
+}
+
+// modelLineEdit is the reference implementation of precise_edit's line arithmetic.
+// It works on terminated lines built from the case itself rather than on the
+// rendered content, so it shares no splitting or joining logic with applyLineEdit.
+func modelLineEdit(c lineEditCase) string {
+	// The file's line ending is only observable when at least one line is terminated
+	ending := "\n"
+	if c.CRLF && (len(c.Lines) > 1 || (len(c.Lines) == 1 && c.Trailing)) {
+		ending = "\r\n"
+	}
+	terminated := func(lines []string) []string {
+		out := make([]string, len(lines))
+		for i, line := range lines {
+			out[i] = line + ending
+		}
+		return out
+	}
+
+	var units []string
+	units = append(units, terminated(c.Lines[:c.Start-1])...)
+	units = append(units, terminated(c.NewLines)...)
+	units = append(units, terminated(c.Lines[c.End:])...)
+	result := strings.Join(units, "")
+
+	// A file that did not end with a line ending still does not
+	if len(c.Lines) > 0 && !c.Trailing {
+		result = strings.TrimSuffix(result, ending)
+	}
+	return result
+}
+
+func TestApplyLineEditMatchesModel(t *testing.T) {
+	property := func(c lineEditCase) bool {
+		got, err := applyLineEdit(c.content(), c.Start, c.End, c.newContent())
+		if err != nil {
+			t.Logf("unexpected error for %+v: %v", c, err)
+			return false
+		}
+		if want := modelLineEdit(c); got != want {
+			t.Logf("case %+v\ngot:  %q\nwant: %q", c, got, want)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyLineEditInsertDeleteRoundTrip(t *testing.T) {
+	property := func(c lineEditCase) bool {
+		original := c.content()
+		inserted := append([]string(nil), c.NewLines...)
+		if len(inserted) == 0 {
+			inserted = []string{"inserted"}
+		}
+		// A file without a final line ending cannot end with an empty line, so an empty
+		// last line appended at the end of such a file is necessarily lost
+		appending := c.Start == len(c.Lines)+1 && len(c.Lines) > 0 && !c.Trailing
+		if appending && inserted[len(inserted)-1] == "" {
+			inserted[len(inserted)-1] = "inserted"
+		}
+		insertContent := strings.Join(inserted, "\n") + "\n"
+
+		afterInsert, err := applyLineEdit(original, c.Start, c.Start-1, insertContent)
+		if err != nil {
+			t.Logf("insert failed for %+v: %v", c, err)
+			return false
+		}
+		afterDelete, err := applyLineEdit(afterInsert, c.Start, c.Start+len(inserted)-1, "")
+		if err != nil {
+			t.Logf("delete failed for %+v: %v", c, err)
+			return false
+		}
+		if afterDelete != original {
+			t.Logf("case %+v\noriginal:     %q\nafter insert: %q\nafter delete: %q", c, original, afterInsert, afterDelete)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyLineEditRejectsInvalidRanges(t *testing.T) {
+	content := "one\ntwo\nthree\n"
+	tests := []struct {
+		name       string
+		start, end int
+	}{
+		{"start beyond end of file", 5, 4},
+		{"end beyond end of file", 2, 4},
+		{"end before insertion point", 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := applyLineEdit(content, tt.start, tt.end, "x"); err == nil {
+				t.Errorf("applyLineEdit(%d, %d) expected an error", tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestHandlePreciseEditCRLF(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "crlf.txt")
+	if err := os.WriteFile(filePath, []byte("one\r\ntwo\r\nthree\r\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	args := PreciseEditArgs{
+		FilePath:   filePath,
+		StartLine:  2,
+		EndLine:    2,
+		NewContent: "TWO\nand a half\n",
+	}
+	if _, err := HandlePreciseEdit(ctx, args); err != nil {
+		t.Fatalf("HandlePreciseEdit failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read edited file: %v", err)
+	}
+	if want := "one\r\nTWO\r\nand a half\r\nthree\r\n"; string(content) != want {
+		t.Errorf("edited content = %q, want %q", content, want)
+	}
+}
+
+func TestEditToolsReturnAppliedDiff(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	plain := false
+	content := "a\nb\nc\nd\ne\nf\ng\n"
+
+	filePath := filepath.Join(t.TempDir(), "diff.txt")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: filePath, OldString: "d\n", NewString: "D\n", Plain: &plain})
+	if err != nil {
+		t.Fatalf("HandleEditBlock failed: %v", err)
+	}
+	want := "--- " + filePath + "\n+++ " + filePath + "\n@@ -2,5 +2,5 @@\n b\n c\n-d\n+D\n e\n f"
+	if !strings.HasSuffix(result, want) {
+		t.Errorf("edit_block result does not end with the applied diff:\n%s\nwant suffix:\n%s", result, want)
+	}
+
+	result, err = HandlePreciseEdit(ctx, PreciseEditArgs{FilePath: filePath, StartLine: 1, EndLine: 1, NewContent: "A\n", Plain: &plain})
+	if err != nil {
+		t.Fatalf("HandlePreciseEdit failed: %v", err)
+	}
+	want = "@@ -1,3 +1,3 @@\n-a\n+A\n b\n c"
+	if !strings.HasSuffix(result, want) {
+		t.Errorf("precise_edit result does not end with the applied diff:\n%s\nwant suffix:\n%s", result, want)
+	}
+}
@@ -0,0 +1,169 @@
+package edit
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// streamBufferSize is the read and write buffer used by StreamEdit. Only the
+// current line is held beyond it.
+const streamBufferSize = 1024 * 1024
+
+// ErrNoChange is returned by a StreamEdit callback to discard the rewritten
+// copy and leave the file untouched. StreamEdit passes it back to the caller.
+var ErrNoChange = errors.New("no change")
+
+// LineWriter receives the lines written by a StreamEdit callback. Each line's
+// ending is only written once the next line follows it, so whether the output
+// ends with a line ending follows the input.
+type LineWriter struct {
+	w        *bufio.Writer
+	force    string // ending for every line, "" to keep each line's own
+	fallback string // ending for lines that have none of their own
+	pending  string // ending owed to the last line written
+	started  bool
+}
+
+// WriteLine appends text as a line ended by ending. An empty ending uses the
+// file's line ending, which is taken from its first line.
+func (lw *LineWriter) WriteLine(text, ending string) {
+	if lw.started {
+		lw.writeEnding(lw.pending)
+	}
+	lw.w.WriteString(text)
+	lw.pending = ending
+	lw.started = true
+}
+
+func (lw *LineWriter) writeEnding(ending string) {
+	switch {
+	case lw.force != "":
+		ending = lw.force
+	case ending == "":
+		ending = lw.fallback
+	}
+	lw.w.WriteString(ending)
+}
+
+// StreamEdit rewrites path one line at a time through a temporary file next
+// to it, which replaces the original once every line has been written, so
+// files above maxEditFileSize can be edited without loading them. line is
+// called for each line, numbered from 1, with its text and ending ("\n",
+// "\r\n", or "" for an unterminated last line), and writes its replacement to
+// out. tail is called once the input is exhausted with the number of lines
+// read. An error from either callback discards the copy and is returned. A
+// non-empty lineEnding rewrites every ending to it. Streamed edits are not
+// recorded in the edit journal, as that would need the whole before image.
+func StreamEdit(path, lineEnding string, line func(num int, text, ending string, out *LineWriter) error, tail func(numLines int, out *LineWriter) error) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	out := &LineWriter{w: bufio.NewWriterSize(tmp, streamBufferSize), force: lineEnding, fallback: "\n"}
+	r := bufio.NewReaderSize(in, streamBufferSize)
+	num := 0
+	trailingNewline := true // an empty file counts as terminated, as in splitLines
+	for {
+		raw, readErr := r.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if raw != "" {
+			num++
+			text, ending := raw, ""
+			if strings.HasSuffix(text, "\n") {
+				text, ending = text[:len(text)-1], "\n"
+				if strings.HasSuffix(text, "\r") {
+					text, ending = text[:len(text)-1], "\r\n"
+				}
+				if num == 1 {
+					out.fallback = ending
+				}
+			}
+			trailingNewline = ending != ""
+			if err := line(num, text, ending, out); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+	if err := tail(num, out); err != nil {
+		return err
+	}
+	if out.started && trailingNewline {
+		out.writeEnding(out.pending)
+	}
+
+	if err := out.w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// streamLineEdit applies the precise_edit semantics of applyLineEdit to path
+// with StreamEdit. It returns the number of lines removed.
+func streamLineEdit(path string, startLine, endLine int, newContent, lineEnding string) (int, error) {
+	insertLines := contentLines(newContent)
+	removed := 0
+	err := StreamEdit(path, lineEnding,
+		func(num int, text, ending string, out *LineWriter) error {
+			if num == startLine {
+				for _, l := range insertLines {
+					out.WriteLine(l, "")
+				}
+			}
+			if num >= startLine && num <= endLine {
+				removed++
+				return nil
+			}
+			out.WriteLine(text, ending)
+			return nil
+		},
+		func(numLines int, out *LineWriter) error {
+			if err := checkLineRange(startLine, endLine, numLines); err != nil {
+				return err
+			}
+			if startLine == numLines+1 {
+				for _, l := range insertLines {
+					out.WriteLine(l, "")
+				}
+			}
+			return nil
+		})
+	return removed, err
+}
@@ -0,0 +1,106 @@
+package edit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestStreamLineEditMatchesApplyLineEdit checks that streaming gives the same
+// result as the in-memory edit for the same range.
+func TestStreamLineEditMatchesApplyLineEdit(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		start, end int
+		newContent string
+	}{
+		{"replace middle line", "a\nb\nc\n", 2, 2, "x\ny\n"},
+		{"insert before first line", "a\nb\n", 1, 0, "x"},
+		{"append after last line", "a\nb\n", 3, 2, "x\n"},
+		{"append to unterminated file", "a\nb", 3, 2, "x"},
+		{"replace unterminated last line", "a\nb", 2, 2, "x\n"},
+		{"delete last lines", "a\nb\nc", 2, 3, ""},
+		{"delete everything", "a\nb\n", 1, 2, ""},
+		{"crlf file", "a\r\nb\r\n", 2, 2, "x\ny"},
+		{"start past the end", "a\n", 3, 2, "x"},
+		{"end past the end", "a\nb\n", 2, 3, "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "big.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			want, wantErr := applyLineEdit(tt.content, tt.start, tt.end, tt.newContent)
+
+			_, err := streamLineEdit(path, tt.start, tt.end, tt.newContent, "")
+			got, _ := os.ReadFile(path)
+			if wantErr != nil {
+				var rangeErr *lineRangeError
+				if !errors.As(err, &rangeErr) || err.Error() != wantErr.Error() {
+					t.Errorf("streamLineEdit error = %v, want %v", err, wantErr)
+				}
+				if string(got) != tt.content {
+					t.Errorf("File changed after a failed edit: %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("streamLineEdit failed: %v", err)
+			}
+			if string(got) != want {
+				t.Errorf("streamLineEdit = %q, want %q", got, want)
+			}
+			if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+				t.Errorf("Mode = %v, want 0600", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestStreamEditKeepsEachLineEnding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mixed.txt")
+	if err := os.WriteFile(path, []byte("a\r\nb\nc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := streamLineEdit(path, 2, 1, "x", "")
+	if err != nil || removed != 0 {
+		t.Fatalf("streamLineEdit = %d, %v", removed, err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "a\r\nx\r\nb\nc" {
+		t.Errorf("Content = %q", got)
+	}
+
+	if _, err := streamLineEdit(path, 1, 0, "", "\n"); err != nil {
+		t.Fatalf("streamLineEdit failed: %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "a\nx\nb\nc" {
+		t.Errorf("Content after converting to LF = %q", got)
+	}
+}
+
+func TestStreamEditNoChangeLeavesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := StreamEdit(path, "",
+		func(_ int, text, ending string, out *LineWriter) error {
+			out.WriteLine(text+"!", ending)
+			return nil
+		},
+		func(int, *LineWriter) error { return ErrNoChange })
+	if !errors.Is(err, ErrNoChange) {
+		t.Fatalf("StreamEdit error = %v, want ErrNoChange", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "a\n" {
+		t.Errorf("Content = %q", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Temporary file left behind: %v", entries)
+	}
+}
@@ -0,0 +1,173 @@
+package edit
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gocreate/tools/config"
+	"gocreate/tools/format"
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// InsertTemplateArgs defines the arguments for the insert_template tool.
+type InsertTemplateArgs struct {
+	Template  string            `json:"template" description:"The template name: its path in the templates directory without the .tmpl extension, e.g. license or go/handler." required:"true"`
+	FilePath  string            `json:"file_path" description:"The file to insert into, or to create when line is omitted." required:"true"`
+	Variables map[string]string `json:"variables,omitempty" description:"Optional. Values for the template's {{.name}} placeholders. year, date, file_name, file_stem and dir_name are predefined and may be overridden."`
+	Line      *int              `json:"line,omitempty" description:"Optional. The 1-indexed line the expansion is inserted before; use the number of lines + 1 to append. If omitted, the expansion is written as a new file."`
+	Overwrite *bool             `json:"overwrite,omitempty" description:"Optional. When writing a new file, replace an existing one. Defaults to false."`
+	Format    *bool             `json:"format,omitempty" description:"Optional. If true, run the formatter configured for the file's extension afterwards. Defaults to the formatOnEdit config value."`
+	Plain     *bool             `json:"plain,omitempty" description:"Optional. If true, render the returned diff as plain text without symbols. Defaults to the plainOutput config value."`
+}
+
+// templateExt is the extension of template files.
+const templateExt = ".tmpl"
+
+// templatePath returns the file of the named template in dir. It reports false
+// for names that would leave dir.
+func templatePath(dir, name string) (string, bool) {
+	name = filepath.FromSlash(strings.TrimSuffix(name, templateExt))
+	if name == "" || filepath.IsAbs(name) || !filepath.IsLocal(name) {
+		return "", false
+	}
+	return filepath.Join(dir, name+templateExt), true
+}
+
+// listTemplates returns the names of the templates in dir, sorted.
+func listTemplates(dir string) []string {
+	var names []string
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(p, templateExt) {
+			rel, _ := filepath.Rel(dir, p)
+			names = append(names, filepath.ToSlash(strings.TrimSuffix(rel, templateExt)))
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
+
+// templateVariables returns the predefined variables for a template expanded
+// into path, overridden by vars.
+func templateVariables(path string, vars map[string]string, now time.Time) map[string]string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	base := filepath.Base(path)
+	values := map[string]string{
+		"year":      now.Format("2006"),
+		"date":      now.Format("2006-01-02"),
+		"file_name": base,
+		"file_stem": strings.TrimSuffix(base, filepath.Ext(base)),
+		"dir_name":  filepath.Base(filepath.Dir(abs)),
+	}
+	for k, v := range vars {
+		values[k] = v
+	}
+	return values
+}
+
+// expandTemplate executes the template source with values. A placeholder
+// without a value is an error rather than an empty string.
+func expandTemplate(name, source string, values map[string]string) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, values); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// HandleInsertTemplate implements the insert_template tool.
+func HandleInsertTemplate(ctx *server.Context, args InsertTemplateArgs) (string, error) {
+	ctx.Logger.Info("Handling insert_template tool call")
+
+	cfg, _ := config.GetCurrentConfig(ctx)
+	dir, err := config.TemplatesDir(cfg)
+	if err != nil {
+		ctx.Logger.Info("Error locating templates directory", "error", err)
+		return i18n.T(ctx, i18n.FileAccessError), err
+	}
+	tmplPath, ok := templatePath(dir, args.Template)
+	if !ok {
+		return i18n.T(ctx, i18n.TemplateInvalidName, args.Template), nil
+	}
+	source, err := os.ReadFile(tmplPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			available := strings.Join(listTemplates(dir), ", ")
+			if available == "" {
+				available = "-"
+			}
+			return i18n.T(ctx, i18n.TemplateNotFound, args.Template, dir, available), nil
+		}
+		ctx.Logger.Info("Error reading template", "template", tmplPath, "error", err)
+		return i18n.T(ctx, i18n.FileReadError), err
+	}
+
+	expanded, err := expandTemplate(args.Template, string(source), templateVariables(args.FilePath, args.Variables, time.Now()))
+	if err != nil {
+		return i18n.T(ctx, i18n.TemplateExpandFailed, args.Template, err), nil
+	}
+	lineCount := len(contentLines(expanded))
+
+	if args.Line == nil {
+		// Write the expansion as a new file
+		if _, err := os.Stat(args.FilePath); err == nil && (args.Overwrite == nil || !*args.Overwrite) {
+			return i18n.T(ctx, i18n.TemplateFileExists, args.FilePath), nil
+		}
+		pending := journal.Capture(ctx.Logger, args.FilePath, "insert_template")
+		if err := os.WriteFile(args.FilePath, []byte(expanded), 0644); err != nil {
+			ctx.Logger.Info("Error writing template file", "filePath", args.FilePath, "error", err)
+			return i18n.T(ctx, i18n.FileWriteError), err
+		}
+		formatted, note := format.AfterEdit(ctx, args.FilePath, []byte(expanded), args.Format)
+		pending.Commit(formatted)
+
+		ctx.Logger.Info("Template written", "template", args.Template, "filePath", args.FilePath)
+		return i18n.T(ctx, i18n.TemplateWritten, args.Template, lineCount, args.FilePath) + note, nil
+	}
+
+	original, mode, msg, err := readEditableFile(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+	lines, _, _ := splitLines(original)
+	line := *args.Line
+	if line < 1 || line > len(lines)+1 {
+		msg := i18n.T(ctx, i18n.InsertLineRange, line, len(lines)+1)
+		ctx.Logger.Info(msg)
+		return msg, nil
+	}
+	updated, err := applyLineEdit(original, line, line-1, expanded)
+	if err != nil {
+		ctx.Logger.Info(err.Error())
+		return err.Error(), nil
+	}
+
+	pending := journal.Capture(ctx.Logger, args.FilePath, "insert_template")
+	if err := os.WriteFile(args.FilePath, []byte(updated), mode); err != nil {
+		ctx.Logger.Info("Error writing file after insert_template", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+	formatted, note := format.AfterEdit(ctx, args.FilePath, []byte(updated), args.Format)
+	pending.Commit(formatted)
+
+	ctx.Logger.Info("Template inserted", "template", args.Template, "filePath", args.FilePath, "line", line)
+	result := i18n.T(ctx, i18n.TemplateInserted, args.Template, lineCount, line, args.FilePath)
+	return result + appliedDiff(args.FilePath, original, formatted, render.Plain(ctx, args.Plain)) + note, nil
+}
@@ -0,0 +1,89 @@
+package edit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplatePathAndList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"license.tmpl", "go/handler.tmpl", "notes.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := strings.Join(listTemplates(dir), ","); got != "go/handler,license" {
+		t.Errorf("listTemplates = %q, want go/handler,license", got)
+	}
+
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"license", filepath.Join(dir, "license.tmpl"), true},
+		{"go/handler.tmpl", filepath.Join(dir, "go", "handler.tmpl"), true},
+		{"../secret", "", false},
+		{"/etc/passwd", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := templatePath(dir, tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("templatePath(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	now := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	values := templateVariables(filepath.Join("internal", "users", "handler.go"), map[string]string{"name": "GetUser", "year": "2020"}, now)
+
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "predefined and given variables",
+			source: "// Copyright {{.year}}\npackage {{.dir_name}}\n\n// {{.name}} is defined in {{.file_name}} ({{.file_stem}}), {{.date}}\n",
+			want:   "// Copyright 2020\npackage users\n\n// GetUser is defined in handler.go (handler), 2026-03-04\n",
+		},
+		{
+			name:    "missing variable",
+			source:  "func {{.name}}({{.receiver}})",
+			wantErr: `"receiver"`,
+		},
+		{
+			name:    "syntax error",
+			source:  "{{.name",
+			wantErr: "unclosed action",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandTemplate("t", tt.source, values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expandTemplate error = %v, want one mentioning %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandTemplate failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expandTemplate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// StagedFile holds the original and new contents of a file written by CommitStaged.
+// Path should be absolute and symlink-resolved so the rename replaces the target.
+type StagedFile struct {
+	Path     string
+	Original []byte
+	Content  string
+	Mode     os.FileMode
+	tempPath string
+}
+
+// StageError reports that a temporary file could not be written. No target was changed.
+type StageError struct {
+	Path string
+	Err  error
+}
+
+func (e *StageError) Error() string { return fmt.Sprintf("staging %s: %v", e.Path, e.Err) }
+func (e *StageError) Unwrap() error { return e.Err }
+
+// SwapError reports that renaming a temporary file into place failed. The files
+// swapped before it were restored from their original contents, except those
+// listed in RollbackFailed.
+type SwapError struct {
+	Path           string
+	Err            error
+	RollbackFailed []string
+}
+
+func (e *SwapError) Error() string { return fmt.Sprintf("replacing %s: %v", e.Path, e.Err) }
+func (e *SwapError) Unwrap() error { return e.Err }
+
+// RollbackSummary joins the files that could not be restored into one line.
+func (e *SwapError) RollbackSummary() string { return strings.Join(e.RollbackFailed, "; ") }
+
+// removeTempFiles deletes any temporary files created while staging.
+func removeTempFiles(files []*StagedFile) {
+	for _, sf := range files {
+		if sf.tempPath != "" {
+			_ = os.Remove(sf.tempPath)
+			sf.tempPath = ""
+		}
+	}
+}
+
+// CommitStaged writes every file to a temporary file next to its target and only
+// renames them into place once all have been written. If a rename fails, the files
+// already swapped are restored. Committed files are recorded in the edit journal under tool.
+func CommitStaged(tool string, files []*StagedFile) error {
+	// --- Write temporary files ---
+	for _, sf := range files {
+		tmp, err := os.CreateTemp(filepath.Dir(sf.Path), "."+filepath.Base(sf.Path)+".*.tmp")
+		if err != nil {
+			removeTempFiles(files)
+			return &StageError{Path: sf.Path, Err: err}
+		}
+		sf.tempPath = tmp.Name()
+		_, writeErr := tmp.WriteString(sf.Content)
+		closeErr := tmp.Close()
+		if writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr == nil {
+			writeErr = os.Chmod(sf.tempPath, sf.Mode)
+		}
+		if writeErr != nil {
+			removeTempFiles(files)
+			return &StageError{Path: sf.Path, Err: writeErr}
+		}
+	}
+
+	// --- Swap ---
+	for i, sf := range files {
+		if err := os.Rename(sf.tempPath, sf.Path); err != nil {
+			removeTempFiles(files[i:])
+			swapErr := &SwapError{Path: sf.Path, Err: err}
+			for _, done := range files[:i] {
+				if restoreErr := os.WriteFile(done.Path, done.Original, done.Mode); restoreErr != nil {
+					swapErr.RollbackFailed = append(swapErr.RollbackFailed, fmt.Sprintf("%s: %v", done.Path, restoreErr))
+				}
+			}
+			return swapErr
+		}
+		sf.tempPath = ""
+	}
+
+	for _, sf := range files {
+		journal.GetJournal().RecordContent(sf.Path, tool, sf.Original, []byte(sf.Content), sf.Mode)
+	}
+	return nil
+}
+
+// commitFailure returns the message and error a tool reports when
+// CommitStaged fails.
+func commitFailure(ctx *server.Context, tool string, err error) (string, error) {
+	var stageErr *StageError
+	var swapErr *SwapError
+	switch {
+	case errors.As(err, &stageErr):
+		ctx.Logger.Info("Error writing temporary file for "+tool, "filePath", stageErr.Path, "error", stageErr.Err)
+		return i18n.T(ctx, i18n.EditsStagingFailed, stageErr.Path), stageErr.Err
+	case errors.As(err, &swapErr) && len(swapErr.RollbackFailed) > 0:
+		ctx.Logger.Info("Error swapping file for "+tool+", rollback incomplete", "filePath", swapErr.Path, "error", swapErr.Err)
+		return i18n.T(ctx, i18n.EditsRollbackFailed, swapErr.Path, swapErr.RollbackSummary()), swapErr.Err
+	case errors.As(err, &swapErr):
+		ctx.Logger.Info("Error swapping file for "+tool+", rolled back", "filePath", swapErr.Path, "error", swapErr.Err)
+		return i18n.T(ctx, i18n.EditsRolledBack, swapErr.Path), swapErr.Err
+	default:
+		return err.Error(), err
+	}
+}
@@ -0,0 +1,142 @@
+package edit
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// TransformLinesArgs defines the arguments for the transform_lines tool.
+type TransformLinesArgs struct {
+	FilePath   string   `json:"file_path" description:"The path to the file to edit." required:"true"`
+	Operations []string `json:"operations" description:"Operations applied in order: sort, unique (drop repeated lines, keeping the first) or reverse. For example [\"sort\", \"unique\"]." required:"true"`
+	StartLine  *int     `json:"start_line,omitempty" description:"Optional. The 1-indexed first line of the range (inclusive). Defaults to 1."`
+	EndLine    *int     `json:"end_line,omitempty" description:"Optional. The 1-indexed last line of the range (inclusive). Defaults to the last line."`
+	IgnoreCase *bool    `json:"ignore_case,omitempty" description:"Optional. Compare lines case-insensitively when sorting and removing duplicates."`
+	Numeric    *bool    `json:"numeric,omitempty" description:"Optional. Sort by the number each line starts with; lines without one sort as 0."`
+	Plain      *bool    `json:"plain,omitempty" description:"Optional. If true, render the returned diff as plain text without symbols. Defaults to the plainOutput config value."`
+}
+
+// leadingNumber returns the number at the start of line, ignoring leading
+// whitespace, or 0 when there is none.
+func leadingNumber(line string) float64 {
+	line = strings.TrimSpace(line)
+	end := 0
+	for end < len(line) && strings.ContainsRune("+-.0123456789eE", rune(line[end])) {
+		end++
+	}
+	// Back off until the prefix parses, so "3-4" reads as 3
+	for ; end > 0; end-- {
+		if n, err := strconv.ParseFloat(line[:end], 64); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// transformLines applies operations to lines in order and returns the result
+// and the number of duplicates removed. Sorting is stable, so lines that
+// compare equal keep their relative order.
+func transformLines(lines []string, operations []string, ignoreCase, numeric bool) ([]string, int) {
+	key := func(s string) string {
+		if ignoreCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	out := append([]string(nil), lines...)
+	removed := 0
+	for _, op := range operations {
+		switch op {
+		case "sort":
+			sort.SliceStable(out, func(i, j int) bool {
+				if numeric {
+					if a, b := leadingNumber(out[i]), leadingNumber(out[j]); a != b {
+						return a < b
+					}
+				}
+				return key(out[i]) < key(out[j])
+			})
+		case "unique":
+			seen := make(map[string]bool, len(out))
+			kept := out[:0]
+			for _, line := range out {
+				if seen[key(line)] {
+					removed++
+					continue
+				}
+				seen[key(line)] = true
+				kept = append(kept, line)
+			}
+			out = kept
+		case "reverse":
+			for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+				out[i], out[j] = out[j], out[i]
+			}
+		}
+	}
+	return out, removed
+}
+
+// HandleTransformLines implements the transform_lines tool.
+func HandleTransformLines(ctx *server.Context, args TransformLinesArgs) (string, error) {
+	ctx.Logger.Info("Handling transform_lines tool call")
+
+	if len(args.Operations) == 0 {
+		return i18n.T(ctx, i18n.TransformNoOperations), nil
+	}
+	operations := make([]string, len(args.Operations))
+	for i, op := range args.Operations {
+		operations[i] = strings.ToLower(strings.TrimSpace(op))
+		switch operations[i] {
+		case "sort", "unique", "reverse":
+		default:
+			return i18n.T(ctx, i18n.TransformInvalidOperation, op), nil
+		}
+	}
+
+	original, mode, msg, err := readEditableFile(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+
+	lines, lineEnding, trailingNewline := splitLines(original)
+	numLines := len(lines)
+	start, end := 1, numLines
+	if args.StartLine != nil {
+		start = *args.StartLine
+	}
+	if args.EndLine != nil {
+		end = *args.EndLine
+	}
+	if start < 1 || end < start || end > numLines {
+		msg := i18n.T(ctx, i18n.DeleteLineRange, start, end, numLines)
+		ctx.Logger.Info(msg)
+		return msg, nil
+	}
+
+	transformed, removed := transformLines(lines[start-1:end], operations,
+		args.IgnoreCase != nil && *args.IgnoreCase, args.Numeric != nil && *args.Numeric)
+	newLines := append(append(append([]string(nil), lines[:start-1]...), transformed...), lines[end:]...)
+	updated := strings.Join(newLines, lineEnding)
+	if trailingNewline && len(newLines) > 0 {
+		updated += lineEnding
+	}
+	if updated == original {
+		return i18n.T(ctx, i18n.TransformUnchanged, start, end), nil
+	}
+
+	if err := writeEditedFile(ctx, args.FilePath, "transform_lines", updated, mode); err != nil {
+		ctx.Logger.Info("Error writing file after transform_lines", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+
+	ctx.Logger.Info("Lines transformed", "filePath", args.FilePath, "start", start, "end", end, "operations", operations)
+	result := i18n.T(ctx, i18n.TransformApplied, start, end, len(transformed), removed)
+	return result + appliedDiff(args.FilePath, original, []byte(updated), render.Plain(ctx, args.Plain)), nil
+}
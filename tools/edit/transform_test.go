@@ -0,0 +1,91 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestTransformLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		operations []string
+		ignoreCase bool
+		numeric    bool
+		want       []string
+		removed    int
+	}{
+		{"sort", []string{"b", "a", "C"}, []string{"sort"}, false, false, []string{"C", "a", "b"}, 0},
+		{"sort ignoring case", []string{"b", "a", "C"}, []string{"sort"}, true, false, []string{"a", "b", "C"}, 0},
+		{"numeric sort", []string{"10 ten", "9 nine", "x", "-1"}, []string{"sort"}, false, true, []string{"-1", "x", "9 nine", "10 ten"}, 0},
+		{"unique keeps first", []string{"b", "a", "b", "a"}, []string{"unique"}, false, false, []string{"b", "a"}, 2},
+		{"unique ignoring case", []string{"Go", "go", "GO"}, []string{"unique"}, true, false, []string{"Go"}, 2},
+		{"sort then unique", []string{"b", "a", "b"}, []string{"sort", "unique"}, false, false, []string{"a", "b"}, 1},
+		{"reverse", []string{"1", "2", "3"}, []string{"reverse"}, false, false, []string{"3", "2", "1"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := transformLines(tt.lines, tt.operations, tt.ignoreCase, tt.numeric)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || removed != tt.removed {
+				t.Errorf("transformLines() = %v, %d; want %v, %d", got, removed, tt.want, tt.removed)
+			}
+		})
+	}
+}
+
+func TestHandleTransformLines(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	intPtr := func(i int) *int { return &i }
+	const imports = "package main\r\n\r\nimport (\r\n\t\"os\"\r\n\t\"fmt\"\r\n\t\"os\"\r\n)\r\n"
+
+	tests := []struct {
+		name       string
+		operations []string
+		start, end *int
+		want       string
+		wantMsg    string
+	}{
+		{
+			name: "sort and dedupe a range", operations: []string{"sort", "unique"}, start: intPtr(4), end: intPtr(6),
+			want:    "package main\r\n\r\nimport (\r\n\t\"fmt\"\r\n\t\"os\"\r\n)\r\n",
+			wantMsg: "Transformed lines 4-6: 2 line(s) remain, 1 duplicate(s) removed.",
+		},
+		{
+			name: "nothing to change", operations: []string{"unique"}, start: intPtr(1), end: intPtr(3),
+			want: imports, wantMsg: "already match",
+		},
+		{
+			name: "range past the end", operations: []string{"sort"}, start: intPtr(5), end: intPtr(9),
+			want: imports, wantMsg: "invalid range 5-9",
+		},
+		{
+			name: "unknown operation", operations: []string{"shuffle"},
+			want: imports, wantMsg: `Unknown operation "shuffle"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := newLinesTestFile(t, imports)
+			result, err := HandleTransformLines(ctx, TransformLinesArgs{FilePath: filePath, Operations: tt.operations, StartLine: tt.start, EndLine: tt.end})
+			if err != nil {
+				t.Fatalf("HandleTransformLines failed: %v", err)
+			}
+			if !strings.Contains(result, tt.wantMsg) {
+				t.Errorf("result = %q, want it to contain %q", result, tt.wantMsg)
+			}
+			got, _ := os.ReadFile(filePath)
+			if string(got) != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package edit
+
+import (
+	"strings"
+)
+
+const defaultIndentWidth = 4 // Columns per indentation level when it cannot be inferred
+
+// lineOffsets returns the byte offset at which each line of content starts.
+// The final entry marks the end of content.
+func lineOffsets(content string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' && i+1 < len(content) {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return append(offsets, len(content))
+}
+
+// leadingIndent returns the run of spaces and tabs at the start of line.
+func leadingIndent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// whitespaceMatch is a block of lines in the file whose text equals old_string
+// once leading indentation and trailing whitespace are ignored.
+type whitespaceMatch struct {
+	Start     int      // Byte offset of the matched block
+	End       int      // Byte offset just past the matched block
+	StartLine int      // 1-indexed first line of the match
+	FileLines []string // Matched lines as they appear in the file
+}
+
+// findWhitespaceMatches returns every block of lines in content that matches
+// block when indentation and trailing whitespace are ignored.
+func findWhitespaceMatches(content, block string) []whitespaceMatch {
+	trailingNewline := strings.HasSuffix(block, "\n")
+	blockLines := strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+	for i := range blockLines {
+		blockLines[i] = strings.TrimSpace(blockLines[i])
+	}
+
+	offsets := lineOffsets(content)
+	numLines := len(offsets) - 1
+	lines := make([]string, numLines)
+	for i := 0; i < numLines; i++ {
+		lines[i] = strings.TrimSuffix(content[offsets[i]:offsets[i+1]], "\n")
+	}
+
+	var matches []whitespaceMatch
+	for first := 0; first+len(blockLines) <= numLines; first++ {
+		matched := true
+		for k, want := range blockLines {
+			if strings.TrimSpace(lines[first+k]) != want {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		start := offsets[first]
+		end := offsets[first+len(blockLines)]
+		if !trailingNewline {
+			end = start + len(strings.Join(lines[first:first+len(blockLines)], "\n"))
+			// Keep the line's carriage return when the file uses CRLF endings
+			if end > start && content[end-1] == '\r' && !strings.HasSuffix(strings.TrimSuffix(block, "\n"), "\r") {
+				end--
+			}
+		}
+		matches = append(matches, whitespaceMatch{
+			Start:     start,
+			End:       end,
+			StartLine: first + 1,
+			FileLines: lines[first : first+len(blockLines)],
+		})
+	}
+	return matches
+}
+
+// indentWidth returns the number of columns spanned by indent, counting tabs as width columns.
+func indentWidth(indent string, width int) int {
+	cols := 0
+	for _, c := range indent {
+		if c == '\t' {
+			cols += width
+		} else {
+			cols++
+		}
+	}
+	return cols
+}
+
+// reindentBlock rewrites the indentation of newString so that it follows the
+// indentation the file uses for the matched block rather than the indentation of oldString.
+func reindentBlock(newString, oldString string, fileLines []string) string {
+	oldLines := strings.Split(strings.TrimSuffix(oldString, "\n"), "\n")
+
+	// Map each indentation used in old_string to the indentation found in the file
+	mapping := make(map[string]string)
+	useTabs := false
+	for i, line := range oldLines {
+		if i >= len(fileLines) || strings.TrimSpace(line) == "" {
+			continue
+		}
+		fileIndent := leadingIndent(fileLines[i])
+		if strings.Contains(fileIndent, "\t") {
+			useTabs = true
+		}
+		if _, ok := mapping[leadingIndent(line)]; !ok {
+			mapping[leadingIndent(line)] = fileIndent
+		}
+	}
+
+	// Infer how many columns one indentation level spans in old_string
+	width := 0
+	baseWidth := -1
+	for _, line := range oldLines {
+		if strings.TrimSpace(line) == "" || strings.Contains(leadingIndent(line), "\t") {
+			continue
+		}
+		w := len(leadingIndent(line))
+		if baseWidth == -1 || w < baseWidth {
+			baseWidth = w
+		}
+	}
+	for _, line := range oldLines {
+		if strings.TrimSpace(line) == "" || strings.Contains(leadingIndent(line), "\t") {
+			continue
+		}
+		if diff := len(leadingIndent(line)) - baseWidth; diff > 0 && (width == 0 || diff < width) {
+			width = diff
+		}
+	}
+	if width == 0 {
+		width = defaultIndentWidth
+	}
+
+	newLines := strings.Split(newString, "\n")
+	for i, line := range newLines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := leadingIndent(line)
+		if mapped, ok := mapping[indent]; ok {
+			newLines[i] = mapped + line[len(indent):]
+			continue
+		}
+
+		// Use the longest known indentation that prefixes this one, then convert the remainder
+		bestKey := ""
+		found := false
+		for key := range mapping {
+			if strings.HasPrefix(indent, key) && (!found || len(key) > len(bestKey)) {
+				bestKey, found = key, true
+			}
+		}
+		if !found {
+			continue
+		}
+		remainder := indent[len(bestKey):]
+		cols := indentWidth(remainder, width)
+		if useTabs {
+			remainder = strings.Repeat("\t", cols/width) + strings.Repeat(" ", cols%width)
+		} else {
+			remainder = strings.Repeat(" ", cols)
+		}
+		newLines[i] = mapping[bestKey] + remainder + line[len(indent):]
+	}
+	return strings.Join(newLines, "\n")
+}
@@ -0,0 +1,69 @@
+package edit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestFindWhitespaceMatches(t *testing.T) {
+	content := "func a() {\n\tif x {\n\t\treturn 1\n\t}\n}\n"
+
+	matches := findWhitespaceMatches(content, "if x {\n    return 1\n}")
+	if len(matches) != 1 {
+		t.Fatalf("Expected 1 match, got %d", len(matches))
+	}
+	if matches[0].StartLine != 2 {
+		t.Errorf("Match starts at line %d, want 2", matches[0].StartLine)
+	}
+	if got := content[matches[0].Start:matches[0].End]; got != "\tif x {\n\t\treturn 1\n\t}" {
+		t.Errorf("Matched text %q", got)
+	}
+
+	if got := findWhitespaceMatches("a\n  b\na\n b\n", "a\nb"); len(got) != 2 {
+		t.Errorf("Expected 2 ambiguous matches, got %d", len(got))
+	}
+}
+
+func TestReindentBlock(t *testing.T) {
+	fileLines := []string{"\tif x {", "\t\treturn 1", "\t}"}
+	got := reindentBlock("if x {\n    return 2\n}", "if x {\n    return 1\n}", fileLines)
+	want := "\tif x {\n\t\treturn 2\n\t}"
+	if got != want {
+		t.Errorf("reindentBlock() = %q, want %q", got, want)
+	}
+}
+
+func TestEditBlockIgnoreWhitespaceWithExpectedReplacements(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(filePath, []byte("func a() {\n\treturn 1\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ignore := true
+
+	two := 2
+	result, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: filePath, OldString: "  return 1", NewString: "  return 2", IgnoreWhitespace: &ignore, ExpectedReplacements: &two})
+	if err != nil {
+		t.Fatalf("HandleEditBlock failed: %v", err)
+	}
+	if !strings.Contains(result, "cannot be combined") {
+		t.Errorf("Expected the combination to be rejected, got: %s", result)
+	}
+
+	one := 1
+	if _, err := HandleEditBlock(ctx, EditBlockArgs{FilePath: filePath, OldString: "  return 1", NewString: "  return 2", IgnoreWhitespace: &ignore, ExpectedReplacements: &one}); err != nil {
+		t.Fatalf("HandleEditBlock failed: %v", err)
+	}
+	content, _ := os.ReadFile(filePath)
+	if string(content) != "func a() {\n\treturn 2\n}\n" {
+		t.Errorf("Whitespace-insensitive edit with expected_replacements=1 gave %q", content)
+	}
+}
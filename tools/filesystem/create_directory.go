@@ -3,6 +3,8 @@ package filesystem
 import (
 	"os"
 
+	"gocreate/tools/i18n"
+
 	"github.com/localrivet/gomcp/server"
 )
 
@@ -18,8 +20,8 @@ func HandleCreateDirectory(ctx *server.Context, args CreateDirectoryArgs) (strin
 	// Create the directory and any necessary parent directories. 0755 is a common permission for directories.
 	if err := os.MkdirAll(args.Path, 0755); err != nil {
 		ctx.Logger.Info("Error creating directory", "path", args.Path, "error", err)
-		return "Error creating directory", err
+		return i18n.T(ctx, i18n.DirectoryCreateError), err
 	}
 
-	return "Directory created successfully.", nil
+	return i18n.T(ctx, i18n.DirectoryCreated), nil
 }
@@ -0,0 +1,296 @@
+package filesystem
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+const (
+	// copyBufferSize is the chunk size used when streaming file contents.
+	copyBufferSize = 1 << 20
+	// progressInterval is how many bytes are copied between progress notifications.
+	progressInterval = 8 << 20
+
+	// maxRemainingEntries caps how many leftover source entries are reported.
+	maxRemainingEntries = 20
+)
+
+// isCrossDevice reports whether a rename failed because source and destination
+// live on different filesystems.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV) || isNotSameDevice(err)
+}
+
+// sourceRemovalError reports that a move copied and verified the destination but
+// could not delete all of the source afterwards.
+type sourceRemovalError struct {
+	Remaining []string
+	Err       error
+}
+
+func (e *sourceRemovalError) Error() string {
+	return fmt.Sprintf("copy verified but removing the source failed: %v", e.Err)
+}
+
+func (e *sourceRemovalError) Unwrap() error { return e.Err }
+
+// remainingEntries lists up to maxRemainingEntries paths still present under root.
+func remainingEntries(root string) []string {
+	var remaining []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if len(remaining) == maxRemainingEntries {
+			remaining = append(remaining, "...")
+			return filepath.SkipAll
+		}
+		remaining = append(remaining, path)
+		return nil
+	})
+	return remaining
+}
+
+// moveProgress tracks bytes copied during a cross-device move and reports them
+// to the client when it supplied a progress token. A nil ctx disables reporting.
+type moveProgress struct {
+	ctx      *server.Context
+	total    int64
+	copied   int64
+	reported int64
+}
+
+func (p *moveProgress) add(n int64) {
+	p.copied += n
+	if p.copied-p.reported < progressInterval && p.copied != p.total {
+		return
+	}
+	p.reported = p.copied
+	if p.ctx == nil || !p.ctx.HasProgressToken() {
+		return
+	}
+	total := float64(p.total)
+	msg := fmt.Sprintf("Copied %d of %d bytes", p.copied, p.total)
+	if err := p.ctx.SendProgress(float64(p.copied), &total, msg); err != nil {
+		p.ctx.Logger.Info("Warning: Could not send progress notification", "error", err)
+	}
+}
+
+// moveAcrossDevices moves src to dst by copying, verifying the copy and only
+// then deleting the source. Permissions and modification times are preserved.
+// If anything fails before verification completes, the partial destination is
+// removed and the source is left untouched. If the source cannot be removed
+// afterwards, a *sourceRemovalError lists what remains.
+func moveAcrossDevices(ctx *server.Context, src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if _, err := os.Lstat(dst); err == nil {
+			return fmt.Errorf("destination %s already exists", dst)
+		}
+	}
+
+	total, err := treeSize(src)
+	if err != nil {
+		return err
+	}
+	progress := &moveProgress{ctx: ctx, total: total}
+
+	if err := copyTree(src, dst, progress); err != nil {
+		if info.IsDir() {
+			os.RemoveAll(dst)
+		}
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	if err := verifyTree(src, dst); err != nil {
+		if info.IsDir() {
+			os.RemoveAll(dst)
+		} else {
+			os.Remove(dst)
+		}
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	if err := os.RemoveAll(src); err != nil {
+		return &sourceRemovalError{Remaining: remainingEntries(src), Err: err}
+	}
+	return nil
+}
+
+// treeSize returns the total size in bytes of the regular files under root.
+func treeSize(root string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// copyTree copies src to dst, recursing into directories. Directory
+// modification times are restored after their contents have been written.
+func copyTree(src, dst string, progress *moveProgress) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()|0700); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyTree(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), progress); err != nil {
+				return err
+			}
+		}
+		if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+	case info.Mode().IsRegular():
+		return copyFile(src, dst, info, progress)
+	default:
+		return fmt.Errorf("cannot move special file %s", src)
+	}
+}
+
+// copyFile copies a regular file through a temporary file in the destination
+// directory so a partially written file never appears under the final name.
+func copyFile(src, dst string, info os.FileInfo, progress *moveProgress) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".move-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	buf := make([]byte, copyBufferSize)
+	for {
+		n, readErr := in.Read(buf)
+		if n > 0 {
+			if _, err := tmp.Write(buf[:n]); err != nil {
+				tmp.Close()
+				return err
+			}
+			progress.add(int64(n))
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			tmp.Close()
+			return readErr
+		}
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmpPath, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dst)
+}
+
+// verifyTree checks that every regular file under src has an identical copy
+// under dst, comparing sizes first and then SHA-256 digests.
+func verifyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		srcInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		dstInfo, err := os.Stat(target)
+		if err != nil {
+			return err
+		}
+		if srcInfo.Size() != dstInfo.Size() {
+			return fmt.Errorf("%s: size mismatch (%d != %d)", target, dstInfo.Size(), srcInfo.Size())
+		}
+
+		srcSum, err := fileDigest(path)
+		if err != nil {
+			return err
+		}
+		dstSum, err := fileDigest(target)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(srcSum, dstSum) {
+			return fmt.Errorf("%s: checksum mismatch", target)
+		}
+		return nil
+	})
+}
+
+// fileDigest returns the SHA-256 digest of the file at path.
+func fileDigest(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package filesystem
+
+// isNotSameDevice reports whether err is the Windows cross-volume rename error;
+// other platforms report it as EXDEV.
+func isNotSameDevice(err error) bool {
+	return false
+}
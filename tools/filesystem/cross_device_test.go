@@ -0,0 +1,148 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsCrossDevice(t *testing.T) {
+	linkErr := &os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EXDEV}
+	if !isCrossDevice(linkErr) {
+		t.Error("Expected EXDEV to be reported as cross-device")
+	}
+	if isCrossDevice(fmt.Errorf("wrapped: %w", os.ErrNotExist)) {
+		t.Error("Expected a missing file not to be reported as cross-device")
+	}
+}
+
+func TestMoveAcrossDevicesPreservesMetadata(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create source tree: %v", err)
+	}
+	script := filepath.Join(src, "sub", "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho hi\n"), 0750); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(script, mtime, mtime); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(src, "sub"), mtime, mtime); err != nil {
+		t.Fatalf("Failed to set directory mtime: %v", err)
+	}
+	srcInfo, err := os.Stat(script)
+	if err != nil {
+		t.Fatalf("Failed to stat source: %v", err)
+	}
+
+	if err := moveAcrossDevices(nil, src, dst); err != nil {
+		t.Fatalf("moveAcrossDevices failed: %v", err)
+	}
+
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Errorf("Source still exists after move: %v", err)
+	}
+	got, err := os.Stat(filepath.Join(dst, "sub", "run.sh"))
+	if err != nil {
+		t.Fatalf("Moved file missing: %v", err)
+	}
+	if got.Mode().Perm() != srcInfo.Mode().Perm() {
+		t.Errorf("Mode = %v, want %v", got.Mode().Perm(), srcInfo.Mode().Perm())
+	}
+	if !got.ModTime().Equal(mtime) {
+		t.Errorf("File mtime = %v, want %v", got.ModTime(), mtime)
+	}
+	subInfo, err := os.Stat(filepath.Join(dst, "sub"))
+	if err != nil {
+		t.Fatalf("Moved directory missing: %v", err)
+	}
+	if !subInfo.ModTime().Equal(mtime) {
+		t.Errorf("Directory mtime = %v, want %v", subInfo.ModTime(), mtime)
+	}
+}
+
+func TestMoveAcrossDevicesRefusesExistingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	for _, d := range []string{src, dst} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", d, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := moveAcrossDevices(nil, src, dst); err == nil {
+		t.Fatal("Expected moving onto an existing directory to fail")
+	}
+	if _, err := os.Stat(filepath.Join(src, "a.txt")); err != nil {
+		t.Errorf("Source was modified: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("Destination was written to: %v", err)
+	}
+}
+
+func TestVerifyTreeDetectsMismatch(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	if err := os.WriteFile(src, []byte("abc"), 0644); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+	if err := os.WriteFile(dst, []byte("abd"), 0644); err != nil {
+		t.Fatalf("Failed to write destination: %v", err)
+	}
+	if err := verifyTree(src, dst); err == nil {
+		t.Error("Expected a checksum mismatch")
+	}
+	if err := os.WriteFile(dst, []byte("abc"), 0644); err != nil {
+		t.Fatalf("Failed to rewrite destination: %v", err)
+	}
+	if err := verifyTree(src, dst); err != nil {
+		t.Errorf("Expected identical files to verify, got %v", err)
+	}
+}
+
+func TestMoveAcrossDevicesReportsRemainingSource(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced for root")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	locked := filepath.Join(src, "locked")
+	if err := os.MkdirAll(locked, 0755); err != nil {
+		t.Fatalf("Failed to create source tree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(locked, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	// Entries of a read-only directory can be copied but not deleted
+	if err := os.Chmod(locked, 0555); err != nil {
+		t.Fatalf("Failed to lock directory: %v", err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	dst := filepath.Join(dir, "dst")
+	err := moveAcrossDevices(nil, src, dst)
+	var removalErr *sourceRemovalError
+	if !errors.As(err, &removalErr) {
+		t.Fatalf("Expected a source removal error, got %v", err)
+	}
+	if len(removalErr.Remaining) == 0 {
+		t.Error("Expected the remaining source entries to be listed")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "locked", "a.txt")); err != nil {
+		t.Errorf("Destination copy missing: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestMoveAcrossDevicesRemovesPartialDestination(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	// Special files cannot be moved, so the copy fails after a.txt was written
+	if err := syscall.Mkfifo(filepath.Join(src, "z.fifo"), 0644); err != nil {
+		t.Skipf("mkfifo unsupported: %v", err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := moveAcrossDevices(nil, src, dst); err == nil {
+		t.Fatal("Expected the move to fail on a special file")
+	}
+	if _, err := os.Lstat(dst); !os.IsNotExist(err) {
+		t.Errorf("Partial destination was left behind: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "a.txt")); err != nil {
+		t.Errorf("Source was modified: %v", err)
+	}
+}
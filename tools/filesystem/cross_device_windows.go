@@ -0,0 +1,17 @@
+//go:build windows
+
+package filesystem
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNotSameDevice is ERROR_NOT_SAME_DEVICE, returned by MoveFileEx when the
+// source and destination are on different volumes.
+const errorNotSameDevice syscall.Errno = 17
+
+// isNotSameDevice reports whether err is the Windows cross-volume rename error.
+func isNotSameDevice(err error) bool {
+	return errors.Is(err, errorNotSameDevice)
+}
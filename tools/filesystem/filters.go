@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Entry types search_files can be limited to.
+const (
+	entryFiles       = "file"
+	entryDirectories = "directory"
+	entryAny         = "any"
+)
+
+var (
+	errInvalidTime = errors.New("invalid time")
+	errInvalidType = errors.New("invalid entry type")
+)
+
+// entryFilter holds the metadata filters of a search_files call. Its zero
+// value lets every file through and no directory.
+type entryFilter struct {
+	entryType  string
+	after      time.Time // zero when not set
+	before     time.Time
+	minSize    int64
+	maxSize    int64 // 0 when not set
+	extensions map[string]bool
+}
+
+// newEntryFilter builds the filter for args, relative to now for times given
+// as durations.
+func newEntryFilter(args SearchFilesArgs, now time.Time) (*entryFilter, error) {
+	f := &entryFilter{entryType: entryFiles}
+	if args.Type != nil && *args.Type != "" {
+		switch t := strings.ToLower(*args.Type); t {
+		case entryFiles, entryDirectories, entryAny:
+			f.entryType = t
+		default:
+			return nil, errInvalidType
+		}
+	}
+	var err error
+	if args.ModifiedAfter != nil {
+		if f.after, err = parseTime(*args.ModifiedAfter, now); err != nil {
+			return nil, err
+		}
+	}
+	if args.ModifiedBefore != nil {
+		if f.before, err = parseTime(*args.ModifiedBefore, now); err != nil {
+			return nil, err
+		}
+	}
+	if args.MinSize != nil {
+		f.minSize = *args.MinSize
+	}
+	if args.MaxSize != nil {
+		f.maxSize = *args.MaxSize
+	}
+	for _, ext := range args.Extensions {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext == "" {
+			continue
+		}
+		if f.extensions == nil {
+			f.extensions = make(map[string]bool)
+		}
+		f.extensions["."+ext] = true
+	}
+	return f, nil
+}
+
+// parseTime reads a point in time given in RFC 3339, as a local date
+// (2006-01-02), or as a duration back from now (24h).
+func parseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, now.Location()); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, errInvalidTime
+}
+
+// needsInfo reports whether the filter looks at more than the entry's name
+// and type, and so needs its fs.FileInfo.
+func (f *entryFilter) needsInfo() bool {
+	return !f.after.IsZero() || !f.before.IsZero() || f.minSize > 0 || f.maxSize > 0
+}
+
+// matches reports whether the entry passes the filter. info may be nil when
+// needsInfo is false. Directories have no size and are not filtered by it.
+func (f *entryFilter) matches(d fs.DirEntry, info fs.FileInfo) bool {
+	if d.IsDir() {
+		if f.entryType == entryFiles {
+			return false
+		}
+	} else if f.entryType == entryDirectories {
+		return false
+	}
+	if f.extensions != nil && !f.extensions[strings.ToLower(filepath.Ext(d.Name()))] {
+		return false
+	}
+	if info == nil {
+		return true
+	}
+	if !f.after.IsZero() && !info.ModTime().After(f.after) {
+		return false
+	}
+	if !f.before.IsZero() && !info.ModTime().Before(f.before) {
+		return false
+	}
+	if !d.IsDir() {
+		if info.Size() < f.minSize || (f.maxSize > 0 && info.Size() > f.maxSize) {
+			return false
+		}
+	}
+	return true
+}
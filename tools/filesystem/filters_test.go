@@ -0,0 +1,116 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"2024-05-01T08:30:00Z": time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+		"2024-05-01":           time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"36h":                  time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC),
+	}
+	for s, want := range tests {
+		if got, err := parseTime(s, now); err != nil || !got.Equal(want) {
+			t.Errorf("parseTime(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "yesterday", "-1h", "05/01/2024"} {
+		if _, err := parseTime(s, now); err == nil {
+			t.Errorf("parseTime(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestHandleSearchFilesFilters(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-72 * time.Hour)
+	files := []struct {
+		name string
+		size int
+		old  bool
+	}{
+		{"main.go", 2048, false},
+		{"small.go", 10, false},
+		{"old.go", 4096, true},
+		{"README.MD", 2048, false},
+		{"pkg/util.go", 1500, false},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", f.size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if f.old {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	search := func(args SearchFilesArgs) []string {
+		t.Helper()
+		args.Path = dir
+		result, err := HandleSearchFiles(ctx, args)
+		if err != nil {
+			t.Fatalf("HandleSearchFiles failed: %v", err)
+		}
+		var paths []string
+		if err := json.Unmarshal([]byte(result), &paths); err != nil {
+			t.Fatalf("Result is not a JSON list: %v\n%s", err, result)
+		}
+		for i, p := range paths {
+			paths[i], _ = filepath.Rel(dir, p)
+			paths[i] = filepath.ToSlash(paths[i])
+		}
+		return paths
+	}
+	str := func(s string) *string { return &s }
+	size := func(n int64) *int64 { return &n }
+
+	// Go files changed in the last day over 1 KB
+	got := search(SearchFilesArgs{Extensions: []string{"go"}, ModifiedAfter: str("24h"), MinSize: size(1024)})
+	if want := []string{"main.go", "pkg/util.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recent large go files = %q, want %q", got, want)
+	}
+	got = search(SearchFilesArgs{ModifiedBefore: str(time.Now().Add(-time.Hour).Format(time.RFC3339))})
+	if want := []string{"old.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("old files = %q, want %q", got, want)
+	}
+	got = search(SearchFilesArgs{Extensions: []string{".md"}, MaxSize: size(4096)})
+	if want := []string{"README.MD"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("markdown files = %q, want %q", got, want)
+	}
+	got = search(SearchFilesArgs{Type: str("directory")})
+	if want := []string{"pkg"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("directories = %q, want %q", got, want)
+	}
+	got = search(SearchFilesArgs{Pattern: "u", Type: str("any")})
+	if want := []string{"pkg/util.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entries named u = %q, want %q", got, want)
+	}
+
+	for _, args := range []SearchFilesArgs{{Type: str("link")}, {ModifiedAfter: str("last week")}} {
+		args.Path = dir
+		if result, err := HandleSearchFiles(ctx, args); err != nil || !strings.HasPrefix(result, "Invalid") {
+			t.Errorf("HandleSearchFiles(%+v) = %q, %v; want an invalid argument message", args, result, err)
+		}
+	}
+}
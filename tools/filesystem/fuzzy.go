@@ -0,0 +1,164 @@
+package filesystem
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scores of the fuzzy matcher, in the spirit of fzf: every matched character
+// counts, more so at the start of a word or right after the previous match,
+// and every skipped character costs a little.
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 8
+	bonusConsecutive = 4
+	penaltyGap       = 1
+	bonusBaseName    = 10 // the term matched in the file name, not a directory
+)
+
+// fuzzyTerms splits a query into lower-case terms at spaces and path or word
+// separators, so "user controller" and "user_controller" look for the same.
+func fuzzyTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), isSeparator)
+}
+
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("/\\_-.", r)
+}
+
+// fuzzyScore reports whether every term matches path and how well. A term
+// matches when its characters appear in order in path, or when a word of path
+// abbreviates it, as "usr" does "user" and "ctrl" does "controller".
+func fuzzyScore(terms []string, path string) (int, bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+	runes := []rune(path)
+	lower := []rune(strings.ToLower(path))
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+	base := strings.LastIndexAny(path, `/\`) + 1
+	baseStart := len([]rune(path[:base]))
+	words := fuzzyWords(runes)
+
+	total := 0
+	for _, term := range terms {
+		t := []rune(term)
+		best, bestStart, ok := subsequenceScore(t, runes, lower)
+		for _, w := range words {
+			if score, matched := abbreviationScore(t, w.text); matched && (!ok || score > best) {
+				best, bestStart, ok = score, w.start, true
+			}
+		}
+		if !ok {
+			return 0, false
+		}
+		if bestStart >= baseStart {
+			best += bonusBaseName
+		}
+		total += best
+	}
+	return total, true
+}
+
+// subsequenceScore finds the shortest stretch of path holding the characters
+// of term in order, as fzf's first algorithm does, and scores it. It returns
+// where the stretch starts.
+func subsequenceScore(term, runes, lower []rune) (int, int, bool) {
+	end, j := -1, 0
+	for i := 0; i < len(lower) && j < len(term); i++ {
+		if lower[i] == term[j] {
+			j++
+			if j == len(term) {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return 0, 0, false
+	}
+	start := end
+	for j = len(term) - 1; j >= 0; start-- {
+		if lower[start] == term[j] {
+			j--
+		}
+	}
+	start++
+
+	score, prev := 0, -1
+	j = 0
+	for i := start; i <= end && j < len(term); i++ {
+		if lower[i] != term[j] {
+			continue
+		}
+		score += scoreMatch
+		if isWordStart(runes, i) {
+			score += bonusBoundary
+		}
+		if prev >= 0 {
+			if prev == i-1 {
+				score += bonusConsecutive
+			} else {
+				score -= penaltyGap * (i - prev - 1)
+			}
+		}
+		prev = i
+		j++
+	}
+	return score, start, true
+}
+
+// abbreviationScore matches a word of the path that abbreviates term: it
+// shares term's first letter and its letters appear in term in order.
+func abbreviationScore(term, word []rune) (int, bool) {
+	if len(word) < 2 || len(word) >= len(term) || word[0] != term[0] {
+		return 0, false
+	}
+	j := 0
+	for i := 0; i < len(term) && j < len(word); i++ {
+		if term[i] == word[j] {
+			j++
+		}
+	}
+	if j < len(word) {
+		return 0, false
+	}
+	return (scoreMatch-penaltyGap*2)*len(word) + bonusBoundary, true
+}
+
+// fuzzyWord is a lower-case word of a path and the index of its first rune.
+type fuzzyWord struct {
+	text  []rune
+	start int
+}
+
+// fuzzyWords splits a path into words at separators and camelCase humps.
+func fuzzyWords(runes []rune) []fuzzyWord {
+	var words []fuzzyWord
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i == len(runes) || isSeparator(runes[i]) || (start >= 0 && isWordStart(runes, i)) {
+			if start >= 0 {
+				words = append(words, fuzzyWord{text: []rune(strings.ToLower(string(runes[start:i]))), start: start})
+			}
+			start = -1
+			if i == len(runes) || isSeparator(runes[i]) {
+				continue
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	return words
+}
+
+// isWordStart reports whether the rune at i begins a word: it follows a
+// separator or is an upper-case letter after a lower-case one.
+func isWordStart(runes []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	return isSeparator(runes[i-1]) || (unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]))
+}
@@ -0,0 +1,95 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query string
+		path  string
+		match bool
+	}{
+		{"user controller", "api/usr_ctrl.go", true},
+		{"user controller", "api/UserController.go", true},
+		{"usercontroller", "api/user_controller.go", true},
+		{"user controller", "docs/users.md", false},
+		{"ctrl", "api/controller.go", true},
+		{"main", "cmd/server/main.go", true},
+		{"zzz", "main.go", false},
+		{"", "main.go", false},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzyScore(fuzzyTerms(tt.query), tt.path); ok != tt.match {
+			t.Errorf("fuzzyScore(%q, %q) matched = %v, want %v", tt.query, tt.path, ok, tt.match)
+		}
+	}
+
+	// Tighter, word-aligned matches and matches in the file name score higher
+	better := [][3]string{
+		{"user controller", "api/user_controller.go", "api/usr_ctrl.go"},
+		{"conf", "config.go", "cmd/obscure_notes_file.go"},
+		{"handler", "api/handler.go", "handler/api.go"},
+	}
+	for _, b := range better {
+		hi, _ := fuzzyScore(fuzzyTerms(b[0]), b[1])
+		lo, _ := fuzzyScore(fuzzyTerms(b[0]), b[2])
+		if hi <= lo {
+			t.Errorf("For %q, %s scored %d, not above %s with %d", b[0], b[1], hi, b[2], lo)
+		}
+	}
+}
+
+func TestHandleSearchFilesFuzzy(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api/usr_ctrl.go", "api/UserController.go", "api/user_controller_test.go", "docs/users.md", "main.go"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	fuzzy := true
+	result, err := HandleSearchFiles(ctx, SearchFilesArgs{Path: dir, Pattern: "user controller", Fuzzy: &fuzzy})
+	if err != nil {
+		t.Fatalf("HandleSearchFiles failed: %v", err)
+	}
+	var files []string
+	if err := json.Unmarshal([]byte(result), &files); err != nil {
+		t.Fatalf("Result is not a JSON list: %v\n%s", err, result)
+	}
+	want := []string{
+		filepath.Join(dir, "api", "UserController.go"),
+		filepath.Join(dir, "api", "user_controller_test.go"),
+		filepath.Join(dir, "api", "usr_ctrl.go"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("HandleSearchFiles = %q\nwant %q", files, want)
+	}
+
+	limit := 1
+	result, _ = HandleSearchFiles(ctx, SearchFilesArgs{Path: dir, Pattern: "user controller", Fuzzy: &fuzzy, Limit: &limit})
+	if err := json.Unmarshal([]byte(result), &files); err != nil || !reflect.DeepEqual(files, want[:1]) {
+		t.Errorf("HandleSearchFiles with limit 1 = %s", result)
+	}
+
+	// Without fuzzy the pattern is a plain substring of the name
+	result, _ = HandleSearchFiles(ctx, SearchFilesArgs{Path: dir, Pattern: "user"})
+	if err := json.Unmarshal([]byte(result), &files); err != nil || len(files) != 3 {
+		t.Errorf("HandleSearchFiles without fuzzy = %s", result)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"time"
 
+	"gocreate/tools/i18n"
+
 	"github.com/localrivet/gomcp/server"
 )
 
@@ -20,7 +22,7 @@ func HandleGetFileInfo(ctx *server.Context, args GetFileInfoArgs) (string, error
 	fileInfo, err := os.Stat(args.Path)
 	if err != nil {
 		ctx.Logger.Info("Error getting file info", "path", args.Path, "error", err)
-		return "Error getting file info", err
+		return i18n.T(ctx, i18n.FileInfoError), err
 	}
 
 	// Format the file info
@@ -0,0 +1,87 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+
+	"gocreate/tools/config"
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// CreateSymlinkArgs defines the arguments for the create_symlink tool.
+type CreateSymlinkArgs struct {
+	Target   string `json:"target" description:"The path the link points to. A relative target is resolved from the link's directory." required:"true"`
+	LinkPath string `json:"link_path" description:"The path of the symbolic link to create." required:"true"`
+}
+
+// CreateHardlinkArgs defines the arguments for the create_hardlink tool.
+type CreateHardlinkArgs struct {
+	Target   string `json:"target" description:"The existing regular file to link to." required:"true"`
+	LinkPath string `json:"link_path" description:"The path of the hard link to create." required:"true"`
+}
+
+// checkLinkPaths returns a refusal message when link creation is disabled or
+// either end of the link lies outside the allowed directories.
+func checkLinkPaths(ctx *server.Context, cfg *config.ServerConfig, target, linkPath string) string {
+	if cfg == nil || cfg.AllowLinkCreation == nil || !*cfg.AllowLinkCreation {
+		return i18n.T(ctx, i18n.LinksDisabled)
+	}
+	if !config.PathAllowed(cfg, linkPath) {
+		return i18n.T(ctx, i18n.PathNotAllowed, linkPath)
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(linkPath), target)
+	}
+	if !config.PathAllowed(cfg, target) {
+		return i18n.T(ctx, i18n.PathNotAllowed, target)
+	}
+	return ""
+}
+
+// createSymlink creates a symbolic link after checking cfg.
+func createSymlink(ctx *server.Context, cfg *config.ServerConfig, args CreateSymlinkArgs) (string, error) {
+	if msg := checkLinkPaths(ctx, cfg, args.Target, args.LinkPath); msg != "" {
+		return msg, nil
+	}
+	if err := os.Symlink(args.Target, args.LinkPath); err != nil {
+		ctx.Logger.Info("Error creating symbolic link", "target", args.Target, "linkPath", args.LinkPath, "error", err)
+		if isSymlinkPrivilegeError(err) {
+			return i18n.T(ctx, i18n.SymlinkPrivilege), nil
+		}
+		return i18n.T(ctx, i18n.LinkCreateError), err
+	}
+	return i18n.T(ctx, i18n.SymlinkCreated, args.LinkPath, args.Target), nil
+}
+
+// createHardlink creates a hard link to a regular file after checking cfg.
+func createHardlink(ctx *server.Context, cfg *config.ServerConfig, args CreateHardlinkArgs) (string, error) {
+	if msg := checkLinkPaths(ctx, cfg, args.Target, args.LinkPath); msg != "" {
+		return msg, nil
+	}
+	// Linking a symlink or directory is either unsupported or surprising across platforms
+	info, err := os.Lstat(args.Target)
+	if err != nil || !info.Mode().IsRegular() {
+		return i18n.T(ctx, i18n.HardlinkNotRegular, args.Target), nil
+	}
+	if err := os.Link(args.Target, args.LinkPath); err != nil {
+		ctx.Logger.Info("Error creating hard link", "target", args.Target, "linkPath", args.LinkPath, "error", err)
+		return i18n.T(ctx, i18n.LinkCreateError), err
+	}
+	return i18n.T(ctx, i18n.HardlinkCreated, args.LinkPath, args.Target), nil
+}
+
+// HandleCreateSymlink implements the create_symlink tool.
+func HandleCreateSymlink(ctx *server.Context, args CreateSymlinkArgs) (string, error) {
+	ctx.Logger.Info("Handling create_symlink tool call")
+	cfg, _ := config.GetCurrentConfig(ctx)
+	return createSymlink(ctx, cfg, args)
+}
+
+// HandleCreateHardlink implements the create_hardlink tool.
+func HandleCreateHardlink(ctx *server.Context, args CreateHardlinkArgs) (string, error) {
+	ctx.Logger.Info("Handling create_hardlink tool call")
+	cfg, _ := config.GetCurrentConfig(ctx)
+	return createHardlink(ctx, cfg, args)
+}
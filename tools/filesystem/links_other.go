@@ -0,0 +1,9 @@
+//go:build !windows
+
+package filesystem
+
+// isSymlinkPrivilegeError reports whether err means the process may not create
+// symbolic links; only Windows restricts this.
+func isSymlinkPrivilegeError(err error) bool {
+	return false
+}
@@ -0,0 +1,61 @@
+package filesystem
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestCreateLinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	target := filepath.Join(root, "config.json")
+	if err := os.WriteFile(target, []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write target: %v", err)
+	}
+
+	enabled := true
+	cfg := &config.ServerConfig{AllowLinkCreation: &enabled, AllowedDirectories: []string{root}}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *config.ServerConfig
+		symlink  bool
+		target   string
+		linkPath string
+		created  bool
+	}{
+		{"disabled by default", &config.ServerConfig{}, true, target, filepath.Join(root, "a"), false},
+		{"relative symlink", cfg, true, "config.json", filepath.Join(root, "b"), true},
+		{"symlink outside roots", cfg, true, target, filepath.Join(outside, "c"), false},
+		{"symlink target outside roots", cfg, true, "../" + filepath.Base(outside), filepath.Join(root, "d"), false},
+		{"hard link", cfg, false, target, filepath.Join(root, "e"), true},
+		{"hard link to directory", cfg, false, root, filepath.Join(root, "f"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.symlink {
+				_, err = createSymlink(ctx, tt.cfg, CreateSymlinkArgs{Target: tt.target, LinkPath: tt.linkPath})
+			} else {
+				_, err = createHardlink(ctx, tt.cfg, CreateHardlinkArgs{Target: tt.target, LinkPath: tt.linkPath})
+			}
+			if err != nil {
+				t.Skipf("links unsupported here: %v", err)
+			}
+			_, statErr := os.Lstat(tt.linkPath)
+			if created := statErr == nil; created != tt.created {
+				t.Errorf("link created = %v, want %v", created, tt.created)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package filesystem
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorPrivilegeNotHeld is ERROR_PRIVILEGE_NOT_HELD, returned by CreateSymbolicLink
+// when the process is neither elevated nor running with Developer Mode enabled.
+const errorPrivilegeNotHeld syscall.Errno = 1314
+
+// isSymlinkPrivilegeError reports whether err means the process may not create symbolic links.
+func isSymlinkPrivilegeError(err error) bool {
+	return errors.Is(err, errorPrivilegeNotHeld)
+}
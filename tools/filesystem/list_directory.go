@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 
+	"gocreate/tools/i18n"
+
 	"github.com/localrivet/gomcp/server"
 )
 
@@ -19,7 +21,7 @@ func HandleListDirectory(ctx *server.Context, args ListDirectoryArgs) (string, e
 	files, err := os.ReadDir(args.Path)
 	if err != nil {
 		ctx.Logger.Info("Error reading directory", "path", args.Path, "error", err)
-		return "Error reading directory", err
+		return i18n.T(ctx, i18n.DirectoryReadError), err
 	}
 
 	var fileList []string
@@ -0,0 +1,202 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// Conflict policies understood by merge_directory.
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+)
+
+// MergeDirectoryArgs defines the arguments for the merge_directory tool.
+type MergeDirectoryArgs struct {
+	Source      string  `json:"source" description:"The directory whose contents are moved." required:"true"`
+	Destination string  `json:"destination" description:"The directory to merge into. It is created if it does not exist." required:"true"`
+	Conflict    *string `json:"conflict,omitempty" description:"What to do when a file exists in both: skip (default) leaves both in place, overwrite replaces the destination file, rename moves the source file under a numbered name such as name-1.txt."`
+	DryRun      *bool   `json:"dry_run,omitempty" description:"If true, report what would happen without moving anything."`
+}
+
+// MergeAction describes what merge_directory did, or would do, with one entry.
+type MergeAction struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Action      string `json:"action"` // move, overwrite, rename or skip
+}
+
+// MergeReport is the result returned by merge_directory.
+type MergeReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Actions []MergeAction     `json:"actions"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// merger carries the state of a single merge_directory call.
+type merger struct {
+	ctx     *server.Context
+	policy  string
+	dryRun  bool
+	report  *MergeReport
+	planned map[string]bool // destination names claimed by a dry run
+}
+
+// exists reports whether path is present on disk or already claimed by the plan.
+func (m *merger) exists(path string) bool {
+	if m.planned[path] {
+		return true
+	}
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// numberedName returns the first "name-N.ext" next to path that is not taken.
+func (m *merger) numberedName(path string) string {
+	dir, base := filepath.Split(path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if stem == "" {
+		// Dotfiles such as .env have no stem; number the whole name
+		stem, ext = base, ""
+	}
+	for n := 1; ; n++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, n, ext))
+		if !m.exists(candidate) {
+			return candidate
+		}
+	}
+}
+
+// move renames src to dst, copying across filesystems when needed, and records the action.
+func (m *merger) move(src, dst, action string) {
+	m.report.Actions = append(m.report.Actions, MergeAction{Source: src, Destination: dst, Action: action})
+	if m.dryRun {
+		m.planned[dst] = true
+		return
+	}
+	err := os.Rename(src, dst)
+	if err != nil && isCrossDevice(err) {
+		err = moveAcrossDevices(m.ctx, src, dst)
+	}
+	if err != nil {
+		m.report.Errors[src] = err.Error()
+	}
+}
+
+// merge moves the entries of src into dst, recursing into directories present in both.
+func (m *merger) merge(src, dst string) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		m.report.Errors[src] = err.Error()
+		return
+	}
+
+	for _, entry := range entries {
+		s := filepath.Join(src, entry.Name())
+		d := filepath.Join(dst, entry.Name())
+
+		dInfo, err := os.Lstat(d)
+		if os.IsNotExist(err) && !m.planned[d] {
+			m.move(s, d, "move")
+			continue
+		}
+		if err != nil && !m.planned[d] {
+			m.report.Errors[s] = err.Error()
+			continue
+		}
+
+		sIsDir := entry.IsDir()
+		dIsDir := dInfo != nil && dInfo.IsDir()
+		if sIsDir && dIsDir {
+			m.merge(s, d)
+			continue
+		}
+
+		switch m.policy {
+		case conflictSkip:
+			m.report.Actions = append(m.report.Actions, MergeAction{Source: s, Destination: d, Action: "skip"})
+		case conflictOverwrite:
+			if sIsDir || dIsDir {
+				// Replacing a directory with a file (or the reverse) would delete data wholesale
+				m.report.Errors[s] = fmt.Sprintf("cannot overwrite %s: one is a directory and the other is not", d)
+				continue
+			}
+			m.move(s, d, "overwrite")
+		case conflictRename:
+			m.move(s, m.numberedName(d), "rename")
+		}
+	}
+
+	if !m.dryRun {
+		// Only succeeds once everything has been moved out; skipped entries keep it
+		_ = os.Remove(src)
+	}
+}
+
+// HandleMergeDirectory implements the merge_directory tool. Unlike move_file, which
+// fails when the destination exists, it merges the source tree into the destination
+// entry by entry and resolves files present in both according to the conflict policy.
+func HandleMergeDirectory(ctx *server.Context, args MergeDirectoryArgs) (string, error) {
+	ctx.Logger.Info("Handling merge_directory tool call")
+
+	policy := conflictSkip
+	if args.Conflict != nil && *args.Conflict != "" {
+		policy = strings.ToLower(*args.Conflict)
+	}
+	if policy != conflictSkip && policy != conflictOverwrite && policy != conflictRename {
+		return i18n.T(ctx, i18n.MergeInvalidPolicy, policy), nil
+	}
+
+	srcInfo, err := os.Stat(args.Source)
+	if err != nil {
+		ctx.Logger.Info("Error accessing merge source", "source", args.Source, "error", err)
+		return i18n.T(ctx, i18n.FileAccessError), err
+	}
+	if !srcInfo.IsDir() {
+		return i18n.T(ctx, i18n.MergeSourceNotDir, args.Source), nil
+	}
+	if dstInfo, err := os.Stat(args.Destination); err == nil && !dstInfo.IsDir() {
+		return i18n.T(ctx, i18n.MergeDestinationNotDir, args.Destination), nil
+	}
+
+	absSrc, err := filepath.Abs(args.Source)
+	if err != nil {
+		return i18n.T(ctx, i18n.FileAccessError), err
+	}
+	absDst, err := filepath.Abs(args.Destination)
+	if err != nil {
+		return i18n.T(ctx, i18n.FileAccessError), err
+	}
+	if rel, err := filepath.Rel(absSrc, absDst); err == nil && (rel == "." || !strings.HasPrefix(rel, "..")) {
+		return i18n.T(ctx, i18n.MergeIntoItself, args.Source), nil
+	}
+
+	dryRun := args.DryRun != nil && *args.DryRun
+	report := &MergeReport{DryRun: dryRun, Actions: []MergeAction{}, Errors: make(map[string]string)}
+	m := &merger{ctx: ctx, policy: policy, dryRun: dryRun, report: report, planned: make(map[string]bool)}
+
+	if _, err := os.Lstat(args.Destination); os.IsNotExist(err) {
+		// Nothing to merge with; move the directory as a whole
+		m.move(args.Source, args.Destination, "move")
+	} else {
+		m.merge(args.Source, args.Destination)
+	}
+
+	reportJson, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling merge report", "error", err)
+		return "Error generating merge report", err
+	}
+
+	ctx.Logger.Info("merge_directory completed", "actions", len(report.Actions), "errors", len(report.Errors), "dryRun", dryRun)
+	return string(reportJson), nil
+}
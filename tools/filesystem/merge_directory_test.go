@@ -0,0 +1,202 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// writeTree creates files (relative path to content) under root.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// readTree returns the regular files under root keyed by slash-separated relative path.
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	return files
+}
+
+func TestHandleMergeDirectory(t *testing.T) {
+	source := map[string]string{
+		"new.txt":        "new",
+		"same.txt":       "from source",
+		"sub/deep.txt":   "deep",
+		"sub/shared.txt": "source shared",
+	}
+	destination := map[string]string{
+		"same.txt":       "from destination",
+		"sub/shared.txt": "destination shared",
+		"keep.txt":       "keep",
+	}
+
+	tests := []struct {
+		policy     string
+		wantDst    map[string]string
+		wantSource map[string]string
+	}{
+		{
+			policy: "skip",
+			wantDst: map[string]string{
+				"new.txt": "new", "same.txt": "from destination", "keep.txt": "keep",
+				"sub/deep.txt": "deep", "sub/shared.txt": "destination shared",
+			},
+			wantSource: map[string]string{"same.txt": "from source", "sub/shared.txt": "source shared"},
+		},
+		{
+			policy: "overwrite",
+			wantDst: map[string]string{
+				"new.txt": "new", "same.txt": "from source", "keep.txt": "keep",
+				"sub/deep.txt": "deep", "sub/shared.txt": "source shared",
+			},
+			wantSource: map[string]string{},
+		},
+		{
+			policy: "rename",
+			wantDst: map[string]string{
+				"new.txt": "new", "same.txt": "from destination", "same-1.txt": "from source", "keep.txt": "keep",
+				"sub/deep.txt": "deep", "sub/shared.txt": "destination shared", "sub/shared-1.txt": "source shared",
+			},
+			wantSource: map[string]string{},
+		},
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src")
+			dst := filepath.Join(dir, "dst")
+			writeTree(t, src, source)
+			writeTree(t, dst, destination)
+
+			policy := tt.policy
+			if _, err := HandleMergeDirectory(ctx, MergeDirectoryArgs{Source: src, Destination: dst, Conflict: &policy}); err != nil {
+				t.Fatalf("HandleMergeDirectory failed: %v", err)
+			}
+
+			if got := readTree(t, dst); !equalTrees(got, tt.wantDst) {
+				t.Errorf("destination = %v, want %v", got, tt.wantDst)
+			}
+			if got := readTree(t, src); !equalTrees(got, tt.wantSource) {
+				t.Errorf("source = %v, want %v", got, tt.wantSource)
+			}
+			if len(tt.wantSource) == 0 {
+				if _, err := os.Stat(src); !os.IsNotExist(err) {
+					t.Errorf("Expected the emptied source to be removed, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleMergeDirectoryDryRun(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	writeTree(t, src, map[string]string{"a.txt": "source", "a-1.txt": "source numbered"})
+	writeTree(t, dst, map[string]string{"a.txt": "destination"})
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	policy := "rename"
+	dryRun := true
+	result, err := HandleMergeDirectory(ctx, MergeDirectoryArgs{Source: src, Destination: dst, Conflict: &policy, DryRun: &dryRun})
+	if err != nil {
+		t.Fatalf("HandleMergeDirectory failed: %v", err)
+	}
+
+	var report MergeReport
+	if err := json.Unmarshal([]byte(result), &report); err != nil {
+		t.Fatalf("Failed to parse report: %v\n%s", err, result)
+	}
+	// a-1.txt sorts first and is moved as is, so a.txt must take the next free number
+	want := map[string]string{
+		filepath.Join(src, "a-1.txt"): filepath.Join(dst, "a-1.txt"),
+		filepath.Join(src, "a.txt"):   filepath.Join(dst, "a-2.txt"),
+	}
+	if len(report.Actions) != len(want) {
+		t.Fatalf("Expected %d actions, got %+v", len(want), report.Actions)
+	}
+	for _, a := range report.Actions {
+		if want[a.Source] != a.Destination {
+			t.Errorf("%s planned to %s, want %s", a.Source, a.Destination, want[a.Source])
+		}
+	}
+	if got := readTree(t, dst); len(got) != 1 {
+		t.Errorf("Dry run modified the destination: %v", got)
+	}
+}
+
+func TestHandleMergeDirectoryRefusals(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTree(t, src, map[string]string{"a.txt": "a"})
+	writeTree(t, dir, map[string]string{"file.txt": "f"})
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	bad := "merge"
+	tests := []struct {
+		name string
+		args MergeDirectoryArgs
+	}{
+		{"unknown policy", MergeDirectoryArgs{Source: src, Destination: filepath.Join(dir, "dst"), Conflict: &bad}},
+		{"destination is a file", MergeDirectoryArgs{Source: src, Destination: filepath.Join(dir, "file.txt")}},
+		{"source is a file", MergeDirectoryArgs{Source: filepath.Join(dir, "file.txt"), Destination: filepath.Join(dir, "dst")}},
+		{"into itself", MergeDirectoryArgs{Source: src, Destination: filepath.Join(src, "nested")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HandleMergeDirectory(ctx, tt.args); err != nil {
+				t.Fatalf("Expected a refusal message, got error %v", err)
+			}
+			if got := readTree(t, src); len(got) != 1 {
+				t.Errorf("Source was modified: %v", got)
+			}
+		})
+	}
+}
+
+func equalTrees(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
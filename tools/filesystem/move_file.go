@@ -1,7 +1,11 @@
 package filesystem
 
 import (
+	"errors"
 	"os"
+	"strings"
+
+	"gocreate/tools/i18n"
 
 	"github.com/localrivet/gomcp/server"
 )
@@ -17,10 +21,22 @@ func HandleMoveFile(ctx *server.Context, args MoveFileArgs) (string, error) {
 	ctx.Logger.Info("Handling move_file tool call")
 
 	// Perform the move/rename operation
-	if err := os.Rename(args.Source, args.Destination); err != nil {
+	err := os.Rename(args.Source, args.Destination)
+	if err != nil && isCrossDevice(err) {
+		// Rename cannot cross filesystems; fall back to copy, verify, delete
+		ctx.Logger.Info("Source and destination are on different filesystems, copying instead", "source", args.Source, "destination", args.Destination)
+		err = moveAcrossDevices(ctx, args.Source, args.Destination)
+	}
+	var removalErr *sourceRemovalError
+	if errors.As(err, &removalErr) {
+		// The destination is complete; only part of the source was left behind
+		ctx.Logger.Info("Moved file but could not remove the source", "source", args.Source, "destination", args.Destination, "error", removalErr.Err)
+		return i18n.T(ctx, i18n.MoveSourceRemains, args.Destination, strings.Join(removalErr.Remaining, ", ")), nil
+	}
+	if err != nil {
 		ctx.Logger.Info("Error moving/renaming file", "source", args.Source, "destination", args.Destination, "error", err)
-		return "Error moving/renaming file", err
+		return i18n.T(ctx, i18n.MoveFailed), err
 	}
 
-	return "File moved/renamed successfully.", nil
+	return i18n.T(ctx, i18n.FileMoved), nil
 }
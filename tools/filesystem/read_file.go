@@ -5,6 +5,8 @@ import (
 	"os"
 	"strings"
 
+	"gocreate/tools/i18n"
+
 	"github.com/localrivet/gomcp/server"
 )
 
@@ -23,7 +25,7 @@ func HandleReadFile(ctx *server.Context, args ReadFileArgs) (string, error) {
 	content, err := os.ReadFile(args.FilePath)
 	if err != nil {
 		ctx.Logger.Info("Error reading file", "file_path", args.FilePath, "error", err)
-		return "Error reading file", err
+		return i18n.T(ctx, i18n.FileReadError), err
 	}
 
 	fileContent := string(content)
@@ -55,7 +57,7 @@ func HandleReadFile(ctx *server.Context, args ReadFileArgs) (string, error) {
 		endLine = totalLines
 	}
 	if startLine > endLine {
-		return "Invalid line range: start_line must be <= end_line", nil
+		return i18n.T(ctx, i18n.InvalidLineRange), nil
 	}
 
 	// Extract the requested lines (convert to 0-based indexing)
@@ -5,17 +5,40 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"gocreate/tools/i18n"
+	"gocreate/tools/request"
+
 	"github.com/localrivet/gomcp/server"
 )
 
 // SearchFilesArgs defines the arguments for the search_files tool.
 type SearchFilesArgs struct {
 	Path      string `json:"path" description:"The directory path to search in." required:"true"`
-	Pattern   string `json:"pattern" description:"The case-insensitive substring pattern to search for in file names." required:"true"`
+	Pattern   string `json:"pattern" description:"The case-insensitive substring pattern to search for in file names, or with fuzzy the approximate name to look for (e.g., 'user controller'). An empty pattern matches every name." required:"true"`
+	Fuzzy     *bool  `json:"fuzzy,omitempty" description:"Match the pattern fzf-style against paths relative to path: its words must appear in order or be abbreviated (usr_ctrl.go matches 'user controller'). Results are ordered best match first."`
+	Limit     *int   `json:"limit,omitempty" description:"Maximum number of files to return. Defaults to 50 with fuzzy and no limit otherwise."`
 	TimeoutMs *int   `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds for the search."`
+
+	ModifiedAfter  *string  `json:"modifiedAfter,omitempty" description:"Only return entries modified after this time: RFC 3339, a date (2006-01-02, local time) or a duration back from now (24h)."`
+	ModifiedBefore *string  `json:"modifiedBefore,omitempty" description:"Only return entries modified before this time, in the same forms as modifiedAfter."`
+	MinSize        *int64   `json:"minSize,omitempty" description:"Only return files of at least this many bytes."`
+	MaxSize        *int64   `json:"maxSize,omitempty" description:"Only return files of at most this many bytes."`
+	Extensions     []string `json:"extensions,omitempty" description:"Only return entries with one of these extensions, with or without the dot (e.g., ['go', '.md']). Case-insensitive."`
+	Type           *string  `json:"type,omitempty" description:"What to return: file (default), directory or any."`
+}
+
+// defaultFuzzyLimit is the number of files a fuzzy search returns unless
+// limit says otherwise.
+const defaultFuzzyLimit = 50
+
+// scoredFile is a file found by a fuzzy search and how well it matched.
+type scoredFile struct {
+	path  string
+	score int
 }
 
 // HandleSearchFiles implements the search_files tool using the new API
@@ -23,17 +46,29 @@ func HandleSearchFiles(ctx *server.Context, args SearchFilesArgs) (string, error
 	ctx.Logger.Info("Handling search_files tool call")
 
 	var foundFiles []string
+	var scored []scoredFile
+	fuzzy := args.Fuzzy != nil && *args.Fuzzy
+	terms := fuzzyTerms(args.Pattern)
+
+	filter, err := newEntryFilter(args, time.Now())
+	switch err {
+	case errInvalidType:
+		return i18n.T(ctx, i18n.SearchFilesInvalidType, *args.Type), nil
+	case errInvalidTime:
+		return i18n.T(ctx, i18n.SearchFilesInvalidTime), nil
+	}
 
-	// Set up context with timeout
-	searchCtx := context.Background()
+	// Set up context with timeout, cancelled with the call
+	searchCtx, stop := request.Context(ctx)
+	defer stop()
 	if args.TimeoutMs != nil && *args.TimeoutMs > 0 {
 		var cancel context.CancelFunc
-		searchCtx, cancel = context.WithTimeout(context.Background(), time.Duration(*args.TimeoutMs)*time.Millisecond)
+		searchCtx, cancel = context.WithTimeout(searchCtx, time.Duration(*args.TimeoutMs)*time.Millisecond)
 		defer cancel()
 	}
 
 	// Walk the directory tree
-	err := filepath.WalkDir(args.Path, func(path string, d os.DirEntry, err error) error {
+	err = filepath.WalkDir(args.Path, func(path string, d os.DirEntry, err error) error {
 		// Check for context cancellation
 		select {
 		case <-searchCtx.Done():
@@ -48,8 +83,25 @@ func HandleSearchFiles(ctx *server.Context, args SearchFilesArgs) (string, error
 			return nil // Don't stop the walk for individual errors
 		}
 
-		// Skip directories themselves, we only care about files matching the pattern
-		if d.IsDir() {
+		// The directory searched is not a result
+		if path == args.Path || !filter.matches(d, nil) {
+			return nil
+		}
+		if filter.needsInfo() {
+			info, infoErr := d.Info()
+			if infoErr != nil || !filter.matches(d, info) {
+				return nil
+			}
+		}
+
+		if fuzzy {
+			rel, relErr := filepath.Rel(args.Path, path)
+			if relErr != nil {
+				rel = path
+			}
+			if score, ok := fuzzyScore(terms, rel); ok {
+				scored = append(scored, scoredFile{path: path, score: score})
+			}
 			return nil
 		}
 
@@ -66,6 +118,30 @@ func HandleSearchFiles(ctx *server.Context, args SearchFilesArgs) (string, error
 		return "Error during file search", err
 	}
 
+	limit := 0
+	if fuzzy {
+		// Best matches first; among equals, shorter paths are likelier targets
+		sort.Slice(scored, func(i, j int) bool {
+			if scored[i].score != scored[j].score {
+				return scored[i].score > scored[j].score
+			}
+			if len(scored[i].path) != len(scored[j].path) {
+				return len(scored[i].path) < len(scored[j].path)
+			}
+			return scored[i].path < scored[j].path
+		})
+		for _, f := range scored {
+			foundFiles = append(foundFiles, f.path)
+		}
+		limit = defaultFuzzyLimit
+	}
+	if args.Limit != nil && *args.Limit > 0 {
+		limit = *args.Limit
+	}
+	if limit > 0 && len(foundFiles) > limit {
+		foundFiles = foundFiles[:limit]
+	}
+
 	// Marshal the found files list into JSON
 	foundFilesJson, marshalErr := json.MarshalIndent(foundFiles, "", "  ")
 	if marshalErr != nil {
@@ -75,7 +151,7 @@ func HandleSearchFiles(ctx *server.Context, args SearchFilesArgs) (string, error
 
 	// If the context was cancelled due to timeout, indicate an error
 	if searchCtx.Err() != nil {
-		return "Search timed out.", nil
+		return i18n.T(ctx, i18n.SearchTimedOut), nil
 	}
 
 	return string(foundFilesJson), nil
@@ -3,6 +3,11 @@ package filesystem
 import (
 	"os"
 
+	"gocreate/tools/format"
+	"gocreate/tools/generated"
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+
 	"github.com/localrivet/gomcp/server"
 )
 
@@ -10,17 +15,33 @@ import (
 type WriteFileArgs struct {
 	Path    string `json:"path" description:"The path of the file to write to." required:"true"`
 	Content string `json:"content" description:"The content to write to the file." required:"true"`
+	Format  *bool  `json:"format,omitempty" description:"If true, run the formatter configured for the file's extension after writing. Defaults to the formatOnEdit config value."`
+	Stamp   *bool  `json:"stamp_generated,omitempty" description:"If true, add a generated-code header comment in the file's language, after any shebang line. Content already carrying the marker is not stamped twice. Defaults to the stampGenerated config value."`
 }
 
 // HandleWriteFile implements the write_file tool using the new API
 func HandleWriteFile(ctx *server.Context, args WriteFileArgs) (string, error) {
 	ctx.Logger.Info("Handling write_file tool call")
 
+	// Record the before image so the write can be undone
+	pending := journal.Capture(ctx.Logger, args.Path, "write_file")
+
+	content := args.Content
+	stampNote := ""
+	if generated.StampEnabled(ctx, args.Stamp) {
+		if stamped, ok := generated.Stamp(args.Path, content, generated.Marker(ctx)); ok {
+			content = stamped
+			stampNote = "\n" + i18n.T(ctx, i18n.GeneratedHeaderAdded)
+		}
+	}
+
 	// Write the content to the file. 0644 is a common permission for files.
-	if err := os.WriteFile(args.Path, []byte(args.Content), 0644); err != nil {
+	if err := os.WriteFile(args.Path, []byte(content), 0644); err != nil {
 		ctx.Logger.Info("Error writing file", "path", args.Path, "error", err)
-		return "Error writing file", err
+		return i18n.T(ctx, i18n.FileWriteError), err
 	}
+	formatted, note := format.AfterEdit(ctx, args.Path, []byte(content), args.Format)
+	pending.Commit(formatted)
 
-	return "File written successfully.", nil
+	return i18n.T(ctx, i18n.FileWritten) + stampNote + note, nil
 }
@@ -0,0 +1,99 @@
+package format
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gocreate/tools/config"
+	"gocreate/tools/i18n"
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// formatTimeout bounds how long a formatter may run after an edit.
+const formatTimeout = 30 * time.Second
+
+// filePlaceholder is replaced by the edited file's path in formatter commands.
+const filePlaceholder = "{file}"
+
+// formatterFor returns the command configured for path's extension, with the
+// placeholder expanded or the path appended when the command has none.
+func formatterFor(cfg *config.ServerConfig, path string) []string {
+	if cfg == nil {
+		return nil
+	}
+	fields := strings.Fields(cfg.Formatters[strings.ToLower(filepath.Ext(path))])
+	if len(fields) == 0 {
+		return nil
+	}
+	substituted := false
+	for i, f := range fields {
+		if strings.Contains(f, filePlaceholder) {
+			fields[i] = strings.ReplaceAll(f, filePlaceholder, path)
+			substituted = true
+		}
+	}
+	if !substituted {
+		fields = append(fields, path)
+	}
+	return fields
+}
+
+// runFormatter runs argv, which must rewrite path in place, and returns the
+// content left on disk. On failure written is returned with the error and the
+// formatter's combined output.
+func runFormatter(argv []string, path string, written []byte) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), formatTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+	if err != nil {
+		return written, strings.TrimSpace(string(output)), err
+	}
+	formatted, err := os.ReadFile(path)
+	if err != nil {
+		return written, "", err
+	}
+	return formatted, "", nil
+}
+
+// AfterEdit runs the formatter configured for path when formatting is enabled,
+// either by override or by the formatOnEdit config value. written is the content
+// the calling tool just wrote. It returns the content now on disk, which the tool
+// should record in the edit journal, and a note to append to its result. The note
+// is empty when no formatter ran.
+func AfterEdit(ctx *server.Context, path string, written []byte, override *bool) ([]byte, string) {
+	cfg, _ := config.GetCurrentConfig(ctx)
+	enabled := cfg != nil && cfg.FormatOnEdit != nil && *cfg.FormatOnEdit
+	if override != nil {
+		enabled = *override
+	}
+	if !enabled {
+		return written, ""
+	}
+	argv := formatterFor(cfg, path)
+	if argv == nil {
+		return written, ""
+	}
+
+	name := strings.Join(argv, " ")
+	formatted, output, err := runFormatter(argv, path, written)
+	if err != nil {
+		ctx.Logger.Info("Formatter failed", "filePath", path, "command", name, "error", err)
+		detail := err.Error()
+		if output != "" {
+			detail = output
+		}
+		return formatted, "\n" + i18n.T(ctx, i18n.FormatFailed, name, detail)
+	}
+	if string(formatted) == string(written) {
+		return formatted, "\n" + i18n.T(ctx, i18n.FormatUnchanged, name)
+	}
+	diff := render.UnifiedDiff(path, string(written), string(formatted), 1, render.Plain(ctx, nil))
+	return formatted, "\n" + i18n.T(ctx, i18n.FormatApplied, name, diff)
+}
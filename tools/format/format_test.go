@@ -0,0 +1,68 @@
+package format
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gocreate/tools/config"
+)
+
+func TestFormatterFor(t *testing.T) {
+	cfg := &config.ServerConfig{Formatters: map[string]string{
+		".go": "gofmt -w",
+		".js": "prettier --write {file} --log-level=warn",
+	}}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"gofmt", "-w", "main.go"}},
+		{"MAIN.GO", []string{"gofmt", "-w", "MAIN.GO"}},
+		{"app.js", []string{"prettier", "--write", "app.js", "--log-level=warn"}},
+		{"notes.txt", nil},
+	}
+	for _, tt := range tests {
+		if got := formatterFor(cfg, tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("formatterFor(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if got := formatterFor(nil, "main.go"); got != nil {
+		t.Errorf("formatterFor(nil) = %v, want nil", got)
+	}
+}
+
+func TestRunFormatter(t *testing.T) {
+	gofmt, err := exec.LookPath("gofmt")
+	if err != nil {
+		t.Skip("gofmt not installed")
+	}
+	path := filepath.Join(t.TempDir(), "main.go")
+	written := []byte("package main\nfunc main(){}\n")
+	if err := os.WriteFile(path, written, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	formatted, _, err := runFormatter([]string{gofmt, "-w", path}, path, written)
+	if err != nil {
+		t.Fatalf("runFormatter failed: %v", err)
+	}
+	if want := "package main\n\nfunc main() {}\n"; string(formatted) != want {
+		t.Errorf("formatted = %q, want %q", formatted, want)
+	}
+
+	broken := []byte("package main\nfunc {\n")
+	if err := os.WriteFile(path, broken, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	got, output, err := runFormatter([]string{gofmt, "-w", path}, path, broken)
+	if err == nil {
+		t.Fatal("Expected gofmt to reject invalid source")
+	}
+	if string(got) != string(broken) || output == "" {
+		t.Errorf("Expected the written content and gofmt's error output, got %q, %q", got, output)
+	}
+}
@@ -0,0 +1,155 @@
+package generated
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// DefaultMarker is stamped when generatedMarker is not configured. It follows
+// the Go convention for generated files, which linters and code review tools
+// already recognise in other languages too.
+const DefaultMarker = "Code generated by gocreate. DO NOT EDIT."
+
+// HeadSize is how many bytes from the start of a file IsGenerated needs.
+const HeadSize = 4096
+
+// headLines is how many lines at the top of a file are checked for a marker.
+const headLines = 20
+
+// goGenerated matches the comment Go tools write into generated files.
+var goGenerated = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// commentStyle wraps a line of text in a language's comment syntax.
+type commentStyle struct {
+	open, close string
+}
+
+var (
+	slashes = commentStyle{open: "// "}
+	hash    = commentStyle{open: "# "}
+	dashes  = commentStyle{open: "-- "}
+	markup  = commentStyle{open: "<!-- ", close: " -->"}
+	block   = commentStyle{open: "/* ", close: " */"}
+)
+
+// commentStyles maps lower-case file extensions to their comment syntax.
+// Formats without comments, such as JSON, are left out and never stamped.
+var commentStyles = map[string]commentStyle{
+	".go": slashes, ".js": slashes, ".jsx": slashes, ".mjs": slashes, ".cjs": slashes,
+	".ts": slashes, ".tsx": slashes, ".java": slashes, ".kt": slashes, ".kts": slashes,
+	".scala": slashes, ".groovy": slashes, ".gradle": slashes, ".swift": slashes,
+	".c": slashes, ".h": slashes, ".cc": slashes, ".cpp": slashes, ".hpp": slashes,
+	".cs": slashes, ".fs": slashes, ".rs": slashes, ".dart": slashes, ".php": slashes,
+	".proto": slashes, ".scss": slashes, ".less": slashes, ".zig": slashes,
+	".py": hash, ".rb": hash, ".sh": hash, ".bash": hash, ".zsh": hash, ".pl": hash,
+	".r": hash, ".yaml": hash, ".yml": hash, ".toml": hash, ".mk": hash,
+	".tf": hash, ".cmake": hash, ".ps1": hash, ".ex": hash, ".exs": hash,
+	".sql": dashes, ".lua": dashes, ".hs": dashes,
+	".html": markup, ".htm": markup, ".xml": markup, ".svg": markup, ".md": markup, ".vue": markup,
+	".css": block,
+}
+
+// nameStyles maps extensionless file names to their comment syntax.
+var nameStyles = map[string]commentStyle{
+	"Makefile":   hash,
+	"Dockerfile": hash,
+	"Gemfile":    hash,
+	"Rakefile":   hash,
+}
+
+// preamble matches first lines that must stay first: shebangs, XML
+// declarations, PHP open tags and HTML doctypes.
+var preamble = regexp.MustCompile(`^(#!|<\?xml|<\?php|<!(?i:doctype))`)
+
+// encodingLine matches a Python or Ruby source encoding declaration, which
+// must stay on the first or second line.
+var encodingLine = regexp.MustCompile(`^#.*coding[:=]`)
+
+// Marker returns the configured generated-code marker text.
+func Marker(ctx *server.Context) string {
+	cfg, _ := config.GetCurrentConfig(ctx)
+	if cfg != nil && cfg.GeneratedMarker != nil && strings.TrimSpace(*cfg.GeneratedMarker) != "" {
+		return strings.TrimSpace(*cfg.GeneratedMarker)
+	}
+	return DefaultMarker
+}
+
+// StampEnabled reports whether files should be stamped, either by override or
+// by the stampGenerated config value.
+func StampEnabled(ctx *server.Context, override *bool) bool {
+	if override != nil {
+		return *override
+	}
+	cfg, _ := config.GetCurrentConfig(ctx)
+	return cfg != nil && cfg.StampGenerated != nil && *cfg.StampGenerated
+}
+
+// Header returns marker as a comment line in the language of path. It reports
+// false when the file type has no known comment syntax.
+func Header(path, marker string) (string, bool) {
+	style, ok := commentStyles[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		style, ok = nameStyles[filepath.Base(path)]
+	}
+	if !ok {
+		return "", false
+	}
+	return style.open + marker + style.close, true
+}
+
+// Stamp returns content with the marker header inserted at the top, after any
+// line that has to stay first. Content that is already marked as generated, or
+// whose file type has no comment syntax, is returned unchanged.
+func Stamp(path, content, marker string) (string, bool) {
+	header, ok := Header(path, marker)
+	if !ok || IsGenerated([]byte(content), marker) {
+		return content, false
+	}
+	lineEnding := "\n"
+	if strings.Contains(content, "\r\n") {
+		lineEnding = "\r\n"
+	}
+
+	// Skip the lines that must stay where they are
+	offset := 0
+	for n := 0; n < 2 && offset < len(content); n++ {
+		end := strings.IndexByte(content[offset:], '\n')
+		line := content[offset:]
+		if end >= 0 {
+			line = content[offset : offset+end]
+		}
+		line = strings.TrimSuffix(line, "\r")
+		if !(n == 0 && preamble.MatchString(line)) && !encodingLine.MatchString(line) {
+			break
+		}
+		if end < 0 {
+			// The whole file is a single preamble line
+			return content + lineEnding + header + lineEnding, true
+		}
+		offset += end + 1
+	}
+	return content[:offset] + header + lineEnding + content[offset:], true
+}
+
+// IsGenerated reports whether head, the start of a file, carries marker or
+// the comment Go tools write into generated files within its first lines.
+func IsGenerated(head []byte, marker string) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(head))
+	for n := 0; n < headLines && scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if marker != "" && strings.Contains(line, marker) {
+			return true
+		}
+		if goGenerated.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
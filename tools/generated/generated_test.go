@@ -0,0 +1,112 @@
+package generated
+
+import "testing"
+
+const marker = "Code generated by gocreate. DO NOT EDIT."
+
+func TestStamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    string
+		stamped bool
+	}{
+		{
+			name:    "go file",
+			path:    "main.go",
+			content: "package main\n",
+			want:    "// " + marker + "\npackage main\n",
+			stamped: true,
+		},
+		{
+			name:    "shebang stays first",
+			path:    "run.sh",
+			content: "#!/bin/sh\necho hi\n",
+			want:    "#!/bin/sh\n# " + marker + "\necho hi\n",
+			stamped: true,
+		},
+		{
+			name:    "python encoding line stays in place",
+			path:    "tool.py",
+			content: "#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\nprint(1)\n",
+			want:    "#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\n# " + marker + "\nprint(1)\n",
+			stamped: true,
+		},
+		{
+			name:    "xml declaration",
+			path:    "pom.xml",
+			content: "<?xml version=\"1.0\"?>\r\n<project/>\r\n",
+			want:    "<?xml version=\"1.0\"?>\r\n<!-- " + marker + " -->\r\n<project/>\r\n",
+			stamped: true,
+		},
+		{
+			name:    "single preamble line without newline",
+			path:    "empty.sh",
+			content: "#!/bin/sh",
+			want:    "#!/bin/sh\n# " + marker + "\n",
+			stamped: true,
+		},
+		{
+			name:    "empty sql file",
+			path:    "schema.sql",
+			content: "",
+			want:    "-- " + marker + "\n",
+			stamped: true,
+		},
+		{
+			name:    "makefile by name",
+			path:    "sub/Makefile",
+			content: "all:\n",
+			want:    "# " + marker + "\nall:\n",
+			stamped: true,
+		},
+		{
+			name:    "json has no comments",
+			path:    "data.json",
+			content: "{}\n",
+			want:    "{}\n",
+		},
+		{
+			name:    "already stamped",
+			path:    "main.go",
+			content: "// " + marker + "\npackage main\n",
+			want:    "// " + marker + "\npackage main\n",
+		},
+		{
+			name:    "other generator's marker",
+			path:    "api.pb.go",
+			content: "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n",
+			want:    "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stamped := Stamp(tt.path, tt.content, marker)
+			if got != tt.want || stamped != tt.stamped {
+				t.Errorf("Stamp() = %q, %v; want %q, %v", got, stamped, tt.want, tt.stamped)
+			}
+		})
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want bool
+	}{
+		{"configured marker", "/* " + marker + " */\nbody {}\n", true},
+		{"go convention", "//go:build linux\n\n// Code generated by stringer. DO NOT EDIT.\n", true},
+		{"not a marker comment", "// Code generated by hand, feel free to edit.\n", false},
+		{"plain source", "package main\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsGenerated([]byte(tt.head), marker); got != tt.want {
+				t.Errorf("IsGenerated(%q) = %v, want %v", tt.head, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package gitignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFiles are the files LoadDir reads in each directory, in rising
+// precedence: as in ripgrep, a match in a .rgignore file overrides one in an
+// .ignore file, which overrides one in a .gitignore, wherever they are.
+var ignoreFiles = []string{".gitignore", ".ignore", ".rgignore"}
+
+// rule is one pattern from an ignore file.
+type rule struct {
+	base     string // slash-separated directory of the ignore file, "" for the root
+	re       *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	priority int // the index of its file in ignoreFiles
+}
+
+// Matcher decides whether paths are ignored by the ignore files loaded into
+// it. Among the rules of one kind of file the last match wins, so parent
+// directories must be loaded before their children, which is the order
+// filepath.WalkDir visits them in.
+type Matcher struct {
+	rules []rule
+}
+
+// New returns a Matcher with no rules.
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Load returns a Matcher for the repository at root with the ignore files of
+// root and root/.git/info/exclude loaded. Nested ignore files are added with
+// LoadDir as a walk reaches them.
+func Load(root string) *Matcher {
+	m := New()
+	m.loadFile("", filepath.Join(root, ".git", "info", "exclude"), 0)
+	m.LoadDir(root, "")
+	return m
+}
+
+// LoadDir adds the .gitignore, .ignore and .rgignore files in the directory
+// rel (slash-separated, relative to root) that exist.
+func (m *Matcher) LoadDir(root, rel string) {
+	for priority, name := range ignoreFiles {
+		m.loadFile(rel, filepath.Join(root, filepath.FromSlash(rel), name), priority)
+	}
+}
+
+func (m *Matcher) loadFile(base, file string, priority int) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.add(base, scanner.Text(), priority)
+	}
+}
+
+// Add parses one .gitignore line found in the directory base. Blank lines and
+// comments are skipped.
+func (m *Matcher) Add(base, line string) {
+	m.add(base, line, 0)
+}
+
+func (m *Matcher) add(base, line string, priority int) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are dropped unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	r := rule{base: strings.Trim(base, "/"), priority: priority}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:] // \# and \! are literal
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// A pattern with a slash anywhere but the end is relative to base;
+	// otherwise it matches a name at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	prefix := "(?:.*/)?"
+	if anchored {
+		prefix = ""
+	}
+	re, err := regexp.Compile("^" + prefix + globToRegexp(line) + "$")
+	if err != nil {
+		return
+	}
+	r.re = re
+	m.rules = append(m.rules, r)
+}
+
+// globToRegexp translates gitignore glob syntax: * and ? stay within a path
+// segment, ** spans segments and [...] is a character class.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// match reports whether the last rule matching rel, from the ignore file of
+// highest precedence with a match, ignores it, without looking at its parent
+// directories.
+func (m *Matcher) match(rel string, isDir bool) bool {
+	ignored, best := false, -1
+	for i := len(m.rules) - 1; i >= 0 && best < len(ignoreFiles)-1; i-- {
+		r := m.rules[i]
+		if r.priority <= best || (r.dirOnly && !isDir) {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = rel[len(r.base)+1:]
+		}
+		if r.re.MatchString(sub) {
+			ignored, best = !r.negate, r.priority
+		}
+	}
+	return ignored
+}
+
+// Ignored reports whether rel, a slash-separated path relative to the
+// repository root, is ignored. A path inside an ignored directory is ignored
+// too, as git cannot re-include it.
+func (m *Matcher) Ignored(rel string, isDir bool) bool {
+	rel = strings.Trim(path.Clean(filepath.ToSlash(rel)), "/")
+	if rel == "." || rel == "" {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
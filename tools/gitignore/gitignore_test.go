@@ -0,0 +1,96 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcherIgnored(t *testing.T) {
+	m := New()
+	for _, line := range []string{
+		"# comment",
+		"*.log",
+		"!keep.log",
+		"/build/",
+		"docs/**/*.tmp",
+		"cache/",
+		`\#hash`,
+		"data/*",
+		"!data/README.md",
+	} {
+		m.Add("", line)
+	}
+	m.Add("web", "dist")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"src/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build/out.bin", false, true},
+		{"src/build", true, false},
+		{"build", false, false},
+		{"docs/a/b/c.tmp", false, true},
+		{"docs/c.tmp", false, true},
+		{"src/cache", true, true},
+		{"src/cache/x", false, true},
+		{"cache", false, false},
+		{"#hash", false, true},
+		{"data/dump.sql", false, true},
+		{"data/README.md", false, false},
+		{"web/dist", true, true},
+		{"dist", true, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestLoadIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":     "*.log\ngen/\n",
+		".ignore":        "!keep.log\n*.tmp\n",
+		"sub/.gitignore": "!*.tmp\n",
+		"sub/.rgignore":  "!gen/\nlocal.txt\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := Load(root)
+	m.LoadDir(root, "sub")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"keep.log", false, false},     // .ignore overrides .gitignore
+		{"a.tmp", false, true},         // only in .ignore
+		{"sub/a.tmp", false, true},     // a nested .gitignore does not override .ignore
+		{"gen", true, true},            // ignored by .gitignore
+		{"sub/gen", true, false},       // .rgignore overrides .gitignore
+		{"sub/local.txt", false, true}, // only in .rgignore
+		{"local.txt", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package gitignore
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// SuggestIgnoresArgs defines the arguments for the suggest_ignores tool.
+type SuggestIgnoresArgs struct {
+	Path         string `json:"path" description:"The root of the repository to scan." required:"true"`
+	MinLargeSize *int64 `json:"min_large_size,omitempty" description:"Optional. Files at least this many bytes that are not ignored are listed for review. Defaults to 10 MB; 0 disables the check."`
+	Apply        *bool  `json:"apply,omitempty" description:"Optional. If true, append the suggested patterns (not the large files) to the root .gitignore."`
+}
+
+// defaultMinLargeSize is the size from which unignored files are reported.
+const defaultMinLargeSize int64 = 10 * 1024 * 1024
+
+// artifact is a well-known build output, cache or editor file. A directory
+// artifact with markers is only suggested when its parent directory contains
+// one of the marker files, so a source directory that happens to be called
+// build or target is left alone.
+type artifact struct {
+	pattern string // matched against the base name
+	reason  string
+	markers []string
+}
+
+var artifactDirs = []artifact{
+	{pattern: "node_modules", reason: "Node.js dependencies"},
+	{pattern: "bower_components", reason: "Bower dependencies"},
+	{pattern: ".next", reason: "Next.js build output"},
+	{pattern: ".nuxt", reason: "Nuxt build output"},
+	{pattern: ".svelte-kit", reason: "SvelteKit build output"},
+	{pattern: ".parcel-cache", reason: "Parcel cache"},
+	{pattern: ".turbo", reason: "Turborepo cache"},
+	{pattern: "dist", reason: "build output", markers: []string{"package.json", "setup.py", "pyproject.toml"}},
+	{pattern: "build", reason: "build output", markers: []string{"package.json", "setup.py", "pyproject.toml", "build.gradle", "build.gradle.kts", "CMakeLists.txt"}},
+	{pattern: "target", reason: "Cargo or Maven build output", markers: []string{"Cargo.toml", "pom.xml"}},
+	{pattern: "bin", reason: ".NET build output", markers: []string{"*.csproj", "*.fsproj"}},
+	{pattern: "obj", reason: ".NET build output", markers: []string{"*.csproj", "*.fsproj"}},
+	{pattern: "coverage", reason: "test coverage report", markers: []string{"package.json"}},
+	{pattern: "__pycache__", reason: "Python bytecode cache"},
+	{pattern: ".pytest_cache", reason: "pytest cache"},
+	{pattern: ".mypy_cache", reason: "mypy cache"},
+	{pattern: ".ruff_cache", reason: "Ruff cache"},
+	{pattern: ".tox", reason: "tox environments"},
+	{pattern: ".venv", reason: "Python virtual environment"},
+	{pattern: "venv", reason: "Python virtual environment", markers: []string{"requirements.txt", "setup.py", "pyproject.toml"}},
+	{pattern: "htmlcov", reason: "coverage.py HTML report"},
+	{pattern: "*.egg-info", reason: "Python packaging metadata"},
+	{pattern: ".gradle", reason: "Gradle cache"},
+	{pattern: ".terraform", reason: "Terraform providers and modules"},
+	{pattern: ".dart_tool", reason: "Dart tool cache"},
+}
+
+var artifactFiles = []artifact{
+	{pattern: "*.pyc", reason: "Python bytecode"},
+	{pattern: "*.class", reason: "Java class files"},
+	{pattern: "*.o", reason: "object files"},
+	{pattern: "*.test", reason: "Go test binaries"},
+	{pattern: "coverage.out", reason: "Go coverage profile"},
+	{pattern: ".coverage", reason: "coverage.py data"},
+	{pattern: "*.log", reason: "log files"},
+	{pattern: "*.swp", reason: "Vim swap files"},
+	{pattern: ".DS_Store", reason: "macOS Finder metadata"},
+	{pattern: "Thumbs.db", reason: "Windows thumbnail cache"},
+}
+
+// suggestion is an ignore pattern with the paths that justify it.
+type suggestion struct {
+	pattern string
+	reason  string
+	count   int
+	size    int64
+}
+
+// humanSize formats a byte count with a binary unit.
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// dirSize returns the total size of the files under dir.
+func dirSize(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// hasMarker reports whether dir contains a file matching one of markers.
+func hasMarker(dir string, markers []string) bool {
+	for _, marker := range markers {
+		if matches, _ := filepath.Glob(filepath.Join(dir, marker)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchArtifact returns the first artifact whose pattern matches name.
+func matchArtifact(list []artifact, name string) *artifact {
+	for i := range list {
+		if ok, _ := filepath.Match(list[i].pattern, name); ok {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// HandleSuggestIgnores implements the suggest_ignores tool.
+func HandleSuggestIgnores(ctx *server.Context, args SuggestIgnoresArgs) (string, error) {
+	ctx.Logger.Info("Handling suggest_ignores tool call")
+
+	root := args.Path
+	if info, err := os.Stat(root); err != nil {
+		if os.IsNotExist(err) {
+			return i18n.T(ctx, i18n.FileNotFound), nil
+		}
+		return i18n.T(ctx, i18n.FileAccessError), err
+	} else if !info.IsDir() {
+		return i18n.T(ctx, i18n.PathNotDirectory, root), nil
+	}
+	minLarge := defaultMinLargeSize
+	if args.MinLargeSize != nil {
+		minLarge = *args.MinLargeSize
+	}
+
+	matcher := Load(root)
+	found := make(map[string]*suggestion)
+	var order []*suggestion
+	record := func(pattern, reason string, size int64) {
+		s, ok := found[pattern]
+		if !ok {
+			s = &suggestion{pattern: pattern, reason: reason}
+			found[pattern] = s
+			order = append(order, s)
+		}
+		s.count++
+		s.size += size
+	}
+	var large []string
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" || matcher.Ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			if a := matchArtifact(artifactDirs, d.Name()); a != nil && (a.markers == nil || hasMarker(filepath.Dir(p), a.markers)) {
+				record(a.pattern+"/", a.reason, dirSize(p))
+				return filepath.SkipDir
+			}
+			matcher.LoadDir(root, rel)
+			return nil
+		}
+		if !d.Type().IsRegular() || matcher.Ignored(rel, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if a := matchArtifact(artifactFiles, d.Name()); a != nil {
+			record(a.pattern, a.reason, info.Size())
+			return nil
+		}
+		if minLarge > 0 && info.Size() >= minLarge {
+			large = append(large, fmt.Sprintf("%s  # %s", rel, humanSize(info.Size())))
+		}
+		return nil
+	})
+	if err != nil {
+		return i18n.T(ctx, i18n.FileReadError), err
+	}
+
+	if len(order) == 0 && len(large) == 0 {
+		return i18n.T(ctx, i18n.IgnoreNothingToSuggest, root), nil
+	}
+
+	// Largest savings first
+	sort.SliceStable(order, func(i, j int) bool { return order[i].size > order[j].size })
+	var b strings.Builder
+	if len(order) > 0 {
+		b.WriteString(i18n.T(ctx, i18n.IgnoreSuggestions, root) + "\n")
+		for _, s := range order {
+			fmt.Fprintf(&b, "%s  # %s: %d match(es), %s\n", s.pattern, s.reason, s.count, humanSize(s.size))
+		}
+	}
+	if len(large) > 0 {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(i18n.T(ctx, i18n.IgnoreLargeFiles) + "\n")
+		b.WriteString(strings.Join(large, "\n") + "\n")
+	}
+
+	if args.Apply != nil && *args.Apply && len(order) > 0 {
+		ignoreFile := filepath.Join(root, ".gitignore")
+		existing, err := os.ReadFile(ignoreFile)
+		if err != nil && !os.IsNotExist(err) {
+			return i18n.T(ctx, i18n.FileReadError), err
+		}
+		updated := string(existing)
+		if updated != "" && !strings.HasSuffix(updated, "\n") {
+			updated += "\n"
+		}
+		for _, s := range order {
+			updated += s.pattern + "\n"
+		}
+		pending := journal.Capture(ctx.Logger, ignoreFile, "suggest_ignores")
+		if err := os.WriteFile(ignoreFile, []byte(updated), 0644); err != nil {
+			ctx.Logger.Info("Error writing .gitignore", "filePath", ignoreFile, "error", err)
+			return i18n.T(ctx, i18n.FileWriteError), err
+		}
+		pending.Commit([]byte(updated))
+		b.WriteString("\n" + i18n.T(ctx, i18n.IgnoreApplied, len(order), ignoreFile))
+	}
+
+	ctx.Logger.Info("Ignore suggestions generated", "root", root, "patterns", len(order), "largeFiles", len(large))
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
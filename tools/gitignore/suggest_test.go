@@ -0,0 +1,75 @@
+package gitignore
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestHandleSuggestIgnores(t *testing.T) {
+	ctx := &server.Context{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":                 "*.log",
+		"package.json":               "{}",
+		"node_modules/left-pad/x.js": strings.Repeat("x", 2048),
+		"dist/app.js":                "bundle",
+		"src/build/keep.go":          "package build",
+		"py/__pycache__/m.pyc":       "bytecode",
+		"py/m.pyc":                   "stray",
+		"server.log":                 "already ignored",
+		"data/dump.sql":              strings.Repeat("d", 4096),
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	minLarge := int64(4096)
+	apply := true
+	result, err := HandleSuggestIgnores(ctx, SuggestIgnoresArgs{Path: root, MinLargeSize: &minLarge, Apply: &apply})
+	if err != nil {
+		t.Fatalf("HandleSuggestIgnores() error = %v", err)
+	}
+	for _, want := range []string{
+		"node_modules/  # Node.js dependencies: 1 match(es), 2.0 KiB",
+		"dist/  # build output: 1 match(es), 6 B",
+		"__pycache__/  # Python bytecode cache",
+		"*.pyc  # Python bytecode: 1 match(es), 5 B",
+		"data/dump.sql  # 4.0 KiB",
+		"Appended 4 pattern(s)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+	for _, unwanted := range []string{"build/", "*.log"} {
+		if strings.Contains(result, unwanted) {
+			t.Errorf("result should not suggest %q:\n%s", unwanted, result)
+		}
+	}
+	if strings.Index(result, "node_modules/") > strings.Index(result, "dist/") {
+		t.Errorf("suggestions should be ordered by size:\n%s", result)
+	}
+
+	ignore, _ := os.ReadFile(filepath.Join(root, ".gitignore"))
+	if want := "*.log\nnode_modules/\n"; !strings.HasPrefix(string(ignore), want) || strings.Count(string(ignore), "\n") != 5 {
+		t.Errorf(".gitignore = %q", ignore)
+	}
+
+	// Once applied, only the large file is left to review
+	result, _ = HandleSuggestIgnores(ctx, SuggestIgnoresArgs{Path: root, MinLargeSize: &minLarge})
+	if strings.Contains(result, "Suggested") || !strings.Contains(result, "data/dump.sql") {
+		t.Errorf("second scan = %q", result)
+	}
+}
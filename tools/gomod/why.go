@@ -0,0 +1,222 @@
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// GoModWhyArgs defines the arguments for the go_mod_why tool.
+type GoModWhyArgs struct {
+	Path      string   `json:"path" description:"The directory containing the go.mod file." required:"true"`
+	Modules   []string `json:"modules,omitempty" description:"Optional module paths to explain. Defaults to every requirement in go.mod."`
+	TimeoutMs *int     `json:"timeout_ms,omitempty" description:"Optional timeout in milliseconds for the go commands. Defaults to 2 minutes."`
+}
+
+// defaultTimeout bounds the go commands, which may download modules.
+const defaultTimeout = 2 * time.Minute
+
+// ModuleReport explains why one module is in the build.
+type ModuleReport struct {
+	Path string `json:"path"`
+	// Version is the version required by go.mod, if the module is listed there.
+	Version string `json:"version,omitempty"`
+	// Requirement is "direct" or "indirect" for modules listed in go.mod.
+	Requirement string `json:"requirement,omitempty"`
+	// Needed reports whether any package of the module is imported, directly
+	// or through dependencies, by the main module's packages or tests.
+	Needed bool `json:"needed"`
+	// ImportChain is the shortest import path from the main module to a
+	// package of this module, as reported by go mod why.
+	ImportChain []string `json:"import_chain,omitempty"`
+	// RequiredBy lists the modules whose go.mod requires this one.
+	RequiredBy []string `json:"required_by,omitempty"`
+}
+
+// ModWhyReport is returned by go_mod_why.
+type ModWhyReport struct {
+	Module  string         `json:"module"`
+	Modules []ModuleReport `json:"modules"`
+	// CandidateRemovals are go.mod requirements no package needs; go mod
+	// tidy would normally drop them.
+	CandidateRemovals []string `json:"candidate_removals"`
+}
+
+// goModFile is the part of `go mod edit -json` output the tool uses.
+type goModFile struct {
+	Module struct {
+		Path string
+	}
+	Require []struct {
+		Path     string
+		Version  string
+		Indirect bool
+	}
+}
+
+// runGo runs a go subcommand in dir and returns its standard output. A failure
+// is reported with the command's standard error.
+func runGo(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseModGraph maps each module path to the modules that require it, as
+// "path@version" (or just the path for the main module), from `go mod graph`
+// output.
+func parseModGraph(output []byte) map[string][]string {
+	requiredBy := make(map[string][]string)
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		from, to, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		path, _, _ := strings.Cut(to, "@")
+		if key := path + " " + from; !seen[key] {
+			seen[key] = true
+			requiredBy[path] = append(requiredBy[path], from)
+		}
+	}
+	for _, froms := range requiredBy {
+		sort.Strings(froms)
+	}
+	return requiredBy
+}
+
+// parseModWhy splits `go mod why -m` output into the import chain for each
+// module. Modules the main module does not need map to an empty chain.
+func parseModWhy(output []byte) map[string][]string {
+	chains := make(map[string][]string)
+	current := ""
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "# "):
+			current = strings.TrimPrefix(line, "# ")
+			chains[current] = nil
+		case line == "" || current == "" || strings.HasPrefix(line, "("):
+			// Blank separators and "(main module does not need ...)"
+		default:
+			chains[current] = append(chains[current], line)
+		}
+	}
+	return chains
+}
+
+// HandleGoModWhy implements the go_mod_why tool.
+func HandleGoModWhy(ctx *server.Context, args GoModWhyArgs) (string, error) {
+	ctx.Logger.Info("Handling go_mod_why tool call")
+
+	dir, err := filepath.Abs(args.Path)
+	if err != nil {
+		return i18n.T(ctx, i18n.FileAccessError), err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+		return i18n.T(ctx, i18n.GoModMissing, dir), nil
+	}
+
+	timeout := defaultTimeout
+	if args.TimeoutMs != nil && *args.TimeoutMs > 0 {
+		timeout = time.Duration(*args.TimeoutMs) * time.Millisecond
+	}
+	runCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	editJSON, err := runGo(runCtx, dir, "mod", "edit", "-json")
+	if err != nil {
+		return i18n.T(ctx, i18n.GoCommandFailed, "go mod edit -json", err), nil
+	}
+	var modFile goModFile
+	if err := json.Unmarshal(editJSON, &modFile); err != nil {
+		return i18n.T(ctx, i18n.GoCommandFailed, "go mod edit -json", err), nil
+	}
+
+	graph, err := runGo(runCtx, dir, "mod", "graph")
+	if err != nil {
+		return i18n.T(ctx, i18n.GoCommandFailed, "go mod graph", err), nil
+	}
+	requiredBy := parseModGraph(graph)
+
+	report := ModWhyReport{Module: modFile.Module.Path, Modules: []ModuleReport{}, CandidateRemovals: []string{}}
+	indexOf := make(map[string]int)
+	for _, req := range modFile.Require {
+		requirement := "direct"
+		if req.Indirect {
+			requirement = "indirect"
+		}
+		indexOf[req.Path] = len(report.Modules)
+		report.Modules = append(report.Modules, ModuleReport{Path: req.Path, Version: req.Version, Requirement: requirement})
+	}
+	if len(args.Modules) > 0 {
+		// Only the requested modules, in the order given
+		requested := make([]ModuleReport, 0, len(args.Modules))
+		for _, path := range args.Modules {
+			if i, ok := indexOf[path]; ok {
+				requested = append(requested, report.Modules[i])
+			} else {
+				requested = append(requested, ModuleReport{Path: path})
+			}
+		}
+		report.Modules = requested
+	}
+	if len(report.Modules) == 0 {
+		return marshalReport(ctx, report)
+	}
+
+	paths := make([]string, len(report.Modules))
+	for i, m := range report.Modules {
+		paths[i] = m.Path
+	}
+	why, err := runGo(runCtx, dir, append([]string{"mod", "why", "-m"}, paths...)...)
+	if err != nil {
+		return i18n.T(ctx, i18n.GoCommandFailed, "go mod why -m", err), nil
+	}
+	chains := parseModWhy(why)
+
+	for i := range report.Modules {
+		m := &report.Modules[i]
+		m.ImportChain = chains[m.Path]
+		m.Needed = len(m.ImportChain) > 0
+		m.RequiredBy = requiredBy[m.Path]
+		if !m.Needed && m.Requirement != "" {
+			report.CandidateRemovals = append(report.CandidateRemovals, m.Path)
+		}
+	}
+
+	ctx.Logger.Info("go_mod_why finished", "module", report.Module, "modules", len(report.Modules), "candidates", len(report.CandidateRemovals))
+	return marshalReport(ctx, report)
+}
+
+func marshalReport(ctx *server.Context, report ModWhyReport) (string, error) {
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling go_mod_why report", "error", err)
+		return "Error generating go_mod_why report", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,117 @@
+package gomod
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestParseModGraph(t *testing.T) {
+	output := []byte(`example.com/app example.com/lib@v1.2.0
+example.com/app golang.org/x/text@v0.3.0
+example.com/lib@v1.2.0 golang.org/x/text@v0.14.0
+example.com/lib@v1.1.0 golang.org/x/text@v0.14.0
+example.com/lib@v1.2.0 golang.org/x/text@v0.14.0
+`)
+	got := parseModGraph(output)
+	want := map[string][]string{
+		"example.com/lib":   {"example.com/app"},
+		"golang.org/x/text": {"example.com/app", "example.com/lib@v1.1.0", "example.com/lib@v1.2.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseModGraph() = %v, want %v", got, want)
+	}
+}
+
+func TestParseModWhy(t *testing.T) {
+	output := []byte(`# example.com/lib
+example.com/app
+example.com/lib/util
+
+# golang.org/x/text
+(main module does not need module golang.org/x/text)
+`)
+	got := parseModWhy(output)
+	want := map[string][]string{
+		"example.com/lib":   {"example.com/app", "example.com/lib/util"},
+		"golang.org/x/text": nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseModWhy() = %v, want %v", got, want)
+	}
+}
+
+func writeModule(t *testing.T, dir, goMod, source string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandleGoModWhy(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOWORK", "off")
+
+	root := t.TempDir()
+	writeModule(t, filepath.Join(root, "used"), "module example.com/used\n\ngo 1.21\n", "package used\n\nconst Name = \"used\"\n")
+	writeModule(t, filepath.Join(root, "unused"), "module example.com/unused\n\ngo 1.21\n", "package unused\n")
+	writeModule(t, filepath.Join(root, "app"), `module example.com/app
+
+go 1.21
+
+require (
+	example.com/unused v0.0.0
+	example.com/used v0.0.0
+)
+
+replace example.com/used => ../used
+
+replace example.com/unused => ../unused
+`, "package main\n\nimport \"example.com/used\"\n\nfunc main() { println(used.Name) }\n")
+
+	ctx := &server.Context{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	result, err := HandleGoModWhy(ctx, GoModWhyArgs{Path: filepath.Join(root, "app")})
+	if err != nil {
+		t.Fatalf("HandleGoModWhy() error = %v", err)
+	}
+	var report ModWhyReport
+	if err := json.Unmarshal([]byte(result), &report); err != nil {
+		t.Fatalf("result is not a report: %v\n%s", err, result)
+	}
+
+	if report.Module != "example.com/app" || len(report.Modules) != 2 {
+		t.Fatalf("unexpected report: %s", result)
+	}
+	used := report.Modules[1]
+	if !used.Needed || used.Requirement != "direct" || !reflect.DeepEqual(used.ImportChain, []string{"example.com/app", "example.com/used"}) {
+		t.Errorf("used = %+v", used)
+	}
+	if !reflect.DeepEqual(used.RequiredBy, []string{"example.com/app"}) {
+		t.Errorf("used.RequiredBy = %v", used.RequiredBy)
+	}
+	if !reflect.DeepEqual(report.CandidateRemovals, []string{"example.com/unused"}) {
+		t.Errorf("CandidateRemovals = %v", report.CandidateRemovals)
+	}
+
+	result, _ = HandleGoModWhy(ctx, GoModWhyArgs{Path: root})
+	if result != "No go.mod found in "+root+"." {
+		t.Errorf("result = %q", result)
+	}
+}
@@ -0,0 +1,122 @@
+package health
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// Checker answers the health, readiness and build-info endpoints.
+type Checker struct {
+	version string
+	started time.Time
+	logger  *slog.Logger
+	ready   atomic.Bool
+}
+
+// BuildInfo is the body of /buildinfo.
+type BuildInfo struct {
+	Version   string            `json:"version"`
+	GoVersion string            `json:"goVersion"`
+	Platform  string            `json:"platform"`
+	Revision  string            `json:"revision,omitempty"`
+	Modified  bool              `json:"modified,omitempty"`
+	Settings  map[string]string `json:"settings,omitempty"` // Build settings such as CGO_ENABLED and -ldflags
+	StartTime string            `json:"startTime"`
+}
+
+// New returns a Checker for a server built as version. It reports not ready
+// until SetReady is called.
+func New(version string, logger *slog.Logger) *Checker {
+	return &Checker{version: version, started: time.Now(), logger: logger}
+}
+
+// SetReady marks the server as ready, or not, to take tool calls.
+func (c *Checker) SetReady(ready bool) {
+	c.ready.Store(ready)
+}
+
+// Handler serves /healthz, /readyz and /buildinfo. /healthz answers 200 while
+// the process is up. /readyz answers 200 once the server is ready and its
+// configuration loads, and 503 with the reason otherwise.
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeText(w, http.StatusOK, "ok")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !c.ready.Load() {
+			writeText(w, http.StatusServiceUnavailable, "starting")
+			return
+		}
+		if _, err := config.GetCurrentConfig(&server.Context{Logger: c.logger}); err != nil {
+			writeText(w, http.StatusServiceUnavailable, "configuration: "+err.Error())
+			return
+		}
+		writeText(w, http.StatusOK, "ok")
+	})
+	mux.HandleFunc("GET /buildinfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.buildInfo())
+	})
+	return mux
+}
+
+// buildInfo describes the running binary.
+func (c *Checker) buildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   c.version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		StartTime: c.started.UTC().Format(time.RFC3339),
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		case "CGO_ENABLED", "-ldflags", "-tags", "-trimpath":
+			if info.Settings == nil {
+				info.Settings = make(map[string]string)
+			}
+			info.Settings[s.Key] = s.Value
+		}
+	}
+	return info
+}
+
+func writeText(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(body + "\n"))
+}
+
+// Serve listens on addr and serves c's endpoints in the background. The
+// listener is separate from the MCP transport, which may be stdio.
+func Serve(addr string, c *Checker) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: c.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			c.logger.Error("Health endpoint server stopped", "error", err)
+		}
+	}()
+	return srv, nil
+}
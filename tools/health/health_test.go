@@ -0,0 +1,70 @@
+package health
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	c := New("1.2.3", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ts := httptest.NewServer(c.Handler())
+	defer ts.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := get("/healthz"); status != http.StatusOK || strings.TrimSpace(body) != "ok" {
+		t.Errorf("/healthz = %d %q, want 200 ok", status, body)
+	}
+	if status, body := get("/readyz"); status != http.StatusServiceUnavailable || !strings.Contains(body, "starting") {
+		t.Errorf("/readyz before SetReady = %d %q, want 503 starting", status, body)
+	}
+	c.SetReady(true)
+	if status, body := get("/readyz"); status != http.StatusOK {
+		t.Errorf("/readyz after SetReady = %d %q, want 200", status, body)
+	}
+
+	status, body := get("/buildinfo")
+	var info BuildInfo
+	if err := json.Unmarshal([]byte(body), &info); status != http.StatusOK || err != nil {
+		t.Fatalf("/buildinfo = %d %q (%v)", status, body, err)
+	}
+	if info.Version != "1.2.3" || info.GoVersion != runtime.Version() || info.Platform != runtime.GOOS+"/"+runtime.GOARCH || info.StartTime == "" {
+		t.Errorf("/buildinfo = %+v", info)
+	}
+
+	resp, err := http.Post(ts.URL+"/healthz", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST /healthz = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestServe(t *testing.T) {
+	c := New("dev", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	srv, err := Serve("127.0.0.1:0", c)
+	if err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+	srv.Close()
+	if _, err := Serve("127.0.0.1:-1", c); err == nil {
+		t.Error("Expected an invalid address to be reported")
+	}
+}
@@ -0,0 +1,1018 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// DefaultLocale is used when no locale is configured or a message is missing from the catalog.
+const DefaultLocale = "en"
+
+// Message keys for user-facing tool messages.
+const (
+	FileWritten               = "file.written"
+	FileEdited                = "file.edited"
+	FileMoved                 = "file.moved"
+	FileNotFound              = "file.not_found"
+	DirectoryCreated          = "directory.created"
+	EditsReverted             = "journal.edits_reverted"
+	CommandStarted            = "terminal.command_started"
+	CommandBlocked            = "terminal.command_blocked"
+	TerminationSent           = "terminal.termination_sent"
+	SearchTimedOut            = "search.timed_out"
+	InvalidLineRange          = "read.invalid_line_range"
+	ExpectedPositive          = "edit.expected_positive"
+	StartLinePositive         = "edit.start_line_positive"
+	EndLineBeforeStart        = "edit.end_line_before_start"
+	FileAccessError           = "file.access_error"
+	FileReadError             = "file.read_error"
+	FileWriteError            = "file.write_error"
+	FileTooLarge              = "file.too_large"
+	FileInfoError             = "file.info_error"
+	MoveFailed                = "file.move_failed"
+	DirectoryCreateError      = "directory.create_error"
+	DirectoryReadError        = "directory.read_error"
+	ReplacementsShort         = "edit.replacements_short"
+	ReplacementFailed         = "edit.replacement_failed"
+	InternalEditError         = "edit.internal_error"
+	FuzzyThresholdRange       = "edit.fuzzy_threshold_range"
+	FuzzyApplied              = "edit.fuzzy_applied"
+	MatchModeConflict         = "edit.match_mode_conflict"
+	WhitespaceAmbiguous       = "edit.whitespace_ambiguous"
+	InsertLineRange           = "edit.insert_line_range"
+	DeleteLineRange           = "edit.delete_line_range"
+	NoEditsProvided           = "edit.no_edits"
+	EditsNotApplied           = "edit.not_applied"
+	EditsStagingFailed        = "edit.staging_failed"
+	EditsRolledBack           = "edit.rolled_back"
+	EditsRollbackFailed       = "edit.rollback_failed"
+	EditsApplied              = "edit.applied"
+	UndoFailed                = "journal.undo_failed"
+	CommandBlockedSecurity    = "process.command_blocked"
+	ProcessNotFound           = "process.not_found"
+	SignalFailed              = "process.signal_failed"
+	MoveSourceRemains         = "file.move_source_remains"
+	MergeInvalidPolicy        = "merge.invalid_policy"
+	MergeSourceNotDir         = "merge.source_not_dir"
+	MergeDestinationNotDir    = "merge.destination_not_dir"
+	MergeIntoItself           = "merge.into_itself"
+	FormatApplied             = "format.applied"
+	FormatUnchanged           = "format.unchanged"
+	FormatFailed              = "format.failed"
+	LineEndingInvalid         = "edit.line_ending_invalid"
+	LineEndingsConverted      = "edit.line_endings_converted"
+	LineEndingsUnchanged      = "edit.line_endings_unchanged"
+	LinksDisabled             = "link.disabled"
+	PathNotAllowed            = "path.not_allowed"
+	LinkCreateError           = "link.create_error"
+	SymlinkCreated            = "link.symlink_created"
+	HardlinkCreated           = "link.hardlink_created"
+	SymlinkPrivilege          = "link.symlink_privilege"
+	HardlinkNotRegular        = "link.hardlink_not_regular"
+	SemanticFormatInvalid     = "semantic.format_invalid"
+	SemanticFormatUnknown     = "semantic.format_unknown"
+	SemanticParseError        = "semantic.parse_error"
+	SemanticNoDifferences     = "semantic.no_differences"
+	SemanticDifferences       = "semantic.differences"
+	SemanticAdded             = "semantic.added"
+	SemanticRemoved           = "semantic.removed"
+	SemanticMoved             = "semantic.moved"
+	SemanticChanged           = "semantic.changed"
+	JSONEditInvalidOp         = "json_edit.invalid_op"
+	JSONEditInvalidValue      = "json_edit.invalid_value"
+	JSONEditValueRequired     = "json_edit.value_required"
+	JSONEditPathNotFound      = "json_edit.path_not_found"
+	JSONEditNotArray          = "json_edit.not_array"
+	JSONEditIndexRange        = "json_edit.index_range"
+	JSONEditDeleteRoot        = "json_edit.delete_root"
+	JSONEditSet               = "json_edit.set"
+	JSONEditDeleted           = "json_edit.deleted"
+	JSONEditAppended          = "json_edit.appended"
+	StructuredInvalidDocument = "structured.invalid_document"
+	StructuredInvalidResult   = "structured.invalid_result"
+	TOMLNullValue             = "toml_edit.null_value"
+	TOMLTablePath             = "toml_edit.table_path"
+	NotebookInvalid           = "notebook.invalid"
+	NotebookCellRange         = "notebook.cell_range"
+	NotebookCellNotFound      = "notebook.cell_not_found"
+	NotebookInvalidOperation  = "notebook.invalid_operation"
+	NotebookInvalidCellType   = "notebook.invalid_cell_type"
+	NotebookSourceRequired    = "notebook.source_required"
+	NotebookCellReplaced      = "notebook.cell_replaced"
+	NotebookCellInserted      = "notebook.cell_inserted"
+	NotebookCellDeleted       = "notebook.cell_deleted"
+	SQLiteOpenError           = "sqlite.open_error"
+	SQLiteNoDatabases         = "sqlite.no_databases"
+	SQLiteDatabasesFound      = "sqlite.databases_found"
+	SQLiteTableNotFound       = "sqlite.table_not_found"
+	SQLiteWithoutRowid        = "sqlite.without_rowid"
+	SQLiteShellMissing        = "sqlite.shell_missing"
+	SQLiteDotCommand          = "sqlite.dot_command"
+	SQLiteQueryFailed         = "sqlite.query_failed"
+	SQLiteRowsTruncated       = "sqlite.rows_truncated"
+	TransformNoOperations     = "transform.no_operations"
+	TransformInvalidOperation = "transform.invalid_operation"
+	TransformApplied          = "transform.applied"
+	TransformUnchanged        = "transform.unchanged"
+	GoModMissing              = "gomod.missing"
+	GoCommandFailed           = "gomod.command_failed"
+	IndentNothingToDo         = "indent.nothing_to_do"
+	IndentInvalidConvert      = "indent.invalid_convert"
+	IndentShiftTooFar         = "indent.shift_too_far"
+	IndentAdjusted            = "indent.adjusted"
+	IgnoreSuggestions         = "ignore.suggestions"
+	IgnoreNothingToSuggest    = "ignore.nothing"
+	IgnoreLargeFiles          = "ignore.large_files"
+	IgnoreApplied             = "ignore.applied"
+	PathNotDirectory          = "path.not_directory"
+	MergeFilesInvalidStyle    = "merge_files.invalid_style"
+	MergeFilesSummary         = "merge_files.summary"
+	MergeFilesWritten         = "merge_files.written"
+	GeneratedHeaderAdded      = "generated.header_added"
+	StreamEditApplied         = "edit.stream_applied"
+	ProxyCallFailed           = "proxy.call_failed"
+	TemplateInvalidName       = "template.invalid_name"
+	TemplateNotFound          = "template.not_found"
+	TemplateExpandFailed      = "template.expand_failed"
+	TemplateFileExists        = "template.file_exists"
+	TemplateWritten           = "template.written"
+	TemplateInserted          = "template.inserted"
+	EditAmbiguous             = "edit.ambiguous"
+	EditAmbiguousMore         = "edit.ambiguous_more"
+	FileCreatedByEdit         = "edit.file_created"
+	ColumnsInvalidOperation   = "edit.columns_invalid_operation"
+	ColumnsEndRequired        = "edit.columns_end_required"
+	ColumnsRange              = "edit.columns_range"
+	ColumnsTextRequired       = "edit.columns_text_required"
+	ColumnsTextLines          = "edit.columns_text_lines"
+	ColumnsUnchanged          = "edit.columns_unchanged"
+	ColumnsApplied            = "edit.columns_applied"
+	UsageCallLimit            = "usage.call_limit"
+	UsageToolLimit            = "usage.tool_limit"
+	UsageBytesLimit           = "usage.bytes_limit"
+	PolicyDenied              = "policy.denied"
+	PolicyDeniedReason        = "policy.denied_reason"
+	PolicyError               = "policy.error"
+	PatchParseError           = "patch.parse_error"
+	PatchEmpty                = "patch.empty"
+	PatchHunks                = "patch.hunks"
+	PatchHunkApplies          = "patch.hunk_applies"
+	PatchHunkOffset           = "patch.hunk_offset"
+	PatchHunkFails            = "patch.hunk_fails"
+	PatchHunkMissing          = "patch.hunk_missing"
+	PatchNotFound             = "patch.not_found"
+	PatchNoHunks              = "patch.no_hunks"
+	PatchUnknownHunk          = "patch.unknown_hunk"
+	PatchConflict             = "patch.conflict"
+	PatchApplied              = "patch.applied"
+	SearchFormatInvalid       = "search.format_invalid"
+	PreviewReplaceSummary     = "search.preview_replace_summary"
+	PreviewReplaceTruncated   = "search.preview_replace_truncated"
+	PreviewReplaceNoMatches   = "search.preview_replace_no_matches"
+	SymbolKindInvalid         = "search.symbol_kind_invalid"
+	SymbolPatternInvalid      = "search.symbol_pattern_invalid"
+	SymbolsNotFound           = "search.symbols_not_found"
+	SymbolsTruncated          = "search.symbols_truncated"
+	TodosNotFound             = "search.todos_not_found"
+	TodosSummary              = "search.todos_summary"
+	TodosTruncated            = "search.todos_truncated"
+	SearchStreamed            = "search.streamed"
+	SearchCursorInvalid       = "search.cursor_invalid"
+	SearchNextPage            = "search.next_page"
+	SearchFilesInvalidType    = "search.files_invalid_type"
+	SearchFilesInvalidTime    = "search.files_invalid_time"
+	SearchHexInvalid          = "search.hex_invalid"
+	SearchPatternTooComplex   = "search.pattern_too_complex"
+	SearchOverBudget          = "search.over_budget"
+	InputSent                 = "terminal.input_sent"
+	InputClosed               = "terminal.input_closed"
+	TerminalResized           = "terminal.resized"
+	CommandNotAllowed         = "terminal.command_not_allowed"
+	CommandRuleDenied         = "terminal.command_rule_denied"
+	CommandRuleDeniedReason   = "terminal.command_rule_denied_reason"
+	ContainerUnavailable      = "terminal.container_unavailable"
+	MacroNotFound             = "macro.not_found"
+	MacroMissingParams        = "macro.missing_params"
+	MacroUnknownParams        = "macro.unknown_params"
+	MacroUnsafeValue          = "macro.unsafe_value"
+	MacroRunning              = "macro.running"
+	SessionLimitReached       = "terminal.session_limit_reached"
+	SessionQueueTimeout       = "terminal.session_queue_timeout"
+	CommandQueued             = "terminal.command_queued"
+	CommandStartedQueued      = "terminal.command_started_queued"
+)
+
+// catalog maps a locale to its translated messages. Messages may contain fmt verbs.
+var catalog = map[string]map[string]string{
+	"en": {
+		FileWritten:               "File written successfully.",
+		FileEdited:                "File edited successfully.",
+		FileMoved:                 "File moved/renamed successfully.",
+		FileNotFound:              "Error: File not found.",
+		DirectoryCreated:          "Directory created successfully.",
+		EditsReverted:             "Reverted %d edit(s) to %s.",
+		CommandStarted:            "Command started in background with PID: %d",
+		CommandBlocked:            "Command execution blocked: Command '%s' is blocked or syntax is invalid/unsupported for validation.",
+		TerminationSent:           "Termination signal sent to PID %d.",
+		SearchTimedOut:            "Search timed out.",
+		InvalidLineRange:          "Invalid line range: start_line must be <= end_line",
+		ExpectedPositive:          "expected_replacements must be positive",
+		StartLinePositive:         "start_line must be positive and 1-indexed",
+		EndLineBeforeStart:        "end_line cannot be less than start_line - 1",
+		FileAccessError:           "Error accessing file information.",
+		FileReadError:             "Error reading file.",
+		FileWriteError:            "Error writing file.",
+		FileTooLarge:              "Error: File size (%d bytes) exceeds the %d MB limit for this editing tool due to memory constraints. Please use a different tool or method for editing very large files. If this is a source code file, consider splitting it into smaller modules/files if appropriate for the language.",
+		FileInfoError:             "Error getting file info.",
+		MoveFailed:                "Error moving/renaming file.",
+		DirectoryCreateError:      "Error creating directory.",
+		DirectoryReadError:        "Error reading directory.",
+		ReplacementsShort:         "Expected %d replacements, but only found %d occurrences of the old string.",
+		ReplacementFailed:         "Replacement failed unexpectedly for %d expected replacements despite %d occurrences.",
+		InternalEditError:         "Internal error during replacement.",
+		FuzzyThresholdRange:       "fuzzy_threshold must be greater than 0 and at most 1",
+		FuzzyApplied:              "File edited successfully using fuzzy match at lines %d-%d (similarity %.2f). Applied change:\n%s",
+		MatchModeConflict:         "fuzzy_apply and ignore_whitespace locate a single block; they cannot be combined with expected_replacements greater than 1",
+		WhitespaceAmbiguous:       "Whitespace-insensitive match is ambiguous: old_string matches %d blocks starting at lines %s. Add more context to old_string.",
+		InsertLineRange:           "line (%d) must be between 1 and %d (the number of lines + 1)",
+		DeleteLineRange:           "invalid range %d-%d: start_line must be >= 1, end_line must be >= start_line and <= %d (the number of lines)",
+		NoEditsProvided:           "No edits provided.",
+		EditsNotApplied:           "No files were changed. %s",
+		EditsStagingFailed:        "No files were changed. Error staging %s",
+		EditsRolledBack:           "Error applying edits to %s; all changes were rolled back.",
+		EditsRollbackFailed:       "Error applying edits to %s; rollback failed for: %s",
+		EditsApplied:              "Applied %d edit(s) across %d file(s).",
+		UndoFailed:                "Error undoing edit: %s",
+		CommandBlockedSecurity:    "Error: Execution of this command is blocked for security reasons.",
+		ProcessNotFound:           "Error finding process with PID %d: %v",
+		SignalFailed:              "Error sending termination signal to process with PID %d: %v",
+		MoveSourceRemains:         "File copied to %s and verified, but the source could not be fully removed. Remaining: %s",
+		MergeInvalidPolicy:        "Unknown conflict policy %q; use skip, overwrite or rename.",
+		MergeSourceNotDir:         "Source %s is not a directory.",
+		MergeDestinationNotDir:    "Destination %s exists and is not a directory.",
+		MergeIntoItself:           "Cannot merge %s into itself or one of its subdirectories.",
+		FormatApplied:             "Formatted with %s:\n%s",
+		FormatUnchanged:           "Formatted with %s; no changes were needed.",
+		FormatFailed:              "Formatter %s failed; the file was left as written: %s",
+		LineEndingInvalid:         "line_ending must be auto, lf or crlf, got %q.",
+		LineEndingsConverted:      "Converted %d line ending(s) to %s.",
+		LineEndingsUnchanged:      "The file already uses %s line endings.",
+		LinksDisabled:             "Link creation is disabled; set allowLinkCreation to true in the configuration to enable it.",
+		PathNotAllowed:            "%s is outside the allowed directories.",
+		LinkCreateError:           "Error creating link.",
+		SymlinkCreated:            "Created symbolic link %s -> %s.",
+		HardlinkCreated:           "Created hard link %s to %s.",
+		SymlinkPrivilege:          "Creating symbolic links requires administrator rights or Developer Mode on Windows.",
+		HardlinkNotRegular:        "Hard links can only be created to existing regular files; %s is not one.",
+		SemanticFormatInvalid:     "Unsupported format %q; use json or yaml.",
+		SemanticFormatUnknown:     "Cannot tell the format of %s from its extension; pass format as json or yaml.",
+		SemanticParseError:        "Could not parse %s as %s: %v",
+		SemanticNoDifferences:     "No semantic differences.",
+		SemanticDifferences:       "%d semantic difference(s) between %s and %s:",
+		SemanticAdded:             "Added: %s = %s",
+		SemanticRemoved:           "Removed: %s, was %s",
+		SemanticMoved:             "Moved: %s to %s",
+		SemanticChanged:           "Changed: %s from %s to %s",
+		JSONEditInvalidOp:         "Unknown operation %q; use set, delete or append.",
+		JSONEditInvalidValue:      "The value is not valid JSON: %v. Quote strings, e.g. \"\\\"text\\\"\".",
+		JSONEditValueRequired:     "The %s operation requires a value.",
+		JSONEditPathNotFound:      "Path %s does not exist.",
+		JSONEditNotArray:          "Path %s is not an array.",
+		JSONEditIndexRange:        "Index %s in %s is out of range; the array has %d element(s).",
+		JSONEditDeleteRoot:        "Cannot delete the document root.",
+		JSONEditSet:               "Set %s in %s.",
+		JSONEditDeleted:           "Deleted %s from %s.",
+		JSONEditAppended:          "Appended to %s in %s.",
+		StructuredInvalidDocument: "%s is not valid %s: %v",
+		StructuredInvalidResult:   "The edit would leave %s as invalid %s (%v); the file was not changed.",
+		TOMLNullValue:             "TOML has no null value; use the delete operation to remove %s.",
+		TOMLTablePath:             "%s is a table; set or append to its keys individually.",
+		NotebookInvalid:           "%s is not a valid notebook: %v",
+		NotebookCellRange:         "Cell %d does not exist; the notebook has %d cell(s).",
+		NotebookCellNotFound:      "No cell has the id %q.",
+		NotebookInvalidOperation:  "Unknown operation %q; use replace, insert or delete.",
+		NotebookInvalidCellType:   "Unknown cell type %q; use code, markdown or raw.",
+		NotebookSourceRequired:    "The %s operation requires source.",
+		NotebookCellReplaced:      "Replaced cell %d in %s.",
+		NotebookCellInserted:      "Inserted a %s cell at index %d in %s.",
+		NotebookCellDeleted:       "Deleted cell %d from %s.",
+		SQLiteOpenError:           "Could not read %s as an SQLite database: %v",
+		SQLiteNoDatabases:         "No SQLite databases found under %s.",
+		SQLiteDatabasesFound:      "SQLite databases under %s:",
+		SQLiteTableNotFound:       "No table named %q. Tables: %s",
+		SQLiteWithoutRowid:        "%s is a WITHOUT ROWID table; use query to read its rows.",
+		SQLiteShellMissing:        "Running queries needs the sqlite3 command-line shell, which was not found on PATH.",
+		SQLiteDotCommand:          "Queries must be SQL; sqlite3 dot-commands are not allowed.",
+		SQLiteQueryFailed:         "Query failed: %s",
+		SQLiteRowsTruncated:       "Showing the first %d row(s).",
+		TransformNoOperations:     "operations must list at least one of sort, unique or reverse.",
+		TransformInvalidOperation: "Unknown operation %q; use sort, unique or reverse.",
+		TransformApplied:          "Transformed lines %d-%d: %d line(s) remain, %d duplicate(s) removed.",
+		TransformUnchanged:        "Lines %d-%d already match; the file was not changed.",
+		GoModMissing:              "No go.mod found in %s.",
+		GoCommandFailed:           "%s failed: %s",
+		IndentNothingToDo:         "Specify convert, shift or both.",
+		IndentInvalidConvert:      "Unknown convert value %q; use spaces or tabs.",
+		IndentShiftTooFar:         "Line %d is indented by less than %d level(s); nothing was changed.",
+		IndentAdjusted:            "Adjusted the indentation of lines %d-%d: %d line(s) changed.",
+		IgnoreSuggestions:         "Suggested .gitignore additions for %s:",
+		IgnoreNothingToSuggest:    "Nothing to suggest: no build artifacts, caches or large files outside .gitignore under %s.",
+		IgnoreLargeFiles:          "Large files that are not ignored (review before ignoring them):",
+		IgnoreApplied:             "Appended %d pattern(s) to %s.",
+		PathNotDirectory:          "%s is not a directory.",
+		MergeFilesInvalidStyle:    "Unknown style %q; use merge or diff3.",
+		MergeFilesSummary:         "Merged %d change(s) cleanly; %d conflict(s) marked.",
+		MergeFilesWritten:         "Wrote the merged result to %s.",
+		GeneratedHeaderAdded:      "Added the generated-code header.",
+		StreamEditApplied:         "File edited by streaming it line by line, as it is larger than %d MB: %d line(s) removed, %d inserted. No diff is shown, the formatter is not run and the edit is not recorded in the edit journal, so undo_edit cannot revert it.",
+		ProxyCallFailed:           "Error calling upstream tool %s: %v",
+		TemplateInvalidName:       "Template name %q is not a relative path inside the templates directory.",
+		TemplateNotFound:          "Template %q was not found in %s. Available templates: %s",
+		TemplateExpandFailed:      "Template %s could not be expanded: %v",
+		TemplateFileExists:        "%s already exists; pass line to insert into it or overwrite to replace it.",
+		TemplateWritten:           "Wrote template %s (%d lines) to %s.",
+		TemplateInserted:          "Inserted template %s (%d lines) before line %d of %s.",
+		EditAmbiguous:             "old_string occurs %d times, but %d replacement(s) were expected. Add surrounding lines to old_string, restrict the search with start_line/end_line, or set expected_replacements. Occurrences:\n%s",
+		EditAmbiguousMore:         "\n  … and %d more",
+		FileCreatedByEdit:         "File %s did not exist and was created.",
+		ColumnsInvalidOperation:   "Unknown operation %q; use insert, delete or replace.",
+		ColumnsEndRequired:        "end_column is required for %s.",
+		ColumnsRange:              "invalid columns %d-%d: start_column must be >= 1 and end_column >= start_column.",
+		ColumnsTextRequired:       "text is required for %s.",
+		ColumnsTextLines:          "text has %d lines, but the block has %d; pass one line for every line of the block or a single line for all of them.",
+		ColumnsUnchanged:          "Columns of lines %d-%d already match; the file was not changed.",
+		ColumnsApplied:            "Applied %s to columns %d-%d of lines %d-%d.",
+		UsageCallLimit:            "Usage limit reached: this session has made %d tool calls (usageLimits.maxToolCalls). %s was not run; raise the limit in the config to continue.",
+		UsageToolLimit:            "Usage limit reached: %s has been called %d times this session (usageLimits.tools). It was not run; raise the limit in the config to continue.",
+		UsageBytesLimit:           "Usage limit reached: this session has moved %d bytes of tool arguments and results (usageLimits.maxBytes). %s was not run; raise the limit in the config to continue.",
+		PolicyDenied:              "Policy %q denied %s.",
+		PolicyDeniedReason:        "Policy %q denied %s: %s",
+		PolicyError:               "Policy %q could not be evaluated (%v); %s was denied.",
+		PatchParseError:           "Could not parse the patch: %s",
+		PatchEmpty:                "The patch contains no hunks.",
+		PatchHunks:                "Patch %s has %d hunks in %d files; nothing was changed. Apply the ones you want with apply_hunks.",
+		PatchHunkApplies:          "applies",
+		PatchHunkOffset:           "applies at line %d",
+		PatchHunkFails:            "does not apply: %s",
+		PatchHunkMissing:          "the lines it changes are not in the file",
+		PatchNotFound:             "No pending patch %q; split the patch again with apply_patch_interactive.",
+		PatchNoHunks:              "No hunks selected; nothing was changed.",
+		PatchUnknownHunk:          "Patch %s has no hunk %d; choose from 1 to %d.",
+		PatchConflict:             "Hunk %d no longer applies to %s; nothing was changed.",
+		PatchApplied:              "Applied %d of %d hunks of patch %s to %d files.",
+		SearchFormatInvalid:       "Unsupported format %q; use text, heading or json.",
+		PreviewReplaceSummary:     "%d replacements on %d lines in %d files. Nothing was written; apply them with replace_in_files and the same arguments.",
+		PreviewReplaceTruncated:   "Showing the first %d lines.",
+		PreviewReplaceNoMatches:   "No line matches %q; nothing would be replaced.",
+		SymbolKindInvalid:         "Unknown kind %q; use func, method, type, const or var.",
+		SymbolPatternInvalid:      "Invalid name pattern %q: %v",
+		SymbolsNotFound:           "No Go definitions match %q.",
+		SymbolsTruncated:          "... %d more definitions not shown; narrow the name or raise maxResults.",
+		TodosNotFound:             "No TODO comments found in %s.",
+		TodosSummary:              "Found %d comments in %d files (%s).",
+		TodosTruncated:            "Stopped after %d matches; narrow the search or raise maxResults.",
+		SearchStreamed:            "Sent %d matches as progress notifications; scanned %d files in %v.",
+		SearchCursorInvalid:       "Invalid cursor %q; pass the cursor returned by the previous page.",
+		SearchNextPage:            "More matches follow; pass cursor %q with the same arguments for the next page.",
+		SearchFilesInvalidType:    "Invalid type %q: use file, directory or any.",
+		SearchFilesInvalidTime:    "Invalid modification time: use RFC 3339, a date such as 2006-01-02 or a duration such as 24h.",
+		SearchHexInvalid:          "Invalid hex pattern %q: use pairs of hex digits such as '7f 45 4c 46'.",
+		SearchPatternTooComplex:   "Pattern %q is too complex to search safely: it compiles to more than %d instructions. Simplify it or use smaller repetition counts.",
+		SearchOverBudget:          "The pattern took too long on %d file(s), which were searched only in part: %s",
+		InputSent:                 "Sent %d bytes to the input of PID %d.",
+		InputClosed:               "Sent %d bytes to the input of PID %d and closed it.",
+		TerminalResized:           "Resized the terminal of PID %d to %d columns by %d rows.",
+		CommandNotAllowed:         "Command execution blocked: '%s' is not in the allowed command list (allowedCommands), so it may not run. Allowed: %s.",
+		CommandRuleDenied:         "Command execution blocked: rule %q (commandRules) denies '%s'.",
+		CommandRuleDeniedReason:   "Command execution blocked: rule %q (commandRules) denies '%s': %s",
+		ContainerUnavailable:      "Cannot run the command in the configured container: %v",
+		MacroNotFound:             "Macro %q is not configured. Available macros: %s",
+		MacroMissingParams:        "Macro %q needs values for: %s",
+		MacroUnknownParams:        "Macro %q has no parameters named %s; it is %s",
+		MacroUnsafeValue:          "Value of parameter %q of macro %q cannot be quoted safely for this shell: %q. Use only letters, digits and . _ / : = @ + , -",
+		MacroRunning:              "Macro %s runs: %s",
+		SessionLimitReached:       "Command not started: %d sessions are already running, as many as maxSessions allows. Wait for one to finish with wait_for_completion, or end one with force_terminate, and try again.",
+		SessionQueueTimeout:       "Command not started: it waited %s in the queue for one of the %d running sessions to finish.",
+		CommandQueued:             "Queued: position %d, waiting for a running session to finish",
+		CommandStartedQueued:      "(after waiting %s in the queue)",
+	},
+	"es": {
+		FileWritten:               "Archivo escrito correctamente.",
+		FileEdited:                "Archivo editado correctamente.",
+		FileMoved:                 "Archivo movido/renombrado correctamente.",
+		FileNotFound:              "Error: Archivo no encontrado.",
+		DirectoryCreated:          "Directorio creado correctamente.",
+		EditsReverted:             "Se revirtieron %d edición(es) en %s.",
+		CommandStarted:            "Comando iniciado en segundo plano con PID: %d",
+		CommandBlocked:            "Ejecución bloqueada: el comando '%s' está bloqueado o su sintaxis no es válida para la validación.",
+		TerminationSent:           "Señal de terminación enviada al PID %d.",
+		SearchTimedOut:            "La búsqueda superó el tiempo de espera.",
+		InvalidLineRange:          "Rango de líneas no válido: start_line debe ser <= end_line",
+		ExpectedPositive:          "expected_replacements debe ser positivo",
+		StartLinePositive:         "start_line debe ser positivo y comenzar en 1",
+		EndLineBeforeStart:        "end_line no puede ser menor que start_line - 1",
+		FileAccessError:           "Error al acceder a la información del archivo.",
+		FileReadError:             "Error al leer el archivo.",
+		FileWriteError:            "Error al escribir el archivo.",
+		FileTooLarge:              "Error: el tamaño del archivo (%d bytes) supera el límite de %d MB de esta herramienta de edición por restricciones de memoria. Use otra herramienta o método para editar archivos muy grandes. Si es código fuente, considere dividirlo en módulos/archivos más pequeños si el lenguaje lo permite.",
+		FileInfoError:             "Error al obtener la información del archivo.",
+		MoveFailed:                "Error al mover/renombrar el archivo.",
+		DirectoryCreateError:      "Error al crear el directorio.",
+		DirectoryReadError:        "Error al leer el directorio.",
+		ReplacementsShort:         "Se esperaban %d reemplazos, pero solo se encontraron %d apariciones del texto original.",
+		ReplacementFailed:         "El reemplazo falló inesperadamente para %d reemplazos esperados a pesar de %d apariciones.",
+		InternalEditError:         "Error interno durante el reemplazo.",
+		FuzzyThresholdRange:       "fuzzy_threshold debe ser mayor que 0 y como máximo 1",
+		FuzzyApplied:              "Archivo editado correctamente mediante coincidencia aproximada en las líneas %d-%d (similitud %.2f). Cambio aplicado:\n%s",
+		MatchModeConflict:         "fuzzy_apply e ignore_whitespace localizan un único bloque; no se pueden combinar con expected_replacements mayor que 1",
+		WhitespaceAmbiguous:       "La coincidencia sin espacios es ambigua: old_string coincide con %d bloques que empiezan en las líneas %s. Añada más contexto a old_string.",
+		InsertLineRange:           "line (%d) debe estar entre 1 y %d (el número de líneas + 1)",
+		DeleteLineRange:           "rango no válido %d-%d: start_line debe ser >= 1, end_line debe ser >= start_line y <= %d (el número de líneas)",
+		NoEditsProvided:           "No se proporcionaron ediciones.",
+		EditsNotApplied:           "No se modificó ningún archivo. %s",
+		EditsStagingFailed:        "No se modificó ningún archivo. Error al preparar %s",
+		EditsRolledBack:           "Error al aplicar las ediciones a %s; se revirtieron todos los cambios.",
+		EditsRollbackFailed:       "Error al aplicar las ediciones a %s; la reversión falló para: %s",
+		EditsApplied:              "Se aplicaron %d edición(es) en %d archivo(s).",
+		UndoFailed:                "Error al deshacer la edición: %s",
+		CommandBlockedSecurity:    "Error: la ejecución de este comando está bloqueada por motivos de seguridad.",
+		ProcessNotFound:           "Error al buscar el proceso con PID %d: %v",
+		SignalFailed:              "Error al enviar la señal de terminación al proceso con PID %d: %v",
+		MoveSourceRemains:         "Archivo copiado a %s y verificado, pero el origen no se pudo eliminar por completo. Restante: %s",
+		MergeInvalidPolicy:        "Política de conflicto desconocida %q; use skip, overwrite o rename.",
+		MergeSourceNotDir:         "El origen %s no es un directorio.",
+		MergeDestinationNotDir:    "El destino %s existe y no es un directorio.",
+		MergeIntoItself:           "No se puede fusionar %s consigo mismo ni con uno de sus subdirectorios.",
+		FormatApplied:             "Formateado con %s:\n%s",
+		FormatUnchanged:           "Formateado con %s; no fue necesario ningún cambio.",
+		FormatFailed:              "El formateador %s falló; el archivo quedó tal como se escribió: %s",
+		LineEndingInvalid:         "line_ending debe ser auto, lf o crlf; se recibió %q.",
+		LineEndingsConverted:      "Se convirtieron %d final(es) de línea a %s.",
+		LineEndingsUnchanged:      "El archivo ya usa finales de línea %s.",
+		LinksDisabled:             "La creación de enlaces está desactivada; establezca allowLinkCreation en true en la configuración para activarla.",
+		PathNotAllowed:            "%s está fuera de los directorios permitidos.",
+		LinkCreateError:           "Error al crear el enlace.",
+		SymlinkCreated:            "Enlace simbólico creado %s -> %s.",
+		HardlinkCreated:           "Enlace duro %s creado hacia %s.",
+		SymlinkPrivilege:          "Crear enlaces simbólicos requiere derechos de administrador o el Modo de desarrollador en Windows.",
+		HardlinkNotRegular:        "Solo se pueden crear enlaces duros a archivos normales existentes; %s no lo es.",
+		SemanticFormatInvalid:     "Formato no compatible %q; use json o yaml.",
+		SemanticFormatUnknown:     "No se puede deducir el formato de %s por su extensión; indique format como json o yaml.",
+		SemanticParseError:        "No se pudo analizar %s como %s: %v",
+		SemanticNoDifferences:     "No hay diferencias semánticas.",
+		SemanticDifferences:       "%d diferencia(s) semántica(s) entre %s y %s:",
+		SemanticAdded:             "Añadido: %s = %s",
+		SemanticRemoved:           "Eliminado: %s, era %s",
+		SemanticMoved:             "Movido: %s a %s",
+		SemanticChanged:           "Cambiado: %s de %s a %s",
+		JSONEditInvalidOp:         "Operación desconocida %q; use set, delete o append.",
+		JSONEditInvalidValue:      "El valor no es JSON válido: %v. Ponga las cadenas entre comillas, p. ej. \"\\\"texto\\\"\".",
+		JSONEditValueRequired:     "La operación %s requiere un valor.",
+		JSONEditPathNotFound:      "La ruta %s no existe.",
+		JSONEditNotArray:          "La ruta %s no es un array.",
+		JSONEditIndexRange:        "El índice %s de %s está fuera de rango; el array tiene %d elemento(s).",
+		JSONEditDeleteRoot:        "No se puede eliminar la raíz del documento.",
+		JSONEditSet:               "Se estableció %s en %s.",
+		JSONEditDeleted:           "Se eliminó %s de %s.",
+		JSONEditAppended:          "Se añadió a %s en %s.",
+		StructuredInvalidDocument: "%s no es %s válido: %v",
+		StructuredInvalidResult:   "La edición dejaría %s como %s no válido (%v); el archivo no se modificó.",
+		TOMLNullValue:             "TOML no tiene valor nulo; use la operación delete para eliminar %s.",
+		TOMLTablePath:             "%s es una tabla; establezca o añada a sus claves individualmente.",
+		NotebookInvalid:           "%s no es un cuaderno válido: %v",
+		NotebookCellRange:         "La celda %d no existe; el cuaderno tiene %d celda(s).",
+		NotebookCellNotFound:      "Ninguna celda tiene el id %q.",
+		NotebookInvalidOperation:  "Operación desconocida %q; use replace, insert o delete.",
+		NotebookInvalidCellType:   "Tipo de celda desconocido %q; use code, markdown o raw.",
+		NotebookSourceRequired:    "La operación %s requiere source.",
+		NotebookCellReplaced:      "Se reemplazó la celda %d en %s.",
+		NotebookCellInserted:      "Se insertó una celda %s en el índice %d de %s.",
+		NotebookCellDeleted:       "Se eliminó la celda %d de %s.",
+		SQLiteOpenError:           "No se pudo leer %s como base de datos SQLite: %v",
+		SQLiteNoDatabases:         "No se encontraron bases de datos SQLite en %s.",
+		SQLiteDatabasesFound:      "Bases de datos SQLite en %s:",
+		SQLiteTableNotFound:       "No hay ninguna tabla llamada %q. Tablas: %s",
+		SQLiteWithoutRowid:        "%s es una tabla WITHOUT ROWID; use query para leer sus filas.",
+		SQLiteShellMissing:        "Para ejecutar consultas se necesita el shell de línea de comandos sqlite3, que no se encontró en PATH.",
+		SQLiteDotCommand:          "Las consultas deben ser SQL; no se permiten los comandos con punto de sqlite3.",
+		SQLiteQueryFailed:         "La consulta falló: %s",
+		SQLiteRowsTruncated:       "Se muestran las primeras %d fila(s).",
+		TransformNoOperations:     "operations debe incluir al menos una de sort, unique o reverse.",
+		TransformInvalidOperation: "Operación desconocida %q; use sort, unique o reverse.",
+		TransformApplied:          "Se transformaron las líneas %d-%d: quedan %d línea(s), se eliminaron %d duplicado(s).",
+		TransformUnchanged:        "Las líneas %d-%d ya coinciden; el archivo no se modificó.",
+		GoModMissing:              "No se encontró go.mod en %s.",
+		GoCommandFailed:           "%s falló: %s",
+		IndentNothingToDo:         "Indique convert, shift o ambos.",
+		IndentInvalidConvert:      "Valor de convert desconocido %q; use spaces o tabs.",
+		IndentShiftTooFar:         "La línea %d tiene menos de %d nivel(es) de sangría; no se cambió nada.",
+		IndentAdjusted:            "Se ajustó la sangría de las líneas %d-%d: %d línea(s) modificada(s).",
+		IgnoreSuggestions:         "Adiciones sugeridas a .gitignore para %s:",
+		IgnoreNothingToSuggest:    "Nada que sugerir: no hay artefactos de compilación, cachés ni archivos grandes fuera de .gitignore en %s.",
+		IgnoreLargeFiles:          "Archivos grandes que no se ignoran (revíselos antes de ignorarlos):",
+		IgnoreApplied:             "Se añadieron %d patrón(es) a %s.",
+		PathNotDirectory:          "%s no es un directorio.",
+		MergeFilesInvalidStyle:    "Estilo desconocido %q; use merge o diff3.",
+		MergeFilesSummary:         "Se fusionaron %d cambio(s) sin conflictos; %d conflicto(s) marcado(s).",
+		MergeFilesWritten:         "Se escribió el resultado de la fusión en %s.",
+		GeneratedHeaderAdded:      "Se añadió la cabecera de código generado.",
+		StreamEditApplied:         "Archivo editado procesándolo línea a línea, ya que supera los %d MB: %d línea(s) eliminada(s), %d insertada(s). No se muestra el diff, no se ejecuta el formateador y la edición no se registra en el historial de ediciones, por lo que undo_edit no puede revertirla.",
+		ProxyCallFailed:           "Error al llamar a la herramienta remota %s: %v",
+		TemplateInvalidName:       "El nombre de plantilla %q no es una ruta relativa dentro del directorio de plantillas.",
+		TemplateNotFound:          "No se encontró la plantilla %q en %s. Plantillas disponibles: %s",
+		TemplateExpandFailed:      "No se pudo expandir la plantilla %s: %v",
+		TemplateFileExists:        "%s ya existe; indique line para insertar en él u overwrite para reemplazarlo.",
+		TemplateWritten:           "Plantilla %s (%d líneas) escrita en %s.",
+		TemplateInserted:          "Plantilla %s (%d líneas) insertada antes de la línea %d de %s.",
+		EditAmbiguous:             "old_string aparece %d veces, pero se esperaban %d reemplazo(s). Añada líneas de contexto a old_string, restrinja la búsqueda con start_line/end_line o indique expected_replacements. Apariciones:\n%s",
+		EditAmbiguousMore:         "\n  … y %d más",
+		FileCreatedByEdit:         "El archivo %s no existía y se ha creado.",
+		ColumnsInvalidOperation:   "Operación desconocida %q; use insert, delete o replace.",
+		ColumnsEndRequired:        "end_column es obligatorio para %s.",
+		ColumnsRange:              "columnas no válidas %d-%d: start_column debe ser >= 1 y end_column >= start_column.",
+		ColumnsTextRequired:       "text es obligatorio para %s.",
+		ColumnsTextLines:          "text tiene %d líneas, pero el bloque tiene %d; pase una línea por cada línea del bloque o una sola línea para todas.",
+		ColumnsUnchanged:          "Las columnas de las líneas %d-%d ya coinciden; el archivo no se modificó.",
+		ColumnsApplied:            "Se aplicó %s a las columnas %d-%d de las líneas %d-%d.",
+		UsageCallLimit:            "Límite de uso alcanzado: esta sesión ha hecho %d llamadas a herramientas (usageLimits.maxToolCalls). %s no se ejecutó; aumente el límite en la configuración para continuar.",
+		UsageToolLimit:            "Límite de uso alcanzado: %s se ha llamado %d veces en esta sesión (usageLimits.tools). No se ejecutó; aumente el límite en la configuración para continuar.",
+		UsageBytesLimit:           "Límite de uso alcanzado: esta sesión ha movido %d bytes de argumentos y resultados de herramientas (usageLimits.maxBytes). %s no se ejecutó; aumente el límite en la configuración para continuar.",
+		PolicyDenied:              "La política %q denegó %s.",
+		PolicyDeniedReason:        "La política %q denegó %s: %s",
+		PolicyError:               "No se pudo evaluar la política %q (%v); se denegó %s.",
+		PatchParseError:           "No se pudo analizar el parche: %s",
+		PatchEmpty:                "El parche no contiene fragmentos.",
+		PatchHunks:                "El parche %s tiene %d fragmentos en %d archivos; no se modificó nada. Aplique los que quiera con apply_hunks.",
+		PatchHunkApplies:          "se aplica",
+		PatchHunkOffset:           "se aplica en la línea %d",
+		PatchHunkFails:            "no se aplica: %s",
+		PatchHunkMissing:          "las líneas que cambia no están en el archivo",
+		PatchNotFound:             "No hay ningún parche pendiente %q; divida el parche de nuevo con apply_patch_interactive.",
+		PatchNoHunks:              "No se seleccionó ningún fragmento; no se modificó nada.",
+		PatchUnknownHunk:          "El parche %s no tiene el fragmento %d; elija entre 1 y %d.",
+		PatchConflict:             "El fragmento %d ya no se aplica a %s; no se modificó nada.",
+		PatchApplied:              "Se aplicaron %d de %d fragmentos del parche %s a %d archivos.",
+		SearchFormatInvalid:       "Formato no compatible %q; use text, heading o json.",
+		PreviewReplaceSummary:     "%d reemplazos en %d líneas de %d archivos. No se escribió nada; aplíquelos con replace_in_files y los mismos argumentos.",
+		PreviewReplaceTruncated:   "Se muestran las primeras %d líneas.",
+		PreviewReplaceNoMatches:   "Ninguna línea coincide con %q; no se reemplazaría nada.",
+		SymbolKindInvalid:         "Tipo desconocido %q; use func, method, type, const o var.",
+		SymbolPatternInvalid:      "Patrón de nombre no válido %q: %v",
+		SymbolsNotFound:           "Ninguna definición de Go coincide con %q.",
+		SymbolsTruncated:          "... %d definiciones más no se muestran; precise el nombre o aumente maxResults.",
+		TodosNotFound:             "No se encontraron comentarios TODO en %s.",
+		TodosSummary:              "Se encontraron %d comentarios en %d archivos (%s).",
+		TodosTruncated:            "Se detuvo tras %d coincidencias; acote la búsqueda o aumente maxResults.",
+		SearchStreamed:            "Se enviaron %d coincidencias como notificaciones de progreso; se examinaron %d archivos en %v.",
+		SearchCursorInvalid:       "Cursor no válido %q; pase el cursor devuelto por la página anterior.",
+		SearchNextPage:            "Hay más coincidencias; pase el cursor %q con los mismos argumentos para obtener la página siguiente.",
+		SearchFilesInvalidType:    "Tipo %q no válido: usa file, directory o any.",
+		SearchFilesInvalidTime:    "Fecha de modificación no válida: usa RFC 3339, una fecha como 2006-01-02 o una duración como 24h.",
+		SearchHexInvalid:          "Patrón hexadecimal %q no válido: usa pares de dígitos hexadecimales como '7f 45 4c 46'.",
+		SearchPatternTooComplex:   "El patrón %q es demasiado complejo para buscarlo con seguridad: se compila en más de %d instrucciones. Simplifíquelo o use recuentos de repetición menores.",
+		SearchOverBudget:          "El patrón tardó demasiado en %d archivo(s), que solo se buscaron en parte: %s",
+		InputSent:                 "Se enviaron %d bytes a la entrada del PID %d.",
+		InputClosed:               "Se enviaron %d bytes a la entrada del PID %d y se cerró.",
+		TerminalResized:           "Se cambió el tamaño del terminal del PID %d a %d columnas por %d filas.",
+		CommandNotAllowed:         "Ejecución bloqueada: '%s' no está en la lista de comandos permitidos (allowedCommands), así que no puede ejecutarse. Permitidos: %s.",
+		CommandRuleDenied:         "Ejecución bloqueada: la regla %q (commandRules) deniega '%s'.",
+		CommandRuleDeniedReason:   "Ejecución bloqueada: la regla %q (commandRules) deniega '%s': %s",
+		ContainerUnavailable:      "No se puede ejecutar el comando en el contenedor configurado: %v",
+		MacroNotFound:             "La macro %q no está configurada. Macros disponibles: %s",
+		MacroMissingParams:        "La macro %q necesita valores para: %s",
+		MacroUnknownParams:        "La macro %q no tiene parámetros llamados %s; es %s",
+		MacroUnsafeValue:          "El valor del parámetro %q de la macro %q no puede entrecomillarse de forma segura para este shell: %q. Use solo letras, dígitos y . _ / : = @ + , -",
+		MacroRunning:              "La macro %s ejecuta: %s",
+		SessionLimitReached:       "Comando no iniciado: ya hay %d sesiones en ejecución, tantas como permite maxSessions. Espere a que termine una con wait_for_completion, o finalice una con force_terminate, y vuelva a intentarlo.",
+		SessionQueueTimeout:       "Comando no iniciado: esperó %s en la cola a que terminara una de las %d sesiones en ejecución.",
+		CommandQueued:             "En cola: posición %d, esperando a que termine una sesión en ejecución",
+		CommandStartedQueued:      "(tras esperar %s en la cola)",
+	},
+	"fr": {
+		FileWritten:               "Fichier écrit avec succès.",
+		FileEdited:                "Fichier modifié avec succès.",
+		FileMoved:                 "Fichier déplacé/renommé avec succès.",
+		FileNotFound:              "Erreur : fichier introuvable.",
+		DirectoryCreated:          "Répertoire créé avec succès.",
+		EditsReverted:             "%d modification(s) annulée(s) dans %s.",
+		CommandStarted:            "Commande lancée en arrière-plan avec le PID : %d",
+		CommandBlocked:            "Exécution bloquée : la commande '%s' est bloquée ou sa syntaxe est invalide pour la validation.",
+		TerminationSent:           "Signal d'arrêt envoyé au PID %d.",
+		SearchTimedOut:            "La recherche a expiré.",
+		InvalidLineRange:          "Plage de lignes invalide : start_line doit être <= end_line",
+		ExpectedPositive:          "expected_replacements doit être positif",
+		StartLinePositive:         "start_line doit être positif et commencer à 1",
+		EndLineBeforeStart:        "end_line ne peut pas être inférieur à start_line - 1",
+		FileAccessError:           "Erreur lors de l'accès aux informations du fichier.",
+		FileReadError:             "Erreur lors de la lecture du fichier.",
+		FileWriteError:            "Erreur lors de l'écriture du fichier.",
+		FileTooLarge:              "Erreur : la taille du fichier (%d octets) dépasse la limite de %d Mo de cet outil d'édition en raison des contraintes mémoire. Utilisez un autre outil ou une autre méthode pour les très gros fichiers. S'il s'agit de code source, envisagez de le diviser en modules/fichiers plus petits si le langage s'y prête.",
+		FileInfoError:             "Erreur lors de la récupération des informations du fichier.",
+		MoveFailed:                "Erreur lors du déplacement/renommage du fichier.",
+		DirectoryCreateError:      "Erreur lors de la création du répertoire.",
+		DirectoryReadError:        "Erreur lors de la lecture du répertoire.",
+		ReplacementsShort:         "%d remplacement(s) attendu(s), mais seulement %d occurrence(s) du texte d'origine trouvée(s).",
+		ReplacementFailed:         "Le remplacement a échoué de façon inattendue pour %d remplacement(s) attendu(s) malgré %d occurrence(s).",
+		InternalEditError:         "Erreur interne lors du remplacement.",
+		FuzzyThresholdRange:       "fuzzy_threshold doit être supérieur à 0 et au plus égal à 1",
+		FuzzyApplied:              "Fichier modifié avec succès par correspondance approximative aux lignes %d-%d (similarité %.2f). Modification appliquée :\n%s",
+		MatchModeConflict:         "fuzzy_apply et ignore_whitespace localisent un seul bloc ; ils ne peuvent pas être combinés avec expected_replacements supérieur à 1",
+		WhitespaceAmbiguous:       "La correspondance sans espaces est ambiguë : old_string correspond à %d blocs commençant aux lignes %s. Ajoutez du contexte à old_string.",
+		InsertLineRange:           "line (%d) doit être comprise entre 1 et %d (le nombre de lignes + 1)",
+		DeleteLineRange:           "plage invalide %d-%d : start_line doit être >= 1, end_line doit être >= start_line et <= %d (le nombre de lignes)",
+		NoEditsProvided:           "Aucune modification fournie.",
+		EditsNotApplied:           "Aucun fichier n'a été modifié. %s",
+		EditsStagingFailed:        "Aucun fichier n'a été modifié. Erreur lors de la préparation de %s",
+		EditsRolledBack:           "Erreur lors de l'application des modifications à %s ; toutes les modifications ont été annulées.",
+		EditsRollbackFailed:       "Erreur lors de l'application des modifications à %s ; l'annulation a échoué pour : %s",
+		EditsApplied:              "%d modification(s) appliquée(s) dans %d fichier(s).",
+		UndoFailed:                "Erreur lors de l'annulation de la modification : %s",
+		CommandBlockedSecurity:    "Erreur : l'exécution de cette commande est bloquée pour des raisons de sécurité.",
+		ProcessNotFound:           "Erreur lors de la recherche du processus avec le PID %d : %v",
+		SignalFailed:              "Erreur lors de l'envoi du signal d'arrêt au processus avec le PID %d : %v",
+		MoveSourceRemains:         "Fichier copié vers %s et vérifié, mais la source n'a pas pu être entièrement supprimée. Restant : %s",
+		MergeInvalidPolicy:        "Politique de conflit inconnue %q ; utilisez skip, overwrite ou rename.",
+		MergeSourceNotDir:         "La source %s n'est pas un répertoire.",
+		MergeDestinationNotDir:    "La destination %s existe et n'est pas un répertoire.",
+		MergeIntoItself:           "Impossible de fusionner %s avec lui-même ou l'un de ses sous-répertoires.",
+		FormatApplied:             "Formaté avec %s :\n%s",
+		FormatUnchanged:           "Formaté avec %s ; aucune modification n'était nécessaire.",
+		FormatFailed:              "Le formateur %s a échoué ; le fichier est resté tel qu'écrit : %s",
+		LineEndingInvalid:         "line_ending doit valoir auto, lf ou crlf, reçu %q.",
+		LineEndingsConverted:      "%d fin(s) de ligne convertie(s) en %s.",
+		LineEndingsUnchanged:      "Le fichier utilise déjà des fins de ligne %s.",
+		LinksDisabled:             "La création de liens est désactivée ; définissez allowLinkCreation à true dans la configuration pour l'activer.",
+		PathNotAllowed:            "%s est en dehors des répertoires autorisés.",
+		LinkCreateError:           "Erreur lors de la création du lien.",
+		SymlinkCreated:            "Lien symbolique créé %s -> %s.",
+		HardlinkCreated:           "Lien physique %s créé vers %s.",
+		SymlinkPrivilege:          "La création de liens symboliques nécessite des droits administrateur ou le mode développeur sous Windows.",
+		HardlinkNotRegular:        "Les liens physiques ne peuvent viser que des fichiers ordinaires existants ; %s n'en est pas un.",
+		SemanticFormatInvalid:     "Format non pris en charge %q ; utilisez json ou yaml.",
+		SemanticFormatUnknown:     "Impossible de déduire le format de %s depuis son extension ; indiquez format json ou yaml.",
+		SemanticParseError:        "Impossible d'analyser %s en tant que %s : %v",
+		SemanticNoDifferences:     "Aucune différence sémantique.",
+		SemanticDifferences:       "%d différence(s) sémantique(s) entre %s et %s :",
+		SemanticAdded:             "Ajouté : %s = %s",
+		SemanticRemoved:           "Supprimé : %s, était %s",
+		SemanticMoved:             "Déplacé : %s vers %s",
+		SemanticChanged:           "Modifié : %s de %s à %s",
+		JSONEditInvalidOp:         "Opération inconnue %q ; utilisez set, delete ou append.",
+		JSONEditInvalidValue:      "La valeur n'est pas du JSON valide : %v. Mettez les chaînes entre guillemets, par ex. \"\\\"texte\\\"\".",
+		JSONEditValueRequired:     "L'opération %s nécessite une valeur.",
+		JSONEditPathNotFound:      "Le chemin %s n'existe pas.",
+		JSONEditNotArray:          "Le chemin %s n'est pas un tableau.",
+		JSONEditIndexRange:        "L'index %s de %s est hors limites ; le tableau a %d élément(s).",
+		JSONEditDeleteRoot:        "Impossible de supprimer la racine du document.",
+		JSONEditSet:               "%s défini dans %s.",
+		JSONEditDeleted:           "%s supprimé de %s.",
+		JSONEditAppended:          "Ajouté à %s dans %s.",
+		StructuredInvalidDocument: "%s n'est pas du %s valide : %v",
+		StructuredInvalidResult:   "La modification rendrait %s invalide en %s (%v) ; le fichier n'a pas été modifié.",
+		TOMLNullValue:             "TOML n'a pas de valeur nulle ; utilisez l'opération delete pour supprimer %s.",
+		TOMLTablePath:             "%s est une table ; modifiez ses clés individuellement.",
+		NotebookInvalid:           "%s n'est pas un notebook valide : %v",
+		NotebookCellRange:         "La cellule %d n'existe pas ; le notebook compte %d cellule(s).",
+		NotebookCellNotFound:      "Aucune cellule n'a l'id %q.",
+		NotebookInvalidOperation:  "Opération inconnue %q ; utilisez replace, insert ou delete.",
+		NotebookInvalidCellType:   "Type de cellule inconnu %q ; utilisez code, markdown ou raw.",
+		NotebookSourceRequired:    "L'opération %s nécessite source.",
+		NotebookCellReplaced:      "Cellule %d remplacée dans %s.",
+		NotebookCellInserted:      "Cellule %s insérée à l'index %d dans %s.",
+		NotebookCellDeleted:       "Cellule %d supprimée de %s.",
+		SQLiteOpenError:           "Impossible de lire %s comme base de données SQLite : %v",
+		SQLiteNoDatabases:         "Aucune base de données SQLite trouvée sous %s.",
+		SQLiteDatabasesFound:      "Bases de données SQLite sous %s :",
+		SQLiteTableNotFound:       "Aucune table nommée %q. Tables : %s",
+		SQLiteWithoutRowid:        "%s est une table WITHOUT ROWID ; utilisez query pour lire ses lignes.",
+		SQLiteShellMissing:        "L'exécution de requêtes nécessite le shell en ligne de commande sqlite3, introuvable dans le PATH.",
+		SQLiteDotCommand:          "Les requêtes doivent être du SQL ; les commandes point de sqlite3 ne sont pas autorisées.",
+		SQLiteQueryFailed:         "La requête a échoué : %s",
+		SQLiteRowsTruncated:       "Affichage des %d première(s) ligne(s).",
+		TransformNoOperations:     "operations doit contenir au moins une opération parmi sort, unique ou reverse.",
+		TransformInvalidOperation: "Opération inconnue %q ; utilisez sort, unique ou reverse.",
+		TransformApplied:          "Lignes %d-%d transformées : %d ligne(s) restante(s), %d doublon(s) supprimé(s).",
+		TransformUnchanged:        "Les lignes %d-%d correspondent déjà ; le fichier n'a pas été modifié.",
+		GoModMissing:              "Aucun go.mod trouvé dans %s.",
+		GoCommandFailed:           "%s a échoué : %s",
+		IndentNothingToDo:         "Indiquez convert, shift ou les deux.",
+		IndentInvalidConvert:      "Valeur de convert inconnue %q ; utilisez spaces ou tabs.",
+		IndentShiftTooFar:         "La ligne %d est indentée de moins de %d niveau(x) ; rien n'a été modifié.",
+		IndentAdjusted:            "Indentation des lignes %d-%d ajustée : %d ligne(s) modifiée(s).",
+		IgnoreSuggestions:         "Ajouts suggérés au .gitignore pour %s :",
+		IgnoreNothingToSuggest:    "Rien à suggérer : aucun artefact de build, cache ou gros fichier hors .gitignore sous %s.",
+		IgnoreLargeFiles:          "Gros fichiers non ignorés (à vérifier avant de les ignorer) :",
+		IgnoreApplied:             "%d motif(s) ajouté(s) à %s.",
+		PathNotDirectory:          "%s n'est pas un répertoire.",
+		MergeFilesInvalidStyle:    "Style inconnu %q ; utilisez merge ou diff3.",
+		MergeFilesSummary:         "%d modification(s) fusionnée(s) sans conflit ; %d conflit(s) marqué(s).",
+		MergeFilesWritten:         "Résultat de la fusion écrit dans %s.",
+		GeneratedHeaderAdded:      "L'en-tête de code généré a été ajouté.",
+		StreamEditApplied:         "Fichier modifié ligne par ligne en flux, car il dépasse %d Mo : %d ligne(s) supprimée(s), %d insérée(s). Aucun diff n'est affiché, le formateur n'est pas exécuté et la modification n'est pas enregistrée dans le journal des modifications, donc undo_edit ne peut pas l'annuler.",
+		ProxyCallFailed:           "Erreur lors de l'appel de l'outil distant %s : %v",
+		TemplateInvalidName:       "Le nom de modèle %q n'est pas un chemin relatif dans le répertoire des modèles.",
+		TemplateNotFound:          "Modèle %q introuvable dans %s. Modèles disponibles : %s",
+		TemplateExpandFailed:      "Impossible de développer le modèle %s : %v",
+		TemplateFileExists:        "%s existe déjà ; indiquez line pour y insérer ou overwrite pour le remplacer.",
+		TemplateWritten:           "Modèle %s (%d lignes) écrit dans %s.",
+		TemplateInserted:          "Modèle %s (%d lignes) inséré avant la ligne %d de %s.",
+		EditAmbiguous:             "old_string apparaît %d fois, mais %d remplacement(s) étaient attendus. Ajoutez des lignes de contexte à old_string, limitez la recherche avec start_line/end_line ou indiquez expected_replacements. Occurrences :\n%s",
+		EditAmbiguousMore:         "\n  … et %d de plus",
+		FileCreatedByEdit:         "Le fichier %s n'existait pas et a été créé.",
+		ColumnsInvalidOperation:   "Opération inconnue %q ; utilisez insert, delete ou replace.",
+		ColumnsEndRequired:        "end_column est obligatoire pour %s.",
+		ColumnsRange:              "colonnes invalides %d-%d : start_column doit être >= 1 et end_column >= start_column.",
+		ColumnsTextRequired:       "text est obligatoire pour %s.",
+		ColumnsTextLines:          "text a %d lignes, mais le bloc en a %d ; passez une ligne par ligne du bloc ou une seule ligne pour toutes.",
+		ColumnsUnchanged:          "Les colonnes des lignes %d-%d correspondent déjà ; le fichier n'a pas été modifié.",
+		ColumnsApplied:            "%s appliqué aux colonnes %d-%d des lignes %d-%d.",
+		UsageCallLimit:            "Limite d'utilisation atteinte : cette session a fait %d appels d'outils (usageLimits.maxToolCalls). %s n'a pas été exécuté ; augmentez la limite dans la configuration pour continuer.",
+		UsageToolLimit:            "Limite d'utilisation atteinte : %s a été appelé %d fois dans cette session (usageLimits.tools). Il n'a pas été exécuté ; augmentez la limite dans la configuration pour continuer.",
+		UsageBytesLimit:           "Limite d'utilisation atteinte : cette session a transféré %d octets d'arguments et de résultats d'outils (usageLimits.maxBytes). %s n'a pas été exécuté ; augmentez la limite dans la configuration pour continuer.",
+		PolicyDenied:              "La politique %q a refusé %s.",
+		PolicyDeniedReason:        "La politique %q a refusé %s : %s",
+		PolicyError:               "La politique %q n'a pas pu être évaluée (%v) ; %s a été refusé.",
+		PatchParseError:           "Impossible d'analyser le correctif : %s",
+		PatchEmpty:                "Le correctif ne contient aucun bloc.",
+		PatchHunks:                "Le correctif %s contient %d blocs dans %d fichiers ; rien n'a été modifié. Appliquez ceux que vous voulez avec apply_hunks.",
+		PatchHunkApplies:          "s'applique",
+		PatchHunkOffset:           "s'applique à la ligne %d",
+		PatchHunkFails:            "ne s'applique pas : %s",
+		PatchHunkMissing:          "les lignes qu'il modifie ne sont pas dans le fichier",
+		PatchNotFound:             "Aucun correctif en attente %q ; découpez à nouveau le correctif avec apply_patch_interactive.",
+		PatchNoHunks:              "Aucun bloc sélectionné ; rien n'a été modifié.",
+		PatchUnknownHunk:          "Le correctif %s n'a pas de bloc %d ; choisissez entre 1 et %d.",
+		PatchConflict:             "Le bloc %d ne s'applique plus à %s ; rien n'a été modifié.",
+		PatchApplied:              "%d blocs sur %d du correctif %s appliqués à %d fichiers.",
+		SearchFormatInvalid:       "Format non pris en charge %q ; utilisez text, heading ou json.",
+		PreviewReplaceSummary:     "%d remplacements sur %d lignes dans %d fichiers. Rien n'a été écrit ; appliquez-les avec replace_in_files et les mêmes arguments.",
+		PreviewReplaceTruncated:   "Affichage des %d premières lignes.",
+		PreviewReplaceNoMatches:   "Aucune ligne ne correspond à %q ; rien ne serait remplacé.",
+		SymbolKindInvalid:         "Type inconnu %q ; utilisez func, method, type, const ou var.",
+		SymbolPatternInvalid:      "Motif de nom non valide %q : %v",
+		SymbolsNotFound:           "Aucune définition Go ne correspond à %q.",
+		SymbolsTruncated:          "... %d définitions supplémentaires non affichées ; précisez le nom ou augmentez maxResults.",
+		TodosNotFound:             "Aucun commentaire TODO trouvé dans %s.",
+		TodosSummary:              "%d commentaires trouvés dans %d fichiers (%s).",
+		TodosTruncated:            "Arrêt après %d correspondances ; restreignez la recherche ou augmentez maxResults.",
+		SearchStreamed:            "%d correspondances envoyées en notifications de progression ; %d fichiers parcourus en %v.",
+		SearchCursorInvalid:       "Curseur non valide %q ; passez le curseur renvoyé par la page précédente.",
+		SearchNextPage:            "D'autres correspondances suivent ; passez le curseur %q avec les mêmes arguments pour la page suivante.",
+		SearchFilesInvalidType:    "Type %q invalide : utilisez file, directory ou any.",
+		SearchFilesInvalidTime:    "Date de modification invalide : utilisez RFC 3339, une date comme 2006-01-02 ou une durée comme 24h.",
+		SearchHexInvalid:          "Motif hexadécimal %q invalide : utilisez des paires de chiffres hexadécimaux comme '7f 45 4c 46'.",
+		SearchPatternTooComplex:   "Le motif %q est trop complexe pour être recherché sans risque : il se compile en plus de %d instructions. Simplifiez-le ou utilisez des nombres de répétitions plus petits.",
+		SearchOverBudget:          "Le motif a pris trop de temps sur %d fichier(s), qui n'ont été parcourus qu'en partie : %s",
+		InputSent:                 "%d octets envoyés à l'entrée du PID %d.",
+		InputClosed:               "%d octets envoyés à l'entrée du PID %d, puis fermée.",
+		TerminalResized:           "Terminal du PID %d redimensionné à %d colonnes sur %d lignes.",
+		CommandNotAllowed:         "Exécution bloquée : '%s' ne figure pas dans la liste des commandes autorisées (allowedCommands) et ne peut donc pas être exécuté. Autorisées : %s.",
+		CommandRuleDenied:         "Exécution bloquée : la règle %q (commandRules) refuse '%s'.",
+		CommandRuleDeniedReason:   "Exécution bloquée : la règle %q (commandRules) refuse '%s' : %s",
+		ContainerUnavailable:      "Impossible d'exécuter la commande dans le conteneur configuré : %v",
+		MacroNotFound:             "La macro %q n'est pas configurée. Macros disponibles : %s",
+		MacroMissingParams:        "La macro %q nécessite des valeurs pour : %s",
+		MacroUnknownParams:        "La macro %q n'a pas de paramètres nommés %s ; elle est %s",
+		MacroUnsafeValue:          "La valeur du paramètre %q de la macro %q ne peut pas être protégée sans risque pour ce shell : %q. Utilisez uniquement des lettres, des chiffres et . _ / : = @ + , -",
+		MacroRunning:              "La macro %s exécute : %s",
+		SessionLimitReached:       "Commande non lancée : %d sessions sont déjà en cours, autant que maxSessions le permet. Attendez qu'une se termine avec wait_for_completion, ou arrêtez-en une avec force_terminate, puis réessayez.",
+		SessionQueueTimeout:       "Commande non lancée : elle a attendu %s dans la file qu'une des %d sessions en cours se termine.",
+		CommandQueued:             "En file : position %d, en attente de la fin d'une session en cours",
+		CommandStartedQueued:      "(après %s d'attente dans la file)",
+	},
+	"de": {
+		FileWritten:               "Datei erfolgreich geschrieben.",
+		FileEdited:                "Datei erfolgreich bearbeitet.",
+		FileMoved:                 "Datei erfolgreich verschoben/umbenannt.",
+		FileNotFound:              "Fehler: Datei nicht gefunden.",
+		DirectoryCreated:          "Verzeichnis erfolgreich erstellt.",
+		EditsReverted:             "%d Bearbeitung(en) an %s rückgängig gemacht.",
+		CommandStarted:            "Befehl im Hintergrund gestartet mit PID: %d",
+		CommandBlocked:            "Ausführung blockiert: Befehl '%s' ist gesperrt oder die Syntax ist für die Prüfung ungültig.",
+		TerminationSent:           "Beendigungssignal an PID %d gesendet.",
+		SearchTimedOut:            "Zeitüberschreitung bei der Suche.",
+		InvalidLineRange:          "Ungültiger Zeilenbereich: start_line muss <= end_line sein",
+		ExpectedPositive:          "expected_replacements muss positiv sein",
+		StartLinePositive:         "start_line muss positiv sein und bei 1 beginnen",
+		EndLineBeforeStart:        "end_line darf nicht kleiner als start_line - 1 sein",
+		FileAccessError:           "Fehler beim Zugriff auf die Dateiinformationen.",
+		FileReadError:             "Fehler beim Lesen der Datei.",
+		FileWriteError:            "Fehler beim Schreiben der Datei.",
+		FileTooLarge:              "Fehler: Die Dateigröße (%d Bytes) überschreitet aus Speichergründen das Limit von %d MB für dieses Bearbeitungswerkzeug. Verwenden Sie für sehr große Dateien ein anderes Werkzeug oder Verfahren. Handelt es sich um Quellcode, teilen Sie ihn nach Möglichkeit in kleinere Module/Dateien auf.",
+		FileInfoError:             "Fehler beim Abrufen der Dateiinformationen.",
+		MoveFailed:                "Fehler beim Verschieben/Umbenennen der Datei.",
+		DirectoryCreateError:      "Fehler beim Erstellen des Verzeichnisses.",
+		DirectoryReadError:        "Fehler beim Lesen des Verzeichnisses.",
+		ReplacementsShort:         "%d Ersetzungen erwartet, aber nur %d Vorkommen des alten Textes gefunden.",
+		ReplacementFailed:         "Ersetzung unerwartet fehlgeschlagen für %d erwartete Ersetzungen trotz %d Vorkommen.",
+		InternalEditError:         "Interner Fehler bei der Ersetzung.",
+		FuzzyThresholdRange:       "fuzzy_threshold muss größer als 0 und höchstens 1 sein",
+		FuzzyApplied:              "Datei erfolgreich per unscharfer Übereinstimmung in den Zeilen %d-%d bearbeitet (Ähnlichkeit %.2f). Angewendete Änderung:\n%s",
+		MatchModeConflict:         "fuzzy_apply und ignore_whitespace finden genau einen Block; sie können nicht mit expected_replacements größer als 1 kombiniert werden",
+		WhitespaceAmbiguous:       "Die leerzeichenunabhängige Übereinstimmung ist mehrdeutig: old_string passt auf %d Blöcke ab den Zeilen %s. Fügen Sie old_string mehr Kontext hinzu.",
+		InsertLineRange:           "line (%d) muss zwischen 1 und %d (Anzahl der Zeilen + 1) liegen",
+		DeleteLineRange:           "ungültiger Bereich %d-%d: start_line muss >= 1 sein, end_line muss >= start_line und <= %d (Anzahl der Zeilen) sein",
+		NoEditsProvided:           "Keine Bearbeitungen angegeben.",
+		EditsNotApplied:           "Es wurden keine Dateien geändert. %s",
+		EditsStagingFailed:        "Es wurden keine Dateien geändert. Fehler beim Vorbereiten von %s",
+		EditsRolledBack:           "Fehler beim Anwenden der Bearbeitungen auf %s; alle Änderungen wurden zurückgenommen.",
+		EditsRollbackFailed:       "Fehler beim Anwenden der Bearbeitungen auf %s; Zurücknehmen fehlgeschlagen für: %s",
+		EditsApplied:              "%d Bearbeitung(en) in %d Datei(en) angewendet.",
+		UndoFailed:                "Fehler beim Rückgängigmachen der Bearbeitung: %s",
+		CommandBlockedSecurity:    "Fehler: Die Ausführung dieses Befehls ist aus Sicherheitsgründen gesperrt.",
+		ProcessNotFound:           "Fehler beim Suchen des Prozesses mit PID %d: %v",
+		SignalFailed:              "Fehler beim Senden des Beendigungssignals an den Prozess mit PID %d: %v",
+		MoveSourceRemains:         "Datei nach %s kopiert und überprüft, aber die Quelle konnte nicht vollständig entfernt werden. Verbleibend: %s",
+		MergeInvalidPolicy:        "Unbekannte Konfliktrichtlinie %q; verwenden Sie skip, overwrite oder rename.",
+		MergeSourceNotDir:         "Die Quelle %s ist kein Verzeichnis.",
+		MergeDestinationNotDir:    "Das Ziel %s existiert und ist kein Verzeichnis.",
+		MergeIntoItself:           "%s kann nicht mit sich selbst oder einem seiner Unterverzeichnisse zusammengeführt werden.",
+		FormatApplied:             "Formatiert mit %s:\n%s",
+		FormatUnchanged:           "Formatiert mit %s; keine Änderungen erforderlich.",
+		FormatFailed:              "Formatierer %s ist fehlgeschlagen; die Datei bleibt wie geschrieben: %s",
+		LineEndingInvalid:         "line_ending muss auto, lf oder crlf sein, erhalten: %q.",
+		LineEndingsConverted:      "%d Zeilenende(n) in %s umgewandelt.",
+		LineEndingsUnchanged:      "Die Datei verwendet bereits %s-Zeilenenden.",
+		LinksDisabled:             "Das Erstellen von Links ist deaktiviert; setzen Sie allowLinkCreation in der Konfiguration auf true, um es zu aktivieren.",
+		PathNotAllowed:            "%s liegt außerhalb der erlaubten Verzeichnisse.",
+		LinkCreateError:           "Fehler beim Erstellen des Links.",
+		SymlinkCreated:            "Symbolischer Link %s -> %s erstellt.",
+		HardlinkCreated:           "Harter Link %s auf %s erstellt.",
+		SymlinkPrivilege:          "Das Erstellen symbolischer Links erfordert unter Windows Administratorrechte oder den Entwicklermodus.",
+		HardlinkNotRegular:        "Harte Links können nur auf vorhandene reguläre Dateien zeigen; %s ist keine.",
+		SemanticFormatInvalid:     "Nicht unterstütztes Format %q; verwenden Sie json oder yaml.",
+		SemanticFormatUnknown:     "Das Format von %s lässt sich nicht aus der Endung ermitteln; geben Sie format als json oder yaml an.",
+		SemanticParseError:        "%s konnte nicht als %s geparst werden: %v",
+		SemanticNoDifferences:     "Keine semantischen Unterschiede.",
+		SemanticDifferences:       "%d semantische(r) Unterschied(e) zwischen %s und %s:",
+		SemanticAdded:             "Hinzugefügt: %s = %s",
+		SemanticRemoved:           "Entfernt: %s, war %s",
+		SemanticMoved:             "Verschoben: %s nach %s",
+		SemanticChanged:           "Geändert: %s von %s zu %s",
+		JSONEditInvalidOp:         "Unbekannte Operation %q; verwenden Sie set, delete oder append.",
+		JSONEditInvalidValue:      "Der Wert ist kein gültiges JSON: %v. Setzen Sie Zeichenketten in Anführungszeichen, z. B. \"\\\"Text\\\"\".",
+		JSONEditValueRequired:     "Die Operation %s benötigt einen Wert.",
+		JSONEditPathNotFound:      "Der Pfad %s existiert nicht.",
+		JSONEditNotArray:          "Der Pfad %s ist kein Array.",
+		JSONEditIndexRange:        "Index %s in %s liegt außerhalb des Bereichs; das Array hat %d Element(e).",
+		JSONEditDeleteRoot:        "Die Wurzel des Dokuments kann nicht gelöscht werden.",
+		JSONEditSet:               "%s in %s gesetzt.",
+		JSONEditDeleted:           "%s aus %s gelöscht.",
+		JSONEditAppended:          "An %s in %s angehängt.",
+		StructuredInvalidDocument: "%s ist kein gültiges %s: %v",
+		StructuredInvalidResult:   "Die Änderung würde %s zu ungültigem %s machen (%v); die Datei wurde nicht geändert.",
+		TOMLNullValue:             "TOML kennt keinen Nullwert; verwenden Sie die Operation delete, um %s zu entfernen.",
+		TOMLTablePath:             "%s ist eine Tabelle; setzen oder ergänzen Sie ihre Schlüssel einzeln.",
+		NotebookInvalid:           "%s ist kein gültiges Notebook: %v",
+		NotebookCellRange:         "Zelle %d existiert nicht; das Notebook hat %d Zelle(n).",
+		NotebookCellNotFound:      "Keine Zelle hat die ID %q.",
+		NotebookInvalidOperation:  "Unbekannte Operation %q; verwenden Sie replace, insert oder delete.",
+		NotebookInvalidCellType:   "Unbekannter Zelltyp %q; verwenden Sie code, markdown oder raw.",
+		NotebookSourceRequired:    "Die Operation %s benötigt source.",
+		NotebookCellReplaced:      "Zelle %d in %s ersetzt.",
+		NotebookCellInserted:      "%s-Zelle an Index %d in %s eingefügt.",
+		NotebookCellDeleted:       "Zelle %d aus %s gelöscht.",
+		SQLiteOpenError:           "%s konnte nicht als SQLite-Datenbank gelesen werden: %v",
+		SQLiteNoDatabases:         "Keine SQLite-Datenbanken unter %s gefunden.",
+		SQLiteDatabasesFound:      "SQLite-Datenbanken unter %s:",
+		SQLiteTableNotFound:       "Keine Tabelle namens %q. Tabellen: %s",
+		SQLiteWithoutRowid:        "%s ist eine WITHOUT-ROWID-Tabelle; verwenden Sie query, um ihre Zeilen zu lesen.",
+		SQLiteShellMissing:        "Für Abfragen wird die sqlite3-Kommandozeile benötigt, die im PATH nicht gefunden wurde.",
+		SQLiteDotCommand:          "Abfragen müssen SQL sein; sqlite3-Punktbefehle sind nicht erlaubt.",
+		SQLiteQueryFailed:         "Abfrage fehlgeschlagen: %s",
+		SQLiteRowsTruncated:       "Die ersten %d Zeile(n) werden angezeigt.",
+		TransformNoOperations:     "operations muss mindestens eine der Operationen sort, unique oder reverse enthalten.",
+		TransformInvalidOperation: "Unbekannte Operation %q; verwenden Sie sort, unique oder reverse.",
+		TransformApplied:          "Zeilen %d-%d umgewandelt: %d Zeile(n) verbleiben, %d Duplikat(e) entfernt.",
+		TransformUnchanged:        "Die Zeilen %d-%d stimmen bereits; die Datei wurde nicht geändert.",
+		GoModMissing:              "Keine go.mod in %s gefunden.",
+		GoCommandFailed:           "%s ist fehlgeschlagen: %s",
+		IndentNothingToDo:         "Geben Sie convert, shift oder beides an.",
+		IndentInvalidConvert:      "Unbekannter convert-Wert %q; verwenden Sie spaces oder tabs.",
+		IndentShiftTooFar:         "Zeile %d ist um weniger als %d Ebene(n) eingerückt; es wurde nichts geändert.",
+		IndentAdjusted:            "Einrückung der Zeilen %d-%d angepasst: %d Zeile(n) geändert.",
+		IgnoreSuggestions:         "Vorgeschlagene .gitignore-Ergänzungen für %s:",
+		IgnoreNothingToSuggest:    "Nichts vorzuschlagen: keine Build-Artefakte, Caches oder großen Dateien außerhalb von .gitignore unter %s.",
+		IgnoreLargeFiles:          "Große Dateien, die nicht ignoriert werden (vor dem Ignorieren prüfen):",
+		IgnoreApplied:             "%d Muster an %s angehängt.",
+		PathNotDirectory:          "%s ist kein Verzeichnis.",
+		MergeFilesInvalidStyle:    "Unbekannter Stil %q; verwenden Sie merge oder diff3.",
+		MergeFilesSummary:         "%d Änderung(en) konfliktfrei zusammengeführt; %d Konflikt(e) markiert.",
+		MergeFilesWritten:         "Das Ergebnis der Zusammenführung wurde nach %s geschrieben.",
+		GeneratedHeaderAdded:      "Kopfzeile für generierten Code hinzugefügt.",
+		StreamEditApplied:         "Datei zeilenweise im Datenstrom bearbeitet, da sie größer als %d MB ist: %d Zeile(n) entfernt, %d eingefügt. Es wird kein Diff angezeigt, der Formatierer läuft nicht und die Änderung wird nicht im Bearbeitungsjournal erfasst, daher kann undo_edit sie nicht rückgängig machen.",
+		ProxyCallFailed:           "Fehler beim Aufruf des Upstream-Werkzeugs %s: %v",
+		TemplateInvalidName:       "Der Vorlagenname %q ist kein relativer Pfad im Vorlagenverzeichnis.",
+		TemplateNotFound:          "Vorlage %q wurde in %s nicht gefunden. Verfügbare Vorlagen: %s",
+		TemplateExpandFailed:      "Vorlage %s konnte nicht expandiert werden: %v",
+		TemplateFileExists:        "%s existiert bereits; geben Sie line zum Einfügen oder overwrite zum Ersetzen an.",
+		TemplateWritten:           "Vorlage %s (%d Zeilen) nach %s geschrieben.",
+		TemplateInserted:          "Vorlage %s (%d Zeilen) vor Zeile %d von %s eingefügt.",
+		EditAmbiguous:             "old_string kommt %d-mal vor, erwartet wurden aber %d Ersetzung(en). Fügen Sie old_string umgebende Zeilen hinzu, schränken Sie die Suche mit start_line/end_line ein oder setzen Sie expected_replacements. Vorkommen:\n%s",
+		EditAmbiguousMore:         "\n  … und %d weitere",
+		FileCreatedByEdit:         "Die Datei %s existierte nicht und wurde erstellt.",
+		ColumnsInvalidOperation:   "Unbekannte Operation %q; verwenden Sie insert, delete oder replace.",
+		ColumnsEndRequired:        "end_column ist für %s erforderlich.",
+		ColumnsRange:              "ungültige Spalten %d-%d: start_column muss >= 1 und end_column >= start_column sein.",
+		ColumnsTextRequired:       "text ist für %s erforderlich.",
+		ColumnsTextLines:          "text hat %d Zeilen, der Block aber %d; übergeben Sie eine Zeile je Blockzeile oder eine einzige Zeile für alle.",
+		ColumnsUnchanged:          "Die Spalten der Zeilen %d-%d stimmen bereits überein; die Datei wurde nicht geändert.",
+		ColumnsApplied:            "%s auf die Spalten %d-%d der Zeilen %d-%d angewendet.",
+		UsageCallLimit:            "Nutzungslimit erreicht: Diese Sitzung hat %d Werkzeugaufrufe gemacht (usageLimits.maxToolCalls). %s wurde nicht ausgeführt; erhöhen Sie das Limit in der Konfiguration, um fortzufahren.",
+		UsageToolLimit:            "Nutzungslimit erreicht: %s wurde in dieser Sitzung %d-mal aufgerufen (usageLimits.tools). Es wurde nicht ausgeführt; erhöhen Sie das Limit in der Konfiguration, um fortzufahren.",
+		UsageBytesLimit:           "Nutzungslimit erreicht: Diese Sitzung hat %d Bytes an Werkzeugargumenten und -ergebnissen übertragen (usageLimits.maxBytes). %s wurde nicht ausgeführt; erhöhen Sie das Limit in der Konfiguration, um fortzufahren.",
+		PolicyDenied:              "Richtlinie %q hat %s abgelehnt.",
+		PolicyDeniedReason:        "Richtlinie %q hat %s abgelehnt: %s",
+		PolicyError:               "Richtlinie %q konnte nicht ausgewertet werden (%v); %s wurde abgelehnt.",
+		PatchParseError:           "Der Patch konnte nicht gelesen werden: %s",
+		PatchEmpty:                "Der Patch enthält keine Hunks.",
+		PatchHunks:                "Patch %s hat %d Hunks in %d Dateien; nichts wurde geändert. Wenden Sie die gewünschten mit apply_hunks an.",
+		PatchHunkApplies:          "passt",
+		PatchHunkOffset:           "passt in Zeile %d",
+		PatchHunkFails:            "passt nicht: %s",
+		PatchHunkMissing:          "die Zeilen, die er ändert, stehen nicht in der Datei",
+		PatchNotFound:             "Kein ausstehender Patch %q; teilen Sie den Patch erneut mit apply_patch_interactive auf.",
+		PatchNoHunks:              "Keine Hunks ausgewählt; nichts wurde geändert.",
+		PatchUnknownHunk:          "Patch %s hat keinen Hunk %d; wählen Sie zwischen 1 und %d.",
+		PatchConflict:             "Hunk %d passt nicht mehr auf %s; nichts wurde geändert.",
+		PatchApplied:              "%d von %d Hunks von Patch %s auf %d Dateien angewendet.",
+		SearchFormatInvalid:       "Nicht unterstütztes Format %q; verwenden Sie text, heading oder json.",
+		PreviewReplaceSummary:     "%d Ersetzungen in %d Zeilen in %d Dateien. Es wurde nichts geschrieben; wenden Sie sie mit replace_in_files und denselben Argumenten an.",
+		PreviewReplaceTruncated:   "Die ersten %d Zeilen werden angezeigt.",
+		PreviewReplaceNoMatches:   "Keine Zeile passt zu %q; nichts würde ersetzt.",
+		SymbolKindInvalid:         "Unbekannte Art %q; verwenden Sie func, method, type, const oder var.",
+		SymbolPatternInvalid:      "Ungültiges Namensmuster %q: %v",
+		SymbolsNotFound:           "Keine Go-Definition passt zu %q.",
+		SymbolsTruncated:          "... %d weitere Definitionen nicht angezeigt; grenzen Sie den Namen ein oder erhöhen Sie maxResults.",
+		TodosNotFound:             "Keine TODO-Kommentare in %s gefunden.",
+		TodosSummary:              "%d Kommentare in %d Dateien gefunden (%s).",
+		TodosTruncated:            "Nach %d Treffern angehalten; grenzen Sie die Suche ein oder erhöhen Sie maxResults.",
+		SearchStreamed:            "%d Treffer als Fortschrittsmeldungen gesendet; %d Dateien in %v durchsucht.",
+		SearchCursorInvalid:       "Ungültiger Cursor %q; übergeben Sie den Cursor der vorherigen Seite.",
+		SearchNextPage:            "Weitere Treffer folgen; übergeben Sie den Cursor %q mit denselben Argumenten für die nächste Seite.",
+		SearchFilesInvalidType:    "Ungültiger Typ %q: verwende file, directory oder any.",
+		SearchFilesInvalidTime:    "Ungültige Änderungszeit: verwende RFC 3339, ein Datum wie 2006-01-02 oder eine Dauer wie 24h.",
+		SearchHexInvalid:          "Ungültiges Hex-Muster %q: verwende Paare von Hexziffern wie '7f 45 4c 46'.",
+		SearchPatternTooComplex:   "Das Muster %q ist zu komplex für eine sichere Suche: Es ergibt mehr als %d Anweisungen. Vereinfachen Sie es oder verwenden Sie kleinere Wiederholungszahlen.",
+		SearchOverBudget:          "Das Muster brauchte bei %d Datei(en) zu lange, die nur teilweise durchsucht wurden: %s",
+		InputSent:                 "%d Bytes an die Eingabe von PID %d gesendet.",
+		InputClosed:               "%d Bytes an die Eingabe von PID %d gesendet und geschlossen.",
+		TerminalResized:           "Terminal von PID %d auf %d Spalten mal %d Zeilen geändert.",
+		CommandNotAllowed:         "Ausführung blockiert: '%s' steht nicht in der Liste erlaubter Befehle (allowedCommands) und darf daher nicht ausgeführt werden. Erlaubt: %s.",
+		CommandRuleDenied:         "Ausführung blockiert: Regel %q (commandRules) verweigert '%s'.",
+		CommandRuleDeniedReason:   "Ausführung blockiert: Regel %q (commandRules) verweigert '%s': %s",
+		ContainerUnavailable:      "Der Befehl kann nicht im konfigurierten Container ausgeführt werden: %v",
+		MacroNotFound:             "Makro %q ist nicht konfiguriert. Verfügbare Makros: %s",
+		MacroMissingParams:        "Makro %q braucht Werte für: %s",
+		MacroUnknownParams:        "Makro %q hat keine Parameter namens %s; es ist %s",
+		MacroUnsafeValue:          "Der Wert des Parameters %q von Makro %q kann für diese Shell nicht sicher maskiert werden: %q. Verwenden Sie nur Buchstaben, Ziffern und . _ / : = @ + , -",
+		MacroRunning:              "Makro %s führt aus: %s",
+		SessionLimitReached:       "Befehl nicht gestartet: Es laufen bereits %d Sitzungen, so viele wie maxSessions erlaubt. Warten Sie mit wait_for_completion, bis eine endet, oder beenden Sie eine mit force_terminate, und versuchen Sie es erneut.",
+		SessionQueueTimeout:       "Befehl nicht gestartet: Er hat %s in der Warteschlange gewartet, bis eine der %d laufenden Sitzungen endet.",
+		CommandQueued:             "In der Warteschlange: Position %d, wartet auf das Ende einer laufenden Sitzung",
+		CommandStartedQueued:      "(nach %s in der Warteschlange)",
+	},
+}
+
+// resolveLocale maps a configured locale such as "es-MX" or "pt_BR" to a catalog entry.
+func resolveLocale(locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if _, ok := catalog[locale]; ok {
+		return locale
+	}
+	if lang, _, ok := strings.Cut(locale, "-"); ok {
+		if _, ok := catalog[lang]; ok {
+			return lang
+		}
+	}
+	return DefaultLocale
+}
+
+// Translate returns the message for key in locale, falling back to English
+// and finally to the key itself.
+func Translate(locale, key string, args ...interface{}) string {
+	msg, ok := catalog[resolveLocale(locale)][key]
+	if !ok {
+		msg, ok = catalog[DefaultLocale][key]
+		if !ok {
+			msg = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// T returns the message for key in the locale configured for the server.
+func T(ctx *server.Context, key string, args ...interface{}) string {
+	locale := DefaultLocale
+	if cfg, err := config.GetCurrentConfig(ctx); err == nil && cfg.Locale != nil {
+		locale = *cfg.Locale
+	}
+	return Translate(locale, key, args...)
+}
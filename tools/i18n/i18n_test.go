@@ -0,0 +1,57 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogComplete(t *testing.T) {
+	for locale, messages := range catalog {
+		for key, english := range catalog[DefaultLocale] {
+			msg, ok := messages[key]
+			if !ok {
+				t.Errorf("%s: missing message %q", locale, key)
+				continue
+			}
+			want := verbPattern.FindAllString(english, -1)
+			got := verbPattern.FindAllString(msg, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s: %q has verbs %v, English has %v", locale, key, got, want)
+				continue
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("%s: %q has verbs %v, English has %v", locale, key, got, want)
+					break
+				}
+			}
+		}
+		for key := range messages {
+			if _, ok := catalog[DefaultLocale][key]; !ok {
+				t.Errorf("%s: message %q has no English original", locale, key)
+			}
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		locale string
+		key    string
+		args   []interface{}
+		want   string
+	}{
+		{"es", FileWritten, nil, "Archivo escrito correctamente."},
+		{"es-MX", FileWritten, nil, "Archivo escrito correctamente."},
+		{"pt_BR", FileWritten, nil, "File written successfully."},
+		{"de", EditsApplied, []interface{}{2, 1}, "2 Bearbeitung(en) in 1 Datei(en) angewendet."},
+		{"fr", "no.such.key", nil, "no.such.key"},
+	}
+	for _, tt := range tests {
+		if got := Translate(tt.locale, tt.key, tt.args...); got != tt.want {
+			t.Errorf("Translate(%q, %q) = %q, want %q", tt.locale, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package journal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxEntriesPerFile bounds how many before images are kept for a single file.
+const maxEntriesPerFile = 20
+
+// maxJournalBytes bounds the total size of before images held across all files.
+// When it is exceeded the oldest entries are evicted first.
+var maxJournalBytes int64 = 256 * 1024 * 1024
+
+// Entry records the state of a file immediately before a tool modified it.
+type Entry struct {
+	ID        int64
+	Path      string
+	Tool      string
+	Timestamp time.Time
+	Existed   bool        // False if the tool created the file
+	Before    []byte      // Content before the edit (nil if the file did not exist)
+	Mode      os.FileMode // Permissions before the edit
+	After     []byte      // SHA-256 of the content the tool wrote
+}
+
+// Journal keeps a per-file history of before images so edits can be reverted.
+type Journal struct {
+	mu      sync.Mutex // Protects entries, nextID and size
+	entries map[string][]*Entry
+	nextID  int64
+	size    int64 // Total bytes of before images held
+}
+
+// Pending is a before image captured ahead of a write. It is added to the
+// journal by Commit once the write has succeeded.
+type Pending struct {
+	journal *Journal
+	entry   *Entry
+}
+
+// OnRecord, if set, is called with each entry recorded by Commit or
+// RecordContent, after the write it describes has succeeded.
+var OnRecord func(*Entry)
+
+// Global instance of the Journal
+var globalJournal *Journal
+var once sync.Once
+
+// GetJournal returns the singleton instance of the Journal.
+func GetJournal() *Journal {
+	once.Do(func() {
+		globalJournal = newJournal()
+	})
+	return globalJournal
+}
+
+func newJournal() *Journal {
+	return &Journal{entries: make(map[string][]*Entry)}
+}
+
+// Capture records the before image of path in the global journal ahead of a
+// write by tool. Failures are logged rather than returned so that a file that
+// cannot be journaled can still be edited; the returned Pending is then nil,
+// which Commit tolerates.
+func Capture(logger *slog.Logger, path, tool string) *Pending {
+	pending, err := GetJournal().Begin(path, tool)
+	if err != nil {
+		logger.Info("Warning: Could not record edit journal entry", "path", path, "tool", tool, "error", err)
+		return nil
+	}
+	return pending
+}
+
+// digest returns the SHA-256 of content.
+func digest(content []byte) []byte {
+	sum := sha256.Sum256(content)
+	return sum[:]
+}
+
+// normalizePath returns the key used to group entries for a file.
+func normalizePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// Begin captures the current content of path before a tool modifies it.
+// It must be called before the write; a missing file is recorded as a creation.
+func (j *Journal) Begin(path, tool string) (*Pending, error) {
+	entry := &Entry{
+		Path:      normalizePath(path),
+		Tool:      tool,
+		Timestamp: time.Now(),
+		Mode:      0644,
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read before image of %s: %w", path, readErr)
+		}
+		entry.Existed = true
+		entry.Before = content
+		entry.Mode = info.Mode()
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	return &Pending{journal: j, entry: entry}, nil
+}
+
+// Commit adds the captured entry to the journal, remembering a digest of the
+// content that was written so a later undo can detect outside changes.
+// It is a no-op on a nil Pending.
+func (p *Pending) Commit(after []byte) {
+	if p == nil {
+		return
+	}
+	p.entry.After = digest(after)
+	p.journal.add(p.entry)
+	if OnRecord != nil {
+		OnRecord(p.entry)
+	}
+}
+
+// RecordContent records a before image that the caller already holds in memory,
+// for tools that stage content before writing and only journal successful writes.
+func (j *Journal) RecordContent(path, tool string, before, after []byte, mode os.FileMode) {
+	entry := &Entry{
+		Path:      normalizePath(path),
+		Tool:      tool,
+		Timestamp: time.Now(),
+		Existed:   true,
+		Before:    before,
+		Mode:      mode,
+		After:     digest(after),
+	}
+	j.add(entry)
+	if OnRecord != nil {
+		OnRecord(entry)
+	}
+}
+
+// add appends an entry to the history of its file, trimming the oldest entries
+// of that file and then the oldest entries overall until the byte budget is met.
+func (j *Journal) add(entry *Entry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.nextID++
+	entry.ID = j.nextID
+
+	history := append(j.entries[entry.Path], entry)
+	j.size += int64(len(entry.Before))
+	if len(history) > maxEntriesPerFile {
+		for _, dropped := range history[:len(history)-maxEntriesPerFile] {
+			j.size -= int64(len(dropped.Before))
+		}
+		history = history[len(history)-maxEntriesPerFile:]
+	}
+	j.entries[entry.Path] = history
+
+	for j.size > maxJournalBytes && j.evictOldest() {
+	}
+}
+
+// evictOldest drops the oldest entry across all files. It reports false when
+// the journal is empty. The caller must hold j.mu.
+func (j *Journal) evictOldest() bool {
+	var oldestKey string
+	var oldest *Entry
+	for key, history := range j.entries {
+		if len(history) > 0 && (oldest == nil || history[0].ID < oldest.ID) {
+			oldestKey, oldest = key, history[0]
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	j.size -= int64(len(oldest.Before))
+	j.entries[oldestKey] = j.entries[oldestKey][1:]
+	if len(j.entries[oldestKey]) == 0 {
+		delete(j.entries, oldestKey)
+	}
+	return true
+}
+
+// Size returns the total bytes of before images currently held.
+func (j *Journal) Size() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.size
+}
+
+// List returns journal entries, oldest first. If path is empty, entries for all files are returned.
+func (j *Journal) List(path string) []*Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var result []*Entry
+	if path != "" {
+		result = append(result, j.entries[normalizePath(path)]...)
+		return result
+	}
+	for _, history := range j.entries {
+		result = append(result, history...)
+	}
+	return result
+}
+
+// Undo reverts the last count edits recorded for path, restoring the oldest
+// before image among them. It returns the number of edits reverted. Unless
+// force is set, Undo refuses when the file no longer holds the content written
+// by the last recorded edit, since restoring would discard changes made by
+// tools or editors that are not journaled.
+func (j *Journal) Undo(path string, count int, force bool) (int, error) {
+	if count <= 0 {
+		return 0, fmt.Errorf("count must be positive")
+	}
+	key := normalizePath(path)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	history := j.entries[key]
+	if len(history) == 0 {
+		return 0, fmt.Errorf("no recorded edits for %s", path)
+	}
+	if count > len(history) {
+		count = len(history)
+	}
+
+	if last := history[len(history)-1]; !force && last.After != nil {
+		current, err := os.ReadFile(key)
+		if err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err != nil || !bytes.Equal(digest(current), last.After) {
+			return 0, fmt.Errorf("%s has changed since the last recorded edit (%s); undoing would discard those changes. Pass force to undo anyway", path, last.Tool)
+		}
+	}
+
+	// Restoring the oldest of the reverted entries undoes every later edit as well
+	target := history[len(history)-count]
+	if target.Existed {
+		if err := os.WriteFile(key, target.Before, target.Mode); err != nil {
+			return 0, fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	} else {
+		if err := os.Remove(key); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to remove created file %s: %w", path, err)
+		}
+	}
+
+	for _, reverted := range history[len(history)-count:] {
+		j.size -= int64(len(reverted.Before))
+	}
+	j.entries[key] = history[:len(history)-count]
+	if len(j.entries[key]) == 0 {
+		delete(j.entries, key)
+	}
+	return count, nil
+}
@@ -0,0 +1,158 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// write records an edit of path to content in j, as a journaled tool would.
+func write(t *testing.T, j *Journal, path, content string) {
+	t.Helper()
+	pending, err := j.Begin(path, "test")
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	pending.Commit([]byte(content))
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(content)
+}
+
+func TestUndo(t *testing.T) {
+	j := newJournal()
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	write(t, j, path, "v2")
+	write(t, j, path, "v3")
+	write(t, j, path, "v4")
+
+	if n, err := j.Undo(path, 1, false); err != nil || n != 1 {
+		t.Fatalf("Undo(1) = %d, %v", n, err)
+	}
+	if got := readString(t, path); got != "v3" {
+		t.Errorf("After undoing one edit got %q, want v3", got)
+	}
+
+	// Undoing more than recorded reverts everything that is left
+	if n, err := j.Undo(path, 10, false); err != nil || n != 2 {
+		t.Fatalf("Undo(10) = %d, %v", n, err)
+	}
+	if got := readString(t, path); got != "v1" {
+		t.Errorf("After undoing all edits got %q, want v1", got)
+	}
+	if _, err := j.Undo(path, 1, false); err == nil {
+		t.Error("Expected an error when no edits are left")
+	}
+	if j.Size() != 0 {
+		t.Errorf("Size() = %d after undoing everything, want 0", j.Size())
+	}
+}
+
+func TestUndoCreatedFile(t *testing.T) {
+	j := newJournal()
+	path := filepath.Join(t.TempDir(), "new.txt")
+
+	write(t, j, path, "created")
+	if _, err := j.Undo(path, 1, false); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected created file to be removed, stat error = %v", err)
+	}
+}
+
+func TestUndoRefusesOutsideChanges(t *testing.T) {
+	j := newJournal()
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	write(t, j, path, "edited")
+
+	// Simulate a change by a tool that is not journaled
+	if err := os.WriteFile(path, []byte("edited elsewhere"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+
+	_, err := j.Undo(path, 1, false)
+	if err == nil || !strings.Contains(err.Error(), "has changed") {
+		t.Fatalf("Expected a refusal, got %v", err)
+	}
+	if got := readString(t, path); got != "edited elsewhere" {
+		t.Errorf("File was modified by a refused undo: %q", got)
+	}
+
+	if _, err := j.Undo(path, 1, true); err != nil {
+		t.Fatalf("Forced undo failed: %v", err)
+	}
+	if got := readString(t, path); got != "original" {
+		t.Errorf("After forced undo got %q, want original", got)
+	}
+}
+
+func TestEntriesPerFileLimit(t *testing.T) {
+	j := newJournal()
+	path := filepath.Join(t.TempDir(), "a.txt")
+	for i := 0; i < maxEntriesPerFile+5; i++ {
+		write(t, j, path, strings.Repeat("x", i+1))
+	}
+	if got := len(j.List(path)); got != maxEntriesPerFile {
+		t.Errorf("Kept %d entries, want %d", got, maxEntriesPerFile)
+	}
+
+	var want int64
+	for _, e := range j.List(path) {
+		want += int64(len(e.Before))
+	}
+	if j.Size() != want {
+		t.Errorf("Size() = %d, want %d", j.Size(), want)
+	}
+}
+
+func TestByteBudgetEvictsOldest(t *testing.T) {
+	defer func(old int64) { maxJournalBytes = old }(maxJournalBytes)
+	maxJournalBytes = 25
+
+	j := newJournal()
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte(strings.Repeat("0", 10)), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	write(t, j, a, strings.Repeat("1", 10)) // holds 10 bytes
+	write(t, j, b, strings.Repeat("1", 10)) // holds 20 bytes
+	write(t, j, a, strings.Repeat("2", 10)) // 30 bytes, evicts the first edit of a
+
+	if j.Size() > maxJournalBytes {
+		t.Errorf("Size() = %d exceeds the budget of %d", j.Size(), maxJournalBytes)
+	}
+	if got := len(j.List(a)); got != 1 {
+		t.Errorf("Expected 1 remaining entry for a, got %d", got)
+	}
+	if got := len(j.List(b)); got != 1 {
+		t.Errorf("Expected the entry for b to be kept, got %d", got)
+	}
+}
+
+func TestCommitNilPending(t *testing.T) {
+	var p *Pending
+	p.Commit([]byte("ignored")) // must not panic
+}
@@ -0,0 +1,80 @@
+package journal
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// ListEditsArgs defines the arguments for the list_edits tool.
+type ListEditsArgs struct {
+	FilePath string `json:"file_path,omitempty" description:"Optional file path to list edits for. Lists edits for all files if omitted."`
+}
+
+// UndoEditArgs defines the arguments for the undo_edit tool.
+type UndoEditArgs struct {
+	FilePath string `json:"file_path" description:"The path of the file whose edits should be reverted." required:"true"`
+	Count    *int   `json:"count,omitempty" description:"Optional number of most recent edits to revert. Defaults to 1."`
+	Force    *bool  `json:"force,omitempty" description:"Optional. Undo even if the file was changed after the last recorded edit, discarding those changes."`
+}
+
+// EditInfo is the JSON representation of a journal entry returned by list_edits.
+type EditInfo struct {
+	ID         int64  `json:"id"`
+	FilePath   string `json:"file_path"`
+	Tool       string `json:"tool"`
+	Timestamp  string `json:"timestamp"`
+	Created    bool   `json:"created"`
+	BeforeSize int    `json:"before_size"`
+}
+
+// HandleListEdits implements the list_edits tool.
+func HandleListEdits(ctx *server.Context, args ListEditsArgs) (string, error) {
+	ctx.Logger.Info("Handling list_edits tool call")
+
+	entries := GetJournal().List(args.FilePath)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	edits := make([]EditInfo, 0, len(entries))
+	for _, e := range entries {
+		edits = append(edits, EditInfo{
+			ID:         e.ID,
+			FilePath:   e.Path,
+			Tool:       e.Tool,
+			Timestamp:  e.Timestamp.Format(time.RFC3339),
+			Created:    !e.Existed,
+			BeforeSize: len(e.Before),
+		})
+	}
+
+	editsJson, err := json.MarshalIndent(edits, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling edit list", "error", err)
+		return "Error generating edit list output", err
+	}
+	return string(editsJson), nil
+}
+
+// HandleUndoEdit implements the undo_edit tool.
+func HandleUndoEdit(ctx *server.Context, args UndoEditArgs) (string, error) {
+	ctx.Logger.Info("Handling undo_edit tool call")
+
+	count := 1
+	if args.Count != nil {
+		count = *args.Count
+	}
+
+	force := args.Force != nil && *args.Force
+	reverted, err := GetJournal().Undo(args.FilePath, count, force)
+	if err != nil {
+		ctx.Logger.Info("Error undoing edit", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.UndoFailed, err.Error()), nil
+	}
+
+	ctx.Logger.Info("Edits reverted", "filePath", args.FilePath, "count", reverted)
+	return i18n.T(ctx, i18n.EditsReverted, reverted, args.FilePath), nil
+}
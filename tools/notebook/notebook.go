@@ -0,0 +1,375 @@
+package notebook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// NotebookReadArgs defines the arguments for the notebook_read tool.
+type NotebookReadArgs struct {
+	FilePath       string `json:"file_path" description:"The .ipynb file to read." required:"true"`
+	IncludeOutputs *bool  `json:"include_outputs,omitempty" description:"Optional. If true, show text outputs (truncated) instead of a one-line summary per output. Images and other binary outputs are always summarized."`
+}
+
+// NotebookEditCellArgs defines the arguments for the notebook_edit_cell tool.
+type NotebookEditCellArgs struct {
+	FilePath  string  `json:"file_path" description:"The .ipynb file to edit." required:"true"`
+	Operation string  `json:"operation" description:"replace (the cell's source), insert (a new cell) or delete." required:"true"`
+	Index     *int    `json:"index,omitempty" description:"The 0-based cell index as shown by notebook_read. For insert, the new cell goes before this index; defaults to the end of the notebook."`
+	CellID    *string `json:"cell_id,omitempty" description:"Optional. Select the cell to replace or delete by its id instead of its index."`
+	Source    *string `json:"source,omitempty" description:"The new cell source, required for replace and insert. Replacing a code cell clears its outputs and execution count."`
+	CellType  *string `json:"cell_type,omitempty" description:"Optional. code, markdown or raw. Defaults to code for insert; for replace it changes the cell's type."`
+}
+
+// maxOutputLines caps each text output shown by notebook_read.
+const maxOutputLines = 30
+
+// notebook is a parsed .ipynb file. Fields other than cells are kept as read so
+// they are written back unchanged.
+type notebook struct {
+	doc      map[string]interface{}
+	cells    []interface{}
+	indent   string
+	trailing bool // whether the file ends with a newline
+}
+
+// loadNotebook reads and parses an .ipynb file. When it cannot be loaded, msg
+// is the message for the client and err the underlying I/O error, if any.
+func loadNotebook(ctx *server.Context, filePath string) (nb *notebook, msg string, err error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, i18n.T(ctx, i18n.FileNotFound), nil
+		}
+		ctx.Logger.Info("Error reading notebook", "filePath", filePath, "error", err)
+		return nil, i18n.T(ctx, i18n.FileReadError), err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, i18n.T(ctx, i18n.NotebookInvalid, filePath, err), nil
+	}
+	cells, ok := doc["cells"].([]interface{})
+	if !ok {
+		return nil, i18n.T(ctx, i18n.NotebookInvalid, filePath, "missing cells list"), nil
+	}
+	for i, c := range cells {
+		if _, ok := c.(map[string]interface{}); !ok {
+			return nil, i18n.T(ctx, i18n.NotebookInvalid, filePath, fmt.Sprintf("cell %d is not an object", i)), nil
+		}
+	}
+
+	// nbformat writes one space of indentation; keep whatever the file uses
+	indent := " "
+	if i := bytes.IndexByte(content, '\n'); i >= 0 {
+		rest := content[i+1:]
+		n := len(rest) - len(bytes.TrimLeft(rest, " \t"))
+		if n > 0 {
+			indent = string(rest[:n])
+		}
+	}
+
+	return &notebook{
+		doc:      doc,
+		cells:    cells,
+		indent:   indent,
+		trailing: bytes.HasSuffix(content, []byte("\n")),
+	}, "", nil
+}
+
+// encode renders the notebook the way nbformat does: sorted keys and no HTML escaping.
+func (nb *notebook) encode() ([]byte, error) {
+	nb.doc["cells"] = nb.cells
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", nb.indent)
+	if err := enc.Encode(nb.doc); err != nil {
+		return nil, err
+	}
+	out := buf.Bytes()
+	if !nb.trailing {
+		out = bytes.TrimSuffix(out, []byte("\n"))
+	}
+	return out, nil
+}
+
+// multiline joins an nbformat multi-line string, which is either a string or a
+// list of lines.
+func multiline(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []interface{}:
+		var b strings.Builder
+		for _, line := range t {
+			if s, ok := line.(string); ok {
+				b.WriteString(s)
+			}
+		}
+		return b.String()
+	}
+	return ""
+}
+
+// splitSource converts text to the list-of-lines form nbformat writes.
+func splitSource(text string) []interface{} {
+	lines := []interface{}{}
+	for text != "" {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:i+1])
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// summarizeOutput describes one output in a line, or shows its text when full is set.
+func summarizeOutput(output map[string]interface{}, full bool) string {
+	outputType, _ := output["output_type"].(string)
+	switch outputType {
+	case "stream":
+		name, _ := output["name"].(string)
+		return describeText(fmt.Sprintf("stream %s", name), multiline(output["text"]), full)
+	case "error":
+		ename, _ := output["ename"].(string)
+		evalue, _ := output["evalue"].(string)
+		return fmt.Sprintf("error %s: %s", ename, evalue)
+	case "execute_result", "display_data":
+		data, _ := output["data"].(map[string]interface{})
+		mimes := make([]string, 0, len(data))
+		for mime := range data {
+			mimes = append(mimes, mime)
+		}
+		sort.Strings(mimes)
+		label := outputType + " " + strings.Join(mimes, ", ")
+		if text, ok := data["text/plain"]; ok {
+			return describeText(label, multiline(text), full)
+		}
+		return label
+	}
+	return outputType
+}
+
+// describeText labels an output's text with its line count and either its
+// first line or, when full is set, up to maxOutputLines lines.
+func describeText(label, text string, full bool) string {
+	text = strings.TrimSuffix(text, "\n")
+	lines := strings.Split(text, "\n")
+	if !full {
+		first := lines[0]
+		if len(first) > 80 {
+			first = first[:80] + "..."
+		}
+		return fmt.Sprintf("%s (%d line(s)): %q", label, len(lines), first)
+	}
+	if len(lines) > maxOutputLines {
+		omitted := len(lines) - maxOutputLines
+		lines = append(lines[:maxOutputLines], fmt.Sprintf("... %d more line(s)", omitted))
+	}
+	return label + ":\n" + strings.Join(lines, "\n")
+}
+
+// HandleNotebookRead implements the notebook_read tool.
+func HandleNotebookRead(ctx *server.Context, args NotebookReadArgs) (string, error) {
+	ctx.Logger.Info("Handling notebook_read tool call")
+
+	nb, msg, err := loadNotebook(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+	full := args.IncludeOutputs != nil && *args.IncludeOutputs
+
+	var b strings.Builder
+	language := ""
+	if meta, ok := nb.doc["metadata"].(map[string]interface{}); ok {
+		if info, ok := meta["language_info"].(map[string]interface{}); ok {
+			language, _ = info["name"].(string)
+		}
+	}
+	if language != "" {
+		fmt.Fprintf(&b, "%s: %d cell(s), %s\n", args.FilePath, len(nb.cells), language)
+	} else {
+		fmt.Fprintf(&b, "%s: %d cell(s)\n", args.FilePath, len(nb.cells))
+	}
+
+	for i, c := range nb.cells {
+		cell := c.(map[string]interface{})
+		cellType, _ := cell["cell_type"].(string)
+		fmt.Fprintf(&b, "\n[%d] %s", i, cellType)
+		if id, ok := cell["id"].(string); ok {
+			fmt.Fprintf(&b, " id=%s", id)
+		}
+		if count, ok := cell["execution_count"].(json.Number); ok {
+			fmt.Fprintf(&b, " execution_count=%s", count)
+		}
+		b.WriteByte('\n')
+		if source := strings.TrimSuffix(multiline(cell["source"]), "\n"); source != "" {
+			b.WriteString(source + "\n")
+		}
+
+		outputs, _ := cell["outputs"].([]interface{})
+		for _, o := range outputs {
+			if output, ok := o.(map[string]interface{}); ok {
+				b.WriteString("Output: " + summarizeOutput(output, full) + "\n")
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// newCellID returns a random id in the form nbformat 4.5 uses.
+func newCellID() string {
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// setCellType changes a cell's type, adding or removing the code-only fields.
+func setCellType(cell map[string]interface{}, cellType string) {
+	cell["cell_type"] = cellType
+	if cellType == "code" {
+		if _, ok := cell["outputs"]; !ok {
+			cell["outputs"] = []interface{}{}
+		}
+		if _, ok := cell["execution_count"]; !ok {
+			cell["execution_count"] = nil
+		}
+		return
+	}
+	delete(cell, "outputs")
+	delete(cell, "execution_count")
+}
+
+// supportsCellIDs reports whether the notebook format version has cell ids (4.5+).
+func (nb *notebook) supportsCellIDs() bool {
+	major, _ := nb.doc["nbformat"].(json.Number)
+	minor, _ := nb.doc["nbformat_minor"].(json.Number)
+	ma, _ := major.Int64()
+	mi, _ := minor.Int64()
+	return ma > 4 || ma == 4 && mi >= 5
+}
+
+// HandleNotebookEditCell implements the notebook_edit_cell tool.
+func HandleNotebookEditCell(ctx *server.Context, args NotebookEditCellArgs) (string, error) {
+	ctx.Logger.Info("Handling notebook_edit_cell tool call")
+
+	operation := strings.ToLower(args.Operation)
+	switch operation {
+	case "replace", "insert":
+		if args.Source == nil {
+			return i18n.T(ctx, i18n.NotebookSourceRequired, operation), nil
+		}
+	case "delete":
+	default:
+		return i18n.T(ctx, i18n.NotebookInvalidOperation, args.Operation), nil
+	}
+	cellType := ""
+	if args.CellType != nil && *args.CellType != "" {
+		cellType = strings.ToLower(*args.CellType)
+		if cellType != "code" && cellType != "markdown" && cellType != "raw" {
+			return i18n.T(ctx, i18n.NotebookInvalidCellType, *args.CellType), nil
+		}
+	}
+
+	info, err := os.Stat(args.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return i18n.T(ctx, i18n.FileNotFound), nil
+		}
+		return i18n.T(ctx, i18n.FileAccessError), err
+	}
+	nb, msg, err := loadNotebook(ctx, args.FilePath)
+	if msg != "" {
+		return msg, err
+	}
+
+	// Resolve the target cell
+	index := len(nb.cells)
+	if args.Index != nil {
+		index = *args.Index
+	}
+	if operation != "insert" && args.CellID != nil && *args.CellID != "" {
+		index = -1
+		for i, c := range nb.cells {
+			if id, _ := c.(map[string]interface{})["id"].(string); id == *args.CellID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return i18n.T(ctx, i18n.NotebookCellNotFound, *args.CellID), nil
+		}
+	}
+	limit := len(nb.cells)
+	if operation == "insert" {
+		limit++ // inserting at the end is allowed
+	}
+	if index < 0 || index >= limit {
+		return i18n.T(ctx, i18n.NotebookCellRange, index, len(nb.cells)), nil
+	}
+
+	var result string
+	switch operation {
+	case "replace":
+		cell := nb.cells[index].(map[string]interface{})
+		cell["source"] = splitSource(*args.Source)
+		if cellType != "" {
+			setCellType(cell, cellType)
+		}
+		if cell["cell_type"] == "code" {
+			// The old outputs no longer describe the new source
+			cell["outputs"] = []interface{}{}
+			cell["execution_count"] = nil
+		}
+		result = i18n.T(ctx, i18n.NotebookCellReplaced, index, args.FilePath)
+	case "insert":
+		if cellType == "" {
+			cellType = "code"
+		}
+		cell := map[string]interface{}{
+			"metadata": map[string]interface{}{},
+			"source":   splitSource(*args.Source),
+		}
+		setCellType(cell, cellType)
+		if nb.supportsCellIDs() {
+			cell["id"] = newCellID()
+		}
+		nb.cells = append(nb.cells[:index], append([]interface{}{cell}, nb.cells[index:]...)...)
+		result = i18n.T(ctx, i18n.NotebookCellInserted, cellType, index, args.FilePath)
+	case "delete":
+		nb.cells = append(nb.cells[:index], nb.cells[index+1:]...)
+		result = i18n.T(ctx, i18n.NotebookCellDeleted, index, args.FilePath)
+	}
+
+	updated, err := nb.encode()
+	if err != nil {
+		ctx.Logger.Info("Error encoding notebook", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+	pending := journal.Capture(ctx.Logger, args.FilePath, "notebook_edit_cell")
+	if err := os.WriteFile(args.FilePath, updated, info.Mode()); err != nil {
+		ctx.Logger.Info("Error writing notebook", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+	pending.Commit(updated)
+
+	ctx.Logger.Info("Notebook cell edited", "filePath", args.FilePath, "operation", operation, "index", index)
+	return result, nil
+}
@@ -0,0 +1,206 @@
+package notebook
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+const sampleNotebook = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "id": "a1",
+   "metadata": {},
+   "source": [
+    "# Title\n",
+    "Intro <b>text</b>"
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 3,
+   "id": "b2",
+   "metadata": {},
+   "outputs": [
+    {
+     "name": "stdout",
+     "output_type": "stream",
+     "text": [
+      "hello\n",
+      "world\n"
+     ]
+    },
+    {
+     "data": {
+      "image/png": "iVBORw0KGgo=",
+      "text/plain": [
+       "<Figure size 640x480>"
+      ]
+     },
+     "metadata": {},
+     "output_type": "display_data"
+    }
+   ],
+   "source": [
+    "print('hello')\n",
+    "print('world')"
+   ]
+  }
+ ],
+ "metadata": {
+  "language_info": {
+   "name": "python"
+  }
+ },
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func testContext() *server.Context {
+	return &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func writeNotebook(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "analysis.ipynb")
+	if err := os.WriteFile(path, []byte(sampleNotebook), 0644); err != nil {
+		t.Fatalf("Failed to write notebook: %v", err)
+	}
+	return path
+}
+
+func TestNotebookRead(t *testing.T) {
+	path := writeNotebook(t)
+
+	result, err := HandleNotebookRead(testContext(), NotebookReadArgs{FilePath: path})
+	if err != nil {
+		t.Fatalf("HandleNotebookRead() error = %v", err)
+	}
+	for _, want := range []string{
+		"2 cell(s), python",
+		"[0] markdown id=a1\n# Title\nIntro <b>text</b>\n",
+		"[1] code id=b2 execution_count=3\nprint('hello')\nprint('world')\n",
+		`Output: stream stdout (2 line(s)): "hello"`,
+		`Output: display_data image/png, text/plain (1 line(s)): "<Figure size 640x480>"`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "iVBORw0KGgo") {
+		t.Errorf("image data should be elided:\n%s", result)
+	}
+
+	full := true
+	result, _ = HandleNotebookRead(testContext(), NotebookReadArgs{FilePath: path, IncludeOutputs: &full})
+	if !strings.Contains(result, "Output: stream stdout:\nhello\nworld\n") {
+		t.Errorf("expected full stream output:\n%s", result)
+	}
+}
+
+func TestNotebookEditCell(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	strPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		args    NotebookEditCellArgs
+		wantMsg string
+		check   func(t *testing.T, content string)
+	}{
+		{
+			name:    "replace code cell clears outputs",
+			args:    NotebookEditCellArgs{Operation: "replace", CellID: strPtr("b2"), Source: strPtr("x = 1\nx")},
+			wantMsg: "Replaced cell 1",
+			check: func(t *testing.T, content string) {
+				if !strings.Contains(content, "   \"execution_count\": null,\n") || !strings.Contains(content, "   \"outputs\": [],\n") {
+					t.Errorf("outputs not cleared:\n%s", content)
+				}
+				if !strings.Contains(content, "    \"x = 1\\n\",\n    \"x\"\n") {
+					t.Errorf("source not split into lines:\n%s", content)
+				}
+			},
+		},
+		{
+			name:    "replace keeps the rest of the file as written",
+			args:    NotebookEditCellArgs{Operation: "replace", Index: intPtr(0), Source: strPtr("# Title\nIntro <b>text</b>"), CellType: strPtr("markdown")},
+			wantMsg: "Replaced cell 0",
+			check: func(t *testing.T, content string) {
+				if content != sampleNotebook {
+					t.Errorf("unchanged replace rewrote the notebook:\n%s", content)
+				}
+			},
+		},
+		{
+			name:    "insert markdown",
+			args:    NotebookEditCellArgs{Operation: "insert", Index: intPtr(1), Source: strPtr("## Setup"), CellType: strPtr("markdown")},
+			wantMsg: "Inserted a markdown cell at index 1",
+			check: func(t *testing.T, content string) {
+				setup := strings.Index(content, "## Setup")
+				if setup < 0 || setup > strings.Index(content, "print('hello')") {
+					t.Errorf("cell not inserted before the code cell:\n%s", content)
+				}
+				if strings.Count(content, "\"id\"") != 3 {
+					t.Errorf("new cell should get an id:\n%s", content)
+				}
+			},
+		},
+		{
+			name:    "delete",
+			args:    NotebookEditCellArgs{Operation: "delete", Index: intPtr(0)},
+			wantMsg: "Deleted cell 0",
+			check: func(t *testing.T, content string) {
+				if strings.Contains(content, "# Title") {
+					t.Errorf("cell not deleted:\n%s", content)
+				}
+			},
+		},
+		{
+			name:    "index out of range",
+			args:    NotebookEditCellArgs{Operation: "delete", Index: intPtr(2)},
+			wantMsg: "Cell 2 does not exist",
+		},
+		{
+			name:    "unknown id",
+			args:    NotebookEditCellArgs{Operation: "replace", CellID: strPtr("zz"), Source: strPtr("")},
+			wantMsg: `No cell has the id "zz"`,
+		},
+		{
+			name:    "source required",
+			args:    NotebookEditCellArgs{Operation: "insert"},
+			wantMsg: "requires source",
+		},
+		{
+			name:    "bad cell type",
+			args:    NotebookEditCellArgs{Operation: "insert", Source: strPtr("x"), CellType: strPtr("sql")},
+			wantMsg: "Unknown cell type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeNotebook(t)
+			tt.args.FilePath = path
+			result, err := HandleNotebookEditCell(testContext(), tt.args)
+			if err != nil {
+				t.Fatalf("HandleNotebookEditCell() error = %v", err)
+			}
+			if !strings.Contains(result, tt.wantMsg) {
+				t.Errorf("result = %q, want it to contain %q", result, tt.wantMsg)
+			}
+			if tt.check != nil {
+				content, _ := os.ReadFile(path)
+				tt.check(t, string(content))
+			}
+		})
+	}
+}
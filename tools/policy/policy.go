@@ -0,0 +1,248 @@
+// Package policy authorizes tool calls against the CEL rules in the policies
+// config value. Each rule's expression sees the call as these variables:
+//
+//	tool    string               the tool name, e.g. "write_file"
+//	args    map(string, dyn)     the call's arguments as sent by the client
+//	paths   list(string)         the absolute, symlink-resolved paths named in args
+//	client  map(string, string)  "user" (the OS user running the server) and "protocol"
+//	now     timestamp            the time of the call
+//
+// The first rule whose expression is true allows or denies the call; a call no
+// rule matches is allowed. A rule that fails to compile or evaluate denies the
+// call. The blocked command list and allowed directories are still checked by
+// the tools themselves, so an allow rule cannot lift them.
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gocreate/tools/config"
+	"gocreate/tools/i18n"
+
+	"github.com/google/cel-go/cel"
+	"github.com/localrivet/gomcp/server"
+)
+
+// pathKeys are the argument names that hold file system paths, at any depth
+// of the arguments.
+var pathKeys = map[string]bool{
+	"path": true, "paths": true, "file_path": true, "source": true, "destination": true,
+	"target": true, "link_path": true, "output_path": true, "output_dir": true,
+	"file_a": true, "file_b": true, "base": true, "ours": true, "theirs": true,
+	"cwd": true, "files": true, "base_dir": true,
+}
+
+var (
+	envOnce sync.Once
+	env     *cel.Env
+	envErr  error
+)
+
+func init() {
+	config.CompilePolicy = func(expr string) error {
+		_, err := compile(expr)
+		return err
+	}
+}
+
+// celEnv returns the environment rules are compiled in.
+func celEnv() (*cel.Env, error) {
+	envOnce.Do(func() {
+		env, envErr = cel.NewEnv(
+			cel.Variable("tool", cel.StringType),
+			cel.Variable("args", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("paths", cel.ListType(cel.StringType)),
+			cel.Variable("client", cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable("now", cel.TimestampType),
+		)
+	})
+	return env, envErr
+}
+
+// compile turns a rule expression into a program, which must yield a bool.
+func compile(expr string) (cel.Program, error) {
+	e, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := e.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("the expression yields %s, not bool", ast.OutputType())
+	}
+	return e.Program(ast)
+}
+
+// rule is a compiled policy. err is kept so the rule denies every call it is
+// asked about instead of being skipped.
+type rule struct {
+	config.Policy
+	program cel.Program
+	err     error
+}
+
+// rules caches the compiled form of the policies of one loaded config.
+var rules struct {
+	mu       sync.Mutex
+	policies []config.Policy
+	compiled []rule
+}
+
+// compiled returns the rules for policies, compiling them when the config
+// has been reloaded since the last call.
+func compiled(policies []config.Policy) []rule {
+	rules.mu.Lock()
+	defer rules.mu.Unlock()
+	if len(policies) > 0 && len(rules.policies) == len(policies) && &rules.policies[0] == &policies[0] {
+		return rules.compiled
+	}
+	rules.policies = policies
+	rules.compiled = make([]rule, len(policies))
+	for i, p := range policies {
+		rules.compiled[i] = rule{Policy: p}
+		rules.compiled[i].program, rules.compiled[i].err = compile(p.When)
+	}
+	return rules.compiled
+}
+
+// Call is a tool call as policies see it.
+type Call struct {
+	Tool   string
+	Args   map[string]interface{}
+	Client map[string]string
+	Time   time.Time
+}
+
+// Decide returns the message denying call, or "" when policies allow it.
+func Decide(ctx *server.Context, policies []config.Policy, call Call) string {
+	if len(policies) == 0 {
+		return ""
+	}
+	args := call.Args
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	client := map[string]string{"user": "", "protocol": ""}
+	for k, v := range call.Client {
+		client[k] = v
+	}
+	vars := map[string]interface{}{
+		"tool":   call.Tool,
+		"args":   args,
+		"paths":  argPaths(args),
+		"client": client,
+		"now":    call.Time,
+	}
+	for i, r := range compiled(policies) {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("policy %d", i+1)
+		}
+		if r.err != nil {
+			return i18n.T(ctx, i18n.PolicyError, name, r.err, call.Tool)
+		}
+		out, _, err := r.program.Eval(vars)
+		if err != nil {
+			return i18n.T(ctx, i18n.PolicyError, name, err, call.Tool)
+		}
+		if matched, _ := out.Value().(bool); !matched {
+			continue
+		}
+		if r.Effect == "allow" {
+			return ""
+		}
+		if r.Message != "" {
+			return i18n.T(ctx, i18n.PolicyDeniedReason, name, call.Tool, r.Message)
+		}
+		return i18n.T(ctx, i18n.PolicyDenied, name, call.Tool)
+	}
+	return ""
+}
+
+// argPaths returns the resolved paths held by the path arguments in args,
+// including those of nested objects such as apply_edits' edits. A relative
+// name in files is taken from the path beside it, as the search tools do.
+func argPaths(args interface{}) []string {
+	paths := []string{}
+	var walk func(key, dir string, v interface{})
+	walk = func(key, dir string, v interface{}) {
+		switch v := v.(type) {
+		case string:
+			if pathKeys[key] && v != "" {
+				if key == "files" && dir != "" && !filepath.IsAbs(v) {
+					v = filepath.Join(dir, v)
+				}
+				paths = append(paths, filepath.ToSlash(config.ResolvePath(v)))
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(key, dir, item)
+			}
+		case map[string]interface{}:
+			dir, _ := v["path"].(string)
+			for k, item := range v {
+				walk(k, dir, item)
+			}
+		}
+	}
+	walk("", "", args)
+	return paths
+}
+
+// toolRegistry is implemented by the gomcp server; its handlers all take the
+// raw arguments once registered.
+type toolRegistry interface {
+	GetTools() map[string]*server.Tool
+}
+
+// Enforce wraps the handler of every tool registered on s so each call is
+// checked against the policies config value first. It is called once all
+// tools, proxied ones included, are registered.
+func Enforce(s server.Server, logger *slog.Logger) {
+	registry, ok := s.(toolRegistry)
+	if !ok {
+		logger.Error("Server does not list its tools; policies are not enforced")
+		return
+	}
+	username := ""
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	for name, tool := range registry.GetTools() {
+		handler, ok := tool.Handler.(func(*server.Context, interface{}) (interface{}, error))
+		if !ok {
+			logger.Error("Tool handler has an unexpected type; policies are not enforced for it", "tool", name)
+			continue
+		}
+		tool.Handler = enforce(name, username, handler)
+	}
+}
+
+// enforce returns handler with its calls checked against the policies.
+func enforce(name, username string, handler func(*server.Context, interface{}) (interface{}, error)) func(*server.Context, interface{}) (interface{}, error) {
+	return func(ctx *server.Context, args interface{}) (interface{}, error) {
+		cfg, err := config.GetCurrentConfig(ctx)
+		if err != nil || len(cfg.Policies) == 0 {
+			return handler(ctx, args)
+		}
+		argMap, _ := args.(map[string]interface{})
+		call := Call{
+			Tool:   name,
+			Args:   argMap,
+			Client: map[string]string{"user": username, "protocol": ctx.Version},
+			Time:   time.Now(),
+		}
+		if msg := Decide(ctx, cfg.Policies, call); msg != "" {
+			ctx.Logger.Info("Tool call denied by policy", "tool", name)
+			return msg, nil
+		}
+		return handler(ctx, args)
+	}
+}
@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestDecide(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	policies := []config.Policy{
+		{Name: "ops", When: "client.user == 'ops'", Effect: "allow"},
+		{Name: "no-etc", When: "paths.exists(p, p.startsWith('/etc/'))", Message: "system files are off limits"},
+		{Name: "weekday-shell", When: "tool == 'execute_command' && now.getDayOfWeek('UTC') in [0, 6]"},
+		{Name: "no-force-push", When: "tool == 'execute_command' && args.command.contains('push --force')"},
+	}
+	saturday := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	monday := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policies []config.Policy
+		call     Call
+		want     string // substring of the denial; "" when allowed
+	}{
+		{"no policies", nil, Call{Tool: "write_file"}, ""},
+		{"no match", policies, Call{Tool: "write_file", Args: map[string]interface{}{"path": "/tmp/a.txt"}, Time: monday}, ""},
+		{"denied path", policies, Call{Tool: "write_file", Args: map[string]interface{}{"path": "/etc/hosts"}, Time: monday}, `Policy "no-etc" denied write_file: system files are off limits`},
+		{"nested path", policies, Call{Tool: "apply_edits", Args: map[string]interface{}{"edits": []interface{}{map[string]interface{}{"file_path": "/etc/passwd"}}}, Time: monday}, `"no-etc"`},
+		{"cwd outside", []config.Policy{{Name: "workspace", When: "paths.exists(p, !p.startsWith('/work/'))"}}, Call{Tool: "execute_command", Args: map[string]interface{}{"command": "ls", "cwd": "/etc"}, Time: monday}, `Policy "workspace" denied execute_command.`},
+		{"cwd inside", []config.Policy{{Name: "workspace", When: "paths.exists(p, !p.startsWith('/work/'))"}}, Call{Tool: "execute_command", Args: map[string]interface{}{"command": "ls", "cwd": "/work/app"}, Time: monday}, ""},
+		{"searched file", policies, Call{Tool: "search_code", Args: map[string]interface{}{"pattern": "root", "files": []interface{}{"/tmp/a.txt", "/etc/passwd"}}, Time: monday}, `"no-etc"`},
+		{"searched relative file", policies, Call{Tool: "search_files", Args: map[string]interface{}{"path": "/etc", "pattern": "root", "files": []interface{}{"passwd"}}, Time: monday}, `"no-etc"`},
+		{"patch base", policies, Call{Tool: "apply_hunks", Args: map[string]interface{}{"patch": "", "base_dir": "/etc/ssh"}, Time: monday}, `"no-etc"`},
+		{"allowed first", policies, Call{Tool: "write_file", Args: map[string]interface{}{"path": "/etc/hosts"}, Client: map[string]string{"user": "ops"}, Time: monday}, ""},
+		{"weekend shell", policies, Call{Tool: "execute_command", Args: map[string]interface{}{"command": "ls"}, Time: saturday}, `Policy "weekday-shell" denied execute_command.`},
+		{"weekday shell", policies, Call{Tool: "execute_command", Args: map[string]interface{}{"command": "ls"}, Time: monday}, ""},
+		{"missing argument", policies, Call{Tool: "execute_command", Time: monday}, `"no-force-push" could not be evaluated`},
+		{"does not compile", []config.Policy{{When: "tool =="}}, Call{Tool: "read_file"}, `"policy 1" could not be evaluated`},
+		{"not a bool", []config.Policy{{Name: "str", When: "tool"}}, Call{Tool: "read_file"}, "not bool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Decide(ctx, tt.policies, tt.call)
+			if tt.want == "" && got != "" {
+				t.Errorf("Decide denied the call: %q", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("Decide = %q, want a denial mentioning %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgPaths(t *testing.T) {
+	dir := t.TempDir()
+	args := map[string]interface{}{
+		"source":  filepath.Join(dir, "a"),
+		"content": "path: not a path",
+		"paths":   []interface{}{filepath.Join(dir, "b")},
+		"edits":   []interface{}{map[string]interface{}{"file_path": filepath.Join(dir, "c")}},
+		"search":  map[string]interface{}{"path": dir, "files": []interface{}{"d"}},
+	}
+	got := argPaths(args)
+	resolved := filepath.ToSlash(config.ResolvePath(dir))
+	want := []string{resolved + "/a", resolved + "/b", resolved + "/c", resolved, resolved + "/d"}
+	if len(got) != len(want) {
+		t.Fatalf("argPaths = %q, want %q", got, want)
+	}
+	seen := map[string]bool{}
+	for _, p := range got {
+		seen[p] = true
+	}
+	for _, p := range want {
+		if !seen[p] {
+			t.Errorf("argPaths = %q, missing %q", got, p)
+		}
+	}
+	if !reflect.DeepEqual(argPaths(map[string]interface{}{}), []string{}) {
+		t.Error("argPaths of no arguments should be empty")
+	}
+}
+
+func TestCompilePolicy(t *testing.T) {
+	if config.CompilePolicy == nil {
+		t.Fatal("config.CompilePolicy is not set")
+	}
+	if err := config.CompilePolicy("tool == 'read_file'"); err != nil {
+		t.Errorf("CompilePolicy rejected a valid rule: %v", err)
+	}
+	if err := config.CompilePolicy("tool.size()"); err == nil {
+		t.Error("CompilePolicy accepted a rule that is not a bool")
+	}
+}
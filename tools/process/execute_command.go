@@ -5,6 +5,8 @@ import (
 	"os/exec"
 	"strings"
 
+	"gocreate/tools/i18n"
+
 	"github.com/localrivet/gomcp/server"
 )
 
@@ -32,7 +34,7 @@ func HandleExecuteCommandNew(ctx *server.Context, args ExecuteCommandArgs) (stri
 	for _, blocked := range blockedCommands {
 		if strings.Contains(commandLower, blocked) {
 			ctx.Logger.Info("Blocked command execution attempt", "command", args.Command)
-			return "Error: Execution of this command is blocked for security reasons.", nil
+			return i18n.T(ctx, i18n.CommandBlockedSecurity), nil
 		}
 	}
 
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package process
+
+import "os"
+
+// interrupt asks the process to stop, as Ctrl-C would.
+func interrupt(process *os.Process) error {
+	return process.Signal(os.Interrupt)
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// interrupt asks the process to close with taskkill, as Windows has no
+// interrupt to send it, and forces it when it cannot close, as console
+// programs cannot.
+func interrupt(process *os.Process) error {
+	pid := strconv.Itoa(process.Pid)
+	if err := exec.Command("taskkill", "/PID", pid).Run(); err == nil {
+		return nil
+	}
+	return exec.Command("taskkill", "/F", "/PID", pid).Run()
+}
@@ -0,0 +1,465 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gocreate/tools/config"
+)
+
+// protocolVersion is the MCP revision requested from upstream servers.
+const protocolVersion = "2025-03-26"
+
+// clientInfo identifies the proxy to upstream servers.
+var clientInfo = map[string]interface{}{"name": "gocreate", "version": "1.0.0"}
+
+// rpcError is a JSON-RPC error returned by an upstream server.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return fmt.Sprintf("%s (code %d)", e.Message, e.Code) }
+
+// rpcMessage is any JSON-RPC message: a request, notification or response.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// transport carries JSON-RPC messages to one upstream server.
+type transport interface {
+	// call sends a request and waits for the response with the same ID.
+	call(ctx context.Context, id int64, method string, params interface{}) (json.RawMessage, error)
+	// notify sends a notification, which has no response.
+	notify(ctx context.Context, method string, params interface{}) error
+	close() error
+}
+
+// client is an MCP client session with one upstream server.
+type client struct {
+	t      transport
+	nextID atomic.Int64
+}
+
+// upstreamTool is a tool as listed by an upstream server.
+type upstreamTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// initialize performs the MCP handshake.
+func (c *client) initialize(ctx context.Context) error {
+	params := map[string]interface{}{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      clientInfo,
+	}
+	if _, err := c.t.call(ctx, c.nextID.Add(1), "initialize", params); err != nil {
+		return err
+	}
+	return c.t.notify(ctx, "notifications/initialized", nil)
+}
+
+// listTools returns every tool of the upstream server, following pagination.
+func (c *client) listTools(ctx context.Context) ([]upstreamTool, error) {
+	var tools []upstreamTool
+	cursor := ""
+	for {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		raw, err := c.t.call(ctx, c.nextID.Add(1), "tools/list", params)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Tools      []upstreamTool `json:"tools"`
+			NextCursor string         `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("invalid tools/list result: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// callTool calls an upstream tool and returns its result object.
+func (c *client) callTool(ctx context.Context, name string, args interface{}) (map[string]interface{}, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	raw, err := c.t.call(ctx, c.nextID.Add(1), "tools/call", map[string]interface{}{"name": name, "arguments": args})
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid tools/call result: %w", err)
+	}
+	return result, nil
+}
+
+// dial starts or connects to the server up describes and performs the
+// handshake. A started server's standard error goes to stderr, which is
+// closed right away when no server is started.
+func dial(ctx context.Context, up config.UpstreamServer, stderr io.WriteCloser) (*client, error) {
+	if up.Command == "" || up.URL != "" {
+		stderr.Close()
+	}
+	var t transport
+	switch {
+	case up.Command != "" && up.URL != "":
+		return nil, fmt.Errorf("set either command or url, not both")
+	case up.Command != "":
+		st, err := startStdio(up.Command, up.Args, up.Env, stderr)
+		if err != nil {
+			return nil, err
+		}
+		t = st
+	case up.URL != "":
+		t = newHTTPTransport(up.URL, up.Headers)
+	default:
+		return nil, fmt.Errorf("neither command nor url is set")
+	}
+
+	c := &client{t: t}
+	if err := c.initialize(ctx); err != nil {
+		t.close()
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	return c, nil
+}
+
+// Session is an MCP client session with one server, for callers other than
+// the proxy, such as the gocreate/client package.
+type Session struct {
+	c *client
+}
+
+// Dial opens a session with the server up describes; its Tools and TimeoutMs
+// are not used. A started server's standard error goes to stderr, which is
+// closed once the server has exited.
+func Dial(ctx context.Context, up config.UpstreamServer, stderr io.WriteCloser) (*Session, error) {
+	c, err := dial(ctx, up, stderr)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{c: c}, nil
+}
+
+// CallTool calls the tool name with args, which are sent as their JSON
+// encoding, and returns the result object.
+func (s *Session) CallTool(ctx context.Context, name string, args interface{}) (map[string]interface{}, error) {
+	return s.c.callTool(ctx, name, args)
+}
+
+// Close ends the session, stopping a started server.
+func (s *Session) Close() error {
+	return s.c.t.close()
+}
+
+// stdioTransport talks to a server process over newline-delimited JSON on its
+// standard input and output.
+type stdioTransport struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr io.WriteCloser
+
+	writeMu sync.Mutex // Serializes writes to stdin
+	mu      sync.Mutex // Protects pending and readErr
+	pending map[string]chan rpcMessage
+	readErr error
+	done    chan struct{}
+}
+
+// startStdio launches command and starts reading its responses. The server's
+// standard error goes to stderr, which is closed once the server has exited;
+// it must not be ours, which the server would otherwise share.
+func startStdio(command string, args []string, env map[string]string, stderr io.WriteCloser) (*stdioTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		stderr.Close()
+		return nil, err
+	}
+
+	t := &stdioTransport{cmd: cmd, stdin: stdin, stderr: stderr, pending: make(map[string]chan rpcMessage), done: make(chan struct{})}
+	go t.readLoop(stdout)
+	return t, nil
+}
+
+// readLoop routes responses to their waiting callers until the server's output
+// closes. Requests from the server are answered with "method not found", as
+// the proxy offers no client capabilities.
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue // not JSON-RPC, such as a stray log line
+		}
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			t.write(rpcMessage{JSONRPC: "2.0", ID: msg.ID, Error: &rpcError{Code: -32601, Message: "method not found"}})
+		case msg.Method == "" && len(msg.ID) > 0:
+			t.mu.Lock()
+			ch := t.pending[string(msg.ID)]
+			delete(t.pending, string(msg.ID))
+			t.mu.Unlock()
+			if ch != nil {
+				ch <- msg
+			}
+		}
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	t.mu.Lock()
+	t.readErr = fmt.Errorf("upstream server exited: %w", err)
+	t.mu.Unlock()
+	close(t.done)
+}
+
+func (t *stdioTransport) write(msg rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *stdioTransport) call(ctx context.Context, id int64, method string, params interface{}) (json.RawMessage, error) {
+	key := fmt.Sprint(id)
+	ch := make(chan rpcMessage, 1)
+	t.mu.Lock()
+	if t.readErr != nil {
+		t.mu.Unlock()
+		return nil, t.readErr
+	}
+	t.pending[key] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(rpcMessage{JSONRPC: "2.0", ID: json.RawMessage(key), Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return msg.Result, nil
+	case <-t.done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return nil, t.readErr
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(_ context.Context, method string, params interface{}) error {
+	return t.write(rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// close ends the session by closing the server's input, which stdio servers
+// take as the signal to exit, and kills the process if it does not.
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	select {
+	case <-t.done:
+	case <-time.After(closeTimeout):
+		t.cmd.Process.Kill()
+	}
+	err := t.cmd.Wait()
+	t.stderr.Close()
+	return err
+}
+
+// httpTransport talks to a streamable HTTP MCP endpoint. Each message is a
+// POST; a response may come back as JSON or as a server-sent event stream.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex // Protects sessionID
+	sessionID string
+}
+
+func newHTTPTransport(url string, headers map[string]string) *httpTransport {
+	return &httpTransport{url: url, headers: headers, client: &http.Client{}}
+}
+
+// post sends msg and returns the successful response, whose body the caller
+// must close.
+func (t *httpTransport) post(ctx context.Context, msg rpcMessage) (*http.Response, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("upstream returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) call(ctx context.Context, id int64, method string, params interface{}) (json.RawMessage, error) {
+	key := fmt.Sprint(id)
+	resp, err := t.post(ctx, rpcMessage{JSONRPC: "2.0", ID: json.RawMessage(key), Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var msg *rpcMessage
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		msg, err = readEventResponse(resp.Body, key)
+	} else {
+		msg = &rpcMessage{}
+		err = json.NewDecoder(resp.Body).Decode(msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", method, err)
+	}
+	if msg.Error != nil {
+		return nil, msg.Error
+	}
+	return msg.Result, nil
+}
+
+// readEventResponse reads a server-sent event stream until the response with
+// the given ID arrives. Notifications sent on the stream are skipped.
+func readEventResponse(r io.Reader, id string) (*rpcMessage, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+		// A blank line ends the event
+		var msg rpcMessage
+		if err := json.Unmarshal([]byte(data.String()), &msg); err == nil && msg.Method == "" && string(msg.ID) == id {
+			return &msg, nil
+		}
+		data.Reset()
+	}
+	if data.Len() > 0 {
+		var msg rpcMessage
+		if err := json.Unmarshal([]byte(data.String()), &msg); err == nil && string(msg.ID) == id {
+			return &msg, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("event stream ended without a response")
+}
+
+func (t *httpTransport) notify(ctx context.Context, method string, params interface{}) error {
+	resp, err := t.post(ctx, rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// close ends the HTTP session, if the server issued one.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	id := t.sessionID
+	t.mu.Unlock()
+	if id == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Mcp-Session-Id", id)
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"gocreate/tools/config"
+	"gocreate/tools/i18n"
+	"gocreate/tools/stdio"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// separator joins a namespace and an upstream tool name, as in db.query.
+const separator = "."
+
+// defaultCallTimeout bounds a proxied call when the upstream sets no timeoutMs.
+const defaultCallTimeout = 60 * time.Second
+
+// connectTimeout bounds the handshake and tool listing at startup.
+var connectTimeout = 30 * time.Second
+
+// closeTimeout is how long a stdio upstream gets to exit after its input is
+// closed before it is killed.
+var closeTimeout = 2 * time.Second
+
+// upstream is a connected upstream server.
+type upstream struct {
+	namespace string
+	timeout   time.Duration
+	client    *client
+}
+
+// toolRegistry is implemented by gomcp servers. It is used to check for name
+// clashes and to publish each upstream tool's own input schema, as handlers
+// taking interface{} are registered with an empty one.
+type toolRegistry interface {
+	GetTools() map[string]*server.Tool
+}
+
+// Proxy holds the upstream sessions opened by Start.
+type Proxy struct {
+	upstreams []*upstream
+}
+
+// Start connects to every configured upstream server and registers its tools
+// on s as namespace.tool. Calls are forwarded unchanged and their results
+// passed back as the upstream returned them. An upstream that cannot be
+// reached is logged and skipped so it cannot keep the local tools from
+// starting.
+func Start(s server.Server, logger *slog.Logger, servers map[string]config.UpstreamServer) *Proxy {
+	p := &Proxy{}
+	namespaces := make([]string, 0, len(servers))
+	for ns := range servers {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	registry, _ := s.(toolRegistry)
+	for _, ns := range namespaces {
+		u, tools, err := connect(logger, ns, servers[ns])
+		if err != nil {
+			logger.Error("Could not connect to upstream MCP server", "namespace", ns, "error", err)
+			continue
+		}
+		p.upstreams = append(p.upstreams, u)
+
+		registered := 0
+		for _, tool := range exposed(tools, servers[ns].Tools) {
+			name := ns + separator + tool.Name
+			if registry != nil && registry.GetTools()[name] != nil {
+				logger.Error("Upstream tool clashes with an existing tool and was not registered", "tool", name)
+				continue
+			}
+			s.Tool(name, fmt.Sprintf("%s (proxied from the %s upstream server)", tool.Description, ns), u.handler(tool.Name))
+			if registry != nil && tool.InputSchema != nil {
+				if t := registry.GetTools()[name]; t != nil {
+					t.Schema = tool.InputSchema
+				}
+			}
+			registered++
+		}
+		logger.Info("Upstream MCP server connected", "namespace", ns, "tools", registered)
+	}
+	return p
+}
+
+// Close ends every upstream session.
+func (p *Proxy) Close() {
+	for _, u := range p.upstreams {
+		u.client.t.close()
+	}
+}
+
+// connect opens a session with up and lists its tools.
+func connect(logger *slog.Logger, ns string, up config.UpstreamServer) (*upstream, []upstreamTool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	c, err := dial(ctx, up, stdio.LogWriter(logger, "Upstream server stderr", "namespace", ns))
+	if err != nil {
+		return nil, nil, err
+	}
+	tools, err := c.listTools(ctx)
+	if err != nil {
+		c.t.close()
+		return nil, nil, fmt.Errorf("tools/list: %w", err)
+	}
+
+	timeout := defaultCallTimeout
+	if up.TimeoutMs != nil && *up.TimeoutMs > 0 {
+		timeout = time.Duration(*up.TimeoutMs) * time.Millisecond
+	}
+	return &upstream{namespace: ns, timeout: timeout, client: c}, tools, nil
+}
+
+// exposed returns the tools named in allow, or all of them when allow is empty.
+func exposed(tools []upstreamTool, allow []string) []upstreamTool {
+	if len(allow) == 0 {
+		return tools
+	}
+	allowed := make(map[string]bool, len(allow))
+	for _, name := range allow {
+		allowed[strings.TrimSpace(name)] = true
+	}
+	var kept []upstreamTool
+	for _, tool := range tools {
+		if allowed[tool.Name] {
+			kept = append(kept, tool)
+		}
+	}
+	return kept
+}
+
+// handler returns the tool handler that forwards calls to the upstream tool
+// name. Every call is logged with its outcome so proxied tools are audited
+// alongside local ones.
+func (u *upstream) handler(name string) func(ctx *server.Context, args interface{}) (interface{}, error) {
+	full := u.namespace + separator + name
+	return func(ctx *server.Context, args interface{}) (interface{}, error) {
+		ctx.Logger.Info("Handling proxied tool call", "tool", full)
+
+		callCtx, cancel := context.WithTimeout(context.Background(), u.timeout)
+		defer cancel()
+		start := time.Now()
+		result, err := u.client.callTool(callCtx, name, args)
+		if err != nil {
+			ctx.Logger.Info("Proxied tool call failed", "tool", full, "duration", time.Since(start), "error", err)
+			return i18n.T(ctx, i18n.ProxyCallFailed, full, err), nil
+		}
+		isError, _ := result["isError"].(bool)
+		ctx.Logger.Info("Proxied tool call finished", "tool", full, "duration", time.Since(start), "isError", isError)
+		return result, nil
+	}
+}
@@ -0,0 +1,209 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// fakeReply answers one request the way a small upstream server with an echo
+// and a fail tool would. Notifications get no reply.
+func fakeReply(msg rpcMessage) *rpcMessage {
+	if len(msg.ID) == 0 {
+		return nil
+	}
+	reply := &rpcMessage{JSONRPC: "2.0", ID: msg.ID}
+	params, _ := msg.Params.(map[string]interface{})
+	var result interface{}
+	switch msg.Method {
+	case "initialize":
+		result = map[string]interface{}{"protocolVersion": protocolVersion, "capabilities": map[string]interface{}{"tools": map[string]interface{}{}}, "serverInfo": map[string]interface{}{"name": "fake"}}
+	case "tools/list":
+		// Two pages, to exercise the cursor
+		if params["cursor"] == nil {
+			result = map[string]interface{}{
+				"tools":      []interface{}{map[string]interface{}{"name": "echo", "description": "Echo the arguments", "inputSchema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"text": map[string]interface{}{"type": "string"}}}}},
+				"nextCursor": "page2",
+			}
+		} else {
+			result = map[string]interface{}{"tools": []interface{}{map[string]interface{}{"name": "fail", "description": "Always fails"}}}
+		}
+	case "tools/call":
+		if params["name"] == "fail" {
+			reply.Error = &rpcError{Code: -32000, Message: "boom"}
+			return reply
+		}
+		args, _ := json.Marshal(params["arguments"])
+		result = map[string]interface{}{"content": []interface{}{map[string]interface{}{"type": "text", "text": string(args)}}}
+	default:
+		reply.Error = &rpcError{Code: -32601, Message: "method not found"}
+		return reply
+	}
+	reply.Result, _ = json.Marshal(result)
+	return reply
+}
+
+// TestHelperUpstream is not a real test: it runs the fake server over stdio
+// when the test binary is started as an upstream by the stdio tests.
+func TestHelperUpstream(t *testing.T) {
+	if os.Getenv("GOCREATE_FAKE_UPSTREAM") != "1" {
+		return
+	}
+	out := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if json.Unmarshal(scanner.Bytes(), &msg) != nil {
+			continue
+		}
+		if msg.Method == "tools/call" {
+			// Traffic the proxy must skip over before the response
+			fmt.Println("not json")
+			out.Encode(rpcMessage{JSONRPC: "2.0", Method: "notifications/message", Params: map[string]string{"data": "working"}})
+			out.Encode(rpcMessage{JSONRPC: "2.0", ID: json.RawMessage(`"srv-1"`), Method: "roots/list"})
+		}
+		if reply := fakeReply(msg); reply != nil {
+			out.Encode(reply)
+		}
+	}
+	os.Exit(0)
+}
+
+func newTestServer() server.Server {
+	return server.NewServer("test", server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+}
+
+// callProxied invokes a registered tool the way the server would.
+func callProxied(t *testing.T, s server.Server, name string, args map[string]interface{}) interface{} {
+	t.Helper()
+	tool := s.(toolRegistry).GetTools()[name]
+	if tool == nil {
+		t.Fatalf("Tool %s is not registered", name)
+	}
+	handler := tool.Handler.(func(*server.Context, interface{}) (interface{}, error))
+	ctx := &server.Context{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	result, err := handler(ctx, args)
+	if err != nil {
+		t.Fatalf("%s returned an error: %v", name, err)
+	}
+	return result
+}
+
+func checkProxy(t *testing.T, s server.Server) {
+	t.Helper()
+	tools := s.(toolRegistry).GetTools()
+	if tools["up.echo"] == nil || tools["up.fail"] == nil {
+		t.Fatalf("Expected up.echo and up.fail to be registered, got %v", tools)
+	}
+	schema, _ := tools["up.echo"].Schema.(map[string]interface{})
+	if props, _ := schema["properties"].(map[string]interface{}); props["text"] == nil {
+		t.Errorf("up.echo schema = %v, want the upstream input schema", tools["up.echo"].Schema)
+	}
+
+	result := callProxied(t, s, "up.echo", map[string]interface{}{"text": "hi"})
+	out, _ := json.Marshal(result)
+	if !strings.Contains(string(out), `{\"text\":\"hi\"}`) {
+		t.Errorf("up.echo result = %s", out)
+	}
+
+	result = callProxied(t, s, "up.fail", nil)
+	if msg, ok := result.(string); !ok || !strings.Contains(msg, "up.fail") || !strings.Contains(msg, "boom") {
+		t.Errorf("up.fail result = %v", result)
+	}
+}
+
+func TestStartStdioUpstream(t *testing.T) {
+	s := newTestServer()
+	p := Start(s, slog.New(slog.NewTextHandler(io.Discard, nil)), map[string]config.UpstreamServer{
+		"up": {
+			Command: os.Args[0],
+			Args:    []string{"-test.run=^TestHelperUpstream$"},
+			Env:     map[string]string{"GOCREATE_FAKE_UPSTREAM": "1"},
+		},
+	})
+	defer p.Close()
+	checkProxy(t, s)
+}
+
+func TestStartHTTPUpstream(t *testing.T) {
+	var sessionMissing atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		var msg rpcMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", "session-1")
+		} else if r.Header.Get("Mcp-Session-Id") != "session-1" || r.Header.Get("Authorization") != "Bearer token" {
+			sessionMissing.Store(true)
+		}
+		reply := fakeReply(msg)
+		if reply == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		data, _ := json.Marshal(reply)
+		if msg.Method == "tools/call" {
+			// Answer as an event stream with a notification first
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer ts.Close()
+
+	s := newTestServer()
+	p := Start(s, slog.New(slog.NewTextHandler(io.Discard, nil)), map[string]config.UpstreamServer{
+		"up": {URL: ts.URL, Headers: map[string]string{"Authorization": "Bearer token"}},
+	})
+	defer p.Close()
+	checkProxy(t, s)
+	if sessionMissing.Load() {
+		t.Error("Requests after initialize did not carry the session ID and configured headers")
+	}
+}
+
+func TestStartSkipsUnreachableAndFiltersTools(t *testing.T) {
+	s := newTestServer()
+	s.Tool("up.echo", "A local tool that must not be replaced", func(ctx *server.Context, args struct{}) (string, error) { return "local", nil })
+	p := Start(s, slog.New(slog.NewTextHandler(io.Discard, nil)), map[string]config.UpstreamServer{
+		"down": {Command: "gocreate-no-such-upstream"},
+		"up": {
+			Command: os.Args[0],
+			Args:    []string{"-test.run=^TestHelperUpstream$"},
+			Env:     map[string]string{"GOCREATE_FAKE_UPSTREAM": "1"},
+			Tools:   []string{"echo"},
+		},
+	})
+	defer p.Close()
+
+	tools := s.(toolRegistry).GetTools()
+	if tools["up.fail"] != nil {
+		t.Error("up.fail was registered although it is not in the tools list")
+	}
+	if tools["up.echo"].Description != "A local tool that must not be replaced" {
+		t.Errorf("up.echo = %q, want the local tool to be kept", tools["up.echo"].Description)
+	}
+	if len(p.upstreams) != 1 {
+		t.Errorf("Expected only the reachable upstream to be kept, got %d", len(p.upstreams))
+	}
+}
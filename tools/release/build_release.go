@@ -0,0 +1,251 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// BuildReleaseArgs defines the arguments for the build_release tool.
+type BuildReleaseArgs struct {
+	Path      string   `json:"path" description:"The root directory of the Go project to build." required:"true"`
+	Version   *string  `json:"version,omitempty" description:"Optional version stamped into the binary via -X main.version."`
+	OutputDir *string  `json:"output_dir,omitempty" description:"Optional output directory relative to path, which it must stay within. Defaults to 'dist'."`
+	Name      *string  `json:"name,omitempty" description:"Optional binary name. Defaults to the project directory name."`
+	Targets   []string `json:"targets,omitempty" description:"Optional list of GOOS/GOARCH targets (e.g., 'linux/amd64'). Defaults to the configured releaseTargets."`
+	TimeoutMs *int     `json:"timeout_ms,omitempty" description:"Optional timeout in milliseconds for the whole build."`
+}
+
+// defaultReleaseTargets mirrors the platforms built by `make build-all`.
+var defaultReleaseTargets = []string{
+	"linux/amd64", "linux/arm64",
+	"darwin/amd64", "darwin/arm64",
+	"windows/amd64", "windows/arm64",
+}
+
+// releaseVersionPattern restricts versions to characters that cannot be read
+// as extra linker flags once the value is placed inside -ldflags.
+var releaseVersionPattern = regexp.MustCompile(`^v?[0-9A-Za-z.+-]+$`)
+
+// targetPartPattern matches a single GOOS or GOARCH value.
+var targetPartPattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// validateReleaseName rejects binary names that would be written outside the
+// per-target output directory.
+func validateReleaseName(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid name %q: must not contain path separators", name)
+	}
+	return nil
+}
+
+// releaseOutputDir returns the directory build_release writes to for the
+// output_dir argument dir, rejecting one that is absolute or would leave the
+// project, directly or through a symbolic link.
+func releaseOutputDir(projectDir, dir string) (string, error) {
+	if dir == "" {
+		dir = "dist"
+	}
+	if filepath.IsAbs(dir) || filepath.VolumeName(dir) != "" || strings.HasPrefix(dir, `\`) || strings.HasPrefix(dir, "/") {
+		return "", fmt.Errorf("invalid output_dir %q: must be relative to path", dir)
+	}
+	outputDir := filepath.Join(projectDir, dir)
+	rel, err := filepath.Rel(config.ResolvePath(projectDir), config.ResolvePath(outputDir))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid output_dir %q: must be within path", dir)
+	}
+	return outputDir, nil
+}
+
+// validateReleaseVersion rejects versions that could inject linker flags.
+func validateReleaseVersion(version string) error {
+	if version != "" && !releaseVersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid version %q: only letters, digits, '.', '+' and '-' are allowed", version)
+	}
+	return nil
+}
+
+// ReleaseArtifact describes a single binary produced by build_release.
+type ReleaseArtifact struct {
+	GOOS   string `json:"goos"`
+	GOARCH string `json:"goarch"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ReleaseManifest is written to the output directory and returned by build_release.
+type ReleaseManifest struct {
+	Name      string            `json:"name"`
+	Version   string            `json:"version,omitempty"`
+	BuiltAt   string            `json:"built_at"`
+	Artifacts []ReleaseArtifact `json:"artifacts"`
+	Failures  map[string]string `json:"failures,omitempty"`
+}
+
+// sha256File returns the hex encoded SHA-256 checksum and size of a file.
+func sha256File(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// HandleBuildRelease implements the build_release tool.
+func HandleBuildRelease(ctx *server.Context, args BuildReleaseArgs) (string, error) {
+	ctx.Logger.Info("Handling build_release tool call")
+
+	projectDir, err := filepath.Abs(args.Path)
+	if err != nil {
+		return "Error resolving project path", err
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "go.mod")); err != nil {
+		return "No go.mod found in " + projectDir, nil
+	}
+
+	targets := args.Targets
+	if len(targets) == 0 {
+		targets = defaultReleaseTargets
+		if cfg, cfgErr := config.GetCurrentConfig(ctx); cfgErr == nil && len(cfg.ReleaseTargets) > 0 {
+			targets = cfg.ReleaseTargets
+		}
+	}
+
+	name := filepath.Base(projectDir)
+	if args.Name != nil && *args.Name != "" {
+		name = *args.Name
+	}
+	version := ""
+	if args.Version != nil {
+		version = *args.Version
+	}
+	if err := validateReleaseName(name); err != nil {
+		return err.Error(), nil
+	}
+	if err := validateReleaseVersion(version); err != nil {
+		return err.Error(), nil
+	}
+	outputDir := ""
+	if args.OutputDir != nil {
+		outputDir = *args.OutputDir
+	}
+	if outputDir, err = releaseOutputDir(projectDir, outputDir); err != nil {
+		return err.Error(), nil
+	}
+
+	buildCtx := context.Background()
+	if args.TimeoutMs != nil && *args.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		buildCtx, cancel = context.WithTimeout(buildCtx, time.Duration(*args.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	ldflags := "-s -w"
+	if version != "" {
+		ldflags += " -X main.version=" + version
+	}
+
+	manifest := ReleaseManifest{
+		Name:      name,
+		Version:   version,
+		BuiltAt:   time.Now().Format(time.RFC3339),
+		Artifacts: make([]ReleaseArtifact, 0, len(targets)),
+		Failures:  make(map[string]string),
+	}
+
+	for _, target := range targets {
+		goos, goarch, ok := strings.Cut(target, "/")
+		if !ok || !targetPartPattern.MatchString(goos) || !targetPartPattern.MatchString(goarch) {
+			manifest.Failures[target] = "invalid target, expected GOOS/GOARCH"
+			continue
+		}
+
+		binName := name
+		if goos == "windows" {
+			binName += ".exe"
+		}
+		outPath := filepath.Join(outputDir, goos+"-"+goarch, binName)
+
+		cmd := exec.CommandContext(buildCtx, "go", "build", "-trimpath", "-ldflags", ldflags, "-o", outPath, ".")
+		cmd.Dir = projectDir
+		cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		ctx.Logger.Info("Building release artifact", "target", target, "output", outPath)
+		if err := cmd.Run(); err != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = err.Error()
+			}
+			manifest.Failures[target] = msg
+			continue
+		}
+
+		sum, size, err := sha256File(outPath)
+		if err != nil {
+			manifest.Failures[target] = "checksum failed: " + err.Error()
+			continue
+		}
+		manifest.Artifacts = append(manifest.Artifacts, ReleaseArtifact{
+			GOOS:   goos,
+			GOARCH: goarch,
+			Path:   outPath,
+			Size:   size,
+			SHA256: sum,
+		})
+	}
+
+	if len(manifest.Artifacts) > 0 {
+		// checksums.txt uses the sha256sum format with paths relative to the output directory
+		var checksums strings.Builder
+		for _, a := range manifest.Artifacts {
+			rel, relErr := filepath.Rel(outputDir, a.Path)
+			if relErr != nil {
+				rel = a.Path
+			}
+			checksums.WriteString(fmt.Sprintf("%s  %s\n", a.SHA256, filepath.ToSlash(rel)))
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, "checksums.txt"), []byte(checksums.String()), 0644); err != nil {
+			ctx.Logger.Info("Error writing checksums file", "error", err)
+			return "Error writing checksums file", err
+		}
+	}
+
+	manifestJson, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling release manifest", "error", err)
+		return "Error generating release manifest", err
+	}
+	if len(manifest.Artifacts) > 0 {
+		if err := os.WriteFile(filepath.Join(outputDir, "manifest.json"), manifestJson, 0644); err != nil {
+			ctx.Logger.Info("Error writing release manifest", "error", err)
+			return "Error writing release manifest", err
+		}
+	}
+
+	ctx.Logger.Info("Release build finished", "artifacts", len(manifest.Artifacts), "failures", len(manifest.Failures))
+	return string(manifestJson), nil
+}
@@ -0,0 +1,75 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateReleaseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{"", false},
+		{"1.2.3", false},
+		{"v1.2.3-rc.1+build.5", false},
+		{"1 -linkmode=external", true},
+		{"1.0.0 -extld=/tmp/x", true},
+		{"1.0'", true},
+		{"1.0\n", true},
+	}
+	for _, tt := range tests {
+		if err := validateReleaseVersion(tt.version); (err != nil) != tt.wantErr {
+			t.Errorf("validateReleaseVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateReleaseName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"gocreate", false},
+		{"my-tool_2", false},
+		{"../../x", true},
+		{"dir/x", true},
+		{`dir\x`, true},
+		{"..", true},
+	}
+	for _, tt := range tests {
+		if err := validateReleaseName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("validateReleaseName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReleaseOutputDir(t *testing.T) {
+	projectDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(projectDir, "link")); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+	tests := []struct {
+		dir     string
+		want    string
+		wantErr bool
+	}{
+		{"", filepath.Join(projectDir, "dist"), false},
+		{"build/out", filepath.Join(projectDir, "build", "out"), false},
+		{"build/../out", filepath.Join(projectDir, "out"), false},
+		{"..", "", true},
+		{"../../tmp", "", true},
+		{"build/../../x", "", true},
+		{"/tmp/dist", "", true},
+		{`\\server\share`, "", true},
+		{"link/dist", "", true},
+	}
+	for _, tt := range tests {
+		got, err := releaseOutputDir(projectDir, tt.dir)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("releaseOutputDir(%q) = %q, %v; want %q, error %v", tt.dir, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
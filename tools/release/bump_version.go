@@ -0,0 +1,262 @@
+package release
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gocreate/tools/config"
+	"gocreate/tools/journal"
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// BumpVersionArgs defines the arguments for the bump_version tool.
+type BumpVersionArgs struct {
+	Path   string `json:"path" description:"The project root directory containing the manifest files." required:"true"`
+	Part   string `json:"part" description:"The part of the version to bump: major, minor or patch." required:"true"`
+	DryRun *bool  `json:"dry_run,omitempty" description:"If true, report the changes without writing any files."`
+	Plain  *bool  `json:"plain,omitempty" description:"If true, describe changes in plain sentences instead of a diff. Defaults to the plainOutput config value."`
+}
+
+// defaultVersionVariable is the Makefile variable used for -X main.version ldflags.
+const defaultVersionVariable = "VERSION"
+
+// semverPattern matches a semantic version with an optional leading "v".
+const semverPattern = `v?(\d+)\.(\d+)\.(\d+)`
+
+// versionSource describes a manifest file and the line pattern that declares its version.
+// The pattern must contain exactly one semver match on the declaring line.
+type versionSource struct {
+	File    string
+	Pattern *regexp.Regexp
+	// Tables, if set, restricts matches to lines inside one of these TOML tables.
+	Tables []string
+	// TopLevel restricts matches to keys of the outermost JSON object.
+	TopLevel bool
+}
+
+// tomlTablePattern matches a TOML table header such as [project] or [tool.poetry].
+var tomlTablePattern = regexp.MustCompile(`^\s*\[([^\[\]]+)\]\s*(#.*)?$`)
+
+// versionLocation is a single version declaration found in a manifest file.
+type versionLocation struct {
+	File    string
+	Line    int
+	Text    string
+	Version string
+}
+
+// versionSources returns the manifest files inspected by bump_version.
+func versionSources(makeVariable string) []versionSource {
+	return []versionSource{
+		{File: "go.mod", Pattern: regexp.MustCompile(`^//\s*[Vv]ersion:?\s*` + semverPattern + `\s*$`)},
+		{File: "package.json", Pattern: regexp.MustCompile(`^\s*"version"\s*:\s*"` + semverPattern + `"`), TopLevel: true},
+		{File: "pyproject.toml", Pattern: regexp.MustCompile(`^\s*version\s*=\s*"` + semverPattern + `"`), Tables: []string{"project", "tool.poetry"}},
+		{File: "VERSION", Pattern: regexp.MustCompile(`^\s*` + semverPattern + `\s*$`)},
+		{File: "Makefile", Pattern: regexp.MustCompile(`^\s*` + regexp.QuoteMeta(makeVariable) + `\s*[:?+]?=\s*` + semverPattern + `\s*$`)},
+	}
+}
+
+// findVersions scans the known manifest files under root for version declarations.
+// Only the first declaration in each file is considered.
+func findVersions(root string, sources []versionSource) ([]versionLocation, error) {
+	var found []versionLocation
+	semver := regexp.MustCompile(semverPattern)
+
+	for _, src := range sources {
+		path := filepath.Join(root, src.File)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+
+		table := ""
+		depth := 0
+		for i, line := range strings.Split(string(content), "\n") {
+			lineDepth := depth
+			depth = jsonDepthAfter(line, depth)
+			if len(src.Tables) > 0 {
+				if m := tomlTablePattern.FindStringSubmatch(line); m != nil {
+					table = strings.TrimSpace(m[1])
+					continue
+				}
+				if !containsString(src.Tables, table) {
+					continue
+				}
+			}
+			if src.TopLevel && lineDepth != 1 {
+				continue
+			}
+			if !src.Pattern.MatchString(line) {
+				continue
+			}
+			found = append(found, versionLocation{
+				File:    path,
+				Line:    i + 1,
+				Text:    line,
+				Version: strings.TrimPrefix(semver.FindString(line), "v"),
+			})
+			break
+		}
+	}
+	return found, nil
+}
+
+// jsonDepthAfter returns the object/array nesting depth after line, starting
+// from depth. Brackets inside string literals are ignored.
+func jsonDepthAfter(line string, depth int) int {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return depth
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bumpSemver increments the requested part of a MAJOR.MINOR.PATCH version.
+func bumpSemver(version, part string) (string, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid version %q", version)
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q: %w", version, err)
+		}
+		nums[i] = n
+	}
+
+	switch part {
+	case "major":
+		nums[0], nums[1], nums[2] = nums[0]+1, 0, 0
+	case "minor":
+		nums[1], nums[2] = nums[1]+1, 0
+	case "patch":
+		nums[2]++
+	default:
+		return "", fmt.Errorf("unknown version part %q (expected major, minor or patch)", part)
+	}
+	return fmt.Sprintf("%d.%d.%d", nums[0], nums[1], nums[2]), nil
+}
+
+// HandleBumpVersion implements the bump_version tool.
+func HandleBumpVersion(ctx *server.Context, args BumpVersionArgs) (string, error) {
+	ctx.Logger.Info("Handling bump_version tool call")
+
+	part := strings.ToLower(strings.TrimSpace(args.Part))
+	if part != "major" && part != "minor" && part != "patch" {
+		return "part must be one of: major, minor, patch", nil
+	}
+
+	makeVariable := defaultVersionVariable
+	if cfg, err := config.GetCurrentConfig(ctx); err == nil && cfg.VersionVariable != nil && *cfg.VersionVariable != "" {
+		makeVariable = *cfg.VersionVariable
+	}
+
+	locations, err := findVersions(args.Path, versionSources(makeVariable))
+	if err != nil {
+		ctx.Logger.Info("Error scanning for version declarations", "path", args.Path, "error", err)
+		return "Error scanning for version declarations", err
+	}
+	if len(locations) == 0 {
+		return "No version declarations found in " + args.Path, nil
+	}
+
+	// All declarations must agree before anything is bumped
+	current := locations[0].Version
+	for _, loc := range locations[1:] {
+		if loc.Version != current {
+			var sb strings.Builder
+			sb.WriteString("Version declarations are inconsistent; refusing to bump:\n")
+			for _, l := range locations {
+				sb.WriteString(fmt.Sprintf("  %s:%d: %s\n", l.File, l.Line, l.Version))
+			}
+			return strings.TrimSuffix(sb.String(), "\n"), nil
+		}
+	}
+
+	next, err := bumpSemver(current, part)
+	if err != nil {
+		return err.Error(), nil
+	}
+
+	dryRun := args.DryRun != nil && *args.DryRun
+	plain := render.Plain(ctx, args.Plain)
+	semver := regexp.MustCompile(semverPattern)
+
+	var diff strings.Builder
+	for _, loc := range locations {
+		newText := semver.ReplaceAllStringFunc(loc.Text, func(m string) string {
+			if strings.HasPrefix(m, "v") {
+				return "v" + next
+			}
+			return next
+		})
+
+		if plain {
+			diff.WriteString(fmt.Sprintf("File %s, line %d.\n%s\n%s\n", loc.File, loc.Line, render.DiffLine(true, '-', loc.Text), render.DiffLine(true, '+', newText)))
+		} else {
+			diff.WriteString(fmt.Sprintf("--- %s\n+++ %s\n@@ -%d +%d @@\n-%s\n+%s\n", loc.File, loc.File, loc.Line, loc.Line, loc.Text, newText))
+		}
+
+		if dryRun {
+			continue
+		}
+
+		content, err := os.ReadFile(loc.File)
+		if err != nil {
+			ctx.Logger.Info("Error reading manifest for bump", "file", loc.File, "error", err)
+			return "Error reading " + loc.File, err
+		}
+		lines := strings.Split(string(content), "\n")
+		lines[loc.Line-1] = newText
+
+		fileMode := os.FileMode(0644)
+		if info, statErr := os.Stat(loc.File); statErr == nil {
+			fileMode = info.Mode()
+		}
+		updated := strings.Join(lines, "\n")
+		pending := journal.Capture(ctx.Logger, loc.File, "bump_version")
+		if err := os.WriteFile(loc.File, []byte(updated), fileMode); err != nil {
+			ctx.Logger.Info("Error writing manifest for bump", "file", loc.File, "error", err)
+			return "Error writing " + loc.File, err
+		}
+		pending.Commit([]byte(updated))
+	}
+
+	header := fmt.Sprintf("Bumped version %s -> %s in %d file(s):\n", current, next, len(locations))
+	if dryRun {
+		header = fmt.Sprintf("Dry run: would bump version %s -> %s in %d file(s):\n", current, next, len(locations))
+	}
+	return header + strings.TrimSuffix(diff.String(), "\n"), nil
+}
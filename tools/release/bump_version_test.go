@@ -0,0 +1,166 @@
+package release
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func writeManifests(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestBumpSemver(t *testing.T) {
+	tests := []struct {
+		version string
+		part    string
+		want    string
+		wantErr bool
+	}{
+		{"1.2.3", "major", "2.0.0", false},
+		{"1.2.3", "minor", "1.3.0", false},
+		{"1.2.3", "patch", "1.2.4", false},
+		{"0.9.9", "patch", "0.9.10", false},
+		{"1.2", "patch", "", true},
+		{"1.x.3", "patch", "", true},
+		{"1.2.3", "build", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := bumpSemver(tt.version, tt.part)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("bumpSemver(%q, %q) error = %v, wantErr %v", tt.version, tt.part, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("bumpSemver(%q, %q) = %q, want %q", tt.version, tt.part, got, tt.want)
+		}
+	}
+}
+
+func TestFindVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		wantFile string
+		wantLine int
+		want     string
+	}{
+		{
+			name:     "package.json nested version before top-level key",
+			files:    map[string]string{"package.json": "{\n  \"engines\": {\n    \"version\": \"9.9.9\"\n  },\n  \"version\": \"1.2.3\"\n}\n"},
+			wantFile: "package.json",
+			wantLine: 5,
+			want:     "1.2.3",
+		},
+		{
+			name:     "package.json braces inside strings",
+			files:    map[string]string{"package.json": "{\n  \"description\": \"uses { and [ freely\",\n  \"version\": \"0.1.0\"\n}\n"},
+			wantFile: "package.json",
+			wantLine: 3,
+			want:     "0.1.0",
+		},
+		{
+			name:     "pyproject tool table before project table",
+			files:    map[string]string{"pyproject.toml": "[tool.bumpversion]\nversion = \"5.0.0\"\n\n[project]\nname = \"x\"\nversion = \"1.0.0\"\n"},
+			wantFile: "pyproject.toml",
+			wantLine: 6,
+			want:     "1.0.0",
+		},
+		{
+			name:     "pyproject poetry table",
+			files:    map[string]string{"pyproject.toml": "[tool.poetry]\nversion = \"2.1.0\"\n"},
+			wantFile: "pyproject.toml",
+			wantLine: 2,
+			want:     "2.1.0",
+		},
+		{
+			name:     "Makefile variable",
+			files:    map[string]string{"Makefile": "BIN := app\nVERSION ?= v3.4.5\n"},
+			wantFile: "Makefile",
+			wantLine: 2,
+			want:     "3.4.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeManifests(t, tt.files)
+			locations, err := findVersions(dir, versionSources(defaultVersionVariable))
+			if err != nil {
+				t.Fatalf("findVersions failed: %v", err)
+			}
+			if len(locations) != 1 {
+				t.Fatalf("Expected 1 location, got %d: %+v", len(locations), locations)
+			}
+			loc := locations[0]
+			if filepath.Base(loc.File) != tt.wantFile || loc.Line != tt.wantLine || loc.Version != tt.want {
+				t.Errorf("Got %s:%d %s, want %s:%d %s", filepath.Base(loc.File), loc.Line, loc.Version, tt.wantFile, tt.wantLine, tt.want)
+			}
+		})
+	}
+
+	t.Run("pyproject without project table", func(t *testing.T) {
+		dir := writeManifests(t, map[string]string{"pyproject.toml": "[tool.black]\nversion = \"1.0.0\"\n"})
+		locations, err := findVersions(dir, versionSources(defaultVersionVariable))
+		if err != nil {
+			t.Fatalf("findVersions failed: %v", err)
+		}
+		if len(locations) != 0 {
+			t.Errorf("Expected no locations, got %+v", locations)
+		}
+	})
+}
+
+func TestHandleBumpVersionInconsistent(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"VERSION":      "1.2.3\n",
+		"package.json": "{\n  \"version\": \"1.2.4\"\n}\n",
+	})
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	result, err := HandleBumpVersion(ctx, BumpVersionArgs{Path: dir, Part: "patch"})
+	if err != nil {
+		t.Fatalf("HandleBumpVersion failed: %v", err)
+	}
+	if !strings.Contains(result, "inconsistent") {
+		t.Errorf("Expected an inconsistency refusal, got: %s", result)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "VERSION"))
+	if string(content) != "1.2.3\n" {
+		t.Errorf("VERSION was modified despite the refusal: %q", content)
+	}
+}
+
+func TestHandleBumpVersion(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"VERSION":        "1.2.3\n",
+		"pyproject.toml": "[tool.other]\nversion = \"7.7.7\"\n\n[project]\nversion = \"1.2.3\"\n",
+	})
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	if _, err := HandleBumpVersion(ctx, BumpVersionArgs{Path: dir, Part: "minor"}); err != nil {
+		t.Fatalf("HandleBumpVersion failed: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	want := "[tool.other]\nversion = \"7.7.7\"\n\n[project]\nversion = \"1.3.0\"\n"
+	if string(content) != want {
+		t.Errorf("pyproject.toml = %q, want %q", content, want)
+	}
+}
@@ -0,0 +1,119 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffOp is a single line of a line-oriented diff.
+type diffOp struct {
+	Op   byte // '-' removed, '+' added, ' ' unchanged
+	Text string
+}
+
+// lineOps computes the line-level differences between before and after.
+func lineOps(before, after string) []diffOp {
+	dmp := diffmatchpatch.New()
+	a, b, lineArray := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lineArray)
+
+	var ops []diffOp
+	for _, d := range diffs {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			ops = append(ops, diffOp{Op: op, Text: strings.TrimSuffix(line, "\n")})
+		}
+	}
+	return ops
+}
+
+// LineDiff renders every line of the diff between before and after.
+// Removed lines are prefixed with "-", added lines with "+" and unchanged lines with " ".
+// In plain mode each line is labelled in words instead.
+func LineDiff(before, after string, plain bool) string {
+	var sb strings.Builder
+	for _, op := range lineOps(before, after) {
+		sb.WriteString(DiffLine(plain, op.Op, op.Text) + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// UnifiedDiff renders the changes between before and after as hunks surrounded by
+// up to context unchanged lines, headed by the file name. It returns an empty
+// string if the contents are identical.
+func UnifiedDiff(name, before, after string, context int, plain bool) string {
+	ops := lineOps(before, after)
+
+	// Mark the unchanged lines that are close enough to a change to be shown
+	show := make([]bool, len(ops))
+	changed := false
+	for i, op := range ops {
+		if op.Op == ' ' {
+			continue
+		}
+		changed = true
+		for j := max(0, i-context); j <= min(len(ops)-1, i+context); j++ {
+			show[j] = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	if plain {
+		sb.WriteString(fmt.Sprintf("Changes in %s.\n", name))
+	} else {
+		sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", name, name))
+	}
+
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(ops); {
+		if !show[i] {
+			if ops[i].Op != '+' {
+				oldLine++
+			}
+			if ops[i].Op != '-' {
+				newLine++
+			}
+			i++
+			continue
+		}
+
+		// Collect a contiguous hunk
+		j := i
+		oldCount, newCount := 0, 0
+		for j < len(ops) && show[j] {
+			if ops[j].Op != '+' {
+				oldCount++
+			}
+			if ops[j].Op != '-' {
+				newCount++
+			}
+			j++
+		}
+		if plain {
+			sb.WriteString(fmt.Sprintf("Original lines %d to %d, new lines %d to %d.\n", oldLine, oldLine+oldCount-1, newLine, newLine+newCount-1))
+		} else {
+			sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount))
+		}
+		for k := i; k < j; k++ {
+			sb.WriteString(DiffLine(plain, ops[k].Op, ops[k].Text) + "\n")
+		}
+		oldLine += oldCount
+		newLine += newCount
+		i = j
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
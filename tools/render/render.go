@@ -0,0 +1,45 @@
+package render
+
+import (
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// Plain reports whether output should be rendered as plain text for screen readers:
+// no color escape sequences, no box-drawing or symbolic markers, one fact per line.
+// A per-call override takes precedence over the plainOutput configuration value.
+func Plain(ctx *server.Context, override *bool) bool {
+	if override != nil {
+		return *override
+	}
+	cfg, err := config.GetCurrentConfig(ctx)
+	if err != nil || cfg.PlainOutput == nil {
+		return false
+	}
+	return *cfg.PlainOutput
+}
+
+// Block surrounds body with delimiters. Plain mode announces the block in words
+// instead of drawing "---" rules.
+func Block(plain bool, body string) string {
+	if plain {
+		return "Begin block.\n" + body + "\nEnd block."
+	}
+	return "---\n" + body + "\n---"
+}
+
+// DiffLine renders a single diff line. op is '-' for removed, '+' for added and ' ' for unchanged.
+func DiffLine(plain bool, op byte, text string) string {
+	if !plain {
+		return string(op) + text
+	}
+	switch op {
+	case '-':
+		return "Removed: " + text
+	case '+':
+		return "Added: " + text
+	default:
+		return "Unchanged: " + text
+	}
+}
@@ -0,0 +1,41 @@
+// Package request ties the work a tool handler does to the MCP request it
+// serves, so that a walk or search stops when the client gives up on it.
+package request
+
+import (
+	"context"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// Context returns a context that is cancelled when the client cancels the
+// request ctx serves, or when ctx itself is done. Its CancelFunc releases it
+// and must be called once the handler has finished with it.
+func Context(ctx *server.Context) (context.Context, context.CancelFunc) {
+	c, cancel := context.WithCancel(context.Background())
+	if ctx == nil {
+		return c, cancel
+	}
+	cancelled := ctx.RegisterForCancellation()
+	done := doneChan(ctx)
+	go func() {
+		select {
+		case <-cancelled:
+		case <-done:
+		case <-c.Done():
+		}
+		cancel()
+	}()
+	return c, cancel
+}
+
+// doneChan returns ctx's Done channel, or nil for a context that was built
+// without one, such as those tests create.
+func doneChan(ctx *server.Context) (done <-chan struct{}) {
+	defer func() {
+		if recover() != nil {
+			done = nil
+		}
+	}()
+	return ctx.Done()
+}
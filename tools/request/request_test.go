@@ -0,0 +1,30 @@
+package request
+
+import (
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestContext(t *testing.T) {
+	// A context without a request or server is only cancelled by its CancelFunc
+	c, cancel := Context(&server.Context{})
+	select {
+	case <-c.Done():
+		t.Fatal("context cancelled before its CancelFunc was called")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not cancelled by its CancelFunc")
+	}
+
+	c, cancel = Context(nil)
+	defer cancel()
+	if c.Err() != nil {
+		t.Errorf("Context(nil) is already done: %v", c.Err())
+	}
+}
@@ -0,0 +1,144 @@
+package search
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Archives larger than maxArchiveSize are skipped, as are entries that
+// decompress to more than maxArchiveEntrySize. They are variables so tests can
+// lower them.
+var (
+	maxArchiveSize      int64 = 100 * 1024 * 1024
+	maxArchiveEntrySize int64 = 10 * 1024 * 1024
+)
+
+// isArchive reports whether path names an archive search_code can descend into.
+func isArchive(p string) bool {
+	name := strings.ToLower(p)
+	for _, ext := range []string{".zip", ".jar", ".war", ".ear", ".tar.gz", ".tgz", ".tar"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchArchive searches the text entries of a zip or tar archive. Matches are
+// reported as archivePath!entry/name.
+func (e *SearchEngine) searchArchive(ctx context.Context, archivePath string, resultCount *int64) ([]SearchMatch, int64, error) {
+	name := strings.ToLower(archivePath)
+	if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".tar") {
+		return e.searchTar(ctx, archivePath, resultCount)
+	}
+	return e.searchZip(ctx, archivePath, resultCount)
+}
+
+func (e *SearchEngine) searchZip(ctx context.Context, archivePath string, resultCount *int64) ([]SearchMatch, int64, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer reader.Close()
+
+	var matches []SearchMatch
+	var bytesRead int64
+	for _, f := range reader.File {
+		if ctx.Err() != nil {
+			return matches, bytesRead, ctx.Err()
+		}
+		if f.FileInfo().IsDir() || int64(f.UncompressedSize64) > maxArchiveEntrySize || !e.includeEntry(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		entryMatches, n, _ := e.searchEntry(ctx, rc, archivePath, f.Name, resultCount)
+		rc.Close()
+		matches = append(matches, entryMatches...)
+		bytesRead += n
+	}
+	return matches, bytesRead, nil
+}
+
+func (e *SearchEngine) searchTar(ctx context.Context, archivePath string, resultCount *int64) ([]SearchMatch, int64, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if name := strings.ToLower(archivePath); strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var matches []SearchMatch
+	var bytesRead int64
+	tr := tar.NewReader(r)
+	for {
+		if ctx.Err() != nil {
+			return matches, bytesRead, ctx.Err()
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			return matches, bytesRead, nil
+		}
+		if err != nil {
+			// A truncated or corrupt archive still yields the entries read so far
+			return matches, bytesRead, nil
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > maxArchiveEntrySize || !e.includeEntry(header.Name) {
+			continue
+		}
+		entryMatches, n, _ := e.searchEntry(ctx, tr, archivePath, header.Name, resultCount)
+		matches = append(matches, entryMatches...)
+		bytesRead += n
+	}
+}
+
+// includeEntry applies the hidden, exclude and file pattern filters to an
+// archive entry name.
+func (e *SearchEngine) includeEntry(entry string) bool {
+	entry = strings.TrimSuffix(entry, "/")
+	base := path.Base(entry)
+	if !e.config.IncludeHidden {
+		for _, part := range strings.Split(entry, "/") {
+			if strings.HasPrefix(part, ".") {
+				return false
+			}
+		}
+	}
+	for _, pattern := range e.config.ExcludePatterns {
+		if matchGlob(pattern, base, entry) {
+			return false
+		}
+	}
+	return matchesFilePatterns(e.config.FilePatterns, base, entry)
+}
+
+// searchEntry searches one archive entry, skipping binary content unless
+// searching for bytes. The entry is read through a limit in case its header
+// understates the decompressed size.
+func (e *SearchEngine) searchEntry(ctx context.Context, r io.Reader, archivePath, entry string, resultCount *int64) ([]SearchMatch, int64, error) {
+	br := bufio.NewReader(io.LimitReader(r, maxArchiveEntrySize))
+	head, _ := br.Peek(512)
+	if len(e.config.Bytes) == 0 && bytes.IndexByte(head, 0) >= 0 {
+		return nil, 0, nil
+	}
+	return e.searchReader(ctx, br, archivePath+"!"+entry, resultCount)
+}
@@ -0,0 +1,159 @@
+package search
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func writeZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+}
+
+func writeTarGz(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range entries {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip: %v", err)
+	}
+}
+
+func TestSearchArchives(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "app.jar"), map[string]string{
+		"com/example/Main.java": "class Main {\n  // needle\n}\n",
+		"com/example/data.bin":  "needle\x00\x01",
+		".hidden/secret.txt":    "needle\n",
+		"META-INF/MANIFEST.MF":  "needle: yes\n",
+	})
+	writeTarGz(t, filepath.Join(dir, "src.tar.gz"), map[string]string{
+		"pkg/readme.txt": "first\nneedle\n",
+	})
+	os.WriteFile(filepath.Join(dir, "plain.txt"), []byte("needle\n"), 0644)
+
+	tests := []struct {
+		name    string
+		options []SearchOption
+		want    []string
+	}{
+		{
+			name: "archives ignored by default",
+			want: []string{"plain.txt"},
+		},
+		{
+			name:    "archive entries",
+			options: []SearchOption{WithArchives()},
+			want:    []string{"app.jar!META-INF/MANIFEST.MF", "app.jar!com/example/Main.java", "plain.txt", "src.tar.gz!pkg/readme.txt"},
+		},
+		{
+			name:    "file pattern applies to entries",
+			options: []SearchOption{WithArchives(), WithFilePattern("*.java")},
+			want:    []string{"app.jar!com/example/Main.java"},
+		},
+		{
+			name:    "hidden entries included on request",
+			options: []SearchOption{WithArchives(), WithHidden(), WithFilePattern("*.txt")},
+			want:    []string{"app.jar!.hidden/secret.txt", "plain.txt", "src.tar.gz!pkg/readme.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Find("needle", dir, tt.options...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			var got []string
+			for _, m := range results.Matches {
+				rel, _ := filepath.Rel(dir, m.File)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Matches = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Matches = %q, want %q", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	t.Run("line numbers inside entries", func(t *testing.T) {
+		results, err := Find("needle", dir, WithArchives(), WithFilePattern("readme.txt"))
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if len(results.Matches) != 1 || results.Matches[0].Line != 2 {
+			t.Errorf("Expected one match on line 2, got %+v", results.Matches)
+		}
+	})
+}
+
+func TestSearchArchivesSizeCaps(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "small.zip"), map[string]string{"a.txt": "needle\n"})
+	writeZip(t, filepath.Join(dir, "big.zip"), map[string]string{"a.txt": "needle\npadding padding padding padding padding padding\n"})
+
+	bigInfo, _ := os.Stat(filepath.Join(dir, "big.zip"))
+	smallInfo, _ := os.Stat(filepath.Join(dir, "small.zip"))
+	oldArchive := maxArchiveSize
+	maxArchiveSize = smallInfo.Size()
+	defer func() { maxArchiveSize = oldArchive }()
+	if bigInfo.Size() <= maxArchiveSize {
+		t.Fatalf("Test archive sizes do not differ: %d <= %d", bigInfo.Size(), maxArchiveSize)
+	}
+
+	results, err := Find("needle", dir, WithArchives())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Matches) != 1 || filepath.Base(results.Matches[0].File) != "small.zip!a.txt" {
+		t.Errorf("Expected only the small archive to be searched, got %+v", results.Matches)
+	}
+
+	oldEntry := maxArchiveEntrySize
+	maxArchiveEntrySize = 4
+	defer func() { maxArchiveEntrySize = oldEntry }()
+	maxArchiveSize = oldArchive
+	results, err = Find("needle", dir, WithArchives())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results.Matches) != 0 {
+		t.Errorf("Expected oversized entries to be skipped, got %+v", results.Matches)
+	}
+}
@@ -0,0 +1,158 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+)
+
+// minBlockSize is the smallest block searchBlocks reads at a time.
+const minBlockSize = 64 * 1024
+
+// blockBuffers are the buffers of one searchBlocks call. They are pooled, as
+// most files are small and allocating a block for each would cost more than
+// searching it.
+type blockBuffers struct {
+	buf, folded []byte
+}
+
+var blockPool = sync.Pool{New: func() any { return new(blockBuffers) }}
+
+// searchesBlocks reports whether searchBlocks can run the search: a literal
+// pattern on one line, without context lines, and, when case is ignored, made
+// of ASCII letters that fold without changing the length of the text.
+func (e *SearchEngine) searchesBlocks() bool {
+	c := &e.config
+	if !c.UseOptimization || e.literalSearch == "" || c.BeforeContext > 0 || c.AfterContext > 0 {
+		return false
+	}
+	if strings.ContainsAny(e.literalSearch, "\r\n") {
+		return false
+	}
+	return !c.IgnoreCase || isASCII(c.Pattern)
+}
+
+// searchBlocks searches r a block at a time with bytes.Index rather than
+// line by line, and works out the line of each match from the newlines before
+// it. Only the lines holding matches are turned into strings. A block ends at
+// its last newline; the partial line after it is carried into the next.
+func (e *SearchEngine) searchBlocks(ctx context.Context, r io.Reader, name string, resultCount *int64) ([]SearchMatch, int64, error) {
+	size := max(e.config.BufferSize, minBlockSize)
+	needle := []byte(e.literalSearch)
+	buffers := blockPool.Get().(*blockBuffers)
+	defer blockPool.Put(buffers)
+	if cap(buffers.buf) < size {
+		buffers.buf = make([]byte, 0, size)
+	}
+	buf, folded := buffers.buf[:0], buffers.folded
+	defer func() {
+		// A buffer grown for a very long line is not worth keeping
+		if cap(buf) <= 4*size {
+			buffers.buf, buffers.folded = buf, folded
+		}
+	}()
+	var matches []SearchMatch
+	var bytesRead int64
+	lineNum := 1 // the line buf starts on
+
+	for {
+		if ctx.Err() != nil {
+			return matches, bytesRead, ctx.Err()
+		}
+		// A line longer than half the buffer grows it
+		if len(buf) > cap(buf)/2 {
+			grown := make([]byte, len(buf), 2*cap(buf))
+			copy(grown, buf)
+			buf = grown
+		}
+		n, err := io.ReadFull(r, buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		bytesRead += int64(n)
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return matches, bytesRead, err
+		}
+
+		end := len(buf)
+		if !eof {
+			end = bytes.LastIndexByte(buf, '\n') + 1
+		}
+		region := buf[:end]
+		haystack := region
+		if e.config.IgnoreCase {
+			folded = foldASCII(folded[:0], region)
+			haystack = folded
+		}
+
+		counted := 0 // region[:counted] has been counted into lineNum
+		for pos := 0; pos < len(haystack); {
+			idx := bytes.Index(haystack[pos:], needle)
+			if idx < 0 {
+				break
+			}
+			idx += pos
+			start := bytes.LastIndexByte(haystack[:idx], '\n') + 1
+			stop := bytes.IndexByte(haystack[idx:], '\n')
+			if stop < 0 {
+				stop = len(haystack)
+			} else {
+				stop += idx
+			}
+			lineNum += bytes.Count(region[counted:start], []byte{'\n'})
+			counted = start
+
+			if e.atLimit(resultCount, len(matches)) {
+				return matches, bytesRead, nil
+			}
+			// Every match on the line, found where case is folded
+			var locs [][]int
+			for at := idx; at >= 0 && at < stop; {
+				locs = append(locs, []int{at - start, at - start + len(needle)})
+				at += len(needle)
+				if next := bytes.Index(haystack[at:stop], needle); next >= 0 {
+					at += next
+				} else {
+					at = -1
+				}
+			}
+			content := string(bytes.TrimSuffix(region[start:stop], []byte{'\r'}))
+			matches = append(matches, SearchMatch{
+				File:    name,
+				Line:    lineNum,
+				Column:  idx - start + 1,
+				Content: content,
+				Spans:   newSpans(content, locs),
+			})
+			pos = stop + 1
+		}
+		lineNum += bytes.Count(region[counted:], []byte{'\n'})
+
+		if eof {
+			return matches, bytesRead, nil
+		}
+		buf = buf[:copy(buf, buf[end:])]
+	}
+}
+
+// foldASCII appends src to dst with ASCII letters in lower case.
+func foldASCII(dst, src []byte) []byte {
+	for _, c := range src {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+// isASCII reports whether s holds only ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
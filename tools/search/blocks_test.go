@@ -0,0 +1,92 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchBlocksMatchesLineSearch(t *testing.T) {
+	var long strings.Builder
+	for i := 0; long.Len() < 3*minBlockSize; i++ {
+		long.WriteString("filler line without it\n")
+		if i%997 == 0 {
+			long.WriteString("a needle and another needle\r\n")
+		}
+	}
+	long.WriteString("last needle")
+	longLine := strings.Repeat("x", 2*minBlockSize) + "needle"
+
+	tests := []struct {
+		name    string
+		content string
+		pattern string
+		options []SearchOption
+		want    []SearchMatch // when line search cannot serve as the reference
+	}{
+		{"short", "one\nneedle here\n\nand a needle\n", "needle", nil, nil},
+		{"no trailing newline", "needle", "needle", nil, nil},
+		{"crlf", "a\r\nneedle\r\nb\r\n", "needle", nil, nil},
+		{"across blocks", long.String(), "needle", nil, nil},
+		{"ignore case", "NEEDLE\nNeedle\nnope\n", "needle", []SearchOption{WithIgnoreCase()}, nil},
+		{"max results", "needle\nneedle\nneedle\n", "needle", []SearchOption{WithMaxResults(2)}, nil},
+		{"no match", "haystack\n", "needle", nil, nil},
+		// Lines longer than bufio.Scanner's limit end a line search
+		{"long line", "a\n" + longLine + "\nb needle\n", "needle", nil, []SearchMatch{
+			{File: "f", Line: 2, Column: 2*minBlockSize + 1, Content: longLine, Spans: []MatchSpan{{2 * minBlockSize, 2*minBlockSize + 6, 2 * minBlockSize, 2*minBlockSize + 6}}},
+			{File: "f", Line: 3, Column: 3, Content: "b needle", Spans: []MatchSpan{{2, 8, 2, 8}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := func(optimize bool) []SearchMatch {
+				config := SearchConfig{Pattern: tt.pattern, UseOptimization: optimize}
+				for _, option := range tt.options {
+					option(&config)
+				}
+				engine := NewSearchEngine(config)
+				if engine.searchesBlocks() != optimize {
+					t.Fatalf("searchesBlocks() = %v, want %v", !optimize, optimize)
+				}
+				var count int64
+				matches, _, err := engine.searchReader(context.Background(), strings.NewReader(tt.content), "f", &count)
+				if err != nil {
+					t.Fatalf("searchReader failed: %v", err)
+				}
+				return matches
+			}
+			got, want := search(true), tt.want
+			if want == nil {
+				want = search(false)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("searchBlocks found %d matches, line search %d:\n%+v\nwant\n%+v", len(got), len(want), got, want)
+			}
+		})
+	}
+}
+
+func TestSearchesBlocks(t *testing.T) {
+	tests := []struct {
+		pattern string
+		options []SearchOption
+		want    bool
+	}{
+		{"needle", nil, true},
+		{`func \w+`, nil, false},
+		{"needle", []SearchOption{WithContextLines(1)}, false},
+		{"needle", []SearchOption{WithWholeWord()}, false},
+		{"straße", []SearchOption{WithIgnoreCase()}, false},
+		{"straße", nil, true},
+	}
+	for _, tt := range tests {
+		config := SearchConfig{Pattern: tt.pattern, UseOptimization: true}
+		for _, option := range tt.options {
+			option(&config)
+		}
+		if got := NewSearchEngine(config).searchesBlocks(); got != tt.want {
+			t.Errorf("searchesBlocks(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
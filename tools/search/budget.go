@@ -0,0 +1,107 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sync"
+	"time"
+)
+
+// maxRegexProgram is the most instructions a pattern may compile to. Matching
+// costs time in proportion to the program's size, so a pattern such as
+// `(\w+\s*,\s*){0,999}\w+` is refused before it reaches a file.
+const maxRegexProgram = 5000
+
+// Default regex time budgets: a file is abandoned when matching a single line
+// takes longer than defaultLineBudget, or its lines take longer than
+// defaultFileBudget in all.
+const (
+	defaultLineBudget = time.Second
+	defaultFileBudget = 5 * time.Second
+)
+
+var (
+	errPatternTooComplex = errors.New("pattern too complex")
+	errRegexBudget       = errors.New("regex time budget exceeded")
+)
+
+// compileRegexp compiles source like regexp.Compile, refusing patterns whose
+// program has more than maxRegexProgram instructions.
+func compileRegexp(source string) (*regexp.Regexp, error) {
+	re, err := syntax.Parse(source, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, err
+	}
+	if len(prog.Inst) > maxRegexProgram {
+		return nil, fmt.Errorf("%w: it compiles to %d instructions, over the limit of %d", errPatternTooComplex, len(prog.Inst), maxRegexProgram)
+	}
+	return regexp.Compile(source)
+}
+
+// WithRegexBudget bounds the time a regex search spends matching the lines of
+// one file: a file is abandoned, keeping the matches found so far, once one
+// line takes longer than line or all of them longer than file. Either may be
+// 0 for no limit. The files abandoned are listed in SearchStats. The budgets
+// apply to line by line searches on the built-in engine only.
+func WithRegexBudget(line, file time.Duration) SearchOption {
+	return func(c *SearchConfig) {
+		c.LineBudget = line
+		c.FileBudget = file
+	}
+}
+
+// regexBudget is the time left to match the lines of one file. A nil
+// *regexBudget has no limits.
+type regexBudget struct {
+	line, file time.Duration
+	spent      time.Duration
+}
+
+// newBudget returns the budget for a file, or nil when there is none.
+func (e *SearchEngine) newBudget() *regexBudget {
+	if e.config.LineBudget <= 0 && e.config.FileBudget <= 0 {
+		return nil
+	}
+	return &regexBudget{line: e.config.LineBudget, file: e.config.FileBudget}
+}
+
+// start returns the time matching a line starts at, or the zero time when
+// there is no budget to charge it to.
+func (b *regexBudget) start() time.Time {
+	if b == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// charge counts the time since started against the budget, returning
+// errRegexBudget once it is exceeded.
+func (b *regexBudget) charge(started time.Time) error {
+	if b == nil {
+		return nil
+	}
+	took := time.Since(started)
+	b.spent += took
+	if (b.line > 0 && took > b.line) || (b.file > 0 && b.spent > b.file) {
+		return errRegexBudget
+	}
+	return nil
+}
+
+// overBudget collects the files a search abandoned for taking too long.
+type overBudget struct {
+	mu    sync.Mutex
+	files []string
+}
+
+func (o *overBudget) add(path string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.files = append(o.files, path)
+}
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestCompileRegexp(t *testing.T) {
+	for _, source := range []string{`func \w+\(`, `(?i)todo|fixme`, `.{0,1000}`} {
+		if _, err := compileRegexp(source); err != nil {
+			t.Errorf("compileRegexp(%q) failed: %v", source, err)
+		}
+	}
+	if _, err := compileRegexp(`(\w+\s*,\s*){0,999}\w+`); !errors.Is(err, errPatternTooComplex) {
+		t.Errorf("compileRegexp of a huge program = %v, want errPatternTooComplex", err)
+	}
+	if _, err := compileRegexp(`func (`); err == nil || errors.Is(err, errPatternTooComplex) {
+		t.Errorf("compileRegexp of an invalid pattern = %v, want a syntax error", err)
+	}
+}
+
+func TestFindRegexBudget(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("hay\nneedle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No line can be matched within a nanosecond, so both files are abandoned
+	results, err := Find("ne+dle", dir, WithRegexBudget(time.Nanosecond, 0))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	if results.Count() != 0 || !reflect.DeepEqual(results.Stats.OverBudget, want) {
+		t.Errorf("Find over budget = %d matches, over budget %q; want none and %q", results.Count(), results.Stats.OverBudget, want)
+	}
+
+	// Literal searches are not timed
+	results, err = Find("needle", dir, WithRegexBudget(time.Nanosecond, time.Nanosecond))
+	if err != nil || results.Count() != 2 || results.Stats.OverBudget != nil {
+		t.Errorf("literal Find = %d matches, over budget %q, %v; want 2", results.Count(), results.Stats.OverBudget, err)
+	}
+
+	results, err = Find("ne+dle", dir)
+	if err != nil || results.Count() != 2 || results.Stats.OverBudget != nil {
+		t.Errorf("Find with the default budget = %d matches, over budget %q, %v; want 2", results.Count(), results.Stats.OverBudget, err)
+	}
+}
+
+func TestHandleSearchCodeTooComplex(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a.b.c.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: `(\w+\s*,\s*){0,999}\w+`})
+	if err != nil || !strings.HasPrefix(result, "Pattern") || !strings.Contains(result, "too complex") {
+		t.Errorf("HandleSearchCode = %q, %v; want the pattern refused", result, err)
+	}
+}
@@ -0,0 +1,184 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/request"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// Reasons a file or directory is left out of a search, as counted in
+// SearchStats.Skipped.
+const (
+	skipHidden      = "hidden"
+	skipExcluded    = "excluded"
+	skipGitignored  = "gitignored"
+	skipFilePattern = "file_pattern"
+	skipTooLarge    = "too_large"
+	skipBinary      = "binary"
+	skipUnreadable  = "unreadable"
+)
+
+// Diagnostics list at most maxSkippedListed skipped files, counting the rest,
+// and the slowestDirs directories that took longest.
+const (
+	maxSkippedListed = 100
+	slowestDirs      = 10
+)
+
+// SkippedFile is a file or directory a search left out, and why. Directories
+// end in a path separator.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// DirTime is the time spent searching the files directly inside a directory.
+type DirTime struct {
+	Dir      string        `json:"dir"`
+	Duration time.Duration `json:"duration"`
+	Files    int           `json:"files"`
+}
+
+// WorkerStats is what one search worker did.
+type WorkerStats struct {
+	Files       int           `json:"files"`
+	Bytes       int64         `json:"bytes"`
+	Busy        time.Duration `json:"busy"`
+	Utilization float64       `json:"utilization"` // the share of the search's duration it was busy
+}
+
+// WithDiagnostics records in SearchStats what the search skipped and why, the
+// directories it spent longest in and what each worker did. It always runs on
+// the built-in engine, as rg does not report them.
+func WithDiagnostics() SearchOption {
+	return func(c *SearchConfig) {
+		c.Diagnostics = true
+	}
+}
+
+// diagnostics collects what WithDiagnostics reports while a search runs. Its
+// methods do nothing on a nil *diagnostics, which is what a search without
+// them has.
+type diagnostics struct {
+	mu      sync.Mutex
+	skipped map[string]int
+	listed  []SkippedFile
+	dirs    map[string]*DirTime
+	workers []WorkerStats
+}
+
+func newDiagnostics(workers int) *diagnostics {
+	return &diagnostics{
+		skipped: make(map[string]int),
+		dirs:    make(map[string]*DirTime),
+		workers: make([]WorkerStats, workers),
+	}
+}
+
+// skip records that path was left out for reason.
+func (d *diagnostics) skip(path, reason string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.skipped[reason]++
+	if len(d.listed) < maxSkippedListed {
+		d.listed = append(d.listed, SkippedFile{Path: path, Reason: reason})
+	}
+}
+
+// searched records that worker searched the file at path, reading size bytes
+// in took.
+func (d *diagnostics) searched(worker int, path string, size int64, took time.Duration) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w := &d.workers[worker]
+	w.Files++
+	w.Bytes += size
+	w.Busy += took
+	dir := filepath.Dir(path)
+	t := d.dirs[dir]
+	if t == nil {
+		t = &DirTime{Dir: dir}
+		d.dirs[dir] = t
+	}
+	t.Duration += took
+	t.Files++
+}
+
+// report adds the diagnostics to stats, whose Duration is set.
+func (d *diagnostics) report(stats *SearchStats) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats.Skipped = d.skipped
+	stats.SkippedFiles = d.listed
+
+	dirs := make([]DirTime, 0, len(d.dirs))
+	for _, t := range d.dirs {
+		dirs = append(dirs, *t)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].Duration != dirs[j].Duration {
+			return dirs[i].Duration > dirs[j].Duration
+		}
+		return dirs[i].Dir < dirs[j].Dir
+	})
+	if len(dirs) > slowestDirs {
+		dirs = dirs[:slowestDirs]
+	}
+	stats.SlowestDirs = dirs
+
+	stats.Workers = d.workers
+	for i := range stats.Workers {
+		if stats.Duration > 0 {
+			stats.Workers[i].Utilization = float64(stats.Workers[i].Busy) / float64(stats.Duration)
+		}
+	}
+}
+
+// HandleSearchDiagnostics implements the search_diagnostics tool: it runs the
+// search search_code would with the same arguments, on the built-in engine,
+// and returns its statistics with diagnostics as JSON.
+func HandleSearchDiagnostics(ctx *server.Context, args SearchCodeArgs) (string, error) {
+	ctx.Logger.Info("Handling search_diagnostics tool call", "path", args.Path, "pattern", args.Pattern)
+
+	options := append(searchCodeOptions(ctx, args), WithDiagnostics())
+	if args.Hex != nil && *args.Hex {
+		needle, err := ParseHex(args.Pattern)
+		if err != nil {
+			return i18n.T(ctx, i18n.SearchHexInvalid, args.Pattern), nil
+		}
+		options = append(options, WithBytes(needle))
+	}
+
+	reqCtx, cancel := request.Context(ctx)
+	defer cancel()
+	results, err := FindContext(reqCtx, args.Pattern, args.Path, options...)
+	if errors.Is(err, errPatternTooComplex) {
+		return i18n.T(ctx, i18n.SearchPatternTooComplex, args.Pattern, maxRegexProgram), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("search failed: %v", err)
+	}
+	statsJson, err := json.MarshalIndent(results.Stats, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("search failed: %v", err)
+	}
+	return string(statsJson), nil
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestFindDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":         "package main\n",
+		"sub/util.go":     "package sub\n",
+		"notes.txt":       "package notes\n",
+		"big.go":          strings.Repeat("package big\n", 100),
+		"blob.go":         "package\x00blob\n",
+		".env":            "package=hidden\n",
+		".cache/c.go":     "package cache\n",
+		"vendor/v.go":     "package vendor\n",
+		"gen/skip.go":     "package gen\n",
+		".gitignore":      "vendor/\n*.log\n",
+		"debug.log":       "package log\n",
+		"sub/deep/two.go": "package deep\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := Find("package", dir, WithDiagnostics(), WithWorkers(3), WithGitignore(true),
+		WithFilePattern("*.go", "*.log"), WithExcludePatterns("gen/"), WithMaxFileSize(100))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	stats := results.Stats
+
+	want := map[string]int{skipHidden: 3, skipExcluded: 1, skipGitignored: 2, skipTooLarge: 1, skipBinary: 1, skipFilePattern: 1}
+	if !reflect.DeepEqual(stats.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", stats.Skipped, want)
+	}
+	reasons := make(map[string]string)
+	for _, s := range stats.SkippedFiles {
+		rel, _ := filepath.Rel(dir, s.Path)
+		if strings.HasSuffix(s.Path, string(filepath.Separator)) {
+			rel += "/"
+		}
+		reasons[filepath.ToSlash(rel)] = s.Reason
+	}
+	for path, reason := range map[string]string{"vendor/": skipGitignored, "debug.log": skipGitignored, ".cache/": skipHidden, "gen/": skipExcluded, "notes.txt": skipFilePattern, "big.go": skipTooLarge, "blob.go": skipBinary} {
+		if reasons[path] != reason {
+			t.Errorf("%s skipped as %q, want %q", path, reasons[path], reason)
+		}
+	}
+
+	if len(stats.Workers) != 3 {
+		t.Fatalf("Workers = %+v, want 3", stats.Workers)
+	}
+	searched := 0
+	for _, w := range stats.Workers {
+		searched += w.Files
+		if w.Utilization < 0 || w.Utilization > 1 {
+			t.Errorf("worker utilization %v is not a share of the search", w.Utilization)
+		}
+	}
+	if searched != stats.FilesScanned || searched != 3 {
+		t.Errorf("workers searched %d files, FilesScanned = %d; want 3", searched, stats.FilesScanned)
+	}
+	dirs := make(map[string]int)
+	for _, d := range stats.SlowestDirs {
+		dirs[d.Dir] = d.Files
+	}
+	if want := map[string]int{dir: 1, filepath.Join(dir, "sub"): 1, filepath.Join(dir, "sub", "deep"): 1}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("SlowestDirs = %+v, want one file in each of %v", stats.SlowestDirs, want)
+	}
+
+	// Without diagnostics nothing is recorded
+	results, err = Find("package", dir, WithGitignore(true))
+	if err != nil || results.Stats.Skipped != nil || results.Stats.Workers != nil {
+		t.Errorf("Find without diagnostics = %+v, %v", results.Stats, err)
+	}
+}
+
+func TestHandleSearchDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.go": "needle\n", "b.bin": "needle\x00\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	result, err := HandleSearchDiagnostics(ctx, SearchCodeArgs{Path: dir, Pattern: "needle"})
+	if err != nil {
+		t.Fatalf("HandleSearchDiagnostics failed: %v", err)
+	}
+	var stats SearchStats
+	if err := json.Unmarshal([]byte(result), &stats); err != nil {
+		t.Fatalf("Result is not JSON statistics: %v\n%s", err, result)
+	}
+	if stats.MatchesFound != 1 || stats.Skipped[skipBinary] != 1 || len(stats.Workers) == 0 {
+		t.Errorf("HandleSearchDiagnostics = %s", result)
+	}
+}
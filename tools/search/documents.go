@@ -0,0 +1,294 @@
+package search
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Documents larger than maxDocumentSize are not extracted. Extracted text is
+// cached by path, size and modification time, keeping at most
+// maxCachedDocuments entries.
+var (
+	maxDocumentSize    int64 = 50 * 1024 * 1024
+	maxCachedDocuments       = 256
+)
+
+// isDocument reports whether path is a document whose text search_code can extract.
+func isDocument(p string) bool {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".pdf", ".docx", ".xlsx":
+		return true
+	}
+	return false
+}
+
+type documentKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// documentCache holds extracted text in insertion order for eviction.
+type documentCache struct {
+	mu    sync.Mutex
+	text  map[documentKey]string
+	order []documentKey
+}
+
+var documents = &documentCache{text: make(map[documentKey]string)}
+
+func (c *documentCache) get(key documentKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	text, ok := c.text[key]
+	return text, ok
+}
+
+func (c *documentCache) put(key documentKey, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.text[key]; ok {
+		return
+	}
+	for len(c.order) >= maxCachedDocuments && len(c.order) > 0 {
+		delete(c.text, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.text[key] = text
+	c.order = append(c.order, key)
+}
+
+// documentText returns the plain text of a PDF, DOCX or XLSX file, one
+// paragraph, PDF text row or spreadsheet row per line.
+func documentText(filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > maxDocumentSize {
+		return "", fmt.Errorf("%s is larger than %d bytes", filePath, maxDocumentSize)
+	}
+	key := documentKey{path: filePath, size: info.Size(), modTime: info.ModTime()}
+	if text, ok := documents.get(key); ok {
+		return text, nil
+	}
+
+	var text string
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".pdf":
+		text, err = pdfText(filePath)
+	case ".docx":
+		text, err = docxText(filePath)
+	case ".xlsx":
+		text, err = xlsxText(filePath)
+	}
+	if err != nil {
+		return "", err
+	}
+	documents.put(key, text)
+	return text, nil
+}
+
+// pdfText extracts the text of each page, starting a new line whenever the
+// baseline moves. The PDF reader panics on some malformed files, which is
+// reported as an error.
+func pdfText(filePath string) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("reading %s: %v", filePath, r)
+		}
+	}()
+
+	f, reader, err := pdf.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		var lastY float64
+		for j, t := range page.Content().Text {
+			if j > 0 && math.Abs(t.Y-lastY) > 1 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(t.S)
+			lastY = t.Y
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// readZipEntry returns the contents of one file inside a zip archive, or nil
+// if it is missing.
+func readZipEntry(r *zip.Reader, name string) ([]byte, error) {
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxDocumentSize))
+	}
+	return nil, nil
+}
+
+// docxText extracts the paragraphs of word/document.xml.
+func docxText(filePath string) (string, error) {
+	r, err := zip.OpenReader(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	content, err := readZipEntry(&r.Reader, "word/document.xml")
+	if err != nil || content == nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
+
+// xlsxText extracts every worksheet, one row per line with cells separated by
+// tabs. Worksheets are separated by a blank line.
+func xlsxText(filePath string) (string, error) {
+	r, err := zip.OpenReader(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var shared []string
+	if content, err := readZipEntry(&r.Reader, "xl/sharedStrings.xml"); err != nil {
+		return "", err
+	} else if content != nil {
+		var sst struct {
+			Items []struct {
+				Text string `xml:"t"`
+				Runs []struct {
+					Text string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"si"`
+		}
+		if err := xml.Unmarshal(content, &sst); err != nil {
+			return "", err
+		}
+		for _, si := range sst.Items {
+			text := si.Text
+			for _, run := range si.Runs {
+				text += run.Text
+			}
+			shared = append(shared, text)
+		}
+	}
+
+	var sheets []string
+	for _, f := range r.File {
+		if dir, name := path.Split(f.Name); dir == "xl/worksheets/" && strings.HasSuffix(name, ".xml") {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	// sheet2.xml sorts before sheet10.xml
+	sort.Slice(sheets, func(i, j int) bool {
+		if len(sheets[i]) != len(sheets[j]) {
+			return len(sheets[i]) < len(sheets[j])
+		}
+		return sheets[i] < sheets[j]
+	})
+
+	var b strings.Builder
+	for i, name := range sheets {
+		content, err := readZipEntry(&r.Reader, name)
+		if err != nil {
+			return "", err
+		}
+		var ws struct {
+			Rows []struct {
+				Cells []struct {
+					Type   string `xml:"t,attr"`
+					Value  string `xml:"v"`
+					Inline string `xml:"is>t"`
+				} `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		if err := xml.Unmarshal(content, &ws); err != nil {
+			return "", err
+		}
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, row := range ws.Rows {
+			cells := make([]string, len(row.Cells))
+			for j, c := range row.Cells {
+				switch c.Type {
+				case "s":
+					if n, err := strconv.Atoi(c.Value); err == nil && n >= 0 && n < len(shared) {
+						cells[j] = shared[n]
+					}
+				case "inlineStr":
+					cells[j] = c.Inline
+				default:
+					cells[j] = c.Value
+				}
+			}
+			b.WriteString(strings.Join(cells, "\t"))
+			b.WriteByte('\n')
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,163 @@
+package search
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeZipFile(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// minimalPDF returns a one-page PDF showing each line of text on its own row.
+func minimalPDF(lines ...string) []byte {
+	var stream strings.Builder
+	stream.WriteString("BT /F1 12 Tf 72 720 Td\n")
+	for i, line := range lines {
+		if i > 0 {
+			stream.WriteString("0 -20 Td\n")
+		}
+		fmt.Fprintf(&stream, "(%s) Tj\n", line)
+	}
+	stream.WriteString("ET")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", stream.Len(), stream.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestDocumentText(t *testing.T) {
+	dir := t.TempDir()
+
+	docx := filepath.Join(dir, "spec.docx")
+	writeZipFile(t, docx, map[string]string{
+		"word/document.xml": `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			`<w:p><w:r><w:t>Retry </w:t></w:r><w:r><w:t>policy</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:t>Second</w:t><w:tab/><w:t>paragraph</w:t></w:r></w:p></w:body></w:document>`,
+	})
+
+	xlsx := filepath.Join(dir, "budget.xlsx")
+	writeZipFile(t, xlsx, map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>Item</t></si><si><r><t>Co</t></r><r><t>st</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row><c t="s"><v>0</v></c><c t="s"><v>1</v></c></row>` +
+			`<row><c t="inlineStr"><is><t>Servers</t></is></c><c><v>1200</v></c></row>` +
+			`</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row><c><v>7</v></c></row></sheetData></worksheet>`,
+	})
+
+	pdfPath := filepath.Join(dir, "manual.pdf")
+	if err := os.WriteFile(pdfPath, minimalPDF("Installation guide", "Run the installer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{docx, "Retry policy\nSecond\tparagraph\n"},
+		{xlsx, "Item\tCost\nServers\t1200\n\n7\n"},
+		{pdfPath, "Installation guide\nRun the installer\n"},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			got, err := documentText(tt.path)
+			if err != nil {
+				t.Fatalf("documentText failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("documentText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("search", func(t *testing.T) {
+		results, err := Find("installer", dir)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if results.HasMatches() {
+			t.Errorf("Documents were searched without the option: %+v", results.Matches)
+		}
+
+		results, err = Find("installer|Servers", dir, WithDocuments())
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		var got []string
+		for _, m := range results.Matches {
+			got = append(got, fmt.Sprintf("%s:%d", filepath.Base(m.File), m.Line))
+		}
+		if strings.Join(got, ",") != "budget.xlsx:2,manual.pdf:2" {
+			t.Errorf("Matches = %v", got)
+		}
+	})
+}
+
+func TestDocumentCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.docx")
+	doc := func(text string) map[string]string {
+		return map[string]string{"word/document.xml": "<document><body><p><t>" + text + "</t></p></body></document>"}
+	}
+
+	writeZipFile(t, path, doc("first"))
+	if got, _ := documentText(path); got != "first\n" {
+		t.Fatalf("documentText = %q", got)
+	}
+
+	// A rewrite with a different size is extracted again
+	writeZipFile(t, path, doc("second version"))
+	if got, _ := documentText(path); got != "second version\n" {
+		t.Errorf("Stale cache entry returned: %q", got)
+	}
+
+	old := maxCachedDocuments
+	maxCachedDocuments = 1
+	defer func() { maxCachedDocuments = old }()
+	other := filepath.Join(dir, "b.docx")
+	writeZipFile(t, other, doc("other"))
+	documentText(other)
+	documents.mu.Lock()
+	n := len(documents.text)
+	documents.mu.Unlock()
+	if n != 1 {
+		t.Errorf("Cache holds %d entries, want 1", n)
+	}
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// writeMatches writes matches in the text output format of search_code:
+// "heading" groups them by file, anything else writes each on its own.
+func writeMatches(output *strings.Builder, format string, matches []SearchMatch) {
+	if format == "heading" {
+		writeHeadings(output, matches)
+		return
+	}
+	for _, match := range matches {
+		writeMatch(output, match)
+	}
+}
+
+// writeHeadings writes matches as ripgrep's --heading does: the name of each
+// file on a line of its own, then its matching lines as line:content and
+// their context as line-content. A line is written once however many matches
+// it is context for and, when there is context, "--" separates lines that do
+// not follow each other. Files are separated by an empty line.
+func writeHeadings(output *strings.Builder, matches []SearchMatch) {
+	for start := 0; start < len(matches); {
+		end := start + 1
+		for end < len(matches) && matches[end].File == matches[start].File {
+			end++
+		}
+		if start > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString(matches[start].File + "\n")
+		writeFileLines(output, matches[start:end])
+		start = end
+	}
+}
+
+// headingLine is a line written under a file heading.
+type headingLine struct {
+	text  string
+	match bool
+}
+
+// writeFileLines writes the lines of one file's matches and their context in
+// line order, each once.
+func writeFileLines(output *strings.Builder, matches []SearchMatch) {
+	lines := make(map[int]headingLine)
+	hasContext := false
+	set := func(n int, text string, match bool) {
+		// A line that matches is not turned into context by another match
+		if prev, ok := lines[n]; !ok || !prev.match {
+			lines[n] = headingLine{text: text, match: match}
+		}
+	}
+	for _, match := range matches {
+		// Byte matches have no lines, and no context
+		if match.Offset != nil {
+			output.WriteString(fmt.Sprintf("%#x:%s\n", *match.Offset, match.Content))
+			continue
+		}
+		hasContext = hasContext || len(match.Context) > 0 || len(match.ContextAfter) > 0
+		first := match.Line - len(match.Context)
+		for i, text := range match.Context {
+			set(first+i, text, false)
+		}
+		// A multiline match covers a line for each of its content's
+		for i, text := range strings.Split(match.Content, "\n") {
+			set(match.Line+i, text, true)
+		}
+		last := max(match.Line, match.EndLine)
+		for i, text := range match.ContextAfter {
+			set(last+1+i, text, false)
+		}
+	}
+
+	numbers := make([]int, 0, len(lines))
+	for n := range lines {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for i, n := range numbers {
+		if hasContext && i > 0 && n > numbers[i-1]+1 {
+			output.WriteString("--\n")
+		}
+		sep := "-"
+		if lines[n].match {
+			sep = ":"
+		}
+		output.WriteString(fmt.Sprintf("%d%s%s\n", n, sep, lines[n].text))
+	}
+}
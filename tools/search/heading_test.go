@@ -0,0 +1,70 @@
+package search
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestWriteHeadings(t *testing.T) {
+	offset := int64(16)
+	matches := []SearchMatch{
+		{File: "a.go", Line: 3, Content: "three", Context: []string{"two"}, ContextAfter: []string{"four"}},
+		// Its context overlaps that of the match before, and line 4 matches
+		{File: "a.go", Line: 4, Content: "four", Context: []string{"three"}, ContextAfter: []string{"five"}},
+		{File: "a.go", Line: 9, EndLine: 10, Content: "nine\nten", Context: []string{"eight"}},
+		{File: "b.go", Line: 1, Content: "one", ContextAfter: []string{"two"}},
+		{File: "c.bin", Offset: &offset, Content: "de ad  |..|"},
+	}
+	var output strings.Builder
+	writeHeadings(&output, matches)
+	want := `a.go
+2-two
+3:three
+4:four
+5-five
+--
+8-eight
+9:nine
+10:ten
+
+b.go
+1:one
+2-two
+
+c.bin
+0x10:de ad  |..|
+`
+	if output.String() != want {
+		t.Errorf("writeHeadings =\n%s\nwant\n%s", output.String(), want)
+	}
+
+	// Without context, lines far apart are not separated
+	output.Reset()
+	writeHeadings(&output, []SearchMatch{{File: "a.go", Line: 1, Content: "one"}, {File: "a.go", Line: 7, Content: "seven"}})
+	if want := "a.go\n1:one\n7:seven\n"; output.String() != want {
+		t.Errorf("writeHeadings without context = %q, want %q", output.String(), want)
+	}
+}
+
+func TestHandleSearchCodeHeading(t *testing.T) {
+	dir := t.TempDir()
+	content := "package main\n\nfunc a() {}\nfunc b() {}\n\n// end\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	format, lines := "heading", 1
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "func", Format: &format, ContextLines: &lines})
+	want := filepath.Join(dir, "main.go") + "\n2-\n3:func a() {}\n4:func b() {}\n5-"
+	if err != nil || result != want {
+		t.Errorf("HandleSearchCode = %q, %v; want %q", result, err, want)
+	}
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+)
+
+// hexContext is the number of bytes shown on each side of a byte match.
+const hexContext = 8
+
+var errInvalidHex = errors.New("invalid hex pattern")
+
+// WithBytes searches for a byte sequence instead of the pattern, in binary
+// files as well as text ones. Matches are reported by file offset, with the
+// bytes around them as a hex dump for their content. Case, whole word,
+// multiline and context options do not apply, and pages are not cut.
+func WithBytes(needle []byte) SearchOption {
+	return func(c *SearchConfig) {
+		c.Bytes = needle
+	}
+}
+
+// ParseHex reads a byte sequence written in hex, such as "7f 45 4c 46",
+// "7f454c46" or "0x7f 0x45". Whitespace between bytes is ignored.
+func ParseHex(s string) ([]byte, error) {
+	var digits strings.Builder
+	for _, field := range strings.Fields(s) {
+		field = strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+		digits.WriteString(field)
+	}
+	needle, err := hex.DecodeString(digits.String())
+	if err != nil || len(needle) == 0 {
+		return nil, errInvalidHex
+	}
+	return needle, nil
+}
+
+// searchBytes finds the configured byte sequence in what is read from r. The
+// content is read in blocks, keeping enough of each for the context of a match
+// that straddles two of them.
+func (e *SearchEngine) searchBytes(ctx context.Context, r io.Reader, name string, resultCount *int64) ([]SearchMatch, int64, error) {
+	needle := e.config.Bytes
+	buf := make([]byte, 0, max(max(e.config.BufferSize, minBlockSize), 4*(len(needle)+2*hexContext)))
+	var matches []SearchMatch
+	var base, bytesRead int64 // base is the file offset of buf[0]
+	next := 0                 // where in buf the search goes on
+	for {
+		if ctx.Err() != nil {
+			return matches, bytesRead, ctx.Err()
+		}
+		n, err := io.ReadFull(r, buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		bytesRead += int64(n)
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return matches, bytesRead, err
+		}
+
+		// Until the end, a match must start early enough for its context to
+		// have been read
+		limit := len(buf)
+		if !eof {
+			limit = len(buf) - len(needle) - hexContext + 1
+		}
+		for next < limit {
+			idx := bytes.Index(buf[next:], needle)
+			if idx < 0 || next+idx >= limit {
+				break
+			}
+			at := next + idx
+			if e.atLimit(resultCount, len(matches)) {
+				return matches, bytesRead, nil
+			}
+			matches = append(matches, byteMatch(name, buf, at, len(needle), base))
+			next = at + len(needle)
+		}
+		if eof {
+			return matches, bytesRead, nil
+		}
+
+		next = max(next, limit)
+		keep := max(0, next-hexContext)
+		buf = buf[:copy(buf, buf[keep:])]
+		base += int64(keep)
+		next -= keep
+	}
+}
+
+// byteMatch reports the match of size bytes at buf[at], whose first byte is
+// at the file offset base. Its content is the hex dump of the match and the
+// bytes around it, with the match as its span.
+func byteMatch(name string, buf []byte, at, size int, base int64) SearchMatch {
+	start, end := max(0, at-hexContext), min(len(buf), at+size+hexContext)
+	// Each byte takes two digits and a space
+	span := MatchSpan{Start: 3 * (at - start), End: 3*(at-start+size) - 1}
+	span.StartRune, span.EndRune = span.Start, span.End
+	offset := base + int64(at)
+	return SearchMatch{
+		File:    name,
+		Offset:  &offset,
+		Content: hexDump(buf[start:end]),
+		Spans:   []MatchSpan{span},
+	}
+}
+
+// hexDump formats b as space-separated hex bytes followed by its printable
+// ASCII characters, as in "7f 45 4c 46  |.ELF|".
+func hexDump(b []byte) string {
+	var sb strings.Builder
+	for i, c := range b {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(hex.EncodeToString([]byte{c}))
+	}
+	sb.WriteString("  |")
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteByte('|')
+	return sb.String()
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestParseHex(t *testing.T) {
+	for s, want := range map[string][]byte{
+		"7f 45 4c 46":     {0x7f, 'E', 'L', 'F'},
+		"7F454C46":        {0x7f, 'E', 'L', 'F'},
+		"0x89 0x50\t0X4e": {0x89, 'P', 'N'},
+	} {
+		if got, err := ParseHex(s); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("ParseHex(%q) = %x, %v; want %x", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "7f4", "zz", "ELF"} {
+		if _, err := ParseHex(s); err == nil {
+			t.Errorf("ParseHex(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestFindBytes(t *testing.T) {
+	dir := t.TempDir()
+	needle := []byte{0xde, 0xad, 0xbe, 0xef}
+	// The second match straddles the first block boundary
+	data := make([]byte, 3*minBlockSize)
+	copy(data[2:], needle)
+	copy(data[minBlockSize-2:], needle)
+	copy(data[len(data)-len(needle):], needle)
+	if err := os.WriteFile(filepath.Join(dir, "blob.bin"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "text.txt"), []byte("no bytes here\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Find("", dir, WithBytes(needle))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	var offsets []int64
+	for _, m := range results.Matches {
+		if m.Offset == nil {
+			t.Fatalf("match %+v has no offset", m)
+		}
+		offsets = append(offsets, *m.Offset)
+	}
+	if want := []int64{2, minBlockSize - 2, int64(len(data) - len(needle))}; !reflect.DeepEqual(offsets, want) {
+		t.Fatalf("offsets = %v, want %v", offsets, want)
+	}
+
+	first := results.Matches[0]
+	if want := "00 00 de ad be ef 00 00 00 00 00 00 00 00  |..............|"; first.Content != want {
+		t.Errorf("content = %q, want %q", first.Content, want)
+	}
+	if span := first.Spans[0]; first.Content[span.Start:span.End] != "de ad be ef" {
+		t.Errorf("span %+v covers %q, want the match", span, first.Content[span.Start:span.End])
+	}
+	if last := results.Matches[2]; !strings.HasPrefix(last.Content, "00 00 00 00 00 00 00 00 de ad be ef  |") {
+		t.Errorf("content at the end = %q, want no bytes after the match", last.Content)
+	}
+
+	results, err = Find("", dir, WithBytes(needle), WithMaxPerFile(2))
+	if err != nil || results.Count() != 2 {
+		t.Errorf("Find with 2 per file found %d matches, %v", results.Count(), err)
+	}
+}
+
+func TestHandleSearchCodeHex(t *testing.T) {
+	dir := t.TempDir()
+	elf := append([]byte{0x7f, 'E', 'L', 'F', 2, 1, 1, 0}, make([]byte, 32)...)
+	if err := os.WriteFile(filepath.Join(dir, "prog"), elf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	hex := true
+
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "7f 45 4c 46", Hex: &hex})
+	want := filepath.Join(dir, "prog") + ":0x0:7f 45 4c 46 02 01 01 00 00 00 00 00  |.ELF........|"
+	if err != nil || result != want {
+		t.Errorf("HandleSearchCode = %q, %v; want %q", result, err, want)
+	}
+
+	// Without hex, the binary file is skipped
+	if result, _ := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "ELF"}); result != "" {
+		t.Errorf("HandleSearchCode without hex = %q, want no matches", result)
+	}
+
+	if result, _ := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "ELF", Hex: &hex}); !strings.HasPrefix(result, "Invalid hex pattern") {
+		t.Errorf("HandleSearchCode with a bad hex pattern = %q", result)
+	}
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gocreate/tools/gitignore"
+)
+
+// ignoreFilter applies the .gitignore, .ignore and .rgignore files of the
+// repository containing the search path to a walk of it.
+type ignoreFilter struct {
+	matcher *gitignore.Matcher
+	root    string // the repository root, or the search path outside a repository
+	prefix  string // the search path relative to root, slash-separated
+}
+
+// newIgnoreFilter loads the ignore files from the repository root down to
+// searchPath. Those below it are loaded by skipDir as the walk reaches them.
+// It returns nil when searchPath is not a directory or is itself ignored, as
+// searching it was asked for explicitly.
+func newIgnoreFilter(searchPath string) *ignoreFilter {
+	abs, err := filepath.Abs(searchPath)
+	if err != nil {
+		return nil
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		return nil
+	}
+	root := repositoryRoot(abs)
+	prefix, err := filepath.Rel(root, abs)
+	if err != nil {
+		return nil
+	}
+	f := &ignoreFilter{matcher: gitignore.Load(root), root: root, prefix: filepath.ToSlash(prefix)}
+	if f.prefix == "." {
+		f.prefix = ""
+		return f
+	}
+	if f.matcher.Ignored(f.prefix, true) {
+		return nil
+	}
+	dir := ""
+	for _, part := range strings.Split(f.prefix, "/") {
+		dir = path.Join(dir, part)
+		f.matcher.LoadDir(root, dir)
+	}
+	return f
+}
+
+// repositoryRoot returns the nearest directory at or above dir holding a .git
+// entry, or dir itself when there is none.
+func repositoryRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// rel returns the path of p, found by walking searchPath, relative to the
+// repository root.
+func (f *ignoreFilter) rel(searchPath, p string) string {
+	rel, err := filepath.Rel(searchPath, p)
+	if err != nil {
+		rel = p
+	}
+	return path.Join(f.prefix, filepath.ToSlash(rel))
+}
+
+// skipDir reports whether the walk should skip the directory at rel. When it
+// does not, the directory's ignore files are loaded for the files below it.
+func (f *ignoreFilter) skipDir(rel string) bool {
+	if path.Base(rel) == ".git" || f.matcher.Ignored(rel, true) {
+		return true
+	}
+	f.matcher.LoadDir(f.root, rel)
+	return false
+}
+
+// skipFile reports whether the file at rel is ignored.
+func (f *ignoreFilter) skipFile(rel string) bool {
+	return f.matcher.Ignored(rel, false)
+}
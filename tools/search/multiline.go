@@ -0,0 +1,106 @@
+package search
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+)
+
+// In multiline mode a file is held in memory whole, so files larger than
+// maxMultilineSize are skipped. It is a variable so tests can lower it.
+var maxMultilineSize int64 = 32 * 1024 * 1024
+
+// searchContent searches all of r at once, so a match may span lines. Each
+// match reports the lines from its first to its last; matches on the same or
+// adjacent lines are reported as one, as rg --multiline does.
+func (e *SearchEngine) searchContent(ctx context.Context, r io.Reader, name string, resultCount *int64) ([]SearchMatch, int64, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxMultilineSize+1))
+	bytesRead := int64(len(data))
+	if err != nil {
+		return nil, bytesRead, err
+	}
+	if bytesRead > maxMultilineSize {
+		return nil, bytesRead, nil
+	}
+	if ctx.Err() != nil {
+		return nil, bytesRead, ctx.Err()
+	}
+	text := string(data)
+
+	// starts holds the offset of each line; line n begins at starts[n-1]
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' && i+1 < len(text) {
+			starts = append(starts, i+1)
+		}
+	}
+	lineOf := func(offset int) int {
+		return sort.Search(len(starts), func(i int) bool { return starts[i] > offset })
+	}
+	lines := func(first, last int) string {
+		end := len(text)
+		if last < len(starts) {
+			end = starts[last] - 1
+		} else if strings.HasSuffix(text, "\n") {
+			end--
+		}
+		spanned := strings.Split(text[starts[first-1]:end], "\n")
+		for i, line := range spanned {
+			spanned[i] = strings.TrimSuffix(line, "\r")
+		}
+		return strings.Join(spanned, "\n")
+	}
+
+	var matches []SearchMatch
+	var locs [][][]int // the matches merged into each, as offsets into text
+	for _, loc := range e.pattern.FindAllStringIndex(text, -1) {
+		first := lineOf(loc[0])
+		last := first
+		if loc[1] > loc[0] {
+			last = lineOf(loc[1] - 1)
+		}
+
+		if n := len(matches); n > 0 && first <= matches[n-1].EndLine+1 {
+			prev := &matches[n-1]
+			locs[n-1] = append(locs[n-1], loc)
+			if last > prev.EndLine {
+				prev.EndLine = last
+				prev.Content = lines(prev.Line, last)
+			}
+			continue
+		}
+
+		if e.atLimit(resultCount, len(matches)) {
+			break
+		}
+		match := SearchMatch{
+			File:    name,
+			Line:    first,
+			EndLine: last,
+			Column:  loc[0] - starts[first-1] + 1,
+			Content: lines(first, last),
+		}
+		if e.config.BeforeContext > 0 && first > 1 {
+			match.Context = strings.Split(lines(max(1, first-e.config.BeforeContext), first-1), "\n")
+		}
+		matches = append(matches, match)
+		locs = append(locs, [][]int{loc})
+	}
+	for i := range matches {
+		// Spans are offsets into the content, which has no \r line endings
+		lineStart := starts[matches[i].Line-1]
+		for _, loc := range locs[i] {
+			loc[0] = contentOffset(text[lineStart:], loc[0]-lineStart)
+			loc[1] = contentOffset(text[lineStart:], loc[1]-lineStart)
+		}
+		matches[i].Spans = newSpans(matches[i].Content, locs[i])
+		if last := matches[i].EndLine; e.config.AfterContext > 0 && last < len(starts) {
+			matches[i].ContextAfter = strings.Split(lines(last+1, min(len(starts), last+e.config.AfterContext)), "\n")
+		}
+		if matches[i].EndLine == matches[i].Line {
+			matches[i].EndLine = 0 // a single line, as in line-by-line mode
+		}
+	}
+	return matches, bytesRead, nil
+}
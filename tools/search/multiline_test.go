@@ -0,0 +1,87 @@
+package search
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestSearchMultiline(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	content := "package main\n\nfunc main() {\n\tHandle()\n}\n\ntype point struct {\r\n\tx, y int\r\n}\r\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		options []SearchOption
+		want    []SearchMatch
+	}{
+		{"signature and brace", `func \w+\(\) \{\n\tHandle`, nil, []SearchMatch{
+			{File: file, Line: 3, EndLine: 4, Column: 1, Content: "func main() {\n\tHandle()", Spans: []MatchSpan{{0, 21, 0, 21}}},
+		}},
+		{"struct literal with context", `struct \{\r?\n[^}]*\}`, []SearchOption{WithContextLines(1)}, []SearchMatch{
+			{File: file, Line: 7, EndLine: 9, Column: 12, Content: "type point struct {\n\tx, y int\n}", Context: []string{""}, Spans: []MatchSpan{{11, 31, 11, 31}}},
+		}},
+		{"adjacent matches merge", `main|Handle`, nil, []SearchMatch{
+			{File: file, Line: 1, Column: 9, Content: "package main", Spans: []MatchSpan{{8, 12, 8, 12}}},
+			{File: file, Line: 3, EndLine: 4, Column: 6, Content: "func main() {\n\tHandle()", Spans: []MatchSpan{{5, 9, 5, 9}, {15, 21, 15, 21}}},
+		}},
+		{"literal", "Handle()", []SearchOption{WithIgnoreCase()}, []SearchMatch{
+			{File: file, Line: 4, Column: 2, Content: "\tHandle()", Spans: []MatchSpan{{1, 7, 1, 7}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Find(tt.pattern, dir, append(tt.options, WithMultiline())...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if !reflect.DeepEqual(results.Matches, tt.want) {
+				t.Errorf("Matches = %+v\nwant %+v", results.Matches, tt.want)
+			}
+		})
+	}
+
+	// Line by line, a pattern spanning lines never matches
+	results, err := Find(`\{\n\tHandle`, dir)
+	if err != nil || results.HasMatches() {
+		t.Errorf("Find without multiline = %+v, %v", results, err)
+	}
+
+	old := maxMultilineSize
+	maxMultilineSize = int64(len(content)) - 1
+	defer func() { maxMultilineSize = old }()
+	if results, err := Find("main", dir, WithMultiline()); err != nil || results.HasMatches() {
+		t.Errorf("Find in a file over the size limit = %+v, %v", results, err)
+	}
+}
+
+func TestHandleSearchCodeMultiline(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	multiline := true
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: `package \w+|\{\n\}`, Multiline: &multiline})
+	if err != nil {
+		t.Fatalf("HandleSearchCode failed: %v", err)
+	}
+	want := file + ":1:package main\n" + file + ":3-4:func main() {\n}"
+	if result != want {
+		t.Errorf("HandleSearchCode = %q, want %q", result, want)
+	}
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Cursor marks where a page of matches ended: the file and line of its last
+// match. The next page starts with the first match after it in path order.
+type Cursor struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// errInvalidCursor is returned by DecodeCursor for a string Encode did not
+// produce.
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Encode returns the cursor as an opaque string for a follow-up call.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a string returned by Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.File == "" || c.Line < 1 {
+		return nil, errInvalidCursor
+	}
+	return &c, nil
+}
+
+// WithPagination returns matches in path order, starting after the cursor
+// when it is not nil, and sets NextCursor when more than MaxResults remain.
+// Every page is the same for the same tree, as the whole tree is searched
+// for it rather than stopping at the first MaxResults matches found. It has
+// no effect on ranked searches.
+func WithPagination(after *Cursor) SearchOption {
+	return func(c *SearchConfig) {
+		c.Paginate = true
+		c.After = after
+	}
+}
+
+// precedes reports whether a match at file and line comes at or before the
+// cursor in path order, and so belongs to an earlier page.
+func (c *Cursor) precedes(file string, line int) bool {
+	if c == nil {
+		return false
+	}
+	if file != c.File {
+		return file < c.File
+	}
+	return line <= c.Line
+}
+
+// skipsFile reports whether every match in the file at path belongs to an
+// earlier page. An archive is kept while the cursor is inside it, as its
+// entries are reported as archive!entry.
+func (c *Cursor) skipsFile(path string) bool {
+	return c != nil && path < c.File && !strings.HasPrefix(c.File, path+"!")
+}
+
+// pageMatches reduces results, sorted in path order, to the limit matches
+// after the cursor, and sets NextCursor when more follow.
+func pageMatches(results *SearchResults, after *Cursor, limit int) {
+	matches := results.Matches[:0]
+	for _, m := range results.Matches {
+		if !after.precedes(m.File, m.Line) {
+			matches = append(matches, m)
+		}
+	}
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+		last := matches[limit-1]
+		results.NextCursor = Cursor{File: last.File, Line: last.Line}.Encode()
+	}
+	results.Matches = matches
+	results.Stats.MatchesFound = len(matches)
+}
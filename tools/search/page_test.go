@@ -0,0 +1,119 @@
+package search
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestCursor(t *testing.T) {
+	c := Cursor{File: "/src/a b/main.go", Line: 12}
+	got, err := DecodeCursor(c.Encode())
+	if err != nil || *got != c {
+		t.Errorf("DecodeCursor(Encode()) = %+v, %v; want %+v", got, err, c)
+	}
+	for _, s := range []string{"", "not base64!", Cursor{File: "a.go"}.Encode(), "e30"} {
+		if _, err := DecodeCursor(s); err == nil {
+			t.Errorf("DecodeCursor(%q) succeeded, want an error", s)
+		}
+	}
+
+	if !c.precedes("/src/a b/main.go", 12) || c.precedes("/src/a b/main.go", 13) || !c.precedes("/src/a", 99) || c.precedes("/src/b", 1) {
+		t.Error("precedes does not follow path order")
+	}
+	archive := Cursor{File: "/src/lib.zip!pkg/a.go", Line: 3}
+	if archive.skipsFile("/src/lib.zip") || !archive.skipsFile("/src/a.go") || archive.skipsFile("/src/z.go") {
+		t.Error("skipsFile does not keep the archive the cursor is in")
+	}
+}
+
+func TestFindPagination(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 12; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("d%d", i%3))
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := strings.Repeat(fmt.Sprintf("needle %d\nhay\n", i), i%4+1)
+		if err := os.WriteFile(filepath.Join(sub, fmt.Sprintf("f%d.txt", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	engines := map[string][]SearchOption{"builtin": nil}
+	if rg, err := exec.LookPath("rg"); err == nil {
+		engines["ripgrep"] = []SearchOption{WithRipgrep(rg)}
+	}
+	for name, engine := range engines {
+		all, err := Find("needle", dir, append([]SearchOption{WithMaxResults(0)}, engine...)...)
+		if err != nil {
+			t.Fatalf("%s: Find failed: %v", name, err)
+		}
+
+		var paged []SearchMatch
+		var after *Cursor
+		for pages := 1; ; pages++ {
+			results, err := Find("needle", dir, append([]SearchOption{WithMaxResults(7), WithPagination(after)}, engine...)...)
+			if err != nil {
+				t.Fatalf("%s: Find failed on page %d: %v", name, pages, err)
+			}
+			if results.Count() > 7 {
+				t.Fatalf("%s: page %d has %d matches, want at most 7", name, pages, results.Count())
+			}
+			paged = append(paged, results.Matches...)
+			if results.NextCursor == "" {
+				break
+			}
+			if after, err = DecodeCursor(results.NextCursor); err != nil {
+				t.Fatalf("%s: DecodeCursor failed: %v", name, err)
+			}
+			if pages > 10 {
+				t.Fatalf("%s: pagination does not end", name)
+			}
+		}
+		if !reflect.DeepEqual(paged, all.Matches) {
+			t.Errorf("%s: pages hold %d matches, want the %d of an unlimited search", name, len(paged), all.Count())
+		}
+	}
+}
+
+func TestHandleSearchCodePagination(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("needle\nneedle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	paginate, maxResults := true, 3
+
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "needle", Paginate: &paginate, MaxResults: &maxResults})
+	if err != nil {
+		t.Fatalf("HandleSearchCode failed: %v", err)
+	}
+	cursor := regexp.MustCompile(`cursor "([^"]+)"`).FindStringSubmatch(result)
+	if cursor == nil || !strings.HasPrefix(result, filepath.Join(dir, "a.txt")+":1:needle\n") {
+		t.Fatalf("first page = %q, want a.txt first and a cursor", result)
+	}
+
+	result, err = HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "needle", Cursor: &cursor[1], MaxResults: &maxResults})
+	if want := filepath.Join(dir, "b.txt") + ":2:needle"; err != nil || result != want {
+		t.Errorf("second page = %q, %v; want %q", result, err, want)
+	}
+
+	bad := "nope"
+	if result, _ := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "needle", Cursor: &bad}); !strings.Contains(result, "Invalid cursor") {
+		t.Errorf("HandleSearchCode with a bad cursor = %q, want an invalid cursor message", result)
+	}
+}
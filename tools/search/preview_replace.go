@@ -0,0 +1,203 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/render"
+	"gocreate/tools/request"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// defaultPreviewLines is the number of changed lines preview_replace shows
+// unless maxLines says otherwise.
+const defaultPreviewLines = 200
+
+// PreviewReplaceArgs defines the arguments for the preview_replace tool. They
+// are those of replace_in_files, so a preview is applied by passing the same
+// arguments to it.
+type PreviewReplaceArgs struct {
+	Path          string   `json:"path" description:"The directory path to search within." required:"true"`
+	Pattern       string   `json:"pattern" description:"The text or regex pattern to replace. Matches are found line by line." required:"true"`
+	Replacement   string   `json:"replacement" description:"The replacement text. In regex mode, $1 or ${name} expand to capture groups." required:"true"`
+	Regex         *bool    `json:"regex,omitempty" description:"Treat pattern as a regular expression. Defaults to false (literal text)."`
+	IgnoreCase    *bool    `json:"ignoreCase,omitempty" description:"Match case-insensitively."`
+	FilePattern   *string  `json:"filePattern,omitempty" description:"Optional glob pattern to filter files, matched against the file name or its path relative to path; ** spans directories (e.g., '*.go', 'src/**/*.ts')."`
+	Exclude       []string `json:"exclude,omitempty" description:"Optional glob patterns for files or directories to skip (e.g., 'vendor', '*_test.go')."`
+	IncludeHidden *bool    `json:"includeHidden,omitempty" description:"Include hidden files and directories."`
+	MaxPerFile    *int     `json:"maxPerFile,omitempty" description:"Optional maximum number of replacements per file."`
+	MaxLines      *int     `json:"maxLines,omitempty" description:"Maximum number of changed lines to show. Defaults to 200; the totals still count every line."`
+	Plain         *bool    `json:"plain,omitempty" description:"If true, render the preview as plain text. Defaults to the plainOutput config value."`
+	TimeoutMs     *int     `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds."`
+}
+
+// LinePreview is a matched line and what the replacement makes of it.
+type LinePreview struct {
+	Line         int    `json:"line"`
+	Before       string `json:"before"`
+	After        string `json:"after"`
+	Replacements int    `json:"replacements"`
+}
+
+// FilePreview holds the changed lines of one file, in line order.
+type FilePreview struct {
+	File  string        `json:"file"`
+	Lines []LinePreview `json:"lines"`
+}
+
+// previewReplace walks the engine's search path and returns the lines that
+// replacing re with replacement would change, up to limit replacements per
+// file when limit is positive, along with the number of replacements made.
+// Nothing is written.
+func previewReplace(ctx context.Context, engine *SearchEngine, re *regexp.Regexp, replacement string, expand bool, limit int) ([]FilePreview, int, error) {
+	var previews []FilePreview
+	total := 0
+	err := engine.walk(ctx, func(path string) error {
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&os.ModeSymlink != 0 {
+			return nil // replace_in_files skips them too
+		}
+		lines, n, err := previewFile(ctx, path, re, replacement, expand, limit)
+		if err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			previews = append(previews, FilePreview{File: path, Lines: lines})
+			total += n
+		}
+		return nil
+	})
+	sort.Slice(previews, func(i, j int) bool { return previews[i].File < previews[j].File })
+	return previews, total, err
+}
+
+// previewFile applies the replacement to each line of path as replace_in_files
+// does to files it streams, and returns the lines it changes.
+func previewFile(ctx context.Context, path string, re *regexp.Regexp, replacement string, expand bool, limit int) ([]LinePreview, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil // unreadable files are left out, as by search_code
+	}
+	defer f.Close()
+
+	var lines []LinePreview
+	total := 0
+	r := bufio.NewReaderSize(f, 64*1024)
+	for number := 1; ; number++ {
+		if ctx.Err() != nil {
+			return lines, total, ctx.Err()
+		}
+		line, err := r.ReadString('\n')
+		if line != "" && (limit <= 0 || total < limit) {
+			text := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			remaining := 0
+			if limit > 0 {
+				remaining = limit - total
+			}
+			updated, n := replaceLimited(text, re, replacement, expand, remaining)
+			if n > 0 && updated != text {
+				lines = append(lines, LinePreview{Line: number, Before: text, After: updated, Replacements: n})
+				total += n
+			}
+		}
+		if err == io.EOF {
+			return lines, total, nil
+		}
+		if err != nil {
+			return nil, 0, nil
+		}
+	}
+}
+
+// renderPreview writes the changed lines of each file as one-line hunks of a
+// unified diff, stopping after maxLines lines.
+func renderPreview(previews []FilePreview, maxLines int, plain bool) string {
+	var sb strings.Builder
+	shown := 0
+	for _, p := range previews {
+		if shown >= maxLines {
+			break
+		}
+		if plain {
+			sb.WriteString(fmt.Sprintf("\nChanges in %s.\n", p.File))
+		} else {
+			sb.WriteString(fmt.Sprintf("\n--- %s\n+++ %s\n", p.File, p.File))
+		}
+		for _, l := range p.Lines {
+			if shown >= maxLines {
+				break
+			}
+			if plain {
+				sb.WriteString(fmt.Sprintf("Line %d.\n", l.Line))
+			} else {
+				sb.WriteString(fmt.Sprintf("@@ -%d,1 +%d,1 @@\n", l.Line, l.Line))
+			}
+			sb.WriteString(render.DiffLine(plain, '-', l.Before) + "\n")
+			sb.WriteString(render.DiffLine(plain, '+', l.After) + "\n")
+			shown++
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// HandlePreviewReplace implements the preview_replace tool.
+func HandlePreviewReplace(ctx *server.Context, args PreviewReplaceArgs) (string, error) {
+	ctx.Logger.Info("Handling preview_replace tool call")
+
+	re, expand, msg := replacePattern(args.Pattern, args.Regex, args.IgnoreCase)
+	if msg != "" {
+		return msg, nil
+	}
+	engine := replaceEngine(args.Path, args.Pattern, args.FilePattern, args.Exclude, args.IncludeHidden)
+	limit := 0
+	if args.MaxPerFile != nil && *args.MaxPerFile > 0 {
+		limit = *args.MaxPerFile
+	}
+	maxLines := defaultPreviewLines
+	if args.MaxLines != nil && *args.MaxLines > 0 {
+		maxLines = *args.MaxLines
+	}
+
+	walkCtx, stop := request.Context(ctx)
+	defer stop()
+	if args.TimeoutMs != nil && *args.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		walkCtx, cancel = context.WithTimeout(walkCtx, time.Duration(*args.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+	previews, total, err := previewReplace(walkCtx, engine, re, args.Replacement, expand, limit)
+	if err == context.DeadlineExceeded {
+		ctx.Logger.Info("preview_replace timed out", "path", args.Path)
+		return i18n.T(ctx, i18n.SearchTimedOut), nil
+	}
+	if err != nil {
+		ctx.Logger.Info("Error previewing replacements", "error", err)
+		return "", fmt.Errorf("preview failed: %v", err)
+	}
+	if len(previews) == 0 {
+		return i18n.T(ctx, i18n.PreviewReplaceNoMatches, args.Pattern), nil
+	}
+
+	lines := 0
+	for _, p := range previews {
+		lines += len(p.Lines)
+	}
+	var sb strings.Builder
+	sb.WriteString(i18n.T(ctx, i18n.PreviewReplaceSummary, total, lines, len(previews)))
+	if lines > maxLines {
+		sb.WriteString(" " + i18n.T(ctx, i18n.PreviewReplaceTruncated, maxLines))
+	}
+	sb.WriteString("\n" + renderPreview(previews, maxLines, render.Plain(ctx, args.Plain)))
+
+	ctx.Logger.Info("preview_replace completed", "files", len(previews), "lines", lines, "replacements", total)
+	return sb.String(), nil
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestPreviewReplace(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":       "package main\r\n\r\nfunc oldName() {}\r\n\r\nfunc main() { oldName(); oldName() }\r\n",
+		"sub/helper.go": "package sub\n\n// calls oldName",
+		"vendor/lib.go": "func oldName() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine := replaceEngine(dir, "oldName", nil, []string{"vendor"}, nil)
+	re := regexp.MustCompile("oldName")
+
+	previews, total, err := previewReplace(context.Background(), engine, re, "newName", false, 0)
+	if err != nil {
+		t.Fatalf("previewReplace failed: %v", err)
+	}
+	want := []FilePreview{
+		{File: filepath.Join(dir, "main.go"), Lines: []LinePreview{
+			{Line: 3, Before: "func oldName() {}", After: "func newName() {}", Replacements: 1},
+			{Line: 5, Before: "func main() { oldName(); oldName() }", After: "func main() { newName(); newName() }", Replacements: 2},
+		}},
+		{File: filepath.Join(dir, "sub", "helper.go"), Lines: []LinePreview{
+			{Line: 3, Before: "// calls oldName", After: "// calls newName", Replacements: 1},
+		}},
+	}
+	if total != 4 || !reflect.DeepEqual(previews, want) {
+		t.Errorf("previewReplace = %+v, %d\nwant %+v, 4", previews, total, want)
+	}
+
+	// maxPerFile counts across the lines of a file
+	previews, total, _ = previewReplace(context.Background(), engine, re, "newName", false, 2)
+	if total != 3 || len(previews[0].Lines) != 2 || previews[0].Lines[1].After != "func main() { newName(); oldName() }" {
+		t.Errorf("previewReplace with a limit of 2 = %+v, %d", previews, total)
+	}
+
+	for name, content := range files {
+		if got, _ := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); string(got) != content {
+			t.Errorf("previewReplace changed %s", name)
+		}
+	}
+}
+
+func TestHandlePreviewReplace(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.go")
+	if err := os.WriteFile(file, []byte("a := get(\"x\")\nb := get(\"y\")\nc := 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	regex := true
+	result, err := HandlePreviewReplace(ctx, PreviewReplaceArgs{Path: dir, Pattern: `get\("(\w)"\)`, Replacement: `lookup("$1", nil)`, Regex: &regex})
+	if err != nil {
+		t.Fatalf("HandlePreviewReplace failed: %v", err)
+	}
+	want := "--- " + file + "\n+++ " + file + "\n" +
+		"@@ -1,1 +1,1 @@\n-a := get(\"x\")\n+a := lookup(\"x\", nil)\n" +
+		"@@ -2,1 +2,1 @@\n-b := get(\"y\")\n+b := lookup(\"y\", nil)"
+	if !strings.HasPrefix(result, "2 replacements on 2 lines in 1 files") || !strings.HasSuffix(result, want) {
+		t.Errorf("HandlePreviewReplace =\n%s\nwant it to end with\n%s", result, want)
+	}
+
+	maxLines := 1
+	result, _ = HandlePreviewReplace(ctx, PreviewReplaceArgs{Path: dir, Pattern: "get", Replacement: "lookup", MaxLines: &maxLines})
+	if !strings.Contains(result, "Showing the first 1 lines") || strings.Contains(result, "b := lookup") {
+		t.Errorf("HandlePreviewReplace with maxLines 1 =\n%s", result)
+	}
+
+	result, _ = HandlePreviewReplace(ctx, PreviewReplaceArgs{Path: dir, Pattern: "missing", Replacement: "x"})
+	if !strings.Contains(result, "No line matches") {
+		t.Errorf("HandlePreviewReplace without matches = %q", result)
+	}
+}
@@ -0,0 +1,109 @@
+package search
+
+import (
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// rankPoolFactor is how many times MaxResults matches a ranked search
+// collects before ranking, so the best hits are not cut off by walk order.
+const rankPoolFactor = 10
+
+// Weights of the ranking heuristic.
+const (
+	rankNameMatch  = 3.0 // the file name contains the pattern
+	rankDepth      = 0.5 // subtracted per directory below the search root
+	rankTestFile   = 2.0 // subtracted for test files and test data
+	rankPerMatches = 1.0 // added per doubling of the matches in the file
+)
+
+// WithRanking orders matches by relevance instead of by path: files whose
+// name matches the pattern, shallow files, source rather than test files and
+// files with many matches come first. Matches in a file stay together in line
+// order.
+func WithRanking() SearchOption {
+	return func(c *SearchConfig) {
+		c.Rank = true
+	}
+}
+
+// rankMatches sorts matches, found under root, by the score of their file.
+// nameMatches reports whether a file name contains the search pattern.
+func rankMatches(matches []SearchMatch, root string, nameMatches func(string) bool) {
+	counts := make(map[string]int)
+	for _, m := range matches {
+		counts[m.File]++
+	}
+	scores := make(map[string]float64, len(counts))
+	for file, count := range counts {
+		scores[file] = fileScore(file, root, count, nameMatches)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.File != b.File {
+			if scores[a.File] != scores[b.File] {
+				return scores[a.File] > scores[b.File]
+			}
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+}
+
+// fileScore is the relevance of a file with count matches.
+func fileScore(file, root string, count int, nameMatches func(string) bool) float64 {
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		rel = file
+	}
+	rel = filepath.ToSlash(rel)
+
+	score := rankPerMatches * math.Log2(1+float64(count))
+	score -= rankDepth * float64(strings.Count(rel, "/"))
+	if nameMatches(rel[strings.LastIndexAny(rel, "/!")+1:]) {
+		score += rankNameMatch
+	}
+	if isTestPath(rel) {
+		score -= rankTestFile
+	}
+	return score
+}
+
+// isTestPath reports whether a slash-separated path is a test file or lies in
+// a test directory, by the naming conventions of common languages.
+func isTestPath(rel string) bool {
+	for _, dir := range strings.Split(strings.ToLower(rel), "/") {
+		switch dir {
+		case "test", "tests", "__tests__", "testdata", "spec", "specs":
+			return true
+		}
+	}
+	name := strings.ToLower(rel[strings.LastIndex(rel, "/")+1:])
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.HasSuffix(stem, "_test") || strings.HasSuffix(stem, ".test") ||
+		strings.HasSuffix(stem, ".spec") || strings.HasPrefix(stem, "test_")
+}
+
+// nameMatcher returns a function reporting whether a file name contains the
+// search pattern, the way the content search would match it.
+func nameMatcher(c *SearchConfig) func(string) bool {
+	if searchesLiteral(c, c.Pattern) {
+		if c.IgnoreCase {
+			pattern := strings.ToLower(c.Pattern)
+			return func(name string) bool { return strings.Contains(strings.ToLower(name), pattern) }
+		}
+		return func(name string) bool { return strings.Contains(name, c.Pattern) }
+	}
+	pattern := regexpSource(c, c.Pattern)
+	if c.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return func(string) bool { return false }
+	}
+	return re.MatchString
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsTestPath(t *testing.T) {
+	tests := map[string]bool{
+		"search/search_code_test.go": true,
+		"src/app.spec.ts":            true,
+		"src/app.test.js":            true,
+		"tests/helpers.py":           true,
+		"pkg/testdata/input.txt":     true,
+		"test_config.py":             true,
+		"src/app.ts":                 false,
+		"contest/entry.go":           false,
+		"attestation.go":             false,
+	}
+	for path, want := range tests {
+		if got := isTestPath(path); got != want {
+			t.Errorf("isTestPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestFindRanked(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a/b/c/deep.go":     "handler\n",
+		"handler.go":        "func handler() {}\n",
+		"server_test.go":    "handler\nhandler\n",
+		"server.go":         "handler\n",
+		"util/dense.go":     "handler\nhandler\nhandler\nhandler\nhandler\nhandler\nhandler\n",
+		"aaa/first_by_path": "handler\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := Find("handler", dir, WithRanking())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	var order []string
+	for _, m := range results.Matches {
+		rel, _ := filepath.Rel(dir, m.File)
+		if len(order) == 0 || order[len(order)-1] != filepath.ToSlash(rel) {
+			order = append(order, filepath.ToSlash(rel))
+		}
+	}
+	want := []string{"handler.go", "util/dense.go", "server.go", "aaa/first_by_path", "server_test.go", "a/b/c/deep.go"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("Ranked files = %v, want %v", order, want)
+	}
+
+	// The limit applies after ranking, not in walk order
+	results, err = Find("handler", dir, WithRanking(), WithMaxResults(1), WithWorkers(1))
+	if err != nil || len(results.Matches) != 1 || filepath.Base(results.Matches[0].File) != "handler.go" {
+		t.Errorf("Ranked search with maxResults 1 = %+v, %v; want handler.go", results, err)
+	}
+}
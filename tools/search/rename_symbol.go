@@ -0,0 +1,142 @@
+package search
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// RenameSymbolArgs defines the arguments for the rename_symbol tool.
+type RenameSymbolArgs struct {
+	Path                   string   `json:"path" description:"The directory path to rename within." required:"true"`
+	OldName                string   `json:"oldName" description:"The identifier to rename." required:"true"`
+	NewName                string   `json:"newName" description:"The new identifier." required:"true"`
+	FilePattern            *string  `json:"filePattern,omitempty" description:"Optional glob pattern to filter files, matched against the file name or its path relative to path; ** spans directories (e.g., '*.go', 'src/**/*.ts')."`
+	Exclude                []string `json:"exclude,omitempty" description:"Optional glob patterns for files or directories to skip."`
+	IncludeStringsComments *bool    `json:"includeStringsComments,omitempty" description:"Also rename whole-word occurrences inside string literals and comments of Go files. Defaults to false. Non-Go files are always renamed on word boundaries."`
+	DryRun                 *bool    `json:"dryRun,omitempty" description:"If true, return the diff without writing any files."`
+	Plain                  *bool    `json:"plain,omitempty" description:"If true, render the diff as plain text. Defaults to the plainOutput config value."`
+	TimeoutMs              *int     `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds."`
+}
+
+// span is a half-open byte range within a file.
+type span struct {
+	Start, End int
+}
+
+// wordPattern matches name on word boundaries.
+func wordPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+}
+
+// wordBoundarySpans returns the matches of re in text, offset by base.
+func wordBoundarySpans(text string, re *regexp.Regexp, base int) []span {
+	var spans []span
+	for _, m := range re.FindAllStringIndex(text, -1) {
+		spans = append(spans, span{Start: base + m[0], End: base + m[1]})
+	}
+	return spans
+}
+
+// goIdentSpans tokenizes Go source and returns the positions of identifiers equal to name.
+// String, character and comment tokens are searched with textRe only when it is non-nil.
+func goIdentSpans(src []byte, name string, textRe *regexp.Regexp) []span {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	var spans []span
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		offset := file.Offset(pos)
+		switch tok {
+		case token.IDENT:
+			if lit == name {
+				spans = append(spans, span{Start: offset, End: offset + len(lit)})
+			}
+		case token.STRING, token.CHAR, token.COMMENT:
+			// The scanner strips carriage returns from raw strings and comments, so only
+			// use the literal when it maps byte for byte onto the source
+			if textRe != nil && offset+len(lit) <= len(src) && string(src[offset:offset+len(lit)]) == lit {
+				spans = append(spans, wordBoundarySpans(lit, textRe, offset)...)
+			}
+		}
+	}
+	return spans
+}
+
+// applySpans replaces every span in content with replacement.
+func applySpans(content string, spans []span, replacement string) string {
+	var sb strings.Builder
+	last := 0
+	for _, sp := range spans {
+		sb.WriteString(content[last:sp.Start])
+		sb.WriteString(replacement)
+		last = sp.End
+	}
+	sb.WriteString(content[last:])
+	return sb.String()
+}
+
+// HandleRenameSymbol implements the rename_symbol tool.
+func HandleRenameSymbol(ctx *server.Context, args RenameSymbolArgs) (string, error) {
+	ctx.Logger.Info("Handling rename_symbol tool call")
+
+	if !token.IsIdentifier(args.OldName) {
+		return fmt.Sprintf("oldName %q is not a valid identifier", args.OldName), nil
+	}
+	if !token.IsIdentifier(args.NewName) {
+		return fmt.Sprintf("newName %q is not a valid identifier", args.NewName), nil
+	}
+	if args.OldName == args.NewName {
+		return "oldName and newName are identical; nothing to rename.", nil
+	}
+
+	config := SearchConfig{SearchPath: args.Path, Pattern: args.OldName}
+	if args.FilePattern != nil {
+		WithFilePattern(*args.FilePattern)(&config)
+	}
+	WithExcludePatterns(args.Exclude...)(&config)
+	engine := &SearchEngine{config: config}
+
+	// Compile the word pattern once; it is shared by every file in the walk
+	wordRe := wordPattern(args.OldName)
+	var textRe *regexp.Regexp
+	if args.IncludeStringsComments != nil && *args.IncludeStringsComments {
+		textRe = wordRe
+	}
+
+	opts := rewriteOptions{
+		Tool:        "rename_symbol",
+		DryRun:      args.DryRun != nil && *args.DryRun,
+		Plain:       render.Plain(ctx, args.Plain),
+		AlwaysDiff:  true,
+		DiffContext: 1,
+		TimeoutMs:   args.TimeoutMs,
+	}
+	return rewriteFiles(ctx, engine, opts, func(path, content string) (string, int) {
+		// Skip files that cannot contain the name before tokenizing
+		if !strings.Contains(content, args.OldName) {
+			return content, 0
+		}
+		var spans []span
+		if filepath.Ext(path) == ".go" {
+			spans = goIdentSpans([]byte(content), args.OldName, textRe)
+		} else {
+			spans = wordBoundarySpans(content, wordRe, 0)
+		}
+		return applySpans(content, spans, args.NewName), len(spans)
+	})
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGoIdentSpans(t *testing.T) {
+	src := `package main
+
+// Process handles the request; ProcessAll is unrelated.
+func Process(x int) int {
+	s := "Process"
+	return ProcessAll(x) + Process(x)
+}
+`
+	tests := []struct {
+		name        string
+		includeText bool
+		want        string
+	}{
+		{
+			name:        "identifiers only",
+			includeText: false,
+			want: `package main
+
+// Process handles the request; ProcessAll is unrelated.
+func Handle(x int) int {
+	s := "Process"
+	return ProcessAll(x) + Handle(x)
+}
+`,
+		},
+		{
+			name:        "including strings and comments",
+			includeText: true,
+			want: `package main
+
+// Handle handles the request; ProcessAll is unrelated.
+func Handle(x int) int {
+	s := "Handle"
+	return ProcessAll(x) + Handle(x)
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var textRe *regexp.Regexp
+			if tt.includeText {
+				textRe = wordPattern("Process")
+			}
+			spans := goIdentSpans([]byte(src), "Process", textRe)
+			if got := applySpans(src, spans, "Handle"); got != tt.want {
+				t.Errorf("rename result:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordBoundarySpans(t *testing.T) {
+	text := "user_id userId user id_user user"
+	spans := wordBoundarySpans(text, wordPattern("user"), 0)
+	if got := applySpans(text, spans, "account"); got != "user_id userId account id_user account" {
+		t.Errorf("unexpected rename result: %q", got)
+	}
+}
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// ReplaceInFilesArgs defines the arguments for the replace_in_files tool.
+type ReplaceInFilesArgs struct {
+	Path          string   `json:"path" description:"The directory path to search within." required:"true"`
+	Pattern       string   `json:"pattern" description:"The text or regex pattern to replace. In files over 100 MB, which are edited line by line, a match cannot span lines." required:"true"`
+	Replacement   string   `json:"replacement" description:"The replacement text. In regex mode, $1 or ${name} expand to capture groups." required:"true"`
+	Regex         *bool    `json:"regex,omitempty" description:"Treat pattern as a regular expression. Defaults to false (literal text)."`
+	IgnoreCase    *bool    `json:"ignoreCase,omitempty" description:"Match case-insensitively."`
+	FilePattern   *string  `json:"filePattern,omitempty" description:"Optional glob pattern to filter files, matched against the file name or its path relative to path; ** spans directories (e.g., '*.go', 'src/**/*.ts')."`
+	Exclude       []string `json:"exclude,omitempty" description:"Optional glob patterns for files or directories to skip (e.g., 'vendor', '*_test.go')."`
+	IncludeHidden *bool    `json:"includeHidden,omitempty" description:"Include hidden files and directories."`
+	MaxPerFile    *int     `json:"maxPerFile,omitempty" description:"Optional maximum number of replacements per file."`
+	DryRun        *bool    `json:"dryRun,omitempty" description:"If true, return the diff without writing any files."`
+	Plain         *bool    `json:"plain,omitempty" description:"If true, render the diff as plain text. Defaults to the plainOutput config value."`
+	TimeoutMs     *int     `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds."`
+}
+
+// FileReplacement summarizes the replacements made in a single file.
+type FileReplacement struct {
+	File         string `json:"file"`
+	Replacements int    `json:"replacements"`
+}
+
+// ReplaceSummary is the summary returned by replace_in_files.
+type ReplaceSummary struct {
+	DryRun            bool              `json:"dry_run"`
+	FilesScanned      int               `json:"files_scanned"`
+	FilesChanged      int               `json:"files_changed"`
+	TotalReplacements int               `json:"total_replacements"`
+	Files             []FileReplacement `json:"files"`
+	Errors            map[string]string `json:"errors,omitempty"`
+	Skipped           map[string]string `json:"skipped,omitempty"`
+	// Streamed lists the files over 100 MB that were edited line by line. They
+	// have no diff and are not recorded in the edit journal.
+	Streamed []string `json:"streamed,omitempty"`
+}
+
+// replaceLimited replaces up to limit matches of re in content (all matches if limit <= 0).
+// When expand is true, the replacement may reference capture groups.
+func replaceLimited(content string, re *regexp.Regexp, replacement string, expand bool, limit int) (string, int) {
+	n := -1
+	if limit > 0 {
+		n = limit
+	}
+	matches := re.FindAllStringSubmatchIndex(content, n)
+	if len(matches) == 0 {
+		return content, 0
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(content[last:m[0]])
+		if expand {
+			sb.Write(re.ExpandString(nil, replacement, content, m))
+		} else {
+			sb.WriteString(replacement)
+		}
+		last = m[1]
+	}
+	sb.WriteString(content[last:])
+	return sb.String(), len(matches)
+}
+
+// replacePattern compiles the pattern of replace_in_files, quoted unless regex
+// is set, and reports whether replacements expand capture groups. A pattern
+// that cannot be used is reported by msg instead.
+func replacePattern(pattern string, regex, ignoreCase *bool) (re *regexp.Regexp, expand bool, msg string) {
+	if pattern == "" {
+		return nil, false, "pattern must not be empty"
+	}
+	expand = regex != nil && *regex
+	expr := pattern
+	if !expand {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if ignoreCase != nil && *ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := compileRegexp(expr)
+	if err != nil {
+		return nil, false, "Error compiling regex: " + err.Error()
+	}
+	return re, expand, ""
+}
+
+// replaceEngine returns the engine walking the files replace_in_files edits.
+func replaceEngine(path, pattern string, filePattern *string, exclude []string, includeHidden *bool) *SearchEngine {
+	config := SearchConfig{
+		SearchPath:    path,
+		Pattern:       pattern,
+		IncludeHidden: includeHidden != nil && *includeHidden,
+	}
+	if filePattern != nil {
+		WithFilePattern(*filePattern)(&config)
+	}
+	WithExcludePatterns(exclude...)(&config)
+	return &SearchEngine{config: config}
+}
+
+// HandleReplaceInFiles implements the replace_in_files tool.
+func HandleReplaceInFiles(ctx *server.Context, args ReplaceInFilesArgs) (string, error) {
+	ctx.Logger.Info("Handling replace_in_files tool call")
+
+	re, useRegex, msg := replacePattern(args.Pattern, args.Regex, args.IgnoreCase)
+	if msg != "" {
+		return msg, nil
+	}
+	engine := replaceEngine(args.Path, args.Pattern, args.FilePattern, args.Exclude, args.IncludeHidden)
+
+	limit := 0
+	if args.MaxPerFile != nil && *args.MaxPerFile > 0 {
+		limit = *args.MaxPerFile
+	}
+	opts := rewriteOptions{
+		Tool:        "replace_in_files",
+		DryRun:      args.DryRun != nil && *args.DryRun,
+		Plain:       render.Plain(ctx, args.Plain),
+		DiffContext: 2,
+		TimeoutMs:   args.TimeoutMs,
+	}
+	// Files over the size limit are matched one line at a time, still
+	// honouring maxPerFile across the whole file
+	opts.StreamLine = func(string) lineRewriteFunc {
+		remaining := limit
+		return func(line string) (string, int) {
+			if limit > 0 && remaining == 0 {
+				return line, 0
+			}
+			updated, n := replaceLimited(line, re, args.Replacement, useRegex, remaining)
+			remaining -= n
+			return updated, n
+		}
+	}
+	return rewriteFiles(ctx, engine, opts, func(_, content string) (string, int) {
+		return replaceLimited(content, re, args.Replacement, useRegex, limit)
+	})
+}
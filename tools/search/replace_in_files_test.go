@@ -0,0 +1,114 @@
+package search
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestReplaceLimited(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expr        string
+		replacement string
+		expand      bool
+		limit       int
+		want        string
+		wantCount   int
+	}{
+		{"literal all", "foo foo foo", "foo", "bar", false, 0, "bar bar bar", 3},
+		{"literal limited", "foo foo foo", "foo", "bar", false, 2, "bar bar foo", 2},
+		{"regex expansion", "a=1 b=2", `(\w)=(\d)`, "$2=$1", true, 0, "1=a 2=b", 2},
+		{"literal dollar kept", "x", "x", "$1", false, 0, "$1", 1},
+		{"no match", "abc", "z", "y", false, 0, "abc", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := regexp.Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Failed to compile %q: %v", tt.expr, err)
+			}
+			got, count := replaceLimited(tt.content, re, tt.replacement, tt.expand, tt.limit)
+			if got != tt.want || count != tt.wantCount {
+				t.Errorf("replaceLimited() = %q, %d; want %q, %d", got, count, tt.want, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestHandleReplaceInFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFiles := map[string]string{
+		"main.go":          "package main\n\nfunc oldName() {}\n\nfunc main() { oldName() }\n",
+		"vendor/lib.go":    "package lib\n\nfunc oldName() {}\n",
+		"notes.txt":        "oldName is referenced here too\n",
+		"sub/helper.go":    "package sub\n\n// calls oldName\n",
+		"sub/unrelated.go": "package sub\n",
+	}
+	for name, content := range testFiles {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	filePattern := "*.go"
+	dryRun := true
+
+	args := ReplaceInFilesArgs{
+		Path:        tempDir,
+		Pattern:     "oldName",
+		Replacement: "newName",
+		FilePattern: &filePattern,
+		Exclude:     []string{"vendor"},
+		DryRun:      &dryRun,
+	}
+
+	result, err := HandleReplaceInFiles(ctx, args)
+	if err != nil {
+		t.Fatalf("HandleReplaceInFiles dry run failed: %v", err)
+	}
+	if !strings.Contains(result, "+func newName() {}") {
+		t.Errorf("Expected dry run diff to contain the replacement, got:\n%s", result)
+	}
+	content, _ := os.ReadFile(filepath.Join(tempDir, "main.go"))
+	if strings.Contains(string(content), "newName") {
+		t.Fatal("Dry run should not modify files")
+	}
+
+	args.DryRun = nil
+	if _, err := HandleReplaceInFiles(ctx, args); err != nil {
+		t.Fatalf("HandleReplaceInFiles failed: %v", err)
+	}
+
+	expectations := map[string]bool{
+		"main.go":       true,
+		"sub/helper.go": true,
+		"vendor/lib.go": false, // excluded directory
+		"notes.txt":     false, // filtered by file pattern
+	}
+	for name, wantReplaced := range expectations {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if got := strings.Contains(string(content), "newName"); got != wantReplaced {
+			t.Errorf("%s: replaced = %v, want %v", name, got, wantReplaced)
+		}
+	}
+}
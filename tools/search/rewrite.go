@@ -0,0 +1,216 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gocreate/tools/edit"
+	"gocreate/tools/render"
+	"gocreate/tools/request"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// maxRewriteFileSize matches the limit of the single-file editing tools; larger files are
+// streamed when the tool supports it and skipped otherwise.
+var maxRewriteFileSize int64 = 100 * 1024 * 1024
+
+// rewriteOptions configures a rewriteFiles run.
+type rewriteOptions struct {
+	Tool        string
+	DryRun      bool
+	Plain       bool
+	AlwaysDiff  bool // include diffs in the result even when files are written
+	DiffContext int
+	TimeoutMs   *int
+	// StreamLine, if set, returns the per-line rewrite for a file over
+	// maxRewriteFileSize, which is then edited line by line with
+	// edit.StreamEdit instead of being skipped.
+	StreamLine func(path string) lineRewriteFunc
+}
+
+// lineRewriteFunc returns the new text of a line, without its ending, and the
+// number of changes made.
+type lineRewriteFunc func(line string) (string, int)
+
+// rewriteFunc returns the new content of a file and the number of changes made.
+// A count of zero leaves the file untouched.
+type rewriteFunc func(path, content string) (string, int)
+
+// rewriteFiles walks the engine's search path, applies fn to every regular file and
+// returns a JSON summary followed by the diffs. Symbolic links and files over
+// maxRewriteFileSize are skipped, unless opts.StreamLine is set. Changes are staged
+// in memory and written with edit.CommitStaged, so either every changed file is
+// written or none are. Streamed files are written one at a time afterwards, and only
+// if that commit succeeded.
+func rewriteFiles(ctx *server.Context, engine *SearchEngine, opts rewriteOptions, fn rewriteFunc) (string, error) {
+	walkCtx, stop := request.Context(ctx)
+	defer stop()
+	if opts.TimeoutMs != nil && *opts.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		walkCtx, cancel = context.WithTimeout(walkCtx, time.Duration(*opts.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	summary := ReplaceSummary{DryRun: opts.DryRun, Files: []FileReplacement{}, Errors: make(map[string]string), Skipped: make(map[string]string)}
+	var diffs []string
+	var staged []*edit.StagedFile
+	var large []string
+
+	walkErr := engine.walk(walkCtx, func(path string) error {
+		summary.FilesScanned++
+		info, err := os.Lstat(path)
+		if err != nil {
+			summary.Errors[path] = err.Error()
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			summary.Skipped[path] = "symbolic link"
+			return nil
+		}
+		if info.Size() > maxRewriteFileSize && opts.StreamLine != nil {
+			large = append(large, path)
+			return nil
+		}
+		if info.Size() > maxRewriteFileSize {
+			summary.Skipped[path] = fmt.Sprintf("larger than %d MB", maxRewriteFileSize/(1024*1024))
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			summary.Errors[path] = err.Error()
+			return nil
+		}
+
+		original := string(content)
+		updated, count := fn(path, original)
+		if count == 0 || updated == original {
+			return nil
+		}
+
+		summary.Files = append(summary.Files, FileReplacement{File: path, Replacements: count})
+		summary.TotalReplacements += count
+		if opts.DryRun || opts.AlwaysDiff {
+			diffs = append(diffs, render.UnifiedDiff(path, original, updated, opts.DiffContext, opts.Plain))
+		}
+		staged = append(staged, &edit.StagedFile{Path: path, Original: content, Content: updated, Mode: info.Mode()})
+		return nil
+	})
+
+	stopped := walkErr == context.DeadlineExceeded || walkErr == context.Canceled
+	if stopped {
+		reason := "timed out"
+		if walkErr == context.Canceled {
+			reason = "cancelled"
+		}
+		ctx.Logger.Info(opts.Tool+" "+reason, "path", engine.config.SearchPath)
+		if opts.DryRun {
+			summary.Errors[engine.config.SearchPath] = reason + " before the walk completed; the summary covers the files processed so far"
+		} else {
+			summary.Errors[engine.config.SearchPath] = reason + " before the walk completed; no files were written"
+		}
+	} else if !opts.DryRun && len(staged) > 0 {
+		if err := edit.CommitStaged(opts.Tool, staged); err != nil {
+			ctx.Logger.Info("Error writing files for "+opts.Tool, "error", err)
+			msg := err.Error() + "; no files were changed"
+			var swapErr *edit.SwapError
+			if errors.As(err, &swapErr) && len(swapErr.RollbackFailed) > 0 {
+				msg = err.Error() + "; could not restore " + swapErr.RollbackSummary()
+			}
+			summary.Errors[engine.config.SearchPath] = msg
+		} else {
+			summary.FilesChanged = len(staged)
+		}
+	}
+	if !stopped && summary.Errors[engine.config.SearchPath] == "" {
+		for _, path := range large {
+			count, err := streamFile(path, opts.StreamLine(path), opts.DryRun)
+			if err != nil {
+				summary.Errors[path] = err.Error()
+				continue
+			}
+			if count == 0 {
+				continue
+			}
+			summary.Files = append(summary.Files, FileReplacement{File: path, Replacements: count})
+			summary.TotalReplacements += count
+			summary.Streamed = append(summary.Streamed, path)
+			if !opts.DryRun {
+				summary.FilesChanged++
+			}
+		}
+	}
+
+	sort.Strings(summary.Streamed)
+	sort.Slice(summary.Files, func(i, j int) bool { return summary.Files[i].File < summary.Files[j].File })
+
+	summaryJson, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling "+opts.Tool+" summary", "error", err)
+		return "Error generating " + opts.Tool + " summary", err
+	}
+
+	ctx.Logger.Info(opts.Tool+" completed", "files", len(summary.Files), "replacements", summary.TotalReplacements, "dryRun", opts.DryRun)
+	if len(diffs) == 0 {
+		return string(summaryJson), nil
+	}
+	sort.Strings(diffs)
+	return fmt.Sprintf("%s\n\n%s", string(summaryJson), strings.Join(diffs, "\n\n")), nil
+}
+
+// streamFile applies fn to every line of path and returns the number of changes.
+// Unless dryRun is set, a changed file is rewritten with edit.StreamEdit; a dry
+// run only reads it.
+func streamFile(path string, fn lineRewriteFunc, dryRun bool) (int, error) {
+	count := 0
+	if dryRun {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		r := bufio.NewReaderSize(f, 1024*1024)
+		for {
+			line, err := r.ReadString('\n')
+			if line != "" {
+				_, n := fn(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+				count += n
+			}
+			if err == io.EOF {
+				return count, nil
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	err := edit.StreamEdit(path, "",
+		func(_ int, text, ending string, out *edit.LineWriter) error {
+			updated, n := fn(text)
+			count += n
+			out.WriteLine(updated, ending)
+			return nil
+		},
+		func(int, *edit.LineWriter) error {
+			if count == 0 {
+				return edit.ErrNoChange
+			}
+			return nil
+		})
+	if errors.Is(err, edit.ErrNoChange) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,162 @@
+package search
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestRewriteFilesSkipsSymlinksAndStreamsLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	target := filepath.Join(outside, "target.txt")
+	if err := os.WriteFile(target, []byte("foo\n"), 0644); err != nil {
+		t.Fatalf("Failed to write target: %v", err)
+	}
+	if err := os.Symlink(target, filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("foo ", 64)), 0644); err != nil {
+		t.Fatalf("Failed to write big.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "small.txt"), []byte("foo\n"), 0644); err != nil {
+		t.Fatalf("Failed to write small.txt: %v", err)
+	}
+
+	oldMax := maxRewriteFileSize
+	maxRewriteFileSize = 64
+	defer func() { maxRewriteFileSize = oldMax }()
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	result, err := HandleReplaceInFiles(ctx, ReplaceInFilesArgs{Path: dir, Pattern: "foo", Replacement: "bar"})
+	if err != nil {
+		t.Fatalf("HandleReplaceInFiles failed: %v", err)
+	}
+
+	var summary ReplaceSummary
+	if err := json.Unmarshal([]byte(result), &summary); err != nil {
+		t.Fatalf("Failed to parse summary: %v\n%s", err, result)
+	}
+	if summary.FilesChanged != 2 {
+		t.Errorf("FilesChanged = %d, want 2", summary.FilesChanged)
+	}
+	if len(summary.Skipped) != 1 {
+		t.Errorf("Expected only the link to be skipped, got %v", summary.Skipped)
+	}
+	if len(summary.Streamed) != 1 || filepath.Base(summary.Streamed[0]) != "big.txt" {
+		t.Errorf("Streamed = %v, want big.txt", summary.Streamed)
+	}
+
+	for path, want := range map[string]string{
+		target:                          "foo\n",
+		filepath.Join(dir, "small.txt"): "bar\n",
+		filepath.Join(dir, "big.txt"):   strings.Repeat("bar ", 64),
+	} {
+		content, _ := os.ReadFile(path)
+		if string(content) != want {
+			t.Errorf("%s = %q, want %q", path, content, want)
+		}
+	}
+	if fi, err := os.Lstat(filepath.Join(dir, "link.txt")); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link.txt is no longer a symbolic link")
+	}
+}
+
+func TestRewriteFilesAllOrNothing(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced for root")
+	}
+	dir := t.TempDir()
+	locked := filepath.Join(dir, "locked")
+	if err := os.MkdirAll(locked, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	files := map[string]string{
+		filepath.Join(dir, "a.txt"):    "foo\n",
+		filepath.Join(locked, "b.txt"): "foo\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	// Temporary files cannot be created next to b.txt
+	if err := os.Chmod(locked, 0555); err != nil {
+		t.Fatalf("Failed to lock directory: %v", err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	result, err := HandleReplaceInFiles(ctx, ReplaceInFilesArgs{Path: dir, Pattern: "foo", Replacement: "bar"})
+	if err != nil {
+		t.Fatalf("HandleReplaceInFiles failed: %v", err)
+	}
+	if !strings.Contains(result, "no files were changed") {
+		t.Errorf("Expected a staging failure, got:\n%s", result)
+	}
+	for path, want := range files {
+		content, _ := os.ReadFile(path)
+		if string(content) != want {
+			t.Errorf("%s was modified: %q", path, content)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("Temporary file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestReplaceInFilesStreamsLargeFilesByLine(t *testing.T) {
+	dir := t.TempDir()
+	big := filepath.Join(dir, "big.log")
+	original := "id=1 id=2\r\nid=3\r\nnone\r\nid=4"
+	if err := os.WriteFile(big, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldMax := maxRewriteFileSize
+	maxRewriteFileSize = 8
+	defer func() { maxRewriteFileSize = oldMax }()
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	regex, dryRun, maxPerFile := true, true, 3
+	args := ReplaceInFilesArgs{Path: dir, Pattern: `id=(\d)`, Replacement: "n$1", Regex: &regex, DryRun: &dryRun, MaxPerFile: &maxPerFile}
+
+	result, err := HandleReplaceInFiles(ctx, args)
+	if err != nil {
+		t.Fatalf("HandleReplaceInFiles failed: %v", err)
+	}
+	var summary ReplaceSummary
+	if err := json.Unmarshal([]byte(result), &summary); err != nil {
+		t.Fatalf("Failed to parse summary: %v\n%s", err, result)
+	}
+	if summary.TotalReplacements != 3 || summary.FilesChanged != 0 || len(summary.Streamed) != 1 {
+		t.Errorf("Dry run summary = %+v", summary)
+	}
+	if content, _ := os.ReadFile(big); string(content) != original {
+		t.Errorf("Dry run changed the file: %q", content)
+	}
+
+	dryRun = false
+	if _, err := HandleReplaceInFiles(ctx, args); err != nil {
+		t.Fatalf("HandleReplaceInFiles failed: %v", err)
+	}
+	want := "n1 n2\r\nn3\r\nnone\r\nid=4"
+	if content, _ := os.ReadFile(big); string(content) != want {
+		t.Errorf("big.log = %q, want %q", content, want)
+	}
+}
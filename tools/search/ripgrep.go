@@ -0,0 +1,368 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// Values of the searchEngine config key.
+const (
+	engineAuto    = "auto"    // rg when it can be found, otherwise the built-in engine
+	engineBuiltin = "builtin" // always the built-in engine
+	engineRipgrep = "ripgrep" // rg, falling back to the built-in engine if it is missing
+)
+
+// ripgrepBinary returns the rg executable search_code should use, or "" for
+// the built-in engine. The ripgrepPath config value is used when set,
+// otherwise rg is looked up on the PATH.
+func ripgrepBinary(ctx *server.Context) string {
+	cfg, err := config.GetCurrentConfig(ctx)
+	if err != nil {
+		return ""
+	}
+	if cfg.SearchEngine != nil && *cfg.SearchEngine == engineBuiltin {
+		return ""
+	}
+	name := "rg"
+	if cfg.RipgrepPath != nil && *cfg.RipgrepPath != "" {
+		name = *cfg.RipgrepPath
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		if cfg.SearchEngine != nil && *cfg.SearchEngine == engineRipgrep {
+			ctx.Logger.Info("ripgrep not found, using the built-in search engine", "ripgrep", name, "error", err)
+		}
+		return ""
+	}
+	return path
+}
+
+// WithRipgrep runs the search with the rg executable at path when the other
+// options allow it. Archives, documents and generated-file detection are only
+// implemented by the built-in engine, which is used for them instead, as it is
+// when rg fails. A list of files is searched by the built-in engine too, as rg
+// applies no filters to the paths it is given.
+func WithRipgrep(path string) SearchOption {
+	return func(c *SearchConfig) {
+		c.RipgrepPath = path
+	}
+}
+
+// ripgrepSupports reports whether rg can run a search with c.
+func ripgrepSupports(c *SearchConfig) bool {
+	return c.RipgrepPath != "" && !c.SearchArchives && !c.SearchDocuments && !c.SkipGenerated && len(c.Files) == 0 && len(c.Bytes) == 0 && !c.Diagnostics
+}
+
+// errRipgrepFailed reports an rg run that produced no usable results.
+var errRipgrepFailed = errors.New("ripgrep failed")
+
+// ripgrepArgs returns the rg arguments matching the built-in engine's
+// behaviour for c: ignore files only when asked for, hidden files only
+// when included, and the lines around each match as its context.
+func ripgrepArgs(c *SearchConfig) []string {
+	args := []string{"--json", "--no-config", "--no-messages"}
+	if c.UseGitignore {
+		// .gitignore, .ignore and .rgignore files and .git/info/exclude, in or
+		// out of a repository, but no global excludes
+		args = append(args, "--no-ignore-global", "--no-require-git", "--glob", "!.git")
+	} else {
+		args = append(args, "--no-ignore")
+	}
+	if c.MaxWorkers > 0 {
+		args = append(args, "--threads", strconv.Itoa(c.MaxWorkers))
+	}
+	if c.IgnoreCase {
+		args = append(args, "--ignore-case")
+	}
+	if c.IncludeHidden {
+		args = append(args, "--hidden")
+	}
+	maxSize := c.MaxFileSize
+	if c.Multiline {
+		args = append(args, "--multiline")
+		if maxSize <= 0 || maxSize > maxMultilineSize {
+			maxSize = maxMultilineSize
+		}
+	}
+	if maxSize > 0 {
+		args = append(args, "--max-filesize", strconv.FormatInt(maxSize, 10))
+	}
+	if c.MaxPerFile > 0 {
+		args = append(args, "--max-count", strconv.Itoa(c.MaxPerFile))
+	}
+	if c.BeforeContext > 0 {
+		args = append(args, "--before-context", strconv.Itoa(c.BeforeContext))
+	}
+	if c.AfterContext > 0 {
+		args = append(args, "--after-context", strconv.Itoa(c.AfterContext))
+	}
+	for _, pattern := range c.FilePatterns {
+		args = append(args, "--glob", pattern)
+	}
+	for _, pattern := range c.ExcludePatterns {
+		args = append(args, "--glob", "!"+pattern)
+	}
+	pattern := c.Pattern
+	if c.WholeWord {
+		// rg's --word-regexp differs for patterns starting or ending with a
+		// non-word character, so the pattern is wrapped as the built-in
+		// engine does
+		pattern = regexpSource(c, pattern)
+	} else if c.Literal || isLiteralPattern(pattern) {
+		args = append(args, "--fixed-strings")
+	}
+	return append(args, "--regexp", pattern, "--", ripgrepTarget(c))
+}
+
+// ripgrepTarget returns the path argument of rg. A directory is searched as
+// "." from inside it, so rg matches exclude patterns with a slash against
+// paths relative to it as the built-in engine does.
+func ripgrepTarget(c *SearchConfig) string {
+	if info, err := os.Stat(c.SearchPath); err == nil && info.IsDir() {
+		return "."
+	}
+	return c.SearchPath
+}
+
+// findRipgrep runs the search described by c with rg. The matches are the
+// ones the built-in engine reports, in the same order.
+func findRipgrep(ctx context.Context, c *SearchConfig) (*SearchResults, error) {
+	start := time.Now()
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	args := ripgrepArgs(c)
+	cmd := exec.CommandContext(runCtx, c.RipgrepPath, args...)
+	inside := args[len(args)-1] == "."
+	if inside {
+		cmd.Dir = c.SearchPath
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	var emit func(SearchMatch)
+	if c.OnMatch != nil {
+		emit = func(m SearchMatch) {
+			if inside {
+				m.File = filepath.Join(c.SearchPath, m.File)
+			}
+			c.OnMatch(m)
+		}
+	}
+	results, parseErr := parseRipgrepJSON(stdout, c, emit)
+	stopped := parseErr != nil || (c.MaxResults > 0 && len(results.Matches) >= c.MaxResults)
+	if stopped {
+		cancel() // nothing more is read; stop rg early
+	}
+	io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	// Exit status 1 means no matches; 2 means an error, such as a pattern rg
+	// cannot compile or an unreadable file, which only counts without results
+	var exitErr *exec.ExitError
+	if waitErr != nil && !stopped && errors.As(waitErr, &exitErr) && exitErr.ExitCode() == 2 && len(results.Matches) == 0 {
+		return nil, errRipgrepFailed
+	}
+
+	if inside {
+		for i := range results.Matches {
+			results.Matches[i].File = filepath.Join(c.SearchPath, results.Matches[i].File)
+		}
+	}
+	sortMatches(results.Matches)
+	results.Stats.Duration = time.Since(start)
+	results.Stats.MatchesFound = len(results.Matches)
+	return results, nil
+}
+
+// rgMessage is one line of rg --json output.
+type rgMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       rgText `json:"path"`
+		Lines      rgText `json:"lines"`
+		LineNumber int    `json:"line_number"`
+		Submatches []struct {
+			Start int `json:"start"`
+			End   int `json:"end"`
+		} `json:"submatches"`
+		Stats struct {
+			Searches      int   `json:"searches"`
+			BytesSearched int64 `json:"bytes_searched"`
+		} `json:"stats"`
+	} `json:"data"`
+}
+
+// rgText is a path or line, which rg sends base64 encoded when it is not UTF-8.
+type rgText struct {
+	Text  *string `json:"text"`
+	Bytes string  `json:"bytes"`
+}
+
+func (t rgText) String() string {
+	if t.Text != nil {
+		return *t.Text
+	}
+	b, _ := base64.StdEncoding.DecodeString(t.Bytes)
+	return string(b)
+}
+
+// parseRipgrepJSON reads rg --json output into results, stopping after
+// c.MaxResults matches when it is positive. Each match gets up to
+// c.BeforeContext preceding and c.AfterContext following lines of its file as
+// context, whether rg sent them as context or as other matches. When emit is
+// not nil, it is called with the matches of each file once the file is done.
+func parseRipgrepJSON(r io.Reader, c *SearchConfig, emit func(SearchMatch)) (*SearchResults, error) {
+	before, after, maxResults := c.BeforeContext, c.AfterContext, c.MaxResults
+	results := &SearchResults{Matches: make([]SearchMatch, 0)}
+	emitted, fileStart := 0, 0
+	flush := func() {
+		if emit != nil {
+			for _, m := range results.Matches[emitted:] {
+				emit(m)
+			}
+		}
+		emitted = len(results.Matches)
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	seen := make(map[int]string) // lines of the current file by number, for context
+	var pending []int            // matches of the current file still missing lines after them
+	limitReached := false
+
+	// follow passes line n of the current file to the matches it follows
+	follow := func(n int, line string) {
+		collecting := pending[:0]
+		for _, i := range pending {
+			m := &results.Matches[i]
+			last := max(m.Line, m.EndLine)
+			if n > last && n <= last+after {
+				m.ContextAfter = append(m.ContextAfter, line)
+			}
+			if n < last+after {
+				collecting = append(collecting, i)
+			}
+		}
+		pending = collecting
+	}
+
+	for scanner.Scan() {
+		var msg rgMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return nil, err
+		}
+		switch msg.Type {
+		case "begin":
+			clear(seen)
+			pending = nil
+			fileStart = len(results.Matches)
+		case "context":
+			line := trimLineEnding(msg.Data.Lines.String())
+			seen[msg.Data.LineNumber] = line
+			follow(msg.Data.LineNumber, line)
+		case "match":
+			// A multiline match sends all the lines it spans at once
+			raw := msg.Data.Lines.String()
+			lines := strings.Split(trimLineEnding(raw), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSuffix(line, "\r")
+				follow(msg.Data.LineNumber+i, lines[i])
+			}
+			// rg --max-count reports matches in the after context of a
+			// file's last match as matches too
+			if limitReached || (c.MaxPerFile > 0 && len(results.Matches)-fileStart >= c.MaxPerFile) {
+				for i, line := range lines {
+					seen[msg.Data.LineNumber+i] = line
+				}
+				break
+			}
+			match := SearchMatch{
+				File:    filepath.Clean(msg.Data.Path.String()),
+				Line:    msg.Data.LineNumber,
+				Column:  1,
+				Content: strings.Join(lines, "\n"),
+			}
+			if len(lines) > 1 {
+				match.EndLine = match.Line + len(lines) - 1
+			}
+			if len(msg.Data.Submatches) > 0 {
+				match.Column = msg.Data.Submatches[0].Start + 1
+			}
+			var locs [][]int
+			for _, sub := range msg.Data.Submatches {
+				locs = append(locs, []int{contentOffset(raw, sub.Start), contentOffset(raw, sub.End)})
+			}
+			match.Spans = newSpans(match.Content, locs)
+			for n := match.Line - before; n < match.Line; n++ {
+				if text, ok := seen[n]; ok {
+					match.Context = append(match.Context, text)
+				}
+			}
+			for i, line := range lines {
+				seen[match.Line+i] = line
+			}
+			if after > 0 {
+				pending = append(pending, len(results.Matches))
+			}
+			results.Matches = append(results.Matches, match)
+			limitReached = maxResults > 0 && len(results.Matches) >= maxResults
+		case "end":
+			pending = nil
+			flush()
+		case "summary":
+			results.Stats.FilesScanned = msg.Data.Stats.Searches
+			results.Stats.BytesScanned = msg.Data.Stats.BytesSearched
+		}
+		if limitReached && len(pending) == 0 {
+			flush()
+			return results, nil
+		}
+	}
+	flush()
+	return results, scanner.Err()
+}
+
+// trimLineEnding drops the line ending rg keeps on each line, as the
+// built-in engine's line scanner does.
+func trimLineEnding(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r")
+}
+
+// sortMatches orders matches by file path and line number.
+func sortMatches(matches []SearchMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].File == matches[j].File {
+			if matches[i].Offset != nil && matches[j].Offset != nil {
+				return *matches[i].Offset < *matches[j].Offset
+			}
+			return matches[i].Line < matches[j].Line
+		}
+		return matches[i].File < matches[j].File
+	})
+}
@@ -0,0 +1,163 @@
+package search
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRipgrepJSON(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"begin","data":{"path":{"text":"./a.go"}}}`,
+		`{"type":"context","data":{"path":{"text":"./a.go"},"lines":{"text":"one\n"},"line_number":1}}`,
+		`{"type":"context","data":{"path":{"text":"./a.go"},"lines":{"text":"two\r\n"},"line_number":2}}`,
+		`{"type":"match","data":{"path":{"text":"./a.go"},"lines":{"text":"func a()\n"},"line_number":3,"submatches":[{"match":{"text":"func"},"start":0,"end":4}]}}`,
+		`{"type":"match","data":{"path":{"text":"./a.go"},"lines":{"text":"  func b()\n"},"line_number":4,"submatches":[{"match":{"text":"func"},"start":2,"end":6}]}}`,
+		`{"type":"end","data":{"path":{"text":"./a.go"}}}`,
+		`{"type":"begin","data":{"path":{"text":"b.txt"}}}`,
+		`{"type":"match","data":{"path":{"text":"b.txt"},"lines":{"bytes":"ZnVuY/8K"},"line_number":1,"submatches":[{"start":0,"end":4}]}}`,
+		`{"type":"end","data":{"path":{"text":"b.txt"}}}`,
+		`{"type":"summary","data":{"stats":{"searches":5,"bytes_searched":120}}}`,
+	}, "\n")
+
+	results, err := parseRipgrepJSON(strings.NewReader(stream), &SearchConfig{BeforeContext: 2}, nil)
+	if err != nil {
+		t.Fatalf("parseRipgrepJSON failed: %v", err)
+	}
+	want := []SearchMatch{
+		{File: "a.go", Line: 3, Column: 1, Content: "func a()", Context: []string{"one", "two"}, Spans: []MatchSpan{{0, 4, 0, 4}}},
+		{File: "a.go", Line: 4, Column: 3, Content: "  func b()", Context: []string{"two", "func a()"}, Spans: []MatchSpan{{2, 6, 2, 6}}},
+		{File: "b.txt", Line: 1, Column: 1, Content: "func\xff", Spans: []MatchSpan{{0, 4, 0, 4}}},
+	}
+	if !reflect.DeepEqual(results.Matches, want) {
+		t.Errorf("Matches = %+v\nwant %+v", results.Matches, want)
+	}
+	if results.Stats.FilesScanned != 5 || results.Stats.BytesScanned != 120 {
+		t.Errorf("Stats = %+v, want the summary's", results.Stats)
+	}
+
+	results, err = parseRipgrepJSON(strings.NewReader(stream), &SearchConfig{MaxResults: 2}, nil)
+	if err != nil || len(results.Matches) != 2 || results.Matches[0].Context != nil {
+		t.Errorf("With maxResults 2 and no context got %+v, %v", results, err)
+	}
+
+	// The last match allowed still gets the lines after it
+	results, err = parseRipgrepJSON(strings.NewReader(stream), &SearchConfig{AfterContext: 1, MaxResults: 1}, nil)
+	want = []SearchMatch{{File: "a.go", Line: 3, Column: 1, Content: "func a()", ContextAfter: []string{"  func b()"}, Spans: []MatchSpan{{0, 4, 0, 4}}}}
+	if err != nil || !reflect.DeepEqual(results.Matches, want) {
+		t.Errorf("With maxResults 1 and after context 1 got %+v, %v", results.Matches, err)
+	}
+
+	// Matches past the per-file limit only serve as context
+	results, err = parseRipgrepJSON(strings.NewReader(stream), &SearchConfig{AfterContext: 1, MaxPerFile: 1}, nil)
+	want = []SearchMatch{
+		{File: "a.go", Line: 3, Column: 1, Content: "func a()", ContextAfter: []string{"  func b()"}, Spans: []MatchSpan{{0, 4, 0, 4}}},
+		{File: "b.txt", Line: 1, Column: 1, Content: "func\xff", Spans: []MatchSpan{{0, 4, 0, 4}}},
+	}
+	if err != nil || !reflect.DeepEqual(results.Matches, want) {
+		t.Errorf("With maxPerFile 1 and after context 1 got %+v, %v", results.Matches, err)
+	}
+}
+
+func TestFindRipgrepMatchesBuiltin(t *testing.T) {
+	rg, err := exec.LookPath("rg")
+	if err != nil {
+		t.Skip("rg is not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":            "package main\n\nfunc main() {\n\tHandle()\n}\n\nfunc Handle() {}\n",
+		"ignored/skip.go":    "func ignoredByGitignore() {}\n",
+		".gitignore":         "ignored/\n",
+		".hidden/h.go":       "func hidden() {}\n",
+		"docs/notes.txt":     "Functions and FUNC in text\r\nsecond line\r\n",
+		"sub/deep/values.go": "var x = 1\nvar y = 2\n// func comment\n",
+		"docs/greet.txt":     "Grüße, grÜße and GRÜSSE!\n",
+		"docs/.ignore":       "*.tmp\n",
+		"docs/scratch.tmp":   "func scratch() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		options []SearchOption
+	}{
+		{"literal", "func", nil},
+		{"ignore case", "func", []SearchOption{WithIgnoreCase()}},
+		{"regex", `func [A-Z]\w*\(`, nil},
+		{"context", "func", []SearchOption{WithContextLines(2)}},
+		{"after context", "func|var", []SearchOption{WithAfterContext(3)}},
+		{"before and after context", "func", []SearchOption{WithBeforeContext(1), WithAfterContext(2), WithMultiline()}},
+		{"file pattern", "func", []SearchOption{WithFilePattern("*.go")}},
+		{"file patterns", "func", []SearchOption{WithFilePattern("*.txt", "main.*")}},
+		{"doublestar", "func", []SearchOption{WithFilePattern("sub/**/*.go"), WithExcludePatterns("**/skip.go")}},
+		{"whole word", "func", []SearchOption{WithWholeWord(), WithIgnoreCase()}},
+		{"whole word regex", `func|Hand`, []SearchOption{WithWholeWord()}},
+		{"literal", "main()", []SearchOption{WithLiteral()}},
+		{"literal whole word", "x = 1", []SearchOption{WithLiteral(), WithWholeWord(), WithMultiline()}},
+		{"multiline", `func \w+\(\) \{\n|\)\n\}`, []SearchOption{WithMultiline()}},
+		{"multiline context", `\{\n\tHandle|text\r\nsecond`, []SearchOption{WithMultiline(), WithContextLines(2)}},
+		{"hidden", "func", []SearchOption{WithHidden()}},
+		{"max file size", "func", []SearchOption{WithMaxFileSize(40)}},
+		{"max per file", "e", []SearchOption{WithMaxPerFile(2)}},
+		{"max per file context", "e", []SearchOption{WithMaxPerFile(1), WithContextLines(1)}},
+		{"max per file literal", "func", []SearchOption{WithMaxPerFile(1), WithLiteral()}},
+		{"max per file multiline", `\w+
+`, []SearchOption{WithMaxPerFile(1), WithMultiline()}},
+		{"gitignore", "func", []SearchOption{WithGitignore(true), WithHidden()}},
+		{"exclude", "func", []SearchOption{WithExcludePatterns("sub/", "*.txt", "ignored/skip.go")}},
+		{"spans", `e\w?`, nil},
+		{"multibyte spans", "grüße", []SearchOption{WithIgnoreCase()}},
+		{"no matches", "nothing-matches-this", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builtin, err := Find(tt.pattern, dir, tt.options...)
+			if err != nil {
+				t.Fatalf("Built-in search failed: %v", err)
+			}
+			withRg, err := Find(tt.pattern, dir, append(tt.options, WithRipgrep(rg))...)
+			if err != nil {
+				t.Fatalf("ripgrep search failed: %v", err)
+			}
+			if !reflect.DeepEqual(withRg.Matches, builtin.Matches) {
+				t.Errorf("ripgrep matches = %+v\nbuilt-in matches = %+v", withRg.Matches, builtin.Matches)
+			}
+		})
+	}
+
+	// A pattern rg rejects is reported by the built-in engine
+	if _, err := Find("func(", dir, WithRipgrep(rg)); err == nil || !strings.Contains(err.Error(), "invalid regex pattern") {
+		t.Errorf("Find with an invalid pattern returned %v", err)
+	}
+}
+
+func TestFindRipgrepFallsBack(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("func a() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	failing := filepath.Join(t.TempDir(), "rg")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\nexit 2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{"failing": failing, "missing": filepath.Join(dir, "no-such-rg")} {
+		results, err := Find("func", dir, WithRipgrep(path))
+		if err != nil || results.Count() != 1 {
+			t.Errorf("Find with a %s rg = %v, %v; want the built-in engine's match", name, results, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchTree describes the shape of a synthetic directory tree for benchmarks.
+type benchTree struct {
+	name      string
+	files     int // Files per directory
+	depth     int // Nesting depth below the root
+	lineCount int // Lines per file
+}
+
+var benchTrees = []benchTree{
+	{name: "ManySmallFiles", files: 500, depth: 0, lineCount: 20},
+	{name: "FewHugeFiles", files: 3, depth: 0, lineCount: 100000},
+	{name: "DeepNesting", files: 5, depth: 20, lineCount: 50},
+}
+
+// benchLine returns deterministic source-like content for line i of a file.
+func benchLine(i int) string {
+	switch i % 10 {
+	case 0:
+		return fmt.Sprintf("func handler%d(ctx context.Context, req *Request) error {", i)
+	case 3:
+		return "\t// TODO: validate the request before processing"
+	case 7:
+		return fmt.Sprintf("\treturn fmt.Errorf(\"failed at step %d: %%w\", err)", i)
+	default:
+		return fmt.Sprintf("\tvalue%d := compute(req.Field%d, %d)", i, i%7, i)
+	}
+}
+
+// createBenchTree writes the synthetic tree under dir and returns the total bytes written.
+func createBenchTree(b *testing.B, dir string, tree benchTree) int64 {
+	b.Helper()
+
+	var sb strings.Builder
+	for i := 0; i < tree.lineCount; i++ {
+		sb.WriteString(benchLine(i))
+		sb.WriteByte('\n')
+	}
+	content := []byte(sb.String())
+
+	var total int64
+	current := dir
+	for level := 0; level <= tree.depth; level++ {
+		if err := os.MkdirAll(current, 0755); err != nil {
+			b.Fatalf("Failed to create directory %s: %v", current, err)
+		}
+		for f := 0; f < tree.files; f++ {
+			path := filepath.Join(current, fmt.Sprintf("file%d.go", f))
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				b.Fatalf("Failed to write file %s: %v", path, err)
+			}
+			total += int64(len(content))
+		}
+		current = filepath.Join(current, fmt.Sprintf("level%d", level))
+	}
+	return total
+}
+
+// runSearchBenchmark runs pattern against every synthetic tree shape.
+func runSearchBenchmark(b *testing.B, pattern string, options ...SearchOption) {
+	for _, tree := range benchTrees {
+		b.Run(tree.name, func(b *testing.B) {
+			dir := b.TempDir()
+			size := createBenchTree(b, dir, tree)
+
+			// Unlimited results and file sizes so every benchmark scans the whole tree
+			opts := append([]SearchOption{WithMaxResults(0), WithMaxFileSize(0)}, options...)
+
+			b.SetBytes(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				results, err := Find(pattern, dir, opts...)
+				if err != nil {
+					b.Fatalf("Search failed: %v", err)
+				}
+				if !results.HasMatches() {
+					b.Fatal("Expected matches")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSearchLiteral(b *testing.B) {
+	runSearchBenchmark(b, "TODO")
+}
+
+func BenchmarkSearchLiteralIgnoreCase(b *testing.B) {
+	runSearchBenchmark(b, "todo", WithIgnoreCase())
+}
+
+func BenchmarkSearchRegex(b *testing.B) {
+	runSearchBenchmark(b, `func handler\d+\(`)
+}
+
+func BenchmarkSearchContextLines(b *testing.B) {
+	runSearchBenchmark(b, "TODO", WithContextLines(3))
+}
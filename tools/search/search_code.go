@@ -3,6 +3,8 @@ package search
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -15,28 +17,60 @@ import (
 	"sync/atomic"
 	"time"
 
+	"gocreate/tools/config"
+	"gocreate/tools/generated"
+	"gocreate/tools/i18n"
+	"gocreate/tools/request"
+
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/localrivet/gomcp/server"
 )
 
 // Go structs for tool arguments
 type SearchCodeArgs struct {
-	Path          string  `json:"path" description:"The directory path to search within." required:"true"`
-	Pattern       string  `json:"pattern" description:"The text or regex pattern to search for." required:"true"`
-	FilePattern   *string `json:"filePattern,omitempty" description:"Optional glob pattern to filter files (e.g., '*.go')."`
-	IgnoreCase    *bool   `json:"ignoreCase,omitempty" description:"Perform case-insensitive search."`
-	MaxResults    *int    `json:"maxResults,omitempty" description:"Maximum number of results to return."`
-	IncludeHidden *bool   `json:"includeHidden,omitempty" description:"Include hidden files and directories in the search."`
-	ContextLines  *int    `json:"contextLines,omitempty" description:"Number of context lines to show around matches."`
-	TimeoutMs     *int    `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds for the search."`
+	Path              string   `json:"path" description:"The directory path to search within." required:"true"`
+	Pattern           string   `json:"pattern" description:"The text or regex pattern to search for." required:"true"`
+	Files             []string `json:"files,omitempty" description:"Search only these files instead of walking path, such as the files changed in the last commit. Relative paths are taken from path. Listed files are searched even when hidden or gitignored; directories and missing files are left out."`
+	FilePattern       *string  `json:"filePattern,omitempty" description:"Optional glob pattern to filter files, matched against the file name or its path relative to path; ** spans directories (e.g., '*.go', 'src/**/*.ts')."`
+	FilePatterns      []string `json:"filePatterns,omitempty" description:"Glob patterns to filter files, matched like filePattern; a file matching any of them is searched (e.g., ['*.go', '*.mod']). Combined with filePattern."`
+	IgnoreCase        *bool    `json:"ignoreCase,omitempty" description:"Perform case-insensitive search."`
+	Literal           *bool    `json:"literal,omitempty" description:"Search for the pattern as plain text rather than a regex, so characters such as . ( [ * match themselves (e.g., 'foo.bar(')."`
+	Multiline         *bool    `json:"multiline,omitempty" description:"Search whole files instead of single lines, so the pattern can span lines: \\n matches a line break (e.g., 'func \\w+\\(\\) \\{\\n'). Matches are reported with their first and last line numbers. Files over 32 MB are skipped."`
+	WholeWord         *bool    `json:"wholeWord,omitempty" description:"Only match whole words: the pattern, literal or regex, must start and end at word boundaries, so 'add' does not match 'address'."`
+	MaxResults        *int     `json:"maxResults,omitempty" description:"Maximum number of results to return."`
+	MaxMatchesPerFile *int     `json:"maxMatchesPerFile,omitempty" description:"Maximum number of matches to return from any one file, so a file full of matches, such as a generated one, does not use up maxResults."`
+	IncludeHidden     *bool    `json:"includeHidden,omitempty" description:"Include hidden files and directories in the search."`
+	MaxFileSize       *int64   `json:"maxFileSize,omitempty" description:"Skip files larger than this many bytes, such as minified bundles, logs and data files. Defaults to 4 MB; 0 searches files of any size."`
+	ContextLines      *int     `json:"contextLines,omitempty" description:"Number of context lines to show before and after matches, like grep -C."`
+	BeforeContext     *int     `json:"beforeContext,omitempty" description:"Number of lines to show before each match, like grep -B. Overrides contextLines."`
+	AfterContext      *int     `json:"afterContext,omitempty" description:"Number of lines to show after each match, like grep -A. Overrides contextLines."`
+	TimeoutMs         *int     `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds for the search."`
+	Archives          *bool    `json:"archives,omitempty" description:"Also search inside zip, jar and tar.gz archives (size-capped). Matches are reported as archive.zip!inner/path:line. filePattern and filePatterns apply to the entries inside archives."`
+	Documents         *bool    `json:"documents,omitempty" description:"Also search the text of PDF, DOCX and XLSX files instead of skipping them as binary. Extracted text is cached until the file changes."`
+	ExcludeGenerated  *bool    `json:"excludeGenerated,omitempty" description:"Skip generated files: those whose first lines carry the configured generatedMarker or a Go-style 'Code generated ... DO NOT EDIT.' comment."`
+	ExcludePatterns   []string `json:"excludePatterns,omitempty" description:"Glob patterns of files and directories to skip, matched against names and paths relative to path (e.g., 'dist/', '*_test.go', 'internal/gen', '**/testdata'). A trailing slash matches directories only."`
+	ExcludeGitignored *bool    `json:"excludeGitignored,omitempty" description:"Skip files ignored by .gitignore files (nested ones and negations included), .git/info/exclude and ripgrep-style .ignore and .rgignore files, such as vendor or node_modules directories. Defaults to true."`
+	Format            *string  `json:"format,omitempty" description:"Output format: text (default) prints file:line:content lines like ripgrep; heading groups matches under a line with their file's name, as line:content, with context lines shared by nearby matches printed once, like ripgrep --heading; json returns the matches, with their columns and context, and the search statistics as one JSON object."`
+	Rank              *bool    `json:"rank,omitempty" description:"Order matches by relevance instead of by path: files whose name matches the pattern, shallow files, source rather than test files and files with many matches come first."`
+	Paginate          *bool    `json:"paginate,omitempty" description:"Return matches in path order and, when more than maxResults match, a cursor for the next page. The whole tree is searched for each page, so pages are the same from call to call."`
+	Cursor            *string  `json:"cursor,omitempty" description:"Cursor returned by a previous paginated call with the same arguments; the page starts after it. Implies paginate."`
+	Hex               *bool    `json:"hex,omitempty" description:"Treat pattern as a hex byte sequence (e.g., '7f 45 4c 46' or '0x89 0x50 0x4e 0x47') and search binary files for it too. Matches are reported by file offset with a hex dump of the bytes around them; ignoreCase, wholeWord, multiline, context and paginate do not apply."`
+	Workers           *int     `json:"workers,omitempty" description:"Number of files searched at once, and of rg threads. Defaults to the searchWorkers config value, or one per CPU."`
+	BufferKB          *int     `json:"bufferKB,omitempty" description:"Size in KB of the blocks files are read in, at least 64. Defaults to the searchBufferKB config value, or 64."`
+	Stream            *bool    `json:"stream,omitempty" description:"Send matches in batches as progress notifications while the search runs, in the chosen format, and return only the search statistics. Needs a progress token on the request; without one, with rank or when paginating, matches are returned as usual."`
 }
 
 // SearchMatch represents a single search match
 type SearchMatch struct {
-	File    string   `json:"file"`
-	Line    int      `json:"line"`
-	Column  int      `json:"column"`
-	Content string   `json:"content"`
-	Context []string `json:"context,omitempty"`
+	File         string      `json:"file"`
+	Line         int         `json:"line"`
+	EndLine      int         `json:"end_line,omitempty"` // last line of a match spanning lines
+	Column       int         `json:"column"`
+	Offset       *int64      `json:"offset,omitempty"` // file offset of a byte match, which has no line
+	Content      string      `json:"content"`
+	Spans        []MatchSpan `json:"spans,omitempty"`         // every match in the content
+	Context      []string    `json:"context,omitempty"`       // lines before the match
+	ContextAfter []string    `json:"context_after,omitempty"` // lines after the match
 }
 
 // SearchStats contains performance statistics
@@ -45,12 +79,22 @@ type SearchStats struct {
 	FilesScanned int           `json:"files_scanned"`
 	BytesScanned int64         `json:"bytes_scanned"`
 	MatchesFound int           `json:"matches_found"`
+
+	// Set by WithDiagnostics
+	Skipped      map[string]int `json:"skipped,omitempty"`       // files and directories left out, by reason
+	SkippedFiles []SkippedFile  `json:"skipped_files,omitempty"` // the first of them
+	SlowestDirs  []DirTime      `json:"slowest_dirs,omitempty"`
+	Workers      []WorkerStats  `json:"workers,omitempty"`
+
+	// Files abandoned because the pattern took too long on them
+	OverBudget []string `json:"over_budget,omitempty"`
 }
 
 // SearchResults contains all search results and metadata
 type SearchResults struct {
-	Matches []SearchMatch `json:"matches"`
-	Stats   SearchStats   `json:"stats"`
+	Matches    []SearchMatch `json:"matches"`
+	Stats      SearchStats   `json:"stats"`
+	NextCursor string        `json:"next_cursor,omitempty"` // where the next page starts, when paginating
 }
 
 // Count returns the number of matches found
@@ -81,16 +125,37 @@ func (r *SearchResults) Files() []string {
 // SearchConfig holds configuration for the search engine
 type SearchConfig struct {
 	SearchPath      string
+	Files           []string // search only these files, relative to SearchPath unless absolute
 	Pattern         string
+	Bytes           []byte // search for this byte sequence instead of the pattern
 	MaxWorkers      int
 	BufferSize      int
 	MaxResults      int
+	MaxPerFile      int // matches reported per file at most; 0 for no limit
 	UseOptimization bool
 	UseGitignore    bool
 	IgnoreCase      bool
-	FilePattern     string
-	ContextLines    int
+	WholeWord       bool     // the pattern must start and end at word boundaries
+	Literal         bool     // the pattern is a plain string, never a regex
+	Multiline       bool     // match against whole files, so matches may span lines
+	FilePatterns    []string // a file is searched if its name matches any of them
+	BeforeContext   int      // lines of context before each match
+	AfterContext    int      // lines of context after each match
 	IncludeHidden   bool
+	MaxFileSize     int64 // files larger than this many bytes are skipped; 0 for no limit
+	ExcludePatterns []string
+	SearchArchives  bool
+	SearchDocuments bool
+	SkipGenerated   bool
+	GeneratedMarker string
+	RipgrepPath     string            // rg executable to search with; empty for the built-in engine
+	Rank            bool              // order matches by relevance instead of by path
+	OnMatch         func(SearchMatch) // called with each match as it is found
+	Paginate        bool              // return a page of matches in path order
+	After           *Cursor           // where the previous page ended, when paginating
+	Diagnostics     bool              // record what was skipped and where time went
+	LineBudget      time.Duration     // a file is abandoned when matching one of its lines takes longer
+	FileBudget      time.Duration     // or matching all of them does
 	Timeout         time.Duration
 }
 
@@ -104,27 +169,86 @@ func WithIgnoreCase() SearchOption {
 	}
 }
 
-// WithContextLines sets the number of context lines around matches
+// WithLiteral searches for the pattern as a plain string, so regex
+// metacharacters in it match themselves
+func WithLiteral() SearchOption {
+	return func(c *SearchConfig) {
+		c.Literal = true
+	}
+}
+
+// WithWholeWord only matches the pattern as a whole word
+func WithWholeWord() SearchOption {
+	return func(c *SearchConfig) {
+		c.WholeWord = true
+	}
+}
+
+// WithMultiline searches whole files instead of single lines, so a pattern
+// containing \n can match across lines
+func WithMultiline() SearchOption {
+	return func(c *SearchConfig) {
+		c.Multiline = true
+	}
+}
+
+// WithContextLines sets the number of context lines before and after matches
 func WithContextLines(lines int) SearchOption {
 	return func(c *SearchConfig) {
-		c.ContextLines = lines
+		c.BeforeContext = lines
+		c.AfterContext = lines
+	}
+}
+
+// WithBeforeContext sets the number of context lines before matches
+func WithBeforeContext(lines int) SearchOption {
+	return func(c *SearchConfig) {
+		c.BeforeContext = lines
+	}
+}
+
+// WithAfterContext sets the number of context lines after matches
+func WithAfterContext(lines int) SearchOption {
+	return func(c *SearchConfig) {
+		c.AfterContext = lines
 	}
 }
 
-// WithFilePattern sets a glob pattern to filter files
-func WithFilePattern(pattern string) SearchOption {
+// WithFilePattern restricts the search to files matching any of the glob patterns
+func WithFilePattern(patterns ...string) SearchOption {
 	return func(c *SearchConfig) {
-		c.FilePattern = pattern
+		c.FilePatterns = append(c.FilePatterns, patterns...)
 	}
 }
 
-// WithMaxResults limits the number of results returned
+// WithMaxResults limits the number of results returned. The built-in engine
+// returns the first max matches in walk order, whatever order its workers
+// finish files in, and stops walking and searching as soon as it has them.
 func WithMaxResults(max int) SearchOption {
 	return func(c *SearchConfig) {
 		c.MaxResults = max
 	}
 }
 
+// WithFiles searches only the given files instead of walking the search path.
+// Relative paths are taken from the search path. Listed files are searched
+// even when hidden or ignored, but file patterns, exclude patterns, the size
+// limit and binary detection still apply; directories and missing files are
+// left out.
+func WithFiles(paths ...string) SearchOption {
+	return func(c *SearchConfig) {
+		c.Files = append(c.Files, paths...)
+	}
+}
+
+// WithMaxPerFile stops searching a file after max matches, so one file full
+// of matches, such as a generated one, cannot use up MaxResults.
+func WithMaxPerFile(max int) SearchOption {
+	return func(c *SearchConfig) {
+		c.MaxPerFile = max
+	}
+}
+
 // WithWorkers sets the number of worker goroutines
 func WithWorkers(workers int) SearchOption {
 	return func(c *SearchConfig) {
@@ -139,6 +263,48 @@ func WithHidden() SearchOption {
 	}
 }
 
+// defaultMaxFileSize is the size above which Find skips files unless
+// WithMaxFileSize says otherwise: large enough for source files, small
+// enough to pass over minified bundles, logs and data dumps.
+const defaultMaxFileSize int64 = 4 * 1024 * 1024
+
+// WithMaxFileSize skips files larger than size bytes; 0 searches files of any size
+func WithMaxFileSize(size int64) SearchOption {
+	return func(c *SearchConfig) {
+		c.MaxFileSize = size
+	}
+}
+
+// WithExcludePatterns skips files and directories matching any of the glob patterns
+func WithExcludePatterns(patterns ...string) SearchOption {
+	return func(c *SearchConfig) {
+		c.ExcludePatterns = append(c.ExcludePatterns, patterns...)
+	}
+}
+
+// WithArchives searches inside zip, jar and tar.gz archives
+func WithArchives() SearchOption {
+	return func(c *SearchConfig) {
+		c.SearchArchives = true
+	}
+}
+
+// WithDocuments searches the text of PDF, DOCX and XLSX files
+func WithDocuments() SearchOption {
+	return func(c *SearchConfig) {
+		c.SearchDocuments = true
+	}
+}
+
+// WithoutGenerated skips files whose first lines carry marker or Go's
+// generated-code comment
+func WithoutGenerated(marker string) SearchOption {
+	return func(c *SearchConfig) {
+		c.SkipGenerated = true
+		c.GeneratedMarker = marker
+	}
+}
+
 // WithTimeout sets a timeout for the search operation
 func WithTimeout(timeout time.Duration) SearchOption {
 	return func(c *SearchConfig) {
@@ -146,14 +312,17 @@ func WithTimeout(timeout time.Duration) SearchOption {
 	}
 }
 
-// WithGitignore enables respecting .gitignore files
+// WithGitignore skips files and directories ignored by the .gitignore files of
+// the repository containing the search path, including nested ones and
+// .git/info/exclude, and by .ignore and .rgignore files
 func WithGitignore(enabled bool) SearchOption {
 	return func(c *SearchConfig) {
 		c.UseGitignore = enabled
 	}
 }
 
-// WithBufferSize sets the buffer size for file I/O
+// WithBufferSize sets the size of the blocks files are read in, which is at
+// least 64 KB
 func WithBufferSize(size int) SearchOption {
 	return func(c *SearchConfig) {
 		c.BufferSize = size
@@ -169,6 +338,12 @@ func WithOptimization(enabled bool) SearchOption {
 
 // Find performs a search with the given pattern and options
 func Find(pattern, searchPath string, options ...SearchOption) (*SearchResults, error) {
+	return FindContext(context.Background(), pattern, searchPath, options...)
+}
+
+// FindContext is Find with a context: the search stops once ctx is done,
+// returning what it found so far or ctx's error.
+func FindContext(ctx context.Context, pattern, searchPath string, options ...SearchOption) (*SearchResults, error) {
 	config := &SearchConfig{
 		SearchPath:      searchPath,
 		Pattern:         pattern,
@@ -178,8 +353,12 @@ func Find(pattern, searchPath string, options ...SearchOption) (*SearchResults,
 		UseOptimization: true,
 		UseGitignore:    false,
 		IgnoreCase:      false,
-		ContextLines:    0,
+		BeforeContext:   0,
+		AfterContext:    0,
 		IncludeHidden:   false,
+		MaxFileSize:     defaultMaxFileSize,
+		LineBudget:      defaultLineBudget,
+		FileBudget:      defaultFileBudget,
 		Timeout:         0,
 	}
 
@@ -188,15 +367,72 @@ func Find(pattern, searchPath string, options ...SearchOption) (*SearchResults,
 		option(config)
 	}
 
-	engine := NewSearchEngine(*config)
-	ctx := context.Background()
 	if config.Timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
 		defer cancel()
 	}
 
-	return engine.Search(ctx, pattern)
+	// A page is cut from every match after the cursor, so that it does not
+	// depend on the order files are searched in
+	if config.Paginate && !config.Rank && len(config.Bytes) == 0 {
+		limit := config.MaxResults
+		config.MaxResults = 0
+		results, err := find(ctx, config)
+		if err != nil {
+			return results, err
+		}
+		pageMatches(results, config.After, limit)
+		return results, nil
+	}
+
+	// A ranked search collects more matches than it returns, so that the
+	// most relevant ones are not cut off by the order files are walked in
+	limit := config.MaxResults
+	if config.Rank && limit > 0 {
+		config.MaxResults = limit * rankPoolFactor
+	}
+
+	if config.Rank {
+		config.OnMatch = nil // ranked matches are only known at the end
+	}
+
+	results, err := find(ctx, config)
+	if err != nil || !config.Rank {
+		return results, err
+	}
+	rankMatches(results.Matches, config.SearchPath, nameMatcher(config))
+	if limit > 0 && len(results.Matches) > limit {
+		results.Matches = results.Matches[:limit]
+	}
+	results.Stats.MatchesFound = len(results.Matches)
+	return results, nil
+}
+
+// find runs the search described by config with rg or the built-in engine.
+func find(ctx context.Context, config *SearchConfig) (*SearchResults, error) {
+	// A pattern too complex to search is refused whichever engine runs it
+	if len(config.Bytes) == 0 && !searchesLiteral(config, config.Pattern) {
+		source := regexpSource(config, config.Pattern)
+		if config.IgnoreCase {
+			source = "(?i)" + source
+		}
+		if _, err := compileRegexp(source); errors.Is(err, errPatternTooComplex) {
+			return nil, err
+		}
+	}
+
+	if ripgrepSupports(config) {
+		results, err := findRipgrep(ctx, config)
+		if err == nil || ctx.Err() != nil {
+			return results, err
+		}
+		// rg could not run the search; the built-in engine reports any problem
+		// with the pattern in its own words
+	}
+
+	engine := NewSearchEngine(*config)
+	return engine.Search(ctx, config.Pattern)
 }
 
 // SearchEngine provides fast text search functionality
@@ -204,6 +440,7 @@ type SearchEngine struct {
 	config        SearchConfig
 	pattern       *regexp.Regexp
 	literalSearch string
+	diag          *diagnostics // nil unless config.Diagnostics is set
 }
 
 // NewSearchEngine creates a new search engine with the given configuration
@@ -213,7 +450,7 @@ func NewSearchEngine(config SearchConfig) *SearchEngine {
 	}
 
 	// Check if pattern is a simple literal string or regex
-	if isLiteralPattern(config.Pattern) {
+	if searchesLiteral(&config, config.Pattern) {
 		// Use literal string search for better performance
 		if config.IgnoreCase {
 			engine.literalSearch = strings.ToLower(config.Pattern)
@@ -222,13 +459,13 @@ func NewSearchEngine(config SearchConfig) *SearchEngine {
 		}
 	} else {
 		// Compile regex pattern
-		pattern := config.Pattern
+		pattern := regexpSource(&config, config.Pattern)
 		if config.IgnoreCase {
 			pattern = "(?i)" + pattern
 		}
 
 		var err error
-		engine.pattern, err = regexp.Compile(pattern)
+		engine.pattern, err = compileRegexp(pattern)
 		if err != nil {
 			// Return engine with error state - will be caught in Search
 			return engine
@@ -243,15 +480,15 @@ func (e *SearchEngine) Search(ctx context.Context, pattern string) (*SearchResul
 	startTime := time.Now()
 
 	// Validate pattern if using regex
-	if e.pattern == nil && !isLiteralPattern(pattern) {
-		regexPattern := pattern
+	if e.pattern == nil && !searchesLiteral(&e.config, pattern) {
+		regexPattern := regexpSource(&e.config, pattern)
 		if e.config.IgnoreCase {
-			regexPattern = "(?i)" + pattern
+			regexPattern = "(?i)" + regexPattern
 		}
 		var err error
-		e.pattern, err = regexp.Compile(regexPattern)
+		e.pattern, err = compileRegexp(regexPattern)
 		if err != nil {
-			return nil, fmt.Errorf("invalid regex pattern: %v", err)
+			return nil, fmt.Errorf("invalid regex pattern: %w", err)
 		}
 	}
 
@@ -264,135 +501,319 @@ func (e *SearchEngine) Search(ctx context.Context, pattern string) (*SearchResul
 		},
 	}
 
-	matchChan := make(chan SearchMatch, 1000)
+	if e.config.Diagnostics {
+		e.diag = newDiagnostics(e.config.MaxWorkers)
+	}
+
+	// The search stops as soon as MaxResults matches are in
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Files are numbered in walk order, so that their matches are reported
+	// in that order whichever worker finishes first
+	type fileJob struct {
+		seq  int
+		path string
+	}
+	type fileResult struct {
+		seq     int
+		matches []SearchMatch
+	}
+	jobs := make(chan fileJob, e.config.MaxWorkers*2)
+	done := make(chan fileResult, e.config.MaxWorkers*2)
 	var wg sync.WaitGroup
-	var resultCount int64
+	var resultCount int64 // matches reported by the files before those being searched
 	var filesScanned int64
 	var bytesScanned int64
-
-	// Use worker pool for concurrent file processing
-	filePaths := make(chan string, e.config.MaxWorkers*2)
+	var over overBudget
 
 	// Start workers
 	for i := 0; i < e.config.MaxWorkers; i++ {
 		wg.Add(1)
-		go func() {
+		go func(worker int) {
 			defer wg.Done()
-			for filePath := range filePaths {
-				select {
-				case <-ctx.Done():
+			for job := range jobs {
+				if ctx.Err() != nil {
 					return
-				default:
 				}
 
-				matches, fileBytes, err := e.searchFile(ctx, filePath, &resultCount)
-				if err != nil {
-					continue // Skip files with errors
+				started := time.Now()
+				matches, fileBytes, err := e.searchFile(ctx, job.path, &resultCount)
+				if err == errRegexBudget {
+					// The matches found before the file was abandoned stand
+					over.add(job.path)
+					err = nil
 				}
-
-				atomic.AddInt64(&filesScanned, 1)
-				atomic.AddInt64(&bytesScanned, fileBytes)
-
-				for _, match := range matches {
-					select {
-					case matchChan <- match:
-						atomic.AddInt64(&resultCount, 1)
-					case <-ctx.Done():
-						return
+				if err != nil {
+					if ctx.Err() == nil {
+						e.diag.skip(job.path, skipUnreadable)
 					}
+					matches = nil // Skip files with errors
+				} else {
+					atomic.AddInt64(&filesScanned, 1)
+					atomic.AddInt64(&bytesScanned, fileBytes)
+					e.diag.searched(worker, job.path, fileBytes, time.Since(started))
+				}
 
-					// Check max results limit
-					if e.config.MaxResults > 0 && int(resultCount) >= e.config.MaxResults {
-						return
-					}
+				// Every file is reported, so that the ones after it are not held up
+				select {
+				case done <- fileResult{seq: job.seq, matches: matches}:
+				case <-ctx.Done():
+					return
 				}
 			}
-		}()
+		}(i)
 	}
 
 	// Walk directory and send file paths to workers
 	go func() {
-		defer close(filePaths)
-
-		_ = filepath.Walk(e.config.SearchPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // Skip files with errors
-			}
-
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
+		defer close(jobs)
 
-			if e.shouldSkipFile(path, info) {
-				if info.IsDir() && !e.config.IncludeHidden && strings.HasPrefix(info.Name(), ".") {
-					return filepath.SkipDir
-				}
+		seq := 0
+		_ = e.walk(ctx, func(path string) error {
+			if e.config.After.skipsFile(path) {
 				return nil
 			}
-
 			select {
-			case filePaths <- path:
+			case jobs <- fileJob{seq: seq, path: path}:
+				seq++
 			case <-ctx.Done():
 				return ctx.Err()
 			}
-
 			return nil
 		})
 
 		// Walk completed - errors are handled individually during the walk
 	}()
 
-	// Wait for all workers to finish and close match channel
 	go func() {
 		wg.Wait()
-		close(matchChan)
+		close(done)
 	}()
 
-	// Collect results
-	for match := range matchChan {
-		results.Matches = append(results.Matches, match)
-		if e.config.MaxResults > 0 && len(results.Matches) >= e.config.MaxResults {
-			break
+	// Collect results in walk order. Files finished out of order wait in
+	// pending until the ones before them are in.
+	pending := make(map[int][]SearchMatch)
+	next := 0
+	limitReached := false
+	add := func(matches []SearchMatch) {
+		for _, match := range matches {
+			if limitReached {
+				return
+			}
+			results.Matches = append(results.Matches, match)
+			if e.config.OnMatch != nil {
+				e.config.OnMatch(match)
+			}
+			if e.config.MaxResults > 0 && len(results.Matches) >= e.config.MaxResults {
+				limitReached = true
+				cancel()
+			}
 		}
+		atomic.StoreInt64(&resultCount, int64(len(results.Matches)))
+	}
+	for result := range done {
+		pending[result.seq] = result.matches
+		for {
+			matches, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			add(matches)
+		}
+	}
+	// Files left waiting follow one that was never searched, as the search
+	// timed out or was cancelled
+	seqs := make([]int, 0, len(pending))
+	for seq := range pending {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	for _, seq := range seqs {
+		add(pending[seq])
 	}
 
 	// Sort results by file path and line number
-	sort.Slice(results.Matches, func(i, j int) bool {
-		if results.Matches[i].File == results.Matches[j].File {
-			return results.Matches[i].Line < results.Matches[j].Line
-		}
-		return results.Matches[i].File < results.Matches[j].File
-	})
+	sortMatches(results.Matches)
 
 	// Update statistics
 	results.Stats.Duration = time.Since(startTime)
 	results.Stats.FilesScanned = int(filesScanned)
 	results.Stats.BytesScanned = bytesScanned
 	results.Stats.MatchesFound = len(results.Matches)
+	sort.Strings(over.files)
+	results.Stats.OverBudget = over.files
+	e.diag.report(&results.Stats)
 
 	return results, nil
 }
 
+// walk calls fn for every file under the search path that passes the configured filters.
+// Hidden, excluded and gitignored directories are pruned from the walk.
+func (e *SearchEngine) walk(ctx context.Context, fn func(path string) error) error {
+	if len(e.config.Files) > 0 {
+		return e.walkFiles(ctx, fn)
+	}
+	var ignore *ignoreFilter
+	if e.config.UseGitignore {
+		ignore = newIgnoreFilter(e.config.SearchPath)
+	}
+	return filepath.Walk(e.config.SearchPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip files with errors
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if info.IsDir() {
+			if path == e.config.SearchPath {
+				return nil
+			}
+			reason := ""
+			switch {
+			case !e.config.IncludeHidden && strings.HasPrefix(info.Name(), "."):
+				reason = skipHidden
+			case e.isExcluded(path, info):
+				reason = skipExcluded
+			case ignore != nil && ignore.skipDir(ignore.rel(e.config.SearchPath, path)):
+				reason = skipGitignored
+			default:
+				return nil
+			}
+			e.diag.skip(path+string(filepath.Separator), reason)
+			return filepath.SkipDir
+		}
+		if reason := e.skipReason(path, info); reason != "" {
+			e.diag.skip(path, reason)
+			return nil
+		}
+		if ignore != nil && ignore.skipFile(ignore.rel(e.config.SearchPath, path)) {
+			e.diag.skip(path, skipGitignored)
+			return nil
+		}
+
+		return fn(path)
+	})
+}
+
+// walkFiles calls fn for each listed file that passes the configured filters,
+// once each, without walking any directory.
+func (e *SearchEngine) walkFiles(ctx context.Context, fn func(path string) error) error {
+	seen := make(map[string]bool)
+	for _, path := range e.config.Files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(e.config.SearchPath, path)
+		}
+		path = filepath.Clean(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if reason := e.filterReason(path, info); reason != "" {
+			e.diag.skip(path, reason)
+			continue
+		}
+		if err := fn(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relPath returns the slash-separated path of path relative to the search root.
+func (e *SearchEngine) relPath(path string) string {
+	rel, err := filepath.Rel(e.config.SearchPath, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// isExcluded reports whether path matches one of the exclude patterns, either by
+// its base name or by its slash-separated path relative to the search root.
+// Patterns ending in a slash only match directories.
+func (e *SearchEngine) isExcluded(path string, info os.FileInfo) bool {
+	if len(e.config.ExcludePatterns) == 0 {
+		return false
+	}
+	rel := e.relPath(path)
+	for _, pattern := range e.config.ExcludePatterns {
+		// A trailing slash limits a pattern to directories, as in "dist/"
+		if strings.HasSuffix(pattern, "/") {
+			if !info.IsDir() {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		if matchGlob(pattern, info.Name(), rel) {
+			return true
+		}
+	}
+	return false
+}
+
 // searchFile searches for the pattern in a single file
 func (e *SearchEngine) searchFile(ctx context.Context, filePath string, resultCount *int64) ([]SearchMatch, int64, error) {
+	if e.config.SearchArchives && isArchive(filePath) {
+		return e.searchArchive(ctx, filePath, resultCount)
+	}
+	if e.config.SearchDocuments && isDocument(filePath) {
+		text, err := documentText(filePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		return e.searchReader(ctx, strings.NewReader(text), filePath, resultCount)
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer file.Close()
 
+	return e.searchReader(ctx, file, filePath, resultCount)
+}
+
+// searchReader searches the lines read from r, reporting matches against name
+func (e *SearchEngine) searchReader(ctx context.Context, r io.Reader, name string, resultCount *int64) ([]SearchMatch, int64, error) {
 	var matches []SearchMatch
-	scanner := bufio.NewScanner(file)
+	if e.config.SkipGenerated {
+		br := bufio.NewReader(r)
+		head, _ := br.Peek(generated.HeadSize)
+		if generated.IsGenerated(head, e.config.GeneratedMarker) {
+			return nil, 0, nil
+		}
+		r = br
+	}
+	if len(e.config.Bytes) > 0 {
+		return e.searchBytes(ctx, r, name, resultCount)
+	}
+	if e.config.Multiline {
+		return e.searchContent(ctx, r, name, resultCount)
+	}
+	if e.searchesBlocks() {
+		return e.searchBlocks(ctx, r, name, resultCount)
+	}
+	scanner := bufio.NewScanner(r)
 	lineNum := 1
-	var lines []string
+	var before []string // the last BeforeContext lines
+	var pending []int   // matches still collecting the lines after them
 	var bytesRead int64
-
-	// Store lines for context if needed
-	if e.config.ContextLines > 0 {
-		lines = make([]string, 0)
-	}
+	limitReached := false
+	budget := e.newBudget()
 
 	for scanner.Scan() {
 		select {
@@ -404,8 +825,21 @@ func (e *SearchEngine) searchFile(ctx context.Context, filePath string, resultCo
 		line := scanner.Text()
 		bytesRead += int64(len(line) + 1) // +1 for newline
 
-		if e.config.ContextLines > 0 {
-			lines = append(lines, line)
+		// The lines following a match are its after context, matching or not
+		collecting := pending[:0]
+		for _, i := range pending {
+			matches[i].ContextAfter = append(matches[i].ContextAfter, line)
+			if len(matches[i].ContextAfter) < e.config.AfterContext {
+				collecting = append(collecting, i)
+			}
+		}
+		pending = collecting
+		if limitReached {
+			if len(pending) == 0 {
+				break
+			}
+			lineNum++
+			continue
 		}
 
 		var matched bool
@@ -423,74 +857,143 @@ func (e *SearchEngine) searchFile(ctx context.Context, filePath string, resultCo
 			}
 		} else if e.pattern != nil {
 			// Regex search
+			started := budget.start()
 			if loc := e.pattern.FindStringIndex(line); loc != nil {
 				matched = true
 				column = loc[0] + 1 // 1-indexed
 			}
+			if err := budget.charge(started); err != nil {
+				return matches, bytesRead, err
+			}
 		}
 
 		if matched {
-			// Check if we've hit the max results limit
-			if e.config.MaxResults > 0 && *resultCount >= int64(e.config.MaxResults) {
-				break
+			// Check if we've hit the max results limit, overall or for this file
+			if e.atLimit(resultCount, len(matches)) {
+				limitReached = true
+				if len(pending) == 0 {
+					break
+				}
+				lineNum++
+				continue
 			}
 
 			match := SearchMatch{
-				File:    filePath,
+				File:    name,
 				Line:    lineNum,
 				Column:  column,
 				Content: line,
+				Spans:   newSpans(line, e.findAll(line)),
 			}
 
 			// Add context lines if requested
-			if e.config.ContextLines > 0 && len(lines) > 0 {
-				start := max(0, len(lines)-e.config.ContextLines-1)
-				end := min(len(lines)-1, len(lines)-1+e.config.ContextLines)
-
-				for i := start; i <= end; i++ {
-					if i != len(lines)-1 { // Don't include the matched line itself
-						match.Context = append(match.Context, lines[i])
-					}
-				}
+			if len(before) > 0 {
+				match.Context = append([]string(nil), before...)
+			}
+			if e.config.AfterContext > 0 {
+				pending = append(pending, len(matches))
 			}
 
 			matches = append(matches, match)
 		}
 
+		if e.config.BeforeContext > 0 {
+			before = append(before, line)
+			if len(before) > e.config.BeforeContext {
+				before = before[1:]
+			}
+		}
+
 		lineNum++
 	}
 
 	return matches, bytesRead, scanner.Err()
 }
 
-// shouldSkipFile determines if a file should be skipped based on various criteria
-func (e *SearchEngine) shouldSkipFile(path string, info os.FileInfo) bool {
-	// Skip directories
-	if info.IsDir() {
-		return true
-	}
+// atLimit reports whether a file with found matches so far needs no more:
+// with those of the files before it, counted by resultCount, they make
+// MaxResults, or they make MaxPerFile.
+func (e *SearchEngine) atLimit(resultCount *int64, found int) bool {
+	return (e.config.MaxResults > 0 && atomic.LoadInt64(resultCount)+int64(found) >= int64(e.config.MaxResults)) ||
+		(e.config.MaxPerFile > 0 && found >= e.config.MaxPerFile)
+}
 
+// skipReason returns why a file found by the walk should be skipped, or ""
+// when it is searched
+func (e *SearchEngine) skipReason(path string, info os.FileInfo) string {
 	// Skip hidden files unless explicitly included
 	if !e.config.IncludeHidden && strings.HasPrefix(info.Name(), ".") {
-		return true
+		return skipHidden
 	}
 
-	// Skip binary files (basic heuristic)
-	if isBinaryFile(path) {
-		return true
+	return e.filterReason(path, info)
+}
+
+// filterReason returns which of the configured filters leaves out a file,
+// apart from the hidden file rule, or "" when none does.
+func (e *SearchEngine) filterReason(path string, info os.FileInfo) string {
+	// Skip excluded files
+	if e.isExcluded(path, info) {
+		return skipExcluded
 	}
 
-	// Check file pattern
-	if e.config.FilePattern != "" {
-		matched, _ := filepath.Match(e.config.FilePattern, info.Name())
-		if !matched {
-			return true
+	// Archives are opened and their entries filtered individually
+	if e.config.SearchArchives && isArchive(path) {
+		if info.Size() > maxArchiveSize {
+			return skipTooLarge
+		}
+		return ""
+	}
+
+	if e.config.MaxFileSize > 0 && info.Size() > e.config.MaxFileSize {
+		return skipTooLarge
+	}
+
+	// Check file patterns
+	if !matchesFilePatterns(e.config.FilePatterns, info.Name(), e.relPath(path)) {
+		return skipFilePattern
+	}
+
+	// Documents are searched through their extracted text
+	if e.config.SearchDocuments && isDocument(path) {
+		if info.Size() > maxDocumentSize {
+			return skipTooLarge
 		}
+		return ""
+	}
+
+	// Skip binary files (basic heuristic), unless searching for bytes
+	if len(e.config.Bytes) == 0 && isBinaryFile(path) {
+		return skipBinary
 	}
+	return ""
+}
 
+// matchesFilePatterns reports whether a file matches any of the glob patterns
+// by its base name or its slash-separated path rel relative to the search
+// root, or true when there are none.
+func matchesFilePatterns(patterns []string, name, rel string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matchGlob(pattern, name, rel) {
+			return true
+		}
+	}
 	return false
 }
 
+// matchGlob reports whether pattern matches name or rel. ** spans directories,
+// as in src/**/*.ts, while * and ? stay within one; {a,b} matches either.
+func matchGlob(pattern, name, rel string) bool {
+	if matched, _ := doublestar.Match(pattern, name); matched {
+		return true
+	}
+	matched, _ := doublestar.Match(pattern, rel)
+	return matched
+}
+
 // isLiteralPattern checks if a pattern is a simple literal string
 func isLiteralPattern(pattern string) bool {
 	// Check for regex metacharacters
@@ -503,6 +1006,30 @@ func isLiteralPattern(pattern string) bool {
 	return true
 }
 
+// searchesLiteral reports whether the built-in engine looks for pattern as a
+// plain string under c instead of compiling it as a regex
+func searchesLiteral(c *SearchConfig, pattern string) bool {
+	return (c.Literal || isLiteralPattern(pattern)) && !c.WholeWord && !c.Multiline
+}
+
+// regexpSource returns the regex searched for pattern under c, before case
+// folding: quoted when c.Literal is set, in word boundaries for c.WholeWord
+func regexpSource(c *SearchConfig, pattern string) string {
+	if c.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if c.WholeWord {
+		pattern = wholeWordPattern(pattern)
+	}
+	return pattern
+}
+
+// wholeWordPattern wraps a literal or regex pattern in word boundaries, so the
+// whole of each match is one or more words.
+func wholeWordPattern(pattern string) string {
+	return `\b(?:` + pattern + `)\b`
+}
+
 // isBinaryFile performs a basic check to determine if a file is binary
 func isBinaryFile(path string) bool {
 	// Check file extension first
@@ -556,66 +1083,249 @@ func min(a, b int) int {
 	return b
 }
 
-// HandleSearchCode implements the search_code tool using GoRipGrep API
-func HandleSearchCode(ctx *server.Context, args SearchCodeArgs) (string, error) {
-	ctx.Logger.Info("Handling search_code tool call with GoRipGrep implementation")
-
-	// Build options from args
+// tuningOptions returns the worker count and buffer size of a search: workers
+// and bufferKB when given, otherwise the searchWorkers and searchBufferKB
+// config values. Searches without either keep the defaults of Find.
+func tuningOptions(ctx *server.Context, workers, bufferKB *int) []SearchOption {
+	if cfg, err := config.GetCurrentConfig(ctx); err == nil {
+		if workers == nil {
+			workers = cfg.SearchWorkers
+		}
+		if bufferKB == nil {
+			bufferKB = cfg.SearchBufferKB
+		}
+	}
 	var options []SearchOption
+	if workers != nil && *workers > 0 {
+		options = append(options, WithWorkers(*workers))
+	}
+	if bufferKB != nil && *bufferKB > 0 {
+		options = append(options, WithBufferSize(*bufferKB*1024))
+	}
+	return options
+}
+
+// searchCodeOptions returns the search options the arguments of search_code
+// ask for, apart from paging and streaming.
+func searchCodeOptions(ctx *server.Context, args SearchCodeArgs) []SearchOption {
+	options := tuningOptions(ctx, args.Workers, args.BufferKB)
 
 	if args.IgnoreCase != nil && *args.IgnoreCase {
 		options = append(options, WithIgnoreCase())
 	}
 
+	if args.Literal != nil && *args.Literal {
+		options = append(options, WithLiteral())
+	}
+
+	if args.WholeWord != nil && *args.WholeWord {
+		options = append(options, WithWholeWord())
+	}
+
+	if args.Multiline != nil && *args.Multiline {
+		options = append(options, WithMultiline())
+	}
+
 	if args.ContextLines != nil && *args.ContextLines > 0 {
 		options = append(options, WithContextLines(*args.ContextLines))
 	}
 
+	if args.BeforeContext != nil && *args.BeforeContext >= 0 {
+		options = append(options, WithBeforeContext(*args.BeforeContext))
+	}
+
+	if args.AfterContext != nil && *args.AfterContext >= 0 {
+		options = append(options, WithAfterContext(*args.AfterContext))
+	}
+
+	if len(args.Files) > 0 {
+		options = append(options, WithFiles(args.Files...))
+	}
+
 	if args.FilePattern != nil && *args.FilePattern != "" {
 		options = append(options, WithFilePattern(*args.FilePattern))
 	}
 
+	if len(args.FilePatterns) > 0 {
+		options = append(options, WithFilePattern(args.FilePatterns...))
+	}
+
 	if args.MaxResults != nil && *args.MaxResults > 0 {
 		options = append(options, WithMaxResults(*args.MaxResults))
 	}
 
+	if args.MaxMatchesPerFile != nil && *args.MaxMatchesPerFile > 0 {
+		options = append(options, WithMaxPerFile(*args.MaxMatchesPerFile))
+	}
+
 	if args.IncludeHidden != nil && *args.IncludeHidden {
 		options = append(options, WithHidden())
 	}
 
+	if args.MaxFileSize != nil && *args.MaxFileSize >= 0 {
+		options = append(options, WithMaxFileSize(*args.MaxFileSize))
+	}
+
+	if args.Archives != nil && *args.Archives {
+		options = append(options, WithArchives())
+	}
+
+	if args.Documents != nil && *args.Documents {
+		options = append(options, WithDocuments())
+	}
+
+	if args.ExcludeGenerated != nil && *args.ExcludeGenerated {
+		options = append(options, WithoutGenerated(generated.Marker(ctx)))
+	}
+
+	if len(args.ExcludePatterns) > 0 {
+		options = append(options, WithExcludePatterns(args.ExcludePatterns...))
+	}
+
+	if args.ExcludeGitignored == nil || *args.ExcludeGitignored {
+		options = append(options, WithGitignore(true))
+	}
+
+	if args.Rank != nil && *args.Rank {
+		options = append(options, WithRanking())
+	}
+
 	if args.TimeoutMs != nil && *args.TimeoutMs > 0 {
 		timeout := time.Duration(*args.TimeoutMs) * time.Millisecond
 		options = append(options, WithTimeout(timeout))
 	}
 
-	// Perform search using GoRipGrep API
-	results, err := Find(args.Pattern, args.Path, options...)
+	if rg := ripgrepBinary(ctx); rg != "" {
+		options = append(options, WithRipgrep(rg))
+	}
+
+	return options
+}
+
+// writeMatch writes a match and its context lines as ripgrep does.
+func writeMatch(output *strings.Builder, match SearchMatch) {
+	// Context lines surround the match as filename-content, like grep
+	for _, contextLine := range match.Context {
+		output.WriteString(fmt.Sprintf("%s-%s\n", match.File, contextLine))
+	}
+
+	// Format: filename:line:content, or filename:first-last:content
+	// for a multiline match, whose content keeps its line breaks, or
+	// filename:offset:hex dump for a byte match
+	if match.Offset != nil {
+		output.WriteString(fmt.Sprintf("%s:%#x:%s\n", match.File, *match.Offset, match.Content))
+	} else if match.EndLine > match.Line {
+		output.WriteString(fmt.Sprintf("%s:%d-%d:%s\n", match.File, match.Line, match.EndLine, match.Content))
+	} else {
+		output.WriteString(fmt.Sprintf("%s:%d:%s\n", match.File, match.Line, match.Content))
+	}
+
+	for _, contextLine := range match.ContextAfter {
+		output.WriteString(fmt.Sprintf("%s-%s\n", match.File, contextLine))
+	}
+}
+
+// HandleSearchCode implements the search_code tool using GoRipGrep API
+func HandleSearchCode(ctx *server.Context, args SearchCodeArgs) (string, error) {
+	ctx.Logger.Info("Handling search_code tool call with GoRipGrep implementation")
+
+	format := "text"
+	if args.Format != nil && *args.Format != "" {
+		format = strings.ToLower(*args.Format)
+		if format != "text" && format != "heading" && format != "json" {
+			return i18n.T(ctx, i18n.SearchFormatInvalid, *args.Format), nil
+		}
+	}
+
+	options := searchCodeOptions(ctx, args)
+
+	if args.Hex != nil && *args.Hex {
+		needle, err := ParseHex(args.Pattern)
+		if err != nil {
+			return i18n.T(ctx, i18n.SearchHexInvalid, args.Pattern), nil
+		}
+		options = append(options, WithBytes(needle))
+	}
+
+	paginate := args.Paginate != nil && *args.Paginate
+	if args.Cursor != nil && *args.Cursor != "" {
+		after, err := DecodeCursor(*args.Cursor)
+		if err != nil {
+			return i18n.T(ctx, i18n.SearchCursorInvalid, *args.Cursor), nil
+		}
+		options = append(options, WithPagination(after))
+		paginate = true
+	} else if paginate {
+		options = append(options, WithPagination(nil))
+	}
+
+	// Streamed matches are sent as they are found, in batches
+	var streamer *matchStreamer
+	if args.Stream != nil && *args.Stream && (args.Rank == nil || !*args.Rank) && !paginate && ctx.HasProgressToken() {
+		streamer = newMatchStreamer(format, func(progress float64, message string) error {
+			return ctx.SendProgress(progress, nil, message)
+		})
+		options = append(options, WithMatchHandler(streamer.add))
+	}
+
+	// Perform search using GoRipGrep API, until the client cancels the call
+	reqCtx, cancel := request.Context(ctx)
+	defer cancel()
+	results, err := FindContext(reqCtx, args.Pattern, args.Path, options...)
 	if err != nil {
 		if err == context.DeadlineExceeded {
 			ctx.Logger.Info("Search timed out", "pattern", args.Pattern)
-			return "Search timed out.", nil
+			return i18n.T(ctx, i18n.SearchTimedOut), nil
+		}
+		if errors.Is(err, errPatternTooComplex) {
+			return i18n.T(ctx, i18n.SearchPatternTooComplex, args.Pattern, maxRegexProgram), nil
 		}
 		ctx.Logger.Info("Error during search", "error", err, "pattern", args.Pattern)
 		return "", fmt.Errorf("search failed: %v", err)
 	}
 
+	if streamer != nil {
+		streamer.flush()
+		if streamer.err == nil {
+			ctx.Logger.Info("Search streamed successfully", "pattern", args.Pattern, "matches", streamer.sent)
+			if format == "json" {
+				statsJson, err := json.MarshalIndent(results.Stats, "", "  ")
+				if err != nil {
+					return "", fmt.Errorf("search failed: %v", err)
+				}
+				return string(statsJson), nil
+			}
+			return i18n.T(ctx, i18n.SearchStreamed, streamer.sent, results.Stats.FilesScanned, results.Stats.Duration.Round(time.Millisecond)), nil
+		}
+		// The client may have missed matches; return them all instead
+		ctx.Logger.Info("Warning: Could not stream search results", "error", streamer.err)
+	}
+
+	if format == "json" {
+		resultsJson, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			ctx.Logger.Info("Error marshalling search results", "error", err)
+			return "", fmt.Errorf("search failed: %v", err)
+		}
+		ctx.Logger.Info("Search completed successfully", "pattern", args.Pattern, "matches", results.Count())
+		return string(resultsJson), nil
+	}
+
 	// Format results in ripgrep-like output format
-	if !results.HasMatches() {
+	over := results.Stats.OverBudget
+	if !results.HasMatches() && len(over) == 0 {
 		ctx.Logger.Info("Search completed with no matches", "pattern", args.Pattern)
 		return "", nil
 	}
 
 	var output strings.Builder
-	for _, match := range results.Matches {
-		// Format: filename:line:content
-		output.WriteString(fmt.Sprintf("%s:%d:%s\n", match.File, match.Line, match.Content))
-
-		// Add context lines if available
-		if len(match.Context) > 0 {
-			for _, contextLine := range match.Context {
-				output.WriteString(fmt.Sprintf("%s-%s\n", match.File, contextLine))
-			}
-		}
+	writeMatches(&output, format, results.Matches)
+	if results.NextCursor != "" {
+		output.WriteString(i18n.T(ctx, i18n.SearchNextPage, results.NextCursor) + "\n")
+	}
+	if len(over) > 0 {
+		ctx.Logger.Info("Search abandoned files over the regex time budget", "pattern", args.Pattern, "files", len(over))
+		output.WriteString(i18n.T(ctx, i18n.SearchOverBudget, len(over), strings.Join(over, ", ")) + "\n")
 	}
 
 	ctx.Logger.Info("Search completed successfully",
@@ -2,11 +2,14 @@ package search
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -355,3 +358,600 @@ func main() {
 		t.Fatal("Should not find match in test2.txt due to file pattern")
 	}
 }
+
+func TestSearchCodeWithoutGenerated(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"hand.go":  "package demo\n\n// needle\n",
+		"gen.go":   "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage demo\n\n// needle\n",
+		"stamp.py": "#!/usr/bin/env python3\n# Made by our generator\nneedle = 1\n",
+		"late.txt": strings.Repeat("line\n", 30) + "Made by our generator\nneedle\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := Find("needle", dir, WithoutGenerated("Made by our generator"))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	got := results.Files()
+	for i := range got {
+		got[i] = filepath.Base(got[i])
+	}
+	want := []string{"hand.go", "late.txt"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Files = %q, want %q", got, want)
+	}
+}
+
+func TestSearchCodeGitignore(t *testing.T) {
+	repo := t.TempDir()
+	files := map[string]string{
+		".git/info/exclude":         "local.txt\n",
+		".gitignore":                "node_modules/\n*.log\n!keep.log\n",
+		"main.go":                   "needle\n",
+		"debug.log":                 "needle\n",
+		"keep.log":                  "needle\n",
+		"local.txt":                 "needle\n",
+		"node_modules/dep/index.js": "needle\n",
+		"src/.gitignore":            "gen/\n!gen/\n/*.tmp\n",
+		"src/gen/out.go":            "needle\n",
+		"src/scratch.tmp":           "needle\n",
+		"src/deep/other.tmp":        "needle\n",
+		"vendor/.gitignore":         "*\n",
+		"vendor/lib.go":             "needle\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	relFiles := func(results *SearchResults, root string) string {
+		var got []string
+		for _, file := range results.Files() {
+			rel, _ := filepath.Rel(root, file)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return fmt.Sprint(got)
+	}
+
+	results, err := Find("needle", repo, WithGitignore(true), WithHidden())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if got, want := relFiles(results, repo), "[keep.log main.go src/deep/other.tmp src/gen/out.go]"; got != want {
+		t.Errorf("Files = %s, want %s", got, want)
+	}
+
+	// Searching a subdirectory still applies the repository's .gitignore files
+	sub := filepath.Join(repo, "src")
+	results, err = Find("needle", sub, WithGitignore(true))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if got, want := relFiles(results, sub), "[deep/other.tmp gen/out.go]"; got != want {
+		t.Errorf("Files under src = %s, want %s", got, want)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: repo, Pattern: "needle"})
+	if err != nil || strings.Contains(result, "node_modules") || !strings.Contains(result, "main.go") {
+		t.Errorf("HandleSearchCode = %q, %v; want gitignored files skipped by default", result, err)
+	}
+	off := false
+	result, err = HandleSearchCode(ctx, SearchCodeArgs{Path: repo, Pattern: "needle", ExcludeGitignored: &off})
+	if err != nil || !strings.Contains(result, "node_modules") {
+		t.Errorf("HandleSearchCode with excludeGitignored false = %q, %v", result, err)
+	}
+}
+
+func TestHandleSearchCodeExcludePatterns(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{"main.go", "main_test.go", "dist/bundle.js", "internal/gen/api.go", "internal/api.go", "dist"}
+	for _, name := range files[:5] {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("needle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file named like a directory pattern is only skipped by the plain pattern
+	if err := os.WriteFile(filepath.Join(dir, "internal", "dist"), []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{
+		Path:            dir,
+		Pattern:         "needle",
+		ExcludePatterns: []string{"dist/", "*_test.go", "internal/gen"},
+	})
+	if err != nil {
+		t.Fatalf("HandleSearchCode failed: %v", err)
+	}
+	var got []string
+	for _, line := range strings.Split(result, "\n") {
+		rel, _ := filepath.Rel(dir, strings.SplitN(line, ":", 2)[0])
+		got = append(got, filepath.ToSlash(rel))
+	}
+	if want := "[internal/api.go internal/dist main.go]"; fmt.Sprint(got) != want {
+		t.Errorf("Searched files = %v, want %s", got, want)
+	}
+}
+
+func TestHandleSearchCodeFilePatterns(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "go.mod", "go.sum", "README.md", "archive.zip"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("needle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	markdown := "*.md"
+	tests := []struct {
+		name         string
+		filePattern  *string
+		filePatterns []string
+		want         string
+	}{
+		{"patterns", nil, []string{"*.go", "*.mod"}, "[go.mod main.go]"},
+		{"pattern and patterns", &markdown, []string{"*.go"}, "[README.md main.go]"},
+		{"no match", nil, []string{"*.rs", "*.toml"}, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := HandleSearchCode(ctx, SearchCodeArgs{
+				Path:         dir,
+				Pattern:      "needle",
+				FilePattern:  tt.filePattern,
+				FilePatterns: tt.filePatterns,
+			})
+			if err != nil {
+				t.Fatalf("HandleSearchCode failed: %v", err)
+			}
+			got := []string{}
+			for _, line := range strings.Split(result, "\n") {
+				if strings.Contains(line, ":1:") {
+					got = append(got, filepath.Base(strings.SplitN(line, ":", 2)[0]))
+				}
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("Searched files = %v, want %s\n%s", got, tt.want, result)
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.ts", "src/app/main.ts", true},
+		{"src/**/*.ts", "src/main.ts", true},
+		{"src/**/*.ts", "src/app/deep/main.ts", true},
+		{"src/**/*.ts", "lib/src/main.ts", false},
+		{"src/*.ts", "src/app/main.ts", false},
+		{"**/testdata", "pkg/a/testdata", true},
+		{"*.{go,mod}", "go.mod", true},
+		{"internal/gen", "internal/gen", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, path.Base(tt.rel), tt.rel); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestHandleSearchCodeDoublestar(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/main.ts", "src/app/deep/view.ts", "src/app/testdata/fixture.ts", "src/app/util.go", "lib/src/other.ts"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("needle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	pattern := "src/**/*.ts"
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{
+		Path:            dir,
+		Pattern:         "needle",
+		FilePattern:     &pattern,
+		ExcludePatterns: []string{"**/testdata"},
+	})
+	if err != nil {
+		t.Fatalf("HandleSearchCode failed: %v", err)
+	}
+	got := []string{}
+	for _, line := range strings.Split(result, "\n") {
+		if strings.Contains(line, ":1:") {
+			rel, _ := filepath.Rel(dir, strings.SplitN(line, ":", 2)[0])
+			got = append(got, filepath.ToSlash(rel))
+		}
+	}
+	if want := "[src/app/deep/view.ts src/main.ts]"; fmt.Sprint(got) != want {
+		t.Errorf("Searched files = %v, want %s\n%s", got, want, result)
+	}
+}
+
+func TestHandleSearchCodeWholeWord(t *testing.T) {
+	dir := t.TempDir()
+	content := "add(1, 2)\naddress := home\nx.Add(y)\npadded\nadd_one()\nreadd\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	tests := []struct {
+		name       string
+		pattern    string
+		ignoreCase bool
+		want       string
+	}{
+		{"literal", "add", false, "[1]"},
+		{"ignore case", "add", true, "[1 3]"},
+		{"regex", `add|re\w+`, false, "[1 6]"},
+		{"underscore", "one", false, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wholeWord := true
+			result, err := HandleSearchCode(ctx, SearchCodeArgs{
+				Path:       dir,
+				Pattern:    tt.pattern,
+				WholeWord:  &wholeWord,
+				IgnoreCase: &tt.ignoreCase,
+			})
+			if err != nil {
+				t.Fatalf("HandleSearchCode failed: %v", err)
+			}
+			got := []string{}
+			for _, line := range strings.Split(result, "\n") {
+				if parts := strings.SplitN(line, ":", 3); len(parts) == 3 && strings.HasSuffix(parts[0], "main.go") {
+					got = append(got, parts[1])
+				}
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("Matched lines = %v, want %s\n%s", got, tt.want, result)
+			}
+		})
+	}
+}
+
+func TestHandleSearchCodeLiteral(t *testing.T) {
+	dir := t.TempDir()
+	content := "foo.bar(x)\nfooXbar(y)\nvalues[i]\nabc\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	tests := []struct {
+		name       string
+		pattern    string
+		ignoreCase bool
+		want       string
+	}{
+		{"unbalanced parenthesis", "foo.bar(", false, "[1]"},
+		{"brackets", "values[i]", false, "[3]"},
+		{"dot", "a.c", false, "[]"},
+		{"ignore case", "FOO.BAR(", true, "[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			literal := true
+			result, err := HandleSearchCode(ctx, SearchCodeArgs{
+				Path:       dir,
+				Pattern:    tt.pattern,
+				Literal:    &literal,
+				IgnoreCase: &tt.ignoreCase,
+			})
+			if err != nil {
+				t.Fatalf("HandleSearchCode failed: %v", err)
+			}
+			got := []string{}
+			for _, line := range strings.Split(result, "\n") {
+				if parts := strings.SplitN(line, ":", 3); len(parts) == 3 && strings.HasSuffix(parts[0], "main.go") {
+					got = append(got, parts[1])
+				}
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("Matched lines = %v, want %s\n%s", got, tt.want, result)
+			}
+		})
+	}
+
+	// Without the flag the pattern is taken for a regex
+	if _, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "foo.bar("}); err == nil {
+		t.Error("An unbalanced regex searched without literal succeeded")
+	}
+}
+
+func TestHandleSearchCodeJSON(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	format := "JSON"
+	contextLines := 1
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "main", Format: &format, ContextLines: &contextLines})
+	if err != nil {
+		t.Fatalf("HandleSearchCode failed: %v", err)
+	}
+	var results SearchResults
+	if err := json.Unmarshal([]byte(result), &results); err != nil {
+		t.Fatalf("Result is not JSON: %v\n%s", err, result)
+	}
+	want := []SearchMatch{
+		{File: file, Line: 1, Column: 9, Content: "package main", ContextAfter: []string{""}, Spans: []MatchSpan{{8, 12, 8, 12}}},
+		{File: file, Line: 3, Column: 6, Content: "func main() {}", Context: []string{""}, Spans: []MatchSpan{{5, 9, 5, 9}}},
+	}
+	if !reflect.DeepEqual(results.Matches, want) {
+		t.Errorf("Matches = %+v\nwant %+v", results.Matches, want)
+	}
+	if results.Stats.FilesScanned != 1 || results.Stats.MatchesFound != 2 {
+		t.Errorf("Stats = %+v", results.Stats)
+	}
+
+	// No matches is an empty list rather than an empty answer
+	result, _ = HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "absent", Format: &format})
+	if !strings.Contains(result, `"matches": []`) {
+		t.Errorf("HandleSearchCode without matches = %s", result)
+	}
+
+	format = "xml"
+	result, _ = HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "main", Format: &format})
+	if !strings.Contains(result, "Unsupported format") {
+		t.Errorf("HandleSearchCode with format xml = %q", result)
+	}
+}
+
+func TestHandleSearchCodeContext(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	content := "one\ntwo\nneedle a\nthree\nneedle b\nfour\nfive\nsix\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	intPtr := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		args SearchCodeArgs
+		want string
+	}{
+		{"after only", SearchCodeArgs{AfterContext: intPtr(2)},
+			"F:3:needle a\nF-three\nF-needle b\nF:5:needle b\nF-four\nF-five"},
+		{"before only", SearchCodeArgs{BeforeContext: intPtr(1)},
+			"F-two\nF:3:needle a\nF-three\nF:5:needle b"},
+		{"context lines with before override", SearchCodeArgs{ContextLines: intPtr(1), BeforeContext: intPtr(0)},
+			"F:3:needle a\nF-three\nF:5:needle b\nF-four"},
+		{"after context of the last match allowed", SearchCodeArgs{AfterContext: intPtr(1), MaxResults: intPtr(1)},
+			"F:3:needle a\nF-three"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.Path = dir
+			tt.args.Pattern = "needle"
+			result, err := HandleSearchCode(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("HandleSearchCode failed: %v", err)
+			}
+			if got := strings.ReplaceAll(result, file, "F"); got != tt.want {
+				t.Errorf("HandleSearchCode =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchCodeMaxFileSize(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.go")
+	if err := os.WriteFile(small, []byte("needle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A bundle over the default limit, with its only match at the start
+	big := "needle\n" + strings.Repeat("x\n", int(defaultMaxFileSize/2))
+	if err := os.WriteFile(filepath.Join(dir, "bundle.min.js"), []byte(big), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options []SearchOption
+		want    int
+	}{
+		{"default limit", nil, 1},
+		{"lower limit", []SearchOption{WithMaxFileSize(3)}, 0},
+		{"no limit", []SearchOption{WithMaxFileSize(0)}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Find("needle", dir, tt.options...)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+			if results.Count() != tt.want {
+				t.Errorf("Found %d matches, want %d: %+v", results.Count(), tt.want, results.Matches)
+			}
+			if tt.want == 1 && results.Stats.BytesScanned > 1024 {
+				t.Errorf("BytesScanned = %d, want the skipped bundle left out", results.Stats.BytesScanned)
+			}
+		})
+	}
+}
+
+func TestHandleSearchCodeMaxMatchesPerFile(t *testing.T) {
+	dir := t.TempDir()
+	// A generated file whose matches would use up maxResults on their own
+	if err := os.WriteFile(filepath.Join(dir, "a_gen.go"), []byte(strings.Repeat("needle\n", 50)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.go"), []byte("x\nneedle\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	perFile, maxResults, paginate := 2, 10, true
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "needle", MaxMatchesPerFile: &perFile, MaxResults: &maxResults, Paginate: &paginate})
+	if err != nil {
+		t.Fatalf("HandleSearchCode failed: %v", err)
+	}
+	gen, b := filepath.Join(dir, "a_gen.go"), filepath.Join(dir, "b.go")
+	want := gen + ":1:needle\n" + gen + ":2:needle\n" + b + ":2:needle"
+	if result != want {
+		t.Errorf("HandleSearchCode =\n%s\nwant\n%s", result, want)
+	}
+}
+
+func TestSearchCodeFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go":       "needle\n",
+		"b.go":       "needle\n",
+		"sub/c.go":   "x\nneedle\n",
+		"sub/d.txt":  "needle\n",
+		".hidden.go": "needle\n",
+		".gitignore": "sub/\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	pattern := "*.go"
+	list := []string{"a.go", "sub/c.go", "sub/d.txt", "missing.go", "sub", filepath.Join(dir, ".hidden.go"), "./a.go"}
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "needle", Files: list, FilePattern: &pattern})
+	if err != nil {
+		t.Fatalf("HandleSearchCode failed: %v", err)
+	}
+	want := filepath.Join(dir, ".hidden.go") + ":1:needle\n" +
+		filepath.Join(dir, "a.go") + ":1:needle\n" +
+		filepath.Join(dir, "sub", "c.go") + ":2:needle"
+	if result != want {
+		t.Errorf("HandleSearchCode =\n%s\nwant\n%s", result, want)
+	}
+}
+
+func TestTuningOptions(t *testing.T) {
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	workers, bufferKB := 2, 256
+	config := SearchConfig{MaxWorkers: 8, BufferSize: 64 * 1024}
+	for _, option := range tuningOptions(ctx, &workers, &bufferKB) {
+		option(&config)
+	}
+	if config.MaxWorkers != 2 || config.BufferSize != 256*1024 {
+		t.Errorf("MaxWorkers = %d, BufferSize = %d; want 2 and 256 KB", config.MaxWorkers, config.BufferSize)
+	}
+	if args := strings.Join(ripgrepArgs(&config), " "); !strings.Contains(args, "--threads 2") {
+		t.Errorf("ripgrepArgs = %s, want --threads 2", args)
+	}
+
+	// A search with 1 worker and small blocks finds what the defaults do
+	dir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte(strings.Repeat("hay\n", 50000)+"needle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	one, kb := 1, 64
+	result, err := HandleSearchCode(ctx, SearchCodeArgs{Path: dir, Pattern: "needle", Workers: &one, BufferKB: &kb})
+	if err != nil || strings.Count(result, ":50001:needle") != 5 {
+		t.Errorf("HandleSearchCode with 1 worker = %q, %v; want 5 matches", result, err)
+	}
+}
+
+func TestSearchMaxResultsStopsEarly(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 500; i++ {
+		content := fmt.Sprintf("needle %d\nhay\nneedle again\n", i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%03d.txt", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := Find("needle", dir, WithMaxResults(0))
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	for run := 0; run < 20; run++ {
+		results, err := Find("needle", dir, WithMaxResults(25), WithWorkers(8))
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if !reflect.DeepEqual(results.Matches, all.Matches[:25]) {
+			t.Fatalf("run %d: matches are not the first 25 in walk order:\n%+v", run, results.Matches)
+		}
+		if results.Stats.FilesScanned > 100 {
+			t.Errorf("run %d: %d files searched for 25 matches in 13 files", run, results.Stats.FilesScanned)
+		}
+	}
+}
+
+func TestFindContextCancelled(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 200; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%03d.txt", i)), []byte("needle\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := FindContext(ctx, "needle", dir, WithMaxResults(0))
+	if err == nil && results.Stats.FilesScanned > 0 {
+		t.Errorf("cancelled search scanned %d files", results.Stats.FilesScanned)
+	}
+
+	// The same search without a cancelled context finds every file
+	results, err = FindContext(context.Background(), "needle", dir, WithMaxResults(0))
+	if err != nil || results.Count() != 200 {
+		t.Errorf("FindContext found %d matches, %v; want 200", results.Count(), err)
+	}
+}
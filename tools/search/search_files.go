@@ -3,20 +3,44 @@ package search
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
 
+	"gocreate/tools/i18n"
+	"gocreate/tools/request"
+
 	"github.com/localrivet/gomcp/server"
 )
 
-// SearchFilesArgs defines the arguments for the search_files tool.
+// SearchFilesArgs defines the arguments for the search_files tool. Apart from
+// regex and the snake_case file patterns, they are those of search_code.
 type SearchFilesArgs struct {
-	Path        string `json:"path" description:"The path of the directory to search in." required:"true"`
-	Regex       string `json:"regex" description:"The regular expression pattern to search for." required:"true"`
-	FilePattern string `json:"file_pattern,omitempty" description:"Glob pattern to filter files (e.g., '*.ts'). If not provided, searches all files."`
+	Path              string   `json:"path" description:"The path of the directory to search in." required:"true"`
+	Regex             string   `json:"regex" description:"The regular expression pattern to search for." required:"true"`
+	FilePattern       string   `json:"file_pattern,omitempty" description:"Glob pattern to filter files, matched against the file name or its path relative to path; ** spans directories (e.g., '*.ts', 'src/**/*.ts'). If not provided, searches all files."`
+	FilePatterns      []string `json:"file_patterns,omitempty" description:"Glob patterns to filter files, matched like file_pattern; a file matching any of them is searched (e.g., ['*.go', '*.mod']). Combined with file_pattern."`
+	Files             []string `json:"files,omitempty" description:"Search only these files instead of walking path. Relative paths are taken from path."`
+	IgnoreCase        *bool    `json:"ignoreCase,omitempty" description:"Perform case-insensitive search."`
+	Literal           *bool    `json:"literal,omitempty" description:"Search for regex as plain text, so characters such as . ( [ * match themselves."`
+	Multiline         *bool    `json:"multiline,omitempty" description:"Search whole files instead of single lines, so the pattern can span lines. Files over 32 MB are skipped."`
+	WholeWord         *bool    `json:"wholeWord,omitempty" description:"Only match whole words."`
+	MaxResults        *int     `json:"maxResults,omitempty" description:"Maximum number of results to return."`
+	MaxMatchesPerFile *int     `json:"maxMatchesPerFile,omitempty" description:"Maximum number of results to return from any one file."`
+	IncludeHidden     *bool    `json:"includeHidden,omitempty" description:"Include hidden files and directories in the search."`
+	MaxFileSize       *int64   `json:"maxFileSize,omitempty" description:"Skip files larger than this many bytes. Defaults to 4 MB; 0 searches files of any size."`
+	ContextLines      *int     `json:"contextLines,omitempty" description:"Number of lines to include before and after each match in its context. Defaults to 2."`
+	BeforeContext     *int     `json:"beforeContext,omitempty" description:"Number of lines to include before each match. Overrides contextLines."`
+	AfterContext      *int     `json:"afterContext,omitempty" description:"Number of lines to include after each match. Overrides contextLines."`
+	TimeoutMs         *int     `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds for the search."`
+	Archives          *bool    `json:"archives,omitempty" description:"Also search inside zip, jar and tar.gz archives (size-capped)."`
+	Documents         *bool    `json:"documents,omitempty" description:"Also search the text of PDF, DOCX and XLSX files."`
+	ExcludeGenerated  *bool    `json:"excludeGenerated,omitempty" description:"Skip generated files."`
+	ExcludePatterns   []string `json:"excludePatterns,omitempty" description:"Glob patterns of files and directories to skip, matched against names and paths relative to path."`
+	ExcludeGitignored *bool    `json:"excludeGitignored,omitempty" description:"Skip files ignored by .gitignore, .ignore and .rgignore files. Defaults to true."`
+	Rank              *bool    `json:"rank,omitempty" description:"Order results by relevance instead of by path."`
+	Workers           *int     `json:"workers,omitempty" description:"Number of files searched at once. Defaults to the searchWorkers config value, or one per CPU."`
+	BufferKB          *int     `json:"bufferKB,omitempty" description:"Size in KB of the blocks files are read in, at least 64. Defaults to the searchBufferKB config value."`
 }
 
 // SearchResult represents a single match found during search.
@@ -29,76 +53,143 @@ type SearchFilesResult struct {
 	Context  string `json:"context"` // Surrounding lines for context
 }
 
-// HandleSearchFilesNew implements the search_files tool using the new API
-func HandleSearchFilesNew(ctx *server.Context, args SearchFilesArgs) (string, error) {
-	ctx.Logger.Info("Handling search_files tool call")
+// defaultFilesContext is the number of lines search_files shows before and
+// after each match unless told otherwise.
+const defaultFilesContext = 2
+
+// codeArgs returns the search_code arguments that run the same search.
+func (a SearchFilesArgs) codeArgs() SearchCodeArgs {
+	args := SearchCodeArgs{
+		Path:              a.Path,
+		Pattern:           a.Regex,
+		Files:             a.Files,
+		FilePatterns:      a.FilePatterns,
+		IgnoreCase:        a.IgnoreCase,
+		Literal:           a.Literal,
+		Multiline:         a.Multiline,
+		WholeWord:         a.WholeWord,
+		MaxResults:        a.MaxResults,
+		MaxMatchesPerFile: a.MaxMatchesPerFile,
+		IncludeHidden:     a.IncludeHidden,
+		MaxFileSize:       a.MaxFileSize,
+		ContextLines:      a.ContextLines,
+		BeforeContext:     a.BeforeContext,
+		AfterContext:      a.AfterContext,
+		TimeoutMs:         a.TimeoutMs,
+		Archives:          a.Archives,
+		Documents:         a.Documents,
+		ExcludeGenerated:  a.ExcludeGenerated,
+		ExcludePatterns:   a.ExcludePatterns,
+		ExcludeGitignored: a.ExcludeGitignored,
+		Rank:              a.Rank,
+		Workers:           a.Workers,
+		BufferKB:          a.BufferKB,
+	}
+	if a.FilePattern != "" {
+		args.FilePattern = &a.FilePattern
+	}
+	if a.ContextLines == nil && a.BeforeContext == nil && a.AfterContext == nil {
+		lines := defaultFilesContext
+		args.ContextLines = &lines
+	}
+	return args
+}
 
-	// Compile the regex
-	re, err := regexp.Compile(args.Regex)
+// searchFiles runs the search described by args with the search engine of
+// search_code and returns its matches as search_files results.
+func searchFiles(ctx *server.Context, args SearchFilesArgs) ([]SearchFilesResult, error) {
+	codeArgs := args.codeArgs()
+	options := searchCodeOptions(ctx, codeArgs)
+
+	// The text each match starts with is found again with the engine's pattern
+	config := SearchConfig{Pattern: args.Regex}
+	for _, option := range options {
+		option(&config)
+	}
+	source := regexpSource(&config, args.Regex)
+	if config.IgnoreCase {
+		source = "(?i)" + source
+	}
+	re, err := compileRegexp(source)
 	if err != nil {
-		ctx.Logger.Info("Error compiling regex", "regex", args.Regex, "error", err)
-		return "Error compiling regex: " + err.Error(), err
+		return nil, err
 	}
 
-	var results []SearchFilesResult
-
-	// Walk the directory
-	err = filepath.Walk(args.Path, func(filePath string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			ctx.Logger.Info("Error walking path", "path", filePath, "error", walkErr)
-			return walkErr // Continue walking other paths
+	reqCtx, cancel := request.Context(ctx)
+	defer cancel()
+	results, err := FindContext(reqCtx, args.Regex, args.Path, options...)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]SearchFilesResult, 0, len(results.Matches))
+	perFile := map[string]int{}
+	for _, m := range results.Matches {
+		// The context holds the match and the lines around it, numbered
+		var context []string
+		first := m.Line - len(m.Context)
+		for i, line := range m.Context {
+			context = append(context, fmt.Sprintf("%d | %s", first+i, line))
+		}
+		for i, line := range strings.Split(m.Content, "\n") {
+			context = append(context, fmt.Sprintf("%d | %s", m.Line+i, line))
+		}
+		for i, line := range m.ContextAfter {
+			context = append(context, fmt.Sprintf("%d | %s", max(m.Line, m.EndLine)+1+i, line))
 		}
 
-		if !info.IsDir() {
-			// Apply file pattern filter if provided
-			if args.FilePattern != "" {
-				matched, _ := filepath.Match(args.FilePattern, info.Name())
-				if !matched {
-					return nil // Skip this file
+		// The engine reports a line once; search_files reports each match on
+		// it, from the first the engine found
+		var locs [][]int
+		if m.Column >= 1 && m.Column <= len(m.Content)+1 {
+			rest := m.Content[m.Column-1:]
+			if config.Multiline {
+				if loc := re.FindStringIndex(rest); loc != nil {
+					locs = [][]int{loc}
 				}
+			} else {
+				locs = re.FindAllStringIndex(rest, -1)
 			}
-
-			// Read the file content
-			contentBytes, readErr := os.ReadFile(filePath)
-			if readErr != nil {
-				ctx.Logger.Info("Error reading file for search", "filePath", filePath, "error", readErr)
-				return nil // Skip this file, continue walking
+		}
+		if len(locs) == 0 {
+			locs = [][]int{nil}
+		}
+		for _, loc := range locs {
+			if config.MaxResults > 0 && len(found) >= config.MaxResults ||
+				config.MaxPerFile > 0 && perFile[m.File] >= config.MaxPerFile {
+				break
 			}
-			content := string(contentBytes)
-			lines := strings.Split(content, "\n")
-
-			// Search for matches line by line
-			for i, line := range lines {
-				matches := re.FindAllStringIndex(line, -1)
-				for _, matchIndex := range matches {
-					start, end := matchIndex[0], matchIndex[1]
-					matchText := line[start:end]
-
-					// Get context (e.g., 2 lines before and after)
-					contextLines := []string{}
-					contextStart := max(0, i-2)
-					contextEnd := min(len(lines)-1, i+2)
-					for j := contextStart; j <= contextEnd; j++ {
-						contextLines = append(contextLines, fmt.Sprintf("%d | %s", j+1, lines[j]))
-					}
-					contextText := strings.Join(contextLines, "\n")
-
-					results = append(results, SearchFilesResult{
-						FilePath: filePath,
-						Line:     i + 1,     // 1-based line number
-						Column:   start + 1, // 1-based column number
-						Match:    matchText,
-						LineText: line,
-						Context:  contextText,
-					})
-				}
+			perFile[m.File]++
+			result := SearchFilesResult{
+				FilePath: m.File,
+				Line:     m.Line,
+				Column:   m.Column,
+				LineText: m.Content,
+				Context:  strings.Join(context, "\n"),
+			}
+			if loc != nil {
+				result.Column += loc[0]
+				result.Match = m.Content[m.Column-1+loc[0] : m.Column-1+loc[1]]
 			}
+			found = append(found, result)
 		}
-		return nil
-	})
+	}
+	return found, nil
+}
 
+// HandleSearchFilesNew implements the search_files tool using the new API
+func HandleSearchFilesNew(ctx *server.Context, args SearchFilesArgs) (string, error) {
+	ctx.Logger.Info("Handling search_files tool call")
+
+	results, err := searchFiles(ctx, args)
 	if err != nil {
-		ctx.Logger.Info("Error during directory walk for search", "error", err)
+		if err == context.DeadlineExceeded {
+			ctx.Logger.Info("Search timed out", "regex", args.Regex)
+			return i18n.T(ctx, i18n.SearchTimedOut), nil
+		}
+		if errors.Is(err, errPatternTooComplex) {
+			return i18n.T(ctx, i18n.SearchPatternTooComplex, args.Regex, maxRegexProgram), nil
+		}
+		ctx.Logger.Info("Error during file search", "regex", args.Regex, "error", err)
 		return "Error during file search: " + err.Error(), err
 	}
 
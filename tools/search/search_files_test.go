@@ -2,11 +2,15 @@ package search
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"regexp"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/localrivet/gomcp/server"
 )
 
 // Test helper function (reuse from search_code_test.go)
@@ -32,70 +36,12 @@ func createTestFilesForSearch(t *testing.T, files map[string]string) string {
 	return tempDir
 }
 
-// Direct test of search functionality without server context
+// searchFilesDirectly runs a search_files search without the JSON output
 func searchFilesDirectly(args SearchFilesArgs) ([]SearchFilesResult, error) {
-	// Compile the regex
-	re, err := regexp.Compile(args.Regex)
-	if err != nil {
-		return nil, err
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
-
-	var results []SearchFilesResult
-
-	// Walk the directory
-	err = filepath.Walk(args.Path, func(filePath string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-
-		if !info.IsDir() {
-			// Apply file pattern filter if provided
-			if args.FilePattern != "" {
-				matched, _ := filepath.Match(args.FilePattern, info.Name())
-				if !matched {
-					return nil
-				}
-			}
-
-			// Read the file content
-			contentBytes, readErr := os.ReadFile(filePath)
-			if readErr != nil {
-				return nil // Skip this file, continue walking
-			}
-			content := string(contentBytes)
-			lines := strings.Split(content, "\n")
-
-			// Search for matches line by line
-			for i, line := range lines {
-				matches := re.FindAllStringIndex(line, -1)
-				for _, matchIndex := range matches {
-					start, end := matchIndex[0], matchIndex[1]
-					matchText := line[start:end]
-
-					// Get context (e.g., 2 lines before and after)
-					contextLines := []string{}
-					contextStart := max(0, i-2)
-					contextEnd := min(len(lines)-1, i+2)
-					for j := contextStart; j <= contextEnd; j++ {
-						contextLines = append(contextLines, fmt.Sprintf("%d | %s", j+1, lines[j]))
-					}
-					contextText := strings.Join(contextLines, "\n")
-
-					results = append(results, SearchFilesResult{
-						FilePath: filePath,
-						Line:     i + 1,     // 1-based line number
-						Column:   start + 1, // 1-based column number
-						Match:    matchText,
-						LineText: line,
-						Context:  contextText,
-					})
-				}
-			}
-		}
-		return nil
-	})
-
-	return results, err
+	return searchFiles(ctx, args)
 }
 
 func TestSearchFilesDirectly(t *testing.T) {
@@ -220,6 +166,17 @@ func Helper() string {
 			wantMatches: 1, // ## Features
 			wantFiles:   []string{"README.md"},
 		},
+		{
+			name: "search in markdown or JSON files",
+			args: SearchFilesArgs{
+				Path:         tempDir,
+				Regex:        `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}|##\s+\w+`,
+				FilePatterns: []string{"*.md", "*.json"},
+			},
+			wantErr:     false,
+			wantMatches: 3, // two email addresses and ## Features
+			wantFiles:   []string{"README.md", "config.json"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -540,3 +497,62 @@ Line 7: End of file`
 		}
 	}
 }
+
+func TestSearchFilesUsesSearchCodeOptions(t *testing.T) {
+	tempDir := createTestFilesForSearch(t, map[string]string{
+		".gitignore":      "build/\n",
+		"a.go":            "add(x)\naddress := 1\nadd(y)\n",
+		"build/out.go":    "add(z)\n",
+		".hidden/h.go":    "add(h)\n",
+		"docs/readme.txt": "Add it\n",
+	})
+	defer os.RemoveAll(tempDir)
+
+	wholeWord, ignoreCase, perFile, noContext := true, true, 1, 0
+	results, err := searchFilesDirectly(SearchFilesArgs{
+		Path:              tempDir,
+		Regex:             "add",
+		WholeWord:         &wholeWord,
+		IgnoreCase:        &ignoreCase,
+		MaxMatchesPerFile: &perFile,
+		ContextLines:      &noContext,
+	})
+	if err != nil {
+		t.Fatalf("searchFilesDirectly() error = %v", err)
+	}
+	var got []string
+	for _, r := range results {
+		rel, _ := filepath.Rel(tempDir, r.FilePath)
+		got = append(got, fmt.Sprintf("%s:%d:%d:%s|%s", filepath.ToSlash(rel), r.Line, r.Column, r.Match, r.Context))
+	}
+	want := []string{"a.go:1:1:add|1 | add(x)", "docs/readme.txt:1:1:Add|1 | Add it"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("search_files results = %q, want %q", got, want)
+	}
+}
+
+func TestSearchFilesReportsEachMatchOnALine(t *testing.T) {
+	tempDir := createTestFilesForSearch(t, map[string]string{
+		"a.txt": "x 🚀 y 🚀\nnone\n",
+	})
+	defer os.RemoveAll(tempDir)
+
+	results, err := searchFilesDirectly(SearchFilesArgs{Path: tempDir, Regex: "🚀|y"})
+	if err != nil {
+		t.Fatalf("searchFilesDirectly() error = %v", err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, fmt.Sprintf("%d:%d:%s", r.Line, r.Column, r.Match))
+	}
+	want := []string{"1:3:🚀", "1:8:y", "1:10:🚀"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("search_files results = %q, want %q", got, want)
+	}
+
+	perFile := 2
+	results, _ = searchFilesDirectly(SearchFilesArgs{Path: tempDir, Regex: "🚀|y", MaxMatchesPerFile: &perFile})
+	if len(results) != perFile {
+		t.Errorf("got %d results with maxMatchesPerFile %d", len(results), perFile)
+	}
+}
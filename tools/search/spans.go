@@ -0,0 +1,79 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MatchSpan is where one match lies in the content of a SearchMatch, as
+// 0-based byte and rune offsets. End and EndRune are exclusive.
+type MatchSpan struct {
+	Start     int `json:"start"`
+	End       int `json:"end"`
+	StartRune int `json:"start_rune"`
+	EndRune   int `json:"end_rune"`
+}
+
+// newSpans turns byte ranges of content into spans.
+func newSpans(content string, locs [][]int) []MatchSpan {
+	if len(locs) == 0 {
+		return nil
+	}
+	spans := make([]MatchSpan, 0, len(locs))
+	runes, counted := 0, 0 // runes in content[:counted]
+	runesTo := func(off int) int {
+		off = min(max(off, counted), len(content))
+		runes += utf8.RuneCountInString(content[counted:off])
+		counted = off
+		return runes
+	}
+	for _, loc := range locs {
+		span := MatchSpan{Start: loc[0], End: loc[1]}
+		span.StartRune = runesTo(loc[0])
+		span.EndRune = runesTo(loc[1])
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// findAll returns the byte ranges of every match of the pattern in line.
+func (e *SearchEngine) findAll(line string) [][]int {
+	if e.pattern != nil {
+		return e.pattern.FindAllStringIndex(line, -1)
+	}
+	if e.literalSearch == "" {
+		return nil
+	}
+	haystack := line
+	if e.config.IgnoreCase {
+		if !isASCII(line) {
+			// Lower-casing could change the length of the line, and so the offsets
+			return regexp.MustCompile("(?i)"+regexp.QuoteMeta(e.literalSearch)).FindAllStringIndex(line, -1)
+		}
+		haystack = strings.ToLower(line)
+	}
+	var locs [][]int
+	for pos := 0; pos <= len(haystack); {
+		idx := strings.Index(haystack[pos:], e.literalSearch)
+		if idx < 0 {
+			break
+		}
+		start := pos + idx
+		locs = append(locs, []int{start, start + len(e.literalSearch)})
+		pos = start + len(e.literalSearch)
+	}
+	return locs
+}
+
+// contentOffset maps an offset in raw text to the offset it has in the
+// content reported for it, from which the \r of each \r\n is removed.
+func contentOffset(raw string, off int) int {
+	removed := 0
+	for i := 0; i < off && i < len(raw); i++ {
+		if raw[i] == '\r' && (i+1 == len(raw) || raw[i+1] == '\n') {
+			removed++
+		}
+	}
+	return off - removed
+}
@@ -0,0 +1,43 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindAllSpans(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		options []SearchOption
+		line    string
+		want    []MatchSpan
+	}{
+		{"literal", "ab", nil, "ab xab ab", []MatchSpan{{0, 2, 0, 2}, {4, 6, 4, 6}, {7, 9, 7, 9}}},
+		{"regex", `g[^ ,]+`, nil, "größe, gut", []MatchSpan{{0, 7, 0, 5}, {9, 12, 7, 10}}},
+		{"ignore case", "needle", []SearchOption{WithIgnoreCase()}, "Needle NEEDLE", []MatchSpan{{0, 6, 0, 6}, {7, 13, 7, 13}}},
+		{"ignore case multibyte", "ü", []SearchOption{WithIgnoreCase()}, "aÜbü", []MatchSpan{{1, 3, 1, 2}, {4, 6, 3, 4}}},
+		{"no match", "zz", nil, "abc", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := SearchConfig{Pattern: tt.pattern}
+			for _, option := range tt.options {
+				option(&config)
+			}
+			engine := NewSearchEngine(config)
+			if got := newSpans(tt.line, engine.findAll(tt.line)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("spans = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentOffset(t *testing.T) {
+	raw := "a\r\nbc\rd\r\n\r"
+	for off, want := range map[int]int{0: 0, 1: 1, 3: 2, 6: 5, 7: 6, 9: 7, 10: 7} {
+		if got := contentOffset(raw, off); got != want {
+			t.Errorf("contentOffset(%q, %d) = %d, want %d", raw, off, got, want)
+		}
+	}
+}
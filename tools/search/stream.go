@@ -0,0 +1,73 @@
+package search
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Matches are streamed in batches of up to streamBatchSize, and a batch is
+// sent once streamInterval has passed even if it is not full.
+const (
+	streamBatchSize = 50
+	streamInterval  = 500 * time.Millisecond
+)
+
+// WithMatchHandler calls fn with each match as it is found, before the search
+// completes, so matches can be reported while it runs. fn is called from one
+// goroutine at a time: by the built-in engine in walk order, by rg in the
+// order it finds them. It is not called for ranked searches, whose order is
+// only known at the end.
+func WithMatchHandler(fn func(SearchMatch)) SearchOption {
+	return func(c *SearchConfig) {
+		c.OnMatch = fn
+	}
+}
+
+// matchStreamer batches matches as they are found and sends each batch, in
+// the output format of search_code, as a progress notification whose progress
+// is the number of matches sent so far.
+type matchStreamer struct {
+	send   func(progress float64, message string) error
+	format string
+	batch  []SearchMatch
+	sent   int
+	last   time.Time
+	err    error // the first error send returned; nothing is sent after it
+}
+
+func newMatchStreamer(format string, send func(progress float64, message string) error) *matchStreamer {
+	return &matchStreamer{send: send, format: format, last: time.Now()}
+}
+
+// add queues a match and sends the batch when it is full or due.
+func (s *matchStreamer) add(m SearchMatch) {
+	s.batch = append(s.batch, m)
+	if len(s.batch) >= streamBatchSize || time.Since(s.last) >= streamInterval {
+		s.flush()
+	}
+}
+
+// flush sends the queued matches, if any.
+func (s *matchStreamer) flush() {
+	if len(s.batch) == 0 || s.err != nil {
+		return
+	}
+	var message string
+	if s.format == "json" {
+		data, err := json.Marshal(s.batch)
+		if err != nil {
+			s.err = err
+			return
+		}
+		message = string(data)
+	} else {
+		var sb strings.Builder
+		writeMatches(&sb, s.format, s.batch)
+		message = strings.TrimSuffix(sb.String(), "\n")
+	}
+	s.sent += len(s.batch)
+	s.batch = s.batch[:0]
+	s.last = time.Now()
+	s.err = s.send(float64(s.sent), message)
+}
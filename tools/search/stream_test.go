@@ -0,0 +1,95 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithMatchHandler(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		content := fmt.Sprintf("first\nneedle %d\nlast needle\n", i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.txt", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	engines := map[string][]SearchOption{"builtin": nil}
+	if rg, err := exec.LookPath("rg"); err == nil {
+		engines["ripgrep"] = []SearchOption{WithRipgrep(rg)}
+	}
+	for name, engine := range engines {
+		for _, options := range [][]SearchOption{
+			{WithContextLines(1)},
+			{WithMaxResults(7)},
+		} {
+			var streamed []SearchMatch
+			options = append(append(options, engine...), WithMatchHandler(func(m SearchMatch) {
+				streamed = append(streamed, m)
+			}))
+			results, err := Find("needle", dir, options...)
+			if err != nil {
+				t.Fatalf("%s: Find failed: %v", name, err)
+			}
+			sortMatches(streamed)
+			if !reflect.DeepEqual(streamed, results.Matches) {
+				t.Errorf("%s: streamed %d matches, want the %d returned:\n%+v\nwant\n%+v", name, len(streamed), len(results.Matches), streamed, results.Matches)
+			}
+		}
+	}
+}
+
+func TestMatchStreamer(t *testing.T) {
+	var progress []float64
+	var messages []string
+	s := newMatchStreamer("text", func(p float64, message string) error {
+		progress = append(progress, p)
+		messages = append(messages, message)
+		return nil
+	})
+	for i := 1; i <= streamBatchSize+10; i++ {
+		s.add(SearchMatch{File: "a.go", Line: i, Content: "x"})
+	}
+	s.flush()
+	s.flush() // nothing left to send
+
+	if want := []float64{streamBatchSize, streamBatchSize + 10}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+	if len(messages) == 2 {
+		lines := strings.Split(messages[1], "\n")
+		if len(lines) != 10 || lines[0] != fmt.Sprintf("a.go:%d:x", streamBatchSize+1) {
+			t.Errorf("second batch = %q, want 10 lines from a.go:%d:x", messages[1], streamBatchSize+1)
+		}
+	}
+
+	var batch []SearchMatch
+	s = newMatchStreamer("json", func(p float64, message string) error {
+		return json.Unmarshal([]byte(message), &batch)
+	})
+	s.add(SearchMatch{File: "b.go", Line: 3, Column: 2, Content: "y"})
+	s.flush()
+	if s.err != nil || len(batch) != 1 || batch[0].File != "b.go" || batch[0].Column != 2 {
+		t.Errorf("json batch = %+v, %v; want the match", batch, s.err)
+	}
+
+	sends := 0
+	s = newMatchStreamer("text", func(float64, string) error {
+		sends++
+		return errors.New("closed")
+	})
+	for i := 0; i < 3*streamBatchSize; i++ {
+		s.add(SearchMatch{File: "c.go", Line: i + 1})
+	}
+	s.flush()
+	if sends != 1 || s.err == nil {
+		t.Errorf("sends = %d, err = %v; want streaming to stop after the first error", sends, s.err)
+	}
+}
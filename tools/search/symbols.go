@@ -0,0 +1,239 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/request"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// defaultMaxSymbols is the number of definitions search_symbols returns
+// unless maxResults says otherwise.
+const defaultMaxSymbols = 200
+
+// SearchSymbolsArgs defines the arguments for the search_symbols tool.
+type SearchSymbolsArgs struct {
+	Path         string   `json:"path" description:"The directory or Go file to search." required:"true"`
+	Name         string   `json:"name" description:"Regular expression matched against each definition's name and, for methods, Type.Method (e.g., '^New', 'Server\\.Handle$', '^Config$')." required:"true"`
+	Kind         *string  `json:"kind,omitempty" description:"Only return definitions of this kind: func, method, type, const or var."`
+	IgnoreCase   *bool    `json:"ignoreCase,omitempty" description:"Match the name case-insensitively."`
+	IncludeTests *bool    `json:"includeTests,omitempty" description:"Also search _test.go files. Defaults to false."`
+	Exclude      []string `json:"exclude,omitempty" description:"Optional glob patterns for files or directories to skip (e.g., 'vendor', 'internal/gen')."`
+	MaxResults   *int     `json:"maxResults,omitempty" description:"Maximum number of definitions to return. Defaults to 200."`
+	TimeoutMs    *int     `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds."`
+}
+
+// Symbol is a top-level Go definition.
+type Symbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"` // func, method, type, const or var
+	Receiver  string `json:"receiver,omitempty"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Signature string `json:"signature"`
+}
+
+// symbolKinds are the kinds search_symbols reports.
+var symbolKinds = map[string]bool{"func": true, "method": true, "type": true, "const": true, "var": true}
+
+// fileSymbols returns the top-level definitions in a parsed Go file.
+func fileSymbols(fset *token.FileSet, path string, file *ast.File) []Symbol {
+	var symbols []Symbol
+	add := func(name, kind, receiver string, pos token.Pos, signature string) {
+		symbols = append(symbols, Symbol{Name: name, Kind: kind, Receiver: receiver, File: path, Line: fset.Position(pos).Line, Signature: signature})
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			// The signature is the declaration without its body
+			sig := *d
+			sig.Doc, sig.Body = nil, nil
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				add(d.Name.Name, "method", receiverType(d.Recv.List[0].Type), d.Name.Pos(), printNode(fset, &sig))
+			} else {
+				add(d.Name.Name, "func", "", d.Name.Pos(), printNode(fset, &sig))
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name.Name, "type", "", s.Name.Pos(), "type "+typeSignature(fset, s))
+				case *ast.ValueSpec:
+					kind := d.Tok.String()
+					for i, name := range s.Names {
+						if name.Name == "_" {
+							continue
+						}
+						sig := kind + " " + name.Name
+						if s.Type != nil {
+							sig += " " + printNode(fset, s.Type)
+						}
+						if i < len(s.Values) {
+							sig += " = " + printNode(fset, s.Values[i])
+						}
+						add(name.Name, kind, "", name.Pos(), sig)
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverType returns the name of a method's receiver type, without the
+// pointer or type parameters: Stack for *Stack[T].
+func receiverType(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// typeSignature renders a type definition, shortening struct and interface
+// bodies, which can run for pages, to their keyword.
+func typeSignature(fset *token.FileSet, s *ast.TypeSpec) string {
+	spec := *s
+	spec.Doc, spec.Comment = nil, nil
+	switch s.Type.(type) {
+	case *ast.StructType:
+		spec.Type = ast.NewIdent("struct{...}")
+	case *ast.InterfaceType:
+		spec.Type = ast.NewIdent("interface{...}")
+	}
+	return printNode(fset, &spec)
+}
+
+// printNode prints a node as gofmt would, on one line.
+func printNode(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// findSymbols parses the Go files the engine walks and returns the
+// definitions whose name, or Type.Method for methods, matches re.
+func findSymbols(ctx context.Context, engine *SearchEngine, re *regexp.Regexp, kind string, includeTests bool) ([]Symbol, error) {
+	var symbols []Symbol
+	visit := func(path string) error {
+		if !includeTests && strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		fset := token.NewFileSet()
+		// A file with syntax errors still yields the declarations parsed
+		file, _ := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if file == nil {
+			return nil
+		}
+		for _, s := range fileSymbols(fset, path, file) {
+			if kind != "" && s.Kind != kind {
+				continue
+			}
+			if re.MatchString(s.Name) || (s.Receiver != "" && re.MatchString(s.Receiver+"."+s.Name)) {
+				symbols = append(symbols, s)
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if info, statErr := os.Stat(engine.config.SearchPath); statErr == nil && !info.IsDir() {
+		err = visit(engine.config.SearchPath)
+	} else {
+		err = engine.walk(ctx, visit)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].File != symbols[j].File {
+			return symbols[i].File < symbols[j].File
+		}
+		return symbols[i].Line < symbols[j].Line
+	})
+	return symbols, err
+}
+
+// HandleSearchSymbols implements the search_symbols tool.
+func HandleSearchSymbols(ctx *server.Context, args SearchSymbolsArgs) (string, error) {
+	ctx.Logger.Info("Handling search_symbols tool call")
+
+	kind := ""
+	if args.Kind != nil && *args.Kind != "" {
+		kind = strings.ToLower(*args.Kind)
+		if !symbolKinds[kind] {
+			return i18n.T(ctx, i18n.SymbolKindInvalid, *args.Kind), nil
+		}
+	}
+	expr := args.Name
+	if args.IgnoreCase != nil && *args.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return i18n.T(ctx, i18n.SymbolPatternInvalid, args.Name, err), nil
+	}
+
+	config := SearchConfig{SearchPath: args.Path, UseGitignore: true}
+	WithFilePattern("*.go")(&config)
+	WithExcludePatterns(args.Exclude...)(&config)
+	engine := &SearchEngine{config: config}
+
+	walkCtx, stop := request.Context(ctx)
+	defer stop()
+	if args.TimeoutMs != nil && *args.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		walkCtx, cancel = context.WithTimeout(walkCtx, time.Duration(*args.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+	symbols, err := findSymbols(walkCtx, engine, re, kind, args.IncludeTests != nil && *args.IncludeTests)
+	if err == context.DeadlineExceeded {
+		ctx.Logger.Info("search_symbols timed out", "path", args.Path)
+		return i18n.T(ctx, i18n.SearchTimedOut), nil
+	}
+	if err != nil {
+		ctx.Logger.Info("Error searching symbols", "error", err)
+		return "", fmt.Errorf("symbol search failed: %v", err)
+	}
+	if len(symbols) == 0 {
+		return i18n.T(ctx, i18n.SymbolsNotFound, args.Name), nil
+	}
+
+	maxResults := defaultMaxSymbols
+	if args.MaxResults != nil && *args.MaxResults > 0 {
+		maxResults = *args.MaxResults
+	}
+	var sb strings.Builder
+	for i, s := range symbols {
+		if i == maxResults {
+			sb.WriteString(i18n.T(ctx, i18n.SymbolsTruncated, len(symbols)-maxResults) + "\n")
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%s:%d: %s\n", s.File, s.Line, s.Signature))
+	}
+	ctx.Logger.Info("search_symbols completed", "name", args.Name, "symbols", len(symbols))
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
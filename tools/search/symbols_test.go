@@ -0,0 +1,117 @@
+package search
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+const symbolsSource = `package store
+
+import "context"
+
+// MaxItems bounds a Stack.
+const MaxItems, minItems = 100, 1
+
+var ErrFull error
+
+// Stack is a generic stack.
+type Stack[T any] struct {
+	items []T
+}
+
+type ID = string
+
+type Handler func(ctx context.Context) error
+
+// Push adds v.
+func (s *Stack[T]) Push(v T) error {
+	return nil
+}
+
+func NewStack[T any](capacity int) *Stack[T] {
+	return &Stack[T]{}
+}
+
+func pushAll(s *Stack[int]) {}
+`
+
+func TestHandleSearchSymbols(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "store.go")
+	files := map[string]string{
+		"store.go":      symbolsSource,
+		"store_test.go": "package store\n\nfunc TestPush() {}\n",
+		"broken.go":     "package store\n\nfunc Pushed() {}\n\nfunc broken( {\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	method, ignoreCase, includeTests := "method", true, true
+	tests := []struct {
+		name string
+		args SearchSymbolsArgs
+		want []string
+	}{
+		{"functions and methods", SearchSymbolsArgs{Name: "Push"}, []string{
+			filepath.Join(dir, "broken.go") + ":3: func Pushed()",
+			file + ":20: func (s *Stack[T]) Push(v T) error",
+		}},
+		{"ignore case", SearchSymbolsArgs{Name: "^push", IgnoreCase: &ignoreCase, Kind: new(string)}, []string{
+			filepath.Join(dir, "broken.go") + ":3: func Pushed()",
+			file + ":20: func (s *Stack[T]) Push(v T) error",
+			file + ":28: func pushAll(s *Stack[int])",
+		}},
+		{"Type.Method", SearchSymbolsArgs{Name: `^Stack\.`}, []string{file + ":20: func (s *Stack[T]) Push(v T) error"}},
+		{"kind", SearchSymbolsArgs{Name: "Push", Kind: &method}, []string{file + ":20: func (s *Stack[T]) Push(v T) error"}},
+		{"types", SearchSymbolsArgs{Name: "^(Stack|ID|Handler)$"}, []string{
+			file + ":11: type Stack[T any] struct{...}",
+			file + ":15: type ID = string",
+			file + ":17: type Handler func(ctx context.Context) error",
+		}},
+		{"values", SearchSymbolsArgs{Name: "items$|^Err", IgnoreCase: &ignoreCase}, []string{
+			file + ":6: const MaxItems = 100",
+			file + ":6: const minItems = 1",
+			file + ":8: var ErrFull error",
+		}},
+		{"generic function", SearchSymbolsArgs{Name: "^New"}, []string{file + ":24: func NewStack[T any](capacity int) *Stack[T]"}},
+		{"tests", SearchSymbolsArgs{Name: "^TestPush$", IncludeTests: &includeTests}, []string{filepath.Join(dir, "store_test.go") + ":3: func TestPush()"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.Path = dir
+			result, err := HandleSearchSymbols(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("HandleSearchSymbols failed: %v", err)
+			}
+			if want := strings.Join(tt.want, "\n"); result != want {
+				t.Errorf("HandleSearchSymbols =\n%s\nwant\n%s", result, want)
+			}
+		})
+	}
+
+	bad := "constant"
+	messages := map[string]SearchSymbolsArgs{
+		"Unknown kind":      {Path: dir, Name: "x", Kind: &bad},
+		"Invalid name":      {Path: dir, Name: "("},
+		"No Go definitions": {Path: dir, Name: "^TestPush$"},
+		"more definitions":  {Path: file, Name: ".", MaxResults: new(int)},
+	}
+	*messages["more definitions"].MaxResults = 2
+	for want, args := range messages {
+		if result, _ := HandleSearchSymbols(ctx, args); !strings.Contains(result, want) {
+			t.Errorf("HandleSearchSymbols(%+v) = %q, want %q", args, result, want)
+		}
+	}
+}
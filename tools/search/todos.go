@@ -0,0 +1,284 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/request"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// defaultTodoMarkers are the markers list_todos looks for unless told others.
+var defaultTodoMarkers = []string{"TODO", "FIXME", "HACK", "XXX"}
+
+// defaultMaxTodos is the number of comments list_todos returns unless
+// maxResults says otherwise.
+const defaultMaxTodos = 500
+
+// ListTodosArgs defines the arguments for the list_todos tool.
+type ListTodosArgs struct {
+	Path          string   `json:"path" description:"The directory path to search within." required:"true"`
+	Markers       []string `json:"markers,omitempty" description:"Comment markers to look for. Defaults to TODO, FIXME, HACK and XXX."`
+	IgnoreCase    *bool    `json:"ignoreCase,omitempty" description:"Match markers case-insensitively, so 'todo' counts too. Defaults to false."`
+	FilePattern   *string  `json:"filePattern,omitempty" description:"Optional glob pattern to filter files, matched against the file name or its path relative to path; ** spans directories (e.g., '*.go', 'src/**/*.ts')."`
+	Exclude       []string `json:"exclude,omitempty" description:"Optional glob patterns for files or directories to skip (e.g., 'vendor', '*_test.go')."`
+	IncludeHidden *bool    `json:"includeHidden,omitempty" description:"Include hidden files and directories."`
+	Blame         *bool    `json:"blame,omitempty" description:"Add the author and date of each comment from git blame when path is in a git repository. Defaults to true."`
+	MaxResults    *int     `json:"maxResults,omitempty" description:"Maximum number of comments to return. Defaults to 500."`
+	TimeoutMs     *int     `json:"timeoutMs,omitempty" description:"Optional timeout in milliseconds."`
+}
+
+// Todo is a marker comment such as // TODO(alice): handle errors.
+type Todo struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Marker string `json:"marker"`
+	Owner  string `json:"owner,omitempty"` // the name in TODO(name)
+	Text   string `json:"text"`
+	Author string `json:"author,omitempty"`
+	Date   string `json:"date,omitempty"`
+}
+
+// commentLeaders start a comment in the languages commonly found in a
+// repository; a marker only counts when one comes before it on its line.
+var commentLeaders = []string{"//", "#", "/*", "<!--", "--", ";", "%", "{-", "(*"}
+
+// todoPattern returns the expression list_todos searches for and the one it
+// finds the markers of a matched line with.
+func todoPattern(markers []string, ignoreCase bool) (string, *regexp.Regexp) {
+	quoted := make([]string, 0, len(markers))
+	for _, m := range markers {
+		if m = strings.TrimSpace(m); m != "" {
+			quoted = append(quoted, regexp.QuoteMeta(m))
+		}
+	}
+	if len(quoted) == 0 {
+		return todoPattern(defaultTodoMarkers, ignoreCase)
+	}
+	search := `\b(?:` + strings.Join(quoted, "|") + `)\b`
+	if ignoreCase {
+		return search, regexp.MustCompile("(?i)" + search)
+	}
+	return search, regexp.MustCompile(search)
+}
+
+// todoRest parses what follows a marker: an optional (owner), a colon or
+// dash, and the text.
+var todoRest = regexp.MustCompile(`^(?:\(([^)]*)\))?\s*[:\-]?\s*(.*)`)
+
+// parseTodo returns the first marker comment on a line, skipping markers that
+// do not follow a comment leader, such as those in strings.
+func parseTodo(re *regexp.Regexp, file string, line int, content string) (Todo, bool) {
+	for _, loc := range re.FindAllStringIndex(content, -1) {
+		if !inComment(content[:loc[0]]) {
+			continue
+		}
+		todo := Todo{File: file, Line: line, Marker: strings.ToUpper(content[loc[0]:loc[1]])}
+		rest := todoRest.FindStringSubmatch(content[loc[1]:])
+		todo.Owner = strings.TrimSpace(rest[1])
+		text := strings.TrimSpace(rest[2])
+		for _, closer := range []string{"*/", "-->", "-}", "*)"} {
+			text = strings.TrimSpace(strings.TrimSuffix(text, closer))
+		}
+		todo.Text = text
+		return todo, true
+	}
+	return Todo{}, false
+}
+
+// inComment reports whether the text before a marker opens a comment, or is
+// the leading * of a line inside a block comment.
+func inComment(prefix string) bool {
+	for _, leader := range commentLeaders {
+		if strings.Contains(prefix, leader) {
+			return true
+		}
+	}
+	return strings.HasPrefix(strings.TrimSpace(prefix), "*")
+}
+
+// blameTodos fills in the author and date of each comment from git blame,
+// one run per file. Files outside a git repository, and lines not yet
+// committed, are left as they are.
+func blameTodos(ctx context.Context, todos []Todo) {
+	for start := 0; start < len(todos); {
+		end := start
+		for end < len(todos) && todos[end].File == todos[start].File {
+			end++
+		}
+		file := todos[start].File
+		args := []string{"blame", "--line-porcelain"}
+		for _, t := range todos[start:end] {
+			args = append(args, "-L", fmt.Sprintf("%d,%d", t.Line, t.Line))
+		}
+		args = append(args, "--", filepath.Base(file))
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = filepath.Dir(file)
+		if output, err := cmd.Output(); err == nil {
+			blamed := parseBlame(output)
+			for i := start; i < end; i++ {
+				if b, ok := blamed[todos[i].Line]; ok {
+					todos[i].Author, todos[i].Date = b.Author, b.Date
+				}
+			}
+		}
+		start = end
+	}
+}
+
+// parseBlame reads git blame --line-porcelain output into the author and
+// date of each line.
+func parseBlame(output []byte) map[int]Todo {
+	blamed := make(map[int]Todo)
+	var line int
+	var current Todo
+	uncommitted := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			if !uncommitted && line > 0 {
+				blamed[line] = current
+			}
+			line, current = 0, Todo{}
+		case strings.HasPrefix(text, "author "):
+			current.Author = strings.TrimPrefix(text, "author ")
+		case strings.HasPrefix(text, "author-time "):
+			if sec, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				current.Date = time.Unix(sec, 0).UTC().Format("2006-01-02")
+			}
+		case line == 0:
+			// The header: the commit, its line, then this line in the file
+			fields := strings.Fields(text)
+			if len(fields) >= 3 {
+				line, _ = strconv.Atoi(fields[2])
+				uncommitted = strings.Trim(fields[0], "0") == ""
+			}
+		}
+	}
+	return blamed
+}
+
+// HandleListTodos implements the list_todos tool.
+func HandleListTodos(ctx *server.Context, args ListTodosArgs) (string, error) {
+	ctx.Logger.Info("Handling list_todos tool call")
+
+	ignoreCase := args.IgnoreCase != nil && *args.IgnoreCase
+	pattern, re := todoPattern(args.Markers, ignoreCase)
+	maxResults := defaultMaxTodos
+	if args.MaxResults != nil && *args.MaxResults > 0 {
+		maxResults = *args.MaxResults
+	}
+
+	options := []SearchOption{WithGitignore(true), WithMaxResults(maxResults)}
+	if ignoreCase {
+		options = append(options, WithIgnoreCase())
+	}
+	if args.FilePattern != nil && *args.FilePattern != "" {
+		options = append(options, WithFilePattern(*args.FilePattern))
+	}
+	if len(args.Exclude) > 0 {
+		options = append(options, WithExcludePatterns(args.Exclude...))
+	}
+	if args.IncludeHidden != nil && *args.IncludeHidden {
+		options = append(options, WithHidden())
+	}
+	var timeout time.Duration
+	if args.TimeoutMs != nil && *args.TimeoutMs > 0 {
+		timeout = time.Duration(*args.TimeoutMs) * time.Millisecond
+		options = append(options, WithTimeout(timeout))
+	}
+	options = append(options, tuningOptions(ctx, nil, nil)...)
+	if rg := ripgrepBinary(ctx); rg != "" {
+		options = append(options, WithRipgrep(rg))
+	}
+
+	reqCtx, cancel := request.Context(ctx)
+	defer cancel()
+	results, err := FindContext(reqCtx, pattern, args.Path, options...)
+	if err == context.DeadlineExceeded {
+		ctx.Logger.Info("list_todos timed out", "path", args.Path)
+		return i18n.T(ctx, i18n.SearchTimedOut), nil
+	}
+	if err != nil {
+		ctx.Logger.Info("Error listing TODOs", "error", err)
+		return "", fmt.Errorf("todo search failed: %v", err)
+	}
+
+	var todos []Todo
+	for _, m := range results.Matches {
+		if todo, ok := parseTodo(re, m.File, m.Line, m.Content); ok {
+			todos = append(todos, todo)
+		}
+	}
+	if len(todos) == 0 {
+		return i18n.T(ctx, i18n.TodosNotFound, args.Path), nil
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		if todos[i].File != todos[j].File {
+			return todos[i].File < todos[j].File
+		}
+		return todos[i].Line < todos[j].Line
+	})
+
+	if args.Blame == nil || *args.Blame {
+		blameCtx := reqCtx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			blameCtx, cancel = context.WithTimeout(blameCtx, timeout)
+			defer cancel()
+		}
+		blameTodos(blameCtx, todos)
+	}
+
+	files := 0
+	counts := make(map[string]int)
+	var body strings.Builder
+	for i, t := range todos {
+		if i == 0 || t.File != todos[i-1].File {
+			files++
+			body.WriteString("\n" + t.File + "\n")
+		}
+		counts[t.Marker]++
+		marker := t.Marker
+		if t.Owner != "" {
+			marker += "(" + t.Owner + ")"
+		}
+		body.WriteString(fmt.Sprintf("  %d: %s: %s", t.Line, marker, t.Text))
+		if t.Author != "" {
+			body.WriteString(fmt.Sprintf(" [%s, %s]", t.Author, t.Date))
+		}
+		body.WriteString("\n")
+	}
+
+	markers := make([]string, 0, len(counts))
+	for m := range counts {
+		markers = append(markers, m)
+	}
+	sort.Strings(markers)
+	for i, m := range markers {
+		markers[i] = fmt.Sprintf("%s %d", m, counts[m])
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(ctx, i18n.TodosSummary, len(todos), files, strings.Join(markers, ", ")))
+	if results.Count() >= maxResults {
+		sb.WriteString(" " + i18n.T(ctx, i18n.TodosTruncated, maxResults))
+	}
+	sb.WriteString("\n" + body.String())
+
+	ctx.Logger.Info("list_todos completed", "todos", len(todos), "files", files)
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestParseTodo(t *testing.T) {
+	_, re := todoPattern(nil, false)
+	tests := []struct {
+		content string
+		want    Todo
+		ok      bool
+	}{
+		{"\t// TODO(alice): handle errors", Todo{Marker: "TODO", Owner: "alice", Text: "handle errors"}, true},
+		{"x := 1 // FIXME leaks on retry", Todo{Marker: "FIXME", Text: "leaks on retry"}, true},
+		{"# HACK: works around a bug", Todo{Marker: "HACK", Text: "works around a bug"}, true},
+		{"/* XXX - revisit */", Todo{Marker: "XXX", Text: "revisit"}, true},
+		{" * TODO: document", Todo{Marker: "TODO", Text: "document"}, true},
+		{"<!-- TODO: add alt text -->", Todo{Marker: "TODO", Text: "add alt text"}, true},
+		{`msg := "TODO list"`, Todo{}, false},
+		{"// TODOS are tracked elsewhere", Todo{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseTodo(re, "f", 1, tt.content)
+		if ok != tt.ok || got.Marker != tt.want.Marker || got.Owner != tt.want.Owner || got.Text != tt.want.Text {
+			t.Errorf("parseTodo(%q) = %+v, %v; want %+v, %v", tt.content, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHandleListTodos(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":   "package main\n\n// TODO(bob): split this up\nfunc main() {\n\tprintln(\"TODO\") // FIXME: use a logger\n}\n",
+		"script.py": "# NOTE: keep in sync\nx = 1  # HACK avoid the cache\n",
+		"clean.txt": "nothing to do here\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	noBlame, ignoreCase, txt := false, true, "*.txt"
+
+	result, err := HandleListTodos(ctx, ListTodosArgs{Path: dir, Blame: &noBlame})
+	if err != nil {
+		t.Fatalf("HandleListTodos failed: %v", err)
+	}
+	want := "Found 3 comments in 2 files (FIXME 1, HACK 1, TODO 1).\n" +
+		"\n" + filepath.Join(dir, "main.go") + "\n" +
+		"  3: TODO(bob): split this up\n" +
+		"  5: FIXME: use a logger\n" +
+		"\n" + filepath.Join(dir, "script.py") + "\n" +
+		"  2: HACK: avoid the cache"
+	if result != want {
+		t.Errorf("HandleListTodos =\n%s\nwant\n%s", result, want)
+	}
+
+	result, _ = HandleListTodos(ctx, ListTodosArgs{Path: dir, Markers: []string{"note"}, IgnoreCase: &ignoreCase, Blame: &noBlame})
+	if !strings.Contains(result, "  1: NOTE: keep in sync") || strings.Contains(result, "TODO") {
+		t.Errorf("HandleListTodos with markers = %q, want only the NOTE comment", result)
+	}
+
+	result, _ = HandleListTodos(ctx, ListTodosArgs{Path: dir, FilePattern: &txt})
+	if !strings.Contains(result, "No TODO comments") {
+		t.Errorf("HandleListTodos in clean files = %q, want no comments found", result)
+	}
+}
+
+func TestListTodosBlame(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2024-03-01T12:00:00Z", "GIT_COMMITTER_DATE=2024-03-01T12:00:00Z")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	file := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n\n// TODO: committed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q")
+	git("add", "a.go")
+	git("-c", "user.name=Ada Lovelace", "-c", "user.email=ada@example.com", "commit", "-qm", "init")
+	if err := os.WriteFile(file, []byte("package a\n\n// TODO: committed\n// TODO: not yet\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	result, err := HandleListTodos(ctx, ListTodosArgs{Path: dir})
+	if err != nil {
+		t.Fatalf("HandleListTodos failed: %v", err)
+	}
+	for _, want := range []string{"  3: TODO: committed [Ada Lovelace, 2024-03-01]", "  4: TODO: not yet\n"} {
+		if !strings.Contains(result+"\n", want) {
+			t.Errorf("HandleListTodos =\n%s\nwant it to contain %q", result, want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// InspectSQLiteArgs defines the arguments for the inspect_sqlite tool.
+type InspectSQLiteArgs struct {
+	Path  string  `json:"path" description:"An SQLite database file, or a directory to list the databases found under it." required:"true"`
+	Table *string `json:"table,omitempty" description:"Optional. Show this table's schema and its first rows."`
+	Query *string `json:"query,omitempty" description:"Optional. A read-only SQL query to run. Needs the sqlite3 command-line shell; the database is opened read-only in safe mode."`
+	Limit *int    `json:"limit,omitempty" description:"Optional. Maximum rows to return for table or query (default 20, max 1000)."`
+}
+
+const (
+	defaultRowLimit = 20
+	maxRowLimit     = 1000
+	// maxDatabasesListed caps the directory listing.
+	maxDatabasesListed = 200
+	// queryTimeout bounds how long the sqlite3 shell may run.
+	queryTimeout = 30 * time.Second
+	// maxValueWidth truncates long text values in table previews.
+	maxValueWidth = 80
+)
+
+// databaseExtensions are the file extensions checked when listing a directory.
+var databaseExtensions = map[string]bool{
+	".db": true, ".sqlite": true, ".sqlite3": true, ".db3": true, ".s3db": true, ".sl3": true,
+}
+
+// HandleInspectSQLite implements the inspect_sqlite tool.
+func HandleInspectSQLite(ctx *server.Context, args InspectSQLiteArgs) (string, error) {
+	ctx.Logger.Info("Handling inspect_sqlite tool call")
+
+	info, err := os.Stat(args.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return i18n.T(ctx, i18n.FileNotFound), nil
+		}
+		return i18n.T(ctx, i18n.FileAccessError), err
+	}
+	if info.IsDir() {
+		return listDatabases(ctx, args.Path)
+	}
+
+	limit := defaultRowLimit
+	if args.Limit != nil && *args.Limit > 0 {
+		limit = min(*args.Limit, maxRowLimit)
+	}
+	if args.Query != nil && strings.TrimSpace(*args.Query) != "" {
+		return runQuery(ctx, args.Path, *args.Query, limit)
+	}
+
+	db, err := openDatabase(args.Path)
+	if err != nil {
+		return i18n.T(ctx, i18n.SQLiteOpenError, args.Path, err), nil
+	}
+	defer db.Close()
+	entries, err := db.schema()
+	if err != nil {
+		return i18n.T(ctx, i18n.SQLiteOpenError, args.Path, err), nil
+	}
+
+	if args.Table != nil && *args.Table != "" {
+		return previewTable(ctx, db, entries, *args.Table, limit)
+	}
+	return describeSchema(db, args.Path, entries), nil
+}
+
+// listDatabases finds SQLite files under root, skipping hidden directories.
+func listDatabases(ctx *server.Context, root string) (string, error) {
+	var found []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !databaseExtensions[strings.ToLower(filepath.Ext(path))] || !isDatabase(path) {
+			return nil
+		}
+		size := int64(0)
+		if info, err := d.Info(); err == nil {
+			size = info.Size()
+		}
+		found = append(found, fmt.Sprintf("%s (%d bytes)", path, size))
+		if len(found) >= maxDatabasesListed {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return i18n.T(ctx, i18n.FileReadError), err
+	}
+	if len(found) == 0 {
+		return i18n.T(ctx, i18n.SQLiteNoDatabases, root), nil
+	}
+	return i18n.T(ctx, i18n.SQLiteDatabasesFound, root) + "\n" + strings.Join(found, "\n"), nil
+}
+
+// describeSchema lists each table with its row count and CREATE statement,
+// followed by the indexes, views and triggers.
+func describeSchema(db *database, path string, entries []schemaEntry) string {
+	encodings := map[uint32]string{1: "UTF-8", 2: "UTF-16le", 3: "UTF-16be"}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: SQLite 3, %d page(s) of %d bytes, %s\n", path, db.pages, db.pageSize, encodings[db.encoding])
+
+	for _, e := range entries {
+		if e.kind != "table" {
+			continue
+		}
+		count, err := db.countRows(e.rootPage, 0)
+		if err != nil {
+			fmt.Fprintf(&b, "\ntable %s: %v\n", e.name, err)
+		} else {
+			fmt.Fprintf(&b, "\ntable %s: %d row(s)\n", e.name, count)
+		}
+		if e.sql != "" {
+			b.WriteString(e.sql + "\n")
+		}
+	}
+	for _, kind := range []string{"index", "view", "trigger"} {
+		for _, e := range entries {
+			if e.kind != kind {
+				continue
+			}
+			if kind == "view" {
+				fmt.Fprintf(&b, "\nview %s\n%s\n", e.name, e.sql)
+			} else {
+				fmt.Fprintf(&b, "\n%s %s on %s\n", kind, e.name, e.table)
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// previewTable shows a table's schema and its first limit rows.
+func previewTable(ctx *server.Context, db *database, entries []schemaEntry, name string, limit int) (string, error) {
+	var table *schemaEntry
+	var tables []string
+	for i, e := range entries {
+		if e.kind != "table" {
+			continue
+		}
+		tables = append(tables, e.name)
+		if strings.EqualFold(e.name, name) {
+			table = &entries[i]
+		}
+	}
+	if table == nil {
+		return i18n.T(ctx, i18n.SQLiteTableNotFound, name, strings.Join(tables, ", ")), nil
+	}
+	if withoutRowid(table.sql) {
+		return i18n.T(ctx, i18n.SQLiteWithoutRowid, table.name), nil
+	}
+
+	names, alias := columns(table.sql)
+	var rows []string
+	err := db.scanTable(table.rootPage, 0, func(rowid int64, record []byte) error {
+		if len(rows) == limit {
+			return errStop
+		}
+		values, err := db.decodeRecord(record)
+		if err != nil {
+			return err
+		}
+		if alias >= 0 && alias < len(values) && values[alias] == nil {
+			values[alias] = rowid
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = formatValue(v)
+		}
+		rows = append(rows, strings.Join(cells, " | "))
+		return nil
+	})
+	truncated := errors.Is(err, errStop)
+	if err != nil && !truncated {
+		return i18n.T(ctx, i18n.SQLiteOpenError, db.f.Name(), err), nil
+	}
+
+	var b strings.Builder
+	b.WriteString(table.sql + "\n\n")
+	if len(names) > 0 {
+		b.WriteString(strings.Join(names, " | ") + "\n")
+	}
+	for _, row := range rows {
+		b.WriteString(row + "\n")
+	}
+	if truncated {
+		b.WriteString(i18n.T(ctx, i18n.SQLiteRowsTruncated, limit) + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// formatValue renders a column value the way the sqlite3 shell does, with
+// NULL spelled out and blobs summarized.
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	case []byte:
+		if len(t) <= 16 {
+			return fmt.Sprintf("x'%x'", t)
+		}
+		return fmt.Sprintf("<blob %d bytes>", len(t))
+	case string:
+		t = strings.ReplaceAll(t, "\n", `\n`)
+		if utf8.RuneCountInString(t) > maxValueWidth {
+			t = string([]rune(t)[:maxValueWidth]) + "..."
+		}
+		return t
+	}
+	return fmt.Sprint(v)
+}
+
+// runQuery runs query through the sqlite3 shell with the database opened
+// read-only and the shell in safe mode, which refuses ATTACH and commands that
+// touch other files.
+func runQuery(ctx *server.Context, path, query string, limit int) (string, error) {
+	// The shell reads a dot-command from the start of any line of its input
+	for _, line := range strings.Split(query, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), ".") {
+			return i18n.T(ctx, i18n.SQLiteDotCommand), nil
+		}
+	}
+	shell, err := exec.LookPath("sqlite3")
+	if err != nil {
+		return i18n.T(ctx, i18n.SQLiteShellMissing), nil
+	}
+
+	runCtx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, shell, "-readonly", "-safe", "-bail", "-header", "-separator", " | ", "-nullvalue", "NULL", path)
+	cmd.Stdin = strings.NewReader(query)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		detail := strings.TrimSpace(string(output))
+		if detail == "" {
+			detail = err.Error()
+		}
+		ctx.Logger.Info("sqlite3 query failed", "path", path, "error", err)
+		return i18n.T(ctx, i18n.SQLiteQueryFailed, detail), nil
+	}
+
+	// The first line is the header
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if len(lines) > limit+1 {
+		lines = append(lines[:limit+1], i18n.T(ctx, i18n.SQLiteRowsTruncated, limit))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,260 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func testContext() *server.Context {
+	return &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+// putVarint appends v in SQLite's variable-length integer encoding.
+func putVarint(b []byte, v uint64) []byte {
+	var groups []byte
+	for {
+		groups = append([]byte{byte(v & 0x7f)}, groups...)
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	for i := range groups[:len(groups)-1] {
+		groups[i] |= 0x80
+	}
+	return append(b, groups...)
+}
+
+// record encodes values as an SQLite record.
+func record(values ...interface{}) []byte {
+	var header, body []byte
+	for _, v := range values {
+		switch t := v.(type) {
+		case nil:
+			header = putVarint(header, 0)
+		case int64:
+			header = putVarint(header, 6)
+			body = binary.BigEndian.AppendUint64(body, uint64(t))
+		case float64:
+			header = putVarint(header, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(t))
+		case string:
+			header = putVarint(header, uint64(13+2*len(t)))
+			body = append(body, t...)
+		case []byte:
+			header = putVarint(header, uint64(12+2*len(t)))
+			body = append(body, t...)
+		}
+	}
+	return append(putVarint(nil, uint64(len(header)+1)), append(header, body...)...)
+}
+
+// leafPage fills page with a table leaf holding records keyed by rowid 1..n.
+// Page 1 keeps its first 100 bytes for the database header.
+func leafPage(page []byte, headerOffset int, records ...[]byte) {
+	page[headerOffset] = leafTable
+	binary.BigEndian.PutUint16(page[headerOffset+3:], uint16(len(records)))
+	content := len(page)
+	for i, r := range records {
+		cell := putVarint(nil, uint64(len(r)))
+		cell = putVarint(cell, uint64(i+1))
+		cell = append(cell, r...)
+		content -= len(cell)
+		copy(page[content:], cell)
+		binary.BigEndian.PutUint16(page[headerOffset+8+2*i:], uint16(content))
+	}
+	binary.BigEndian.PutUint16(page[headerOffset+5:], uint16(content))
+}
+
+// writeFixture builds a two-page database with a users table.
+func writeFixture(t *testing.T) string {
+	t.Helper()
+	const pageSize = 1024
+	db := make([]byte, 2*pageSize)
+	copy(db, headerMagic)
+	binary.BigEndian.PutUint16(db[16:], pageSize)
+	db[18], db[19], db[21], db[22], db[23] = 1, 1, 64, 32, 32
+	binary.BigEndian.PutUint32(db[28:], 2)
+	binary.BigEndian.PutUint32(db[44:], 4)
+	binary.BigEndian.PutUint32(db[56:], 1)
+
+	sql := "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, score REAL, avatar BLOB)"
+	leafPage(db[:pageSize], 100, record("table", "users", "users", int64(2), sql))
+	leafPage(db[pageSize:], 0,
+		record(nil, "ada", 9.5, []byte{0xca, 0xfe}),
+		record(nil, "bob\nby", nil, nil),
+		record(nil, "cy", int64(-3), nil),
+	)
+
+	path := filepath.Join(t.TempDir(), "app.db")
+	if err := os.WriteFile(path, db, 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestInspectSQLite(t *testing.T) {
+	path := writeFixture(t)
+	strPtr := func(s string) *string { return &s }
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name string
+		args InspectSQLiteArgs
+		want []string
+	}{
+		{
+			name: "schema and row counts",
+			args: InspectSQLiteArgs{Path: path},
+			want: []string{"SQLite 3, 2 page(s) of 1024 bytes, UTF-8", "table users: 3 row(s)\nCREATE TABLE users"},
+		},
+		{
+			name: "table preview",
+			args: InspectSQLiteArgs{Path: path, Table: strPtr("Users")},
+			want: []string{
+				"id | name | score | avatar\n1 | ada | 9.5 | x'cafe'\n2 | bob\\nby | NULL | NULL\n3 | cy | -3 | NULL",
+			},
+		},
+		{
+			name: "preview limit",
+			args: InspectSQLiteArgs{Path: path, Table: strPtr("users"), Limit: intPtr(1)},
+			want: []string{"1 | ada", "Showing the first 1 row(s)."},
+		},
+		{
+			name: "unknown table",
+			args: InspectSQLiteArgs{Path: path, Table: strPtr("orders")},
+			want: []string{`No table named "orders". Tables: users`},
+		},
+		{
+			name: "directory listing",
+			args: InspectSQLiteArgs{Path: filepath.Dir(path)},
+			want: []string{"app.db (2048 bytes)"},
+		},
+		{
+			name: "dot commands are refused",
+			args: InspectSQLiteArgs{Path: path, Query: strPtr(".shell ls")},
+			want: []string{"dot-commands are not allowed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := HandleInspectSQLite(testContext(), tt.args)
+			if err != nil {
+				t.Fatalf("HandleInspectSQLite() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("result missing %q:\n%s", want, result)
+				}
+			}
+		})
+	}
+}
+
+func TestInspectSQLiteNotADatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.db")
+	if err := os.WriteFile(path, []byte("just text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, _ := HandleInspectSQLite(testContext(), InspectSQLiteArgs{Path: path})
+	if !strings.Contains(result, "not an SQLite 3 database") {
+		t.Errorf("result = %q", result)
+	}
+	result, _ = HandleInspectSQLite(testContext(), InspectSQLiteArgs{Path: filepath.Dir(path)})
+	if !strings.Contains(result, "No SQLite databases found") {
+		t.Errorf("text file with a .db extension was listed: %q", result)
+	}
+}
+
+// TestInspectSQLiteShellDatabase reads a database written by the sqlite3
+// shell, large enough for interior pages and overflow records.
+func TestInspectSQLiteShellDatabase(t *testing.T) {
+	shell, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	path := filepath.Join(t.TempDir(), "big.sqlite")
+	script := `PRAGMA page_size=512;
+CREATE TABLE items ("item id" INTEGER PRIMARY KEY, body TEXT);
+WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i+1 FROM n WHERE i < 2000)
+INSERT INTO items(body) SELECT 'row ' || i FROM n;
+UPDATE items SET body = printf('%.3000c', 'x') WHERE "item id" = 1;
+CREATE TABLE tags (name TEXT PRIMARY KEY) WITHOUT ROWID;
+WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i+1 FROM n WHERE i < 500)
+INSERT INTO tags SELECT 'tag' || i FROM n;
+CREATE INDEX items_body ON items(body);`
+	cmd := exec.Command(shell, path)
+	cmd.Stdin = strings.NewReader(script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 failed: %v\n%s", err, output)
+	}
+
+	result, err := HandleInspectSQLite(testContext(), InspectSQLiteArgs{Path: path})
+	if err != nil {
+		t.Fatalf("HandleInspectSQLite() error = %v", err)
+	}
+	for _, want := range []string{"table items: 2000 row(s)", "table tags: 500 row(s)", "index items_body on items"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+
+	table := "items"
+	limit := 2
+	result, _ = HandleInspectSQLite(testContext(), InspectSQLiteArgs{Path: path, Table: &table, Limit: &limit})
+	if !strings.Contains(result, "item id | body\n1 | "+strings.Repeat("x", maxValueWidth)+"...\n2 | row 2\n") {
+		t.Errorf("unexpected preview:\n%s", result)
+	}
+
+	query := "SELECT count(*) AS n FROM items WHERE body LIKE 'row 1%'"
+	result, _ = HandleInspectSQLite(testContext(), InspectSQLiteArgs{Path: path, Query: &query})
+	if result != "n\n1110" {
+		t.Errorf("query result = %q", result)
+	}
+
+	write := "DELETE FROM items"
+	result, _ = HandleInspectSQLite(testContext(), InspectSQLiteArgs{Path: path, Query: &write})
+	if !strings.Contains(result, "Query failed") {
+		t.Errorf("write query was not refused: %q", result)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	long := strings.Repeat("é", maxValueWidth+1)
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, "NULL"},
+		{int64(-3), "-3"},
+		{"a\nb", `a\nb`},
+		{strings.Repeat("é", maxValueWidth), strings.Repeat("é", maxValueWidth)},
+		{long, strings.Repeat("é", maxValueWidth) + "..."},
+		{[]byte{1, 2}, "x'0102'"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.value); got != tt.want {
+			t.Errorf("formatValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRunQueryRefusesDotCommands(t *testing.T) {
+	for _, query := range []string{".tables", "  .shell ls", "SELECT 1;\n.output /tmp/x\nSELECT 2;", "SELECT 1;\r\n\t.once x"} {
+		if result, _ := runQuery(testContext(), "db.sqlite", query, 10); !strings.Contains(result, "dot-commands are not allowed") {
+			t.Errorf("runQuery(%q) = %q, want the dot-command refused", query, result)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// The reader below understands just enough of the SQLite file format
+// (https://www.sqlite.org/fileformat.html) to list the schema, count rows and
+// read table rows. It needs no cgo, so it works in the CGO_ENABLED=0 release
+// builds, and it never writes to the file.
+
+const headerMagic = "SQLite format 3\x00"
+
+// B-tree page types.
+const (
+	interiorIndex = 0x02
+	interiorTable = 0x05
+	leafIndex     = 0x0a
+	leafTable     = 0x0d
+)
+
+// maxTreeDepth bounds b-tree recursion so a corrupt file cannot loop forever.
+const maxTreeDepth = 64
+
+var errCorrupt = errors.New("database file is malformed")
+
+// errStop ends a table scan early without reporting an error.
+var errStop = errors.New("stop")
+
+// database is a read-only handle on an SQLite 3 file.
+type database struct {
+	f        *os.File
+	pageSize int
+	usable   int
+	pages    int
+	encoding uint32 // 1 UTF-8, 2 UTF-16le, 3 UTF-16be
+}
+
+// schemaEntry is one row of sqlite_master.
+type schemaEntry struct {
+	kind     string
+	name     string
+	table    string
+	rootPage int
+	sql      string
+}
+
+// isDatabase reports whether the file at path starts with the SQLite 3 header.
+func isDatabase(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, len(headerMagic))
+	if _, err := f.ReadAt(magic, 0); err != nil {
+		return false
+	}
+	return string(magic) == headerMagic
+}
+
+func openDatabase(path string) (*database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 100)
+	if _, err := f.ReadAt(header, 0); err != nil || string(header[:16]) != headerMagic {
+		f.Close()
+		return nil, fmt.Errorf("%s is not an SQLite 3 database", path)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(header[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		f.Close()
+		return nil, errCorrupt
+	}
+	encoding := binary.BigEndian.Uint32(header[56:60])
+	if encoding == 0 {
+		encoding = 1
+	}
+	return &database{
+		f:        f,
+		pageSize: pageSize,
+		usable:   pageSize - int(header[20]),
+		pages:    int(info.Size() / int64(pageSize)),
+		encoding: encoding,
+	}, nil
+}
+
+func (db *database) Close() error {
+	return db.f.Close()
+}
+
+// page reads page n, counting from 1.
+func (db *database) page(n int) ([]byte, error) {
+	if n < 1 || n > db.pages {
+		return nil, errCorrupt
+	}
+	buf := make([]byte, db.pageSize)
+	if _, err := db.f.ReadAt(buf, int64(n-1)*int64(db.pageSize)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// btreePage is a decoded b-tree page header and its cell offsets.
+type btreePage struct {
+	data  []byte
+	kind  byte
+	cells []int
+	right int // right-most child of interior pages
+}
+
+func (db *database) btreePage(n int) (*btreePage, error) {
+	data, err := db.page(n)
+	if err != nil {
+		return nil, err
+	}
+	h := 0
+	if n == 1 {
+		h = 100 // page 1 starts with the database header
+	}
+	p := &btreePage{data: data, kind: data[h]}
+	pointers := h + 8
+	switch p.kind {
+	case interiorIndex, interiorTable:
+		p.right = int(binary.BigEndian.Uint32(data[h+8:]))
+		pointers = h + 12
+	case leafIndex, leafTable:
+	default:
+		return nil, errCorrupt
+	}
+	count := int(binary.BigEndian.Uint16(data[h+3:]))
+	if pointers+2*count > len(data) {
+		return nil, errCorrupt
+	}
+	p.cells = make([]int, count)
+	for i := range p.cells {
+		off := int(binary.BigEndian.Uint16(data[pointers+2*i:]))
+		if off >= len(data) {
+			return nil, errCorrupt
+		}
+		p.cells[i] = off
+	}
+	return p, nil
+}
+
+// varint decodes an SQLite variable-length integer, returning it and its length.
+func varint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, len(b)
+}
+
+// countRows counts the entries of the b-tree rooted at page n. Tables declared
+// WITHOUT ROWID are index b-trees, whose interior cells hold rows too.
+func (db *database) countRows(n, depth int) (int64, error) {
+	if depth > maxTreeDepth {
+		return 0, errCorrupt
+	}
+	p, err := db.btreePage(n)
+	if err != nil {
+		return 0, err
+	}
+	switch p.kind {
+	case leafTable, leafIndex:
+		return int64(len(p.cells)), nil
+	}
+	var total int64
+	if p.kind == interiorIndex {
+		total = int64(len(p.cells))
+	}
+	for _, off := range p.cells {
+		if off+4 > len(p.data) {
+			return 0, errCorrupt
+		}
+		child, err := db.countRows(int(binary.BigEndian.Uint32(p.data[off:])), depth+1)
+		if err != nil {
+			return 0, err
+		}
+		total += child
+	}
+	child, err := db.countRows(p.right, depth+1)
+	return total + child, err
+}
+
+// scanTable calls visit with the rowid and record of each row of the rowid
+// table rooted at page n, in rowid order. visit returns errStop to end early.
+func (db *database) scanTable(n, depth int, visit func(rowid int64, record []byte) error) error {
+	if depth > maxTreeDepth {
+		return errCorrupt
+	}
+	p, err := db.btreePage(n)
+	if err != nil {
+		return err
+	}
+	switch p.kind {
+	case interiorTable:
+		for _, off := range p.cells {
+			if off+4 > len(p.data) {
+				return errCorrupt
+			}
+			if err := db.scanTable(int(binary.BigEndian.Uint32(p.data[off:])), depth+1, visit); err != nil {
+				return err
+			}
+		}
+		return db.scanTable(p.right, depth+1, visit)
+	case leafTable:
+		for _, off := range p.cells {
+			size, n1 := varint(p.data[off:])
+			rowid, n2 := varint(p.data[off+n1:])
+			record, err := db.payload(p.data, off+n1+n2, int(size))
+			if err != nil {
+				return err
+			}
+			if err := visit(int64(rowid), record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return errCorrupt
+}
+
+// payload returns the size bytes of a table leaf cell's record starting at
+// off, following overflow pages when the record does not fit on the page.
+func (db *database) payload(data []byte, off, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, errCorrupt
+	}
+	u := db.usable
+	local := size
+	if maxLocal := u - 35; size > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if off+local > len(data) {
+		return nil, errCorrupt
+	}
+	record := append([]byte(nil), data[off:off+local]...)
+	if local == size {
+		return record, nil
+	}
+	if off+local+4 > len(data) {
+		return nil, errCorrupt
+	}
+	next := int(binary.BigEndian.Uint32(data[off+local:]))
+	for visited := 0; len(record) < size; visited++ {
+		if next == 0 || visited > db.pages {
+			return nil, errCorrupt
+		}
+		page, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		next = int(binary.BigEndian.Uint32(page))
+		chunk := page[4:u]
+		if rest := size - len(record); len(chunk) > rest {
+			chunk = chunk[:rest]
+		}
+		record = append(record, chunk...)
+	}
+	return record, nil
+}
+
+// decodeRecord splits a record into its values: nil, int64, float64, string
+// or []byte.
+func (db *database) decodeRecord(record []byte) ([]interface{}, error) {
+	headerSize, n := varint(record)
+	if int(headerSize) > len(record) || n == 0 {
+		return nil, errCorrupt
+	}
+	var types []uint64
+	for pos := n; pos < int(headerSize); {
+		t, n := varint(record[pos:int(headerSize)])
+		if n == 0 {
+			return nil, errCorrupt
+		}
+		types = append(types, t)
+		pos += n
+	}
+
+	values := make([]interface{}, len(types))
+	body := record[headerSize:]
+	for i, t := range types {
+		var size int
+		switch {
+		case t == 0, t == 8, t == 9:
+			size = 0
+		case t <= 4:
+			size = int(t)
+		case t == 5:
+			size = 6
+		case t == 6, t == 7:
+			size = 8
+		case t >= 12:
+			size = int(t-12) / 2
+		default:
+			return nil, errCorrupt
+		}
+		if size > len(body) {
+			return nil, errCorrupt
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values[i] = nil
+		case t == 8:
+			values[i] = int64(0)
+		case t == 9:
+			values[i] = int64(1)
+		case t == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(field))
+		case t <= 6:
+			// Big-endian two's complement of 1 to 8 bytes
+			v := int64(int8(field[0]))
+			for _, b := range field[1:] {
+				v = v<<8 | int64(b)
+			}
+			values[i] = v
+		case t%2 == 0:
+			values[i] = append([]byte(nil), field...)
+		default:
+			values[i] = db.text(field)
+		}
+	}
+	return values, nil
+}
+
+// text decodes a string in the database's text encoding.
+func (db *database) text(b []byte) string {
+	if db.encoding == 1 {
+		return string(b)
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		if db.encoding == 2 {
+			units[i] = binary.LittleEndian.Uint16(b[2*i:])
+		} else {
+			units[i] = binary.BigEndian.Uint16(b[2*i:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// schema reads sqlite_master, which is the table rooted at page 1.
+func (db *database) schema() ([]schemaEntry, error) {
+	var entries []schemaEntry
+	err := db.scanTable(1, 0, func(_ int64, record []byte) error {
+		values, err := db.decodeRecord(record)
+		if err != nil {
+			return err
+		}
+		if len(values) < 5 {
+			return errCorrupt
+		}
+		e := schemaEntry{}
+		e.kind, _ = values[0].(string)
+		e.name, _ = values[1].(string)
+		e.table, _ = values[2].(string)
+		if root, ok := values[3].(int64); ok {
+			e.rootPage = int(root)
+		}
+		e.sql, _ = values[4].(string)
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+// withoutRowid reports whether a CREATE TABLE statement ends in WITHOUT ROWID.
+func withoutRowid(sql string) bool {
+	tail := sql[strings.LastIndex(sql, ")")+1:]
+	return strings.Contains(strings.ToUpper(strings.Join(strings.Fields(tail), " ")), "WITHOUT ROWID")
+}
+
+// columns returns the column names declared by a CREATE TABLE statement and
+// the index of the INTEGER PRIMARY KEY column, which SQLite stores as the
+// rowid rather than in the record, or -1.
+func columns(sql string) ([]string, int) {
+	start, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if start < 0 || end <= start {
+		return nil, -1
+	}
+
+	// Split on commas outside parentheses and quotes
+	var defs []string
+	depth, quote, from := 0, byte(0), start+1
+	for i := start + 1; i < end; i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote || (quote == '[' && c == ']') {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`' || c == '[':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			defs = append(defs, sql[from:i])
+			from = i + 1
+		}
+	}
+	defs = append(defs, sql[from:end])
+
+	var names []string
+	alias := -1
+	for _, def := range defs {
+		def = strings.TrimSpace(def)
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue // a table constraint, not a column
+		}
+		name := fields[0]
+		if q := name[0]; q == '"' || q == '`' || q == '[' {
+			closing := q
+			if q == '[' {
+				closing = ']'
+			}
+			if i := strings.IndexByte(def[1:], closing); i >= 0 {
+				name = def[1 : i+1]
+				fields = strings.Fields(def[i+2:])
+				fields = append([]string{name}, fields...)
+			}
+		}
+		upper := strings.ToUpper(strings.Join(fields[1:], " "))
+		if strings.HasPrefix(upper, "INTEGER PRIMARY KEY") && !strings.HasPrefix(upper, "INTEGER PRIMARY KEY DESC") {
+			alias = len(names)
+		}
+		names = append(names, name)
+	}
+	return names, alias
+}
@@ -0,0 +1,123 @@
+package stdio
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxPreview bounds how much of an intercepted write is logged.
+const maxPreview = 200
+
+// SharesStdout reports whether f writes to the same pipe or file as stdout,
+// as when the server is started with 2>&1. Anything written to f would then
+// corrupt the JSON-RPC stream. A terminal is not reported, as nothing reads a
+// terminal as a protocol stream.
+func SharesStdout(f *os.File) bool {
+	out, err := os.Stdout.Stat()
+	if err != nil || out.Mode()&os.ModeCharDevice != 0 {
+		return false
+	}
+	other, err := f.Stat()
+	return err == nil && os.SameFile(out, other)
+}
+
+// Guard points os.Stdout at a pipe so that only a transport which took the
+// real stdout before the call can write there. Whatever else is written,
+// whether by a stray print or a child process given os.Stdout, is logged as an
+// error and discarded. The returned function restores os.Stdout.
+func Guard(logger *slog.Logger) (func(), error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	real := os.Stdout
+	os.Stdout = w
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 4096)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				logger.Error("Something other than the MCP transport wrote to stdout; the output was discarded to keep the JSON-RPC stream intact",
+					"bytes", n, "output", preview(buf[:n]))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		os.Stdout = real
+		w.Close()
+		<-done
+		r.Close()
+	}, nil
+}
+
+// WatchStdin points os.Stdin at a pipe fed from the real stdin, so that a
+// transport created after the call reads it as before, and returns a channel
+// that is closed when the real stdin ends, as it does when the client exits
+// or closes it.
+func WatchStdin() (<-chan struct{}, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	real := os.Stdin
+	os.Stdin = r
+
+	ended := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(w, real)
+		w.Close()
+		close(ended)
+	}()
+	return ended, nil
+}
+
+// preview returns the start of b for logging.
+func preview(b []byte) string {
+	runes := []rune(strings.ToValidUTF8(string(b), "?"))
+	if len(runes) > maxPreview {
+		return string(runes[:maxPreview]) + "…"
+	}
+	return string(runes)
+}
+
+// LogWriter returns a writer that logs each line written to it at warning
+// level with msg and args, for the standard error of child processes, which
+// must not inherit the server's. Close flushes a final unterminated line.
+func LogWriter(logger *slog.Logger, msg string, args ...any) io.WriteCloser {
+	r, w := io.Pipe()
+	lw := &logWriter{w: w}
+	lw.wg.Add(1)
+	go func() {
+		defer lw.wg.Done()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			logger.Warn(msg, append(args[:len(args):len(args)], "line", scanner.Text())...)
+		}
+		io.Copy(io.Discard, r) // drain a line over the scanner's limit
+	}()
+	return lw
+}
+
+type logWriter struct {
+	w  *io.PipeWriter
+	wg sync.WaitGroup
+}
+
+func (lw *logWriter) Write(p []byte) (int, error) { return lw.w.Write(p) }
+
+func (lw *logWriter) Close() error {
+	err := lw.w.Close()
+	lw.wg.Wait()
+	return err
+}
@@ -0,0 +1,110 @@
+package stdio
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGuard(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	real := os.Stdout
+
+	restore, err := Guard(logger)
+	if err != nil {
+		t.Fatalf("Guard failed: %v", err)
+	}
+	fmt.Println("stray print")
+	cmd := exec.Command("go", "version")
+	cmd.Stdout = os.Stdout
+	runErr := cmd.Run()
+	restore()
+
+	if os.Stdout != real {
+		t.Fatal("restore did not put back the real stdout")
+	}
+	if !strings.Contains(logs.String(), "stray print") {
+		t.Errorf("Expected the stray print to be logged, got %q", logs.String())
+	}
+	if runErr == nil && !strings.Contains(logs.String(), "go version go") {
+		t.Errorf("Expected the child's output to be logged, got %q", logs.String())
+	}
+}
+
+func TestWatchStdin(t *testing.T) {
+	real := os.Stdin
+	defer func() { os.Stdin = real }()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdin = r
+
+	ended, err := WatchStdin()
+	if err != nil {
+		t.Fatalf("WatchStdin failed: %v", err)
+	}
+	fmt.Fprintln(w, `{"jsonrpc":"2.0"}`)
+	line := make([]byte, 64)
+	n, err := os.Stdin.Read(line)
+	if err != nil || string(line[:n]) != "{\"jsonrpc\":\"2.0\"}\n" {
+		t.Errorf("read %q, %v from the watched stdin", line[:n], err)
+	}
+	select {
+	case <-ended:
+		t.Fatal("stdin reported ended while open")
+	default:
+	}
+
+	w.Close()
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the end of stdin was not reported")
+	}
+}
+
+func TestSharesStdout(t *testing.T) {
+	real := os.Stdout
+	defer func() { os.Stdout = real }()
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	os.Stdout = f
+	if !SharesStdout(f) {
+		t.Error("Expected the same file to be reported as shared")
+	}
+	other, err := os.Create(filepath.Join(t.TempDir(), "err"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if SharesStdout(other) {
+		t.Error("Expected a different file not to be reported as shared")
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	var logs bytes.Buffer
+	w := LogWriter(slog.New(slog.NewTextHandler(&logs, nil)), "child stderr", "name", "up")
+	fmt.Fprint(w, "first line\nsecond ")
+	fmt.Fprint(w, "line\nunterminated")
+	w.Close()
+
+	out := logs.String()
+	for _, want := range []string{`line="first line"`, `line="second line"`, "line=unterminated", "name=up", `msg="child stderr"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Logs = %q, want %s", out, want)
+		}
+	}
+}
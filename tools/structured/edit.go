@@ -0,0 +1,174 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/journal"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// StructuredEditArgs defines the arguments for the json_edit, yaml_edit and toml_edit tools.
+type StructuredEditArgs struct {
+	FilePath  string  `json:"file_path" description:"The file to edit." required:"true"`
+	Operation string  `json:"operation" description:"set (create or replace a value), delete (remove a key or array element) or append (add to the end of an array)." required:"true"`
+	Path      string  `json:"path" description:"The value to operate on, as a JSON Pointer (/scripts/build, /files/0) or a dot path (scripts.build, files.0 or files[0]). Use a JSON Pointer for keys containing dots. An empty path is the document root." required:"true"`
+	Value     *string `json:"value,omitempty" description:"The new value as JSON, required for set and append. Strings must be quoted, e.g. \"\\\"^1.2.0\\\"\"."`
+}
+
+// editFormat describes how one document format is checked and edited. apply
+// returns a non-empty message when the edit cannot be made.
+type editFormat struct {
+	name     string
+	validate func(src []byte) error
+	apply    func(ctx *server.Context, src []byte, operation, path string, value []byte) ([]byte, string)
+}
+
+// runStructuredEdit validates the arguments and the document, applies the edit
+// and writes the result, refusing edits that would leave the file invalid.
+func runStructuredEdit(ctx *server.Context, tool string, args StructuredEditArgs, format editFormat) (string, error) {
+	operation := strings.ToLower(args.Operation)
+	var value []byte
+	switch operation {
+	case "set", "append":
+		if args.Value == nil {
+			return i18n.T(ctx, i18n.JSONEditValueRequired, operation), nil
+		}
+		value = []byte(*args.Value)
+		if _, err := decodeValue(value); err != nil {
+			return i18n.T(ctx, i18n.JSONEditInvalidValue, err), nil
+		}
+	case "delete":
+	default:
+		return i18n.T(ctx, i18n.JSONEditInvalidOp, args.Operation), nil
+	}
+
+	info, err := os.Stat(args.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return i18n.T(ctx, i18n.FileNotFound), nil
+		}
+		ctx.Logger.Info("Error getting file info", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileAccessError), err
+	}
+	src, err := os.ReadFile(args.FilePath)
+	if err != nil {
+		ctx.Logger.Info("Error reading file", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileReadError), err
+	}
+	if err := format.validate(src); err != nil {
+		return i18n.T(ctx, i18n.StructuredInvalidDocument, args.FilePath, format.name, err), nil
+	}
+
+	updated, msg := format.apply(ctx, src, operation, args.Path, value)
+	if msg != "" {
+		ctx.Logger.Info(msg)
+		return msg, nil
+	}
+	if err := format.validate(updated); err != nil {
+		ctx.Logger.Info("Edit would produce an invalid document", "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.StructuredInvalidResult, args.FilePath, format.name, err), nil
+	}
+
+	pending := journal.Capture(ctx.Logger, args.FilePath, tool)
+	if err := os.WriteFile(args.FilePath, updated, info.Mode()); err != nil {
+		ctx.Logger.Info("Error writing file", "tool", tool, "filePath", args.FilePath, "error", err)
+		return i18n.T(ctx, i18n.FileWriteError), err
+	}
+	pending.Commit(updated)
+
+	ctx.Logger.Info("Structured edit applied", "tool", tool, "filePath", args.FilePath, "operation", operation, "path", args.Path)
+	switch operation {
+	case "set":
+		return i18n.T(ctx, i18n.JSONEditSet, args.Path, args.FilePath), nil
+	case "delete":
+		return i18n.T(ctx, i18n.JSONEditDeleted, args.Path, args.FilePath), nil
+	default:
+		return i18n.T(ctx, i18n.JSONEditAppended, args.Path, args.FilePath), nil
+	}
+}
+
+// decodeValue parses a JSON value argument, keeping numbers as written.
+func decodeValue(raw []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, &json.SyntaxError{Offset: dec.InputOffset()}
+	}
+	return v, nil
+}
+
+// editTree applies operation at tokens below node, a tree of maps, slices and
+// scalars, and returns the updated node. path is only used in messages.
+func editTree(ctx *server.Context, node interface{}, tokens []string, operation, path string, value interface{}) (interface{}, string) {
+	if len(tokens) == 0 {
+		switch operation {
+		case "set":
+			return value, ""
+		case "append":
+			list, ok := node.([]interface{})
+			if !ok {
+				return nil, i18n.T(ctx, i18n.JSONEditNotArray, path)
+			}
+			return append(list, value), ""
+		}
+		return nil, i18n.T(ctx, i18n.JSONEditDeleteRoot)
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	switch t := node.(type) {
+	case map[string]interface{}:
+		child, ok := t[token]
+		if len(rest) == 0 && operation == "delete" {
+			if !ok {
+				return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+			}
+			delete(t, token)
+			return t, ""
+		}
+		if !ok {
+			if len(rest) == 0 && operation == "set" {
+				t[token] = value
+				return t, ""
+			}
+			return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+		}
+		updated, msg := editTree(ctx, child, rest, operation, path, value)
+		if msg != "" {
+			return nil, msg
+		}
+		t[token] = updated
+		return t, ""
+
+	case []interface{}:
+		if len(rest) == 0 && operation == "set" && (token == "-" || token == strconv.Itoa(len(t))) {
+			return append(t, value), ""
+		}
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(t) {
+			if len(rest) == 0 && operation == "set" {
+				return nil, i18n.T(ctx, i18n.JSONEditIndexRange, token, path, len(t))
+			}
+			return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+		}
+		if len(rest) == 0 && operation == "delete" {
+			return append(t[:i:i], t[i+1:]...), ""
+		}
+		updated, msg := editTree(ctx, t[i], rest, operation, path, value)
+		if msg != "" {
+			return nil, msg
+		}
+		t[i] = updated
+		return t, ""
+	}
+	return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+}
@@ -0,0 +1,318 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// jsonNode is the byte span of a value in a JSON document. Objects and arrays
+// also record their members in document order.
+type jsonNode struct {
+	start, end int
+	kind       byte // '{', '[' or 0 for scalars
+	members    []jsonMember
+}
+
+// jsonMember is an object member or array element. For array elements keyStart
+// is the start of the value.
+type jsonMember struct {
+	key      string
+	keyStart int
+	value    *jsonNode
+}
+
+// jsonScanner builds jsonNodes over a document that is already known to be valid.
+type jsonScanner struct {
+	src []byte
+}
+
+func (s *jsonScanner) skipSpace(pos int) int {
+	for pos < len(s.src) && strings.IndexByte(" \t\r\n", s.src[pos]) >= 0 {
+		pos++
+	}
+	return pos
+}
+
+func (s *jsonScanner) scanString(pos int) int {
+	for pos++; pos < len(s.src); pos++ {
+		switch s.src[pos] {
+		case '\\':
+			pos++
+		case '"':
+			return pos + 1
+		}
+	}
+	return pos
+}
+
+func (s *jsonScanner) value(pos int) *jsonNode {
+	pos = s.skipSpace(pos)
+	node := &jsonNode{start: pos}
+	switch s.src[pos] {
+	case '{', '[':
+		node.kind = s.src[pos]
+		closing := byte('}')
+		if node.kind == '[' {
+			closing = ']'
+		}
+		pos = s.skipSpace(pos + 1)
+		for s.src[pos] != closing {
+			member := jsonMember{keyStart: pos}
+			if node.kind == '{' {
+				keyEnd := s.scanString(pos)
+				_ = json.Unmarshal(s.src[pos:keyEnd], &member.key)
+				pos = s.skipSpace(keyEnd) + 1 // past ':'
+			}
+			member.value = s.value(pos)
+			node.members = append(node.members, member)
+			pos = s.skipSpace(member.value.end)
+			if s.src[pos] == ',' {
+				pos = s.skipSpace(pos + 1)
+			}
+		}
+		node.end = pos + 1
+	case '"':
+		node.end = s.scanString(pos)
+	default:
+		for pos < len(s.src) && strings.IndexByte(",]} \t\r\n", s.src[pos]) < 0 {
+			pos++
+		}
+		node.end = pos
+	}
+	return node
+}
+
+// parsePath splits a JSON Pointer or dot path into reference tokens.
+func parsePath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	if strings.HasPrefix(path, "/") {
+		tokens := strings.Split(path[1:], "/")
+		for i, t := range tokens {
+			t = strings.ReplaceAll(t, "~1", "/")
+			tokens[i] = strings.ReplaceAll(t, "~0", "~")
+		}
+		return tokens
+	}
+	// files[0].name is the same as files.0.name
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	return strings.Split(strings.TrimPrefix(path, "."), ".")
+}
+
+// child returns the position of token within a container, or -1.
+func (n *jsonNode) child(token string) int {
+	switch n.kind {
+	case '{':
+		// JSON allows duplicate keys; the last one is the effective value
+		for i := len(n.members) - 1; i >= 0; i-- {
+			if n.members[i].key == token {
+				return i
+			}
+		}
+	case '[':
+		if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(n.members) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lineIndent returns the leading whitespace of the line containing pos.
+func lineIndent(src []byte, pos int) string {
+	start := bytes.LastIndexByte(src[:pos], '\n') + 1
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
+
+// indentUnit returns the document's indentation step, or "" for documents
+// written on a single line.
+func indentUnit(src []byte) string {
+	if !bytes.Contains(bytes.TrimSpace(src), []byte("\n")) {
+		return ""
+	}
+	for _, line := range bytes.Split(src, []byte("\n"))[1:] {
+		if indent := lineIndent(line, 0); indent != "" {
+			return indent
+		}
+	}
+	return "  "
+}
+
+// formatValue renders a JSON value for insertion on a line indented by indent.
+func formatValue(value []byte, indent, unit string) string {
+	var buf bytes.Buffer
+	if unit == "" {
+		_ = json.Compact(&buf, value)
+	} else {
+		_ = json.Indent(&buf, value, indent, unit)
+	}
+	return buf.String()
+}
+
+// quoteKey encodes an object key without HTML escaping.
+func quoteKey(key string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(key)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// splice replaces src[start:end] with text.
+func splice(src []byte, start, end int, text string) []byte {
+	out := make([]byte, 0, len(src)-(end-start)+len(text))
+	out = append(out, src[:start]...)
+	out = append(out, text...)
+	return append(out, src[end:]...)
+}
+
+// keySeparator returns the text the document puts between object keys and
+// values, such as ": " or ":".
+func keySeparator(scanner *jsonScanner, node *jsonNode) (string, bool) {
+	if node.kind == '{' && len(node.members) > 0 {
+		m := node.members[0]
+		sep := scanner.src[scanner.scanString(m.keyStart):m.value.start]
+		if bytes.ContainsAny(sep, "\r\n") {
+			return ": ", true
+		}
+		return string(sep), true
+	}
+	for _, m := range node.members {
+		if sep, ok := keySeparator(scanner, m.value); ok {
+			return sep, true
+		}
+	}
+	return "", false
+}
+
+// insertMember adds a member at the end of container, laid out like its
+// existing members. key is ignored for arrays.
+func insertMember(scanner *jsonScanner, root, container *jsonNode, key string, value []byte, unit string) []byte {
+	src := scanner.src
+	prefix := ""
+	if container.kind == '{' {
+		sep, ok := keySeparator(scanner, root)
+		if !ok {
+			sep = ": "
+		}
+		prefix = quoteKey(key) + sep
+	}
+
+	if len(container.members) == 0 {
+		if unit == "" {
+			return splice(src, container.start+1, container.end-1, prefix+formatValue(value, "", ""))
+		}
+		outer := lineIndent(src, container.start)
+		indent := outer + unit
+		return splice(src, container.start+1, container.end-1, "\n"+indent+prefix+formatValue(value, indent, unit)+"\n"+outer)
+	}
+
+	// Reuse the whitespace that precedes the first member
+	gap := string(src[container.start+1 : container.members[0].keyStart])
+	last := container.members[len(container.members)-1].value
+	if i := strings.LastIndexByte(gap, '\n'); i >= 0 {
+		indent := gap[i+1:]
+		return splice(src, last.end, last.end, ",\n"+indent+prefix+formatValue(value, indent, unit))
+	}
+	return splice(src, last.end, last.end, ","+gap+prefix+formatValue(value, "", ""))
+}
+
+// deleteMember removes the member at index i together with one separator.
+func deleteMember(src []byte, container *jsonNode, i int) []byte {
+	members := container.members
+	switch {
+	case len(members) == 1:
+		return splice(src, container.start+1, container.end-1, "")
+	case i > 0:
+		return splice(src, members[i-1].value.end, members[i].value.end, "")
+	default:
+		return splice(src, members[0].keyStart, members[1].keyStart, "")
+	}
+}
+
+// applyJSONEdit performs operation on src. When the edit cannot be made the
+// returned message explains why.
+func applyJSONEdit(ctx *server.Context, src []byte, operation, path string, value []byte) ([]byte, string) {
+	tokens := parsePath(path)
+	scanner := &jsonScanner{src: src}
+	root := scanner.value(0)
+	unit := indentUnit(src)
+
+	// Walk to the parent of the last token
+	node := root
+	walk := tokens
+	if operation != "append" && len(tokens) > 0 {
+		walk = tokens[:len(tokens)-1]
+	}
+	for _, token := range walk {
+		i := node.child(token)
+		if i < 0 {
+			return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+		}
+		node = node.members[i].value
+	}
+
+	switch operation {
+	case "append":
+		if node.kind != '[' {
+			return nil, i18n.T(ctx, i18n.JSONEditNotArray, path)
+		}
+		return insertMember(scanner, root, node, "", value, unit), ""
+
+	case "set":
+		if len(tokens) == 0 {
+			return splice(src, root.start, root.end, formatValue(value, lineIndent(src, root.start), unit)), ""
+		}
+		last := tokens[len(tokens)-1]
+		if i := node.child(last); i >= 0 {
+			target := node.members[i].value
+			return splice(src, target.start, target.end, formatValue(value, lineIndent(src, target.start), unit)), ""
+		}
+		switch node.kind {
+		case '{':
+			return insertMember(scanner, root, node, last, value, unit), ""
+		case '[':
+			// Setting one past the end, or "-", appends
+			if last == "-" || last == strconv.Itoa(len(node.members)) {
+				return insertMember(scanner, root, node, "", value, unit), ""
+			}
+			return nil, i18n.T(ctx, i18n.JSONEditIndexRange, last, path, len(node.members))
+		}
+		return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+
+	case "delete":
+		if len(tokens) == 0 {
+			return nil, i18n.T(ctx, i18n.JSONEditDeleteRoot)
+		}
+		i := node.child(tokens[len(tokens)-1])
+		if i < 0 {
+			return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+		}
+		return deleteMember(src, node, i), ""
+	}
+	return nil, i18n.T(ctx, i18n.JSONEditInvalidOp, operation)
+}
+
+// HandleJSONEdit implements the json_edit tool.
+func HandleJSONEdit(ctx *server.Context, args StructuredEditArgs) (string, error) {
+	ctx.Logger.Info("Handling json_edit tool call")
+	return runStructuredEdit(ctx, "json_edit", args, editFormat{
+		name: "JSON",
+		validate: func(src []byte) error {
+			var doc interface{}
+			return json.Unmarshal(src, &doc)
+		},
+		apply: applyJSONEdit,
+	})
+}
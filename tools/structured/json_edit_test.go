@@ -0,0 +1,126 @@
+package structured
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestApplyJSONEdit(t *testing.T) {
+	pkg := "{\n  \"name\": \"app\",\n  \"scripts\": {\n    \"build\": \"tsc\"\n  },\n  \"files\": [\n    \"dist\"\n  ]\n}\n"
+
+	tests := []struct {
+		name      string
+		src       string
+		operation string
+		path      string
+		value     string
+		want      string
+		wantMsg   string
+	}{
+		{
+			name: "replace scalar by dot path", src: pkg, operation: "set", path: "scripts.build", value: `"tsc -b"`,
+			want: "{\n  \"name\": \"app\",\n  \"scripts\": {\n    \"build\": \"tsc -b\"\n  },\n  \"files\": [\n    \"dist\"\n  ]\n}\n",
+		},
+		{
+			name: "add key with object value", src: pkg, operation: "set", path: "/scripts/test", value: `{"cmd":"jest","watch":false}`,
+			want: "{\n  \"name\": \"app\",\n  \"scripts\": {\n    \"build\": \"tsc\",\n    \"test\": {\n      \"cmd\": \"jest\",\n      \"watch\": false\n    }\n  },\n  \"files\": [\n    \"dist\"\n  ]\n}\n",
+		},
+		{
+			name: "append to array", src: pkg, operation: "append", path: "files", value: `"src"`,
+			want: "{\n  \"name\": \"app\",\n  \"scripts\": {\n    \"build\": \"tsc\"\n  },\n  \"files\": [\n    \"dist\",\n    \"src\"\n  ]\n}\n",
+		},
+		{
+			name: "set index one past the end appends", src: pkg, operation: "set", path: "files[1]", value: `"src"`,
+			want: "{\n  \"name\": \"app\",\n  \"scripts\": {\n    \"build\": \"tsc\"\n  },\n  \"files\": [\n    \"dist\",\n    \"src\"\n  ]\n}\n",
+		},
+		{
+			name: "delete first member", src: pkg, operation: "delete", path: "name",
+			want: "{\n  \"scripts\": {\n    \"build\": \"tsc\"\n  },\n  \"files\": [\n    \"dist\"\n  ]\n}\n",
+		},
+		{
+			name: "delete last member", src: pkg, operation: "delete", path: "/files",
+			want: "{\n  \"name\": \"app\",\n  \"scripts\": {\n    \"build\": \"tsc\"\n  }\n}\n",
+		},
+		{
+			name: "delete only member", src: pkg, operation: "delete", path: "scripts.build",
+			want: "{\n  \"name\": \"app\",\n  \"scripts\": {},\n  \"files\": [\n    \"dist\"\n  ]\n}\n",
+		},
+		{
+			name: "insert into empty object keeps tab indentation", src: "{\n\t\"a\": {}\n}", operation: "set", path: "a.b", value: `[1, 2]`,
+			want: "{\n\t\"a\": {\n\t\t\"b\": [\n\t\t\t1,\n\t\t\t2\n\t\t]\n\t}\n}",
+		},
+		{
+			name: "compact document stays compact", src: `{"a":[1],"b":{"c":1}}`, operation: "set", path: "b.d", value: "{\n \"x\": 1\n}",
+			want: `{"a":[1],"b":{"c":1,"d":{"x":1}}}`,
+		},
+		{
+			name: "single-line container keeps its spacing", src: "{\n  \"tags\": [ \"a\" ]\n}", operation: "append", path: "tags", value: `"b"`,
+			want: "{\n  \"tags\": [ \"a\", \"b\" ]\n}",
+		},
+		{
+			name: "pointer escapes", src: `{"a/b": {"m~n": 1}}`, operation: "set", path: "/a~1b/m~0n", value: `2`,
+			want: `{"a/b": {"m~n": 2}}`,
+		},
+		{
+			name: "string containing braces", src: `{"s": "}{,]", "t": 1}`, operation: "delete", path: "s",
+			want: `{"t": 1}`,
+		},
+		{name: "missing parent", src: pkg, operation: "set", path: "engines.node", value: `"20"`, wantMsg: "does not exist"},
+		{name: "append to object", src: pkg, operation: "append", path: "scripts", value: `1`, wantMsg: "is not an array"},
+		{name: "index out of range", src: pkg, operation: "set", path: "files.5", value: `1`, wantMsg: "out of range"},
+		{name: "delete root", src: pkg, operation: "delete", path: "", wantMsg: "document root"},
+		{name: "delete missing key", src: pkg, operation: "delete", path: "version", wantMsg: "does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msg := applyJSONEdit(testContext(), []byte(tt.src), tt.operation, tt.path, []byte(tt.value))
+			if tt.wantMsg != "" {
+				if !strings.Contains(msg, tt.wantMsg) {
+					t.Errorf("Message %q does not contain %q", msg, tt.wantMsg)
+				}
+				return
+			}
+			if msg != "" {
+				t.Fatalf("Unexpected message: %s", msg)
+			}
+			if string(got) != tt.want {
+				t.Errorf("applyJSONEdit =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleJSONEdit(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "package.json", "{\n    \"version\": \"1.0.0\"\n}\n")
+	value := `"1.1.0"`
+
+	result, err := HandleJSONEdit(testContext(), StructuredEditArgs{FilePath: path, Operation: "set", Path: "version", Value: &value})
+	if err != nil {
+		t.Fatalf("HandleJSONEdit failed: %v", err)
+	}
+	if !strings.Contains(result, "Set version") {
+		t.Errorf("Unexpected result: %s", result)
+	}
+	content, _ := os.ReadFile(path)
+	if want := "{\n    \"version\": \"1.1.0\"\n}\n"; string(content) != want {
+		t.Errorf("File = %q, want %q", content, want)
+	}
+
+	unquoted := "1.2.0-beta"
+	result, _ = HandleJSONEdit(testContext(), StructuredEditArgs{FilePath: path, Operation: "set", Path: "version", Value: &unquoted})
+	if !strings.Contains(result, "not valid JSON") {
+		t.Errorf("Expected an invalid value message, got: %s", result)
+	}
+
+	broken := writeFile(t, dir, "broken.json", `{"a":`)
+	result, _ = HandleJSONEdit(testContext(), StructuredEditArgs{FilePath: broken, Operation: "delete", Path: "a"})
+	if !strings.Contains(result, "not valid JSON") {
+		t.Errorf("Expected an invalid document message, got: %s", result)
+	}
+	if content, _ := os.ReadFile(broken); string(content) != `{"a":` {
+		t.Errorf("Invalid document was modified: %q", content)
+	}
+}
@@ -0,0 +1,323 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gocreate/tools/i18n"
+	"gocreate/tools/render"
+
+	"github.com/localrivet/gomcp/server"
+	"gopkg.in/yaml.v3"
+)
+
+// SemanticDiffArgs defines the arguments for the semantic_diff tool.
+type SemanticDiffArgs struct {
+	FileA  string  `json:"file_a" description:"The original JSON or YAML file." required:"true"`
+	FileB  string  `json:"file_b" description:"The changed JSON or YAML file." required:"true"`
+	Format *string `json:"format,omitempty" description:"Optional. json or yaml. Defaults to detection from each file's extension."`
+	Plain  *bool   `json:"plain,omitempty" description:"Optional. If true, describe changes in words instead of symbols. Defaults to the plainOutput config value."`
+}
+
+// Change is a single structural difference between two documents.
+type Change struct {
+	Kind string // added, removed, changed or moved
+	Path string // JSON Pointer of the value (the destination for moves)
+	From string // JSON Pointer of the original position, for moves
+	Old  interface{}
+	New  interface{}
+}
+
+// formatFor returns the document format of path, honouring an explicit
+// override. An empty format means it could not be determined; msg explains why.
+func formatFor(ctx *server.Context, path string, override *string) (format string, msg string) {
+	if override != nil && *override != "" {
+		switch f := strings.ToLower(*override); f {
+		case "json", "yaml":
+			return f, ""
+		case "yml":
+			return "yaml", ""
+		default:
+			return "", i18n.T(ctx, i18n.SemanticFormatInvalid, *override)
+		}
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json", ""
+	case ".yaml", ".yml":
+		return "yaml", ""
+	}
+	return "", i18n.T(ctx, i18n.SemanticFormatUnknown, path)
+}
+
+// parseDocument decodes content into maps, slices and scalars.
+func parseDocument(content []byte, format string) (interface{}, error) {
+	var v interface{}
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(content, &v); err != nil {
+			return nil, err
+		}
+	}
+	return normalize(v), nil
+}
+
+// normalize converts YAML's non-string-keyed maps to string-keyed ones so both
+// formats produce the same shapes.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalize(e)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[fmt.Sprint(k)] = normalize(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalize(e)
+		}
+		return t
+	}
+	return v
+}
+
+// canonical returns a stable encoding of v used to compare values.
+func canonical(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(b)
+}
+
+// pointerJoin appends an escaped reference token to a JSON Pointer.
+func pointerJoin(base, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return base + "/" + token
+}
+
+// diffValues appends the differences between a and b at path to changes.
+func diffValues(path string, a, b interface{}, changes []Change) []Change {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			return diffMaps(path, av, bv, changes)
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			return diffArrays(path, av, bv, changes)
+		}
+	}
+	if canonical(a) != canonical(b) {
+		changes = append(changes, Change{Kind: "changed", Path: path, Old: a, New: b})
+	}
+	return changes
+}
+
+func diffMaps(path string, a, b map[string]interface{}, changes []Change) []Change {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		av, inA := a[k]
+		bv, inB := b[k]
+		p := pointerJoin(path, k)
+		switch {
+		case !inB:
+			changes = append(changes, Change{Kind: "removed", Path: p, Old: av})
+		case !inA:
+			changes = append(changes, Change{Kind: "added", Path: p, New: bv})
+		default:
+			changes = diffValues(p, av, bv, changes)
+		}
+	}
+	return changes
+}
+
+// diffArrays keeps the longest common subsequence of equal elements in place.
+// Remaining elements equal to one on the other side are reported as moves,
+// elements left at the same index on both sides are compared recursively, and
+// the rest are additions and removals.
+func diffArrays(path string, a, b []interface{}, changes []Change) []Change {
+	ka := make([]string, len(a))
+	for i, v := range a {
+		ka[i] = canonical(v)
+	}
+	kb := make([]string, len(b))
+	for i, v := range b {
+		kb[i] = canonical(v)
+	}
+
+	// lcs[i][j] is the LCS length of ka[i:] and kb[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if ka[i] == kb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	keptA := make([]bool, len(a))
+	keptB := make([]bool, len(b))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case ka[i] == kb[j]:
+			keptA[i], keptB[j] = true, true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	// Pair leftover equal elements as moves
+	for j := range b {
+		if keptB[j] {
+			continue
+		}
+		for i := range a {
+			if !keptA[i] && ka[i] == kb[j] {
+				keptA[i], keptB[j] = true, true
+				changes = append(changes, Change{Kind: "moved", Path: pointerJoin(path, strconv.Itoa(j)), From: pointerJoin(path, strconv.Itoa(i)), New: b[j]})
+				break
+			}
+		}
+	}
+
+	for i := range a {
+		if keptA[i] {
+			continue
+		}
+		if i < len(b) && !keptB[i] {
+			// Same position on both sides: describe what changed inside it
+			keptB[i] = true
+			changes = diffValues(pointerJoin(path, strconv.Itoa(i)), a[i], b[i], changes)
+			continue
+		}
+		changes = append(changes, Change{Kind: "removed", Path: pointerJoin(path, strconv.Itoa(i)), Old: a[i]})
+	}
+	for j := range b {
+		if !keptB[j] {
+			changes = append(changes, Change{Kind: "added", Path: pointerJoin(path, strconv.Itoa(j)), New: b[j]})
+		}
+	}
+	return changes
+}
+
+// displayPath renders the root pointer as "/" rather than an empty string.
+func displayPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return p
+}
+
+// renderChange formats a change as one line.
+func renderChange(ctx *server.Context, c Change, plain bool) string {
+	path := displayPath(c.Path)
+	switch c.Kind {
+	case "added":
+		if plain {
+			return i18n.T(ctx, i18n.SemanticAdded, path, canonical(c.New))
+		}
+		return fmt.Sprintf("+ %s: %s", path, canonical(c.New))
+	case "removed":
+		if plain {
+			return i18n.T(ctx, i18n.SemanticRemoved, path, canonical(c.Old))
+		}
+		return fmt.Sprintf("- %s: %s", path, canonical(c.Old))
+	case "moved":
+		if plain {
+			return i18n.T(ctx, i18n.SemanticMoved, displayPath(c.From), path)
+		}
+		return fmt.Sprintf("> %s -> %s", displayPath(c.From), path)
+	default:
+		if plain {
+			return i18n.T(ctx, i18n.SemanticChanged, path, canonical(c.Old), canonical(c.New))
+		}
+		return fmt.Sprintf("~ %s: %s -> %s", path, canonical(c.Old), canonical(c.New))
+	}
+}
+
+// loadDocument reads and parses a structured file. When the document cannot be
+// loaded, msg is the message to return to the client and err the underlying
+// I/O error, if any.
+func loadDocument(ctx *server.Context, path string, override *string) (doc interface{}, msg string, err error) {
+	format, msg := formatFor(ctx, path, override)
+	if format == "" {
+		return nil, msg, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, i18n.T(ctx, i18n.FileNotFound), nil
+		}
+		return nil, i18n.T(ctx, i18n.FileReadError), err
+	}
+	doc, err = parseDocument(content, format)
+	if err != nil {
+		ctx.Logger.Info("Error parsing document", "path", path, "format", format, "error", err)
+		return nil, i18n.T(ctx, i18n.SemanticParseError, path, format, err), nil
+	}
+	return doc, "", nil
+}
+
+// HandleSemanticDiff implements the semantic_diff tool.
+func HandleSemanticDiff(ctx *server.Context, args SemanticDiffArgs) (string, error) {
+	ctx.Logger.Info("Handling semantic_diff tool call")
+
+	a, msg, err := loadDocument(ctx, args.FileA, args.Format)
+	if msg != "" {
+		return msg, err
+	}
+	b, msg, err := loadDocument(ctx, args.FileB, args.Format)
+	if msg != "" {
+		return msg, err
+	}
+
+	changes := diffValues("", a, b, nil)
+	if len(changes) == 0 {
+		return i18n.T(ctx, i18n.SemanticNoDifferences), nil
+	}
+
+	plain := render.Plain(ctx, args.Plain)
+	lines := make([]string, 0, len(changes)+1)
+	lines = append(lines, i18n.T(ctx, i18n.SemanticDifferences, len(changes), args.FileA, args.FileB))
+	for _, c := range changes {
+		lines = append(lines, renderChange(ctx, c, plain))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,174 @@
+package structured
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func testContext() *server.Context {
+	return &server.Context{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestDiffValues(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []string // kind path [from]
+	}{
+		{
+			name: "identical with reordered keys",
+			a:    `{"a": 1, "b": {"c": [1, 2]}}`,
+			b:    `{"b": {"c": [1, 2]}, "a": 1}`,
+		},
+		{
+			name: "added removed and changed keys",
+			a:    `{"keep": 1, "old": true, "n": {"v": "x"}}`,
+			b:    `{"keep": 1, "new": null, "n": {"v": "y"}}`,
+			want: []string{"changed /n/v", "added /new", "removed /old"},
+		},
+		{
+			name: "escaped pointer tokens",
+			a:    `{"a/b": 1, "m~n": 1}`,
+			b:    `{"a/b": 2, "m~n": 2}`,
+			want: []string{"changed /a~1b", "changed /m~0n"},
+		},
+		{
+			name: "array move",
+			a:    `[1, 2, 3]`,
+			b:    `[3, 1, 2]`,
+			want: []string{"moved /0 /2"},
+		},
+		{
+			name: "array insertion keeps later elements in place",
+			a:    `["a", "c"]`,
+			b:    `["a", "b", "c"]`,
+			want: []string{"added /1"},
+		},
+		{
+			name: "array element changed in place",
+			a:    `[{"id": 1, "v": "x"}, {"id": 2}]`,
+			b:    `[{"id": 1, "v": "y"}, {"id": 2}]`,
+			want: []string{"changed /0/v"},
+		},
+		{
+			name: "array shrink",
+			a:    `[1, 2, 3]`,
+			b:    `[1]`,
+			want: []string{"removed /1", "removed /2"},
+		},
+		{
+			name: "type change",
+			a:    `{"v": [1]}`,
+			b:    `{"v": {"0": 1}}`,
+			want: []string{"changed /v"},
+		},
+		{
+			name: "numbers compare by value text",
+			a:    `{"v": 1.0}`,
+			b:    `{"v": 1}`,
+			want: []string{"changed /v"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := parseDocument([]byte(tt.a), "json")
+			if err != nil {
+				t.Fatalf("parse a: %v", err)
+			}
+			b, err := parseDocument([]byte(tt.b), "json")
+			if err != nil {
+				t.Fatalf("parse b: %v", err)
+			}
+			var got []string
+			for _, c := range diffValues("", a, b, nil) {
+				s := c.Kind + " " + c.Path
+				if c.From != "" {
+					s += " " + c.From
+				}
+				got = append(got, s)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffValues = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleSemanticDiffYAMLAgainstJSON(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a.yaml", "name: app\nports:\n  - 80\n  - 443\nlabels:\n  tier: web\n")
+	b := writeFile(t, dir, "b.json", `{"name": "app", "ports": [443, 80], "labels": {"tier": "api"}}`)
+
+	result, err := HandleSemanticDiff(testContext(), SemanticDiffArgs{FileA: a, FileB: b})
+	if err != nil {
+		t.Fatalf("HandleSemanticDiff failed: %v", err)
+	}
+	for _, want := range []string{"2 semantic difference(s)", "~ /labels/tier: \"web\" -> \"api\"", "> /ports/0 -> /ports/1"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result missing %q:\n%s", want, result)
+		}
+	}
+
+	plain := true
+	result, err = HandleSemanticDiff(testContext(), SemanticDiffArgs{FileA: a, FileB: b, Plain: &plain})
+	if err != nil {
+		t.Fatalf("HandleSemanticDiff failed: %v", err)
+	}
+	if !strings.Contains(result, "Moved: /ports/0 to /ports/1") {
+		t.Errorf("Plain result missing move description:\n%s", result)
+	}
+}
+
+func TestHandleSemanticDiffErrors(t *testing.T) {
+	dir := t.TempDir()
+	good := writeFile(t, dir, "good.json", `{}`)
+	bad := writeFile(t, dir, "bad.json", `{"a":`)
+	noExt := writeFile(t, dir, "data", `{}`)
+	yamlFormat := "yaml"
+	xmlFormat := "xml"
+
+	tests := []struct {
+		name string
+		args SemanticDiffArgs
+		want string
+	}{
+		{"same file", SemanticDiffArgs{FileA: good, FileB: good}, "No semantic differences"},
+		{"parse error", SemanticDiffArgs{FileA: good, FileB: bad}, "Could not parse"},
+		{"unknown extension", SemanticDiffArgs{FileA: good, FileB: noExt}, "Cannot tell the format"},
+		{"explicit format", SemanticDiffArgs{FileA: noExt, FileB: noExt, Format: &yamlFormat}, "No semantic differences"},
+		{"unsupported format", SemanticDiffArgs{FileA: good, FileB: good, Format: &xmlFormat}, "Unsupported format"},
+		{"missing file", SemanticDiffArgs{FileA: good, FileB: filepath.Join(dir, "missing.json")}, "File not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := HandleSemanticDiff(testContext(), tt.args)
+			if err != nil {
+				t.Fatalf("HandleSemanticDiff failed: %v", err)
+			}
+			if !strings.Contains(result, tt.want) {
+				t.Errorf("Result %q does not contain %q", result, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,517 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// HandleTOMLEdit implements the toml_edit tool.
+func HandleTOMLEdit(ctx *server.Context, args StructuredEditArgs) (string, error) {
+	ctx.Logger.Info("Handling toml_edit tool call")
+	return runStructuredEdit(ctx, "toml_edit", args, editFormat{
+		name: "TOML",
+		validate: func(src []byte) error {
+			var doc map[string]interface{}
+			return toml.Unmarshal(src, &doc)
+		},
+		apply: applyTOMLEdit,
+	})
+}
+
+// tomlEntry is a key/value line. path includes the enclosing table's path.
+type tomlEntry struct {
+	path                 []string
+	tableLen             int // number of leading path elements that come from the table header
+	lineStart, lineEnd   int
+	valueStart, valueEnd int
+}
+
+// tomlTable is a [table] or [[array.table]] header. end is where a new key in
+// the table is inserted: after its last key/value line.
+type tomlTable struct {
+	path        []string
+	headerStart int
+	end         int
+}
+
+// tomlDocument locates the keys and tables of a TOML document so values can
+// be replaced without reformatting the rest of the file. The scanner assumes
+// the document has already been validated.
+type tomlDocument struct {
+	src     []byte
+	entries []tomlEntry
+	tables  []tomlTable
+	rootEnd int // insertion point for new top-level keys, -1 before any are seen
+}
+
+func (d *tomlDocument) skipSpace(pos int) int {
+	for pos < len(d.src) && (d.src[pos] == ' ' || d.src[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
+
+func (d *tomlDocument) lineEnd(pos int) int {
+	if i := bytes.IndexByte(d.src[pos:], '\n'); i >= 0 {
+		return pos + i + 1
+	}
+	return len(d.src)
+}
+
+// skipString returns the position after the string starting at pos.
+func (d *tomlDocument) skipString(pos int) int {
+	quote := d.src[pos]
+	if bytes.HasPrefix(d.src[pos:], []byte{quote, quote, quote}) {
+		closing := []byte{quote, quote, quote}
+		for i := pos + 3; i < len(d.src); i++ {
+			if quote == '"' && d.src[i] == '\\' {
+				i++
+				continue
+			}
+			if bytes.HasPrefix(d.src[i:], closing) {
+				// Up to two quotes may directly precede the closing delimiter
+				end := i + 3
+				for end < len(d.src) && end < i+5 && d.src[end] == quote {
+					end++
+				}
+				return end
+			}
+		}
+		return len(d.src)
+	}
+	for i := pos + 1; i < len(d.src); i++ {
+		if quote == '"' && d.src[i] == '\\' {
+			i++
+			continue
+		}
+		if d.src[i] == quote {
+			return i + 1
+		}
+	}
+	return len(d.src)
+}
+
+// parseKey reads a dotted key such as a."b.c".d starting at pos.
+func (d *tomlDocument) parseKey(pos int) ([]string, int) {
+	var keys []string
+	for {
+		pos = d.skipSpace(pos)
+		switch d.src[pos] {
+		case '"':
+			end := d.skipString(pos)
+			key, err := strconv.Unquote(string(d.src[pos:end]))
+			if err != nil {
+				key = string(d.src[pos+1 : end-1])
+			}
+			keys = append(keys, key)
+			pos = end
+		case '\'':
+			end := d.skipString(pos)
+			keys = append(keys, string(d.src[pos+1:end-1]))
+			pos = end
+		default:
+			start := pos
+			for pos < len(d.src) && isBareKeyChar(d.src[pos]) {
+				pos++
+			}
+			keys = append(keys, string(d.src[start:pos]))
+		}
+		pos = d.skipSpace(pos)
+		if pos >= len(d.src) || d.src[pos] != '.' {
+			return keys, pos
+		}
+		pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// valueEnd returns the end of the value starting at pos, excluding trailing
+// whitespace and comments. Arrays may span lines.
+func (d *tomlDocument) valueEnd(pos int) int {
+	depth, end := 0, pos
+	for pos < len(d.src) {
+		c := d.src[pos]
+		switch {
+		case c == '"' || c == '\'':
+			pos = d.skipString(pos)
+			end = pos
+			continue
+		case c == '#':
+			for pos < len(d.src) && d.src[pos] != '\n' {
+				pos++
+			}
+			continue
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == '\n' && depth == 0:
+			return end
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			end = pos + 1
+		}
+		pos++
+	}
+	return end
+}
+
+// scanTOML builds a tomlDocument from src.
+func scanTOML(src []byte) *tomlDocument {
+	d := &tomlDocument{src: src, rootEnd: -1}
+	arrayCounts := make(map[string]int)
+	// expand inserts the current element index after every array table prefix
+	expand := func(keys []string) []string {
+		var path []string
+		for i, k := range keys {
+			path = append(path, k)
+			if n := arrayCounts[strings.Join(keys[:i+1], "\x00")]; n > 0 {
+				path = append(path, strconv.Itoa(n-1))
+			}
+		}
+		return path
+	}
+
+	table := -1 // index of the current table, or -1 for the root
+	var tablePath []string
+	for pos := 0; pos < len(src); {
+		lineStart := pos
+		pos = d.skipSpace(pos)
+		if pos >= len(src) || src[pos] == '\n' || src[pos] == '\r' || src[pos] == '#' {
+			pos = d.lineEnd(pos)
+			continue
+		}
+
+		if src[pos] == '[' {
+			array := bytes.HasPrefix(src[pos:], []byte("[["))
+			start := pos + 1
+			if array {
+				start++
+			}
+			keys, _ := d.parseKey(start)
+			if array {
+				arrayCounts[strings.Join(keys, "\x00")]++
+			}
+			tablePath = expand(keys)
+			end := d.lineEnd(pos)
+			d.tables = append(d.tables, tomlTable{path: tablePath, headerStart: lineStart, end: end})
+			table = len(d.tables) - 1
+			pos = end
+			continue
+		}
+
+		keys, keyEnd := d.parseKey(pos)
+		valueStart := d.skipSpace(keyEnd + 1) // past '='
+		valueEnd := d.valueEnd(valueStart)
+		entry := tomlEntry{
+			path:       append(append([]string{}, tablePath...), keys...),
+			tableLen:   len(tablePath),
+			lineStart:  lineStart,
+			lineEnd:    d.lineEnd(valueEnd),
+			valueStart: valueStart,
+			valueEnd:   valueEnd,
+		}
+		d.entries = append(d.entries, entry)
+		if table >= 0 {
+			d.tables[table].end = entry.lineEnd
+		} else {
+			d.rootEnd = entry.lineEnd
+		}
+		pos = entry.lineEnd
+	}
+	return d
+}
+
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalPath(a, b []string) bool {
+	return len(a) == len(b) && hasPrefix(a, b)
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey renders a dotted key, quoting parts that are not bare keys.
+func tomlKey(keys []string) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		if bareKey.MatchString(k) {
+			parts[i] = k
+		} else {
+			parts[i] = tomlString(k)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// tomlString renders s as a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// errTOMLNull reports a null value, which TOML cannot represent.
+var errTOMLNull = fmt.Errorf("null value")
+
+// renderTOML renders v as an inline TOML value. When indent is non-empty,
+// arrays are written one element per line at that indentation, closing at
+// outer.
+func renderTOML(v interface{}, indent, outer string) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", errTOMLNull
+	case string:
+		return tomlString(t), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case json.Number:
+		return t.String(), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case float64:
+		switch {
+		case math.IsNaN(t):
+			return "nan", nil
+		case math.IsInf(t, 1):
+			return "inf", nil
+		case math.IsInf(t, -1):
+			return "-inf", nil
+		}
+		s := strconv.FormatFloat(t, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s, nil
+	case time.Time:
+		return t.Format(time.RFC3339Nano), nil
+	case []interface{}:
+		parts := make([]string, len(t))
+		for i, e := range t {
+			s, err := renderTOML(e, "", "")
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		if indent != "" && len(parts) > 0 {
+			return "[\n" + indent + strings.Join(parts, ",\n"+indent) + ",\n" + outer + "]", nil
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			s, err := renderTOML(t[k], "", "")
+			if err != nil {
+				return "", err
+			}
+			parts[i] = tomlKey([]string{k}) + " = " + s
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case fmt.Stringer:
+		// Local dates and times
+		return t.String(), nil
+	}
+	return "", fmt.Errorf("unsupported value %T", v)
+}
+
+// parseTOMLValue decodes the text of a single TOML value.
+func parseTOMLValue(text []byte) (interface{}, error) {
+	var doc map[string]interface{}
+	if err := toml.Unmarshal(append([]byte("v = "), text...), &doc); err != nil {
+		return nil, err
+	}
+	return doc["v"], nil
+}
+
+// arrayLayout returns the element and closing indentation of a multi-line
+// array value, or empty strings for inline values.
+func (d *tomlDocument) arrayLayout(e tomlEntry) (string, string) {
+	text := d.src[e.valueStart:e.valueEnd]
+	i := bytes.IndexByte(text, '\n')
+	if i < 0 {
+		return "", ""
+	}
+	indent := lineIndent(text, i+1)
+	if indent == "" {
+		indent = "  "
+	}
+	return indent, lineIndent(d.src, e.lineStart)
+}
+
+// applyTOMLEdit performs operation on src by rewriting only the affected key,
+// value or table.
+func applyTOMLEdit(ctx *server.Context, src []byte, operation, path string, value []byte) ([]byte, string) {
+	tokens := parsePath(path)
+	if len(tokens) == 0 {
+		if operation == "delete" {
+			return nil, i18n.T(ctx, i18n.JSONEditDeleteRoot)
+		}
+		return nil, i18n.T(ctx, i18n.TOMLTablePath, path)
+	}
+	var v interface{}
+	if operation != "delete" {
+		v, _ = decodeValue(value)
+	}
+	render := func(v interface{}, indent, outer string) (string, string) {
+		s, err := renderTOML(v, indent, outer)
+		if err == errTOMLNull {
+			return "", i18n.T(ctx, i18n.TOMLNullValue, path)
+		}
+		if err != nil {
+			return "", err.Error()
+		}
+		return s, ""
+	}
+
+	d := scanTOML(src)
+
+	// A key/value line at or above the path: edit its value
+	for _, e := range d.entries {
+		if !hasPrefix(tokens, e.path) {
+			continue
+		}
+		rest := tokens[len(e.path):]
+		if len(rest) == 0 && operation == "delete" {
+			return splice(src, e.lineStart, e.lineEnd, ""), ""
+		}
+		current, err := parseTOMLValue(src[e.valueStart:e.valueEnd])
+		if err != nil {
+			return nil, err.Error()
+		}
+		updated, msg := editTree(ctx, current, rest, operation, path, v)
+		if msg != "" {
+			return nil, msg
+		}
+		indent, outer := d.arrayLayout(e)
+		text, msg := render(updated, indent, outer)
+		if msg != "" {
+			return nil, msg
+		}
+		return splice(src, e.valueStart, e.valueEnd, text), ""
+	}
+
+	// A table header: only whole tables can be deleted
+	for i, t := range d.tables {
+		if !equalPath(t.path, tokens) {
+			continue
+		}
+		if operation != "delete" {
+			return nil, i18n.T(ctx, i18n.TOMLTablePath, path)
+		}
+		end := len(src)
+		if i+1 < len(d.tables) {
+			end = d.tables[i+1].headerStart
+		}
+		return splice(src, t.headerStart, end, ""), ""
+	}
+
+	if operation != "set" {
+		return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+	}
+	text, msg := render(v, "", "")
+	if msg != "" {
+		return nil, msg
+	}
+	parent := tokens[:len(tokens)-1]
+	insert := func(at int, keys []string) []byte {
+		line := tomlKey(keys) + " = " + text + "\n"
+		if at > 0 && src[at-1] != '\n' {
+			line = "\n" + line
+		}
+		return splice(src, at, at, line)
+	}
+
+	// The parent table has a header
+	for _, t := range d.tables {
+		if equalPath(t.path, parent) {
+			return insert(t.end, tokens[len(t.path):]), ""
+		}
+	}
+	// The parent is defined by dotted keys: add a sibling after the last one
+	for i := len(d.entries) - 1; i >= 0; i-- {
+		e := d.entries[i]
+		if len(parent) > 0 && e.tableLen < len(parent) && hasPrefix(e.path, parent) {
+			return insert(e.lineEnd, tokens[e.tableLen:]), ""
+		}
+	}
+	if len(parent) == 0 {
+		switch {
+		case d.rootEnd >= 0:
+			return insert(d.rootEnd, tokens), ""
+		case len(d.tables) > 0:
+			// Top-level keys must come before the first table
+			at := d.tables[0].headerStart
+			return splice(src, at, at, tomlKey(tokens)+" = "+text+"\n\n"), ""
+		}
+		return insert(len(src), tokens), ""
+	}
+
+	// Start a new table at the end of the file
+	block := "[" + tomlKey(parent) + "]\n" + tomlKey(tokens[len(parent):]) + " = " + text + "\n"
+	switch {
+	case len(src) == 0:
+	case src[len(src)-1] != '\n':
+		block = "\n\n" + block
+	default:
+		block = "\n" + block
+	}
+	return append(append([]byte{}, src...), block...), ""
+}
@@ -0,0 +1,122 @@
+package structured
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestApplyTOMLEdit(t *testing.T) {
+	cargo := `# Cargo manifest
+[package]
+name = "demo" # crate name
+version = "0.1.0"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+tokio = "1"
+
+[[bin]]
+name = "a"
+
+[[bin]]
+name = "b"
+`
+	pyproject := "title = \"x\"\n\n[project]\nname = \"app\"\nurls.home = \"https://example.com\"\nclassifiers = [\n    \"A\",\n    \"B\",\n]\n"
+
+	tests := []struct {
+		name      string
+		src       string
+		operation string
+		path      string
+		value     string
+		want      string
+		wantMsg   string
+	}{
+		{
+			name: "replace value keeps comment", src: cargo, operation: "set", path: "package.version", value: `"0.2.0"`,
+			want: strings.Replace(cargo, `version = "0.1.0"`, `version = "0.2.0"`, 1),
+		},
+		{
+			name: "edit inside inline table", src: cargo, operation: "set", path: "dependencies.serde.version", value: `"1.0.200"`,
+			want: strings.Replace(cargo, `serde = { version = "1.0", features = ["derive"] }`, `serde = { features = ["derive"], version = "1.0.200" }`, 1),
+		},
+		{
+			name: "add key to table", src: cargo, operation: "set", path: "dependencies.anyhow", value: `"1"`,
+			want: strings.Replace(cargo, "tokio = \"1\"\n", "tokio = \"1\"\nanyhow = \"1\"\n", 1),
+		},
+		{
+			name: "array table element", src: cargo, operation: "set", path: "bin[1].name", value: `"c"`,
+			want: strings.Replace(cargo, `name = "b"`, `name = "c"`, 1),
+		},
+		{
+			name: "delete key", src: cargo, operation: "delete", path: "dependencies.tokio",
+			want: strings.Replace(cargo, "tokio = \"1\"\n", "", 1),
+		},
+		{
+			name: "delete table", src: cargo, operation: "delete", path: "dependencies",
+			want: strings.Replace(cargo, "[dependencies]\nserde = { version = \"1.0\", features = [\"derive\"] }\ntokio = \"1\"\n\n", "", 1),
+		},
+		{
+			name: "new table at end", src: cargo, operation: "set", path: "profile.release.lto", value: `true`,
+			want: cargo + "\n[profile.release]\nlto = true\n",
+		},
+		{
+			name: "append to multi-line array", src: pyproject, operation: "append", path: "project.classifiers", value: `"C"`,
+			want: strings.Replace(pyproject, "    \"B\",\n]", "    \"B\",\n    \"C\",\n]", 1),
+		},
+		{
+			name: "dotted key sibling", src: pyproject, operation: "set", path: "project.urls.docs", value: `"https://docs.example.com"`,
+			want: strings.Replace(pyproject, "urls.home = \"https://example.com\"\n", "urls.home = \"https://example.com\"\nurls.docs = \"https://docs.example.com\"\n", 1),
+		},
+		{
+			name: "top-level key before first table", src: pyproject, operation: "set", path: "description", value: `"d"`,
+			want: strings.Replace(pyproject, "title = \"x\"\n", "title = \"x\"\ndescription = \"d\"\n", 1),
+		},
+		{
+			name: "quoted keys and escapes", src: "[tool]\n", operation: "set", path: "/tool/a.b", value: `"line\n\"quoted\""`,
+			want: "[tool]\n\"a.b\" = \"line\\n\\\"quoted\\\"\"\n",
+		},
+		{name: "null value", src: cargo, operation: "set", path: "package.name", value: `null`, wantMsg: "no null value"},
+		{name: "set table", src: cargo, operation: "set", path: "package", value: `{}`, wantMsg: "is a table"},
+		{name: "append to string", src: cargo, operation: "append", path: "package.name", value: `"x"`, wantMsg: "is not an array"},
+		{name: "missing key", src: cargo, operation: "delete", path: "package.edition", wantMsg: "does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msg := applyTOMLEdit(testContext(), []byte(tt.src), tt.operation, tt.path, []byte(tt.value))
+			if tt.wantMsg != "" {
+				if !strings.Contains(msg, tt.wantMsg) {
+					t.Errorf("Message %q does not contain %q", msg, tt.wantMsg)
+				}
+				return
+			}
+			if msg != "" {
+				t.Fatalf("Unexpected message: %s", msg)
+			}
+			if string(got) != tt.want {
+				t.Errorf("applyTOMLEdit =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleTOMLEditRefusesInvalidResult(t *testing.T) {
+	dir := t.TempDir()
+	original := "[project]\nname = \"app\"\n\n[[bin]]\nname = \"a\"\n"
+	path := writeFile(t, dir, "pyproject.toml", original)
+	value := `"x"`
+
+	// bin is an array of tables, so a top-level bin key would redefine it
+	result, err := HandleTOMLEdit(testContext(), StructuredEditArgs{FilePath: path, Operation: "set", Path: "bin", Value: &value})
+	if err != nil {
+		t.Fatalf("HandleTOMLEdit failed: %v", err)
+	}
+	if !strings.Contains(result, "was not changed") {
+		t.Errorf("Expected the edit to be refused, got: %s", result)
+	}
+	if content, _ := os.ReadFile(path); string(content) != original {
+		t.Errorf("File was modified: %q", content)
+	}
+}
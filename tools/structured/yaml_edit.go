@@ -0,0 +1,251 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+	"gopkg.in/yaml.v3"
+)
+
+// HandleYAMLEdit implements the yaml_edit tool.
+func HandleYAMLEdit(ctx *server.Context, args StructuredEditArgs) (string, error) {
+	ctx.Logger.Info("Handling yaml_edit tool call")
+	return runStructuredEdit(ctx, "yaml_edit", args, editFormat{
+		name: "YAML",
+		validate: func(src []byte) error {
+			_, err := decodeYAMLDocuments(src)
+			return err
+		},
+		apply: applyYAMLEdit,
+	})
+}
+
+// decodeYAMLDocuments parses every document in a YAML stream, keeping comments.
+func decodeYAMLDocuments(src []byte) ([]*yaml.Node, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, &doc)
+	}
+}
+
+// yamlIndent returns the indentation width of the first indented line, which
+// is the step the encoder should use.
+func yamlIndent(src []byte) int {
+	for _, line := range bytes.Split(src, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " ")
+		if len(trimmed) == len(line) || len(trimmed) == 0 || trimmed[0] == '#' {
+			continue
+		}
+		return len(line) - len(trimmed)
+	}
+	return 2
+}
+
+// yamlValue converts a decoded JSON value to a YAML node. JSON numbers become
+// YAML integers or floats rather than strings.
+func yamlValue(v interface{}) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(plainValue(v)); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// plainValue replaces json.Numbers in v with int64 or float64.
+func plainValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = plainValue(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = plainValue(e)
+		}
+	}
+	return v
+}
+
+// replaceYAMLNode puts replacement where old was, keeping old's comments and,
+// for strings, its quoting style.
+func replaceYAMLNode(old, replacement *yaml.Node) *yaml.Node {
+	if replacement.HeadComment == "" {
+		replacement.HeadComment = old.HeadComment
+	}
+	if replacement.LineComment == "" {
+		replacement.LineComment = old.LineComment
+	}
+	if replacement.FootComment == "" {
+		replacement.FootComment = old.FootComment
+	}
+	if old.Kind == yaml.ScalarNode && replacement.Kind == yaml.ScalarNode && replacement.Tag == "!!str" && old.Tag == "!!str" {
+		replacement.Style = old.Style
+	}
+	return replacement
+}
+
+// matchSiblingStyle quotes a new string like the last string among siblings,
+// so values such as "443:443" keep the quoting the file already uses.
+func matchSiblingStyle(siblings []*yaml.Node, node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!str" || node.Style != 0 {
+		return node
+	}
+	for i := len(siblings) - 1; i >= 0; i-- {
+		if s := siblings[i]; s.Kind == yaml.ScalarNode && s.Tag == "!!str" {
+			node.Style = s.Style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle)
+			break
+		}
+	}
+	return node
+}
+
+// applyYAMLEdit performs operation on the first document of src.
+func applyYAMLEdit(ctx *server.Context, src []byte, operation, path string, value []byte) ([]byte, string) {
+	docs, err := decodeYAMLDocuments(src)
+	if err != nil {
+		return nil, err.Error()
+	}
+	if len(docs) == 0 {
+		docs = []*yaml.Node{{Kind: yaml.DocumentNode}}
+	}
+	if len(docs[0].Content) == 0 {
+		// An empty document edits like an empty mapping
+		docs[0].Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	var replacement *yaml.Node
+	if operation != "delete" {
+		v, _ := decodeValue(value)
+		if replacement, err = yamlValue(v); err != nil {
+			return nil, err.Error()
+		}
+	}
+
+	tokens := parsePath(path)
+	root := docs[0]
+	if len(tokens) == 0 {
+		switch operation {
+		case "delete":
+			return nil, i18n.T(ctx, i18n.JSONEditDeleteRoot)
+		case "set":
+			root.Content[0] = replaceYAMLNode(root.Content[0], replacement)
+		}
+	}
+
+	// Walk to the parent of the last token
+	node := root.Content[0]
+	walk := tokens
+	if operation != "append" && len(tokens) > 0 {
+		walk = tokens[:len(tokens)-1]
+	}
+	for _, token := range walk {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		child := yamlChild(node, token)
+		if child < 0 {
+			return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+		}
+		node = node.Content[child]
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch {
+	case operation == "append":
+		if node.Kind != yaml.SequenceNode {
+			return nil, i18n.T(ctx, i18n.JSONEditNotArray, path)
+		}
+		node.Content = append(node.Content, matchSiblingStyle(node.Content, replacement))
+
+	case len(tokens) == 0:
+		// The root was replaced above
+
+	case operation == "set":
+		last := tokens[len(tokens)-1]
+		if i := yamlChild(node, last); i >= 0 {
+			node.Content[i] = replaceYAMLNode(node.Content[i], replacement)
+			break
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: last}
+			node.Content = append(node.Content, key, replacement)
+		case yaml.SequenceNode:
+			if last != "-" && last != strconv.Itoa(len(node.Content)) {
+				return nil, i18n.T(ctx, i18n.JSONEditIndexRange, last, path, len(node.Content))
+			}
+			node.Content = append(node.Content, matchSiblingStyle(node.Content, replacement))
+		default:
+			return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+		}
+
+	case operation == "delete":
+		i := yamlChild(node, tokens[len(tokens)-1])
+		if i < 0 {
+			return nil, i18n.T(ctx, i18n.JSONEditPathNotFound, path)
+		}
+		if node.Kind == yaml.MappingNode {
+			// Remove the key along with its value
+			node.Content = append(node.Content[:i-1], node.Content[i+1:]...)
+		} else {
+			node.Content = append(node.Content[:i], node.Content[i+1:]...)
+		}
+	}
+
+	var buf bytes.Buffer
+	if bytes.HasPrefix(bytes.TrimLeft(src, " \t\r\n"), []byte("---")) {
+		buf.WriteString("---\n")
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent(src))
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err.Error()
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err.Error()
+	}
+	return buf.Bytes(), ""
+}
+
+// yamlChild returns the index in node.Content of the value for token, or -1.
+// For mappings this is the value node that follows the key.
+func yamlChild(node *yaml.Node, token string) int {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := len(node.Content) - 2; i >= 0; i -= 2 {
+			if node.Content[i].Value == token {
+				return i + 1
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+			return i
+		}
+	}
+	return -1
+}
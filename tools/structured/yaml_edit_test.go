@@ -0,0 +1,83 @@
+package structured
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyYAMLEdit(t *testing.T) {
+	compose := `# compose file
+version: "3.8"
+services:
+  web:
+    image: nginx:1.25 # pinned
+    ports:
+      - "80:80"
+  db:
+    image: postgres
+`
+
+	tests := []struct {
+		name      string
+		src       string
+		operation string
+		path      string
+		value     string
+		want      string
+		wantMsg   string
+	}{
+		{
+			name: "replace scalar keeps comment", src: compose, operation: "set", path: "services.web.image", value: `"nginx:1.27"`,
+			want: strings.Replace(compose, "nginx:1.25", "nginx:1.27", 1),
+		},
+		{
+			name: "quoted string stays quoted", src: compose, operation: "set", path: "version", value: `"3.9"`,
+			want: strings.Replace(compose, `"3.8"`, `"3.9"`, 1),
+		},
+		{
+			name: "append to sequence", src: compose, operation: "append", path: "/services/web/ports", value: `"443:443"`,
+			want: strings.Replace(compose, "      - \"80:80\"\n", "      - \"80:80\"\n      - \"443:443\"\n", 1),
+		},
+		{
+			name: "add mapping", src: compose, operation: "set", path: "services.db.environment", value: `{"POSTGRES_DB": "app", "RETRIES": 3}`,
+			want: compose + "    environment:\n      POSTGRES_DB: app\n      RETRIES: 3\n",
+		},
+		{
+			name: "delete key", src: compose, operation: "delete", path: "services.db",
+			want: strings.Replace(compose, "  db:\n    image: postgres\n", "", 1),
+		},
+		{
+			name: "four space indentation", src: "a:\n    b: 1\n", operation: "set", path: "a.c", value: `[1]`,
+			want: "a:\n    b: 1\n    c:\n        - 1\n",
+		},
+		{
+			name: "every document is kept", src: "a: 1\n---\nb: 2\n", operation: "set", path: "a", value: `5`,
+			want: "a: 5\n---\nb: 2\n",
+		},
+		{
+			name: "empty file", src: "", operation: "set", path: "name", value: `"x"`,
+			want: "name: x\n",
+		},
+		{name: "append to mapping", src: compose, operation: "append", path: "services", value: `1`, wantMsg: "is not an array"},
+		{name: "missing parent", src: compose, operation: "set", path: "volumes.data.driver", value: `"local"`, wantMsg: "does not exist"},
+		{name: "index out of range", src: compose, operation: "set", path: "services.web.ports.3", value: `"1:1"`, wantMsg: "out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msg := applyYAMLEdit(testContext(), []byte(tt.src), tt.operation, tt.path, []byte(tt.value))
+			if tt.wantMsg != "" {
+				if !strings.Contains(msg, tt.wantMsg) {
+					t.Errorf("Message %q does not contain %q", msg, tt.wantMsg)
+				}
+				return
+			}
+			if msg != "" {
+				t.Fatalf("Unexpected message: %s", msg)
+			}
+			if string(got) != tt.want {
+				t.Errorf("applyYAMLEdit =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package terminal
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// cleanOutput returns output as a terminal would show it, as plain text:
+// ANSI escape sequences, such as colours and cursor movement, and other
+// control characters are removed, and a line redrawn after a carriage return
+// or backspace, as progress bars are, keeps only what was drawn last. Erasing
+// the line (ESC [ K) is honoured; other sequences are dropped. Tabs and
+// newlines are kept, and \r\n is a newline.
+func cleanOutput(output string) string {
+	var b strings.Builder
+	b.Grow(len(output))
+	var line []rune // The line being drawn
+	col := 0        // Where the next character is drawn in it
+
+	for i := 0; i < len(output); {
+		c := output[i]
+		switch {
+		case c == 0x1b:
+			final, n := escapeSequence(output[i:])
+			if final == 'K' {
+				// Erase to the end of the line, from its start, or all of it,
+				// in which case what is drawn next starts the line
+				switch output[i+2 : i+n-1] {
+				case "", "0":
+					line = line[:min(col, len(line))]
+				case "1":
+					for j := 0; j < min(col+1, len(line)); j++ {
+						line[j] = ' '
+					}
+				case "2":
+					line = line[:0]
+				}
+			}
+			i += n
+		case c == '\n':
+			b.WriteString(string(line))
+			b.WriteByte('\n')
+			line, col = line[:0], 0
+			i++
+		case c == '\r':
+			if i+1 < len(output) && output[i+1] == '\n' {
+				i++ // The newline ends the line
+				continue
+			}
+			col = 0
+			i++
+		case c == '\b':
+			if col > 0 {
+				col--
+			}
+			i++
+		case c < 0x20 && c != '\t' || c == 0x7f:
+			i++
+		default:
+			r, size := utf8.DecodeRuneInString(output[i:])
+			if col < len(line) {
+				line[col] = r
+			} else {
+				line = append(line, r)
+			}
+			col++
+			i += size
+		}
+	}
+	b.WriteString(string(line))
+	return b.String()
+}
+
+// escapeSequence returns the final byte of the escape sequence s starts with,
+// for CSI sequences (ESC [ ... final), and its length. A sequence cut off at
+// the end of s runs to the end.
+func escapeSequence(s string) (byte, int) {
+	if len(s) < 2 {
+		return 0, len(s)
+	}
+	switch s[1] {
+	case '[':
+		// Parameter and intermediate bytes up to a final byte
+		for j := 2; j < len(s); j++ {
+			if s[j] >= 0x40 && s[j] <= 0x7e {
+				return s[j], j + 1
+			}
+			if s[j] < 0x20 || s[j] > 0x7e {
+				return 0, j // Malformed: drop what came before
+			}
+		}
+		return 0, len(s)
+	case ']', 'P', '_', '^':
+		// A string, such as a window title, ended by BEL or ESC \
+		for j := 2; j < len(s); j++ {
+			if s[j] == 0x07 {
+				return 0, j + 1
+			}
+			if s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\' {
+				return 0, j + 2
+			}
+		}
+		return 0, len(s)
+	default:
+		return 0, 2
+	}
+}
@@ -0,0 +1,29 @@
+package terminal
+
+import "testing"
+
+func TestCleanOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"plain", "ok\tgocreate/tools\n", "ok\tgocreate/tools\n"},
+		{"colours", "\x1b[1;32mPASS\x1b[0m: \x1b[31mfail\x1b[m\n", "PASS: fail\n"},
+		{"progress bar", "Downloading  10%\rDownloading  55%\rDownloading 100%\ndone\n", "Downloading 100%\ndone\n"},
+		{"shorter redraw", "building 12/12\rok\n", "okilding 12/12\n"},
+		{"erased redraw", "building 12/12\r\x1b[Kok\n", "ok\n"},
+		{"erased line", "50%\x1b[2K\rdone\n", "done\n"},
+		{"crlf", "line one\r\nline two\r\n", "line one\nline two\n"},
+		{"backspace", "spinner |\b/\b-\b\\\n", "spinner \\\n"},
+		{"window title and bell", "\x1b]0;make\x07building\a\n", "building\n"},
+		{"cursor movement", "\x1b[?25l\x1b[2Aabc\x1b[?25h", "abc"},
+		{"utf-8", "✓ über\r✗\n", "✗ über\n"},
+		{"cut off sequence", "text\x1b[3", "text"},
+	}
+	for _, tt := range tests {
+		if got := cleanOutput(tt.output); got != tt.want {
+			t.Errorf("%s: cleanOutput(%q) = %q; want %q", tt.name, tt.output, got, tt.want)
+		}
+	}
+}
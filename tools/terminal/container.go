@@ -0,0 +1,140 @@
+package terminal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gocreate/tools/config"
+)
+
+// Defaults of a container without them in its configuration.
+const (
+	defaultMountPath      = "/workspace"
+	defaultNetwork        = "none"
+	defaultContainerShell = "/bin/sh"
+)
+
+// containerRemoveTimeout bounds how long removing a container may take.
+const containerRemoveTimeout = 10 * time.Second
+
+// errNoRuntime is returned when neither docker nor podman is found.
+var errNoRuntime = errors.New("neither docker nor podman was found")
+
+// Container is a container StartCommand runs a command in, through the docker
+// or podman CLI, which relays its input, output, signals and exit code. The
+// command runs in Workspace, which is mounted at MountPath.
+type Container struct {
+	Runtime   string // The docker or podman executable
+	Image     string
+	Workspace string // Absolute host directory mounted into the container
+	MountPath string
+	ReadOnly  bool
+	Network   string
+	Shell     string   // The shell in the image running the command
+	Args      []string // More options of the run command
+}
+
+// containerFromConfig returns the container cfg describes, with the defaults
+// for what it leaves out.
+func containerFromConfig(cfg *config.Container) (*Container, error) {
+	if cfg.Image == "" {
+		return nil, errors.New("no container image is configured")
+	}
+	c := &Container{
+		Runtime:   cfg.Runtime,
+		Image:     cfg.Image,
+		Workspace: cfg.Workspace,
+		MountPath: cfg.MountPath,
+		ReadOnly:  cfg.ReadOnly,
+		Network:   cfg.Network,
+		Shell:     cfg.Shell,
+		Args:      cfg.Args,
+	}
+	if c.Runtime == "" {
+		for _, runtime := range []string{"docker", "podman"} {
+			if _, err := exec.LookPath(runtime); err == nil {
+				c.Runtime = runtime
+				break
+			}
+		}
+		if c.Runtime == "" {
+			return nil, errNoRuntime
+		}
+	}
+	if c.Workspace == "" {
+		c.Workspace = "."
+	}
+	workspace, err := filepath.Abs(c.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	c.Workspace = workspace
+	if c.MountPath == "" {
+		c.MountPath = defaultMountPath
+	}
+	if c.Network == "" {
+		c.Network = defaultNetwork
+	}
+	if c.Shell == "" {
+		c.Shell = defaultContainerShell
+	}
+	return c, nil
+}
+
+// command returns the command that runs commandStr with shell in a new
+// container called name, in the directory dir of the host mapped into it.
+// The container is removed once the command exits.
+func (c *Container) command(name, dir string, pty bool, shell, executeFlag, commandStr string) (*exec.Cmd, error) {
+	workdir, err := c.mapDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	volume := c.Workspace + ":" + c.MountPath
+	if c.ReadOnly {
+		volume += ":ro"
+	}
+	args := []string{"run", "--rm", "--interactive", "--init", "--name", name, "--network", c.Network, "--volume", volume, "--workdir", workdir}
+	if pty {
+		args = append(args, "--tty")
+	}
+	args = append(args, c.Args...)
+	args = append(args, c.Image, shell, executeFlag, commandStr)
+	return exec.Command(c.Runtime, args...), nil
+}
+
+// mapDir returns where the host directory dir is in the container, which is
+// only where the workspace is mounted.
+func (c *Container) mapDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(c.Workspace, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the container workspace %s", abs, c.Workspace)
+	}
+	return path.Join(c.MountPath, filepath.ToSlash(rel)), nil
+}
+
+// remove kills and removes the container called name, which killing the CLI
+// that runs it leaves running.
+func (c *Container) remove(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), containerRemoveTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, c.Runtime, "rm", "--force", name).Run()
+}
+
+// containerName returns a new name for a session's container.
+func containerName() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "gocreate-" + hex.EncodeToString(b)
+}
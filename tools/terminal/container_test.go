@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gocreate/tools/config"
+)
+
+func TestContainerCommand(t *testing.T) {
+	workspace := t.TempDir()
+	c, err := containerFromConfig(&config.Container{Image: "golang:1.24", Runtime: "podman", Workspace: workspace, ReadOnly: true, Args: []string{"--memory=1g"}})
+	if err != nil {
+		t.Fatalf("containerFromConfig failed: %v", err)
+	}
+	if c.MountPath != defaultMountPath || c.Network != defaultNetwork || c.Shell != defaultContainerShell {
+		t.Errorf("defaults = %q, %q, %q", c.MountPath, c.Network, c.Shell)
+	}
+
+	cmd, err := c.command("gocreate-test", filepath.Join(workspace, "src"), true, "/bin/sh", "-c", "go test ./...")
+	if err != nil {
+		t.Fatalf("command failed: %v", err)
+	}
+	want := []string{"podman", "run", "--rm", "--interactive", "--init", "--name", "gocreate-test", "--network", "none",
+		"--volume", workspace + ":/workspace:ro", "--workdir", "/workspace/src", "--tty", "--memory=1g", "golang:1.24", "/bin/sh", "-c", "go test ./..."}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("command = %q\nwant %q", cmd.Args, want)
+	}
+
+	if _, err := c.command("gocreate-test", filepath.Dir(workspace), false, "/bin/sh", "-c", "ls"); err == nil {
+		t.Error("a command outside the workspace was run in the container")
+	}
+	if _, err := containerFromConfig(&config.Container{Runtime: "docker"}); err == nil {
+		t.Error("a container without an image was accepted")
+	}
+}
+
+func TestContainerSession(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	// A stand-in for the runtime's CLI runs the command on the host and notes
+	// which containers it was asked to remove
+	dir := t.TempDir()
+	runtime := filepath.Join(dir, "runtime")
+	script := "#!/bin/sh\nif [ \"$1\" = rm ]; then echo \"$3\" >> \"$0.removed\"; exit 0; fi\nwhile [ \"$#\" -gt 3 ]; do shift; done\nexec \"$@\"\n"
+	if err := os.WriteFile(runtime, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{Runtime: runtime, Image: "alpine", Workspace: dir, MountPath: "/workspace", Network: "none", Shell: "/bin/sh"}
+
+	tm := newManager()
+	pid, err := tm.StartCommand(testContext(), "echo in the container", "/bin/sh", "-c", StartOptions{Dir: dir, Container: c})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ := tm.GetSession(pid)
+	<-session.Done
+	if output, err := tm.ReadNewOutput(pid); err != nil || output.Output != "in the container\n" || *output.ExitCode != 0 {
+		t.Errorf("ReadNewOutput = %+v, %v", output, err)
+	}
+	if session.Image != "alpine" || !strings.HasPrefix(session.name, "gocreate-") {
+		t.Errorf("session image %q, container %q", session.Image, session.name)
+	}
+
+	// Terminating the session removes its container, which killing the CLI
+	// would leave running
+	pid, err = tm.StartCommand(testContext(), "sleep 30", "/bin/sh", "-c", StartOptions{Dir: dir, Container: c})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ = tm.GetSession(pid)
+	if err := tm.TerminateSession(testContext(), pid); err != nil {
+		t.Fatalf("TerminateSession failed: %v", err)
+	}
+	select {
+	case <-session.Done:
+	case <-time.After(terminateGrace + 2*time.Second):
+		t.Fatal("the session did not exit")
+	}
+	if removed, _ := os.ReadFile(runtime + ".removed"); string(removed) != session.name+"\n" {
+		t.Errorf("removed containers %q; want only %q", removed, session.name)
+	}
+}
@@ -0,0 +1,158 @@
+package terminal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gocreate/tools/config"
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// RunMacroArgs defines the arguments for the run_macro tool.
+type RunMacroArgs struct {
+	Name   string            `json:"name" description:"The macro to run, one of the macros in the configuration. An unknown name lists them with their parameters." required:"true"`
+	Params map[string]string `json:"params,omitempty" description:"A value for each of the macro's {{name}} parameters. Each value is quoted and passed to the command as a single argument."`
+	Label  *string           `json:"label,omitempty" description:"A name for the session, unique among running ones, by which the other terminal tools can address it instead of its PID."`
+	Cwd    *string           `json:"cwd,omitempty" description:"The directory to run the command in, which must be within the allowed directories. Defaults to the server's working directory."`
+}
+
+// macroParam matches a {{name}} parameter of a macro's command template.
+var macroParam = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// safeArg matches values that every shell reads as a single word as they are.
+var safeArg = regexp.MustCompile(`^[A-Za-z0-9_./:=@+,-]+$`)
+
+// macroParams returns the names of the parameters of the command template,
+// in the order they first appear.
+func macroParams(template string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range macroParam.FindAllStringSubmatch(template, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// quoteArg returns value quoted as a single argument for a POSIX shell, or,
+// for the Windows shells, which have no quoting that makes any value inert,
+// reports false unless it needs none.
+func quoteArg(value string, posix bool) (string, bool) {
+	if safeArg.MatchString(value) {
+		return value, true
+	}
+	if !posix {
+		return "", false
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'", true
+}
+
+// expandMacro returns the command template with each parameter replaced by
+// its value from params, quoted, or the message refusing to when a value is
+// missing, not a parameter, or cannot be quoted.
+func expandMacro(ctx *server.Context, name, template string, params map[string]string, posix bool) (string, string) {
+	names := macroParams(template)
+	known := make(map[string]bool, len(names))
+	var missing []string
+	for _, param := range names {
+		known[param] = true
+		if _, ok := params[param]; !ok {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) > 0 {
+		return "", i18n.T(ctx, i18n.MacroMissingParams, name, strings.Join(missing, ", "))
+	}
+	var unknown []string
+	for param := range params {
+		if !known[param] {
+			unknown = append(unknown, param)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", i18n.T(ctx, i18n.MacroUnknownParams, name, strings.Join(unknown, ", "), macroSignature(name, template))
+	}
+
+	quoted := make(map[string]string, len(params))
+	for param, value := range params {
+		q, ok := quoteArg(value, posix)
+		if !ok {
+			return "", i18n.T(ctx, i18n.MacroUnsafeValue, param, name, value)
+		}
+		quoted[param] = q
+	}
+	return macroParam.ReplaceAllStringFunc(template, func(m string) string {
+		return quoted[macroParam.FindStringSubmatch(m)[1]]
+	}), ""
+}
+
+// macroSignature returns the macro's name with its parameters, e.g.
+// test(pkg, name).
+func macroSignature(name, template string) string {
+	params := macroParams(template)
+	if len(params) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(params, ", "))
+}
+
+// commandShell returns the shell execute_command runs a command with: the one
+// the call names, or else the container's, when commands run in one, or the
+// best one on the host, PowerShell on Windows with usePowerShell.
+func commandShell(cfg *config.ServerConfig, shell *string, usePowerShell *bool) string {
+	if shell != nil && *shell != "" {
+		return *shell
+	}
+	if cfg.Container == nil {
+		return detectBestShell(usePowerShell != nil && *usePowerShell)
+	}
+	if cfg.Container.Shell != "" {
+		return cfg.Container.Shell
+	}
+	return defaultContainerShell
+}
+
+// HandleRunMacro implements the run_macro tool: it runs a command template
+// from the configuration as execute_command would run the expanded command.
+func HandleRunMacro(ctx *server.Context, args RunMacroArgs) (string, error) {
+	ctx.Logger.Info("Handling run_macro tool call", "macro", args.Name)
+
+	cfg, err := config.GetCurrentConfig(ctx)
+	if err != nil {
+		ctx.Logger.Info("Error loading config for run_macro", "error", err)
+		return "Error loading configuration", err
+	}
+	template, ok := cfg.Macros[args.Name]
+	if !ok {
+		available := make([]string, 0, len(cfg.Macros))
+		for name, template := range cfg.Macros {
+			available = append(available, macroSignature(name, template))
+		}
+		sort.Strings(available)
+		if len(available) == 0 {
+			available = append(available, "-")
+		}
+		return i18n.T(ctx, i18n.MacroNotFound, args.Name, strings.Join(available, ", ")), nil
+	}
+
+	// The command runs in the shell execute_command would pick, which
+	// decides how values are quoted
+	command, msg := expandMacro(ctx, args.Name, template, args.Params, getShellExecuteFlag(commandShell(cfg, nil, nil)) == "-c")
+	if msg != "" {
+		return msg, nil
+	}
+
+	ctx.Logger.Info("Running macro", "macro", args.Name, "command", command)
+	result, err := HandleExecuteCommand(ctx, ExecuteCommandArgs{Command: command, Label: args.Label, Cwd: args.Cwd})
+	if err != nil {
+		return result, err
+	}
+	return i18n.T(ctx, i18n.MacroRunning, args.Name, command) + "\n" + result, nil
+}
@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExpandMacro(t *testing.T) {
+	template := "go test ./{{pkg}}/... -run {{ name }} -count={{count}} {{pkg}}"
+	if got := macroSignature("test", template); got != "test(pkg, name, count)" {
+		t.Errorf("macroSignature = %q", got)
+	}
+
+	command, msg := expandMacro(testContext(), "test", template, map[string]string{"pkg": "tools/edit", "name": "TestPatch|TestDiff", "count": "1"}, true)
+	if want := "go test ./tools/edit/... -run 'TestPatch|TestDiff' -count=1 tools/edit"; msg != "" || command != want {
+		t.Errorf("expandMacro = %q, %q; want %q", command, msg, want)
+	}
+
+	if _, msg := expandMacro(testContext(), "test", template, map[string]string{"pkg": "x"}, true); !strings.Contains(msg, "name, count") {
+		t.Errorf("missing parameters reported as %q", msg)
+	}
+	if _, msg := expandMacro(testContext(), "test", template, map[string]string{"pkg": "x", "name": "y", "count": "1", "race": "1"}, true); !strings.Contains(msg, "race") {
+		t.Errorf("unknown parameter reported as %q", msg)
+	}
+	if _, msg := expandMacro(testContext(), "test", template, map[string]string{"pkg": "x", "name": "a|b", "count": "1"}, false); msg == "" {
+		t.Error("a value that needs quoting was passed to a Windows shell")
+	}
+}
+
+func TestExpandMacroQuoting(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	// Whatever the value, the shell passes it on as it is
+	value := "it's; echo pwned $(id) `id` \"$HOME\" * \\ \n|"
+	command, msg := expandMacro(testContext(), "say", "printf %s {{msg}}", map[string]string{"msg": value}, true)
+	if msg != "" {
+		t.Fatalf("expandMacro refused: %s", msg)
+	}
+	out, err := exec.Command("/bin/sh", "-c", command).Output()
+	if err != nil || string(out) != value {
+		t.Errorf("%s printed %q, %v; want %q", command, out, err, value)
+	}
+}
@@ -1,34 +1,118 @@
 package terminal
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"sync"
 	"time"
 
+	"gocreate/tools/webhook"
+
 	"github.com/localrivet/gomcp/server"
 )
 
-// TerminalSession holds information about a running command process.
+// defaultSessionHistory is how many finished sessions are kept, with their
+// output, for read_output and get_session_result without sessionHistory.
+const defaultSessionHistory = 100
+
+// Session statuses reported by read_output and get_session_result.
+const (
+	StatusRunning = "running"
+	StatusExited  = "exited"
+	StatusLost    = "lost" // The server stopped while the command ran
+)
+
+// terminateGrace is how long TerminateSession lets a command's processes
+// exit after the interrupt before it kills those left.
+const terminateGrace = 5 * time.Second
+
+// shutdownTimeout bounds how long Shutdown waits for the sessions it
+// terminated to exit: past the grace period, they are killed.
+const shutdownTimeout = terminateGrace + time.Second
+
+// defaultStallTimeout is how long a running session may print nothing before
+// it is reported stalled, without stallTimeoutSec.
+const defaultStallTimeout = 5 * time.Minute
+
+// stallCheckInterval is how often sessions are checked for stalls when those
+// are terminated.
+var stallCheckInterval = time.Second
+
+// defaultPageSize is how much read_output reads at an offset without length.
+const defaultPageSize = 64 * 1024
+
+// Default bytes kept of each stream of a session's output, the oldest dropped
+// beyond them: in memory, and in the files of the store.
+const (
+	defaultBufferLimit = 1 << 20
+	defaultFileLimit   = 100 << 20
+)
+
+// TerminalSession holds information about a command process, running or finished.
 type TerminalSession struct {
 	PID       int
 	Cmd       *exec.Cmd
+	Label     string // The name it was started with, if any
+	Command   string // The command string as given to the shell
+	Shell     string // The shell running it
+	Cwd       string // The directory it runs in, on the host
+	Image     string // The image of the container it runs in, if it does
+	RequestID string // ID of the tool call that started it
 	StartTime time.Time
-	Stdout    bytes.Buffer // Buffer to capture stdout
-	Stderr    bytes.Buffer // Buffer to capture stderr
-	Done      chan error   // Channel to signal completion
-	// TODO: Consider adding command string, shell used, etc. if needed for list_sessions
+	Stdin     io.WriteCloser // Pipe to the command's stdin, for send_input
+	Stdout    outputBuffer   // Buffer to capture stdout
+	Stderr    outputBuffer   // Buffer to capture stderr
+	Done      chan error     // Channel to signal completion
+	PTY       *os.File       // The pseudoterminal the command runs on, if it does
+
+	// Set when the command has exited
+	Exited   bool
+	EndTime  time.Time
+	ExitCode int
+	Err      string // Why it failed, if it did
+	Lost     bool   // The server stopped while it ran, so how it exited is not known
+
+	record      string        // Base path of the session's files in the store, if it has them
+	container   *Container    // The container it runs in, if it does
+	name        string        // The name of that container
+	group       processGroup  // The command and the processes it started
+	terminating bool          // Set by TerminateSession
+	stalled     time.Duration // How long it had printed nothing when it was terminated for it
+	detached    bool          // Left running by Shutdown
 }
 
 // TerminalManager manages active terminal sessions.
 type TerminalManager struct {
-	mu       sync.Mutex // Mutex to protect concurrent access to sessions map
-	sessions map[int]*TerminalSession
-	// TODO: Consider adding completed sessions tracking if needed
+	mu        sync.Mutex // Mutex to protect concurrent access to sessions map
+	sessions  map[int]*TerminalSession
+	completed map[int]*TerminalSession // Finished sessions, until history newer ones finish
+	finished  []int                    // PIDs of completed sessions, oldest first
+	history   int                      // How many completed sessions are kept
+	store     *sessionStore            // Where output is spilled; nil keeps it in memory
+	buffer    int64                    // Bytes of each stream new sessions keep in memory
+	file      int64                    // Bytes of each stream new sessions keep in the store
+	stall     time.Duration            // Silence after which a running session is stalled; 0 never
+	terminate bool                     // Terminate stalled sessions
+	watching  bool                     // Set once watchStalled runs
+	limit     int                      // Most sessions running at once; 0 for no limit
+	starting  int                      // Starts let through the limit that are not yet sessions
+	queue     []chan struct{}          // Starts waiting for a session to finish, first in line first
+	moved     chan struct{}            // Closed, and replaced, whenever the queue moves up
+	labels    map[string]bool          // Labels of starts that are not yet sessions
 }
 
+// errSessionLimit is returned by StartCommand when as many sessions as the
+// limit allows are running and the command is not queued.
+var errSessionLimit = errors.New("the session limit is reached")
+
+// errNoGroup is returned when ending a session whose command is in no process
+// group, or job, that could be signalled.
+var errNoGroup = errors.New("the command is not in a process group")
+
 // Global instance of the TerminalManager
 var globalTerminalManager *TerminalManager
 var once sync.Once
@@ -36,14 +120,223 @@ var once sync.Once
 // GetManager returns the singleton instance of the TerminalManager.
 func GetManager() *TerminalManager {
 	once.Do(func() {
-		globalTerminalManager = &TerminalManager{
-			sessions: make(map[int]*TerminalSession),
-		}
+		globalTerminalManager = newManager()
 		// TODO: Add any background cleanup routines if needed (e.g., for old completed sessions)
 	})
 	return globalTerminalManager
 }
 
+func newManager() *TerminalManager {
+	return &TerminalManager{
+		sessions:  make(map[int]*TerminalSession),
+		completed: make(map[int]*TerminalSession),
+		history:   defaultSessionHistory,
+		buffer:    defaultBufferLimit,
+		file:      defaultFileLimit,
+		stall:     defaultStallTimeout,
+		moved:     make(chan struct{}),
+		labels:    make(map[string]bool),
+	}
+}
+
+// SetSessionLimit sets how many sessions may run at once, 0 for any number.
+// Sessions already running beyond it are not ended, but keep others waiting.
+func (tm *TerminalManager) SetSessionLimit(n int) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.limit = max(n, 0)
+	tm.next()
+}
+
+// full reports whether as many sessions as the limit allows are running or
+// being started; tm.mu must be held.
+func (tm *TerminalManager) full() bool {
+	return tm.limit > 0 && len(tm.sessions)+tm.starting >= tm.limit
+}
+
+// next lets the starts first in line through while the limit allows; tm.mu
+// must be held.
+func (tm *TerminalManager) next() {
+	if len(tm.queue) == 0 || tm.full() {
+		return
+	}
+	for len(tm.queue) > 0 && !tm.full() {
+		tm.starting++
+		close(tm.queue[0])
+		tm.queue = tm.queue[1:]
+	}
+	close(tm.moved)
+	tm.moved = make(chan struct{})
+}
+
+// admit lets a start through the session limit. When the limit is reached it
+// fails with errSessionLimit, or, given opts.Queue, waits in line until a
+// session finishes or opts.Queue is done, calling opts.Position with its
+// place in line whenever that changes. Once admitted, the start counts as a
+// session until done is called.
+func (tm *TerminalManager) admit(opts StartOptions) (done func(), err error) {
+	done = func() {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		tm.starting--
+		tm.next()
+	}
+	tm.mu.Lock()
+	if len(tm.queue) == 0 && !tm.full() {
+		tm.starting++
+		tm.mu.Unlock()
+		return done, nil
+	}
+	if opts.Queue == nil {
+		running := len(tm.sessions) + tm.starting
+		tm.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d sessions are running", errSessionLimit, running)
+	}
+	turn := make(chan struct{})
+	tm.queue = append(tm.queue, turn)
+	tm.mu.Unlock()
+
+	for {
+		tm.mu.Lock()
+		position, moved := 0, tm.moved
+		for i, waiting := range tm.queue {
+			if waiting == turn {
+				position = i + 1
+			}
+		}
+		tm.mu.Unlock()
+		if position > 0 && opts.Position != nil {
+			opts.Position(position)
+		}
+
+		select {
+		case <-turn:
+			return done, nil
+		case <-moved:
+		case <-opts.Queue.Done():
+			tm.mu.Lock()
+			defer tm.mu.Unlock()
+			for i, waiting := range tm.queue {
+				if waiting == turn {
+					tm.queue = append(tm.queue[:i], tm.queue[i+1:]...)
+					return nil, fmt.Errorf("%w: %w", errSessionLimit, opts.Queue.Err())
+				}
+			}
+			// It was let through as it gave up, so the next in line goes
+			tm.starting--
+			tm.next()
+			return nil, fmt.Errorf("%w: %w", errSessionLimit, opts.Queue.Err())
+		}
+	}
+}
+
+// SetStallPolicy sets how long a running session may print nothing before it
+// is reported stalled, 0 for never, and whether it is then terminated, which
+// ctx logs.
+func (tm *TerminalManager) SetStallPolicy(ctx *server.Context, timeout time.Duration, terminate bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.stall, tm.terminate = max(timeout, 0), terminate
+	if terminate && timeout > 0 && !tm.watching {
+		tm.watching = true
+		go tm.watchStalled(ctx)
+	}
+}
+
+// watchStalled terminates the running sessions that have stalled, while the
+// stall policy says to.
+func (tm *TerminalManager) watchStalled(ctx *server.Context) {
+	ticker := time.NewTicker(stallCheckInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		tm.mu.Lock()
+		var stalled []*TerminalSession
+		if tm.terminate && tm.stall > 0 {
+			for _, session := range tm.sessions {
+				if idle := session.idle(now); idle >= tm.stall && !session.terminating && !session.detached {
+					session.stalled = idle
+					stalled = append(stalled, session)
+				}
+			}
+		}
+		tm.mu.Unlock()
+		for _, session := range stalled {
+			ctx.Logger.Info("Terminating stalled session", "pid", session.PID, "idle", session.stalled)
+			if err := tm.TerminateSession(ctx, session.PID); err != nil {
+				ctx.Logger.Info("Error terminating stalled session", "pid", session.PID, "error", err)
+			}
+		}
+	}
+}
+
+// SetOutputLimits sets how many bytes of each stream of their output the
+// sessions started from now on keep, in memory and in the store; the oldest
+// output is dropped beyond them.
+func (tm *TerminalManager) SetOutputLimits(buffer, file int64) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.buffer, tm.file = max(buffer, 1), max(file, 1)
+}
+
+// SetHistory sets how many finished sessions are kept, dropping the oldest of
+// those beyond it.
+func (tm *TerminalManager) SetHistory(n int) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.history = max(n, 0)
+	tm.trimCompleted()
+}
+
+// SetStore makes sessions spill their output to files in dir, and adds the
+// finished sessions recorded there, by an earlier run of the server too, to
+// those kept. Sessions already started keep their output where it is.
+func (tm *TerminalManager) SetStore(dir string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.store != nil && tm.store.dir == dir {
+		return nil
+	}
+	store, err := newStore(dir)
+	if err != nil {
+		return err
+	}
+	loaded, err := store.load()
+	if err != nil {
+		return err
+	}
+	tm.store = store
+
+	// The sessions loaded finished before any of this run's
+	var older []int
+	for _, session := range loaded {
+		if _, exists := tm.session(session.PID); exists {
+			continue
+		}
+		tm.completed[session.PID] = session
+		older = append(older, session.PID)
+	}
+	tm.finished = append(older, tm.finished...)
+	tm.trimCompleted()
+	return nil
+}
+
+// trimCompleted drops the oldest completed sessions beyond tm.history, and
+// their files; tm.mu must be held.
+func (tm *TerminalManager) trimCompleted() {
+	for len(tm.finished) > tm.history {
+		tm.forget(tm.completed[tm.finished[0]])
+		delete(tm.completed, tm.finished[0])
+		tm.finished = tm.finished[1:]
+	}
+}
+
+// forget deletes the files of a session that is no longer kept; tm.mu must be held.
+func (tm *TerminalManager) forget(session *TerminalSession) {
+	if session.record != "" && tm.store != nil {
+		tm.store.remove(session.record)
+	}
+}
+
 // AddSession adds a new session to the manager.
 func (tm *TerminalManager) AddSession(pid int, session *TerminalSession) {
 	tm.mu.Lock()
@@ -51,95 +344,554 @@ func (tm *TerminalManager) AddSession(pid int, session *TerminalSession) {
 	tm.sessions[pid] = session
 }
 
-// GetSession retrieves a session by PID.
+// GetSession retrieves a session by PID, running or completed.
 func (tm *TerminalManager) GetSession(pid int) (*TerminalSession, bool) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
-	session, exists := tm.sessions[pid]
+	return tm.session(pid)
+}
+
+// session looks up a session by PID; tm.mu must be held.
+func (tm *TerminalManager) session(pid int) (*TerminalSession, bool) {
+	if session, exists := tm.sessions[pid]; exists {
+		return session, true
+	}
+	session, exists := tm.completed[pid]
 	return session, exists
 }
 
+// running returns the running session labelled label, if any; tm.mu must be held.
+func (tm *TerminalManager) running(label string) *TerminalSession {
+	for _, session := range tm.sessions {
+		if session.Label == label {
+			return session
+		}
+	}
+	return nil
+}
+
+// Lookup returns the PID of the session a tool call addresses by PID or by
+// label. A label names its running session or, when none is running, the
+// session with that label that finished last. Given both, they must agree.
+func (tm *TerminalManager) Lookup(pid int, label string) (int, error) {
+	if label == "" {
+		if pid == 0 {
+			return 0, fmt.Errorf("a session PID or label is required")
+		}
+		return pid, nil
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	session := tm.running(label)
+	for i := len(tm.finished) - 1; session == nil && i >= 0; i-- {
+		if done := tm.completed[tm.finished[i]]; done.Label == label {
+			session = done
+		}
+	}
+	if session == nil {
+		return 0, fmt.Errorf("session labelled %q not found", label)
+	}
+	if pid != 0 && pid != session.PID {
+		return 0, fmt.Errorf("session labelled %q has PID %d, not %d", label, session.PID, pid)
+	}
+	return session.PID, nil
+}
+
 // RemoveSession removes a session by PID.
 func (tm *TerminalManager) RemoveSession(pid int) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 	delete(tm.sessions, pid)
-	// TODO: Potentially add session to a completed list here
+}
+
+// completeSession records how a session's command exited and moves it to the
+// completed sessions, dropping the oldest of them beyond the history kept.
+func (tm *TerminalManager) completeSession(session *TerminalSession, err error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	session.Exited = true
+	session.EndTime = time.Now()
+	session.ExitCode = session.Cmd.ProcessState.ExitCode()
+	if err != nil {
+		session.Err = err.Error()
+	}
+	if session.stalled > 0 {
+		note := fmt.Sprintf(errStalled, session.stalled.Round(time.Second))
+		if session.Err != "" {
+			note = session.Err + "; " + note
+		}
+		session.Err = note
+	}
+	delete(tm.sessions, session.PID)
+	tm.next()
+	if session.terminating {
+		// Processes that outlived the command, such as background jobs of
+		// the shell, which ignore the interrupt, go with it
+		_ = session.group.kill()
+	}
+	session.group.release()
+	session.Stdout.close()
+	session.Stderr.close()
+	if session.record != "" && tm.store != nil {
+		// The output is there whether or not the record is
+		_ = tm.store.save(session)
+	}
+
+	// A PID the system reused replaces the older session's record
+	if prev, exists := tm.completed[session.PID]; exists {
+		tm.forget(prev)
+		for i, pid := range tm.finished {
+			if pid == session.PID {
+				tm.finished = append(tm.finished[:i], tm.finished[i+1:]...)
+				break
+			}
+		}
+	}
+	tm.completed[session.PID] = session
+	tm.finished = append(tm.finished, session.PID)
+	tm.trimCompleted()
+}
+
+// StartOptions are how StartCommand runs a command.
+type StartOptions struct {
+	Label      string     // a name for the session, unique among running ones
+	Dir        string     // the directory to run it in; empty for the server's
+	PTY        bool       // run it on a pseudoterminal instead of pipes
+	Cols, Rows uint16     // the size of the pseudoterminal; 0 for the default
+	Container  *Container // the container to run it in; nil runs it on the host
+
+	// Past the session limit, the start waits in line until Queue is done,
+	// calling Position with its place in line; without Queue it fails
+	Queue    context.Context
+	Position func(position int)
 }
 
 // StartCommand starts a command asynchronously and manages its session.
 // Returns PID and error (nil if start was successful).
-func (tm *TerminalManager) StartCommand(ctx *server.Context, commandStr string, shell string, executeFlag string) (int, error) {
-	cmd := exec.Command(shell, executeFlag, commandStr)
+func (tm *TerminalManager) StartCommand(ctx *server.Context, commandStr string, shell string, executeFlag string, opts StartOptions) (int, error) {
+	if opts.Label != "" {
+		// The label is held from the check until the session is added, so
+		// two starts with one label cannot both pass it
+		tm.mu.Lock()
+		running, starting := tm.running(opts.Label), tm.labels[opts.Label]
+		if running == nil && !starting {
+			tm.labels[opts.Label] = true
+		}
+		tm.mu.Unlock()
+		if running != nil {
+			return -1, fmt.Errorf("label %q is already used by running session PID %d", opts.Label, running.PID)
+		}
+		if starting {
+			return -1, fmt.Errorf("label %q is already used by a session that is starting", opts.Label)
+		}
+		defer func() {
+			tm.mu.Lock()
+			defer tm.mu.Unlock()
+			delete(tm.labels, opts.Label)
+		}()
+	}
+
+	admitted, err := tm.admit(opts)
+	if err != nil {
+		return -1, err
+	}
+	defer admitted()
+
+	cwd := opts.Dir
+	if cwd == "" {
+		// The command runs where the server does
+		cwd, _ = os.Getwd()
+	}
+	cmd := shellCommand(shell, executeFlag, commandStr)
+	var name string
+	if opts.Container != nil {
+		// The runtime's CLI runs the command in the container, where cwd is
+		// mapped to
+		name = containerName()
+		if cmd, err = opts.Container.command(name, cwd, opts.PTY, shell, executeFlag, commandStr); err != nil {
+			return -1, err
+		}
+	}
+	cmd.Dir = opts.Dir
+	prepareGroup(cmd, opts.PTY)
 
 	session := &TerminalSession{
 		Cmd:       cmd,
+		Label:     opts.Label,
+		Command:   commandStr,
+		Shell:     shell,
+		Cwd:       cwd,
+		RequestID: ctx.RequestID,
 		StartTime: time.Now(),
 		Done:      make(chan error, 1), // Buffered channel
+		container: opts.Container,
+		name:      name,
+	}
+	if opts.Container != nil {
+		session.Image = opts.Container.Image
 	}
 
-	// Assign buffers for stdout and stderr capture
-	cmd.Stdout = &session.Stdout
-	cmd.Stderr = &session.Stderr
+	// With a store the output goes to files from the start; without one, or
+	// when they cannot be created, it stays in memory
+	tm.mu.Lock()
+	store, bufferLimit, fileLimit := tm.store, tm.buffer, tm.file
+	tm.mu.Unlock()
+	session.Stdout.limit, session.Stderr.limit = bufferLimit, bufferLimit
+	if store != nil {
+		if err := store.create(session, fileLimit); err != nil {
+			ctx.Logger.Info("Keeping command output in memory", "error", err)
+		}
+	}
+	started := false
+	defer func() {
+		if !started && session.record != "" {
+			session.Stdout.close()
+			session.Stderr.close()
+			store.remove(session.record)
+		}
+	}()
 
-	// Start the command asynchronously
-	err := cmd.Start()
-	if err != nil {
-		return -1, err // Failed to start
+	// On a pseudoterminal the command's output, both streams, is read from
+	// the terminal and its input typed into it
+	var copied <-chan struct{}
+	if opts.PTY {
+		cols, rows := opts.Cols, opts.Rows
+		if cols == 0 {
+			cols = defaultCols
+		}
+		if rows == 0 {
+			rows = defaultRows
+		}
+		ptmx, done, err := startPTY(cmd, cols, rows, &session.Stdout)
+		if err != nil {
+			return -1, err // Failed to start
+		}
+		session.PTY, session.Stdin, copied = ptmx, ptyInput{ptmx}, done
+	} else {
+		// Assign buffers for stdout and stderr capture, and a pipe to write stdin
+		cmd.Stdout = &session.Stdout
+		cmd.Stderr = &session.Stderr
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return -1, err
+		}
+		session.Stdin = stdin
+
+		// Start the command asynchronously
+		if err := cmd.Start(); err != nil {
+			return -1, err // Failed to start
+		}
 	}
 
 	session.PID = cmd.Process.Pid
+	started = true
+	group, err := startGroup(cmd)
+	if err != nil {
+		// Terminating the session then ends what it can without the group
+		ctx.Logger.Info("Error creating process group", "pid", session.PID, "error", err)
+	}
+	session.group = group
+	if session.record != "" {
+		if err := store.save(session); err != nil {
+			ctx.Logger.Info("Error recording session", "pid", session.PID, "error", err)
+		}
+	}
 	tm.AddSession(session.PID, session)
 
-	ctx.Logger.Info("Started command", "pid", session.PID, "command", commandStr)
+	ctx.Logger.Info("Started command", "pid", session.PID, "command", commandStr, "pty", opts.PTY)
 
 	// Start a goroutine to wait for the command to finish
 	go func() {
 		err := cmd.Wait()
+		if session.PTY != nil {
+			closePTY(session.PTY, copied)
+		}
+		tm.mu.Lock()
+		terminating := session.terminating
+		tm.mu.Unlock()
+		if session.container != nil && terminating {
+			// Killing the CLI leaves the container running; one that exited
+			// by itself was removed with it
+			if err := session.container.remove(session.name); err != nil {
+				ctx.Logger.Info("Error removing container", "pid", session.PID, "container", session.name, "error", err)
+			}
+		}
+		// The exit is recorded before it is signalled, so that whoever
+		// waits on Done finds the session completed
+		tm.completeSession(session, err)
 		session.Done <- err // Send completion error (or nil) to the channel
 		close(session.Done) // Close channel to signal completion fully
 
 		ctx.Logger.Info("Command finished", "pid", session.PID, "error", err)
-
-		// Clean up the session from the active map
-		// TODO: Consider moving completed session info elsewhere before removing
-		tm.RemoveSession(session.PID)
+		finished := map[string]interface{}{
+			"pid":        session.PID,
+			"command":    commandStr,
+			"exitCode":   session.ExitCode,
+			"durationMs": session.EndTime.Sub(session.StartTime).Milliseconds(),
+		}
+		if err != nil {
+			finished["error"] = err.Error()
+		}
+		webhook.Notify(ctx, webhook.CommandFinished, finished)
 	}()
 
 	return session.PID, nil // Return PID and nil error indicating successful start
 }
 
-// ReadNewOutput retrieves any output captured since the last call for a given PID.
-// It clears the internal buffer after reading.
-func (tm *TerminalManager) ReadNewOutput(pid int) (string, error) {
+// SessionOutput is what read_output reports for a session: its status, how
+// it exited once it has, and the output captured since the last read.
+type SessionOutput struct {
+	PID        int         `json:"pid"`
+	Label      string      `json:"label,omitempty"`
+	Status     string      `json:"status"` // StatusRunning, StatusExited or StatusLost
+	ExitCode   *int        `json:"exitCode,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	DurationMs int64       `json:"durationMs"`
+	Output     string      `json:"output"`
+	Dropped    int64       `json:"dropped,omitempty"` // Bytes of output the buffer overwrote before they could be read
+	Page       *OutputPage `json:"page,omitempty"`    // Where Output is in its stream, when read at an offset
+	IdleMs     int64       `json:"idleMs,omitempty"`  // Time since the running command last printed anything
+	Stalled    bool        `json:"stalled,omitempty"` // It has printed nothing for longer than the stall timeout
+}
+
+// OutputPage locates output read at an offset in a session's stdout or stderr.
+type OutputPage struct {
+	Stream     string `json:"stream"`
+	Offset     int64  `json:"offset"`     // Past the offset asked for by what was dropped from there
+	NextOffset int64  `json:"nextOffset"` // The offset to read the next page from
+	Size       int64  `json:"size"`       // Bytes in the stream so far
+}
+
+// exit reports how a session has exited, in the terms of SessionOutput and
+// SessionResult, and when it ended; a running session ends now.
+func (session *TerminalSession) exit() (status string, exitCode *int, errText string, end time.Time) {
+	if !session.Exited {
+		return StatusRunning, nil, "", time.Now()
+	}
+	if session.Lost {
+		return StatusLost, nil, session.Err, session.EndTime
+	}
+	code := session.ExitCode
+	return StatusExited, &code, session.Err, session.EndTime
+}
+
+// idle returns how long the session's command has printed nothing, as of now.
+func (session *TerminalSession) idle(now time.Time) time.Duration {
+	last := session.StartTime
+	for _, buffer := range []*outputBuffer{&session.Stdout, &session.Stderr} {
+		if written := buffer.lastWrite(); written.After(last) {
+			last = written
+		}
+	}
+	return now.Sub(last)
+}
+
+// activity reports, for a running session, how long it has printed nothing as
+// of now, and whether that makes it stalled; tm.mu must be held.
+func (tm *TerminalManager) activity(session *TerminalSession, now time.Time) (int64, bool) {
+	if session.Exited {
+		return 0, false
+	}
+	idle := session.idle(now)
+	return idle.Milliseconds(), tm.stall > 0 && idle >= tm.stall
+}
+
+// ReadNewOutput retrieves any output captured since the last call for a given PID,
+// with the session's status. It clears the internal buffer after reading.
+func (tm *TerminalManager) ReadNewOutput(pid int) (SessionOutput, error) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	session, exists := tm.sessions[pid]
+	session, exists := tm.session(pid)
+	if !exists {
+		return SessionOutput{}, fmt.Errorf("session with PID %d not found", pid)
+	}
+
+	result := SessionOutput{PID: pid, Label: session.Label}
+	var end time.Time
+	result.Status, result.ExitCode, result.Error, end = session.exit()
+	result.DurationMs = end.Sub(session.StartTime).Milliseconds()
+	result.IdleMs, result.Stalled = tm.activity(session, end)
+	stdout, stdoutDropped, err := session.Stdout.take()
+	if err != nil {
+		return SessionOutput{}, fmt.Errorf("failed to read the output of PID %d: %w", pid, err)
+	}
+	stderr, stderrDropped, err := session.Stderr.take()
+	if err != nil {
+		return SessionOutput{}, fmt.Errorf("failed to read the output of PID %d: %w", pid, err)
+	}
+	result.Output = stdout + stderr
+	result.Dropped = stdoutDropped + stderrDropped
+	return result, nil
+}
+
+// ReadOutputAt reads at most length bytes of a session's stdout or stderr,
+// as stream says, from offset on, as read_output does to page through output.
+// What ReadNewOutput returns next is not changed.
+func (tm *TerminalManager) ReadOutputAt(pid int, stream string, offset, length int64) (SessionOutput, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	session, exists := tm.session(pid)
+	if !exists {
+		return SessionOutput{}, fmt.Errorf("session with PID %d not found", pid)
+	}
+	buffer := &session.Stdout
+	switch stream {
+	case "", "stdout":
+		stream = "stdout"
+	case "stderr":
+		buffer = &session.Stderr
+	default:
+		return SessionOutput{}, fmt.Errorf("unknown stream %q: use stdout or stderr", stream)
+	}
+	if offset < 0 {
+		return SessionOutput{}, fmt.Errorf("invalid offset %d", offset)
+	}
+	if length <= 0 {
+		length = defaultPageSize
+	}
+
+	result := SessionOutput{PID: pid, Label: session.Label}
+	var end time.Time
+	result.Status, result.ExitCode, result.Error, end = session.exit()
+	result.DurationMs = end.Sub(session.StartTime).Milliseconds()
+	result.IdleMs, result.Stalled = tm.activity(session, end)
+	out, dropped, size, err := buffer.page(offset, length)
+	if err != nil {
+		return SessionOutput{}, fmt.Errorf("failed to read the output of PID %d: %w", pid, err)
+	}
+	offset += dropped
+	result.Output = out
+	result.Dropped = dropped
+	result.Page = &OutputPage{Stream: stream, Offset: offset, NextOffset: offset + int64(len(out)), Size: size}
+	return result, nil
+}
+
+// SessionResult is what get_session_result reports for a session: what ran,
+// where and when, how it exited once it has, and all of its output.
+type SessionResult struct {
+	PID        int    `json:"pid"`
+	Label      string `json:"label,omitempty"`
+	Command    string `json:"command"`
+	Shell      string `json:"shell"`
+	Cwd        string `json:"cwd"`
+	Status     string `json:"status"` // StatusRunning, StatusExited or StatusLost
+	ExitCode   *int   `json:"exitCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	StartTime  string `json:"startTime"`
+	EndTime    string `json:"endTime,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`            // Empty on a pseudoterminal, whose errors are in Stdout
+	Dropped    int64  `json:"dropped,omitempty"` // Bytes of output dropped, the oldest first, when it outgrew the buffer
+}
+
+// Result returns everything recorded about a session, with all of its output
+// so far however much of it read_output has returned.
+func (tm *TerminalManager) Result(pid int) (SessionResult, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	session, exists := tm.session(pid)
 	if !exists {
-		// TODO: Check completed sessions here?
-		return "", fmt.Errorf("session with PID %d not found or already completed", pid)
+		return SessionResult{}, fmt.Errorf("session with PID %d not found", pid)
 	}
 
-	// Read directly from the session's buffers
-	// Note: This might not be perfectly synchronized if the process writes rapidly
-	// between reads, but it captures what's available.
-	// A more robust solution might involve dedicated goroutines reading streams.
+	result := SessionResult{
+		PID:       pid,
+		Label:     session.Label,
+		Command:   session.Command,
+		Shell:     session.Shell,
+		Cwd:       session.Cwd,
+		StartTime: session.StartTime.Format(time.RFC3339),
+	}
+	var end time.Time
+	result.Status, result.ExitCode, result.Error, end = session.exit()
+	if session.Exited {
+		result.EndTime = end.Format(time.RFC3339)
+	}
+	result.DurationMs = end.Sub(session.StartTime).Milliseconds()
+	var stdoutDropped, stderrDropped int64
+	var err error
+	if result.Stdout, stdoutDropped, err = session.Stdout.all(); err != nil {
+		return SessionResult{}, fmt.Errorf("failed to read the output of PID %d: %w", pid, err)
+	}
+	if result.Stderr, stderrDropped, err = session.Stderr.all(); err != nil {
+		return SessionResult{}, fmt.Errorf("failed to read the output of PID %d: %w", pid, err)
+	}
+	result.Dropped = stdoutDropped + stderrDropped
+	return result, nil
+}
 
-	stdoutBytes := session.Stdout.Bytes()
-	stderrBytes := session.Stderr.Bytes()
+// WaitForCompletion blocks until the session's command exits or ctx is done,
+// then reports the session as ReadNewOutput does: exited, or still running if
+// ctx ended the wait.
+func (tm *TerminalManager) WaitForCompletion(ctx context.Context, pid int) (SessionOutput, error) {
+	session, exists := tm.GetSession(pid)
+	if !exists {
+		return SessionOutput{}, fmt.Errorf("session with PID %d not found", pid)
+	}
+	select {
+	case <-session.Done:
+	case <-ctx.Done():
+	}
+	return tm.ReadNewOutput(pid)
+}
 
-	// Reset buffers after reading
-	session.Stdout.Reset()
-	session.Stderr.Reset()
+// SendInput writes input to the stdin of a running session's command, and
+// closes it afterwards if closeInput is set, so that the command reads the
+// end of its input.
+func (tm *TerminalManager) SendInput(pid int, input string, closeInput bool) error {
+	tm.mu.Lock()
+	session, exists := tm.session(pid)
+	exited := exists && session.Exited
+	tm.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("session with PID %d not found", pid)
+	}
+	if exited {
+		return fmt.Errorf("session with PID %d has already exited", pid)
+	}
 
-	output := string(stdoutBytes) + string(stderrBytes)
+	// A command that does not read its input blocks the write once the pipe
+	// is full, so the lock is not held
+	if input != "" {
+		if _, err := io.WriteString(session.Stdin, input); err != nil {
+			return fmt.Errorf("failed to write to the input of PID %d: %w", pid, err)
+		}
+	}
+	if closeInput {
+		if err := session.Stdin.Close(); err != nil {
+			return fmt.Errorf("failed to close the input of PID %d: %w", pid, err)
+		}
+	}
+	return nil
+}
 
-	return output, nil
+// Resize sets the size of a pseudoterminal session's terminal.
+func (tm *TerminalManager) Resize(pid int, cols, rows uint16) error {
+	tm.mu.Lock()
+	session, exists := tm.session(pid)
+	exited := exists && session.Exited
+	tm.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("session with PID %d not found", pid)
+	}
+	if exited {
+		return fmt.Errorf("session with PID %d has already exited", pid)
+	}
+	if session.PTY == nil {
+		return fmt.Errorf("session with PID %d: %w", pid, errNotPTY)
+	}
+	return resizePTY(session.PTY, cols, rows)
 }
 
-// TerminateSession attempts to terminate the process associated with the given PID.
-// It first tries SIGINT, then SIGKILL if necessary.
+// TerminateSession terminates the command of the session with the given PID
+// and the processes it started, which run in its process group. It sends
+// them SIGINT and, to those still running terminateGrace later or once the
+// command exited, SIGKILL. On Windows the processes are terminated at once.
 func (tm *TerminalManager) TerminateSession(ctx *server.Context, pid int) error {
 	tm.mu.Lock()
 	// Unlock happens within the function to allow process killing which might block briefly
@@ -147,25 +899,32 @@ func (tm *TerminalManager) TerminateSession(ctx *server.Context, pid int) error
 	session, exists := tm.sessions[pid]
 	if !exists {
 		tm.mu.Unlock()
-		// TODO: Check completed sessions? Maybe return a specific "already completed" error?
-		return fmt.Errorf("session with PID %d not found or already completed", pid)
+		if _, done := tm.GetSession(pid); done {
+			return fmt.Errorf("session with PID %d has already exited", pid)
+		}
+		return fmt.Errorf("session with PID %d not found", pid)
 	}
 
 	// Get the process
 	process := session.Cmd.Process
 	if process == nil {
 		// Should not happen if session exists, but check anyway
-		tm.mu.Unlock()
 		// Remove the session as it's invalid
 		delete(tm.sessions, pid)
+		tm.mu.Unlock()
 		return fmt.Errorf("process not found for session PID %d", pid)
 	}
+	session.terminating = true
 	tm.mu.Unlock() // Unlock before potentially blocking kill operations
 
-	ctx.Logger.Info("Attempting to terminate process", "pid", pid)
+	ctx.Logger.Info("Attempting to terminate process group", "pid", pid)
 
-	// Try SIGINT first (graceful shutdown) - platform dependent
-	err := process.Signal(os.Interrupt)
+	// Interrupt the whole group first, or the command alone if it has none
+	err := session.group.interrupt()
+	if err != nil {
+		ctx.Logger.Info("Failed to interrupt process group", "pid", pid, "error", err)
+		err = process.Signal(os.Interrupt)
+	}
 	if err != nil {
 		ctx.Logger.Info("Failed to send SIGINT", "pid", pid, "error", err)
 		// If SIGINT fails or isn't supported, try SIGKILL (forceful)
@@ -178,20 +937,86 @@ func (tm *TerminalManager) TerminateSession(ctx *server.Context, pid int) error
 		}
 	}
 
-	// SIGINT sent successfully - process should terminate gracefully
-	// The background goroutine in StartCommand will handle cleanup when cmd.Wait() returns
-	// Note: The session is removed from the map by the goroutine in StartCommand when cmd.Wait() returns.
-	// We don't remove it here directly.
+	// Processes that ignore the interrupt are killed after the grace period;
+	// once the command exits, completeSession kills those left in its group
+	go func() {
+		select {
+		case <-session.Done:
+		case <-time.After(terminateGrace):
+			tm.mu.Lock()
+			defer tm.mu.Unlock()
+			if !session.Exited {
+				ctx.Logger.Info("Killing process group after the grace period", "pid", pid)
+				if session.group.kill() != nil {
+					_ = process.Kill()
+				}
+			}
+		}
+	}()
+
+	// The session is removed from the map by the goroutine in StartCommand
+	// when cmd.Wait() returns, not here.
 	ctx.Logger.Info("Termination signal sent", "pid", pid)
 	return nil // Signal sent successfully (doesn't guarantee process exited immediately)
 }
 
+// Shutdown ends the running sessions as the server exits, returning their
+// PIDs. It terminates them and waits up to shutdownTimeout for them to exit,
+// so that the store records how they exited and has all of their output.
+// With detach, it leaves them running instead, and records that it did; what
+// they print from then on is not captured.
+func (tm *TerminalManager) Shutdown(ctx *server.Context, detach bool) []int {
+	tm.mu.Lock()
+	running := make([]*TerminalSession, 0, len(tm.sessions))
+	pids := make([]int, 0, len(tm.sessions))
+	for pid, session := range tm.sessions {
+		running = append(running, session)
+		pids = append(pids, pid)
+		if detach {
+			session.detached = true
+			if session.record != "" && tm.store != nil {
+				_ = tm.store.save(session)
+			}
+			ctx.Logger.Info("Leaving session running", "pid", pid, "command", session.Command)
+		}
+	}
+	tm.mu.Unlock()
+	if detach {
+		return pids
+	}
+
+	for _, session := range running {
+		if err := tm.TerminateSession(ctx, session.PID); err != nil {
+			ctx.Logger.Info("Error terminating session", "pid", session.PID, "error", err)
+		}
+	}
+	wait, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, session := range running {
+		select {
+		case <-session.Done:
+			ctx.Logger.Info("Terminated session", "pid", session.PID, "command", session.Command)
+		case <-wait.Done():
+			ctx.Logger.Info("Session did not exit in time", "pid", session.PID, "command", session.Command)
+		}
+	}
+	return pids
+}
+
 // ActiveSessionInfo provides basic info about a running session.
 type ActiveSessionInfo struct {
 	PID       int    `json:"pid"`
+	Label     string `json:"label,omitempty"`
+	Command   string `json:"command"`
+	Shell     string `json:"shell"`
+	Cwd       string `json:"cwd"`
+	Image     string `json:"image,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	PTY       bool   `json:"pty,omitempty"`
 	StartTime string `json:"startTime"`
 	RuntimeMs int64  `json:"runtimeMs"`
-	// TODO: Add Command string if stored in TerminalSession
+	IdleMs    int64  `json:"idleMs"`            // Time since the command last printed anything
+	Stalled   bool   `json:"stalled,omitempty"` // It has printed nothing for longer than the stall timeout
 }
 
 // ListActiveSessions returns information about currently running sessions.
@@ -203,10 +1028,20 @@ func (tm *TerminalManager) ListActiveSessions() []ActiveSessionInfo {
 	active := make([]ActiveSessionInfo, 0, len(tm.sessions))
 
 	for pid, session := range tm.sessions {
+		idle, stalled := tm.activity(session, now)
 		active = append(active, ActiveSessionInfo{
 			PID:       pid,
+			Label:     session.Label,
+			Command:   session.Command,
+			Shell:     session.Shell,
+			Cwd:       session.Cwd,
+			Image:     session.Image,
+			RequestID: session.RequestID,
+			PTY:       session.PTY != nil,
 			StartTime: session.StartTime.Format(time.RFC3339),
 			RuntimeMs: now.Sub(session.StartTime).Milliseconds(),
+			IdleMs:    idle,
+			Stalled:   stalled,
 		})
 	}
 	return active
@@ -0,0 +1,446 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func testContext() *server.Context {
+	return &server.Context{
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		RequestID: "7",
+	}
+}
+
+func TestListActiveSessions(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	tm := newManager()
+	pid, err := tm.StartCommand(testContext(), "sleep 5", "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ := tm.GetSession(pid)
+	defer session.Cmd.Process.Kill()
+
+	cwd, _ := os.Getwd()
+	sessions := tm.ListActiveSessions()
+	if len(sessions) != 1 {
+		t.Fatalf("ListActiveSessions = %+v, want one session", sessions)
+	}
+	got := sessions[0]
+	if got.PID != pid || got.Command != "sleep 5" || got.Shell != "/bin/sh" || got.Cwd != cwd || got.RequestID != "7" {
+		t.Errorf("ListActiveSessions = %+v, want the command, shell, cwd and request ID", got)
+	}
+}
+
+func TestReadNewOutputExited(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	tm := newManager()
+	pid, err := tm.StartCommand(testContext(), "echo out; echo err >&2; exit 3", "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ := tm.GetSession(pid)
+	<-session.Done
+
+	output, err := tm.ReadNewOutput(pid)
+	if err != nil {
+		t.Fatalf("ReadNewOutput failed: %v", err)
+	}
+	if output.Status != StatusExited || output.ExitCode == nil || *output.ExitCode != 3 || output.Output != "out\nerr\n" || output.Error == "" {
+		t.Errorf("ReadNewOutput = %+v, want exit code 3 and the output", output)
+	}
+	if sessions := tm.ListActiveSessions(); len(sessions) != 0 {
+		t.Errorf("ListActiveSessions = %+v, want the exited session left out", sessions)
+	}
+
+	// The output is read once; the status stays
+	output, err = tm.ReadNewOutput(pid)
+	if err != nil || output.Output != "" || output.Status != StatusExited {
+		t.Errorf("second ReadNewOutput = %+v, %v", output, err)
+	}
+	if _, err := tm.ReadNewOutput(-1); err == nil {
+		t.Error("ReadNewOutput of an unknown PID succeeded")
+	}
+}
+
+func TestCompletedSessionsBounded(t *testing.T) {
+	tm := newManager()
+	for pid := 1; pid <= defaultSessionHistory+5; pid++ {
+		session := &TerminalSession{PID: pid, Cmd: &exec.Cmd{ProcessState: &os.ProcessState{}}}
+		tm.AddSession(pid, session)
+		tm.completeSession(session, nil)
+	}
+	if len(tm.completed) != defaultSessionHistory || len(tm.sessions) != 0 {
+		t.Errorf("%d completed and %d running sessions, want %d and none", len(tm.completed), len(tm.sessions), defaultSessionHistory)
+	}
+	if _, ok := tm.GetSession(1); ok {
+		t.Error("the oldest completed session was kept")
+	}
+	if _, ok := tm.GetSession(defaultSessionHistory + 5); !ok {
+		t.Error("the newest completed session was dropped")
+	}
+}
+
+func TestSetHistory(t *testing.T) {
+	tm := newManager()
+	for pid := 1; pid <= 5; pid++ {
+		session := &TerminalSession{PID: pid, Cmd: &exec.Cmd{ProcessState: &os.ProcessState{}}}
+		tm.AddSession(pid, session)
+		tm.completeSession(session, nil)
+	}
+	tm.SetHistory(2)
+	if _, ok := tm.GetSession(3); ok || len(tm.completed) != 2 {
+		t.Errorf("%d completed sessions kept, want the newest 2", len(tm.completed))
+	}
+	tm.SetHistory(0)
+	if len(tm.completed) != 0 || len(tm.finished) != 0 {
+		t.Errorf("%d completed sessions kept, want none", len(tm.completed))
+	}
+}
+
+func TestResult(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	tm := newManager()
+	pid, err := tm.StartCommand(testContext(), "echo out; echo err >&2; exit 2", "/bin/sh", "-c", StartOptions{Label: "build"})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	if _, err := tm.WaitForCompletion(context.Background(), pid); err != nil {
+		t.Fatalf("WaitForCompletion failed: %v", err)
+	}
+
+	// The output read_output has returned is still in the result
+	result, err := tm.Result(pid)
+	if err != nil {
+		t.Fatalf("Result failed: %v", err)
+	}
+	if result.Status != StatusExited || result.ExitCode == nil || *result.ExitCode != 2 || result.Label != "build" {
+		t.Errorf("Result = %+v, want the session exited with code 2", result)
+	}
+	if result.Stdout != "out\n" || result.Stderr != "err\n" || result.EndTime == "" {
+		t.Errorf("Result = %+v, want its output and end time", result)
+	}
+	if _, err := tm.Result(-1); err == nil {
+		t.Error("Result of an unknown PID succeeded")
+	}
+}
+
+func TestWaitForCompletion(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	tm := newManager()
+	pid, err := tm.StartCommand(testContext(), "sleep 0.2; echo done", "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	output, err := tm.WaitForCompletion(context.Background(), pid)
+	if err != nil || output.Status != StatusExited || *output.ExitCode != 0 || output.Output != "done\n" {
+		t.Errorf("WaitForCompletion = %+v, %v; want the command exited with its output", output, err)
+	}
+
+	// A wait that times out reports the command still running
+	pid, err = tm.StartCommand(testContext(), "sleep 5", "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ := tm.GetSession(pid)
+	defer session.Cmd.Process.Kill()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	output, err = tm.WaitForCompletion(ctx, pid)
+	if err != nil || output.Status != StatusRunning || output.ExitCode != nil {
+		t.Errorf("WaitForCompletion past its timeout = %+v, %v; want the command running", output, err)
+	}
+}
+
+func TestSendInput(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	tm := newManager()
+	pid, err := tm.StartCommand(testContext(), `read answer; echo "got $answer"; cat`, "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	if err := tm.SendInput(pid, "yes\n", false); err != nil {
+		t.Fatalf("SendInput failed: %v", err)
+	}
+	// cat echoes the rest of the input until it is closed
+	if err := tm.SendInput(pid, "more\n", true); err != nil {
+		t.Fatalf("SendInput with close failed: %v", err)
+	}
+	output, err := tm.WaitForCompletion(context.Background(), pid)
+	if err != nil || output.Status != StatusExited || output.Output != "got yes\nmore\n" {
+		t.Errorf("WaitForCompletion = %+v, %v; want the input echoed", output, err)
+	}
+
+	if err := tm.SendInput(pid, "late\n", false); err == nil {
+		t.Error("SendInput to an exited session succeeded")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	tm := newManager()
+	first, err := tm.StartCommand(testContext(), "true", "/bin/sh", "-c", StartOptions{Label: "build"})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	if _, err := tm.WaitForCompletion(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := tm.Lookup(0, "build"); err != nil || pid != first {
+		t.Errorf("Lookup of a finished session = %d, %v; want %d", pid, err, first)
+	}
+
+	// A running session takes the label over from the finished one
+	second, err := tm.StartCommand(testContext(), "sleep 5", "/bin/sh", "-c", StartOptions{Label: "build"})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ := tm.GetSession(second)
+	defer session.Cmd.Process.Kill()
+	if pid, err := tm.Lookup(0, "build"); err != nil || pid != second {
+		t.Errorf("Lookup = %d, %v; want the running session %d", pid, err, second)
+	}
+	if _, err := tm.StartCommand(testContext(), "true", "/bin/sh", "-c", StartOptions{Label: "build"}); err == nil {
+		t.Error("StartCommand reused the label of a running session")
+	}
+
+	if pid, err := tm.Lookup(second, "build"); err != nil || pid != second {
+		t.Errorf("Lookup by PID and label = %d, %v", pid, err)
+	}
+	if _, err := tm.Lookup(first, "build"); err == nil {
+		t.Error("Lookup with a PID the label does not name succeeded")
+	}
+	if _, err := tm.Lookup(0, "test"); err == nil {
+		t.Error("Lookup of an unknown label succeeded")
+	}
+	if _, err := tm.Lookup(0, ""); err == nil {
+		t.Error("Lookup without a PID or label succeeded")
+	}
+	if pid, err := tm.Lookup(42, ""); err != nil || pid != 42 {
+		t.Errorf("Lookup(42) = %d, %v", pid, err)
+	}
+}
+
+func TestLabelConcurrentStarts(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	tm := newManager()
+	tm.SetSessionLimit(1)
+	first, err := tm.StartCommand(testContext(), "exec sleep 30", "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+
+	// A start waiting in line holds its label, so another start with it
+	// fails at once rather than get through later beside it
+	queued := make(chan int, 1)
+	waiting := make(chan struct{}, 1)
+	go func() {
+		pid, err := tm.StartCommand(testContext(), "exec sleep 30", "/bin/sh", "-c", StartOptions{Label: "serve", Queue: context.Background(), Position: func(int) {
+			select {
+			case waiting <- struct{}{}:
+			default:
+			}
+		}})
+		if err != nil {
+			t.Errorf("queued StartCommand failed: %v", err)
+		}
+		queued <- pid
+	}()
+	select {
+	case <-waiting:
+	case <-time.After(time.Second):
+		t.Fatal("the labelled start did not queue")
+	}
+	expired, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := tm.StartCommand(testContext(), "exec sleep 30", "/bin/sh", "-c", StartOptions{Label: "serve", Queue: expired}); err == nil || errors.Is(err, errSessionLimit) || !strings.Contains(err.Error(), `label "serve"`) {
+		t.Errorf("second StartCommand with the label = %v; want the label refused", err)
+	}
+
+	session, _ := tm.GetSession(first)
+	session.Cmd.Process.Kill()
+	select {
+	case pid := <-queued:
+		if session, ok := tm.GetSession(pid); ok {
+			defer session.Cmd.Process.Kill()
+		}
+		if got, err := tm.Lookup(0, "serve"); err != nil || got != pid {
+			t.Errorf("Lookup = %d, %v; want %d", got, err, pid)
+		}
+	case <-time.After(terminateGrace + 2*time.Second):
+		t.Fatal("the queued start did not run once the session ended")
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	dir := t.TempDir()
+	for _, detach := range []bool{false, true} {
+		tm := newManager()
+		if err := tm.SetStore(dir); err != nil {
+			t.Fatalf("SetStore failed: %v", err)
+		}
+		pid, err := tm.StartCommand(testContext(), "exec sleep 30", "/bin/sh", "-c", StartOptions{})
+		if err != nil {
+			t.Fatalf("StartCommand failed: %v", err)
+		}
+		session, _ := tm.GetSession(pid)
+
+		if pids := tm.Shutdown(testContext(), detach); len(pids) != 1 || pids[0] != pid {
+			t.Errorf("detach %v: Shutdown = %v, want [%d]", detach, pids, pid)
+		}
+		if !detach {
+			if output, _ := tm.ReadNewOutput(pid); output.Status != StatusExited {
+				t.Errorf("Shutdown left the session %s", output.Status)
+			}
+			continue
+		}
+
+		// A detached session is still running, and a later run of the
+		// server reports that it was left so
+		if output, _ := tm.ReadNewOutput(pid); output.Status != StatusRunning {
+			t.Errorf("Shutdown with detach ended the session: %s", output.Status)
+		}
+		restarted := newManager()
+		if err := restarted.SetStore(dir); err != nil {
+			t.Fatalf("SetStore failed: %v", err)
+		}
+		if output, _ := restarted.ReadNewOutput(pid); output.Status != StatusLost || output.Error != errDetached {
+			t.Errorf("detached session after restart = %+v", output)
+		}
+		session.Cmd.Process.Kill()
+		<-session.Done
+	}
+}
+
+func TestStalledSession(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	interval := stallCheckInterval
+	stallCheckInterval = 20 * time.Millisecond
+	defer func() { stallCheckInterval = interval }()
+
+	tm := newManager()
+	tm.SetStallPolicy(testContext(), 200*time.Millisecond, false)
+	pid, err := tm.StartCommand(testContext(), "echo started; exec sleep 30", "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ := tm.GetSession(pid)
+	time.Sleep(300 * time.Millisecond)
+	output, err := tm.ReadNewOutput(pid)
+	if err != nil || output.Status != StatusRunning || !output.Stalled || output.IdleMs < 200 {
+		t.Errorf("ReadNewOutput = %+v, %v; want the session running and stalled", output, err)
+	}
+	if active := tm.ListActiveSessions(); len(active) != 1 || !active[0].Stalled {
+		t.Errorf("ListActiveSessions = %+v; want the session stalled", active)
+	}
+
+	// Once stalled sessions are terminated, the watchdog ends it
+	tm.SetStallPolicy(testContext(), 200*time.Millisecond, true)
+	select {
+	case <-session.Done:
+	case <-time.After(terminateGrace + 2*time.Second):
+		session.Cmd.Process.Kill()
+		t.Fatal("the stalled session was not terminated")
+	}
+	if output, _ := tm.ReadNewOutput(pid); output.Stalled || !strings.Contains(output.Error, "printing nothing") {
+		t.Errorf("ReadNewOutput after the stall = %+v", output)
+	}
+}
+
+func TestSessionLimit(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	tm := newManager()
+	tm.SetSessionLimit(1)
+	first, err := tm.StartCommand(testContext(), "exec sleep 30", "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	if _, err := tm.StartCommand(testContext(), "echo over", "/bin/sh", "-c", StartOptions{}); !errors.Is(err, errSessionLimit) {
+		t.Fatalf("StartCommand past the limit = %v; want errSessionLimit", err)
+	}
+
+	// A queued start that gives up leaves the line
+	expired, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := tm.StartCommand(testContext(), "echo late", "/bin/sh", "-c", StartOptions{Queue: expired}); !errors.Is(err, errSessionLimit) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("StartCommand that timed out in the queue = %v", err)
+	}
+
+	// Queued starts run in order as sessions finish
+	started := make(chan int, 2)
+	positions := make(chan int, 10)
+	for i, command := range []string{"echo second", "echo third"} {
+		go func() {
+			pid, err := tm.StartCommand(testContext(), command, "/bin/sh", "-c", StartOptions{Queue: context.Background(), Position: func(p int) {
+				if i == 1 {
+					positions <- p
+				}
+			}})
+			if err != nil {
+				t.Errorf("queued StartCommand failed: %v", err)
+			}
+			started <- pid
+		}()
+		// The first goroutine queues before the second
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			tm.mu.Lock()
+			n := len(tm.queue)
+			tm.mu.Unlock()
+			if n == i+1 {
+				break
+			}
+		}
+	}
+	select {
+	case pid := <-started:
+		t.Fatalf("PID %d started while the limit was reached", pid)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	session, _ := tm.GetSession(first)
+	session.Cmd.Process.Kill()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the queued commands did not start")
+		}
+	}
+	if p := <-positions; p != 2 {
+		t.Errorf("the third command was first at position %d, want 2", p)
+	}
+	if p := <-positions; p != 1 {
+		t.Errorf("the third command moved up to position %d, want 1", p)
+	}
+}
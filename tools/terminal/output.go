@@ -0,0 +1,192 @@
+package terminal
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// outputBuffer collects what a command writes to stdout or stderr, in memory
+// or, when the session has a file for it in the store, in that file. It is a
+// ring buffer: once limit bytes are kept, each write overwrites the oldest,
+// so a command printing without end cannot exhaust memory or disk. It may be
+// written by the command while it is read, so its methods lock.
+//
+// Output is addressed by its offset in all the command wrote, of which the
+// buffer keeps the last limit bytes at offset%limit.
+type outputBuffer struct {
+	mu    sync.Mutex
+	ring  []byte    // The output, when it is kept in memory; grows to limit
+	path  string    // The file the output is spilled to, if it is
+	file  *os.File  // path, open for writing while the command runs
+	limit int64     // Most bytes kept; 0 keeps everything
+	size  int64     // Bytes written, kept or not
+	read  int64     // Bytes already taken
+	last  time.Time // When the command last wrote
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.path != "" && b.file == nil {
+		// The file was closed: a process the command left behind is still
+		// writing after it exited
+		return len(p), nil
+	}
+	n := len(p)
+	if n > 0 {
+		b.last = time.Now()
+	}
+	if b.limit > 0 && int64(len(p)) > b.limit {
+		// Only the end of p is kept
+		b.size += int64(len(p)) - b.limit
+		p = p[int64(len(p))-b.limit:]
+	}
+	for len(p) > 0 {
+		pos, chunk := b.locate(b.size, int64(len(p)))
+		if err := b.writeAt(p[:chunk], pos); err != nil {
+			return n - len(p), err
+		}
+		b.size += chunk
+		p = p[chunk:]
+	}
+	return n, nil
+}
+
+// locate returns where the output at offset is kept, and how many of the n
+// bytes from there on follow it before the ring wraps around.
+func (b *outputBuffer) locate(offset, n int64) (int64, int64) {
+	if b.limit <= 0 {
+		return offset, n
+	}
+	pos := offset % b.limit
+	return pos, min(n, b.limit-pos)
+}
+
+// writeAt stores p at pos, which is in the ring or just past its end while
+// the ring grows; b.mu must be held.
+func (b *outputBuffer) writeAt(p []byte, pos int64) error {
+	if b.file != nil {
+		_, err := b.file.WriteAt(p, pos)
+		return err
+	}
+	if pos == int64(len(b.ring)) {
+		b.ring = append(b.ring, p...)
+	} else {
+		copy(b.ring[pos:], p)
+	}
+	return nil
+}
+
+// spill makes the buffer write to a new file at path, keeping limit bytes.
+func (b *outputBuffer) spill(path string, limit int64) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.path, b.file, b.limit = path, f, limit
+	return nil
+}
+
+// close closes the file the output is spilled to, once the command exited.
+// Its output can still be read.
+func (b *outputBuffer) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.file != nil {
+		b.file.Close()
+		b.file = nil
+	}
+}
+
+// written returns how many bytes the command wrote, kept or not.
+func (b *outputBuffer) written() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// lastWrite returns when the command last wrote, or the zero time if it has
+// not.
+func (b *outputBuffer) lastWrite() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last
+}
+
+// readAt returns at most n bytes of the output from offset on, and how many
+// bytes from offset were dropped before the oldest kept, from which it reads
+// instead; b.mu must be held.
+func (b *outputBuffer) readAt(offset, n int64) (string, int64, error) {
+	var dropped int64
+	if b.limit > 0 && offset < b.size-b.limit {
+		dropped = b.size - b.limit - offset
+		offset += dropped
+	}
+	if offset >= b.size || n <= 0 {
+		return "", dropped, nil
+	}
+	n = min(n, b.size-offset)
+
+	f := b.file
+	if b.path != "" && f == nil {
+		var err error
+		if f, err = os.Open(b.path); err != nil {
+			return "", dropped, err
+		}
+		defer f.Close()
+	}
+	out := make([]byte, 0, n)
+	for n > 0 {
+		pos, chunk := b.locate(offset, n)
+		if b.path == "" {
+			out = append(out, b.ring[pos:pos+chunk]...)
+		} else {
+			p := make([]byte, chunk)
+			read, err := f.ReadAt(p, pos)
+			out = append(out, p[:read]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", dropped, err
+			}
+		}
+		offset += chunk
+		n -= chunk
+	}
+	return string(out), dropped, nil
+}
+
+// take returns the output collected since the last call, and how much of it
+// was dropped before it could be.
+func (b *outputBuffer) take() (string, int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out, dropped, err := b.readAt(b.read, b.size-b.read)
+	if err != nil {
+		return "", 0, err
+	}
+	b.read = b.size
+	return out, dropped, nil
+}
+
+// page returns at most n bytes of the output from offset on, whether taken or
+// not, how many bytes from offset were dropped, and the size of all of it.
+func (b *outputBuffer) page(offset, n int64) (string, int64, int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out, dropped, err := b.readAt(offset, n)
+	return out, dropped, b.size, err
+}
+
+// all returns all the output kept, whether taken or not, and how much was
+// dropped before it.
+func (b *outputBuffer) all() (string, int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.readAt(0, b.size)
+}
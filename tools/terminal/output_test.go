@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputBufferRing(t *testing.T) {
+	for _, inFile := range []bool{false, true} {
+		var b outputBuffer
+		b.limit = 8
+		if inFile {
+			if err := b.spill(filepath.Join(t.TempDir(), "out"), 8); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		b.Write([]byte("abcde"))
+		if out, dropped, err := b.take(); out != "abcde" || dropped != 0 || err != nil {
+			t.Errorf("file %v: take = %q, %d, %v; want all of it", inFile, out, dropped, err)
+		}
+
+		// Writing past the limit overwrites the oldest, unread or not
+		b.Write([]byte("fghij"))
+		b.Write([]byte("klm"))
+		if out, dropped, err := b.take(); out != "fghijklm" || dropped != 0 || err != nil {
+			t.Errorf("file %v: take after wrapping = %q, %d, %v", inFile, out, dropped, err)
+		}
+		b.Write([]byte("0123456789"))
+		if out, dropped, err := b.take(); out != "23456789" || dropped != 2 || err != nil {
+			t.Errorf("file %v: take of a write over the limit = %q, %d, %v; want its end and 2 dropped", inFile, out, dropped, err)
+		}
+
+		// Pages start at the oldest output kept
+		out, dropped, size, err := b.page(3, 4)
+		if out != "2345" || dropped != 15-3 || size != 23 || err != nil {
+			t.Errorf("file %v: page = %q, %d, %d, %v", inFile, out, dropped, size, err)
+		}
+		b.close()
+		if out, dropped, err := b.all(); out != "23456789" || dropped != 15 || err != nil {
+			t.Errorf("file %v: all = %q, %d, %v", inFile, out, dropped, err)
+		}
+	}
+}
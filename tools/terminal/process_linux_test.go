@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// running reports whether the process pid exists and is not a zombie.
+func running(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	// The state follows the command name, which is in parentheses
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+func TestTerminateSessionKillsGroup(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	for _, pty := range []bool{false, true} {
+		tm := newManager()
+		// The background sleep ignores SIGINT, as a non-interactive shell's
+		// background jobs do, and outlives the shell
+		pid, err := tm.StartCommand(testContext(), "sleep 30 & echo $!; wait", "/bin/sh", "-c", StartOptions{PTY: pty})
+		if err != nil {
+			t.Fatalf("pty %v: StartCommand failed: %v", pty, err)
+		}
+		var child int
+		for deadline := time.Now().Add(5 * time.Second); child == 0 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			output, _ := tm.ReadOutputAt(pid, "", 0, 0)
+			child, _ = strconv.Atoi(strings.TrimSpace(output.Output))
+		}
+		if child == 0 {
+			t.Fatalf("pty %v: the command did not print the PID of its child", pty)
+		}
+
+		if err := tm.TerminateSession(testContext(), pid); err != nil {
+			t.Fatalf("pty %v: TerminateSession failed: %v", pty, err)
+		}
+		deadline := time.Now().Add(terminateGrace + 2*time.Second)
+		for running(child) && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if running(child) {
+			killProcess(child)
+			t.Errorf("pty %v: the child %d survived its session", pty, child)
+		}
+	}
+}
+
+func killProcess(pid int) {
+	if process, err := os.FindProcess(pid); err == nil {
+		process.Kill()
+	}
+}
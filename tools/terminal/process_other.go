@@ -0,0 +1,58 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// processGroup is the process group a session's command runs in, with the
+// processes it starts, so that terminating the session reaches them too.
+type processGroup struct {
+	pgid int
+}
+
+// shellCommand returns the command that runs commandStr with shell.
+func shellCommand(shell, executeFlag, commandStr string) *exec.Cmd {
+	return exec.Command(shell, executeFlag, commandStr)
+}
+
+// prepareGroup makes cmd start in a process group of its own. A command on a
+// pseudoterminal leads a new session, and so a new group, already.
+func prepareGroup(cmd *exec.Cmd, pty bool) {
+	if pty {
+		return
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// startGroup returns the group of a command started after prepareGroup, which
+// it leads.
+func startGroup(cmd *exec.Cmd) (processGroup, error) {
+	return processGroup{pgid: cmd.Process.Pid}, nil
+}
+
+// interrupt sends SIGINT to every process in the group.
+func (g processGroup) interrupt() error {
+	return g.signal(syscall.SIGINT)
+}
+
+// kill sends SIGKILL to every process left in the group.
+func (g processGroup) kill() error {
+	return g.signal(syscall.SIGKILL)
+}
+
+func (g processGroup) signal(sig syscall.Signal) error {
+	// A pgid of 0 would signal the server's own group
+	if g.pgid <= 0 {
+		return errNoGroup
+	}
+	return syscall.Kill(-g.pgid, sig)
+}
+
+// release frees what the group holds once its command has exited.
+func (g processGroup) release() {}
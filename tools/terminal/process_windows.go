@@ -0,0 +1,102 @@
+//go:build windows
+
+package terminal
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// processGroup is the job object a session's command runs in, with the
+// processes it starts, so that terminating the session reaches them too.
+// Without one, taskkill ends the command's process tree instead.
+type processGroup struct {
+	job windows.Handle
+	pid int
+}
+
+// shellCommand returns the command that runs commandStr with shell. cmd.exe
+// and PowerShell do not split their command lines by the C runtime's rules,
+// which exec quotes arguments for, so they get commandStr as they read it.
+func shellCommand(shell, executeFlag, commandStr string) *exec.Cmd {
+	cmd := exec.Command(shell)
+	switch executeFlag {
+	case "/C":
+		cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: cmdLine(shell, commandStr)}
+	case "-Command":
+		cmd.Args = append(cmd.Args, "-EncodedCommand", encodedCommand(commandStr))
+	default:
+		cmd.Args = append(cmd.Args, executeFlag, commandStr)
+	}
+	return cmd
+}
+
+// prepareGroup does nothing on Windows: the command is put in a job once it
+// has started.
+func prepareGroup(cmd *exec.Cmd, pty bool) {}
+
+// startGroup creates a job object and assigns the started command to it, so
+// that the processes it starts from then on belong to the job as well. The
+// group it returns with an error has no job.
+func startGroup(cmd *exec.Cmd) (processGroup, error) {
+	group := processGroup{pid: cmd.Process.Pid}
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return group, err
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return group, err
+	}
+	defer windows.CloseHandle(process)
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return group, err
+	}
+	group.job = job
+	return group, nil
+}
+
+// interrupt asks the command's process tree to close with taskkill, which is
+// as near to an interrupt as Windows comes: it cannot send Ctrl-C to console
+// processes it did not start together. Console programs only close when
+// forced, so when taskkill cannot close them, it kills the group.
+func (g processGroup) interrupt() error {
+	if err := taskkill(g.pid, false); err == nil {
+		return nil
+	}
+	return g.kill()
+}
+
+// kill terminates every process left in the job, or the command's process
+// tree without one.
+func (g processGroup) kill() error {
+	if g.job == 0 {
+		return taskkill(g.pid, true)
+	}
+	return windows.TerminateJobObject(g.job, 1)
+}
+
+// taskkill ends the process tree of pid, forcing it with force.
+func taskkill(pid int, force bool) error {
+	if pid <= 0 {
+		return errNoGroup
+	}
+	args := []string{"/T", "/PID", strconv.Itoa(pid)}
+	if force {
+		args = append(args, "/F")
+	}
+	return exec.Command("taskkill", args...).Run()
+}
+
+// release closes the job once its command has exited. The job does not kill
+// on close, so processes the command left running in the background go on.
+func (g processGroup) release() {
+	if g.job != 0 {
+		windows.CloseHandle(g.job)
+	}
+}
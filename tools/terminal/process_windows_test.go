@@ -0,0 +1,27 @@
+package terminal
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestShellCommandQuoting(t *testing.T) {
+	tests := []struct {
+		shell, command, want string
+	}{
+		{"cmd.exe", `echo "a  b" & echo c\"d`, "\"a  b\" \r\nc\\\"d"},
+		{"powershell.exe", `Write-Output 'it''s' "a  b\" $([char]0x41)`, "it's\r\na  b\\\r\nA"},
+	}
+	for _, tt := range tests {
+		tm := newManager()
+		pid, err := tm.StartCommand(testContext(), tt.command, tt.shell, getShellExecuteFlag(tt.shell), StartOptions{})
+		if err != nil {
+			t.Fatalf("%s: StartCommand failed: %v", tt.shell, err)
+		}
+		output, err := tm.WaitForCompletion(context.Background(), pid)
+		if err != nil || strings.TrimSpace(output.Output) != tt.want {
+			t.Errorf("%s: %s printed %q, %v; want %q", tt.shell, tt.command, output.Output, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package terminal
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// Size of a pseudoterminal when none is given, that of a plain terminal window.
+const (
+	defaultCols = 80
+	defaultRows = 24
+)
+
+// ptyDrainTimeout bounds how long the output of a command that exited is read
+// from its terminal, which a process it left running may still hold open.
+const ptyDrainTimeout = time.Second
+
+var errNotPTY = errors.New("not a pseudoterminal session")
+
+// ptyInput writes to a session's pseudoterminal. Closing it types end of input
+// (Ctrl-D) rather than closing the terminal, which would hang up on the command.
+type ptyInput struct {
+	f *os.File
+}
+
+func (in ptyInput) Write(p []byte) (int, error) {
+	return in.f.Write(p)
+}
+
+func (in ptyInput) Close() error {
+	_, err := in.f.Write([]byte{4})
+	return err
+}
+
+// startPTY starts cmd on a new pseudoterminal of cols by rows, as its
+// controlling terminal, and copies what it writes there to out. The channel
+// returned is closed when the copy ends, once every process has closed the
+// terminal or it is closed.
+func startPTY(cmd *exec.Cmd, cols, rows uint16, out io.Writer) (*os.File, <-chan struct{}, error) {
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: cols, Rows: rows})
+	if err != nil {
+		return nil, nil, err
+	}
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		// Reading fails with EIO once the terminal is closed on the other side
+		_, _ = io.Copy(out, ptmx)
+	}()
+	return ptmx, copied, nil
+}
+
+// closePTY closes a session's pseudoterminal once what the command wrote has
+// been read, or after ptyDrainTimeout.
+func closePTY(ptmx *os.File, copied <-chan struct{}) {
+	select {
+	case <-copied:
+	case <-time.After(ptyDrainTimeout):
+	}
+	ptmx.Close()
+}
+
+// resizePTY sets the size of a pseudoterminal, which signals the command
+// running on it with SIGWINCH.
+func resizePTY(ptmx *os.File, cols, rows uint16) error {
+	return pty.Setsize(ptmx, &pty.Winsize{Cols: cols, Rows: rows})
+}
@@ -0,0 +1,81 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startPTYCommand(t *testing.T, tm *TerminalManager, command string, opts StartOptions) int {
+	t.Helper()
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	opts.PTY = true
+	pid, err := tm.StartCommand(testContext(), command, "/bin/sh", "-c", opts)
+	if err != nil {
+		t.Skipf("pseudoterminals not available: %v", err)
+	}
+	return pid
+}
+
+func TestPTYSession(t *testing.T) {
+	tm := newManager()
+	pid := startPTYCommand(t, tm, `test -t 0 && test -t 1 && echo tty; stty size; read line; echo "got $line"; cat`, StartOptions{Cols: 100, Rows: 30})
+
+	if err := tm.SendInput(pid, "hello\n", false); err != nil {
+		t.Fatalf("SendInput failed: %v", err)
+	}
+	// Closing the input types Ctrl-D, the end of cat's input
+	if err := tm.SendInput(pid, "", true); err != nil {
+		t.Fatalf("SendInput with close failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := tm.WaitForCompletion(ctx, pid)
+	if err != nil || output.Status != StatusExited || *output.ExitCode != 0 {
+		t.Fatalf("WaitForCompletion = %+v, %v; want the command exited", output, err)
+	}
+	for _, want := range []string{"tty\r\n", "30 100\r\n", "got hello\r\n"} {
+		if !strings.Contains(output.Output, want) {
+			t.Errorf("output %q does not contain %q", output.Output, want)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	tm := newManager()
+	pid := startPTYCommand(t, tm, `read line; stty size`, StartOptions{})
+	if sessions := tm.ListActiveSessions(); len(sessions) != 1 || !sessions[0].PTY {
+		t.Errorf("ListActiveSessions = %+v, want a pty session", sessions)
+	}
+
+	if err := tm.Resize(pid, 120, 40); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	if err := tm.SendInput(pid, "\n", false); err != nil {
+		t.Fatalf("SendInput failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := tm.WaitForCompletion(ctx, pid)
+	if err != nil || !strings.Contains(output.Output, "40 120\r\n") {
+		t.Errorf("WaitForCompletion = %+v, %v; want the new size", output, err)
+	}
+	if err := tm.Resize(pid, 80, 24); err == nil {
+		t.Error("Resize of an exited session succeeded")
+	}
+
+	pid, err = tm.StartCommand(testContext(), "sleep 5", "/bin/sh", "-c", StartOptions{})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ := tm.GetSession(pid)
+	defer session.Cmd.Process.Kill()
+	if err := tm.Resize(pid, 80, 24); !errors.Is(err, errNotPTY) {
+		t.Errorf("Resize of a plain session = %v, want errNotPTY", err)
+	}
+}
@@ -0,0 +1,218 @@
+package terminal
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+	"mvdan.cc/sh/syntax"
+)
+
+// commandRuleViolation checks every command call within a potentially complex
+// shell string against the command rules, returning the first rule that
+// denies one and the call as it is written. The string is parsed by the
+// syntax of shell, and dir is the directory the command runs in, against
+// which relative paths are resolved.
+func commandRuleViolation(ctx *server.Context, commandStr, shell, dir string, rules []config.CommandRule) (*config.CommandRule, string, error) {
+	if len(rules) == 0 {
+		return nil, "", nil
+	}
+
+	file, err := parseCommand(commandStr, shell)
+	if err != nil {
+		ctx.Logger.Info("Error parsing command string for validation. Blocking execution.", "error", err)
+		return nil, "", err
+	}
+
+	pipedTo := pipeTargets(file)
+	var denied *config.CommandRule
+	var offence string
+	for _, call := range calls(file) {
+		if denied = callRule(call, dir, rules, pipedTo[call]); denied != nil {
+			offence = printNode(call)
+			break
+		}
+	}
+
+	if denied != nil {
+		ctx.Logger.Info("Command validation failed: Denied by a command rule", "rule", denied.Name, "command", offence, "commandStr", commandStr)
+	}
+	return denied, offence, nil
+}
+
+// pipeTargets returns the names of the commands each call within node pipes
+// its output into, however far down the pipeline.
+func pipeTargets(node syntax.Node) map[*syntax.CallExpr][]string {
+	pipedTo := make(map[*syntax.CallExpr][]string)
+	syntax.Walk(node, func(node syntax.Node) bool {
+		if bin, ok := node.(*syntax.BinaryCmd); ok && (bin.Op == syntax.Pipe || bin.Op == syntax.PipeAll) {
+			names := commandNames(bin.Y)
+			for _, call := range calls(bin.X) {
+				pipedTo[call] = append(pipedTo[call], names...)
+			}
+		}
+		return true
+	})
+	return pipedTo
+}
+
+// callRule returns the first of rules that denies call, run in dir with its
+// output piped into the commands named pipedTo, or nil.
+func callRule(call *syntax.CallExpr, dir string, rules []config.CommandRule, pipedTo []string) *config.CommandRule {
+	name := callName(call)
+	if name == "" {
+		return nil
+	}
+	for i := range rules {
+		if commandName(rules[i].Command) == name && ruleMatches(&rules[i], call, dir, pipedTo) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// ruleMatches reports whether all of the conditions rule sets hold for call,
+// whose output is piped into the commands named pipedTo.
+func ruleMatches(rule *config.CommandRule, call *syntax.CallExpr, dir string, pipedTo []string) bool {
+	args := call.Args[1:]
+	for _, pattern := range rule.Args {
+		matched := false
+		for _, arg := range args {
+			// An argument that is not a literal is matched as it is written
+			text := literalWord(arg)
+			if text == "" {
+				text = printNode(arg)
+			}
+			if ok, _ := path.Match(pattern, text); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(rule.PathsWithin) > 0 && !operandOutside(args, dir, rule.PathsWithin) {
+		return false
+	}
+
+	if len(rule.PipedTo) > 0 {
+		piped := false
+		for _, target := range rule.PipedTo {
+			for _, name := range pipedTo {
+				if commandName(target) == name {
+					piped = true
+				}
+			}
+		}
+		if !piped {
+			return false
+		}
+	}
+	return true
+}
+
+// operandOutside reports whether one of the operands among args, the
+// arguments that are not options, names a path outside all of dirs. An
+// operand that is not a literal cannot be resolved, so it counts as outside.
+func operandOutside(args []*syntax.Word, dir string, dirs []string) bool {
+	expand := strings.NewReplacer("{cwd}", dir, "{tmp}", os.TempDir())
+	options := true
+	for _, arg := range args {
+		text := literalWord(arg)
+		if options && text == "--" {
+			options = false
+			continue
+		}
+		if options && strings.HasPrefix(text, "-") {
+			continue
+		}
+		if text == "" {
+			return true
+		}
+		target := config.ResolvePath(resolveOperand(text, dir))
+		inside := false
+		for _, within := range dirs {
+			rel, err := filepath.Rel(config.ResolvePath(resolveOperand(expand.Replace(within), dir)), target)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveOperand returns the path p names for a command run in dir, expanding
+// a leading ~ as the shell would.
+func resolveOperand(p, dir string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, p[1:])
+		}
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return p
+}
+
+// calls returns the command calls within node.
+func calls(node syntax.Node) []*syntax.CallExpr {
+	var found []*syntax.CallExpr
+	syntax.Walk(node, func(node syntax.Node) bool {
+		if call, ok := node.(*syntax.CallExpr); ok && len(call.Args) > 0 {
+			found = append(found, call)
+		}
+		return true
+	})
+	return found
+}
+
+// commandNames returns the names of the command calls within node that are
+// written literally.
+func commandNames(node syntax.Node) []string {
+	var names []string
+	for _, call := range calls(node) {
+		if name := callName(call); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// callName returns the name of the command call runs, as rules match it, or ""
+// when it is not written literally.
+func callName(call *syntax.CallExpr) string {
+	if name := literalWord(call.Args[0]); name != "" {
+		return commandName(name)
+	}
+	return ""
+}
+
+// commandName returns name lowercased and without its directory or a Windows
+// executable's extension, so that /bin/rm, RM and C:\Tools\rm.exe are all rm.
+func commandName(name string) string {
+	name = strings.ToLower(name[strings.LastIndexAny(name, `/\`)+1:])
+	for _, ext := range []string{".exe", ".com", ".bat", ".cmd"} {
+		if base := strings.TrimSuffix(name, ext); base != "" {
+			name = base
+		}
+	}
+	return name
+}
+
+// printNode returns node as it is written in the command string.
+func printNode(node syntax.Node) string {
+	var sb strings.Builder
+	syntax.NewPrinter().Print(&sb, node)
+	return sb.String()
+}
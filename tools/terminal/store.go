@@ -0,0 +1,214 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Extensions of the files a session has in the store: its record, and the
+// output of each stream.
+const (
+	recordExt = ".json"
+	stdoutExt = ".stdout"
+	stderrExt = ".stderr"
+)
+
+// Errors of a session the server stopped, or crashed, while it ran, or left
+// running: how its command exited is not known and, if its output filled the
+// file, where the oldest of it is.
+const (
+	errServerStopped = "the server stopped before the command finished"
+	errDetached      = "the server left the command running when it stopped; its output since is not captured"
+	errOutputWrapped = "; its output filled the buffer, so it may be out of order"
+)
+
+// errStalled notes that a session was terminated for printing nothing for a
+// while.
+const errStalled = "terminated after printing nothing for %s"
+
+// sessionRecord is what the store keeps about a session besides its output,
+// so that it can be read after the server restarts.
+type sessionRecord struct {
+	PID       int       `json:"pid"`
+	Label     string    `json:"label,omitempty"`
+	Command   string    `json:"command"`
+	Shell     string    `json:"shell"`
+	Cwd       string    `json:"cwd"`
+	Image     string    `json:"image,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	Exited    bool      `json:"exited"`
+	EndTime   time.Time `json:"endTime"`
+	ExitCode  int       `json:"exitCode"`
+	Error     string    `json:"error,omitempty"`
+	Detached  bool      `json:"detached,omitempty"` // Left running when the server stopped
+
+	// Bytes kept of each stream, and written to them, kept or not
+	OutputLimit int64 `json:"outputLimit,omitempty"`
+	StdoutBytes int64 `json:"stdoutBytes"`
+	StderrBytes int64 `json:"stderrBytes"`
+}
+
+// sessionStore keeps the output and record of each session in files under a
+// directory, so that output does not have to fit in memory and outlives the
+// server. A session's files share a base name: base.json, base.stdout and
+// base.stderr.
+type sessionStore struct {
+	dir string
+}
+
+// newStore returns the store in dir, creating the directory if need be.
+func newStore(dir string) (*sessionStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create the sessions directory: %w", err)
+	}
+	return &sessionStore{dir: dir}, nil
+}
+
+// create gives a session files for its output, to which its buffers write
+// keeping the last limit bytes of each stream.
+func (s *sessionStore) create(session *TerminalSession, limit int64) error {
+	f, err := os.CreateTemp(s.dir, "*"+stdoutExt)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	base := strings.TrimSuffix(f.Name(), stdoutExt)
+	if err := session.Stdout.spill(base+stdoutExt, limit); err != nil {
+		s.remove(base)
+		return err
+	}
+	if err := session.Stderr.spill(base+stderrExt, limit); err != nil {
+		session.Stdout.close()
+		s.remove(base)
+		return err
+	}
+	session.record = base
+	return nil
+}
+
+// save writes a session's record.
+func (s *sessionStore) save(session *TerminalSession) error {
+	data, err := json.MarshalIndent(sessionRecord{
+		PID:       session.PID,
+		Label:     session.Label,
+		Command:   session.Command,
+		Shell:     session.Shell,
+		Cwd:       session.Cwd,
+		Image:     session.Image,
+		RequestID: session.RequestID,
+		StartTime: session.StartTime,
+		Exited:    session.Exited,
+		EndTime:   session.EndTime,
+		ExitCode:  session.ExitCode,
+		Error:     session.Err,
+		Detached:  session.detached,
+
+		OutputLimit: session.Stdout.limit, // Set before the command started
+		StdoutBytes: session.Stdout.written(),
+		StderrBytes: session.Stderr.written(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(session.record+recordExt, data, 0600)
+}
+
+// remove deletes the files of the session with the base name base.
+func (s *sessionStore) remove(base string) {
+	for _, ext := range []string{recordExt, stdoutExt, stderrExt} {
+		os.Remove(base + ext)
+	}
+}
+
+// load reads the sessions recorded in the store, oldest first. A session
+// that had not exited is reported as having been stopped with the server.
+// Of the sessions with the same PID, only the newest is kept; the files of the
+// others are deleted.
+func (s *sessionStore) load() ([]*TerminalSession, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+recordExt))
+	if err != nil {
+		return nil, err
+	}
+	newest := make(map[int]*TerminalSession)
+	for _, path := range paths {
+		base := strings.TrimSuffix(path, recordExt)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var rec sessionRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			continue
+		}
+		session := &TerminalSession{
+			PID:       rec.PID,
+			Label:     rec.Label,
+			Command:   rec.Command,
+			Shell:     rec.Shell,
+			Cwd:       rec.Cwd,
+			Image:     rec.Image,
+			RequestID: rec.RequestID,
+			StartTime: rec.StartTime,
+			Exited:    true,
+			EndTime:   rec.EndTime,
+			ExitCode:  rec.ExitCode,
+			Err:       rec.Error,
+			record:    base,
+		}
+		session.Stdout.path, session.Stdout.limit, session.Stdout.size = base+stdoutExt, rec.OutputLimit, rec.StdoutBytes
+		session.Stderr.path, session.Stderr.limit, session.Stderr.size = base+stderrExt, rec.OutputLimit, rec.StderrBytes
+		if !rec.Exited {
+			// The command's output ends when the server stopped reading it
+			session.Lost = true
+			session.Err = errServerStopped
+			if rec.Detached {
+				session.Err = errDetached
+			}
+			session.EndTime = rec.StartTime
+		}
+		for _, buffer := range []*outputBuffer{&session.Stdout, &session.Stderr} {
+			if rec.Exited && buffer.limit > 0 {
+				continue
+			}
+			// Only the files tell how much output there is, and once it
+			// wrapped around them not where it starts, so they are read as
+			// they are
+			info, err := os.Stat(buffer.path)
+			if err != nil {
+				continue
+			}
+			if !rec.Exited && info.ModTime().After(session.EndTime) {
+				session.EndTime = info.ModTime()
+			}
+			buffer.size = info.Size()
+			if buffer.limit > 0 && buffer.size >= buffer.limit && !strings.HasSuffix(session.Err, errOutputWrapped) {
+				session.Err += errOutputWrapped
+			}
+			buffer.limit = 0
+		}
+
+		if prev := newest[rec.PID]; prev != nil {
+			if prev.StartTime.After(session.StartTime) {
+				s.remove(base)
+				continue
+			}
+			s.remove(prev.record)
+		}
+		newest[rec.PID] = session
+	}
+
+	sessions := make([]*TerminalSession, 0, len(newest))
+	for _, session := range newest {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].EndTime.Before(sessions[j].EndTime)
+	})
+	return sessions, nil
+}
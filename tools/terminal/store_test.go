@@ -0,0 +1,93 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpilledOutput(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	dir := t.TempDir()
+	tm := newManager()
+	if err := tm.SetStore(dir); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	pid, err := tm.StartCommand(testContext(), "printf 0123456789; echo oops >&2; exit 4", "/bin/sh", "-c", StartOptions{Label: "spill"})
+	if err != nil {
+		t.Fatalf("StartCommand failed: %v", err)
+	}
+	session, _ := tm.GetSession(pid)
+	<-session.Done
+	data, err := os.ReadFile(session.record + stdoutExt)
+	if err != nil || string(data) != "0123456789" {
+		t.Errorf("stdout file = %q, %v", data, err)
+	}
+
+	// Paging leaves the output read_output returns next as it was
+	output, err := tm.ReadOutputAt(pid, "", 4, 3)
+	if err != nil || output.Output != "456" || *output.Page != (OutputPage{Stream: "stdout", Offset: 4, NextOffset: 7, Size: 10}) {
+		t.Errorf("ReadOutputAt = %+v, %v", output, err)
+	}
+	if output, err = tm.ReadOutputAt(pid, "stderr", 0, 0); err != nil || output.Output != "oops\n" {
+		t.Errorf("ReadOutputAt stderr = %+v, %v", output, err)
+	}
+	if _, err := tm.ReadOutputAt(pid, "stdin", 0, 0); err == nil {
+		t.Error("ReadOutputAt of an unknown stream succeeded")
+	}
+	if output, err = tm.ReadNewOutput(pid); err != nil || output.Output != "0123456789oops\n" {
+		t.Errorf("ReadNewOutput = %+v, %v", output, err)
+	}
+
+	// Another run of the server finds the session in the store
+	restarted := newManager()
+	if err := restarted.SetStore(dir); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	loaded, err := restarted.Lookup(0, "spill")
+	if err != nil || loaded != pid {
+		t.Fatalf("Lookup after restart = %d, %v; want %d", loaded, err, pid)
+	}
+	result, err := restarted.Result(pid)
+	if err != nil || result.Status != StatusExited || *result.ExitCode != 4 || result.Stdout != "0123456789" || result.Stderr != "oops\n" {
+		t.Errorf("Result after restart = %+v, %v", result, err)
+	}
+
+	// Sessions dropped from the history take their files with them
+	restarted.SetHistory(0)
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("files left after the history was emptied: %v", files)
+	}
+}
+
+func TestLoadLostSession(t *testing.T) {
+	dir := t.TempDir()
+	store, err := newStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := &TerminalSession{PID: 42, Command: "make", StartTime: time.Now().Add(-time.Minute)}
+	if err := store.create(session, defaultFileLimit); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	session.Stdout.Write([]byte("building\n"))
+	session.Stdout.close()
+	session.Stderr.close()
+	if err := store.save(session); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	// A session the server did not see exit is lost, with the output it had
+	tm := newManager()
+	if err := tm.SetStore(dir); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	output, err := tm.ReadNewOutput(42)
+	if err != nil || output.Status != StatusLost || output.ExitCode != nil || output.Output != "building\n" || !strings.Contains(output.Error, "server stopped") {
+		t.Errorf("ReadNewOutput = %+v, %v; want the session lost with its output", output, err)
+	}
+}
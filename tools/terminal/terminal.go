@@ -1,34 +1,86 @@
 package terminal
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"gocreate/tools/config"
+	"gocreate/tools/i18n"
+	"gocreate/tools/request"
+	"gocreate/tools/webhook"
 
 	"github.com/localrivet/gomcp/server"
 	"mvdan.cc/sh/syntax"
 )
 
+// defaultWaitTimeout is how long wait_for_completion waits without timeout_ms.
+const defaultWaitTimeout = 30 * time.Second
+
+// defaultQueueTimeout is how long a queued execute_command waits for a
+// session to finish without queueTimeoutSec.
+const defaultQueueTimeout = 10 * time.Minute
+
 // Go structs for tool arguments
 type ExecuteCommandArgs struct {
 	Command       string  `json:"command" description:"The command to execute." required:"true"`
 	TimeoutMs     *int    `json:"timeout_ms,omitempty" description:"Optional timeout in milliseconds."`
-	Shell         *string `json:"shell,omitempty" description:"Optional shell to use (e.g., /bin/bash, powershell.exe, cmd.exe). Defaults to best available shell."`
+	Shell         *string `json:"shell,omitempty" description:"Optional shell to use (e.g., /bin/bash, powershell.exe, cmd.exe). Defaults to best available shell, or the container's when commands run in one."`
 	UsePowerShell *bool   `json:"use_powershell,omitempty" description:"If true and on Windows, prefer PowerShell over cmd.exe. Ignored on non-Windows systems."`
+	Label         *string `json:"label,omitempty" description:"A name for the session, unique among running ones, by which the other terminal tools can address it instead of its PID."`
+	Cwd           *string `json:"cwd,omitempty" description:"The directory to run the command in, which must be within the allowed directories. Defaults to the server's working directory."`
+	Pty           *bool   `json:"pty,omitempty" description:"Run the command on a pseudoterminal, so that pagers, watch modes, ssh and coloured CLIs behave as in a terminal. Its output and errors are read together, and input is typed into the terminal."`
+	Cols          *int    `json:"cols,omitempty" description:"Columns of the pseudoterminal. Defaults to 80."`
+	Rows          *int    `json:"rows,omitempty" description:"Rows of the pseudoterminal. Defaults to 24."`
 }
 
 type ReadOutputArgs struct {
-	Pid int `json:"pid" description:"The PID of the terminal session to read output from." required:"true"`
+	Pid    int    `json:"pid,omitempty" description:"The PID of the terminal session to read output from. Sessions that have exited can still be read, with their exit code. Either pid or label is required."`
+	Label  string `json:"label,omitempty" description:"The label the session was started with, instead of its PID."`
+	Offset *int64 `json:"offset,omitempty" description:"Read one stream from this byte offset instead of the output since the last read, which is left unread. Pass back page.nextOffset to read the next page."`
+	Length *int64 `json:"length,omitempty" description:"The most bytes to read at an offset. Defaults to 65536."`
+	Stream string `json:"stream,omitempty" description:"The stream to read at an offset: stdout (the default) or stderr."`
+	Clean  *bool  `json:"clean,omitempty" description:"Strip colour codes and other escape sequences and control characters, and keep only the final text of lines redrawn with carriage returns, such as progress bars. Defaults to true."`
+}
+
+type WaitForCompletionArgs struct {
+	Pid       int    `json:"pid,omitempty" description:"The PID of the terminal session to wait for. Either pid or label is required."`
+	Label     string `json:"label,omitempty" description:"The label the session was started with, instead of its PID."`
+	TimeoutMs *int   `json:"timeout_ms,omitempty" description:"How long to wait in milliseconds before returning with the session still running. Defaults to 30000."`
+	Clean     *bool  `json:"clean,omitempty" description:"Clean the output as read_output does. Defaults to true."`
+}
+
+type SendInputArgs struct {
+	Pid     int    `json:"pid,omitempty" description:"The PID of the terminal session to write to. Either pid or label is required."`
+	Label   string `json:"label,omitempty" description:"The label the session was started with, instead of its PID."`
+	Input   string `json:"input" description:"The text to write to the command's stdin, such as an answer to a prompt or a line for a REPL."`
+	Newline *bool  `json:"newline,omitempty" description:"End the input with a newline, as pressing Enter does. Defaults to true."`
+	Close   *bool  `json:"close,omitempty" description:"Close stdin after writing, so the command reads end of input (Ctrl-D)."`
+}
+
+type ResizeTerminalArgs struct {
+	Pid   int    `json:"pid,omitempty" description:"The PID of the pseudoterminal session to resize. Either pid or label is required."`
+	Label string `json:"label,omitempty" description:"The label the session was started with, instead of its PID."`
+	Cols  int    `json:"cols" description:"The new number of columns." required:"true"`
+	Rows  int    `json:"rows" description:"The new number of rows." required:"true"`
 }
 
 type ForceTerminateArgs struct {
-	Pid int `json:"pid" description:"The PID of the terminal session to force terminate." required:"true"`
+	Pid   int    `json:"pid,omitempty" description:"The PID of the terminal session to force terminate. Either pid or label is required."`
+	Label string `json:"label,omitempty" description:"The label the session was started with, instead of its PID."`
+}
+
+type GetSessionResultArgs struct {
+	Pid   int    `json:"pid,omitempty" description:"The PID of the terminal session, running or among the last finished ones. Either pid or label is required."`
+	Label string `json:"label,omitempty" description:"The label the session was started with, instead of its PID."`
 }
 
 type ListSessionsArgs struct{}
@@ -70,11 +122,12 @@ func detectBestShell(preferPowerShell bool) string {
 
 // getShellExecuteFlag returns the appropriate flag to execute commands based on shell type
 func getShellExecuteFlag(shell string) string {
-	shell = filepath.Base(shell)
-	switch shell {
-	case "powershell.exe", "pwsh.exe":
+	// Windows paths are read on any system, and names in any case
+	shell = strings.ToLower(shell[strings.LastIndexAny(shell, `/\`)+1:])
+	switch strings.TrimSuffix(shell, ".exe") {
+	case "powershell", "pwsh":
 		return "-Command"
-	case "cmd.exe":
+	case "cmd":
 		return "/C"
 	default:
 		return "-c"
@@ -82,7 +135,9 @@ func getShellExecuteFlag(shell string) string {
 }
 
 // isCommandBlockedComplex checks if any command within a potentially complex shell string is blocked using AST parsing.
-func isCommandBlockedComplex(ctx *server.Context, commandStr string, blockedCommands []string) (bool, string) {
+// The string is parsed by the syntax of the shell that runs it. A command is blocked by its name, also when it is run
+// by its path or, on Windows, with its extension, such as C:\Windows\System32\shutdown.exe.
+func isCommandBlockedComplex(ctx *server.Context, commandStr, shell string, blockedCommands []string) (bool, string) {
 	if len(blockedCommands) == 0 {
 		return false, "" // No commands are blocked
 	}
@@ -94,9 +149,7 @@ func isCommandBlockedComplex(ctx *server.Context, commandStr string, blockedComm
 	}
 
 	// Parse the command string
-	parser := syntax.NewParser()
-	reader := strings.NewReader(commandStr)
-	file, err := parser.Parse(reader, "")
+	file, err := parseCommand(commandStr, shell)
 	if err != nil {
 		// If parsing fails, block execution as the command is ambiguous or invalid
 		ctx.Logger.Info("Error parsing command string for validation. Blocking execution.", "error", err)
@@ -113,30 +166,10 @@ func isCommandBlockedComplex(ctx *server.Context, commandStr string, blockedComm
 		}
 		if cmd, ok := node.(*syntax.CallExpr); ok {
 			if len(cmd.Args) > 0 {
-				// Attempt to evaluate the first argument (command name) to a string
-				// This handles simple cases, quotes, and potentially some expansions.
-				// More complex cases (variables, command substitutions) might require an interpreter.
-				// Using WordParts is more direct for simple literals.
-				var cmdName string
-				if len(cmd.Args[0].Parts) == 1 {
-					switch part := cmd.Args[0].Parts[0].(type) {
-					case *syntax.Lit:
-						cmdName = part.Value
-					case *syntax.SglQuoted:
-						cmdName = part.Value
-					case *syntax.DblQuoted:
-						// Only check if it contains simple literals inside
-						if len(part.Parts) == 1 {
-							if lit, ok := part.Parts[0].(*syntax.Lit); ok {
-								cmdName = lit.Value
-							}
-						}
-					}
-				}
+				cmdName := literalWord(cmd.Args[0])
 
 				if cmdName != "" {
-					cmdNameLower := strings.ToLower(cmdName)
-					if _, isBlocked := blockedSet[cmdNameLower]; isBlocked {
+					if isBlockedName(cmdName, blockedSet) {
 						ctx.Logger.Info("Command validation failed: Found blocked command", "command", cmdName, "commandStr", commandStr)
 						firstBlocked = cmdName // Return the original case name
 						blocked = true
@@ -156,26 +189,208 @@ func isCommandBlockedComplex(ctx *server.Context, commandStr string, blockedComm
 	return blocked, firstBlocked
 }
 
-// New API handlers that return strings instead of protocol.Content
+// isBlockedName reports whether the command name is in blockedSet, lowercased
+// or as commandName gives it.
+func isBlockedName(name string, blockedSet map[string]struct{}) bool {
+	_, lower := blockedSet[strings.ToLower(name)]
+	_, base := blockedSet[commandName(name)]
+	return lower || base
+}
 
-// HandleExecuteCommand implements the execute_command tool using the new API
-func HandleExecuteCommand(ctx *server.Context, args ExecuteCommandArgs) (string, error) {
-	ctx.Logger.Info("Handling execute_command tool call")
+// manager returns the terminal manager, keeping as many finished sessions and
+// as much of their output, spilling it, and dealing with stalled sessions as
+// the configuration says.
+func manager(ctx *server.Context) *TerminalManager {
+	tm := GetManager()
+	cfg, err := config.GetCurrentConfig(ctx)
+	if err != nil {
+		return tm
+	}
+	if dir, err := config.SessionsDir(cfg); err == nil {
+		if err := tm.SetStore(dir); err != nil {
+			ctx.Logger.Info("Keeping session output in memory", "dir", dir, "error", err)
+		}
+	}
+	history := defaultSessionHistory
+	if cfg.SessionHistory != nil {
+		history = *cfg.SessionHistory
+	}
+	tm.SetHistory(history)
+	buffer, file := int64(defaultBufferLimit), int64(defaultFileLimit)
+	if cfg.SessionBufferKB != nil {
+		buffer = int64(*cfg.SessionBufferKB) << 10
+	}
+	if cfg.SessionFileMB != nil {
+		file = int64(*cfg.SessionFileMB) << 20
+	}
+	tm.SetOutputLimits(buffer, file)
+	stall := defaultStallTimeout
+	if cfg.StallTimeoutSec != nil {
+		stall = time.Duration(*cfg.StallTimeoutSec) * time.Second
+	}
+	tm.SetStallPolicy(ctx, stall, cfg.TerminateStalled != nil && *cfg.TerminateStalled)
+	limit := 0
+	if cfg.MaxSessions != nil {
+		limit = *cfg.MaxSessions
+	}
+	tm.SetSessionLimit(limit)
+	return tm
+}
 
-	// Determine shell preference
-	usePowerShell := false
-	if args.UsePowerShell != nil {
-		usePowerShell = *args.UsePowerShell
+// Shutdown ends the running terminal sessions as the server exits, or leaves
+// them running when detachSessionsOnExit is set, and logs what it did.
+func Shutdown(ctx *server.Context) {
+	cfg, err := config.GetCurrentConfig(ctx)
+	detach := err == nil && cfg.DetachSessionsOnExit != nil && *cfg.DetachSessionsOnExit
+	if pids := GetManager().Shutdown(ctx, detach); len(pids) > 0 {
+		ctx.Logger.Info("Cleaned up terminal sessions", "pids", pids, "detached", detach)
 	}
+}
 
-	// Get shell path
-	shellPath := ""
-	if args.Shell != nil && *args.Shell != "" {
-		shellPath = *args.Shell
-	} else {
-		shellPath = detectBestShell(usePowerShell)
+// literalWord returns the text word spells out literally, such as a command
+// name, or "" when it does not. This handles simple cases and quotes;
+// variables and command substitutions would require an interpreter.
+func literalWord(word *syntax.Word) string {
+	if len(word.Parts) != 1 {
+		return ""
+	}
+	switch part := word.Parts[0].(type) {
+	case *syntax.Lit:
+		return part.Value
+	case *syntax.SglQuoted:
+		return part.Value
+	case *syntax.DblQuoted:
+		// Only check if it contains simple literals inside
+		if len(part.Parts) == 1 {
+			if lit, ok := part.Parts[0].(*syntax.Lit); ok {
+				return lit.Value
+			}
+		}
+	}
+	return ""
+}
+
+// isCommandNotAllowed checks that every command within a potentially complex
+// shell string is in the allowed list, returning the first that is not. With
+// an allowed list, a command name that is not a literal cannot be checked,
+// and neither can one found through a PATH the command string sets, so both
+// are refused.
+func isCommandNotAllowed(ctx *server.Context, commandStr, shell string, allowedCommands []string) (bool, string) {
+	if len(allowedCommands) == 0 {
+		return false, "" // Every command is allowed
 	}
 
+	allowedSet := make(map[string]struct{}, len(allowedCommands))
+	for _, cmd := range allowedCommands {
+		allowedSet[strings.ToLower(cmd)] = struct{}{}
+	}
+
+	file, err := parseCommand(commandStr, shell)
+	if err != nil {
+		ctx.Logger.Info("Error parsing command string for validation. Blocking execution.", "error", err)
+		return true, fmt.Sprintf("invalid syntax: %v", err)
+	}
+
+	var refused string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if refused != "" {
+			return false
+		}
+		cmd, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		for _, assign := range cmd.Assigns {
+			if assign.Name != nil && assign.Name.Value == "PATH" {
+				refused = "PATH=" // Would choose what the allowed names run
+				return false
+			}
+		}
+		if len(cmd.Args) == 0 {
+			return true
+		}
+		cmdName := literalWord(cmd.Args[0])
+		if cmdName == "" {
+			var sb strings.Builder
+			syntax.NewPrinter().Print(&sb, cmd.Args[0])
+			refused = sb.String()
+			return false
+		}
+		if _, isAllowed := allowedSet[strings.ToLower(cmdName)]; !isAllowed {
+			refused = cmdName
+			return false
+		}
+		return true
+	})
+
+	if refused != "" {
+		ctx.Logger.Info("Command validation failed: Found command not in the allowed list", "command", refused, "commandStr", commandStr)
+		return true, refused
+	}
+	return false, ""
+}
+
+// commandRefusal is why execute_command refuses a command string: the message
+// it answers with, the command that was refused, and the command rule that
+// refused it, if one did.
+type commandRefusal struct {
+	message string
+	blocked string
+	rule    string
+}
+
+// checkCommand checks a command string, to be run by shell in dir, against the
+// blocked list, the allowed list and the command rules, returning why it is
+// refused or nil when it may run.
+func checkCommand(ctx *server.Context, cfg *config.ServerConfig, commandStr, shell, dir string) *commandRefusal {
+	if blocked, name := isCommandBlockedComplex(ctx, commandStr, shell, cfg.BlockedCommands); blocked {
+		errMsg := i18n.T(ctx, i18n.CommandBlocked, name)
+		ctx.Logger.Info("Command blocked", "error", errMsg)
+		return &commandRefusal{message: errMsg, blocked: name}
+	}
+	if notAllowed, name := isCommandNotAllowed(ctx, commandStr, shell, cfg.AllowedCommands); notAllowed {
+		errMsg := i18n.T(ctx, i18n.CommandNotAllowed, name, strings.Join(cfg.AllowedCommands, ", "))
+		ctx.Logger.Info("Command not allowed", "error", errMsg)
+		return &commandRefusal{message: errMsg, blocked: name}
+	}
+	rule, call, err := commandRuleViolation(ctx, commandStr, shell, dir, cfg.CommandRules)
+	if err != nil {
+		name := fmt.Sprintf("invalid syntax: %v", err)
+		return &commandRefusal{message: i18n.T(ctx, i18n.CommandBlocked, name), blocked: name}
+	}
+	if rule != nil {
+		errMsg := i18n.T(ctx, i18n.CommandRuleDenied, rule.Name, call)
+		if rule.Message != "" {
+			errMsg = i18n.T(ctx, i18n.CommandRuleDeniedReason, rule.Name, call, rule.Message)
+		}
+		ctx.Logger.Info("Command denied by rule", "error", errMsg)
+		return &commandRefusal{message: errMsg, blocked: call, rule: rule.Name}
+	}
+	return nil
+}
+
+// workingDir returns the absolute form of cwd, or a refusal message when it is
+// not a directory within the allowed directories.
+func workingDir(ctx *server.Context, cfg *config.ServerConfig, cwd string) (string, string) {
+	dir, err := filepath.Abs(cwd)
+	if err != nil {
+		return "", i18n.T(ctx, i18n.PathNotDirectory, cwd)
+	}
+	if !config.PathAllowed(cfg, dir) {
+		return "", i18n.T(ctx, i18n.PathNotAllowed, dir)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", i18n.T(ctx, i18n.PathNotDirectory, dir)
+	}
+	return dir, ""
+}
+
+// New API handlers that return strings instead of protocol.Content
+
+// HandleExecuteCommand implements the execute_command tool using the new API
+func HandleExecuteCommand(ctx *server.Context, args ExecuteCommandArgs) (string, error) {
+	ctx.Logger.Info("Handling execute_command tool call")
+
 	// --- Command Validation ---
 	cfg, err := config.GetCurrentConfig(ctx) // Get loaded config
 	if err != nil {
@@ -183,23 +398,97 @@ func HandleExecuteCommand(ctx *server.Context, args ExecuteCommandArgs) (string,
 		return "Error loading configuration for validation", err
 	}
 
-	// Use the complex validation function
-	blocked, blockedCmdName := isCommandBlockedComplex(ctx, args.Command, cfg.BlockedCommands)
-	if blocked {
-		errMsg := fmt.Sprintf("Command execution blocked: Command '%s' is blocked or syntax is invalid/unsupported for validation.", blockedCmdName)
-		ctx.Logger.Info("Command blocked", "error", errMsg)
-		return errMsg, nil
+	// The command string is checked by the syntax of the shell that runs it
+	shellPath := commandShell(cfg, args.Shell, args.UsePowerShell)
+
+	dir := ""
+	if args.Cwd != nil && *args.Cwd != "" {
+		var msg string
+		if dir, msg = workingDir(ctx, cfg, *args.Cwd); msg != "" {
+			return msg, nil
+		}
+	}
+
+	// Rules on arguments and paths resolve relative paths against the
+	// directory the command runs in
+	ruleDir := dir
+	if ruleDir == "" {
+		ruleDir, _ = os.Getwd()
+	}
+	if refusal := checkCommand(ctx, cfg, args.Command, shellPath, ruleDir); refusal != nil {
+		data := map[string]interface{}{"command": args.Command, "blocked": refusal.blocked}
+		if refusal.rule != "" {
+			data["rule"] = refusal.rule
+		}
+		webhook.Notify(ctx, webhook.CommandBlocked, data)
+		return refusal.message, nil
 	}
 	// --- End Command Validation ---
 
+	// With a container configured the command runs in it, by its shell
+	// unless the call names another
+	var container *Container
+	if cfg.Container != nil {
+		if container, err = containerFromConfig(cfg.Container); err == nil {
+			_, err = container.mapDir(ruleDir)
+		}
+		if err != nil {
+			return i18n.T(ctx, i18n.ContainerUnavailable, err), nil
+		}
+	}
+
 	// Get the appropriate execute flag for the shell
 	executeFlag := getShellExecuteFlag(shellPath)
 
 	// Get the terminal manager instance
-	tm := GetManager()
+	tm := manager(ctx)
 
 	// Start the command asynchronously using the manager
-	pid, startErr := tm.StartCommand(ctx, args.Command, shellPath, executeFlag)
+	opts := StartOptions{Dir: dir, PTY: args.Pty != nil && *args.Pty, Container: container}
+	if args.Label != nil {
+		opts.Label = *args.Label
+	}
+	if args.Cols != nil {
+		opts.Cols = terminalSize(*args.Cols)
+	}
+	if args.Rows != nil {
+		opts.Rows = terminalSize(*args.Rows)
+	}
+	queueTimeout := defaultQueueTimeout
+	if cfg.QueueTimeoutSec != nil {
+		queueTimeout = time.Duration(*cfg.QueueTimeoutSec) * time.Second
+	}
+	var queued time.Time
+	if cfg.QueueSessions != nil && *cfg.QueueSessions {
+		// Past the session limit, the call waits in line, reporting its place
+		// as progress: how many places it has moved up of those it started at
+		reqCtx, stop := request.Context(ctx)
+		defer stop()
+		queueCtx, cancel := context.WithTimeout(reqCtx, queueTimeout)
+		defer cancel()
+		var first float64
+		opts.Queue = queueCtx
+		opts.Position = func(position int) {
+			if queued.IsZero() {
+				queued, first = time.Now(), float64(position)
+			}
+			ctx.Logger.Info("Command queued", "command", args.Command, "position", position)
+			if ctx.HasProgressToken() {
+				_ = ctx.SendProgress(first-float64(position), &first, i18n.T(ctx, i18n.CommandQueued, position))
+			}
+		}
+	}
+	pid, startErr := tm.StartCommand(ctx, args.Command, shellPath, executeFlag, opts)
+	if errors.Is(startErr, errSessionLimit) {
+		limit := 0
+		if cfg.MaxSessions != nil {
+			limit = *cfg.MaxSessions
+		}
+		if opts.Queue != nil {
+			return i18n.T(ctx, i18n.SessionQueueTimeout, time.Since(queued).Round(time.Second), limit), nil
+		}
+		return i18n.T(ctx, i18n.SessionLimitReached, limit), nil
+	}
 
 	// Check for errors during start
 	if startErr != nil {
@@ -219,7 +508,10 @@ func HandleExecuteCommand(ctx *server.Context, args ExecuteCommandArgs) (string,
 
 	// Return PID indicating successful start
 	ctx.Logger.Info("Command started successfully in background", "pid", pid, "shell", shellPath, "command", args.Command)
-	resultText := fmt.Sprintf("Command started in background with PID: %d", pid)
+	resultText := i18n.T(ctx, i18n.CommandStarted, pid)
+	if !queued.IsZero() {
+		resultText += " " + i18n.T(ctx, i18n.CommandStartedQueued, time.Since(queued).Round(time.Second))
+	}
 	return resultText, nil
 }
 
@@ -228,17 +520,153 @@ func HandleReadOutput(ctx *server.Context, args ReadOutputArgs) (string, error)
 	ctx.Logger.Info("Handling read_output tool call")
 
 	// Get the terminal manager instance
-	tm := GetManager()
+	tm := manager(ctx)
+	pid, err := tm.Lookup(args.Pid, args.Label)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	// Read new output from the manager, or a page of it
+	var output SessionOutput
+	if args.Offset != nil {
+		var length int64
+		if args.Length != nil {
+			length = *args.Length
+		}
+		output, err = tm.ReadOutputAt(pid, args.Stream, *args.Offset, length)
+	} else {
+		output, err = tm.ReadNewOutput(pid)
+	}
+	if err != nil {
+		ctx.Logger.Info("Error reading output", "pid", pid, "error", err)
+		return err.Error(), err
+	}
+	if args.Clean == nil || *args.Clean {
+		output.Output = cleanOutput(output.Output)
+	}
+
+	ctx.Logger.Info("Read output", "pid", pid, "status", output.Status, "bytes", len(output.Output))
+	resultJson, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling session output", "error", err)
+		return "Error formatting session output", err
+	}
+	return string(resultJson), nil
+}
+
+// HandleWaitForCompletion implements the wait_for_completion tool: it waits
+// until the command exits, the timeout passes or the client cancels the call,
+// and returns the session's status and remaining output as read_output does.
+func HandleWaitForCompletion(ctx *server.Context, args WaitForCompletionArgs) (string, error) {
+	ctx.Logger.Info("Handling wait_for_completion tool call", "pid", args.Pid, "label", args.Label)
+	tm := manager(ctx)
+	pid, err := tm.Lookup(args.Pid, args.Label)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	timeout := defaultWaitTimeout
+	if args.TimeoutMs != nil && *args.TimeoutMs > 0 {
+		timeout = time.Duration(*args.TimeoutMs) * time.Millisecond
+	}
+	reqCtx, stop := request.Context(ctx)
+	defer stop()
+	waitCtx, cancel := context.WithTimeout(reqCtx, timeout)
+	defer cancel()
+
+	output, err := tm.WaitForCompletion(waitCtx, pid)
+	if err != nil {
+		ctx.Logger.Info("Error waiting for session", "pid", pid, "error", err)
+		return err.Error(), err
+	}
+	if args.Clean == nil || *args.Clean {
+		output.Output = cleanOutput(output.Output)
+	}
+
+	ctx.Logger.Info("Waited for session", "pid", pid, "status", output.Status)
+	resultJson, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling session output", "error", err)
+		return "Error formatting session output", err
+	}
+	return string(resultJson), nil
+}
+
+// HandleGetSessionResult implements the get_session_result tool: it returns
+// a session's command, exit code, timing and full output as JSON, for as long
+// as the session is among the last finished ones kept.
+func HandleGetSessionResult(ctx *server.Context, args GetSessionResultArgs) (string, error) {
+	ctx.Logger.Info("Handling get_session_result tool call", "pid", args.Pid, "label", args.Label)
+	tm := manager(ctx)
+	pid, err := tm.Lookup(args.Pid, args.Label)
+	if err != nil {
+		return err.Error(), err
+	}
 
-	// Read new output from the manager
-	output, err := tm.ReadNewOutput(args.Pid)
+	result, err := tm.Result(pid)
 	if err != nil {
-		ctx.Logger.Info("Error reading output", "pid", args.Pid, "error", err)
+		ctx.Logger.Info("Error getting session result", "pid", pid, "error", err)
 		return err.Error(), err
 	}
 
-	ctx.Logger.Info("Read output", "pid", args.Pid, "bytes", len(output))
-	return output, nil
+	resultJson, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling session result", "error", err)
+		return "Error formatting session result", err
+	}
+	return string(resultJson), nil
+}
+
+// HandleSendInput implements the send_input tool.
+func HandleSendInput(ctx *server.Context, args SendInputArgs) (string, error) {
+	ctx.Logger.Info("Handling send_input tool call", "pid", args.Pid, "label", args.Label)
+	tm := manager(ctx)
+	pid, err := tm.Lookup(args.Pid, args.Label)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	input := args.Input
+	if args.Newline == nil || *args.Newline {
+		input += "\n"
+	}
+	closeInput := args.Close != nil && *args.Close
+	if err := tm.SendInput(pid, input, closeInput); err != nil {
+		ctx.Logger.Info("Error sending input", "pid", pid, "error", err)
+		return err.Error(), err
+	}
+
+	if closeInput {
+		return i18n.T(ctx, i18n.InputClosed, len(input), pid), nil
+	}
+	return i18n.T(ctx, i18n.InputSent, len(input), pid), nil
+}
+
+// terminalSize clamps a number of columns or rows to what a terminal takes;
+// 0 stands for the default.
+func terminalSize(n int) uint16 {
+	return uint16(min(max(n, 0), math.MaxUint16))
+}
+
+// HandleResizeTerminal implements the resize_terminal tool.
+func HandleResizeTerminal(ctx *server.Context, args ResizeTerminalArgs) (string, error) {
+	ctx.Logger.Info("Handling resize_terminal tool call", "pid", args.Pid, "label", args.Label, "cols", args.Cols, "rows", args.Rows)
+	tm := manager(ctx)
+	pid, err := tm.Lookup(args.Pid, args.Label)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	cols, rows := terminalSize(args.Cols), terminalSize(args.Rows)
+	if cols == 0 || rows == 0 {
+		err := fmt.Errorf("invalid terminal size %dx%d", args.Cols, args.Rows)
+		return err.Error(), err
+	}
+	if err := tm.Resize(pid, cols, rows); err != nil {
+		ctx.Logger.Info("Error resizing terminal", "pid", pid, "error", err)
+		return err.Error(), err
+	}
+	return i18n.T(ctx, i18n.TerminalResized, pid, cols, rows), nil
 }
 
 // HandleForceTerminate implements the force_terminate tool using the new API
@@ -246,17 +674,21 @@ func HandleForceTerminate(ctx *server.Context, args ForceTerminateArgs) (string,
 	ctx.Logger.Info("Handling force_terminate tool call")
 
 	// Get the terminal manager instance
-	tm := GetManager()
+	tm := manager(ctx)
+	pid, err := tm.Lookup(args.Pid, args.Label)
+	if err != nil {
+		return err.Error(), err
+	}
 
 	// Terminate the session using the manager
-	err := tm.TerminateSession(ctx, args.Pid)
+	err = tm.TerminateSession(ctx, pid)
 	if err != nil {
-		ctx.Logger.Info("Error terminating process", "pid", args.Pid, "error", err)
+		ctx.Logger.Info("Error terminating process", "pid", pid, "error", err)
 		return err.Error(), err
 	}
 
-	ctx.Logger.Info("Termination signal sent", "pid", args.Pid)
-	resultText := fmt.Sprintf("Termination signal sent to PID %d.", args.Pid)
+	ctx.Logger.Info("Termination signal sent", "pid", pid)
+	resultText := i18n.T(ctx, i18n.TerminationSent, pid)
 	return resultText, nil
 }
 
@@ -0,0 +1,172 @@
+package terminal
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+	"mvdan.cc/sh/syntax"
+)
+
+// ValidateCommandArgs defines the arguments for the validate_command tool.
+type ValidateCommandArgs struct {
+	Command       string  `json:"command" description:"The command string to check, as it would be passed to execute_command." required:"true"`
+	Cwd           *string `json:"cwd,omitempty" description:"The directory the command would run in, against which command rules resolve relative paths. Defaults to the server's working directory."`
+	Shell         *string `json:"shell,omitempty" description:"The shell execute_command would be asked to use, whose syntax the command is read by. Defaults to the one execute_command picks."`
+	UsePowerShell *bool   `json:"use_powershell,omitempty" description:"Check the command as execute_command would with use_powershell."`
+}
+
+// CommandReport is what validate_command reports about a command string.
+type CommandReport struct {
+	Command string        `json:"command"`
+	Shell   string        `json:"shell"`            // The shell execute_command would run it with
+	Allowed bool          `json:"allowed"`          // Whether execute_command would run it
+	Reason  string        `json:"reason,omitempty"` // Why not, as execute_command would answer
+	Error   string        `json:"error,omitempty"`  // Why the string could not be parsed
+	Calls   []CommandCall `json:"calls,omitempty"`  // The commands it would invoke, in order
+}
+
+// CommandCall is one command a command string invokes.
+type CommandCall struct {
+	Command string   `json:"command"`           // The call as it is written
+	Args    []string `json:"args"`              // Its words as the shell splits and unquotes them, the name first
+	PipedTo []string `json:"pipedTo,omitempty"` // The commands its output is piped into
+	Blocked string   `json:"blocked,omitempty"` // What refuses it: blockedCommands, allowedCommands or commandRules: <name>
+}
+
+// explainCalls returns the command calls within file, each with the setting
+// that refuses it, if one does, for a command run in dir. posix says whether
+// file was parsed by sh's syntax, whose quotes and escapes its words keep.
+func explainCalls(cfg *config.ServerConfig, file *syntax.File, dir string, posix bool) []CommandCall {
+	blockedSet := make(map[string]struct{}, len(cfg.BlockedCommands))
+	for _, cmd := range cfg.BlockedCommands {
+		blockedSet[cmd] = struct{}{}
+	}
+	allowedSet := make(map[string]bool, len(cfg.AllowedCommands))
+	for _, cmd := range cfg.AllowedCommands {
+		allowedSet[strings.ToLower(cmd)] = true
+	}
+
+	pipedTo := pipeTargets(file)
+	var found []CommandCall
+	for _, call := range calls(file) {
+		c := CommandCall{Command: printNode(call), PipedTo: pipedTo[call]}
+		for _, arg := range call.Args {
+			c.Args = append(c.Args, wordText(arg, posix))
+		}
+		name := literalWord(call.Args[0])
+		setsPath := false
+		for _, assign := range call.Assigns {
+			setsPath = setsPath || assign.Name != nil && assign.Name.Value == "PATH"
+		}
+		if name != "" && isBlockedName(name, blockedSet) {
+			c.Blocked = "blockedCommands"
+		} else if len(allowedSet) > 0 && (name == "" || !allowedSet[strings.ToLower(name)] || setsPath) {
+			c.Blocked = "allowedCommands"
+		} else if rule := callRule(call, dir, cfg.CommandRules, pipedTo[call]); rule != nil {
+			c.Blocked = "commandRules: " + rule.Name
+		}
+		found = append(found, c)
+	}
+	return found
+}
+
+// wordText returns word as the shell passes it to a command, without its
+// quotes and escapes, or as it is written when it expands a parameter or runs
+// a command substitution, which only running it would tell. The Windows
+// shells' words are parsed without their escapes already.
+func wordText(word *syntax.Word, posix bool) string {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			if posix {
+				sb.WriteString(unescape(part.Value, ""))
+			} else {
+				sb.WriteString(part.Value)
+			}
+		case *syntax.SglQuoted:
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return printNode(word)
+				}
+				if posix {
+					sb.WriteString(unescape(lit.Value, "$`\"\\\n"))
+				} else {
+					sb.WriteString(lit.Value)
+				}
+			}
+		default:
+			return printNode(word)
+		}
+	}
+	return sb.String()
+}
+
+// unescape removes the backslashes that escape a character from s, any
+// character or only those in special, and those continuing a line.
+func unescape(s, special string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (special == "" || strings.IndexByte(special, s[i+1]) >= 0) {
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// HandleValidateCommand implements the validate_command tool: it reports, as
+// JSON, how execute_command would check a command string, without running it.
+func HandleValidateCommand(ctx *server.Context, args ValidateCommandArgs) (string, error) {
+	ctx.Logger.Info("Handling validate_command tool call")
+
+	cfg, err := config.GetCurrentConfig(ctx)
+	if err != nil {
+		ctx.Logger.Info("Error loading config for validate_command", "error", err)
+		return "Error loading configuration", err
+	}
+
+	report := CommandReport{Command: args.Command, Shell: commandShell(cfg, args.Shell, args.UsePowerShell)}
+	dir := ""
+	if args.Cwd != nil && *args.Cwd != "" {
+		var msg string
+		if dir, msg = workingDir(ctx, cfg, *args.Cwd); msg != "" {
+			report.Reason = msg
+		}
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	if file, err := parseCommand(args.Command, report.Shell); err != nil {
+		report.Error = err.Error()
+	} else {
+		report.Calls = explainCalls(cfg, file, dir, windowsShell(report.Shell) == "")
+	}
+	if report.Reason == "" {
+		if refusal := checkCommand(ctx, cfg, args.Command, report.Shell, dir); refusal != nil {
+			report.Reason = refusal.message
+		}
+	}
+	report.Allowed = report.Reason == ""
+
+	resultJson, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling command report", "error", err)
+		return "Error formatting command report", err
+	}
+	return string(resultJson), nil
+}
@@ -0,0 +1,41 @@
+package terminal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gocreate/tools/config"
+
+	"mvdan.cc/sh/syntax"
+)
+
+func TestExplainCalls(t *testing.T) {
+	cfg := &config.ServerConfig{
+		BlockedCommands: []string{"sudo"},
+		AllowedCommands: []string{"git", "grep", "sudo", "curl", "sh"},
+		CommandRules:    []config.CommandRule{{Name: "no-pipe-to-shell", Command: "curl", PipedTo: []string{"sh"}}},
+	}
+	command := `git log --format='%an <%ae>' "$REF" | grep -e "a \"b\"" x\ y; sudo ls; curl -s url | sh`
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CommandCall{
+		{Command: `git log --format='%an <%ae>' "$REF"`, Args: []string{"git", "log", "--format=%an <%ae>", `"$REF"`}, PipedTo: []string{"grep"}},
+		{Command: `grep -e "a \"b\"" x\ y`, Args: []string{"grep", "-e", `a "b"`, "x y"}},
+		{Command: "sudo ls", Args: []string{"sudo", "ls"}, Blocked: "blockedCommands"},
+		{Command: "curl -s url", Args: []string{"curl", "-s", "url"}, PipedTo: []string{"sh"}, Blocked: "commandRules: no-pipe-to-shell"},
+		{Command: "sh", Args: []string{"sh"}},
+	}
+	if got := explainCalls(cfg, file, t.TempDir(), true); !reflect.DeepEqual(got, want) {
+		t.Errorf("explainCalls = %+v\nwant %+v", got, want)
+	}
+
+	file, _ = syntax.NewParser().Parse(strings.NewReader("PATH=. git status && $CMD build"), "")
+	for _, call := range explainCalls(cfg, file, t.TempDir(), true) {
+		if call.Blocked != "allowedCommands" {
+			t.Errorf("%s blocked by %q; want allowedCommands", call.Command, call.Blocked)
+		}
+	}
+}
@@ -0,0 +1,833 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"mvdan.cc/sh/syntax"
+)
+
+// windowsShell returns "cmd" or "powershell" when shell is one of the Windows
+// shells, whose syntax is not sh's, or "" for any other.
+func windowsShell(shell string) string {
+	switch getShellExecuteFlag(shell) {
+	case "/C":
+		return "cmd"
+	case "-Command":
+		return "powershell"
+	}
+	return ""
+}
+
+// parseCommand parses a command string as shell would: by the syntax of
+// cmd.exe or PowerShell for those, and of sh for any other.
+func parseCommand(commandStr, shell string) (*syntax.File, error) {
+	kind := windowsShell(shell)
+	if kind == "" {
+		return syntax.NewParser().Parse(strings.NewReader(commandStr), "")
+	}
+	p := &winParser{src: commandStr, ps: kind == "powershell"}
+	stmts := p.list(0)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &syntax.File{StmtList: syntax.StmtList{Stmts: stmts}}, nil
+}
+
+// cmdLine returns the command line that has cmd.exe run commandStr as it is
+// written: with /S, cmd strips the quotes around it and parses the rest
+// itself, so no quoting for the C runtime's rules gets in the way.
+func cmdLine(shell, commandStr string) string {
+	if strings.ContainsAny(shell, " \t") {
+		shell = `"` + shell + `"`
+	}
+	return shell + ` /S /C "` + commandStr + `"`
+}
+
+// encodedCommand returns commandStr as PowerShell's -EncodedCommand takes it,
+// base64 of its UTF-16LE encoding, which quoting cannot alter.
+func encodedCommand(commandStr string) string {
+	units := utf16.Encode([]rune(commandStr))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[2*i:], u)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// psKeywords are the PowerShell keywords that can start a statement. They are
+// not commands; the commands in their conditions and blocks are.
+var psKeywords = map[string]bool{
+	"if": true, "elseif": true, "else": true, "switch": true, "foreach": true, "for": true,
+	"while": true, "do": true, "until": true, "try": true, "catch": true, "finally": true,
+	"trap": true, "function": true, "filter": true, "param": true, "begin": true,
+	"process": true, "end": true, "data": true, "class": true, "enum": true,
+}
+
+// psPipelineKeywords are the PowerShell keywords followed by a pipeline that
+// runs, such as return Get-Item x.
+var psPipelineKeywords = map[string]bool{"return": true, "throw": true, "exit": true}
+
+// psAssignOps are PowerShell's assignment operators, after which a pipeline
+// runs, as in $x = Get-Item x.
+var psAssignOps = map[string]bool{"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true, "??=": true}
+
+// winParser reads a cmd.exe or PowerShell command string into the nodes sh's
+// parser gives for the equivalent sh one, so that the same checks apply to
+// it: command calls, pipes, && and ||, redirections, and groups. Commands in
+// PowerShell subexpressions, script blocks and parentheses, and cmd's for /f
+// sets, are command substitutions, and variables parameter expansions, so
+// that the words holding them are not literal. Keywords, such as if and for,
+// assignments and PowerShell expressions are blocks of the commands they run.
+type winParser struct {
+	src string
+	i   int
+	ps  bool // PowerShell rather than cmd
+	err error
+}
+
+// fail records the first syntax error and stops reading.
+func (p *winParser) fail(format string, a ...interface{}) {
+	if p.err == nil {
+		line := strings.Count(p.src[:p.i], "\n") + 1
+		col := p.i - strings.LastIndexByte(p.src[:p.i], '\n')
+		p.err = fmt.Errorf("%d:%d: %s", line, col, fmt.Sprintf(format, a...))
+	}
+	p.i = len(p.src)
+}
+
+func (p *winParser) peek(s string) bool {
+	return strings.HasPrefix(p.src[p.i:], s)
+}
+
+// blank skips spaces, continued lines and comments.
+func (p *winParser) blank() {
+	for p.i < len(p.src) {
+		switch c := p.src[p.i]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.i++
+		case p.ps && (p.peek("`\n") || p.peek("`\r\n")), !p.ps && (p.peek("^\n") || p.peek("^\r\n")):
+			p.i += strings.IndexByte(p.src[p.i:], '\n') + 1
+		case p.ps && p.peek("<#"):
+			j := strings.Index(p.src[p.i:], "#>")
+			if j < 0 {
+				p.fail("reached the end without closing <#")
+				return
+			}
+			p.i += j + 2
+		case p.ps && c == '#':
+			if j := strings.IndexByte(p.src[p.i:], '\n'); j >= 0 {
+				p.i += j
+			} else {
+				p.i = len(p.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// space skips blanks and newlines, as after an operator that needs more.
+func (p *winParser) space() {
+	for p.blank(); p.i < len(p.src) && p.src[p.i] == '\n'; p.blank() {
+		p.i++
+	}
+}
+
+// stop reports whether a command ends where the parser is, at a separator,
+// an operator or end.
+func (p *winParser) stop(end byte) bool {
+	switch c := p.src[p.i]; c {
+	case '\n', '|', '&':
+		return true
+	case ';', ')', '}':
+		return p.ps || c == end
+	}
+	return false
+}
+
+// list reads statements up to end, or the end of the string when end is 0,
+// and the closing end.
+func (p *winParser) list(end byte) []*syntax.Stmt {
+	var stmts []*syntax.Stmt
+	for p.err == nil {
+		p.blank()
+		if p.i >= len(p.src) {
+			if end != 0 {
+				p.fail("reached the end without closing %q", end)
+			}
+			break
+		}
+		c := p.src[p.i]
+		switch {
+		case end != 0 && c == end:
+			p.i++
+			return stmts
+		case c == '\n', p.ps && c == ';', !p.ps && c == '&' && !p.peek("&&"):
+			p.i++
+			continue
+		}
+		stmts = append(stmts, p.andOr(end))
+		p.blank()
+		if p.i >= len(p.src) || end != 0 && p.src[p.i] == end {
+			continue
+		}
+		switch c := p.src[p.i]; {
+		case c == '\n', p.ps && c == ';', c == '&' && !p.peek("&&"):
+			// A PowerShell statement ending in & runs in the background
+			p.i++
+		default:
+			p.fail("unexpected %q", c)
+		}
+	}
+	return stmts
+}
+
+// andOr reads pipelines joined by && and ||.
+func (p *winParser) andOr(end byte) *syntax.Stmt {
+	stmt := p.pipeline(end)
+	for p.err == nil {
+		p.blank()
+		op := syntax.AndStmt
+		switch {
+		case p.peek("&&"):
+		case p.peek("||"):
+			op = syntax.OrStmt
+		default:
+			return stmt
+		}
+		p.i += 2
+		p.space()
+		stmt = &syntax.Stmt{Cmd: &syntax.BinaryCmd{Op: op, X: stmt, Y: p.pipeline(end)}}
+	}
+	return stmt
+}
+
+// pipeline reads commands joined by |.
+func (p *winParser) pipeline(end byte) *syntax.Stmt {
+	stmt := p.command(end)
+	p.blank()
+	if p.err == nil && p.peek("|") && !p.peek("||") {
+		p.i++
+		p.space()
+		stmt = &syntax.Stmt{Cmd: &syntax.BinaryCmd{Op: syntax.Pipe, X: stmt, Y: p.pipeline(end)}}
+	}
+	return stmt
+}
+
+// command reads a command with its redirections.
+func (p *winParser) command(end byte) *syntax.Stmt {
+	p.blank()
+	stmt := &syntax.Stmt{}
+	callOp := p.ps && p.peek("&") && !p.peek("&&")
+	if p.i >= len(p.src) || p.stop(end) && !callOp {
+		p.fail("expected a command")
+		return stmt
+	}
+	if p.ps {
+		return p.psCommand(stmt, end)
+	}
+
+	if p.peek("(") {
+		p.i++
+		stmt.Cmd = &syntax.Subshell{StmtList: syntax.StmtList{Stmts: p.list(')')}}
+		for p.err == nil {
+			p.blank()
+			if p.i >= len(p.src) || p.stop(end) || !p.redirect(stmt) {
+				break
+			}
+		}
+		return stmt
+	}
+	if p.peek("@") {
+		p.i++ // Only turns off echoing the command
+	}
+	first := p.next(stmt, end)
+	if first == nil {
+		if len(stmt.Redirs) == 0 {
+			p.fail("expected a command")
+		}
+		stmt.Cmd = &syntax.CallExpr{}
+		return stmt
+	}
+	switch strings.ToLower(literalWord(first)) {
+	case "if":
+		stmt.Cmd = &syntax.Block{StmtList: syntax.StmtList{Stmts: p.cmdIf(stmt, end)}}
+	case "for":
+		stmt.Cmd = &syntax.Block{StmtList: syntax.StmtList{Stmts: p.cmdFor(stmt, end)}}
+	default:
+		stmt.Cmd = &syntax.CallExpr{Args: append([]*syntax.Word{first}, p.words(stmt, end)...)}
+	}
+	return stmt
+}
+
+// psCommand reads a PowerShell command, or a statement that runs commands:
+// a keyword with its conditions and blocks, or an assignment.
+func (p *winParser) psCommand(stmt *syntax.Stmt, end byte) *syntax.Stmt {
+	// & runs the command a word names, and . runs it in the current scope
+	callOp := p.peek("&") && !p.peek("&&") || p.peek(". ") || p.peek(".\t")
+	if callOp {
+		p.i++
+	}
+	words := p.words(stmt, end)
+	if len(words) == 0 {
+		if len(stmt.Redirs) == 0 {
+			p.fail("expected a command")
+		}
+		stmt.Cmd = &syntax.CallExpr{}
+		return stmt
+	}
+	// A keyword may be followed by its condition without a space, as in if(
+	name := ""
+	if lit, ok := words[0].Parts[0].(*syntax.Lit); ok {
+		name = strings.ToLower(lit.Value)
+	}
+	var runs []*syntax.Stmt
+	switch {
+	case callOp:
+		stmt.Cmd = &syntax.CallExpr{Args: words}
+		return stmt
+	case psKeywords[name]:
+		runs = substitutions(words)
+	case psPipelineKeywords[name] && len(words[0].Parts) == 1 && len(words) > 1:
+		runs = []*syntax.Stmt{{Cmd: &syntax.CallExpr{Args: words[1:]}}}
+	case len(words) > 2 && len(words[1].Parts) == 1 && psAssignOps[literalWord(words[1])]:
+		runs = append(substitutions(words[:1]), &syntax.Stmt{Cmd: &syntax.CallExpr{Args: words[2:]}})
+	case psExpression(words):
+		runs = substitutions(words)
+	default:
+		stmt.Cmd = &syntax.CallExpr{Args: words}
+		return stmt
+	}
+	stmt.Cmd = &syntax.Block{StmtList: syntax.StmtList{Stmts: runs}}
+	return stmt
+}
+
+// cmdIf reads the rest of a cmd if statement, returning the commands it runs:
+// the one after its condition and the one after else.
+func (p *winParser) cmdIf(stmt *syntax.Stmt, end byte) []*syntax.Stmt {
+	start := p.i
+	word := p.next(stmt, end)
+	for _, flag := range []string{"/i", "not"} {
+		if word != nil && strings.EqualFold(literalWord(word), flag) {
+			start, word = p.i, p.next(stmt, end)
+		}
+	}
+	if word == nil {
+		p.fail("expected a condition")
+		return nil
+	}
+	text := strings.TrimSpace(p.src[start:p.i])
+	switch strings.ToLower(literalWord(word)) {
+	case "exist", "defined", "errorlevel", "cmdextversion":
+		p.next(stmt, end)
+	default:
+		// string1==string2, possibly spaced, or string1 op string2
+		if !strings.Contains(text, "==") {
+			start = p.i
+			op := p.next(stmt, end)
+			switch opText := strings.TrimSpace(p.src[start:p.i]); strings.ToUpper(opText) {
+			case "==", "EQU", "NEQ", "LSS", "LEQ", "GTR", "GEQ":
+				p.next(stmt, end)
+			default:
+				if op == nil || !strings.HasPrefix(opText, "==") {
+					p.fail("expected a comparison")
+					return nil
+				}
+			}
+		} else if strings.HasSuffix(text, "==") {
+			p.next(stmt, end)
+		}
+	}
+
+	runs := []*syntax.Stmt{p.command(end)}
+	p.blank()
+	if at := p.i; p.err == nil && p.i < len(p.src) && !p.stop(end) {
+		if strings.EqualFold(literalWord(p.word(end)), "else") {
+			runs = append(runs, p.command(end))
+		} else {
+			p.i = at
+		}
+	}
+	return runs
+}
+
+// cmdFor reads the rest of a cmd for statement, returning the commands it
+// runs: those of a for /f set in quotes and the one after do.
+func (p *winParser) cmdFor(stmt *syntax.Stmt, end byte) []*syntax.Stmt {
+	for {
+		word := p.next(stmt, end)
+		if word == nil {
+			p.fail("expected in")
+			return nil
+		}
+		if strings.EqualFold(literalWord(word), "in") {
+			break
+		}
+	}
+	p.blank()
+	if !p.peek("(") {
+		p.fail("expected a set in parentheses")
+		return nil
+	}
+	j := strings.IndexByte(p.src[p.i:], ')')
+	if j < 0 {
+		p.fail("reached the end without closing '('")
+		return nil
+	}
+	set := strings.TrimSpace(p.src[p.i+1 : p.i+j])
+	p.i += j + 1
+
+	var runs []*syntax.Stmt
+	if len(set) >= 2 && (set[0] == '\'' || set[0] == '`') && set[len(set)-1] == set[0] {
+		// for /f runs the command in quotes, as the escapes leave it, and
+		// reads its output
+		var inner winParser
+		for k := 1; k < len(set)-1; k++ {
+			if set[k] == '^' && k+1 < len(set)-1 {
+				k++
+			}
+			inner.src += set[k : k+1]
+		}
+		runs = inner.list(0)
+		if inner.err != nil {
+			p.fail("in the for /f command: %v", inner.err)
+			return nil
+		}
+	}
+	if do := p.next(stmt, end); do == nil || !strings.EqualFold(literalWord(do), "do") {
+		p.fail("expected do")
+		return nil
+	}
+	return append(runs, p.command(end))
+}
+
+// next reads the next word of a command, and any redirections before it, or
+// returns nil at the end of the command.
+func (p *winParser) next(stmt *syntax.Stmt, end byte) *syntax.Word {
+	for p.err == nil {
+		p.blank()
+		if p.i >= len(p.src) || p.stop(end) {
+			return nil
+		}
+		if !p.redirect(stmt) {
+			return p.word(end)
+		}
+	}
+	return nil
+}
+
+// words reads the remaining words of a command.
+func (p *winParser) words(stmt *syntax.Stmt, end byte) []*syntax.Word {
+	var words []*syntax.Word
+	for word := p.next(stmt, end); word != nil; word = p.next(stmt, end) {
+		words = append(words, word)
+	}
+	return words
+}
+
+// redirect reads a redirection, such as >file, 2>&1 or <input, into stmt,
+// reporting whether there was one.
+func (p *winParser) redirect(stmt *syntax.Stmt) bool {
+	j := p.i
+	var n *syntax.Lit
+	if c := p.src[j]; (c >= '0' && c <= '9' || p.ps && c == '*') && j+1 < len(p.src) && (p.src[j+1] == '>' || p.src[j+1] == '<') {
+		n = &syntax.Lit{Value: p.src[j : j+1]}
+		j++
+	}
+	if p.src[j] != '>' && p.src[j] != '<' {
+		return false
+	}
+	op := syntax.RdrOut
+	if p.src[j] == '<' {
+		op = syntax.RdrIn
+	} else if j+1 < len(p.src) && p.src[j+1] == '>' {
+		op = syntax.AppOut
+		j++
+	}
+	j++
+	if j < len(p.src) && p.src[j] == '&' {
+		op = syntax.DplOut
+		if p.src[j-1] == '<' {
+			op = syntax.DplIn
+		}
+		j++
+	}
+	p.i = j
+	p.blank()
+	if p.i >= len(p.src) || p.stop(0) || p.src[p.i] == '>' || p.src[p.i] == '<' {
+		p.fail("expected a redirection target")
+		return true
+	}
+	stmt.Redirs = append(stmt.Redirs, &syntax.Redirect{Op: op, N: n, Word: p.word(0)})
+	return true
+}
+
+// word reads a word up to a blank or what ends a command.
+func (p *winParser) word(end byte) *syntax.Word {
+	word := &syntax.Word{}
+	var lit strings.Builder
+	add := func(part syntax.WordPart) {
+		if lit.Len() > 0 {
+			word.Parts = append(word.Parts, &syntax.Lit{Value: lit.String()})
+			lit.Reset()
+		}
+		if part != nil {
+			word.Parts = append(word.Parts, part)
+		}
+	}
+	start := p.i
+	for p.i < len(p.src) && p.err == nil {
+		c := p.src[p.i]
+		if c == ' ' || c == '\t' || c == '\r' || c == '<' || c == '>' || p.stop(end) {
+			break
+		}
+		if !p.ps {
+			switch c {
+			case '"':
+				add(p.cmdQuoted())
+			case '^':
+				p.i++
+				if p.peek("\r\n") {
+					p.i++
+				}
+				if p.i < len(p.src) {
+					_, size := utf8.DecodeRuneInString(p.src[p.i:])
+					lit.WriteString(p.src[p.i : p.i+size])
+					p.i += size
+				}
+			case '%':
+				if part := p.percent(); part != nil {
+					add(part)
+				} else {
+					lit.WriteByte('%')
+					p.i++
+				}
+			default:
+				lit.WriteByte(c)
+				p.i++
+			}
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(p.src[p.i:])
+		switch {
+		case isSingleQuote(r) || p.peek("@'\n") || p.peek("@'\r\n"):
+			add(p.psSingle())
+		case isDoubleQuote(r) || p.peek("@\"\n") || p.peek("@\"\r\n"):
+			add(p.psDouble())
+		case c == '`':
+			if p.peek("`\n") || p.peek("`\r\n") {
+				return p.wordEnd(word, add) // Continues the line, not the word
+			}
+			p.i++
+			if p.i < len(p.src) {
+				r, size = utf8.DecodeRuneInString(p.src[p.i:])
+				lit.WriteString(psEscape(r))
+				p.i += size
+			}
+		case c == '$':
+			if part := p.variable(); part != nil {
+				add(part)
+			} else {
+				lit.WriteByte('$')
+			}
+		case c == '(' || c == '{' || (c == '@' && (p.peek("@(") || p.peek("@{"))):
+			if c == '@' {
+				p.i++
+				c = p.src[p.i]
+			}
+			p.i++
+			closing := byte(')')
+			if c == '{' {
+				closing = '}'
+			}
+			add(&syntax.CmdSubst{StmtList: syntax.StmtList{Stmts: p.list(closing)}})
+		case c == '[' && p.i == start:
+			// A type, such as [IO.File], is a part of its own
+			j, depth := p.i, 0
+			for ; j < len(p.src); j++ {
+				if p.src[j] == '[' {
+					depth++
+				} else if p.src[j] == ']' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			if j == len(p.src) {
+				p.fail("reached the end without closing '['")
+				break
+			}
+			lit.WriteString(p.src[p.i : j+1])
+			p.i = j + 1
+			add(nil)
+		default:
+			lit.WriteString(p.src[p.i : p.i+size])
+			p.i += size
+		}
+	}
+	return p.wordEnd(word, add)
+}
+
+// wordEnd adds what is left of a word's literal text to it.
+func (p *winParser) wordEnd(word *syntax.Word, add func(syntax.WordPart)) *syntax.Word {
+	add(nil)
+	if len(word.Parts) == 0 {
+		word.Parts = []syntax.WordPart{&syntax.Lit{}}
+	}
+	return word
+}
+
+// cmdQuoted reads a cmd string in double quotes, which runs to the end of the
+// line when it is not closed, with the variables in it.
+func (p *winParser) cmdQuoted() syntax.WordPart {
+	p.i++
+	quoted := &syntax.DblQuoted{}
+	var lit strings.Builder
+	for p.i < len(p.src) && p.src[p.i] != '"' && p.src[p.i] != '\n' {
+		if p.src[p.i] == '%' {
+			if part := p.percent(); part != nil {
+				if lit.Len() > 0 {
+					quoted.Parts = append(quoted.Parts, &syntax.Lit{Value: lit.String()})
+					lit.Reset()
+				}
+				quoted.Parts = append(quoted.Parts, part)
+				continue
+			}
+		}
+		lit.WriteByte(p.src[p.i])
+		p.i++
+	}
+	if p.peek(`"`) {
+		p.i++
+	}
+	if lit.Len() > 0 {
+		quoted.Parts = append(quoted.Parts, &syntax.Lit{Value: lit.String()})
+	}
+	return quoted
+}
+
+// percent reads a cmd variable at %: %NAME%, or the %i of a for loop, or
+// returns nil for a % that is only a character.
+func (p *winParser) percent() syntax.WordPart {
+	rest := p.src[p.i+1:]
+	if j := strings.IndexAny(rest, "%\n"); j > 0 && rest[j] == '%' && !strings.ContainsAny(rest[:j], " \t\"") {
+		p.i += j + 2
+		return &syntax.ParamExp{Short: true, Param: &syntax.Lit{Value: rest[:j]}}
+	}
+	j := 0
+	if strings.HasPrefix(rest, "%") {
+		j++ // %%i, as in batch files
+	}
+	if j < len(rest) && isWordByte(rest[j]) {
+		p.i += j + 2
+		return &syntax.ParamExp{Short: true, Param: &syntax.Lit{Value: rest[j : j+1]}}
+	}
+	return nil
+}
+
+// variable reads what follows $ in PowerShell: a subexpression, $(...), a
+// variable, such as $name, $env:PATH or ${any name}, or nil for a $ that is
+// only a character.
+func (p *winParser) variable() syntax.WordPart {
+	p.i++
+	switch {
+	case p.peek("("):
+		p.i++
+		return &syntax.CmdSubst{StmtList: syntax.StmtList{Stmts: p.list(')')}}
+	case p.peek("{"):
+		j := strings.IndexByte(p.src[p.i:], '}')
+		if j < 0 {
+			p.fail("reached the end without closing ${")
+			return nil
+		}
+		name := p.src[p.i+1 : p.i+j]
+		p.i += j + 1
+		return &syntax.ParamExp{Param: &syntax.Lit{Value: name}}
+	}
+	j := p.i
+	for j < len(p.src) && (isWordByte(p.src[j]) || p.src[j] == ':' && j > p.i) {
+		j++
+	}
+	if j == p.i && j < len(p.src) && strings.IndexByte("?$^", p.src[j]) >= 0 {
+		j++
+	}
+	if j == p.i {
+		return nil
+	}
+	name := p.src[p.i:j]
+	p.i = j
+	return &syntax.ParamExp{Short: true, Param: &syntax.Lit{Value: name}}
+}
+
+// psSingle reads a PowerShell string in single quotes, in which a doubled
+// quote is one and nothing else is special, or a single-quoted here-string.
+func (p *winParser) psSingle() syntax.WordPart {
+	if p.peek("@") {
+		p.i += strings.IndexByte(p.src[p.i:], '\n') + 1
+		j := strings.Index(p.src[p.i:], "\n'@")
+		if j < 0 {
+			p.fail("reached the end without closing @'")
+			return nil
+		}
+		value := strings.TrimSuffix(p.src[p.i:p.i+j], "\r")
+		p.i += j + 3
+		return &syntax.SglQuoted{Value: value}
+	}
+	_, size := utf8.DecodeRuneInString(p.src[p.i:])
+	p.i += size
+	var value strings.Builder
+	for p.i < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.i:])
+		p.i += size
+		if !isSingleQuote(r) {
+			value.WriteString(p.src[p.i-size : p.i])
+			continue
+		}
+		if r2, size2 := utf8.DecodeRuneInString(p.src[p.i:]); isSingleQuote(r2) {
+			value.WriteRune(r2)
+			p.i += size2
+			continue
+		}
+		return &syntax.SglQuoted{Value: value.String()}
+	}
+	p.fail("reached the end without closing quote")
+	return nil
+}
+
+// psDouble reads a PowerShell string in double quotes, with the variables
+// and subexpressions in it, or a double-quoted here-string.
+func (p *winParser) psDouble() syntax.WordPart {
+	here := p.peek("@")
+	if here {
+		p.i += strings.IndexByte(p.src[p.i:], '\n') + 1
+	} else {
+		_, size := utf8.DecodeRuneInString(p.src[p.i:])
+		p.i += size
+	}
+	quoted := &syntax.DblQuoted{}
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			quoted.Parts = append(quoted.Parts, &syntax.Lit{Value: lit.String()})
+			lit.Reset()
+		}
+	}
+	for p.err == nil {
+		if p.i >= len(p.src) {
+			p.fail("reached the end without closing quote")
+			return nil
+		}
+		r, size := utf8.DecodeRuneInString(p.src[p.i:])
+		switch {
+		case here && (p.peek("\n\"@") || p.peek("\r\n\"@")):
+			p.i += strings.IndexByte(p.src[p.i:], '@') + 1
+			flush()
+			return quoted
+		case !here && isDoubleQuote(r):
+			p.i += size
+			if r2, size2 := utf8.DecodeRuneInString(p.src[p.i:]); isDoubleQuote(r2) {
+				lit.WriteRune(r2)
+				p.i += size2
+				continue
+			}
+			flush()
+			return quoted
+		case r == '`' && p.i+1 < len(p.src):
+			r, size = utf8.DecodeRuneInString(p.src[p.i+1:])
+			lit.WriteString(psEscape(r))
+			p.i += 1 + size
+		case r == '$':
+			if part := p.variable(); part != nil {
+				flush()
+				quoted.Parts = append(quoted.Parts, part)
+			} else {
+				lit.WriteByte('$')
+			}
+		default:
+			lit.WriteString(p.src[p.i : p.i+size])
+			p.i += size
+		}
+	}
+	return nil
+}
+
+// psMethod matches the text before the parentheses of a method call, as in
+// $file.Delete() or [IO.File]::Delete("x").
+var psMethod = regexp.MustCompile(`(\.|::)[A-Za-z_][A-Za-z0-9_]*$`)
+
+// psExpression reports whether words are a PowerShell expression rather than
+// a command: they start with a variable, a string or a number, as in
+// $_.Length -gt 0, and call no method, which could run anything.
+func psExpression(words []*syntax.Word) bool {
+	switch part := words[0].Parts[0].(type) {
+	case *syntax.ParamExp, *syntax.SglQuoted, *syntax.DblQuoted:
+	case *syntax.Lit:
+		if part.Value == "" || part.Value[0] < '0' || part.Value[0] > '9' {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, word := range words {
+		for i, part := range word.Parts[1:] {
+			lit, ok := word.Parts[i].(*syntax.Lit)
+			if _, group := part.(*syntax.CmdSubst); group && ok && psMethod.MatchString(lit.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// substitutions returns the statements of the command substitutions within
+// words, such as a keyword's conditions and blocks.
+func substitutions(words []*syntax.Word) []*syntax.Stmt {
+	var stmts []*syntax.Stmt
+	for _, word := range words {
+		syntax.Walk(word, func(node syntax.Node) bool {
+			if subst, ok := node.(*syntax.CmdSubst); ok {
+				stmts = append(stmts, subst.Stmts...)
+				return false
+			}
+			return true
+		})
+	}
+	return stmts
+}
+
+// psEscape returns what a backtick followed by r stands for in PowerShell.
+func psEscape(r rune) string {
+	switch r {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '0':
+		return "\x00"
+	}
+	return string(r)
+}
+
+// isSingleQuote reports whether r quotes as ' does in PowerShell, which takes
+// typographic quotes for plain ones.
+func isSingleQuote(r rune) bool {
+	return r == '\'' || r == '‘' || r == '’' || r == '‚' || r == '‛'
+}
+
+// isDoubleQuote reports whether r quotes as " does in PowerShell.
+func isDoubleQuote(r rune) bool {
+	return r == '"' || r == '“' || r == '”' || r == '„'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,209 @@
+// Package usage counts the tool calls of a server session and the bytes they
+// move, and refuses calls once a configured ceiling is reached.
+package usage
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"gocreate/tools/config"
+	"gocreate/tools/i18n"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// ReportTool is the name usage_report is registered under. It is counted
+// like any tool but never refused, so a capped session can still be inspected.
+const ReportTool = "usage_report"
+
+// ToolUsage is what one tool consumed in the session.
+type ToolUsage struct {
+	Tool       string `json:"tool"`
+	Calls      int    `json:"calls"`
+	Refused    int    `json:"refused,omitempty"`
+	Errors     int    `json:"errors,omitempty"`
+	BytesIn    int64  `json:"bytes_in"`
+	BytesOut   int64  `json:"bytes_out"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// session holds the usage of every tool since the server started.
+type session struct {
+	mu      sync.Mutex
+	started time.Time
+	tools   map[string]*ToolUsage
+	calls   int
+	bytes   int64
+}
+
+var current = newSession()
+
+func newSession() *session {
+	return &session{started: time.Now(), tools: make(map[string]*ToolUsage)}
+}
+
+func (s *session) tool(name string) *ToolUsage {
+	u := s.tools[name]
+	if u == nil {
+		u = &ToolUsage{Tool: name}
+		s.tools[name] = u
+	}
+	return u
+}
+
+// admit counts a call of name with argsBytes of arguments, or returns the
+// message refusing it when limits is exceeded.
+func (s *session) admit(ctx *server.Context, name string, argsBytes int64, limits *config.UsageLimits) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.tool(name)
+	if limits != nil && name != ReportTool {
+		msg := ""
+		switch {
+		case limits.MaxToolCalls > 0 && s.calls >= limits.MaxToolCalls:
+			msg = i18n.T(ctx, i18n.UsageCallLimit, s.calls, name)
+		case limits.Tools[name] > 0 && u.Calls >= limits.Tools[name]:
+			msg = i18n.T(ctx, i18n.UsageToolLimit, name, u.Calls)
+		case limits.MaxBytes > 0 && s.bytes >= limits.MaxBytes:
+			msg = i18n.T(ctx, i18n.UsageBytesLimit, s.bytes, name)
+		}
+		if msg != "" {
+			u.Refused++
+			return msg
+		}
+	}
+	u.Calls++
+	u.BytesIn += argsBytes
+	s.calls++
+	s.bytes += argsBytes
+	return ""
+}
+
+// finish records the outcome of an admitted call.
+func (s *session) finish(name string, resultBytes int64, elapsed time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.tool(name)
+	u.BytesOut += resultBytes
+	u.DurationMs += elapsed.Milliseconds()
+	if failed {
+		u.Errors++
+	}
+	s.bytes += resultBytes
+}
+
+// toolRegistry is implemented by the gomcp server; its handlers all take the
+// raw arguments once registered.
+type toolRegistry interface {
+	GetTools() map[string]*server.Tool
+}
+
+// Track wraps the handler of every tool registered on s so its calls are
+// counted and checked against the usageLimits config value. It is called once
+// all tools, proxied ones included, are registered.
+func Track(s server.Server, logger *slog.Logger) {
+	registry, ok := s.(toolRegistry)
+	if !ok {
+		logger.Error("Server does not list its tools; usage is not tracked")
+		return
+	}
+	for name, tool := range registry.GetTools() {
+		handler, ok := tool.Handler.(func(*server.Context, interface{}) (interface{}, error))
+		if !ok {
+			logger.Error("Tool handler has an unexpected type; its usage is not tracked", "tool", name)
+			continue
+		}
+		tool.Handler = track(name, handler)
+	}
+}
+
+// track returns handler with its calls counted in the current session.
+func track(name string, handler func(*server.Context, interface{}) (interface{}, error)) func(*server.Context, interface{}) (interface{}, error) {
+	return func(ctx *server.Context, args interface{}) (interface{}, error) {
+		var limits *config.UsageLimits
+		if cfg, err := config.GetCurrentConfig(ctx); err == nil {
+			limits = cfg.UsageLimits
+		}
+		if msg := current.admit(ctx, name, size(args), limits); msg != "" {
+			ctx.Logger.Info("Tool call refused by usage limits", "tool", name)
+			return msg, nil
+		}
+		start := time.Now()
+		result, err := handler(ctx, args)
+		current.finish(name, size(result), time.Since(start), err != nil)
+		return result, err
+	}
+}
+
+// size returns the number of bytes v takes on the wire, counting a string
+// result as its text.
+func size(v interface{}) int64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v))
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return int64(len(b))
+}
+
+// Report is the result returned by usage_report.
+type Report struct {
+	Started    string              `json:"started"`
+	Calls      int                 `json:"calls"`
+	Bytes      int64               `json:"bytes"`
+	Tools      []ToolUsage         `json:"tools"`
+	Limits     *config.UsageLimits `json:"limits,omitempty"`
+	DurationMs int64               `json:"duration_ms"`
+}
+
+// report returns the session's usage, the tools moving the most bytes first.
+func (s *session) report(limits *config.UsageLimits) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := Report{
+		Started:    s.started.Format(time.RFC3339),
+		Calls:      s.calls,
+		Bytes:      s.bytes,
+		Tools:      make([]ToolUsage, 0, len(s.tools)),
+		Limits:     limits,
+		DurationMs: time.Since(s.started).Milliseconds(),
+	}
+	for _, u := range s.tools {
+		r.Tools = append(r.Tools, *u)
+	}
+	sort.Slice(r.Tools, func(i, j int) bool {
+		a, b := r.Tools[i], r.Tools[j]
+		if a.BytesIn+a.BytesOut != b.BytesIn+b.BytesOut {
+			return a.BytesIn+a.BytesOut > b.BytesIn+b.BytesOut
+		}
+		return a.Tool < b.Tool
+	})
+	return r
+}
+
+// UsageReportArgs defines the arguments for the usage_report tool.
+type UsageReportArgs struct{}
+
+// HandleUsageReport implements the usage_report tool.
+func HandleUsageReport(ctx *server.Context, args UsageReportArgs) (string, error) {
+	ctx.Logger.Info("Handling usage_report tool call")
+
+	var limits *config.UsageLimits
+	if cfg, err := config.GetCurrentConfig(ctx); err == nil {
+		limits = cfg.UsageLimits
+	}
+	reportJson, err := json.MarshalIndent(current.report(limits), "", "  ")
+	if err != nil {
+		ctx.Logger.Info("Error marshalling usage report", "error", err)
+		return "Error generating usage report", err
+	}
+	return string(reportJson), nil
+}
@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gocreate/tools/config"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func testContext() *server.Context {
+	return &server.Context{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+func TestSessionLimits(t *testing.T) {
+	ctx := testContext()
+	tests := []struct {
+		name    string
+		limits  config.UsageLimits
+		calls   []string
+		wantMsg string // refusal of the last call; "" when it is admitted
+	}{
+		{"no limits", config.UsageLimits{}, []string{"read_file", "read_file", "read_file"}, ""},
+		{"call limit", config.UsageLimits{MaxToolCalls: 2}, []string{"read_file", "write_file", "search_code"}, "made 2 tool calls"},
+		{"under the call limit", config.UsageLimits{MaxToolCalls: 3}, []string{"read_file", "write_file", "search_code"}, ""},
+		{"tool limit", config.UsageLimits{Tools: map[string]int{"execute_command": 1}}, []string{"execute_command", "read_file", "execute_command"}, "execute_command has been called 1 times"},
+		{"other tools are not capped", config.UsageLimits{Tools: map[string]int{"execute_command": 1}}, []string{"execute_command", "read_file", "read_file"}, ""},
+		{"bytes limit", config.UsageLimits{MaxBytes: 20}, []string{"read_file", "read_file", "read_file"}, "moved 20 bytes"},
+		{"report is never refused", config.UsageLimits{MaxToolCalls: 1}, []string{"read_file", ReportTool}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSession()
+			var msg string
+			for _, name := range tt.calls {
+				if msg = s.admit(ctx, name, 10, &tt.limits); msg == "" {
+					s.finish(name, 0, 0, false)
+				}
+			}
+			if tt.wantMsg == "" && msg != "" {
+				t.Errorf("Last call refused: %q", msg)
+			}
+			if tt.wantMsg != "" && !strings.Contains(msg, tt.wantMsg) {
+				t.Errorf("Last call returned %q, want a refusal mentioning %q", msg, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestTrack(t *testing.T) {
+	current = newSession()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := server.NewServer("test", server.WithLogger(logger))
+	type echoArgs struct {
+		Text string `json:"text"`
+	}
+	s.Tool("echo", "Echo text", func(ctx *server.Context, args echoArgs) (interface{}, error) {
+		if args.Text == "" {
+			return nil, errors.New("empty")
+		}
+		return args.Text + args.Text, nil
+	})
+	Track(s, logger)
+
+	handler := s.(toolRegistry).GetTools()["echo"].Handler.(func(*server.Context, interface{}) (interface{}, error))
+	for _, text := range []string{"abc", "hello", ""} {
+		handler(testContext(), map[string]interface{}{"text": text})
+	}
+
+	out, err := HandleUsageReport(testContext(), UsageReportArgs{})
+	if err != nil {
+		t.Fatalf("HandleUsageReport failed: %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("Report is not JSON: %v\n%s", err, out)
+	}
+	if report.Calls != 3 || len(report.Tools) != 1 {
+		t.Fatalf("Report = %+v, want 3 calls of one tool", report)
+	}
+	echo := report.Tools[0]
+	// {"text":"abc"} is 14 bytes, {"text":"hello"} 16 and {"text":""} 11
+	if echo.Tool != "echo" || echo.Calls != 3 || echo.Errors != 1 || echo.BytesIn != 41 || echo.BytesOut != 16 {
+		t.Errorf("echo usage = %+v", echo)
+	}
+}
@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"gocreate/tools/config"
+	"gocreate/tools/journal"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// Events sent to webhooks. config.WebhookEvents lists the same names.
+const (
+	CommandFinished = "command_finished" // A command started by execute_command exited
+	CommandBlocked  = "command_blocked"  // execute_command refused a blocked command
+	EditApplied     = "edit_applied"     // A tool wrote a file and recorded it in the edit journal
+)
+
+// defaultTimeout bounds a delivery when the webhook sets no timeoutMs.
+const defaultTimeout = 10 * time.Second
+
+// Header names set on every delivery.
+const (
+	EventHeader     = "X-Gocreate-Event"
+	SignatureHeader = "X-Gocreate-Signature"
+)
+
+// Payload is the JSON body POSTed to a webhook.
+type Payload struct {
+	Event string                 `json:"event"`
+	Time  time.Time              `json:"time"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+// Notify sends event with data to every configured webhook subscribed to it.
+// Deliveries run in the background and failures are only logged, so a slow or
+// unreachable endpoint never holds up a tool call.
+func Notify(ctx *server.Context, event string, data map[string]interface{}) {
+	cfg, err := config.GetCurrentConfig(ctx)
+	if err != nil || len(cfg.Webhooks) == 0 {
+		return
+	}
+	dispatch(ctx.Logger, cfg.Webhooks, Payload{Event: event, Time: time.Now().UTC(), Data: data})
+}
+
+// WatchEdits sends EditApplied for every write recorded in the edit journal.
+func WatchEdits(logger *slog.Logger) {
+	journal.OnRecord = func(e *journal.Entry) {
+		Notify(&server.Context{Logger: logger}, EditApplied, map[string]interface{}{
+			"path":    e.Path,
+			"tool":    e.Tool,
+			"created": !e.Existed,
+		})
+	}
+}
+
+// dispatch starts a delivery of payload to each hook subscribed to its event.
+// The returned WaitGroup is done once every delivery has finished.
+func dispatch(logger *slog.Logger, hooks []config.Webhook, payload Payload) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error("Could not encode webhook payload", "event", payload.Event, "error", err)
+		return &wg
+	}
+	for _, hook := range hooks {
+		if !subscribed(hook, payload.Event) {
+			continue
+		}
+		wg.Add(1)
+		go func(hook config.Webhook) {
+			defer wg.Done()
+			if err := deliver(hook, payload.Event, body); err != nil {
+				logger.Error("Webhook delivery failed", "url", hook.URL, "event", payload.Event, "error", err)
+			}
+		}(hook)
+	}
+	return &wg
+}
+
+// subscribed reports whether hook wants event. A hook without events wants all.
+func subscribed(hook config.Webhook, event string) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature of body sent in SignatureHeader: "sha256=" and the
+// hex HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver POSTs body to hook. Any status outside 2xx is an error.
+func deliver(hook config.Webhook, event string, body []byte) error {
+	timeout := defaultTimeout
+	if hook.TimeoutMs != nil && *hook.TimeoutMs > 0 {
+		timeout = time.Duration(*hook.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return nil
+}
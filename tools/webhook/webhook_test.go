@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gocreate/tools/config"
+)
+
+func TestDispatch(t *testing.T) {
+	type delivery struct {
+		path, event, signature, auth string
+		body                         []byte
+	}
+	var mu sync.Mutex
+	var got []delivery
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, delivery{r.URL.Path, r.Header.Get(EventHeader), r.Header.Get(SignatureHeader), r.Header.Get("Authorization"), body})
+		mu.Unlock()
+		if r.URL.Path == "/broken" {
+			http.Error(w, "nope", http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	hooks := []config.Webhook{
+		{URL: ts.URL + "/signed", Secret: "s3cret", Headers: map[string]string{"Authorization": "Bearer token"}},
+		{URL: ts.URL + "/commands", Events: []string{CommandFinished}},
+		{URL: ts.URL + "/broken", Events: []string{EditApplied}},
+	}
+	payload := Payload{Event: EditApplied, Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Data: map[string]interface{}{"path": "/tmp/a.go"}}
+	dispatch(logger, hooks, payload).Wait()
+
+	if len(got) != 2 {
+		t.Fatalf("Expected deliveries to /signed and /broken only, got %d", len(got))
+	}
+	for _, d := range got {
+		if d.path == "/commands" {
+			t.Errorf("Delivered %s to a hook that only wants %s", d.event, CommandFinished)
+		}
+		if d.event != EditApplied {
+			t.Errorf("%s header = %q, want %q", EventHeader, d.event, EditApplied)
+		}
+		var p Payload
+		if err := json.Unmarshal(d.body, &p); err != nil || p.Event != EditApplied || p.Data["path"] != "/tmp/a.go" {
+			t.Errorf("Body = %s, want the encoded payload", d.body)
+		}
+		switch d.path {
+		case "/signed":
+			if d.signature != Sign("s3cret", d.body) || !strings.HasPrefix(d.signature, "sha256=") {
+				t.Errorf("Signature = %q, want %q", d.signature, Sign("s3cret", d.body))
+			}
+			if d.auth != "Bearer token" {
+				t.Errorf("Authorization = %q, want the configured header", d.auth)
+			}
+		case "/broken":
+			if d.signature != "" {
+				t.Errorf("Unsigned hook got signature %q", d.signature)
+			}
+		}
+	}
+	if !strings.Contains(logs.String(), "500 Internal Server Error") {
+		t.Errorf("Expected the failed delivery to be logged, got %q", logs.String())
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Known HMAC-SHA256 test vector (RFC 4231 test case 2)
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got := Sign("Jefe", []byte("what do ya want for nothing?")); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+}